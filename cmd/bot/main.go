@@ -7,20 +7,24 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 
 	"ai-chatter/internal/auth"
 	"ai-chatter/internal/config"
+	"ai-chatter/internal/docs"
 	"ai-chatter/internal/github"
 	"ai-chatter/internal/gmail"
 	"ai-chatter/internal/llm"
+	"ai-chatter/internal/messages"
 	"ai-chatter/internal/notion"
 	"ai-chatter/internal/pending"
 	"ai-chatter/internal/rustore"
 	"ai-chatter/internal/scheduler"
 	"ai-chatter/internal/storage"
 	"ai-chatter/internal/telegram"
+	"ai-chatter/internal/vibecoding"
 )
 
 func main() {
@@ -30,6 +34,13 @@ func main() {
 	}
 
 	cfg := config.New()
+	warnings, err := cfg.Validate()
+	for _, w := range warnings {
+		log.Printf("⚠️ Config: %s", w)
+	}
+	if err != nil {
+		log.Fatalf("❌ Invalid configuration, fix the environment and restart:\n%v", err)
+	}
 
 	var allowRepo auth.Repository
 	if cfg.AllowlistFilePath != "" {
@@ -45,34 +56,75 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to init auth: %v", err)
 	}
+	if limiter := auth.NewRateLimiter(cfg.AuthRatePerMinute, cfg.AuthRateBurst); limiter != nil {
+		authSvc.SetRateLimiter(limiter)
+		log.Printf("⏳ User rate limit: %d messages/min, burst %d", cfg.AuthRatePerMinute, cfg.AuthRateBurst)
+	}
 
 	// Resolve provider/model with overrides
 	prov := string(cfg.LLMProvider)
 	if s := readTrim(cfg.ProviderFilePath); s != "" {
+		if s != prov {
+			log.Printf("⚠️ Provider %s from %s overrides %s of profile %s", s, cfg.ProviderFilePath, prov, cfg.ProfileName())
+		}
 		prov = s
 	}
 	model := cfg.OpenAIModel
 	if s := readTrim(cfg.ModelFilePath); s != "" {
+		if s != model {
+			log.Printf("⚠️ Model %s from %s overrides %s of profile %s", s, cfg.ModelFilePath, model, cfg.ProfileName())
+		}
 		model = s
 	}
 
-	llmFactory := llm.NewFactory(cfg)
-	llmClient, err := llmFactory.CreateClient(prov, model)
-	if err != nil {
-		log.Fatalf("failed to create llm client: %v", err)
-	}
-
 	systemPrompt := readSystemPrompt(cfg.SystemPromptPath)
 
 	var rec storage.Recorder
-	if cfg.LogFilePath != "" {
-		fr, err := storage.NewFileRecorder(cfg.LogFilePath)
+	switch cfg.StorageBackend {
+	case "sqlite":
+		sr, err := storage.NewSQLiteRecorder(cfg.SQLitePath)
 		if err != nil {
-			log.Printf("failed to init file recorder: %v", err)
+			log.Printf("failed to init sqlite recorder: %v", err)
+		} else {
+			defer sr.Close()
+			rec = sr
+			// Однократный перенос истории из LOG_FILE_PATH при переходе с file на sqlite
+			if cfg.SQLiteImportLog && cfg.LogFilePath != "" {
+				if n, err := sr.ImportFileLog(cfg.LogFilePath); err != nil {
+					log.Printf("⚠️ Failed to import %s into sqlite: %v", cfg.LogFilePath, err)
+				} else if n > 0 {
+					log.Printf("📦 Imported %d events from %s into sqlite", n, cfg.LogFilePath)
+				}
+			}
+		}
+	case "file", "":
+		if cfg.LogFilePath != "" {
+			fr, err := storage.NewFileRecorder(cfg.LogFilePath)
+			if err != nil {
+				log.Printf("failed to init file recorder: %v", err)
+			} else {
+				rec = fr
+			}
+		}
+	default:
+		log.Printf("⚠️ Unknown STORAGE_BACKEND %q, history is not recorded", cfg.StorageBackend)
+	}
+
+	llmFactory := llm.NewFactory(cfg)
+	var llmRequestLog storage.LLMRequestRecorder
+	if cfg.LLMLogRequests {
+		if lr, ok := rec.(storage.LLMRequestRecorder); ok {
+			llmRequestLog = lr
+			llmFactory.RequestLog = lr
+			log.Printf("📝 LLM requests are logged (STORAGE_BACKEND=%s)", cfg.StorageBackend)
 		} else {
-			rec = fr
+			log.Printf("⚠️ LLM_LOG_REQUESTS is on but history storage is not available, requests are not logged")
 		}
 	}
+	llmClient, err := llmFactory.CreateClient(prov, model)
+	if err != nil {
+		log.Fatalf("failed to create llm client: %v", err)
+	}
 
 	var pRepo pending.Repository
 	if cfg.PendingFilePath != "" {
@@ -197,12 +249,154 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to create bot: %v", err)
 	}
+	bot.SetProfile(cfg.Profile)
+	bot.SetSecondaryModel(cfg.SecondaryModel)
+	bot.SetFallbackFooter(cfg.OpenRouterFallbackFooter)
+	bot.SetLLMRequestLog(llmRequestLog)
+	bot.SetHistoryLimits(cfg.HistoryMaxMessages, cfg.HistoryMaxTokens)
+	bot.SetStreaming(cfg.StreamingEnabled, cfg.StreamingEditInterval)
+	bot.SetUserModelOverrides(cfg.UserModelsFilePath, cfg.AllowUserModelOverride)
+	bot.SetUserSettings(cfg.UserSettingsFilePath)
+	if cfg.ModelCatalog != "" {
+		if catalog, err := llm.ParseModelCatalog(cfg.ModelCatalog); err != nil {
+			log.Printf("⚠️ Invalid MODEL_CATALOG, using default model list: %v", err)
+		} else {
+			bot.SetModelCatalog(catalog.Restrict(llmFactory.ConfiguredProviders()))
+		}
+	}
+	vibeCodingConfig := vibecoding.NewVibeCodingConfig(cfg)
+	vibeCodingConfig.GitHubToken = githubToken
+	bot.SetVibeCodingConfig(vibeCodingConfig)
+	if err := bot.StartVibeCodingWebServer(cfg.VibeCodingWebPort); err != nil {
+		log.Fatalf("failed to start VibeCoding web server: %v", err)
+	}
+	if err := bot.StartVibeCodingMetricsServer(cfg.VibeCodingMetricsPort); err != nil {
+		log.Fatalf("failed to start VibeCoding metrics server: %v", err)
+	}
+	if cfg.VibeCodingSessionsPath != "" {
+		if store, err := vibecoding.NewFileSessionStore(cfg.VibeCodingSessionsPath); err != nil {
+			log.Printf("⚠️ VibeCoding sessions will not survive restart: %v", err)
+		} else if err := bot.RestoreVibeCodingSessions(context.Background(), store); err != nil {
+			log.Printf("⚠️ Failed to restore VibeCoding sessions: %v", err)
+		}
+	}
+	bot.StartVibeCodingContainerGC()
+	var callbackStore storage.CallbackActionStore
+	if cfg.CallbackActionsFilePath != "" {
+		if store, err := storage.NewFileCallbackActionStore(cfg.CallbackActionsFilePath); err != nil {
+			log.Printf("⚠️ Callback actions will not survive restart: %v", err)
+		} else {
+			callbackStore = store
+		}
+	}
+	bot.SetCallbackActions(callbackStore, cfg.CallbackActionTTL)
+	bot.SetAccessRequestTTL(time.Duration(cfg.PendingRequestTTLDays) * 24 * time.Hour)
+	var userMessages *messages.File
+	if cfg.MessagesFilePath != "" {
+		// Файл уже проверен в Validate; ошибка здесь — файл изменился между проверкой и загрузкой
+		if userMessages, err = messages.Open(cfg.MessagesFilePath); err != nil {
+			log.Fatalf("❌ MESSAGES_FILE_PATH: %v", err)
+		}
+	}
+	bot.SetMessages(userMessages, cfg.AdminContact)
+	bot.SetMaintenanceFile(cfg.MaintenanceFilePath)
+	if cfg.MaintenanceMode {
+		log.Printf("🛠 Maintenance mode: only the admin is served")
+		bot.SetMaintenance(true)
+	}
+	if cfg.DocsLibraryDir != "" {
+		var embedder llm.Embedder
+		if cfg.DocsEmbeddingModel != "" {
+			if e, err := llmFactory.CreateEmbedder(cfg.DocsEmbeddingModel); err != nil {
+				log.Printf("⚠️ Docs embeddings disabled, using keyword search: %v", err)
+			} else {
+				embedder = e
+			}
+		}
+		bot.SetDocsLibrary(docs.NewLibrary(cfg.DocsLibraryDir, embedder, cfg.DocsMaxBytesPerUser, cfg.DocsMaxDocsPerUser), cfg.DocsAutoThreshold)
+		log.Printf("✅ Document library enabled at %s", cfg.DocsLibraryDir)
+	}
+
+	var transcriber llm.Transcriber
+	if cfg.TranscriptionModel != "" {
+		if t, err := llmFactory.CreateTranscriber(cfg.TranscriptionModel, cfg.TranscriptionBaseURL); err != nil {
+			log.Printf("⚠️ Voice messages disabled: %v", err)
+		} else {
+			transcriber = t
+			log.Printf("✅ Voice transcription enabled (%s)", cfg.TranscriptionModel)
+		}
+	}
+	bot.SetVoiceTranscription(transcriber, cfg.VoiceMaxDuration, cfg.TranscriptionTimeout)
+
+	bot.SetReportConfig(telegram.ReportConfig{
+		ChatStats:       cfg.ReportChatStatsEnabled,
+		Gmail:           cfg.ReportGmailEnabled,
+		GmailQuery:      cfg.ReportGmailQuery,
+		RuStore:         cfg.ReportRuStoreEnabled,
+		RuStorePackages: cfg.ReportRuStorePackages,
+		VibeCoding:      cfg.ReportVibeCodingEnabled,
+		SectionTimeout:  cfg.ReportSectionTimeout,
+	})
 
 	// Инициализируем и запускаем планировщик
 	sched := scheduler.New()
 	sched.SetReportFunction(func(ctx context.Context) error {
 		return bot.GenerateDailyReportForAdmin(ctx)
 	})
+	sched.SetReportSchedule(cfg.ReportSchedule)
+
+	loc, err := time.LoadLocation(cfg.RemindersTimezone)
+	if err != nil {
+		log.Printf("⚠️ Unknown REMINDERS_TIMEZONE %q, using UTC: %v", cfg.RemindersTimezone, err)
+		loc = time.UTC
+	}
+	if cfg.RemindersFilePath != "" {
+		store, err := scheduler.NewReminderStore(cfg.RemindersFilePath)
+		if err != nil {
+			log.Printf("⚠️ Reminders disabled: %v", err)
+		} else {
+			bot.SetReminders(store, loc)
+			sched.SetReminders(store, bot.SendReminder)
+		}
+	}
+	if cfg.ScheduledPromptsFilePath != "" {
+		store, err := scheduler.NewPromptStore(cfg.ScheduledPromptsFilePath, cfg.ScheduledPromptsMaxPerUser, loc)
+		if err != nil {
+			log.Printf("⚠️ Scheduled prompts disabled: %v", err)
+		} else {
+			sched.SetScheduledPrompts(store, bot.RunScheduledPrompt)
+			bot.SetPromptScheduler(sched)
+		}
+	}
+
+	sched.AddJob("@every 10m", "callback actions cleanup", bot.PurgeExpiredCallbackActions)
+
+	var usageStore *storage.FileUsageStore
+	if cfg.UsageStatsFilePath != "" {
+		if usageStore, err = storage.NewFileUsageStore(cfg.UsageStatsFilePath); err != nil {
+			log.Printf("⚠️ Usage stats disabled: %v", err)
+			usageStore = nil
+		} else {
+			bot.SetUsageStore(usageStore)
+			sched.AddJob("@every 1m", "usage stats flush", func(ctx context.Context) error {
+				return usageStore.Flush(time.Now())
+			})
+			sched.AddJob(cfg.WeeklyDigestSchedule, "weekly digest", bot.SendWeeklyDigest)
+		}
+	}
+
+	if cfg.PaymentsProviderToken != "" {
+		packages, err := telegram.ParseTopupPackages(cfg.PaymentsPackages)
+		if err != nil {
+			log.Printf("⚠️ Payments disabled: invalid PAYMENTS_PACKAGES: %v", err)
+		} else if paymentStore, err := storage.NewFilePaymentStore(cfg.PaymentsFilePath); err != nil {
+			log.Printf("⚠️ Payments disabled: %v", err)
+		} else {
+			bot.SetPayments(paymentStore, cfg.PaymentsProviderToken, cfg.PaymentsCurrency, packages)
+			sched.AddJob(cfg.PaymentsReportSchedule, "payments report", bot.SendPaymentsReport)
+			log.Printf("💳 Payments enabled: %d packages in %s", len(packages), cfg.PaymentsCurrency)
+		}
+	}
 
 	if err := sched.Start(); err != nil {
 		log.Printf("⚠️ Failed to start scheduler: %v", err)
@@ -220,6 +414,11 @@ func main() {
 		<-sigChan
 		log.Println("🛑 Получен сигнал остановки, завершаем работу...")
 		sched.Stop()
+		if usageStore != nil {
+			if err := usageStore.Flush(time.Now()); err != nil {
+				log.Printf("⚠️ Failed to save usage stats: %v", err)
+			}
+		}
 		cancel()
 	}()
 
@@ -445,7 +445,17 @@ func main() {
 		Description: "Downloads an asset (file) from a GitHub release",
 	}, githubServer.DownloadAsset)
 
-	log.Printf("📋 Registered GitHub MCP tools: get_github_releases, download_github_asset")
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_ref_status",
+		Description: "Aggregates commit statuses and check runs for a branch, tag or SHA: overall verdict (success, failure, pending, no_checks) and per-check name, conclusion, duration and details URL",
+	}, githubServer.GetRefStatus)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "ping",
+		Description: "Checks GITHUB_TOKEN with a lightweight authenticated call (GET /user) and returns OK or the authentication error",
+	}, githubServer.Ping)
+
+	log.Printf("📋 Registered GitHub MCP tools: get_github_releases, download_github_asset, get_ref_status, ping")
 	log.Printf("🔗 Starting GitHub MCP server on stdin/stdout...")
 
 	// Запускаем сервер через stdin/stdout
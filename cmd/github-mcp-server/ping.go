@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// PingParams ping не принимает параметров
+type PingParams struct{}
+
+// Ping проверяет GITHUB_TOKEN запросом GET /user: OK с логином и scopes токена или ошибка авторизации
+func (g *GitHubMCPServer) Ping(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[PingParams]) (*mcp.CallToolResultFor[any], error) {
+	log.Printf("🏓 MCP Server: Checking GitHub credentials")
+
+	if g.token == "" {
+		return pingError("❌ GITHUB_TOKEN is not set: only the public API is available (rate limited)"), nil
+	}
+
+	resp, err := g.makeGitHubRequest(ctx, "https://api.github.com/user")
+	if err != nil {
+		return pingError(fmt.Sprintf("❌ GitHub API request failed: %v", err)), nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return pingError(fmt.Sprintf("❌ GitHub authentication failed (%d): %s", resp.StatusCode, string(body))), nil
+	}
+
+	var user GitHubUser
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return pingError(fmt.Sprintf("❌ Failed to parse GitHub response: %v", err)), nil
+	}
+
+	// X-OAuth-Scopes есть только у классических токенов; у fine-grained права не перечисляются
+	scopes := resp.Header.Get("X-OAuth-Scopes")
+	text := fmt.Sprintf("✅ OK: authenticated as %s", user.Login)
+	if scopes != "" {
+		text += fmt.Sprintf(" (scopes: %s)", scopes)
+	}
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+		Meta: map[string]interface{}{
+			"ok":     true,
+			"login":  user.Login,
+			"scopes": scopes,
+		},
+	}, nil
+}
+
+func pingError(text string) *mcp.CallToolResultFor[any] {
+	return &mcp.CallToolResultFor[any]{
+		IsError: true,
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+		Meta:    map[string]interface{}{"ok": false},
+	}
+}
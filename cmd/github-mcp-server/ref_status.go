@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"ai-chatter/internal/github"
+)
+
+// maxCheckRunPages ограничение страниц check runs (по 100 на странице)
+const maxCheckRunPages = 10
+
+// GitHubRefStatusParams параметры для сводки проверок коммита
+type GitHubRefStatusParams struct {
+	Owner string `json:"owner" mcp:"GitHub repository owner"`
+	Repo  string `json:"repo" mcp:"GitHub repository name"`
+	Ref   string `json:"ref" mcp:"branch, tag or commit SHA (e.g., 'main')"`
+}
+
+// gitHubCombinedStatus ответ /commits/{ref}/status
+type gitHubCombinedStatus struct {
+	State    string `json:"state"`
+	SHA      string `json:"sha"`
+	Statuses []struct {
+		Context   string    `json:"context"`
+		State     string    `json:"state"`
+		TargetURL string    `json:"target_url"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+	} `json:"statuses"`
+}
+
+// gitHubCheckRuns страница ответа /commits/{ref}/check-runs
+type gitHubCheckRuns struct {
+	TotalCount int `json:"total_count"`
+	CheckRuns  []struct {
+		Name        string     `json:"name"`
+		HeadSHA     string     `json:"head_sha"`
+		Status      string     `json:"status"`
+		Conclusion  string     `json:"conclusion"`
+		StartedAt   *time.Time `json:"started_at"`
+		CompletedAt *time.Time `json:"completed_at"`
+		DetailsURL  string     `json:"details_url"`
+		HTMLURL     string     `json:"html_url"`
+	} `json:"check_runs"`
+}
+
+// getJSON выполняет GET к GitHub API и декодирует ответ
+func (g *GitHubMCPServer) getJSON(ctx context.Context, endpoint string, out any) error {
+	resp, err := g.makeGitHubRequest(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, string(body))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// fetchRefStatus собирает commit statuses и все страницы check runs для ref
+func (g *GitHubMCPServer) fetchRefStatus(ctx context.Context, owner, repo, ref string) (github.RefStatus, error) {
+	status := github.RefStatus{Owner: owner, Repo: repo, Ref: ref}
+	base := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits/%s", url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(ref))
+
+	var combined gitHubCombinedStatus
+	if err := g.getJSON(ctx, base+"/status?per_page=100", &combined); err != nil {
+		return status, fmt.Errorf("failed to get combined status: %w", err)
+	}
+	status.SHA = combined.SHA
+	for _, s := range combined.Statuses {
+		check := github.RefCheck{
+			Name:       s.Context,
+			Source:     github.RefCheckSourceStatus,
+			Status:     "completed",
+			Conclusion: s.State,
+			DetailsURL: s.TargetURL,
+		}
+		if s.State == "pending" {
+			check.Status, check.Conclusion = "in_progress", ""
+		} else if !s.CreatedAt.IsZero() && s.UpdatedAt.After(s.CreatedAt) {
+			check.DurationSeconds = s.UpdatedAt.Sub(s.CreatedAt).Seconds()
+		}
+		status.Checks = append(status.Checks, check)
+	}
+
+	seen := 0
+	for page := 1; page <= maxCheckRunPages; page++ {
+		var runs gitHubCheckRuns
+		if err := g.getJSON(ctx, fmt.Sprintf("%s/check-runs?per_page=100&page=%d", base, page), &runs); err != nil {
+			return status, fmt.Errorf("failed to get check runs: %w", err)
+		}
+		for _, r := range runs.CheckRuns {
+			check := github.RefCheck{
+				Name:       r.Name,
+				Source:     github.RefCheckSourceCheckRun,
+				Status:     r.Status,
+				Conclusion: r.Conclusion,
+				DetailsURL: r.DetailsURL,
+			}
+			if check.DetailsURL == "" {
+				check.DetailsURL = r.HTMLURL
+			}
+			if r.StartedAt != nil && r.CompletedAt != nil {
+				check.DurationSeconds = r.CompletedAt.Sub(*r.StartedAt).Seconds()
+			}
+			if status.SHA == "" {
+				status.SHA = r.HeadSHA
+			}
+			status.Checks = append(status.Checks, check)
+		}
+		seen += len(runs.CheckRuns)
+		if len(runs.CheckRuns) == 0 || seen >= runs.TotalCount {
+			break
+		}
+		if page == maxCheckRunPages {
+			log.Printf("⚠️ GitHub API: check runs for %s/%s@%s truncated at %d of %d", owner, repo, ref, seen, runs.TotalCount)
+		}
+	}
+
+	status.Verdict = github.AggregateRefVerdict(status.Checks)
+	return status, nil
+}
+
+// GetRefStatus отвечает на вопрос «зелёный ли ref»: вердикт и разбивка по проверкам
+func (g *GitHubMCPServer) GetRefStatus(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[GitHubRefStatusParams]) (*mcp.CallToolResultFor[any], error) {
+	args := params.Arguments
+	if args.Owner == "" || args.Repo == "" || args.Ref == "" {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{&mcp.TextContent{Text: "❌ owner, repo and ref are required"}},
+		}, nil
+	}
+
+	log.Printf("🚦 MCP Server: Getting ref status for %s/%s@%s", args.Owner, args.Repo, args.Ref)
+
+	status, err := g.fetchRefStatus(ctx, args.Owner, args.Repo, args.Ref)
+	if err != nil {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("❌ %v", err)}},
+		}, nil
+	}
+
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{&mcp.TextContent{Text: github.FormatRefStatus(status)}},
+		Meta: map[string]interface{}{
+			"ref_status": status,
+			"verdict":    status.Verdict,
+			"success":    true,
+		},
+	}, nil
+}
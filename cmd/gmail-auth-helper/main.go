@@ -51,7 +51,7 @@ func main() {
 		ClientID:     credentials.ClientID,
 		ClientSecret: credentials.ClientSecret,
 		RedirectURL:  "urn:ietf:wg:oauth:2.0:oob",
-		Scopes:       []string{gmail.GmailReadonlyScope},
+		Scopes:       []string{gmail.GmailReadonlyScope, gmail.GmailSendScope, gmail.GmailModifyScope},
 		Endpoint:     google.Endpoint,
 	}
 
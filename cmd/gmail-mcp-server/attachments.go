@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/gmail/v1"
+)
+
+// GmailGetAttachmentParams параметры для скачивания вложения письма
+type GmailGetAttachmentParams struct {
+	MessageID    string `json:"message_id" mcp:"Gmail message ID (from search_gmail results)"`
+	AttachmentID string `json:"attachment_id" mcp:"Attachment ID (from list_email_attachments results)"`
+}
+
+// GmailListAttachmentsParams параметры для получения списка вложений письма
+type GmailListAttachmentsParams struct {
+	MessageID string `json:"message_id" mcp:"Gmail message ID (from search_gmail results)"`
+}
+
+// AttachmentInfo метаданные вложения без содержимого
+type AttachmentInfo struct {
+	AttachmentID string `json:"attachment_id"`
+	Filename     string `json:"filename"`
+	MimeType     string `json:"mime_type"`
+	Size         int64  `json:"size"`
+}
+
+// collectAttachments рекурсивно собирает части письма с именем файла и attachment ID
+func collectAttachments(part *gmail.MessagePart) []AttachmentInfo {
+	if part == nil {
+		return nil
+	}
+	var result []AttachmentInfo
+	if part.Filename != "" && part.Body != nil && part.Body.AttachmentId != "" {
+		result = append(result, AttachmentInfo{
+			AttachmentID: part.Body.AttachmentId,
+			Filename:     part.Filename,
+			MimeType:     part.MimeType,
+			Size:         part.Body.Size,
+		})
+	}
+	for _, child := range part.Parts {
+		result = append(result, collectAttachments(child)...)
+	}
+	return result
+}
+
+// ListEmailAttachments возвращает метаданные вложений письма, не скачивая их содержимое
+func (s *GmailMCPServer) ListEmailAttachments(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[GmailListAttachmentsParams]) (*mcp.CallToolResultFor[any], error) {
+	args := params.Arguments
+	log.Printf("📎 MCP Server: Listing attachments of Gmail message %s", args.MessageID)
+
+	if strings.TrimSpace(args.MessageID) == "" {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "❌ message_id parameter is required"},
+			},
+		}, nil
+	}
+
+	msg, err := s.gmailService.Users.Messages.Get("me", args.MessageID).Format("full").Context(ctx).Do()
+	if err != nil {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("❌ Failed to get message %s: %v", args.MessageID, err)},
+			},
+		}, nil
+	}
+
+	attachments := collectAttachments(msg.Payload)
+	var text strings.Builder
+	if len(attachments) == 0 {
+		text.WriteString(fmt.Sprintf("📎 Message %s has no attachments", msg.Id))
+	} else {
+		text.WriteString(fmt.Sprintf("📎 Message %s has %d attachment(s):\n", msg.Id, len(attachments)))
+		for i, a := range attachments {
+			text.WriteString(fmt.Sprintf("%d. %s (%s, %d bytes)\n   attachment_id: %s\n", i+1, a.Filename, a.MimeType, a.Size, a.AttachmentID))
+		}
+	}
+
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text.String()},
+		},
+		Meta: map[string]interface{}{
+			"message_id":  msg.Id,
+			"attachments": attachments,
+			"count":       len(attachments),
+			"success":     true,
+		},
+	}, nil
+}
+
+// GetEmailAttachment скачивает вложение письма и возвращает его содержимое с MIME типом и именем файла
+func (s *GmailMCPServer) GetEmailAttachment(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[GmailGetAttachmentParams]) (*mcp.CallToolResultFor[any], error) {
+	args := params.Arguments
+	log.Printf("📎 MCP Server: Downloading attachment %s of Gmail message %s", args.AttachmentID, args.MessageID)
+
+	if strings.TrimSpace(args.MessageID) == "" || strings.TrimSpace(args.AttachmentID) == "" {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "❌ message_id and attachment_id parameters are required"},
+			},
+		}, nil
+	}
+
+	// Имя файла и MIME тип есть только в структуре письма, Attachments.Get возвращает одни данные
+	info := AttachmentInfo{AttachmentID: args.AttachmentID, MimeType: "application/octet-stream"}
+	if msg, err := s.gmailService.Users.Messages.Get("me", args.MessageID).Format("full").Context(ctx).Do(); err == nil {
+		for _, a := range collectAttachments(msg.Payload) {
+			if a.AttachmentID == args.AttachmentID {
+				info = a
+				break
+			}
+		}
+	} else {
+		log.Printf("⚠️ MCP Server: Failed to get message %s for attachment metadata: %v", args.MessageID, err)
+	}
+
+	body, err := s.gmailService.Users.Messages.Attachments.Get("me", args.MessageID, args.AttachmentID).Context(ctx).Do()
+	if err != nil {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("❌ Failed to get attachment %s of message %s: %v", args.AttachmentID, args.MessageID, err)},
+			},
+		}, nil
+	}
+
+	data, err := decodeBase64URL(body.Data)
+	if err != nil {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("❌ Failed to decode attachment %s: %v", args.AttachmentID, err)},
+			},
+		}, nil
+	}
+
+	filename := info.Filename
+	if filename == "" {
+		filename = "attachment"
+	}
+
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: fmt.Sprintf("✅ Attachment %s (%s, %d bytes) downloaded", filename, info.MimeType, len(data))},
+			&mcp.EmbeddedResource{Resource: &mcp.ResourceContents{
+				URI:      fmt.Sprintf("gmail://messages/%s/attachments/%s", url.PathEscape(args.MessageID), url.PathEscape(filename)),
+				MIMEType: info.MimeType,
+				Blob:     data,
+			}},
+		},
+		Meta: map[string]interface{}{
+			"message_id":    args.MessageID,
+			"attachment_id": args.AttachmentID,
+			"filename":      filename,
+			"mime_type":     info.MimeType,
+			"size":          len(data),
+			"success":       true,
+		},
+	}, nil
+}
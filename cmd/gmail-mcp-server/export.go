@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/gmail/v1"
+)
+
+const (
+	// defaultExportMaxBytes размер экспортируемого документа по умолчанию
+	defaultExportMaxBytes = 200 * 1024
+	// maxExportMaxBytes верхняя граница размера документа
+	maxExportMaxBytes = 1024 * 1024
+)
+
+// GmailExportParams параметры экспорта писем в markdown документ
+type GmailExportParams struct {
+	Query         string   `json:"query,omitempty" mcp:"Gmail search query (ignored when message_ids are given)"`
+	MessageIDs    []string `json:"message_ids,omitempty" mcp:"explicit Gmail message IDs to export"`
+	MaxEmails     int      `json:"max_emails,omitempty" mcp:"maximum number of emails for a query (default: 10, max: 50)"`
+	TimeRange     string   `json:"time_range,omitempty" mcp:"time range filter for a query: 'today', 'week', 'month' (default: no filter)"`
+	Destination   string   `json:"destination,omitempty" mcp:"where the bot puts the document: 'file' (markdown document) or 'notion' (page content); default 'file'"`
+	Title         string   `json:"title,omitempty" mcp:"document title"`
+	MaxTotalBytes int      `json:"max_total_bytes,omitempty" mcp:"maximum size of the exported document in bytes (default: 200 KB, max: 1 MB)"`
+}
+
+// exportedEmail письмо, подготовленное для экспорта
+type exportedEmail struct {
+	ID          string
+	Subject     string
+	From        string
+	To          string
+	Date        time.Time
+	Body        string
+	Attachments []string
+}
+
+// exportReport сведения о том, как письмо попало в документ
+type exportReport struct {
+	ID            string `json:"id"`
+	Subject       string `json:"subject"`
+	BodyBytes     int    `json:"body_bytes"`
+	ExportedBytes int    `json:"exported_bytes"`
+	Truncated     bool   `json:"truncated"`
+	Skipped       bool   `json:"skipped,omitempty"`
+}
+
+// ExportEmails собирает найденные (или явно указанные) письма в один markdown документ:
+// раздел на письмо с заголовками, очищенным текстом и именами вложений
+func (s *GmailMCPServer) ExportEmails(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[GmailExportParams]) (*mcp.CallToolResultFor[any], error) {
+	args := params.Arguments
+
+	destination := strings.ToLower(strings.TrimSpace(args.Destination))
+	if destination == "" {
+		destination = "file"
+	}
+	if destination != "file" && destination != "notion" {
+		return exportError(fmt.Sprintf("❌ Unsupported destination '%s': use 'file' or 'notion'", args.Destination)), nil
+	}
+
+	ids := args.MessageIDs
+	if len(ids) == 0 {
+		if strings.TrimSpace(args.Query) == "" {
+			return exportError("❌ Either query or message_ids is required"), nil
+		}
+		maxResults := int64(args.MaxEmails)
+		if maxResults <= 0 {
+			maxResults = 10
+		}
+		if maxResults > 50 {
+			maxResults = 50
+		}
+		query := args.Query + timeRangeFilter(args.TimeRange)
+		log.Printf("🗂 MCP Server: Exporting Gmail results for query '%s' (max %d)", query, maxResults)
+
+		list, err := s.gmailService.Users.Messages.List("me").Q(query).MaxResults(maxResults).Context(ctx).Do()
+		if err != nil {
+			return exportError(fmt.Sprintf("❌ Gmail search failed: %v", err)), nil
+		}
+		for _, m := range list.Messages {
+			ids = append(ids, m.Id)
+		}
+	} else {
+		log.Printf("🗂 MCP Server: Exporting %d Gmail messages by ID", len(ids))
+	}
+
+	if len(ids) == 0 {
+		return &mcp.CallToolResultFor[any]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("📭 No emails found for query '%s'", args.Query)},
+			},
+			Meta: map[string]interface{}{"total_exported": 0, "destination": destination, "success": true},
+		}, nil
+	}
+
+	var emails []exportedEmail
+	var failed []string
+	for _, id := range ids {
+		msg, err := s.gmailService.Users.Messages.Get("me", id).Context(ctx).Do()
+		if err != nil {
+			log.Printf("⚠️ Failed to get message %s for export: %v", id, err)
+			failed = append(failed, id)
+			continue
+		}
+		emails = append(emails, toExportedEmail(msg))
+	}
+
+	title := strings.TrimSpace(args.Title)
+	if title == "" {
+		title = "Gmail export"
+		if args.Query != "" && len(args.MessageIDs) == 0 {
+			title = fmt.Sprintf("Gmail export: %s", args.Query)
+		}
+	}
+
+	maxBytes := args.MaxTotalBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultExportMaxBytes
+	}
+	if maxBytes > maxExportMaxBytes {
+		maxBytes = maxExportMaxBytes
+	}
+
+	document, reports := buildExportMarkdown(title, emails, maxBytes, time.Now())
+
+	truncated := 0
+	skipped := 0
+	for _, r := range reports {
+		if r.Skipped {
+			skipped++
+		} else if r.Truncated {
+			truncated++
+		}
+	}
+	log.Printf("✅ Exported %d emails (%d truncated, %d skipped, %d failed), %d bytes", len(reports)-skipped, truncated, skipped, len(failed), len(document))
+
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: document},
+		},
+		Meta: map[string]interface{}{
+			"title":          title,
+			"destination":    destination,
+			"emails":         reports,
+			"failed_ids":     failed,
+			"total_exported": len(reports) - skipped,
+			"truncated":      truncated,
+			"skipped":        skipped,
+			"size_bytes":     len(document),
+			"success":        true,
+		},
+	}, nil
+}
+
+func exportError(text string) *mcp.CallToolResultFor[any] {
+	return &mcp.CallToolResultFor[any]{
+		IsError: true,
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}
+}
+
+// timeRangeFilter переводит time_range в фильтр Gmail; пустое значение — без ограничения
+func timeRangeFilter(timeRange string) string {
+	switch timeRange {
+	case "today":
+		return " newer_than:1d"
+	case "week":
+		return " newer_than:7d"
+	case "month":
+		return " newer_than:30d"
+	}
+	return ""
+}
+
+func toExportedEmail(msg *gmail.Message) exportedEmail {
+	email := exportedEmail{ID: msg.Id}
+	if msg.InternalDate > 0 {
+		email.Date = time.Unix(msg.InternalDate/1000, 0)
+	}
+	if msg.Payload == nil {
+		return email
+	}
+	for _, header := range msg.Payload.Headers {
+		switch header.Name {
+		case "Subject":
+			email.Subject = header.Value
+		case "From":
+			email.From = header.Value
+		case "To":
+			email.To = header.Value
+		}
+	}
+	body, mimeType := extractBodyPart(msg.Payload, false)
+	if mimeType == "text/html" {
+		body = htmlToText(body)
+	}
+	email.Body = cleanEmailBody(body)
+	email.Attachments = collectAttachmentNames(msg.Payload)
+	return email
+}
+
+func collectAttachmentNames(part *gmail.MessagePart) []string {
+	if part == nil {
+		return nil
+	}
+	var names []string
+	if part.Filename != "" {
+		names = append(names, part.Filename)
+	}
+	for _, child := range part.Parts {
+		names = append(names, collectAttachmentNames(child)...)
+	}
+	return names
+}
+
+const truncationNote = "\n\n⚠️ _Body truncated: %d of %d bytes exported_"
+
+var blankLinesRe = regexp.MustCompile(`\n{3,}`)
+
+// cleanEmailBody нормализует переводы строк, убирает хвостовые пробелы и лишние пустые строки
+func cleanEmailBody(body string) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// buildExportMarkdown формирует markdown документ не больше maxBytes. Бюджет делится поровну
+// между оставшимися письмами (неиспользованная часть переходит к следующим), тело письма
+// обрезается с пометкой; если не помещаются даже заголовки, письмо пропускается
+func buildExportMarkdown(title string, emails []exportedEmail, maxBytes int, now time.Time) (string, []exportReport) {
+	var doc strings.Builder
+	doc.WriteString(fmt.Sprintf("# %s\n\n_Exported %d emails on %s_\n\n", title, len(emails), now.Format("2006-01-02 15:04")))
+
+	reports := make([]exportReport, 0, len(emails))
+	skipped := 0
+	for i, email := range emails {
+		report := exportReport{ID: email.ID, Subject: email.Subject, BodyBytes: len(email.Body)}
+
+		subject := email.Subject
+		if subject == "" {
+			subject = "(no subject)"
+		}
+		var header strings.Builder
+		header.WriteString(fmt.Sprintf("## %d. %s\n\n", i+1, subject))
+		header.WriteString(fmt.Sprintf("- **From:** %s\n", email.From))
+		if email.To != "" {
+			header.WriteString(fmt.Sprintf("- **To:** %s\n", email.To))
+		}
+		if !email.Date.IsZero() {
+			header.WriteString(fmt.Sprintf("- **Date:** %s\n", email.Date.Format("2006-01-02 15:04")))
+		}
+		header.WriteString(fmt.Sprintf("- **Message ID:** %s\n", email.ID))
+		if len(email.Attachments) > 0 {
+			header.WriteString(fmt.Sprintf("- **Attachments:** %s\n", strings.Join(email.Attachments, ", ")))
+		}
+		header.WriteString("\n")
+		const separator = "\n\n---\n\n"
+
+		remaining := maxBytes - doc.Len()
+		share := remaining / (len(emails) - i)
+		fixed := header.Len() + len(separator)
+		if fixed > remaining {
+			report.Skipped = true
+			report.Truncated = true
+			reports = append(reports, report)
+			skipped++
+			continue
+		}
+		if share < fixed {
+			share = fixed
+		}
+
+		body := email.Body
+		if body == "" {
+			body = "_(no text body)_"
+		}
+		if fixed+len(body) > share {
+			// Длина пометки оценивается сверху: число экспортированных байт не длиннее исходного
+			limit := share - fixed - len(fmt.Sprintf(truncationNote, len(body), len(body)))
+			if limit < 0 {
+				limit = 0
+			}
+			cut := strings.ToValidUTF8(body[:min(limit, len(body))], "")
+			note := fmt.Sprintf(truncationNote, len(cut), len(email.Body))
+			body = cut + note
+			report.Truncated = true
+			report.ExportedBytes = len(cut)
+		} else {
+			report.ExportedBytes = len(email.Body)
+		}
+
+		doc.WriteString(header.String())
+		doc.WriteString(body)
+		doc.WriteString(separator)
+		reports = append(reports, report)
+	}
+
+	if skipped > 0 {
+		doc.WriteString(fmt.Sprintf("_%d emails skipped: size limit of %d KB reached_\n", skipped, maxBytes/1024))
+	}
+	return doc.String(), reports
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/api/gmail/v1"
+)
+
+// GmailMarkParams параметры для отметки письма прочитанным/непрочитанным
+type GmailMarkParams struct {
+	MessageID string `json:"message_id" mcp:"Gmail message ID (from search_gmail results)"`
+}
+
+// GmailModifyLabelsParams параметры изменения меток письма
+type GmailModifyLabelsParams struct {
+	MessageID    string   `json:"message_id" mcp:"Gmail message ID (from search_gmail results)"`
+	AddLabels    []string `json:"add_labels,omitempty" mcp:"Labels to add: system labels (UNREAD, STARRED, IMPORTANT, INBOX, ...) or user label names/IDs"`
+	RemoveLabels []string `json:"remove_labels,omitempty" mcp:"Labels to remove, e.g. [\"UNREAD\"] to mark the message read"`
+}
+
+// systemLabels системные метки Gmail: их ID совпадает с именем, регистр в запросе не важен
+var systemLabels = []string{"INBOX", "UNREAD", "STARRED", "IMPORTANT", "SPAM", "TRASH", "SENT", "DRAFT",
+	"CATEGORY_PERSONAL", "CATEGORY_SOCIAL", "CATEGORY_PROMOTIONS", "CATEGORY_UPDATES", "CATEGORY_FORUMS"}
+
+// MarkAsRead снимает метку UNREAD с письма
+func (s *GmailMCPServer) MarkAsRead(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[GmailMarkParams]) (*mcp.CallToolResultFor[any], error) {
+	return s.modifyLabels(ctx, params.Arguments.MessageID, nil, []string{"UNREAD"})
+}
+
+// MarkAsUnread добавляет письму метку UNREAD
+func (s *GmailMCPServer) MarkAsUnread(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[GmailMarkParams]) (*mcp.CallToolResultFor[any], error) {
+	return s.modifyLabels(ctx, params.Arguments.MessageID, []string{"UNREAD"}, nil)
+}
+
+// ModifyLabels добавляет и снимает метки письма; remove_labels: ["UNREAD"] отмечает письмо прочитанным
+func (s *GmailMCPServer) ModifyLabels(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[GmailModifyLabelsParams]) (*mcp.CallToolResultFor[any], error) {
+	args := params.Arguments
+	return s.modifyLabels(ctx, args.MessageID, args.AddLabels, args.RemoveLabels)
+}
+
+func (s *GmailMCPServer) modifyLabels(ctx context.Context, messageID string, addLabels, removeLabels []string) (*mcp.CallToolResultFor[any], error) {
+	log.Printf("🏷 MCP Server: Modifying labels of Gmail message %s: +%v -%v", messageID, addLabels, removeLabels)
+
+	if strings.TrimSpace(messageID) == "" {
+		return gmailToolError("❌ message_id parameter is required"), nil
+	}
+	if len(addLabels) == 0 && len(removeLabels) == 0 {
+		return gmailToolError("❌ add_labels or remove_labels is required"), nil
+	}
+
+	userLabels := map[string]string{}
+	if needsUserLabels(addLabels) || needsUserLabels(removeLabels) {
+		list, err := s.gmailService.Users.Labels.List("me").Context(ctx).Do()
+		if err != nil {
+			return gmailToolError(fmt.Sprintf("❌ Failed to list Gmail labels: %v", err)), nil
+		}
+		for _, l := range list.Labels {
+			userLabels[strings.ToLower(l.Name)] = l.Id
+			userLabels[strings.ToLower(l.Id)] = l.Id
+		}
+	}
+	addIDs, err := resolveLabelIDs(addLabels, userLabels)
+	if err != nil {
+		return gmailToolError(fmt.Sprintf("❌ %v", err)), nil
+	}
+	removeIDs, err := resolveLabelIDs(removeLabels, userLabels)
+	if err != nil {
+		return gmailToolError(fmt.Sprintf("❌ %v", err)), nil
+	}
+
+	req := &gmail.ModifyMessageRequest{AddLabelIds: addIDs, RemoveLabelIds: removeIDs}
+	msg, err := s.gmailService.Users.Messages.Modify("me", messageID, req).Context(ctx).Do()
+	if err != nil {
+		return gmailToolError(fmt.Sprintf("❌ Failed to modify labels of message %s: %v", messageID, err)), nil
+	}
+
+	unread := slices.Contains(msg.LabelIds, "UNREAD")
+	state := "read"
+	if unread {
+		state = "unread"
+	}
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: fmt.Sprintf("✅ Message %s labels updated (now %s): %s", msg.Id, state, strings.Join(msg.LabelIds, ", "))},
+		},
+		Meta: map[string]interface{}{
+			"message_id":     msg.Id,
+			"label_ids":      msg.LabelIds,
+			"added_labels":   addIDs,
+			"removed_labels": removeIDs,
+			"unread":         unread,
+			"success":        true,
+		},
+	}, nil
+}
+
+// needsUserLabels есть ли среди меток не системные — их ID нужно искать по имени
+func needsUserLabels(labels []string) bool {
+	for _, l := range labels {
+		if !slices.Contains(systemLabels, strings.ToUpper(strings.TrimSpace(l))) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveLabelIDs переводит имена меток в ID: системные по имени в любом регистре, пользовательские по имени или ID
+func resolveLabelIDs(labels []string, userLabels map[string]string) ([]string, error) {
+	var ids []string
+	for _, l := range labels {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		id := strings.ToUpper(l)
+		if !slices.Contains(systemLabels, id) {
+			var ok bool
+			if id, ok = userLabels[strings.ToLower(l)]; !ok {
+				return nil, fmt.Errorf("unknown Gmail label %q", l)
+			}
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func gmailToolError(text string) *mcp.CallToolResultFor[any] {
+	return &mcp.CallToolResultFor[any]{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
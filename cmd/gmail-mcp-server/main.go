@@ -6,12 +6,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"mime"
+	"net/mail"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/net/html"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/gmail/v1"
@@ -25,9 +30,29 @@ type GmailSearchParams struct {
 	TimeRange string `json:"time_range,omitempty" mcp:"time range filter: 'today', 'week', 'month' (default: 'today')"`
 }
 
+// GmailSendEmailParams параметры для отправки email
+type GmailSendEmailParams struct {
+	To               []string `json:"to" mcp:"list of recipient email addresses"`
+	Cc               []string `json:"cc,omitempty" mcp:"list of CC email addresses"`
+	Subject          string   `json:"subject" mcp:"email subject"`
+	Body             string   `json:"body" mcp:"email body"`
+	BodyType         string   `json:"body_type,omitempty" mcp:"body content type: 'text' or 'html' (default: 'text')"`
+	ReplyToMessageID string   `json:"reply_to_message_id,omitempty" mcp:"Gmail message ID to reply to (keeps the reply in the same thread)"`
+}
+
+// GmailGetBodyParams параметры для получения полного тела письма
+type GmailGetBodyParams struct {
+	MessageID  string `json:"message_id" mcp:"Gmail message ID (from search_gmail results)"`
+	PreferHTML bool   `json:"prefer_html,omitempty" mcp:"prefer the text/html part (converted to plain text) over text/plain"`
+}
+
+// maxEmailBodyBytes максимальный размер тела письма в ответе
+const maxEmailBodyBytes = 100 * 1024
+
 // GmailEmailResult результат поиска email
 type GmailEmailResult struct {
 	ID          string    `json:"id"`
+	ThreadID    string    `json:"thread_id,omitempty"`
 	Subject     string    `json:"subject"`
 	From        string    `json:"from"`
 	Date        time.Time `json:"date"`
@@ -56,6 +81,7 @@ type GoogleCredentialsFile struct {
 type GmailMCPServer struct {
 	gmailService *gmail.Service
 	config       *oauth2.Config
+	tokenSource  oauth2.TokenSource
 }
 
 // NewGmailMCPServer создает новый MCP сервер для Gmail
@@ -73,7 +99,7 @@ func NewGmailMCPServer(credentialsJSON string) (*GmailMCPServer, error) {
 		ClientID:     credentials.ClientID,
 		ClientSecret: credentials.ClientSecret,
 		RedirectURL:  "urn:ietf:wg:oauth:2.0:oob", // для desktop приложений
-		Scopes:       []string{gmail.GmailReadonlyScope},
+		Scopes:       []string{gmail.GmailReadonlyScope, gmail.GmailSendScope, gmail.GmailModifyScope},
 		Endpoint:     google.Endpoint,
 	}
 
@@ -83,22 +109,74 @@ func NewGmailMCPServer(credentialsJSON string) (*GmailMCPServer, error) {
 		return nil, fmt.Errorf("failed to get OAuth2 token: %w", err)
 	}
 
-	// Создаем HTTP клиент с токеном
-	httpClient := config.Client(context.Background(), token)
+	// TokenSource обновляет access token по refresh token незадолго до истечения,
+	// обновлённый токен сохраняется в кэш-файл
+	ctx := context.Background()
+	tokenSource := oauth2.ReuseTokenSource(token, &cachingTokenSource{
+		src:       config.TokenSource(ctx, token),
+		tokenFile: getTokenFilePath(),
+		last:      token.AccessToken,
+	})
+	httpClient := oauth2.NewClient(ctx, tokenSource)
 
 	// Создаем Gmail service
 	gmailService, err := gmail.NewService(
-		context.Background(),
+		ctx,
 		option.WithHTTPClient(httpClient),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gmail service: %w", err)
 	}
 
-	return &GmailMCPServer{
+	server := &GmailMCPServer{
 		gmailService: gmailService,
 		config:       config,
-	}, nil
+		tokenSource:  tokenSource,
+	}
+	if err := server.validateToken(); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// validateToken заранее получает access token (обновляя его при необходимости) и логирует срок действия
+func (s *GmailMCPServer) validateToken() error {
+	token, err := s.tokenSource.Token()
+	if err != nil {
+		return fmt.Errorf("failed to validate OAuth2 token: %w", err)
+	}
+	if token.Expiry.IsZero() {
+		log.Printf("✅ OAuth2 token is valid (no expiry)")
+		return nil
+	}
+	log.Printf("✅ OAuth2 token is valid until %s (in %s), it will be refreshed automatically",
+		token.Expiry.Format(time.RFC3339), time.Until(token.Expiry).Round(time.Second))
+	return nil
+}
+
+// cachingTokenSource сохраняет обновлённый токен в кэш-файл, чтобы после перезапуска не проходить OAuth заново
+type cachingTokenSource struct {
+	src       oauth2.TokenSource
+	tokenFile string
+	mu        sync.Mutex
+	last      string
+}
+
+func (c *cachingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := c.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if token.AccessToken != c.last {
+		c.last = token.AccessToken
+		log.Printf("🔄 OAuth2 token refreshed, expires at %s", token.Expiry.Format(time.RFC3339))
+		if err := saveTokenToFile(c.tokenFile, token); err != nil {
+			log.Printf("⚠️ Warning: failed to save refreshed token: %v", err)
+		}
+	}
+	return token, nil
 }
 
 // getToken получает OAuth2 токен (с кэшированием)
@@ -114,7 +192,12 @@ func getToken(config *oauth2.Config) (*oauth2.Token, error) {
 			log.Printf("✅ Using cached OAuth2 token")
 			return token, nil
 		}
-		log.Printf("⚠️ Cached token expired, refreshing...")
+		// Истёкший токен с refresh token обновит TokenSource, OAuth flow не нужен
+		if token.RefreshToken != "" {
+			log.Printf("⚠️ Cached token expired, it will be refreshed with the stored refresh token")
+			return token, nil
+		}
+		log.Printf("⚠️ Cached token expired and has no refresh token")
 	}
 
 	// Если токена нет или он истек, запускаем OAuth flow
@@ -344,8 +427,9 @@ func (s *GmailMCPServer) SearchEmails(ctx context.Context, session *mcp.ServerSe
 // parseGmailMessage извлекает данные из Gmail сообщения
 func (s *GmailMCPServer) parseGmailMessage(msg *gmail.Message) GmailEmailResult {
 	result := GmailEmailResult{
-		ID:      msg.Id,
-		Snippet: msg.Snippet,
+		ID:       msg.Id,
+		ThreadID: msg.ThreadId,
+		Snippet:  msg.Snippet,
 	}
 
 	// Извлекаем дату
@@ -401,6 +485,320 @@ func (s *GmailMCPServer) extractMessageBody(payload *gmail.MessagePart) string {
 	return ""
 }
 
+// GetEmailBody возвращает полное декодированное тело письма по его ID
+func (s *GmailMCPServer) GetEmailBody(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[GmailGetBodyParams]) (*mcp.CallToolResultFor[any], error) {
+	args := params.Arguments
+
+	log.Printf("📨 MCP Server: Getting body of Gmail message %s (prefer_html=%v)", args.MessageID, args.PreferHTML)
+
+	if strings.TrimSpace(args.MessageID) == "" {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "❌ message_id parameter is required"},
+			},
+		}, nil
+	}
+
+	msg, err := s.gmailService.Users.Messages.Get("me", args.MessageID).Context(ctx).Do()
+	if err != nil {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("❌ Failed to get message %s: %v", args.MessageID, err)},
+			},
+		}, nil
+	}
+
+	email := s.parseGmailMessage(msg)
+	body, mimeType := extractBodyPart(msg.Payload, args.PreferHTML)
+	if mimeType == "text/html" {
+		body = htmlToText(body)
+	}
+
+	truncated := false
+	if len(body) > maxEmailBodyBytes {
+		body = strings.ToValidUTF8(body[:maxEmailBodyBytes], "")
+		truncated = true
+	}
+
+	resultMessage := fmt.Sprintf("📨 **From:** %s\n**Subject:** %s\n**Date:** %s\n\n%s",
+		email.From, email.Subject, email.Date.Format("2006-01-02 15:04"), body)
+	if body == "" {
+		resultMessage += "(message has no text body)"
+	}
+	if truncated {
+		resultMessage += fmt.Sprintf("\n\n⚠️ Body truncated to %d KB", maxEmailBodyBytes/1024)
+	}
+
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: resultMessage},
+		},
+		Meta: map[string]interface{}{
+			"message_id": msg.Id,
+			"thread_id":  msg.ThreadId,
+			"subject":    email.Subject,
+			"from":       email.From,
+			"mime_type":  mimeType,
+			"truncated":  truncated,
+			"success":    true,
+		},
+	}, nil
+}
+
+// extractBodyPart рекурсивно ищет text/plain или text/html часть письма и декодирует её.
+// Возвращает тело и MIME тип найденной части
+func extractBodyPart(payload *gmail.MessagePart, preferHTML bool) (string, string) {
+	order := []string{"text/plain", "text/html"}
+	if preferHTML {
+		order = []string{"text/html", "text/plain"}
+	}
+	for _, mimeType := range order {
+		if part := findPartByMimeType(payload, mimeType); part != nil {
+			if decoded, err := decodeBase64URL(part.Body.Data); err == nil {
+				return string(decoded), mimeType
+			}
+		}
+	}
+	return "", ""
+}
+
+func findPartByMimeType(part *gmail.MessagePart, mimeType string) *gmail.MessagePart {
+	if part == nil {
+		return nil
+	}
+	// Вложения пропускаем
+	if part.MimeType == mimeType && part.Filename == "" && part.Body != nil && part.Body.Data != "" {
+		return part
+	}
+	for _, child := range part.Parts {
+		if found := findPartByMimeType(child, mimeType); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// decodeBase64URL декодирует base64url с паддингом или без
+func decodeBase64URL(data string) ([]byte, error) {
+	if decoded, err := base64.URLEncoding.DecodeString(data); err == nil {
+		return decoded, nil
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(data, "="))
+}
+
+// htmlToText преобразует HTML письма в простой текст
+func htmlToText(src string) string {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		return src
+	}
+
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "head", "title":
+				return
+			case "br":
+				sb.WriteString("\n")
+			case "li":
+				sb.WriteString("\n- ")
+			}
+		}
+		if n.Type == html.TextNode {
+			if text := strings.Join(strings.Fields(n.Data), " "); text != "" {
+				sb.WriteString(text + " ")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "p", "div", "tr", "table", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "blockquote":
+				sb.WriteString("\n")
+			case "a":
+				for _, attr := range n.Attr {
+					if attr.Key == "href" && strings.HasPrefix(attr.Val, "http") {
+						sb.WriteString("(" + attr.Val + ") ")
+					}
+				}
+			}
+		}
+	}
+	walk(doc)
+
+	// Убираем лишние пробелы и пустые строки
+	lines := strings.Split(sb.String(), "\n")
+	result := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if !blank && len(result) > 0 {
+				result = append(result, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		result = append(result, line)
+	}
+	return strings.TrimSpace(strings.Join(result, "\n"))
+}
+
+// SendEmail отправляет email через Gmail API (messages.send)
+func (s *GmailMCPServer) SendEmail(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[GmailSendEmailParams]) (*mcp.CallToolResultFor[any], error) {
+	args := params.Arguments
+
+	log.Printf("📤 MCP Server: Sending email to %v (subject: '%s')", args.To, args.Subject)
+
+	if len(args.To) == 0 {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "❌ At least one recipient is required"},
+			},
+		}, nil
+	}
+
+	bodyType := strings.ToLower(args.BodyType)
+	if bodyType == "" {
+		bodyType = "text"
+	}
+	if bodyType != "text" && bodyType != "html" {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("❌ Unsupported body_type '%s' (expected 'text' or 'html')", args.BodyType)},
+			},
+		}, nil
+	}
+
+	// Для ответа берём Message-ID исходного письма и его thread
+	var inReplyTo, threadID string
+	if args.ReplyToMessageID != "" {
+		original, err := s.gmailService.Users.Messages.Get("me", args.ReplyToMessageID).Format("metadata").MetadataHeaders("Message-ID").Context(ctx).Do()
+		if err != nil {
+			return &mcp.CallToolResultFor[any]{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("❌ Failed to get original message %s: %v", args.ReplyToMessageID, err)},
+				},
+			}, nil
+		}
+		threadID = original.ThreadId
+		if original.Payload != nil {
+			for _, header := range original.Payload.Headers {
+				if strings.EqualFold(header.Name, "Message-ID") {
+					inReplyTo = header.Value
+				}
+			}
+		}
+	}
+
+	raw, err := buildRFC2822Message(args.To, args.Cc, args.Subject, args.Body, bodyType, inReplyTo)
+	if err != nil {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("❌ Invalid email: %v", err)},
+			},
+		}, nil
+	}
+	message := &gmail.Message{
+		Raw:      base64.URLEncoding.EncodeToString([]byte(raw)),
+		ThreadId: threadID,
+	}
+
+	sent, err := s.gmailService.Users.Messages.Send("me", message).Context(ctx).Do()
+	if err != nil {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("❌ Gmail send failed: %v", err)},
+			},
+		}, nil
+	}
+
+	resultMessage := fmt.Sprintf("📤 Email sent to %s\n**Subject:** %s\n**Message ID:** %s", strings.Join(args.To, ", "), args.Subject, sent.Id)
+
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: resultMessage},
+		},
+		Meta: map[string]interface{}{
+			"message_id": sent.Id,
+			"thread_id":  sent.ThreadId,
+			"to":         args.To,
+			"cc":         args.Cc,
+			"success":    true,
+		},
+	}, nil
+}
+
+// buildRFC2822Message формирует письмо в формате RFC 2822. Адреса разбираются net/mail и записываются
+// заново, поэтому CR/LF из аргументов инструмента не попадают в заголовки (например, лишний Bcc:)
+func buildRFC2822Message(to, cc []string, subject, body, bodyType, inReplyTo string) (string, error) {
+	contentType := "text/plain"
+	if bodyType == "html" {
+		contentType = "text/html"
+	}
+	toHeader, err := formatAddressList(to)
+	if err != nil {
+		return "", fmt.Errorf("to: %w", err)
+	}
+	ccHeader, err := formatAddressList(cc)
+	if err != nil {
+		return "", fmt.Errorf("cc: %w", err)
+	}
+	if strings.ContainsAny(inReplyTo, "\r\n") {
+		return "", fmt.Errorf("original Message-ID contains a line break")
+	}
+
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf("To: %s\r\n", toHeader))
+	if ccHeader != "" {
+		msg.WriteString(fmt.Sprintf("Cc: %s\r\n", ccHeader))
+	}
+	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject)))
+	if inReplyTo != "" {
+		msg.WriteString(fmt.Sprintf("In-Reply-To: %s\r\n", inReplyTo))
+		msg.WriteString(fmt.Sprintf("References: %s\r\n", inReplyTo))
+	}
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString(fmt.Sprintf("Content-Type: %s; charset=\"UTF-8\"\r\n", contentType))
+	msg.WriteString("Content-Transfer-Encoding: base64\r\n")
+	msg.WriteString("\r\n")
+	// Разбиваем base64 на строки по 76 символов (RFC 2045)
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		msg.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	msg.WriteString(encoded)
+	return msg.String(), nil
+}
+
+// formatAddressList проверяет адреса и собирает значение заголовка To/Cc
+func formatAddressList(addrs []string) (string, error) {
+	formatted := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if strings.ContainsAny(addr, "\r\n") {
+			return "", fmt.Errorf("address %q contains a line break", addr)
+		}
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil {
+			return "", fmt.Errorf("invalid address %q: %w", addr, err)
+		}
+		formatted = append(formatted, parsed.String())
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
 func main() {
 	if err := godotenv.Load(".env" /*, "../.env", "cmd/bot/.env"*/); err != nil {
 		log.Printf("Warning: .env file not found: %v", err)
@@ -443,7 +841,57 @@ func main() {
 		Description: "Searches for emails in Gmail using specified query and filters",
 	}, gmailServer.SearchEmails)
 
-	log.Printf("📋 Registered Gmail MCP tools: search_gmail")
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "send_gmail",
+		Description: "Sends an email via Gmail (plain text or HTML), optionally as a reply to an existing message",
+	}, gmailServer.SendEmail)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_gmail_body",
+		Description: "Returns the full decoded body of a Gmail message by its ID (HTML is converted to plain text, capped at 100 KB)",
+	}, gmailServer.GetEmailBody)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_gmail_thread",
+		Description: "Returns a whole Gmail conversation by thread_id: each message's from/date/snippet in order (latest max_messages) and the concatenated plain-text bodies, for summarizing a back-and-forth",
+	}, gmailServer.GetThread)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "export_gmail_results",
+		Description: "Exports emails found by a query (or given message IDs) into one markdown document: a section per email with headers, cleaned body and attachment names, capped by max_total_bytes with per-email truncation report. The bot saves it as a file or a Notion page",
+	}, gmailServer.ExportEmails)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "gmail_mark_read",
+		Description: "Marks a Gmail message as read (removes the UNREAD label), e.g. after it has been processed",
+	}, gmailServer.MarkAsRead)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "gmail_mark_unread",
+		Description: "Marks a Gmail message as unread (adds the UNREAD label)",
+	}, gmailServer.MarkAsUnread)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "modify_gmail_labels",
+		Description: "Adds and removes labels of a Gmail message (system labels like UNREAD, STARRED, IMPORTANT or user label names) and returns the resulting label set; remove_labels=[\"UNREAD\"] marks it read",
+	}, gmailServer.ModifyLabels)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_email_attachments",
+		Description: "Lists attachments of a Gmail message (filename, MIME type, size, attachment_id) without downloading their data",
+	}, gmailServer.ListEmailAttachments)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_email_attachment",
+		Description: "Downloads a Gmail attachment by message_id and attachment_id (see list_email_attachments) and returns its data with filename and MIME type",
+	}, gmailServer.GetEmailAttachment)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "ping",
+		Description: "Checks the Gmail OAuth2 token with a lightweight authenticated call (Users.GetProfile) and returns OK or the authentication error",
+	}, gmailServer.Ping)
+
+	log.Printf("📋 Registered Gmail MCP tools: search_gmail, send_gmail, get_gmail_body, get_gmail_thread, export_gmail_results, gmail_mark_read, gmail_mark_unread, modify_gmail_labels, list_email_attachments, get_email_attachment, ping")
 	log.Printf("🔗 Starting Gmail MCP server on stdin/stdout...")
 
 	// Запускаем сервер через stdin/stdout
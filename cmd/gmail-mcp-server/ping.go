@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// PingParams ping не принимает параметров
+type PingParams struct{}
+
+// Ping проверяет OAuth2 токен запросом Users.GetProfile: OK с адресом ящика или ошибка авторизации
+func (s *GmailMCPServer) Ping(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[PingParams]) (*mcp.CallToolResultFor[any], error) {
+	log.Printf("🏓 MCP Server: Checking Gmail credentials")
+
+	// Отдельно от запроса: отозванный refresh token должен быть виден как ошибка OAuth, а не API
+	if _, err := s.tokenSource.Token(); err != nil {
+		return gmailToolError(fmt.Sprintf("❌ Gmail OAuth2 token refresh failed, re-run gmail-auth-helper: %v", err)), nil
+	}
+
+	profile, err := s.gmailService.Users.GetProfile("me").Context(ctx).Do()
+	if err != nil {
+		return gmailToolError(fmt.Sprintf("❌ Gmail API request failed: %v", err)), nil
+	}
+
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("✅ OK: authenticated as %s", profile.EmailAddress)}},
+		Meta: map[string]interface{}{
+			"ok":             true,
+			"email":          profile.EmailAddress,
+			"messages_total": profile.MessagesTotal,
+		},
+	}, nil
+}
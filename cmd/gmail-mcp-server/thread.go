@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// defaultThreadMessages сколько последних писем треда возвращается по умолчанию
+	defaultThreadMessages = 20
+	// maxThreadMessages верхняя граница max_messages
+	maxThreadMessages = 50
+)
+
+// GmailThreadParams параметры получения треда
+type GmailThreadParams struct {
+	ThreadID    string `json:"thread_id" mcp:"Gmail thread ID (thread_id from search_gmail results)"`
+	MaxMessages int    `json:"max_messages,omitempty" mcp:"how many latest messages of the thread to return (default 20, max 50)"`
+}
+
+// threadMessage письмо треда в Meta ответа
+type threadMessage struct {
+	ID      string    `json:"id"`
+	From    string    `json:"from"`
+	Date    time.Time `json:"date"`
+	Snippet string    `json:"snippet"`
+}
+
+// GetThread возвращает переписку целиком: письма треда по порядку и их тексты одним документом
+func (s *GmailMCPServer) GetThread(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[GmailThreadParams]) (*mcp.CallToolResultFor[any], error) {
+	args := params.Arguments
+
+	log.Printf("🧵 MCP Server: Getting Gmail thread %s", args.ThreadID)
+
+	if strings.TrimSpace(args.ThreadID) == "" {
+		return gmailToolError("❌ thread_id parameter is required"), nil
+	}
+	limit := args.MaxMessages
+	if limit <= 0 {
+		limit = defaultThreadMessages
+	}
+	limit = min(limit, maxThreadMessages)
+
+	thread, err := s.gmailService.Users.Threads.Get("me", args.ThreadID).Format("full").Context(ctx).Do()
+	if err != nil {
+		return gmailToolError(fmt.Sprintf("❌ Failed to get thread %s: %v", args.ThreadID, err)), nil
+	}
+
+	total := len(thread.Messages)
+	// Для саммари важнее свежие ответы: при превышении лимита отбрасываются самые ранние письма
+	messages := thread.Messages
+	if len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+
+	var subject string
+	var doc strings.Builder
+	items := make([]threadMessage, 0, len(messages))
+	truncated := false
+	for i, msg := range messages {
+		email := toExportedEmail(msg)
+		if subject == "" {
+			subject = email.Subject
+		}
+		items = append(items, threadMessage{ID: msg.Id, From: email.From, Date: email.Date, Snippet: msg.Snippet})
+		if truncated {
+			continue
+		}
+
+		section := fmt.Sprintf("--- %d/%d. %s, %s ---\n", total-len(messages)+i+1, total, email.From, email.Date.Format("2006-01-02 15:04"))
+		body := email.Body
+		if body == "" {
+			body = "(message has no text body)"
+		}
+		// Общий размер текста треда ограничен так же, как тело одного письма в get_gmail_body
+		if remaining := maxEmailBodyBytes - doc.Len() - len(section); len(body) > remaining {
+			body = strings.ToValidUTF8(body[:max(remaining, 0)], "") + "\n\n⚠️ Thread text truncated"
+			truncated = true
+		}
+		doc.WriteString(section + body + "\n\n")
+	}
+
+	header := fmt.Sprintf("🧵 **Thread:** %s — %d messages", subject, total)
+	if len(messages) < total {
+		header += fmt.Sprintf(" (showing the latest %d)", len(messages))
+	}
+
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: header + "\n\n" + strings.TrimSpace(doc.String())},
+		},
+		Meta: map[string]interface{}{
+			"thread_id":      thread.Id,
+			"subject":        subject,
+			"messages":       items,
+			"message_count":  total,
+			"returned_count": len(items),
+			"truncated":      truncated,
+			"success":        true,
+		},
+	}, nil
+}
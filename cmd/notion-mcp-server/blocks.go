@@ -0,0 +1,174 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// notionMaxRichText лимит символов в одном элементе rich_text
+const notionMaxRichText = 2000
+
+// notionMaxRichTextItems лимит элементов rich_text в одном блоке
+const notionMaxRichTextItems = 100
+
+// tocMarker строка markdown, на месте которой создаётся блок оглавления
+const tocMarker = "[TOC]"
+
+var (
+	numberedItemRe = regexp.MustCompile(`^\d+[.)]\s+`)
+	boldRe         = regexp.MustCompile(`\*\*(.+?)\*\*`)
+)
+
+// markdownToBlocks переводит markdown в блоки Notion: заголовки, списки, цитаты, код, разделители,
+// оглавление ([TOC]) и абзацы. Длинный текст делится на элементы rich_text и, при необходимости, на
+// несколько блоков, чтобы не превышать лимиты API
+func markdownToBlocks(content string) []map[string]interface{} {
+	var blocks []map[string]interface{}
+	var paragraph []string
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, textBlocks("paragraph", strings.Join(paragraph, "\n"))...)
+			paragraph = nil
+		}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			flushParagraph()
+			language := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, codeBlocks(strings.Join(code, "\n"), language)...)
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flushParagraph()
+		case trimmed == tocMarker:
+			flushParagraph()
+			blocks = append(blocks, map[string]interface{}{
+				"object":            "block",
+				"type":              "table_of_contents",
+				"table_of_contents": map[string]interface{}{"color": "default"},
+			})
+		case trimmed == "---" || trimmed == "***":
+			flushParagraph()
+			blocks = append(blocks, map[string]interface{}{"object": "block", "type": "divider", "divider": map[string]interface{}{}})
+		case strings.HasPrefix(trimmed, "### "):
+			flushParagraph()
+			blocks = append(blocks, textBlocks("heading_3", strings.TrimPrefix(trimmed, "### "))...)
+		case strings.HasPrefix(trimmed, "## "):
+			flushParagraph()
+			blocks = append(blocks, textBlocks("heading_2", strings.TrimPrefix(trimmed, "## "))...)
+		case strings.HasPrefix(trimmed, "# "):
+			flushParagraph()
+			blocks = append(blocks, textBlocks("heading_1", strings.TrimPrefix(trimmed, "# "))...)
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			flushParagraph()
+			blocks = append(blocks, textBlocks("bulleted_list_item", trimmed[2:])...)
+		case numberedItemRe.MatchString(trimmed):
+			flushParagraph()
+			blocks = append(blocks, textBlocks("numbered_list_item", numberedItemRe.ReplaceAllString(trimmed, ""))...)
+		case strings.HasPrefix(trimmed, "> "):
+			flushParagraph()
+			blocks = append(blocks, textBlocks("quote", strings.TrimPrefix(trimmed, "> "))...)
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flushParagraph()
+	return blocks
+}
+
+// textBlocks блоки заданного типа с текстом; текст сверх лимита элементов rich_text уходит в следующие блоки того же типа
+func textBlocks(blockType, text string) []map[string]interface{} {
+	richText := richTextSegments(text)
+	var blocks []map[string]interface{}
+	for start := 0; start < len(richText) || start == 0; start += notionMaxRichTextItems {
+		end := min(start+notionMaxRichTextItems, len(richText))
+		blocks = append(blocks, map[string]interface{}{
+			"object":  "block",
+			"type":    blockType,
+			blockType: map[string]interface{}{"rich_text": richText[start:end]},
+		})
+	}
+	return blocks
+}
+
+// codeBlocks блок кода; язык, неизвестный Notion, заменяется на "plain text"
+func codeBlocks(code, language string) []map[string]interface{} {
+	if language == "" {
+		language = "plain text"
+	}
+	richText := plainRichText(code)
+	var blocks []map[string]interface{}
+	for start := 0; start < len(richText) || start == 0; start += notionMaxRichTextItems {
+		end := min(start+notionMaxRichTextItems, len(richText))
+		blocks = append(blocks, map[string]interface{}{
+			"object": "block",
+			"type":   "code",
+			"code":   map[string]interface{}{"rich_text": richText[start:end], "language": notionCodeLanguage(language)},
+		})
+	}
+	return blocks
+}
+
+// richTextSegments текст с выделением **жирным**, разбитый на элементы не длиннее notionMaxRichText
+func richTextSegments(text string) []map[string]interface{} {
+	var out []map[string]interface{}
+	pos := 0
+	for _, m := range boldRe.FindAllStringSubmatchIndex(text, -1) {
+		out = append(out, richTextChunks(text[pos:m[0]], false)...)
+		out = append(out, richTextChunks(text[m[2]:m[3]], true)...)
+		pos = m[1]
+	}
+	return append(out, richTextChunks(text[pos:], false)...)
+}
+
+func plainRichText(text string) []map[string]interface{} {
+	out := richTextChunks(text, false)
+	if len(out) == 0 {
+		out = richTextChunks(" ", false)
+	}
+	return out
+}
+
+// richTextChunks делит текст по символам (не байтам) на элементы не длиннее notionMaxRichText
+func richTextChunks(text string, bold bool) []map[string]interface{} {
+	var out []map[string]interface{}
+	runes := []rune(text)
+	for start := 0; start < len(runes); start += notionMaxRichText {
+		end := min(start+notionMaxRichText, len(runes))
+		item := map[string]interface{}{
+			"type": "text",
+			"text": map[string]interface{}{"content": string(runes[start:end])},
+		}
+		if bold {
+			item["annotations"] = map[string]interface{}{"bold": true}
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// notionCodeLanguages языки блоков кода, которые чаще всего встречаются в ответах LLM
+var notionCodeLanguages = map[string]string{
+	"go": "go", "golang": "go", "python": "python", "py": "python", "js": "javascript", "javascript": "javascript",
+	"ts": "typescript", "typescript": "typescript", "json": "json", "yaml": "yaml", "yml": "yaml", "bash": "bash",
+	"sh": "shell", "shell": "shell", "sql": "sql", "java": "java", "kotlin": "kotlin", "rust": "rust",
+	"html": "html", "css": "css", "markdown": "markdown", "md": "markdown", "plain text": "plain text",
+}
+
+func notionCodeLanguage(language string) string {
+	if l, ok := notionCodeLanguages[strings.ToLower(language)]; ok {
+		return l
+	}
+	return "plain text"
+}
@@ -9,6 +9,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -40,11 +41,46 @@ type SearchParams struct {
 	PageSize int                    `json:"page_size,omitempty" mcp:"number of results to return (default: 20)"`
 }
 
-// SearchPagesParams параметры для поиска страниц с возвратом ID
+// SearchPagesParams параметры для поиска страниц с возвратом ID.
+// Notion /search возвращает и несвязанные страницы, поэтому название проверяется на нашей стороне:
+// exact_match важнее contains; без обоих флагов возвращается выдача Notion как есть;
+// case_insensitive действует на оба режима
 type SearchPagesParams struct {
-	Query      string `json:"query" mcp:"search query to find pages by title"`
-	Limit      int    `json:"limit,omitempty" mcp:"maximum number of results to return (default: 10, max: 50)"`
-	ExactMatch bool   `json:"exact_match,omitempty" mcp:"if true, only return exact title matches"`
+	Query           string `json:"query" mcp:"search query to find pages by title"`
+	Limit           int    `json:"limit,omitempty" mcp:"maximum number of results to return (default: 5, max: 20)"`
+	ExactMatch      bool   `json:"exact_match,omitempty" mcp:"if true, only return pages whose title equals the query; takes precedence over contains"`
+	Contains        bool   `json:"contains,omitempty" mcp:"if true, only return pages whose title contains the query; ignored when exact_match is set"`
+	CaseInsensitive bool   `json:"case_insensitive,omitempty" mcp:"if true, exact_match and contains compare titles ignoring case"`
+}
+
+// titleMatchMode режим сравнения названия для Meta ответа: exact, contains или any
+func (p SearchPagesParams) titleMatchMode() string {
+	switch {
+	case p.ExactMatch:
+		return "exact"
+	case p.Contains:
+		return "contains"
+	default:
+		return "any"
+	}
+}
+
+// titleMatches проверяет название страницы по режиму поиска (см. SearchPagesParams)
+func (p SearchPagesParams) titleMatches(title string) bool {
+	query := strings.TrimSpace(p.Query)
+	title = strings.TrimSpace(title)
+	if p.CaseInsensitive {
+		query = strings.ToLower(query)
+		title = strings.ToLower(title)
+	}
+	switch p.titleMatchMode() {
+	case "exact":
+		return title == query
+	case "contains":
+		return strings.Contains(title, query)
+	default:
+		return true
+	}
 }
 
 // PageSearchResult результат поиска страницы
@@ -70,6 +106,16 @@ type AvailablePageResult struct {
 	Type        string `json:"type,omitempty"`
 }
 
+// NotionAPIError ответ Notion API с HTTP статусом ошибки
+type NotionAPIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *NotionAPIError) Error() string {
+	return fmt.Sprintf("Notion API error %d: %s", e.StatusCode, e.Body)
+}
+
 // NotionMCPServer кастомный MCP сервер для Notion
 type NotionMCPServer struct {
 	notionClient *NotionAPIClient
@@ -126,7 +172,7 @@ func (c *NotionAPIClient) doNotionRequest(ctx context.Context, method, endpoint
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("Notion API error %d: %s", resp.StatusCode, string(respBody))
+		return nil, &NotionAPIError{StatusCode: resp.StatusCode, Body: string(respBody)}
 	}
 
 	return respBody, nil
@@ -151,24 +197,16 @@ func (c *NotionAPIClient) createPage(ctx context.Context, title, content, parent
 				},
 			},
 		},
-		"children": []map[string]interface{}{
-			{
-				"object": "block",
-				"type":   "paragraph",
-				"paragraph": map[string]interface{}{
-					"rich_text": []map[string]interface{}{
-						{
-							"type": "text",
-							"text": map[string]interface{}{
-								"content": content,
-							},
-						},
-					},
-				},
-			},
-		},
 	}
 
+	// Markdown переводится в блоки; сверх лимита запроса блоки дописываются после создания страницы
+	blocks := markdownToBlocks(content)
+	first, rest := blocks, []map[string]interface{}(nil)
+	if len(blocks) > notionMaxChildren {
+		first, rest = blocks[:notionMaxChildren], blocks[notionMaxChildren:]
+	}
+	pageData["children"] = first
+
 	respBody, err := c.doNotionRequest(ctx, "POST", "/pages", pageData)
 	if err != nil {
 		return "", err
@@ -179,11 +217,16 @@ func (c *NotionAPIClient) createPage(ctx context.Context, title, content, parent
 		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	if id, ok := result["id"].(string); ok {
-		return id, nil
+	id, ok := result["id"].(string)
+	if !ok {
+		return "", fmt.Errorf("no page ID in response")
 	}
-
-	return "", fmt.Errorf("no page ID in response")
+	if len(rest) > 0 {
+		if err := c.appendBlocks(ctx, id, rest); err != nil {
+			return id, fmt.Errorf("page created but only %d of %d blocks were added: %w", len(first), len(blocks), err)
+		}
+	}
+	return id, nil
 }
 
 // searchPages ищет страницы в Notion
@@ -353,27 +396,31 @@ func (s *NotionMCPServer) SearchPagesWithID(ctx context.Context, session *mcp.Se
 		// Извлекаем URL страницы
 		pageURL, _ := page["url"].(string)
 
-		// Извлекаем название страницы
+		// Извлекаем название страницы: Notion делит его на фрагменты по форматированию,
+		// для сравнения нужен весь текст
 		title := "Untitled"
 		if properties, ok := page["properties"].(map[string]interface{}); ok {
 			if titleProp, ok := properties["title"].(map[string]interface{}); ok {
 				if titleArray, ok := titleProp["title"].([]interface{}); ok && len(titleArray) > 0 {
-					if titleText, ok := titleArray[0].(map[string]interface{}); ok {
-						if text, ok := titleText["text"].(map[string]interface{}); ok {
-							if content, ok := text["content"].(string); ok {
-								title = content
+					var full strings.Builder
+					for _, fragment := range titleArray {
+						if titleText, ok := fragment.(map[string]interface{}); ok {
+							if text, ok := titleText["text"].(map[string]interface{}); ok {
+								if content, ok := text["content"].(string); ok {
+									full.WriteString(content)
+								}
 							}
 						}
 					}
+					if full.Len() > 0 {
+						title = full.String()
+					}
 				}
 			}
 		}
 
-		// Если запрос точного совпадения, проверяем
-		if args.ExactMatch {
-			if title != args.Query {
-				continue
-			}
+		if !args.titleMatches(title) {
+			continue
 		}
 
 		results = append(results, PageSearchResult{
@@ -399,11 +446,13 @@ func (s *NotionMCPServer) SearchPagesWithID(ctx context.Context, session *mcp.Se
 			&mcp.TextContent{Text: resultMessage},
 		},
 		Meta: map[string]interface{}{
-			"query":       args.Query,
-			"results":     results,
-			"total_found": len(results),
-			"exact_match": args.ExactMatch,
-			"success":     true,
+			"query":            args.Query,
+			"results":          results,
+			"total_found":      len(results),
+			"exact_match":      args.ExactMatch,
+			"match":            args.titleMatchMode(),
+			"case_insensitive": args.CaseInsensitive,
+			"success":          true,
 		},
 	}, nil
 }
@@ -642,7 +691,22 @@ func main() {
 		Description: "Lists available pages in Notion workspace that can be used as parent pages",
 	}, notionServer.ListAvailablePages)
 
-	log.Printf("📋 Registered %d tools: create_page, search_pages, save_dialog_to_notion, search_pages_with_id, list_available_pages", 5)
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "archive_page",
+		Description: "Archives (moves to trash) a Notion page. Requires confirm=true; without it only describes what will happen",
+	}, notionServer.ArchivePage)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "move_page",
+		Description: "Moves a Notion page under a new parent page. Requires confirm=true. If the API cannot move the page, copies it and archives the original only with allow_copy_fallback=true (comments and nested blocks are lost, the page ID changes)",
+	}, notionServer.MovePage)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "ping",
+		Description: "Checks NOTION_TOKEN with a lightweight authenticated call (GET /users/me) and returns OK or the authentication error",
+	}, notionServer.Ping)
+
+	log.Printf("📋 Registered %d tools: create_page, search_pages, save_dialog_to_notion, search_pages_with_id, list_available_pages, archive_page, move_page, ping", 8)
 	log.Printf("🔗 Starting server on stdin/stdout...")
 
 	// Запускаем сервер через stdin/stdout
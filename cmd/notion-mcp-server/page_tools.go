@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// notionMaxChildren лимит блоков в одном запросе создания страницы или добавления блоков
+const notionMaxChildren = 100
+
+// copyFallbackWarning что теряется при перемещении копированием
+const copyFallbackWarning = "comments, nested blocks, child pages/databases and uploaded files are not copied, and the page gets a new ID and URL"
+
+// ArchivePageParams параметры архивации страницы
+type ArchivePageParams struct {
+	PageID  string `json:"page_id" mcp:"ID of the page to archive"`
+	Confirm bool   `json:"confirm,omitempty" mcp:"must be true to actually archive; without it the tool only describes the action"`
+}
+
+// MovePageParams параметры перемещения страницы
+type MovePageParams struct {
+	PageID            string `json:"page_id" mcp:"ID of the page to move"`
+	NewParentPageID   string `json:"new_parent_page_id" mcp:"ID of the new parent page"`
+	Confirm           bool   `json:"confirm,omitempty" mcp:"must be true to actually move; without it the tool only describes the action"`
+	AllowCopyFallback bool   `json:"allow_copy_fallback,omitempty" mcp:"if the API cannot move the page, copy it under the new parent and archive the original (loses comments and nested blocks)"`
+}
+
+// errMoveUnsupported Notion API не умеет менять родителя этой страницы
+var errMoveUnsupported = errors.New("moving pages is not supported by the Notion API for this page")
+
+// notionPage страница, на которую указывает ссылка на оригинал или копию
+type notionPage struct {
+	ID    string
+	Title string
+	URL   string
+}
+
+// archivePage переносит страницу в корзину Notion
+func (c *NotionAPIClient) archivePage(ctx context.Context, pageID string) error {
+	_, err := c.doNotionRequest(ctx, http.MethodPatch, "/pages/"+pageID, map[string]interface{}{"archived": true})
+	return err
+}
+
+// movePage меняет родителя страницы; errMoveUnsupported — API не поддерживает перемещение
+func (c *NotionAPIClient) movePage(ctx context.Context, pageID, newParentID string) error {
+	_, err := c.doNotionRequest(ctx, http.MethodPost, "/pages/"+pageID+"/move", map[string]interface{}{
+		"parent": map[string]interface{}{
+			"type":    "page_id",
+			"page_id": newParentID,
+		},
+	})
+	var apiErr *NotionAPIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusBadRequest, http.StatusNotFound, http.StatusMethodNotAllowed:
+			// 404 на самой странице тоже сюда попадёт, но тогда упадёт и чтение страницы в копировании
+			return fmt.Errorf("%w: %v", errMoveUnsupported, apiErr)
+		}
+	}
+	return err
+}
+
+// getPage читает заголовок и URL страницы
+func (c *NotionAPIClient) getPage(ctx context.Context, pageID string) (notionPage, error) {
+	respBody, err := c.doNotionRequest(ctx, http.MethodGet, "/pages/"+pageID, nil)
+	if err != nil {
+		return notionPage{}, err
+	}
+	var page struct {
+		ID         string `json:"id"`
+		URL        string `json:"url"`
+		Properties map[string]struct {
+			Type  string `json:"type"`
+			Title []struct {
+				PlainText string `json:"plain_text"`
+			} `json:"title"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(respBody, &page); err != nil {
+		return notionPage{}, fmt.Errorf("failed to parse page: %w", err)
+	}
+	result := notionPage{ID: page.ID, URL: page.URL, Title: "Untitled"}
+	for _, prop := range page.Properties {
+		if prop.Type != "title" {
+			continue
+		}
+		var title strings.Builder
+		for _, part := range prop.Title {
+			title.WriteString(part.PlainText)
+		}
+		if title.Len() > 0 {
+			result.Title = title.String()
+		}
+	}
+	return result, nil
+}
+
+// getBlockChildren читает все блоки первого уровня страницы
+func (c *NotionAPIClient) getBlockChildren(ctx context.Context, blockID string) ([]map[string]interface{}, error) {
+	var blocks []map[string]interface{}
+	cursor := ""
+	for {
+		endpoint := fmt.Sprintf("/blocks/%s/children?page_size=%d", blockID, notionMaxChildren)
+		if cursor != "" {
+			endpoint += "&start_cursor=" + url.QueryEscape(cursor)
+		}
+		respBody, err := c.doNotionRequest(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Results    []map[string]interface{} `json:"results"`
+			HasMore    bool                     `json:"has_more"`
+			NextCursor string                   `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(respBody, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse blocks: %w", err)
+		}
+		blocks = append(blocks, page.Results...)
+		if !page.HasMore || page.NextCursor == "" {
+			return blocks, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// appendBlocks добавляет блоки в конец страницы порциями по notionMaxChildren
+func (c *NotionAPIClient) appendBlocks(ctx context.Context, blockID string, blocks []map[string]interface{}) error {
+	for start := 0; start < len(blocks); start += notionMaxChildren {
+		end := start + notionMaxChildren
+		if end > len(blocks) {
+			end = len(blocks)
+		}
+		if _, err := c.doNotionRequest(ctx, http.MethodPatch, "/blocks/"+blockID+"/children", map[string]interface{}{
+			"children": blocks[start:end],
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyableBlocks убирает из блоков поля только для чтения; skipped — блоки, которые API не даёт создать,
+// nested — блоки, чьи вложенные блоки не будут скопированы
+func copyableBlocks(blocks []map[string]interface{}) (out []map[string]interface{}, skipped, nested int) {
+	for _, block := range blocks {
+		blockType, _ := block["type"].(string)
+		switch blockType {
+		case "", "child_page", "child_database", "unsupported", "link_preview", "synced_block", "template":
+			skipped++
+			continue
+		}
+		data, _ := block[blockType].(map[string]interface{})
+		if fileType, _ := data["type"].(string); fileType == "file" {
+			// Загруженные в Notion файлы нельзя создать через API, только внешние ссылки
+			skipped++
+			continue
+		}
+		if hasChildren, _ := block["has_children"].(bool); hasChildren {
+			nested++
+		}
+		out = append(out, map[string]interface{}{
+			"object":  "block",
+			"type":    blockType,
+			blockType: data,
+		})
+	}
+	return out, skipped, nested
+}
+
+// redirectBlock заметка в исходной странице о том, куда перенесено содержимое
+func redirectBlock(target notionPage) map[string]interface{} {
+	return map[string]interface{}{
+		"object": "block",
+		"type":   "callout",
+		"callout": map[string]interface{}{
+			"icon": map[string]interface{}{"type": "emoji", "emoji": "➡️"},
+			"rich_text": []map[string]interface{}{
+				{"type": "text", "text": map[string]interface{}{"content": "Page moved to: "}},
+				{"type": "text", "text": map[string]interface{}{"content": target.Title, "link": map[string]interface{}{"url": target.URL}}},
+			},
+		},
+	}
+}
+
+// copyThenArchive копирует страницу под нового родителя, оставляет в оригинале ссылку на копию и архивирует оригинал
+func (c *NotionAPIClient) copyThenArchive(ctx context.Context, pageID, newParentID string) (copied notionPage, skipped, nested int, err error) {
+	original, err := c.getPage(ctx, pageID)
+	if err != nil {
+		return notionPage{}, 0, 0, fmt.Errorf("failed to read page: %w", err)
+	}
+	blocks, err := c.getBlockChildren(ctx, pageID)
+	if err != nil {
+		return notionPage{}, 0, 0, fmt.Errorf("failed to read page content: %w", err)
+	}
+	children, skipped, nested := copyableBlocks(blocks)
+
+	first := children
+	if len(first) > notionMaxChildren {
+		first = first[:notionMaxChildren]
+	}
+	respBody, err := c.doNotionRequest(ctx, http.MethodPost, "/pages", map[string]interface{}{
+		"parent": map[string]interface{}{
+			"type":    "page_id",
+			"page_id": newParentID,
+		},
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"title": []map[string]interface{}{
+					{"text": map[string]interface{}{"content": original.Title}},
+				},
+			},
+		},
+		"children": first,
+	})
+	if err != nil {
+		return notionPage{}, skipped, nested, fmt.Errorf("failed to create copy: %w", err)
+	}
+	var created struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(respBody, &created); err != nil || created.ID == "" {
+		return notionPage{}, skipped, nested, fmt.Errorf("no page ID in copy response")
+	}
+	copied = notionPage{ID: created.ID, Title: original.Title, URL: created.URL}
+
+	if len(children) > len(first) {
+		if err := c.appendBlocks(ctx, copied.ID, children[len(first):]); err != nil {
+			return copied, skipped, nested, fmt.Errorf("copy %s created but content is incomplete, original kept: %w", copied.ID, err)
+		}
+	}
+	if err := c.appendBlocks(ctx, pageID, []map[string]interface{}{redirectBlock(copied)}); err != nil {
+		log.Printf("⚠️ MCP Server: failed to add redirect to %s: %v", pageID, err)
+	}
+	if err := c.archivePage(ctx, pageID); err != nil {
+		return copied, skipped, nested, fmt.Errorf("copy %s created but the original was not archived: %w", copied.ID, err)
+	}
+	return copied, skipped, nested, nil
+}
+
+// ArchivePage архивирует страницу Notion через MCP
+func (s *NotionMCPServer) ArchivePage(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[ArchivePageParams]) (*mcp.CallToolResultFor[any], error) {
+	args := params.Arguments
+
+	if args.PageID == "" {
+		return toolError("❌ page_id is required"), nil
+	}
+	if !args.Confirm {
+		return &mcp.CallToolResultFor[any]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("⚠️ Page %s will be moved to the Notion trash. Call archive_page again with confirm=true to proceed", args.PageID)},
+			},
+			Meta: map[string]interface{}{
+				"page_id":               args.PageID,
+				"requires_confirmation": true,
+				"success":               false,
+			},
+		}, nil
+	}
+
+	log.Printf("🗑️ MCP Server: Archiving Notion page %s", args.PageID)
+
+	if err := s.notionClient.archivePage(ctx, args.PageID); err != nil {
+		return toolError(fmt.Sprintf("❌ Failed to archive page: %v", err)), nil
+	}
+
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: fmt.Sprintf("✅ Page %s archived", args.PageID)},
+		},
+		Meta: map[string]interface{}{
+			"page_id":  args.PageID,
+			"archived": true,
+			"success":  true,
+		},
+	}, nil
+}
+
+// MovePage переносит страницу под другого родителя через MCP
+func (s *NotionMCPServer) MovePage(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[MovePageParams]) (*mcp.CallToolResultFor[any], error) {
+	args := params.Arguments
+
+	if args.PageID == "" || args.NewParentPageID == "" {
+		return toolError("❌ page_id and new_parent_page_id are required"), nil
+	}
+	if !args.Confirm {
+		text := fmt.Sprintf("⚠️ Page %s will be moved under %s. Call move_page again with confirm=true to proceed.", args.PageID, args.NewParentPageID)
+		if args.AllowCopyFallback {
+			text += " If the API cannot move the page, it will be copied and the original archived: " + copyFallbackWarning
+		}
+		return &mcp.CallToolResultFor[any]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+			Meta: map[string]interface{}{
+				"page_id":               args.PageID,
+				"new_parent_page_id":    args.NewParentPageID,
+				"requires_confirmation": true,
+				"success":               false,
+			},
+		}, nil
+	}
+
+	log.Printf("📦 MCP Server: Moving Notion page %s under %s", args.PageID, args.NewParentPageID)
+
+	err := s.notionClient.movePage(ctx, args.PageID, args.NewParentPageID)
+	if err == nil {
+		return &mcp.CallToolResultFor[any]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("✅ Page %s moved under %s", args.PageID, args.NewParentPageID)},
+			},
+			Meta: map[string]interface{}{
+				"page_id":            args.PageID,
+				"new_parent_page_id": args.NewParentPageID,
+				"method":             "move",
+				"success":            true,
+			},
+		}, nil
+	}
+	if !errors.Is(err, errMoveUnsupported) {
+		return toolError(fmt.Sprintf("❌ Failed to move page: %v", err)), nil
+	}
+	if !args.AllowCopyFallback {
+		return toolError(fmt.Sprintf("⚠️ The Notion API cannot move page %s. Call move_page with allow_copy_fallback=true to copy it under the new parent and archive the original: %s", args.PageID, copyFallbackWarning)), nil
+	}
+
+	log.Printf("📦 MCP Server: Native move unsupported for %s, falling back to copy-then-archive", args.PageID)
+	copied, skipped, nested, err := s.notionClient.copyThenArchive(ctx, args.PageID, args.NewParentPageID)
+	if err != nil {
+		return toolError(fmt.Sprintf("❌ Failed to move page by copying: %v", err)), nil
+	}
+
+	text := fmt.Sprintf("✅ Page copied under %s as %s and the original %s archived with a redirect to the copy.\n⚠️ Comments were not copied", args.NewParentPageID, copied.ID, args.PageID)
+	if skipped > 0 {
+		text += fmt.Sprintf("; %d blocks could not be copied", skipped)
+	}
+	if nested > 0 {
+		text += fmt.Sprintf("; nested content of %d blocks was not copied", nested)
+	}
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+		Meta: map[string]interface{}{
+			"page_id":            copied.ID,
+			"url":                copied.URL,
+			"redirect_from":      args.PageID,
+			"new_parent_page_id": args.NewParentPageID,
+			"method":             "copy_archive",
+			"skipped_blocks":     skipped,
+			"nested_blocks_lost": nested,
+			"success":            true,
+		},
+	}, nil
+}
+
+func toolError(text string) *mcp.CallToolResultFor[any] {
+	return &mcp.CallToolResultFor[any]{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
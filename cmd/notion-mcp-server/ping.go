@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// PingParams ping не принимает параметров
+type PingParams struct{}
+
+// Ping проверяет NOTION_TOKEN запросом GET /users/me: OK с именем интеграции или ошибка авторизации
+func (s *NotionMCPServer) Ping(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[PingParams]) (*mcp.CallToolResultFor[any], error) {
+	log.Printf("🏓 MCP Server: Checking Notion credentials")
+
+	respBody, err := s.notionClient.doNotionRequest(ctx, http.MethodGet, "/users/me", nil)
+	if err != nil {
+		text := fmt.Sprintf("❌ Notion API request failed: %v", err)
+		var apiErr *NotionAPIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+			text = fmt.Sprintf("❌ Notion authentication failed, check NOTION_TOKEN: %v", apiErr)
+		}
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{&mcp.TextContent{Text: text}},
+			Meta:    map[string]interface{}{"ok": false},
+		}, nil
+	}
+
+	var me struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(respBody, &me); err != nil {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("❌ Failed to parse Notion response: %v", err)}},
+			Meta:    map[string]interface{}{"ok": false},
+		}, nil
+	}
+
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("✅ OK: authenticated as %s (%s)", me.Name, me.Type)}},
+		Meta: map[string]interface{}{
+			"ok":      true,
+			"user_id": me.ID,
+			"name":    me.Name,
+		},
+	}, nil
+}
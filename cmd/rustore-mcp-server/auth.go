@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	rustoreAuthURL = "https://public-api.rustore.ru/public/auth"
+	// tokenRefreshMargin токен обновляется заранее, чтобы не истёк посреди запроса
+	tokenRefreshMargin = 30 * time.Second
+)
+
+// RuStoreCredentials ключ API компании из консоли RuStore: KeySecret — приватный RSA ключ
+// (base64 DER или PEM), которым подписывается запрос токена
+type RuStoreCredentials struct {
+	CompanyID string
+	KeyID     string
+	KeySecret string
+}
+
+// configured все три поля заданы
+func (c RuStoreCredentials) configured() bool {
+	return c.CompanyID != "" && c.KeyID != "" && c.KeySecret != ""
+}
+
+// rustoreAuthRequest тело POST /auth
+type rustoreAuthRequest struct {
+	CompanyID string `json:"companyId"`
+	KeyID     string `json:"keyId"`
+	Timestamp string `json:"timestamp"`
+	Signature string `json:"signature"`
+}
+
+// rustoreAuthResponse ответ /auth: body.jwe — токен, body.ttl — время жизни в секундах
+type rustoreAuthResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Body    struct {
+		JWE string `json:"jwe"`
+		TTL int    `json:"ttl"`
+	} `json:"body"`
+}
+
+// SetCredentials включает получение короткоживущих токенов по ключу компании вместо RUSTORE_KEY
+func (r *RuStoreMCPServer) SetCredentials(creds RuStoreCredentials) error {
+	if !creds.configured() {
+		return nil
+	}
+	key, err := parseRuStorePrivateKey(creds.KeySecret)
+	if err != nil {
+		return fmt.Errorf("RUSTORE_KEY_SECRET: %w", err)
+	}
+	r.tokenMu.Lock()
+	defer r.tokenMu.Unlock()
+	r.creds = creds
+	r.privateKey = key
+	// Статический токен больше не используется: первый запрос получит JWT
+	r.accessToken = ""
+	r.tokenExpiry = time.Time{}
+	return nil
+}
+
+// usesCredentials токен получается по ключу компании, а не берётся из RUSTORE_KEY
+func (r *RuStoreMCPServer) usesCredentials() bool {
+	return r.privateKey != nil
+}
+
+// token текущий токен для заголовка Public-Token
+func (r *RuStoreMCPServer) token() string {
+	r.tokenMu.Lock()
+	defer r.tokenMu.Unlock()
+	return r.accessToken
+}
+
+// refreshToken подписывает keyId+timestamp приватным ключом и получает новый токен в /auth
+func (r *RuStoreMCPServer) refreshToken(ctx context.Context) error {
+	timestamp := time.Now().Format(time.RFC3339)
+	digest := sha512.Sum512([]byte(r.creds.KeyID + timestamp))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, r.privateKey, crypto.SHA512, digest[:])
+	if err != nil {
+		return fmt.Errorf("sign auth request: %w", err)
+	}
+	payload, err := json.Marshal(rustoreAuthRequest{
+		CompanyID: r.creds.CompanyID,
+		KeyID:     r.creds.KeyID,
+		Timestamp: timestamp,
+		Signature: base64.StdEncoding.EncodeToString(signature),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.authURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ai-chatter-rustore-mcp/1.0.0")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("auth request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("auth request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var authResp rustoreAuthResponse
+	if err := json.Unmarshal(respBody, &authResp); err != nil {
+		return fmt.Errorf("parse auth response: %w", err)
+	}
+	if authResp.Body.JWE == "" {
+		return fmt.Errorf("auth response without token: %s %s", authResp.Code, authResp.Message)
+	}
+
+	ttl := time.Duration(authResp.Body.TTL) * time.Second
+	if ttl > 2*tokenRefreshMargin {
+		ttl -= tokenRefreshMargin
+	}
+	r.accessToken = authResp.Body.JWE
+	r.tokenExpiry = time.Now().Add(ttl)
+	log.Printf("🔑 RuStore token refreshed for key %s, valid until %s", r.creds.KeyID, r.tokenExpiry.Format("15:04:05"))
+	return nil
+}
+
+// parseRuStorePrivateKey приватный ключ из консоли RuStore: base64 PKCS#8 без обёртки или PEM
+func parseRuStorePrivateKey(secret string) (*rsa.PrivateKey, error) {
+	secret = strings.TrimSpace(secret)
+	var der []byte
+	if block, _ := pem.Decode([]byte(secret)); block != nil {
+		der = block.Bytes
+	} else {
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(secret), ""))
+		if err != nil {
+			return nil, fmt.Errorf("private key is neither PEM nor base64: %w", err)
+		}
+		der = decoded
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("private key is not RSA")
+		}
+		return rsaKey, nil
+	}
+	key, err := x509.ParsePKCS1PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	return key, nil
+}
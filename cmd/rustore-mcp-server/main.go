@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/rsa"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -113,19 +115,28 @@ type RuStoreAppListResponse struct {
 
 // RuStoreMCPServer кастомный MCP сервер для RuStore
 type RuStoreMCPServer struct {
-	client      *http.Client
+	client  *http.Client
+	baseURL string
+	authURL string
+
+	tokenMu     sync.Mutex
 	accessToken string
 	tokenExpiry time.Time
-	baseURL     string
+	// ключ компании (SetCredentials); без него используется статический RUSTORE_KEY
+	creds      RuStoreCredentials
+	privateKey *rsa.PrivateKey
 }
 
+// testTokenPlaceholder токен-заглушка, когда RUSTORE_KEY не задан
+const testTokenPlaceholder = "test-token-placeholder"
+
 // NewRuStoreMCPServer создает новый MCP сервер для RuStore с готовым токеном
 func NewRuStoreMCPServer(token string) (*RuStoreMCPServer, error) {
 	log.Printf("🔑 Initializing RuStore MCP Server with token-based auth")
 
 	if token == "" {
 		log.Printf("⚠️ RUSTORE_KEY not set, using test mode")
-		token = "test-token-placeholder"
+		token = testTokenPlaceholder
 	}
 
 	// Токен готов к использованию, устанавливаем время истечения в будущем
@@ -134,13 +145,25 @@ func NewRuStoreMCPServer(token string) (*RuStoreMCPServer, error) {
 	return &RuStoreMCPServer{
 		client:      &http.Client{Timeout: 60 * time.Second},
 		baseURL:     "https://public-api.rustore.ru/public/v1",
+		authURL:     rustoreAuthURL,
 		accessToken: token,
 		tokenExpiry: tokenExpiry,
 	}, nil
 }
 
-// authenticate проверяет действительность токена (теперь токен приходит готовым)
+// authenticate проверяет действительность токена: по ключу компании получает новый JWT, когда
+// старый истёк, иначе использует готовый токен из RUSTORE_KEY
 func (r *RuStoreMCPServer) authenticate(ctx context.Context) error {
+	r.tokenMu.Lock()
+	defer r.tokenMu.Unlock()
+
+	if r.usesCredentials() {
+		if r.accessToken != "" && !time.Now().After(r.tokenExpiry) {
+			return nil
+		}
+		return r.refreshToken(ctx)
+	}
+
 	// Проверяем, есть ли действующий токен
 	if r.accessToken != "" && time.Now().Before(r.tokenExpiry) {
 		log.Printf("✅ Using existing valid RUSTORE_KEY token")
@@ -163,7 +186,7 @@ func (r *RuStoreMCPServer) makeAuthorizedRequest(ctx context.Context, method, ur
 		return nil, err
 	}
 
-	req.Header.Set("Public-Token", r.accessToken)
+	req.Header.Set("Public-Token", r.token())
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "ai-chatter-rustore-mcp/1.0.0")
 
@@ -644,9 +667,19 @@ func (r *RuStoreMCPServer) Authenticate(ctx context.Context, session *mcp.Server
 		}, nil
 	}
 
+	r.tokenMu.Lock()
+	method, expiry := "rustore_key_env", r.tokenExpiry
+	if r.usesCredentials() {
+		method = "company_key"
+	}
+	r.tokenMu.Unlock()
+
 	resultMessage := "✅ Using RUSTORE_KEY token from environment\n"
-	resultMessage += "**Note:** rustore_auth tool is deprecated. Set RUSTORE_KEY in .env file.\n"
-	resultMessage += fmt.Sprintf("**Token valid until:** %s\n", r.tokenExpiry.Format("2006-01-02 15:04:05"))
+	if method == "company_key" {
+		resultMessage = "✅ Using RuStore token issued for RUSTORE_KEY_ID (refreshed automatically)\n"
+	}
+	resultMessage += "**Note:** rustore_auth tool is deprecated. Configure credentials in .env file.\n"
+	resultMessage += fmt.Sprintf("**Token valid until:** %s\n", expiry.Format("2006-01-02 15:04:05"))
 
 	return &mcp.CallToolResultFor[any]{
 		Content: []mcp.Content{
@@ -654,8 +687,8 @@ func (r *RuStoreMCPServer) Authenticate(ctx context.Context, session *mcp.Server
 		},
 		Meta: map[string]interface{}{
 			"success":      true,
-			"method":       "rustore_key_env",
-			"token_expiry": r.tokenExpiry,
+			"method":       method,
+			"token_expiry": expiry,
 		},
 	}, nil
 }
@@ -672,6 +705,14 @@ func main() {
 	if err != nil {
 		log.Fatalf("❌ Failed to create RuStore server: %v", err)
 	}
+	// Ключ компании важнее статического токена: JWT обновляется сам по истечении
+	if err := rustoreServer.SetCredentials(RuStoreCredentials{
+		CompanyID: os.Getenv("RUSTORE_COMPANY_ID"),
+		KeyID:     os.Getenv("RUSTORE_KEY_ID"),
+		KeySecret: os.Getenv("RUSTORE_KEY_SECRET"),
+	}); err != nil {
+		log.Fatalf("❌ Invalid RuStore credentials: %v", err)
+	}
 
 	// Создаем MCP сервер
 	server := mcp.NewServer(&mcp.Implementation{
@@ -700,6 +741,11 @@ func main() {
 		Description: "Uploads APK file for a draft version in RuStore",
 	}, rustoreServer.UploadAPK)
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "rustore_upload_media",
+		Description: "Uploads an app icon or screenshot (media_type: icon|screenshot) for a draft version in RuStore",
+	}, rustoreServer.UploadMedia)
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "rustore_submit_review",
 		Description: "Submits application version for moderation in RuStore",
@@ -710,7 +756,17 @@ func main() {
 		Description: "Gets list of applications from RuStore for automation",
 	}, rustoreServer.GetAppList)
 
-	log.Printf("📋 Registered RuStore MCP tools: rustore_auth, rustore_create_draft, rustore_upload_aab, rustore_upload_apk, rustore_submit_review, rustore_get_apps")
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "rustore_get_versions",
+		Description: "Lists versions of a RuStore application (id, versionName, status, creation date)",
+	}, rustoreServer.GetVersions)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "ping",
+		Description: "Checks RuStore token validity with a lightweight authenticated call (one-item application list) and returns OK or the authentication error",
+	}, rustoreServer.Ping)
+
+	log.Printf("📋 Registered RuStore MCP tools: rustore_auth, rustore_create_draft, rustore_upload_aab, rustore_upload_apk, rustore_upload_media, rustore_submit_review, rustore_get_apps, rustore_get_versions, ping")
 	log.Printf("🔗 Starting RuStore MCP server on stdin/stdout...")
 
 	// Запускаем сервер через stdin/stdout
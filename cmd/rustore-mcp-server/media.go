@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// rustoreMediaTypes типы изображений версии и соответствующий суффикс endpoint'а /image/{type}
+var rustoreMediaTypes = map[string]string{
+	"icon":       "icon",
+	"screenshot": "screenshot",
+}
+
+// RuStoreUploadMediaParams параметры для загрузки иконки или скриншота
+type RuStoreUploadMediaParams struct {
+	AppID     string `json:"app_id" mcp:"RuStore application ID"`
+	VersionID string `json:"version_id" mcp:"version ID from draft creation"`
+	MediaType string `json:"media_type" mcp:"media type: icon or screenshot"`
+	Content   string `json:"content" mcp:"base64-encoded image content (PNG or JPEG)"`
+	FileName  string `json:"file_name" mcp:"image file name, e.g. icon.png"`
+}
+
+// RuStoreMediaResponse ответ на загрузку изображения; body — ID загруженного файла
+// (число или объект с id в зависимости от версии API)
+type RuStoreMediaResponse struct {
+	Code      string          `json:"code"`
+	Message   string          `json:"message"`
+	Body      json.RawMessage `json:"body"`
+	Timestamp string          `json:"timestamp"`
+}
+
+// uploadMultipart отправляет файл multipart/form-data запросом в поле field
+func (r *RuStoreMCPServer) uploadMultipart(ctx context.Context, url, field, fileName string, data []byte) (*http.Response, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, err := writer.CreateFormFile(field, fileName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Public-Token", r.token())
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("User-Agent", "ai-chatter-rustore-mcp/1.0.0")
+
+	return r.client.Do(req)
+}
+
+// UploadMedia загружает иконку или скриншот для версии
+func (r *RuStoreMCPServer) UploadMedia(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[RuStoreUploadMediaParams]) (*mcp.CallToolResultFor[any], error) {
+	args := params.Arguments
+	mediaType := strings.ToLower(strings.TrimSpace(args.MediaType))
+
+	log.Printf("🖼️ MCP Server: Uploading %s %s for app %s, version %s", mediaType, args.FileName, args.AppID, args.VersionID)
+
+	endpoint, ok := rustoreMediaTypes[mediaType]
+	if !ok {
+		return mediaError("❌ Unsupported media_type %q: expected icon or screenshot", args.MediaType), nil
+	}
+	if args.AppID == "" || args.VersionID == "" || args.FileName == "" {
+		return mediaError("❌ app_id, version_id and file_name are required"), nil
+	}
+	data, err := base64.StdEncoding.DecodeString(args.Content)
+	if err != nil {
+		return mediaError("❌ content is not valid base64: %v", err), nil
+	}
+	if len(data) == 0 {
+		return mediaError("❌ content is empty"), nil
+	}
+
+	// Проверяем токен из RUSTORE_KEY
+	if err := r.authenticate(ctx); err != nil {
+		return mediaError("❌ RUSTORE_KEY authentication failed: %v", err), nil
+	}
+
+	uploadURL := fmt.Sprintf("%s/application/%s/version/%s/image/%s", r.baseURL, args.AppID, args.VersionID, endpoint)
+	resp, err := r.uploadMultipart(ctx, uploadURL, "file", args.FileName, data)
+	if err != nil {
+		return mediaError("❌ %s upload request failed: %v", mediaType, err), nil
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return mediaError("❌ %s upload failed with status %d: %s", mediaType, resp.StatusCode, string(respBody)), nil
+	}
+
+	var mediaResp RuStoreMediaResponse
+	if err := json.Unmarshal(respBody, &mediaResp); err != nil {
+		log.Printf("⚠️ Failed to parse %s upload response: %v", mediaType, err)
+	}
+	mediaID := parseMediaID(mediaResp.Body)
+
+	resultMessage := fmt.Sprintf("✅ Successfully uploaded %s %s\n", mediaType, args.FileName)
+	resultMessage += fmt.Sprintf("**App ID:** %s\n", args.AppID)
+	resultMessage += fmt.Sprintf("**Version ID:** %s\n", args.VersionID)
+	if mediaID != "" {
+		resultMessage += fmt.Sprintf("**Media ID:** %s\n", mediaID)
+	}
+
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: resultMessage},
+		},
+		Meta: map[string]interface{}{
+			"success":    true,
+			"app_id":     args.AppID,
+			"version_id": args.VersionID,
+			"media_type": mediaType,
+			"file_name":  args.FileName,
+			"media_id":   mediaID,
+			"code":       mediaResp.Code,
+		},
+	}, nil
+}
+
+// parseMediaID ID загруженного файла из body ответа: число, строка или объект с id/fileId/imageId
+func parseMediaID(body json.RawMessage) string {
+	if len(body) == 0 || string(body) == "null" {
+		return ""
+	}
+	var scalar any
+	if err := json.Unmarshal(body, &scalar); err != nil {
+		return ""
+	}
+	obj, ok := scalar.(map[string]any)
+	if !ok {
+		return parseMediaIDValue(scalar)
+	}
+	for _, key := range []string{"id", "fileId", "imageId"} {
+		if id := parseMediaIDValue(obj[key]); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+func parseMediaIDValue(v any) string {
+	switch id := v.(type) {
+	case float64:
+		return fmt.Sprintf("%.0f", id)
+	case string:
+		return id
+	}
+	return ""
+}
+
+func mediaError(format string, args ...any) *mcp.CallToolResultFor[any] {
+	return &mcp.CallToolResultFor[any]{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: fmt.Sprintf(format, args...)},
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// PingParams ping не принимает параметров
+type PingParams struct{}
+
+// Ping проверяет токен RuStore: по ключу компании получает JWT, затем делает GET /application
+// с одним элементом на странице; возвращает OK или ошибку авторизации
+func (r *RuStoreMCPServer) Ping(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[PingParams]) (*mcp.CallToolResultFor[any], error) {
+	log.Printf("🏓 MCP Server: Checking RuStore credentials")
+
+	if !r.usesCredentials() && r.token() == testTokenPlaceholder {
+		return rustorePingError("❌ RuStore credentials are not set: configure RUSTORE_KEY or RUSTORE_COMPANY_ID, RUSTORE_KEY_ID and RUSTORE_KEY_SECRET"), nil
+	}
+	if err := r.authenticate(ctx); err != nil {
+		return rustorePingError(fmt.Sprintf("❌ RuStore authentication failed: %v", err)), nil
+	}
+
+	resp, err := r.makeAuthorizedRequest(ctx, http.MethodGet, fmt.Sprintf("%s/application?pageSize=1", r.baseURL), nil)
+	if err != nil {
+		return rustorePingError(fmt.Sprintf("❌ RuStore API request failed: %v", err)), nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return rustorePingError(fmt.Sprintf("❌ RuStore token rejected (%d): %s", resp.StatusCode, string(respBody))), nil
+	}
+
+	mode := "RUSTORE_KEY token"
+	if r.usesCredentials() {
+		mode = "company key " + r.creds.KeyID
+	}
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("✅ OK: RuStore API accepts the %s", mode)}},
+		Meta: map[string]interface{}{
+			"ok":   true,
+			"auth": mode,
+		},
+	}, nil
+}
+
+func rustorePingError(text string) *mcp.CallToolResultFor[any] {
+	return &mcp.CallToolResultFor[any]{
+		IsError: true,
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+		Meta:    map[string]interface{}{"ok": false},
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// RuStoreGetVersionsParams параметры для получения списка версий приложения
+type RuStoreGetVersionsParams struct {
+	AppID    string `json:"app_id" mcp:"RuStore application ID (package name)"`
+	PageSize int    `json:"page_size,omitempty" mcp:"Количество версий на странице (1-100)"`
+}
+
+// RuStoreVersion версия приложения из RuStore
+type RuStoreVersion struct {
+	VersionID     json.RawMessage `json:"versionId"` // число или строка в зависимости от версии API
+	VersionName   string          `json:"versionName"`
+	VersionCode   int             `json:"versionCode,omitempty"`
+	VersionStatus string          `json:"versionStatus"`
+	PublishType   string          `json:"publishType,omitempty"`
+	CreatedDate   string          `json:"createdDate,omitempty"`
+	CreateDate    string          `json:"createDate,omitempty"`
+}
+
+// RuStoreVersionListResponse ответ на получение списка версий: body содержит страницу версий
+type RuStoreVersionListResponse struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// created дата создания версии: поле называется по-разному в разных версиях API
+func (v RuStoreVersion) created() string {
+	if v.CreatedDate != "" {
+		return v.CreatedDate
+	}
+	return v.CreateDate
+}
+
+// GetVersions получает список версий приложения
+func (r *RuStoreMCPServer) GetVersions(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[RuStoreGetVersionsParams]) (*mcp.CallToolResultFor[any], error) {
+	args := params.Arguments
+
+	log.Printf("🗂️ MCP Server: Getting RuStore versions for app %s", args.AppID)
+
+	if args.AppID == "" {
+		return mediaError("❌ app_id is required"), nil
+	}
+
+	// Проверяем токен из RUSTORE_KEY
+	if err := r.authenticate(ctx); err != nil {
+		return mediaError("❌ RUSTORE_KEY authentication failed: %v", err), nil
+	}
+
+	versionsURL := fmt.Sprintf("%s/application/%s/version", r.baseURL, args.AppID)
+	if args.PageSize > 0 {
+		versionsURL += fmt.Sprintf("?pageSize=%d", args.PageSize)
+	}
+
+	resp, err := r.makeAuthorizedRequest(ctx, http.MethodGet, versionsURL, nil)
+	if err != nil {
+		return mediaError("❌ Version list request failed: %v", err), nil
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return mediaError("❌ Version list request failed with status %d: %s", resp.StatusCode, string(respBody)), nil
+	}
+
+	rawVersions, err := parseVersionList(respBody)
+	if err != nil {
+		return mediaError("❌ Failed to parse version list response: %v", err), nil
+	}
+
+	var resultMessage strings.Builder
+	versionsMeta := make([]map[string]interface{}, 0, len(rawVersions))
+	if len(rawVersions) == 0 {
+		resultMessage.WriteString(fmt.Sprintf("ℹ️ No versions found for app %s\n", args.AppID))
+	} else {
+		resultMessage.WriteString(fmt.Sprintf("✅ Found %d versions for app %s\n\n", len(rawVersions), args.AppID))
+	}
+
+	for i, raw := range rawVersions {
+		var version RuStoreVersion
+		if err := json.Unmarshal(raw, &version); err != nil {
+			log.Printf("⚠️ Failed to parse RuStore version: %v", err)
+			continue
+		}
+		versionID := parseMediaID(version.VersionID)
+
+		resultMessage.WriteString(fmt.Sprintf("**%d. %s**", i+1, firstNonEmpty(version.VersionName, "(no name)")))
+		if version.VersionCode > 0 {
+			resultMessage.WriteString(fmt.Sprintf(" (code %d)", version.VersionCode))
+		}
+		resultMessage.WriteString("\n")
+		resultMessage.WriteString(fmt.Sprintf("   🆔 Version ID: `%s`\n", versionID))
+		resultMessage.WriteString(fmt.Sprintf("   📊 Status: %s\n", firstNonEmpty(version.VersionStatus, "unknown")))
+		if created := version.created(); created != "" {
+			resultMessage.WriteString(fmt.Sprintf("   📅 Created: %s\n", created))
+		}
+		resultMessage.WriteString("\n")
+
+		// Сохраняем версию как пришла от API, добавляя нормализованный version_id
+		var versionMeta map[string]interface{}
+		if err := json.Unmarshal(raw, &versionMeta); err != nil || versionMeta == nil {
+			versionMeta = map[string]interface{}{}
+		}
+		versionMeta["version_id"] = versionID
+		versionsMeta = append(versionsMeta, versionMeta)
+	}
+
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: resultMessage.String()},
+		},
+		Meta: map[string]interface{}{
+			"success":        true,
+			"app_id":         args.AppID,
+			"versions_count": len(versionsMeta),
+			"versions":       versionsMeta,
+		},
+	}, nil
+}
+
+// parseVersionList версии из ответа: body — страница с content, массив версий или null
+func parseVersionList(respBody []byte) ([]json.RawMessage, error) {
+	var listResp RuStoreVersionListResponse
+	if err := json.Unmarshal(respBody, &listResp); err != nil {
+		return nil, err
+	}
+	body := strings.TrimSpace(string(listResp.Body))
+	if body == "" || body == "null" {
+		return nil, nil
+	}
+	if strings.HasPrefix(body, "[") {
+		var versions []json.RawMessage
+		err := json.Unmarshal(listResp.Body, &versions)
+		return versions, err
+	}
+	var page struct {
+		Content []json.RawMessage `json:"content"`
+	}
+	err := json.Unmarshal(listResp.Body, &page)
+	return page.Content, err
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -67,7 +67,10 @@ func main() {
 
 	fmt.Printf("✅ Using test page ID: %s\n", testPageID)
 
-	dialogResult := mcpClient.CreateDialogSummary(
+	// Созданные тестом страницы архивируются в конце, чтобы повторные запуски не засоряли workspace
+	var createdPages []string
+
+	dialogResult, err := mcpClient.CreateDialogSummary(
 		ctx,
 		"Test Dialog from Custom MCP",
 		"This is a test dialog created through our custom MCP server.",
@@ -77,19 +80,20 @@ func main() {
 		testPageID,
 	)
 
-	if !dialogResult.Success {
-		fmt.Printf("❌ Dialog save failed: %s\n", dialogResult.Message)
+	if err != nil {
+		fmt.Printf("❌ Dialog save failed [%s]: %v\n", notion.CategoryOf(err), err)
 	} else {
 		fmt.Printf("✅ Dialog saved: %s\n", dialogResult.Message)
-		if dialogResult.PageID != "" {
-			fmt.Printf("📄 Page ID: %s\n", dialogResult.PageID)
+		if dialogResult.Page.ID != "" {
+			fmt.Printf("📄 Page: %s (%s)\n", dialogResult.Page.ID, dialogResult.Page.URL)
+			createdPages = append(createdPages, dialogResult.Page.ID)
 		}
 	}
 
 	// Тестируем создание произвольной страницы
 	fmt.Println("\n📄 Testing free-form page creation...")
 
-	pageResult := mcpClient.CreateFreeFormPage(
+	pageResult, err := mcpClient.CreateFreeFormPage(
 		ctx,
 		"Custom MCP Test Page",
 		"# Custom MCP Integration Test\n\nThis page was created using our custom Notion MCP server built with Go and the official MCP SDK.\n\n## Features\n- Direct Notion API integration\n- MCP protocol compliance\n- Go-based implementation\n- Official SDK usage",
@@ -97,22 +101,23 @@ func main() {
 		[]string{"test", "mcp", "custom"},
 	)
 
-	if !pageResult.Success {
-		fmt.Printf("❌ Page creation failed: %s\n", pageResult.Message)
+	if err != nil {
+		fmt.Printf("❌ Page creation failed [%s]: %v\n", notion.CategoryOf(err), err)
 	} else {
 		fmt.Printf("✅ Page created: %s\n", pageResult.Message)
-		if pageResult.PageID != "" {
-			fmt.Printf("📄 Page ID: %s\n", pageResult.PageID)
+		if pageResult.Page.ID != "" {
+			fmt.Printf("📄 Page: %s (%s)\n", pageResult.Page.ID, pageResult.Page.URL)
+			createdPages = append(createdPages, pageResult.Page.ID)
 		}
 	}
 
 	// Тестируем поиск
 	fmt.Println("\n🔍 Testing search functionality...")
 
-	searchResult := mcpClient.SearchDialogSummaries(ctx, "AI", "", "")
+	searchResult, err := mcpClient.SearchDialogSummaries(ctx, "AI", "", "")
 
-	if !searchResult.Success {
-		fmt.Printf("❌ Search failed: %s\n", searchResult.Message)
+	if err != nil {
+		fmt.Printf("❌ Search failed [%s]: %v\n", notion.CategoryOf(err), err)
 	} else {
 		fmt.Printf("✅ Search completed: %s\n", searchResult.Message)
 	}
@@ -120,16 +125,16 @@ func main() {
 	// Тестируем поиск страниц с ID
 	fmt.Println("\n🆔 Testing search pages with ID...")
 
-	pageSearchResult := mcpClient.SearchPagesWithID(ctx, "Test", 5, false)
+	pageSearchResult, err := mcpClient.SearchPagesWithID(ctx, "Test", 5, false)
 
-	if !pageSearchResult.Success {
-		fmt.Printf("❌ Page search failed: %s\n", pageSearchResult.Message)
+	if err != nil {
+		fmt.Printf("❌ Page search failed [%s]: %v\n", notion.CategoryOf(err), err)
 	} else {
 		fmt.Printf("✅ Page search completed: %s\n", pageSearchResult.Message)
 		if len(pageSearchResult.Pages) > 0 {
 			fmt.Printf("📋 Found %d pages:\n", len(pageSearchResult.Pages))
 			for i, page := range pageSearchResult.Pages {
-				fmt.Printf("   %d. %s (ID: %s)\n", i+1, page.Title, page.ID)
+				fmt.Printf("   %d. %s (ID: %s) %s\n", i+1, page.Title, page.ID, page.URL)
 			}
 		}
 	}
@@ -137,10 +142,10 @@ func main() {
 	// Тестируем список доступных страниц
 	fmt.Println("\n📋 Testing list available pages...")
 
-	availablePagesResult := mcpClient.ListAvailablePages(ctx, 10, "", false)
+	availablePagesResult, err := mcpClient.ListAvailablePages(ctx, 10, "", false)
 
-	if !availablePagesResult.Success {
-		fmt.Printf("❌ List available pages failed: %s\n", availablePagesResult.Message)
+	if err != nil {
+		fmt.Printf("❌ List available pages failed [%s]: %v\n", notion.CategoryOf(err), err)
 	} else {
 		fmt.Printf("✅ List available pages completed: %s\n", availablePagesResult.Message)
 		if len(availablePagesResult.Pages) > 0 {
@@ -158,6 +163,17 @@ func main() {
 		}
 	}
 
+	// Убираем созданные страницы в корзину
+	fmt.Printf("\n🗑️ Archiving %d test pages...\n", len(createdPages))
+	for _, pageID := range createdPages {
+		archiveResult, err := mcpClient.ArchivePage(ctx, pageID)
+		if err != nil {
+			fmt.Printf("❌ Archive %s failed [%s]: %v\n", pageID, notion.CategoryOf(err), err)
+		} else {
+			fmt.Printf("✅ Archived: %s\n", archiveResult.Page.ID)
+		}
+	}
+
 	// Закрываем соединение
 	mcpClient.Close()
 
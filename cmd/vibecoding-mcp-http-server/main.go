@@ -96,45 +96,63 @@ func registerVibeCodingTools(server *mcp.Server) {
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "vibe_list_files",
 		Description: "Lists files in the VibeCoding workspace for the specified user",
-	}, vibeCodingServer.ListFiles)
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_list_files", vibeCodingServer.ListFiles))
 
 	// Read file tool
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "vibe_read_file",
 		Description: "Reads the content of a file in the VibeCoding workspace",
-	}, vibeCodingServer.ReadFile)
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_read_file", vibeCodingServer.ReadFile))
 
 	// Write file tool
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "vibe_write_file",
-		Description: "Writes content to a file in the VibeCoding workspace. Set generated=true for AI-generated files.",
-	}, vibeCodingServer.WriteFile)
+		Description: "Writes content to a file in the VibeCoding workspace. Set generated=true for AI-generated files; they never replace original project files unless overwrite_original=true.",
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_write_file", vibeCodingServer.WriteFile))
+
+	// Rename file tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "vibe_rename_file",
+		Description: "Renames or moves a file (old_path -> new_path) in the VibeCoding workspace, keeping whether it is an original or generated file. Fails if new_path already exists.",
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_rename_file", vibecoding.RenameFileToolHandler(vibeCodingServer.sessionManager)))
+
+	// Git commit tool
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "vibe_git_commit",
+		Description: "Commits the current VibeCoding workspace (git init if needed, git add -A, git commit -m message) and returns the commit hash",
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_git_commit", vibecoding.GitCommitToolHandler(vibeCodingServer.sessionManager)))
 
 	// Execute command tool
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "vibe_execute_command",
 		Description: "Executes a command in the VibeCoding environment",
-	}, vibeCodingServer.ExecuteCommand)
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_execute_command", vibeCodingServer.ExecuteCommand))
 
 	// Validate code tool
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "vibe_validate_code",
 		Description: "Validates code in a specific file using the VibeCoding validation system",
-	}, vibeCodingServer.ValidateCode)
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_validate_code", vibeCodingServer.ValidateCode))
 
 	// Run tests tool
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "vibe_run_tests",
 		Description: "Runs tests for the VibeCoding project using the configured test command. Set validate_and_fix=true to automatically validate generated tests and fix failures.",
-	}, vibeCodingServer.RunTests)
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_run_tests", vibeCodingServer.RunTests))
 
 	// Get session info tool
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "vibe_get_session_info",
 		Description: "Gets information about the VibeCoding session for the specified user",
-	}, vibeCodingServer.GetSessionInfo)
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_get_session_info", vibeCodingServer.GetSessionInfo))
+
+	// Tool metrics
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "vibe_get_metrics",
+		Description: "Returns per-tool call counts, error counts by category, p50/p95 latency and last error for VibeCoding MCP tools",
+	}, vibecoding.MetricsToolHandler(vibecoding.DefaultToolMetrics))
 
-	log.Printf("📋 Registered 7 VibeCoding HTTP MCP tools")
+	log.Printf("📋 Registered 10 VibeCoding HTTP MCP tools")
 }
 
 // Implementation of all MCP tools (same logic as stdio version)
@@ -322,7 +340,8 @@ func (s *VibeCodingMCPHTTPServer) WriteFile(ctx context.Context, session *mcp.Se
 		}, nil
 	}
 
-	generated, _ := params.Arguments["generated"].(bool)
+	opts := vibecoding.WriteFileOptionsFromArgs(params.Arguments)
+	generated := opts.Generated
 
 	userID, err := vibecoding.ParseUserID(userIDArg)
 	if err != nil {
@@ -334,7 +353,7 @@ func (s *VibeCodingMCPHTTPServer) WriteFile(ctx context.Context, session *mcp.Se
 		}, nil
 	}
 
-	log.Printf("✏️ HTTP MCP Server: Writing file %s for user %d (generated: %t)", filename, userID, generated)
+	log.Printf("✏️ HTTP MCP Server: Writing file %s for user %d (generated: %t, overwrite_original: %t)", filename, userID, generated, opts.OverwriteOriginal)
 
 	vibeCodingSession := s.sessionManager.GetSession(userID)
 	if vibeCodingSession == nil {
@@ -346,7 +365,7 @@ func (s *VibeCodingMCPHTTPServer) WriteFile(ctx context.Context, session *mcp.Se
 		}, nil
 	}
 
-	err = vibeCodingSession.WriteFile(ctx, filename, content, generated)
+	err = vibeCodingSession.WriteFileWithOptions(ctx, filename, content, opts)
 	if err != nil {
 		return &mcp.CallToolResultFor[any]{
 			IsError: true,
@@ -406,19 +425,26 @@ func (s *VibeCodingMCPHTTPServer) GetSessionInfo(ctx context.Context, session *m
 	}
 
 	sessionInfo := vibecoding.FormatSessionInfo(userID, vibeCodingSession)
+	promptTokens, completionTokens, cost := vibeCodingSession.TokenUsage()
+	activeModel, fallbackFrom := vibeCodingSession.ActiveModel()
 
 	return &mcp.CallToolResultFor[any]{
 		Content: []mcp.Content{
 			&mcp.TextContent{Text: sessionInfo},
 		},
 		Meta: map[string]interface{}{
-			"user_id":         userID,
-			"project_name":    vibeCodingSession.ProjectName,
-			"container_id":    vibeCodingSession.ContainerID,
-			"test_command":    vibeCodingSession.TestCommand,
-			"start_time":      vibeCodingSession.StartTime,
-			"files_count":     len(vibeCodingSession.Files),
-			"generated_count": len(vibeCodingSession.GeneratedFiles),
+			"user_id":            userID,
+			"project_name":       vibeCodingSession.ProjectName,
+			"container_id":       vibeCodingSession.ContainerID,
+			"test_command":       vibeCodingSession.TestCommand,
+			"start_time":         vibeCodingSession.StartTime,
+			"files_count":        len(vibeCodingSession.Files),
+			"generated_count":    len(vibeCodingSession.GeneratedFiles),
+			"prompt_tokens":      promptTokens,
+			"completion_tokens":  completionTokens,
+			"estimated_cost_usd": cost,
+			"active_model":       activeModel,
+			"fallback_from":      fallbackFrom,
 		},
 	}, nil
 }
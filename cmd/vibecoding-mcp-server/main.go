@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"ai-chatter/internal/codevalidation"
 	"ai-chatter/internal/vibecoding"
 
 	"github.com/joho/godotenv"
@@ -23,7 +24,8 @@ type VibeCodingMCPServer struct {
 func NewVibeCodingMCPServer() *VibeCodingMCPServer {
 	log.Printf("🔧 Initializing VibeCoding MCP Server")
 
-	sessionManager := vibecoding.NewSessionManager()
+	// Сервер запускается ботом как подпроцесс; веб-интерфейс обслуживает сам бот (VIBECODING_WEB_PORT)
+	sessionManager := vibecoding.NewSessionManagerWithoutWebServer()
 
 	return &VibeCodingMCPServer{
 		sessionManager: sessionManager,
@@ -234,7 +236,8 @@ func (s *VibeCodingMCPServer) WriteFile(ctx context.Context, session *mcp.Server
 		}, nil
 	}
 
-	generated, _ := params.Arguments["generated"].(bool)
+	opts := vibecoding.WriteFileOptionsFromArgs(params.Arguments)
+	generated := opts.Generated
 
 	var userID int64
 	switch v := userIDArg.(type) {
@@ -264,7 +267,7 @@ func (s *VibeCodingMCPServer) WriteFile(ctx context.Context, session *mcp.Server
 		}, nil
 	}
 
-	log.Printf("✏️ MCP Server: Writing file %s for user %d (generated: %t)", filename, userID, generated)
+	log.Printf("✏️ MCP Server: Writing file %s for user %d (generated: %t, overwrite_original: %t)", filename, userID, generated, opts.OverwriteOriginal)
 
 	vibeCodingSession := s.sessionManager.GetSession(userID)
 	if vibeCodingSession == nil {
@@ -276,7 +279,7 @@ func (s *VibeCodingMCPServer) WriteFile(ctx context.Context, session *mcp.Server
 		}, nil
 	}
 
-	err := vibeCodingSession.WriteFile(ctx, filename, content, generated)
+	err := vibeCodingSession.WriteFileWithOptions(ctx, filename, content, opts)
 	if err != nil {
 		return &mcp.CallToolResultFor[any]{
 			IsError: true,
@@ -364,7 +367,9 @@ func (s *VibeCodingMCPServer) ExecuteCommand(ctx context.Context, session *mcp.S
 		}, nil
 	}
 
-	result, err := vibeCodingSession.ExecuteCommand(ctx, command)
+	workDir, _ := params.Arguments["workdir"].(string)
+	opts := codevalidation.ExecOptions{WorkDir: workDir, Env: vibecoding.EnvArgument(params.Arguments["env"])}
+	result, err := vibeCodingSession.ExecuteCommandWithOptions(ctx, command, opts)
 	if err != nil {
 		return &mcp.CallToolResultFor[any]{
 			IsError: true,
@@ -381,19 +386,36 @@ func (s *VibeCodingMCPServer) ExecuteCommand(ctx context.Context, session *mcp.S
 		status = "❌ Failed"
 	}
 
-	resultMessage := fmt.Sprintf("%s Command execution completed\n\n**Command:** %s\n**Exit Code:** %d\n**Output:**\n```\n%s\n```",
-		status, command, result.ExitCode, result.Output)
+	var resultMessage strings.Builder
+	resultMessage.WriteString(fmt.Sprintf("%s Command execution completed\n\n**Command:** %s\n", status, result.Command))
+	if result.WorkDir != "" {
+		resultMessage.WriteString(fmt.Sprintf("**Workdir:** %s\n", result.WorkDir))
+	}
+	if len(result.Env) > 0 {
+		resultMessage.WriteString(fmt.Sprintf("**Env:** %s\n", strings.Join(result.Env, " ")))
+	}
+	resultMessage.WriteString(fmt.Sprintf("**Exit Code:** %d\n**Duration:** %s\n", result.ExitCode, result.Duration.Round(time.Millisecond)))
+	if result.Combined {
+		resultMessage.WriteString(fmt.Sprintf("**Output (stdout and stderr combined):**\n```\n%s\n```", result.Stdout))
+	} else {
+		resultMessage.WriteString(fmt.Sprintf("**Stdout:**\n```\n%s\n```\n**Stderr:**\n```\n%s\n```", result.Stdout, result.Stderr))
+	}
 
 	return &mcp.CallToolResultFor[any]{
 		Content: []mcp.Content{
-			&mcp.TextContent{Text: resultMessage},
+			&mcp.TextContent{Text: resultMessage.String()},
 		},
 		Meta: map[string]interface{}{
-			"user_id":   userID,
-			"command":   command,
-			"success":   result.Success,
-			"exit_code": result.ExitCode,
-			"output":    result.Output,
+			"user_id":     userID,
+			"command":     result.Command,
+			"workdir":     result.WorkDir,
+			"env":         result.Env,
+			"success":     result.Success,
+			"exit_code":   result.ExitCode,
+			"duration_ms": result.Duration.Milliseconds(),
+			"stdout":      result.Stdout,
+			"stderr":      result.Stderr,
+			"output":      result.ValidationResult().Output,
 		},
 	}, nil
 }
@@ -713,17 +735,29 @@ func (s *VibeCodingMCPServer) GetSessionInfo(ctx context.Context, session *mcp.S
 	resultMessage := fmt.Sprintf("ℹ️ VibeCoding Session Information\n\n**User ID:** %d\n**Status:** %s\n**Container ID:** %s\n**Test Command:** %s\n**Created:** %s",
 		userID, status, vibeCodingSession.ContainerID, vibeCodingSession.TestCommand, vibeCodingSession.CreatedAt().Format(time.RFC3339))
 
+	promptTokens, completionTokens, cost := vibeCodingSession.TokenUsage()
+	resultMessage += fmt.Sprintf("\n**LLM Tokens:** %d prompt + %d completion (≈ $%.4f)", promptTokens, completionTokens, cost)
+	activeModel, fallbackFrom := vibeCodingSession.ActiveModel()
+	if activeModel != "" {
+		resultMessage += "\n**LLM Model:** " + activeModel
+	}
+
 	return &mcp.CallToolResultFor[any]{
 		Content: []mcp.Content{
 			&mcp.TextContent{Text: resultMessage},
 		},
 		Meta: map[string]interface{}{
-			"user_id":      userID,
-			"status":       status,
-			"container_id": vibeCodingSession.ContainerID,
-			"test_command": vibeCodingSession.TestCommand,
-			"created_at":   vibeCodingSession.CreatedAt(),
-			"success":      true,
+			"user_id":            userID,
+			"status":             status,
+			"container_id":       vibeCodingSession.ContainerID,
+			"test_command":       vibeCodingSession.TestCommand,
+			"created_at":         vibeCodingSession.CreatedAt(),
+			"prompt_tokens":      promptTokens,
+			"completion_tokens":  completionTokens,
+			"estimated_cost_usd": cost,
+			"active_model":       activeModel,
+			"fallback_from":      fallbackFrom,
+			"success":            true,
 		},
 	}, nil
 }
@@ -748,46 +782,70 @@ func main() {
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "vibe_list_files",
 		Description: "Lists all files in the VibeCoding workspace for the specified user",
-	}, vibeCodingServer.ListFiles)
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_list_files", vibeCodingServer.ListFiles))
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "vibe_read_file",
 		Description: "Reads the content of a specific file from the VibeCoding workspace",
-	}, vibeCodingServer.ReadFile)
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_read_file", vibeCodingServer.ReadFile))
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "vibe_write_file",
-		Description: "Writes content to a file in the VibeCoding workspace",
-	}, vibeCodingServer.WriteFile)
+		Description: "Writes content to a file in the VibeCoding workspace. Generated files (generated=true) never replace original project files unless overwrite_original=true",
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_write_file", vibeCodingServer.WriteFile))
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "vibe_rename_file",
+		Description: "Renames or moves a file (old_path -> new_path) in the VibeCoding workspace, keeping whether it is an original or generated file. Fails if new_path already exists",
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_rename_file", vibecoding.RenameFileToolHandler(vibeCodingServer.sessionManager)))
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "vibe_git_commit",
+		Description: "Commits the current VibeCoding workspace (git init if needed, git add -A, git commit -m message) and returns the commit hash",
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_git_commit", vibecoding.GitCommitToolHandler(vibeCodingServer.sessionManager)))
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "vibe_push_github",
+		Description: "Pushes new and modified session files to GitHub as a branch (based on the latest head of base) and opens a pull request with an LLM-written summary. repo is owner/repo (default: the cloned repository), base is the target branch",
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_push_github", vibecoding.GitHubPushToolHandler(vibeCodingServer.sessionManager)))
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "vibe_execute_command",
-		Description: "Executes a shell command in the VibeCoding session container",
-	}, vibeCodingServer.ExecuteCommand)
+		Description: "Executes a shell command in the VibeCoding session container. Optional workdir (relative to the project root) and env (object of extra environment variables); stdout and stderr are returned separately",
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_execute_command", vibeCodingServer.ExecuteCommand))
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "vibe_validate_code",
 		Description: "Validates code in a specific file using the VibeCoding validation system",
-	}, vibeCodingServer.ValidateCode)
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_validate_code", vibeCodingServer.ValidateCode))
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "vibe_run_tests",
 		Description: "Runs tests for the VibeCoding project using the configured test command. Set validate_and_fix=true to automatically validate generated tests and fix failures.",
-	}, vibeCodingServer.RunTests)
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_run_tests", vibeCodingServer.RunTests))
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "vibe_get_session_info",
 		Description: "Gets information about the VibeCoding session for the specified user",
-	}, vibeCodingServer.GetSessionInfo)
+	}, vibecoding.InstrumentTool(vibecoding.DefaultToolMetrics, "vibe_get_session_info", vibeCodingServer.GetSessionInfo))
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "vibe_get_metrics",
+		Description: "Returns per-tool call counts, error counts by category, p50/p95 latency and last error for VibeCoding MCP tools",
+	}, vibecoding.MetricsToolHandler(vibecoding.DefaultToolMetrics))
 
-	log.Printf("📋 Registered 7 VibeCoding MCP tools:")
+	log.Printf("📋 Registered 11 VibeCoding MCP tools:")
 	log.Printf("   - vibe_list_files: Lists files in workspace")
 	log.Printf("   - vibe_read_file: Reads file content")
 	log.Printf("   - vibe_write_file: Writes file content")
+	log.Printf("   - vibe_rename_file: Renames or moves a file")
+	log.Printf("   - vibe_git_commit: Commits the workspace")
+	log.Printf("   - vibe_push_github: Opens a pull request with the changes")
 	log.Printf("   - vibe_execute_command: Executes commands")
 	log.Printf("   - vibe_validate_code: Validates code")
 	log.Printf("   - vibe_run_tests: Runs tests")
 	log.Printf("   - vibe_get_session_info: Gets session info")
+	log.Printf("   - vibe_get_metrics: Gets tool metrics")
 	log.Printf("🔗 Starting VibeCoding MCP server on stdin/stdout...")
 
 	// Запускаем сервер через stdin/stdout
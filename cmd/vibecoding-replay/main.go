@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"ai-chatter/internal/vibecoding"
+)
+
+// vibecoding-replay воспроизводит записанный автономный запуск /vibecoding_auto (VIBECODING_RECORD_DIR):
+// оркестрация выполняется заново на записанных ответах LLM без новых запросов к модели,
+// инструменты берут записанные результаты или выполняются на VibeCoding MCP сервере (-mcp-url).
+// Код выхода 1, если последовательность решений расходится с записью
+func main() {
+	mcpURL := flag.String("mcp-url", "", "выполнять инструменты на VibeCoding MCP сервере (например http://localhost:8082/mcp); по умолчанию — записанные результаты")
+	out := flag.String("out", "", "сохранить запись воспроизведения в каталог для сравнения")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: vibecoding-replay [flags] <recording.json>\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	recorded, err := vibecoding.LoadRunRecording(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to load recording: %v", err)
+	}
+
+	ctx := context.Background()
+	var tools vibecoding.VibeToolBackend
+	if *mcpURL != "" {
+		client := vibecoding.NewVibeCodingMCPClient()
+		if err := client.ConnectSSE(ctx, *mcpURL); err != nil {
+			log.Fatalf("Failed to connect to VibeCoding MCP server: %v", err)
+		}
+		defer client.Close()
+		tools = client
+	}
+
+	report := vibecoding.ReplayAutonomousRun(ctx, recorded, tools)
+	fmt.Println(vibecoding.FormatReplayReport(recorded, report))
+
+	if *out != "" {
+		path, err := report.Recording.SaveTo(*out)
+		if err != nil {
+			log.Fatalf("Failed to save replay recording: %v", err)
+		}
+		fmt.Printf("Replay recording: %s\n", path)
+	}
+	if !report.Matched {
+		os.Exit(1)
+	}
+}
@@ -541,15 +541,21 @@ func (w *GmailSummaryWorkflow) ensureGmailSummariesPage(ctx context.Context) (st
 	log.Printf("📋 Ensuring Gmail summaries page exists")
 
 	// Сначала ищем существующую страницу
-	searchResult := w.notionClient.SearchPagesWithID(ctx, "Gmail summaries", 5, true)
-	if searchResult.Success && len(searchResult.Pages) > 0 {
+	searchResult, err := w.notionClient.SearchPagesWithID(ctx, "Gmail summaries", 5, true)
+	if err != nil && notion.CategoryOf(err) != notion.ErrorNotFound {
+		return "", fmt.Errorf("failed to search Gmail summaries page: %w", err)
+	}
+	if len(searchResult.Pages) > 0 {
 		log.Printf("✅ Found existing Gmail summaries page: %s", searchResult.Pages[0].ID)
 		return searchResult.Pages[0].ID, nil
 	}
 
 	// Если не найдено, получаем список доступных страниц для создания родительской
-	availablePages := w.notionClient.ListAvailablePages(ctx, 10, "", true)
-	if !availablePages.Success || len(availablePages.Pages) == 0 {
+	availablePages, err := w.notionClient.ListAvailablePages(ctx, 10, "", true)
+	if err != nil {
+		return "", fmt.Errorf("failed to list parent pages: %w", err)
+	}
+	if len(availablePages.Pages) == 0 {
 		return "", fmt.Errorf("no available parent pages found")
 	}
 
@@ -557,16 +563,15 @@ func (w *GmailSummaryWorkflow) ensureGmailSummariesPage(ctx context.Context) (st
 	parentPageID := availablePages.Pages[0].ID
 
 	// Создаем страницу "Gmail summaries"
-	createResult := w.notionClient.CreateFreeFormPage(ctx, "Gmail summaries",
+	createResult, err := w.notionClient.CreateFreeFormPage(ctx, "Gmail summaries",
 		"This page contains Gmail email summaries generated automatically.",
 		parentPageID, []string{"gmail", "summaries"})
-
-	if !createResult.Success {
-		return "", fmt.Errorf("failed to create Gmail summaries page: %s", createResult.Message)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Gmail summaries page: %w", err)
 	}
 
-	log.Printf("✅ Created new Gmail summaries page: %s", createResult.PageID)
-	return createResult.PageID, nil
+	log.Printf("✅ Created new Gmail summaries page: %s", createResult.Page.ID)
+	return createResult.Page.ID, nil
 }
 
 // validateSummary валидирует созданное саммари
@@ -811,16 +816,13 @@ If validation fails, provide specific correction_request with detailed instructi
 func (w *GmailSummaryWorkflow) createNotionPage(ctx context.Context, parentPageID, title, content string) (string, error) {
 	log.Printf("📄 Creating Notion page: %s", title)
 
-	result := w.notionClient.CreateFreeFormPage(ctx, title, content, parentPageID, []string{"gmail", "summary", "auto-generated"})
-	if !result.Success {
-		return "", fmt.Errorf("failed to create Notion page: %s", result.Message)
+	result, err := w.notionClient.CreateFreeFormPage(ctx, title, content, parentPageID, []string{"gmail", "summary", "auto-generated"})
+	if err != nil {
+		return "", fmt.Errorf("failed to create Notion page: %w", err)
 	}
 
-	// Формируем URL страницы
-	pageURL := fmt.Sprintf("https://www.notion.so/%s", result.PageID)
-	log.Printf("✅ Created Notion page: %s", pageURL)
-
-	return pageURL, nil
+	log.Printf("✅ Created Notion page: %s", result.Page.URL)
+	return result.Page.URL, nil
 }
 
 // GmailSearchQueryResponse представляет ответ агента для создания поискового запроса
@@ -0,0 +1,134 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"ai-chatter/internal/storage"
+)
+
+// FeatureUsage использование одной функции бота за период и за предыдущий такой же период
+type FeatureUsage struct {
+	Feature   string `json:"feature"`
+	Calls     int    `json:"calls"`
+	Users     int    `json:"users"`
+	PrevCalls int    `json:"prev_calls"`
+}
+
+// FeatureUsageReport использование функций за [From, To) в сравнении с предыдущим периодом той же длины
+type FeatureUsageReport struct {
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Features []FeatureUsage `json:"features"` // по убыванию числа вызовов за период
+}
+
+// UsagePeriodStart начало периода для отчёта: from = to - days, предыдущий период — ещё days назад
+func UsagePeriodStart(to time.Time, days int) (from, prevFrom time.Time) {
+	from = to.AddDate(0, 0, -days)
+	return from, from.AddDate(0, 0, -days)
+}
+
+// BuildFeatureUsageReport считает вызовы и пользователей по функциям. records должны покрывать
+// и предыдущий период ([from - (to - from), to)), иначе тренд будет считаться от нуля
+func BuildFeatureUsageReport(records []storage.UsageRecord, from, to time.Time) *FeatureUsageReport {
+	days := int(to.Sub(from).Hours()/24 + 0.5)
+	_, prevFrom := UsagePeriodStart(to, days)
+	fromDay, toDay, prevDay := dayKey(from), dayKey(to), dayKey(prevFrom)
+
+	byFeature := make(map[string]*FeatureUsage)
+	users := make(map[string]map[int64]bool)
+	for _, r := range records {
+		fu := byFeature[r.Feature]
+		if fu == nil {
+			fu = &FeatureUsage{Feature: r.Feature}
+			byFeature[r.Feature] = fu
+			users[r.Feature] = make(map[int64]bool)
+		}
+		switch {
+		case r.Day >= fromDay && r.Day < toDay:
+			fu.Calls += r.Count
+			users[r.Feature][r.UserID] = true
+		case r.Day >= prevDay && r.Day < fromDay:
+			fu.PrevCalls += r.Count
+		}
+	}
+
+	report := &FeatureUsageReport{From: from, To: to}
+	for name, fu := range byFeature {
+		if fu.Calls == 0 && fu.PrevCalls == 0 {
+			continue
+		}
+		fu.Users = len(users[name])
+		report.Features = append(report.Features, *fu)
+	}
+	sort.Slice(report.Features, func(i, j int) bool {
+		a, b := report.Features[i], report.Features[j]
+		if a.Calls != b.Calls {
+			return a.Calls > b.Calls
+		}
+		return a.Feature < b.Feature
+	})
+	return report
+}
+
+// Trend изменение к предыдущему периоду: "↑ +4", "↓ -2", "=" или "новое"
+func (f FeatureUsage) Trend() string {
+	switch {
+	case f.PrevCalls == 0 && f.Calls > 0:
+		return "новое"
+	case f.Calls > f.PrevCalls:
+		return fmt.Sprintf("↑ +%d", f.Calls-f.PrevCalls)
+	case f.Calls < f.PrevCalls:
+		return fmt.Sprintf("↓ -%d", f.PrevCalls-f.Calls)
+	}
+	return "="
+}
+
+// FormatDigestSection секция "Использование функций" еженедельного дайджеста
+func (r *FeatureUsageReport) FormatDigestSection() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📈 Использование функций (%s — %s):\n", dayKey(r.From), dayKey(r.To.AddDate(0, 0, -1))))
+	var unused []string
+	used := 0
+	for _, f := range r.Features {
+		if f.Calls == 0 {
+			unused = append(unused, f.Feature)
+			continue
+		}
+		used++
+		sb.WriteString(fmt.Sprintf("- %s: %d вызовов, %d польз. (%s к прошлому периоду)\n", f.Feature, f.Calls, f.Users, f.Trend()))
+	}
+	if used == 0 {
+		sb.WriteString("- функции бота не использовались\n")
+	}
+	if len(unused) > 0 {
+		sb.WriteString(fmt.Sprintf("Перестали использовать: %s\n", strings.Join(unused, ", ")))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// FormatTable таблица для /stats: функция | вызовы | пользователи | тренд
+func (r *FeatureUsageReport) FormatTable() string {
+	if len(r.Features) == 0 {
+		return "Нет данных об использовании функций"
+	}
+	width := len([]rune("Функция"))
+	for _, f := range r.Features {
+		if n := len([]rune(f.Feature)); n > width {
+			width = n
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-*s | %6s | %5s | %s\n", width, "Функция", "Вызовы", "Польз", "Тренд"))
+	sb.WriteString(strings.Repeat("-", width+30) + "\n")
+	for _, f := range r.Features {
+		sb.WriteString(fmt.Sprintf("%-*s | %6d | %5d | %s\n", width, f.Feature, f.Calls, f.Users, f.Trend()))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func dayKey(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
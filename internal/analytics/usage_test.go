@@ -0,0 +1,55 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ai-chatter/internal/storage"
+)
+
+func TestBuildFeatureUsageReport_TrendsVersusPriorPeriod(t *testing.T) {
+	to := time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)
+	from, _ := UsagePeriodStart(to, 7)
+	records := []storage.UsageRecord{
+		// текущая неделя 03-09..03-15
+		{Day: "2026-03-09", UserID: 1, Feature: "/vibecoding_auto", Count: 5},
+		{Day: "2026-03-15", UserID: 2, Feature: "/vibecoding_auto", Count: 3},
+		{Day: "2026-03-12", UserID: 1, Feature: "mcp:search_pages", Count: 2},
+		// предыдущая неделя 03-02..03-08
+		{Day: "2026-03-02", UserID: 1, Feature: "/vibecoding_auto", Count: 4},
+		{Day: "2026-03-08", UserID: 1, Feature: "mcp:search_pages", Count: 6},
+		{Day: "2026-03-05", UserID: 3, Feature: "/export", Count: 1},
+		// вне обоих периодов
+		{Day: "2026-03-01", UserID: 1, Feature: "/remind", Count: 9},
+		{Day: "2026-03-16", UserID: 1, Feature: "/remind", Count: 9},
+	}
+
+	report := BuildFeatureUsageReport(records, from, to)
+	if len(report.Features) != 3 {
+		t.Fatalf("features = %+v", report.Features)
+	}
+	auto := report.Features[0]
+	if auto.Feature != "/vibecoding_auto" || auto.Calls != 8 || auto.Users != 2 || auto.PrevCalls != 4 || auto.Trend() != "↑ +4" {
+		t.Errorf("unexpected /vibecoding_auto usage: %+v (%s)", auto, auto.Trend())
+	}
+	search := report.Features[1]
+	if search.Calls != 2 || search.PrevCalls != 6 || search.Trend() != "↓ -4" {
+		t.Errorf("unexpected mcp:search_pages usage: %+v (%s)", search, search.Trend())
+	}
+
+	section := report.FormatDigestSection()
+	for _, want := range []string{"2026-03-09 — 2026-03-15", "/vibecoding_auto: 8 вызовов, 2 польз.", "Перестали использовать: /export"} {
+		if !strings.Contains(section, want) {
+			t.Errorf("digest section must contain %q:\n%s", want, section)
+		}
+	}
+	if strings.Contains(section, "/remind") {
+		t.Errorf("usage outside both periods must be ignored:\n%s", section)
+	}
+
+	table := report.FormatTable()
+	if lines := strings.Split(table, "\n"); len(lines) != 5 || !strings.Contains(lines[2], "/vibecoding_auto |      8 |     2 | ↑ +4") {
+		t.Errorf("unexpected table:\n%s", table)
+	}
+}
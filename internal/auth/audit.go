@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Решения проверки доступа в журнале аудита
+const (
+	AuditAllow       = "allow"
+	AuditDeny        = "deny"
+	AuditRateLimited = "rate_limited"
+)
+
+// ErrAuditUnavailable репозиторий не умеет читать журнал аудита
+var ErrAuditUnavailable = errors.New("audit log is not available")
+
+// AuthEvent решение о доступе пользователя к боту
+type AuthEvent struct {
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username,omitempty"`
+	Action    string    `json:"action"`
+	Timestamp time.Time `json:"timestamp"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// auditReader репозиторий, который умеет отдавать последние записи журнала аудита (FileRepository)
+type auditReader interface {
+	RecentAudit(limit int) ([]AuthEvent, error)
+}
+
+// AuditPath журнал аудита лежит рядом с файлом allowlist
+func AuditPath(allowlistPath string) string {
+	return filepath.Join(filepath.Dir(allowlistPath), "audit.jsonl")
+}
+
+// AuditLog дописывает событие строкой JSON в audit.jsonl
+func (r *FileRepository) AuditLog(event AuthEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode audit event: %w", err)
+	}
+	r.auditMu.Lock()
+	defer r.auditMu.Unlock()
+	f, err := os.OpenFile(AuditPath(r.path), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return nil
+}
+
+// RecentAudit последние limit событий журнала в хронологическом порядке (limit <= 0 — все)
+func (r *FileRepository) RecentAudit(limit int) ([]AuthEvent, error) {
+	r.auditMu.Lock()
+	defer r.auditMu.Unlock()
+	f, err := os.Open(AuditPath(r.path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	var events []AuthEvent
+	for s.Scan() {
+		var ev AuthEvent
+		if err := json.Unmarshal(s.Bytes(), &ev); err != nil {
+			continue
+		}
+		events = append(events, ev)
+		if limit > 0 && len(events) > limit {
+			events = events[1:]
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("scan audit log: %w", err)
+	}
+	return events, nil
+}
+
+// RecentAudit последние события журнала аудита; ErrAuditUnavailable, если репозиторий его не ведёт
+func (s *Service) RecentAudit(limit int) ([]AuthEvent, error) {
+	r, ok := s.repo.(auditReader)
+	if !ok {
+		return nil, ErrAuditUnavailable
+	}
+	return r.RecentAudit(limit)
+}
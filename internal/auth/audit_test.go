@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestServiceCheck_AuditsEveryDecision(t *testing.T) {
+	repo := &memRepo{users: []User{{ID: 1, Username: "alice"}}}
+	svc, _ := NewWithRepo(repo, nil)
+	svc.SetRateLimiter(NewRateLimiter(1, 1))
+
+	_ = svc.Check(1, "")
+	if err := svc.Check(1, "alice"); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("second message must be rate limited, got %v", err)
+	}
+	if err := svc.Check(2, "mallory"); !errors.Is(err, ErrNotAllowed) {
+		t.Fatalf("unknown user must be denied, got %v", err)
+	}
+
+	want := []struct {
+		user     int64
+		username string
+		action   string
+	}{{1, "alice", AuditAllow}, {1, "alice", AuditRateLimited}, {2, "mallory", AuditDeny}}
+	if len(repo.events) != len(want) {
+		t.Fatalf("events = %+v", repo.events)
+	}
+	for i, w := range want {
+		ev := repo.events[i]
+		if ev.UserID != w.user || ev.Username != w.username || ev.Action != w.action || ev.Timestamp.IsZero() {
+			t.Errorf("event %d = %+v, want %+v", i, ev, w)
+		}
+	}
+	if repo.events[1].Reason != "retry after 60s" || repo.events[2].Reason == "" {
+		t.Errorf("denials must carry a reason: %+v", repo.events)
+	}
+}
+
+func TestFileRepository_AuditLogTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist.json")
+	repo, err := NewFileRepository(path)
+	if err != nil {
+		t.Fatalf("repo: %v", err)
+	}
+	svc, _ := NewWithRepo(repo, []int64{1})
+	if events, err := svc.RecentAudit(20); err != nil || len(events) != 0 {
+		t.Fatalf("empty audit log: %+v, %v", events, err)
+	}
+	for i := int64(0); i < 5; i++ {
+		_ = svc.Check(i, "")
+	}
+	events, err := svc.RecentAudit(3)
+	if err != nil {
+		t.Fatalf("RecentAudit: %v", err)
+	}
+	if len(events) != 3 || events[0].UserID != 2 || events[2].UserID != 4 || events[2].Action != AuditDeny {
+		t.Fatalf("must return the last 3 events in order: %+v", events)
+	}
+	if AuditPath(path) != filepath.Join(filepath.Dir(path), "audit.jsonl") {
+		t.Errorf("audit log must live next to the allowlist: %s", AuditPath(path))
+	}
+	// Журнал отдельный: allowlist не меняется
+	if users, _ := repo.LoadAll(); len(users) != 0 {
+		t.Errorf("audit must not touch the allowlist file: %+v", users)
+	}
+}
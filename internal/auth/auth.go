@@ -1,21 +1,42 @@
 package auth
 
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
 type User struct {
 	ID        int64  `json:"id"`
 	Username  string `json:"username"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
+	Role      Role   `json:"role,omitempty"`
 }
 
 type Repository interface {
 	LoadAll() ([]User, error)
 	Upsert(user User) error
+	// Add добавляет пользователя по ID, если его ещё нет; false — пользователь уже есть
+	Add(userID int64) (bool, error)
 	Remove(userID int64) error
+	// AuditLog записывает решение о доступе (см. Service.Check)
+	AuditLog(event AuthEvent) error
+}
+
+// appender репозиторий с атомарным добавлением (FileRepository)
+type appender interface {
+	Append(user User) (bool, error)
 }
 
+// Service allowlist в памяти поверх репозитория. Безопасен для конкурентных вызовов:
+// кнопки одобрения и задачи планировщика обращаются к нему из разных горутин
 type Service struct {
+	mu           sync.RWMutex
 	repo         Repository
 	allowedUsers map[int64]User
+	limiter      *RateLimiter
 }
 
 func NewWithRepo(repo Repository, initial []int64) (*Service, error) {
@@ -39,11 +60,100 @@ func NewWithRepo(repo Repository, initial []int64) (*Service, error) {
 }
 
 func (s *Service) IsAllowed(userID int64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	_, ok := s.allowedUsers[userID]
 	return ok
 }
 
+// SetRateLimiter подключает ограничение частоты сообщений для Authorize; nil — без ограничения
+func (s *Service) SetRateLimiter(l *RateLimiter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limiter = l
+}
+
+// Check проверяет входящее сообщение пользователя: ErrNotAllowed вне allowlist,
+// *RateLimitError (errors.Is(err, ErrRateLimited)) при исчерпанном лимите. В отличие от IsAllowed
+// расходует токен лимита, поэтому вызывается один раз на сообщение, а не для проверки прав.
+// Каждое решение записывается в журнал аудита репозитория
+func (s *Service) Check(userID int64, username string) error {
+	s.mu.RLock()
+	user, ok := s.allowedUsers[userID]
+	limiter := s.limiter
+	repo := s.repo
+	s.mu.RUnlock()
+	if username == "" {
+		username = user.Username
+	}
+
+	event := AuthEvent{UserID: userID, Username: username, Action: AuditAllow, Timestamp: time.Now().UTC()}
+	var err error
+	if !ok {
+		err = ErrNotAllowed
+		event.Action, event.Reason = AuditDeny, "not in allowlist"
+	} else if allowed, retryAfter := limiter.Allow(userID); !allowed {
+		limited := &RateLimitError{RetryAfter: retryAfter}
+		err = limited
+		event.Action, event.Reason = AuditRateLimited, fmt.Sprintf("retry after %ds", limited.RetryAfterSeconds())
+	}
+	if repo != nil {
+		if auditErr := repo.AuditLog(event); auditErr != nil {
+			log.Printf("⚠️ Failed to write auth audit event for user %d: %v", userID, auditErr)
+		}
+	}
+	return err
+}
+
 func (s *Service) Upsert(user User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.upsertLocked(user)
+}
+
+// Add добавляет пользователя в allowlist, если его там ещё нет; false — пользователь уже добавлен
+// (повторное или одновременное одобрение одной заявки)
+func (s *Service) Add(user User) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.allowedUsers[user.ID]; ok {
+		return false, nil
+	}
+	if a, ok := s.repo.(appender); ok {
+		if _, err := a.Append(user); err != nil {
+			return false, err
+		}
+	} else if s.repo != nil {
+		if err := s.repo.Upsert(user); err != nil {
+			return false, err
+		}
+	}
+	s.allowedUsers[user.ID] = user
+	return true, nil
+}
+
+// Allow добавляет пользователя по ID в allowlist (/admin_allow); false — пользователь уже в нём.
+// Изменение действует со следующего сообщения, перезапуск не нужен
+func (s *Service) Allow(userID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.allowedUsers[userID]; ok {
+		return false, nil
+	}
+	if s.repo != nil {
+		if _, err := s.repo.Add(userID); err != nil {
+			return false, err
+		}
+	}
+	s.allowedUsers[userID] = User{ID: userID}
+	return true, nil
+}
+
+func (s *Service) upsertLocked(user User) error {
+	// Повторное одобрение не сбрасывает назначенную роль
+	if existing, ok := s.allowedUsers[user.ID]; ok && user.Role == RoleNone {
+		user.Role = existing.Role
+	}
 	s.allowedUsers[user.ID] = user
 	if s.repo != nil {
 		return s.repo.Upsert(user)
@@ -52,6 +162,8 @@ func (s *Service) Upsert(user User) error {
 }
 
 func (s *Service) Remove(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	delete(s.allowedUsers, userID)
 	if s.repo != nil {
 		return s.repo.Remove(userID)
@@ -60,6 +172,8 @@ func (s *Service) Remove(userID int64) error {
 }
 
 func (s *Service) List() []User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	out := make([]User, 0, len(s.allowedUsers))
 	for _, u := range s.allowedUsers {
 		out = append(out, u)
@@ -1,8 +1,16 @@
 package auth
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
 
-type memRepo struct{ users []User }
+type memRepo struct {
+	users  []User
+	events []AuthEvent
+}
 
 func (m *memRepo) LoadAll() ([]User, error) { return append([]User{}, m.users...), nil }
 func (m *memRepo) Upsert(u User) error {
@@ -15,6 +23,15 @@ func (m *memRepo) Upsert(u User) error {
 	m.users = append(m.users, u)
 	return nil
 }
+func (m *memRepo) Add(id int64) (bool, error) {
+	for _, x := range m.users {
+		if x.ID == id {
+			return false, nil
+		}
+	}
+	m.users = append(m.users, User{ID: id})
+	return true, nil
+}
 func (m *memRepo) Remove(id int64) error {
 	out := make([]User, 0, len(m.users))
 	for _, x := range m.users {
@@ -25,6 +42,10 @@ func (m *memRepo) Remove(id int64) error {
 	m.users = out
 	return nil
 }
+func (m *memRepo) AuditLog(ev AuthEvent) error {
+	m.events = append(m.events, ev)
+	return nil
+}
 
 func TestServiceBasic(t *testing.T) {
 	repo := &memRepo{users: []User{{ID: 10, Username: "alice"}}}
@@ -62,3 +83,72 @@ func TestServiceBasic(t *testing.T) {
 		t.Fatalf("want 2 users, got %d", len(lst))
 	}
 }
+
+func TestFileRepository_ConcurrentAppend(t *testing.T) {
+	repo, err := NewFileRepository(filepath.Join(t.TempDir(), "allowlist.json"))
+	if err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	svc, _ := NewWithRepo(repo, nil)
+
+	// Одновременные нажатия «разрешить» для разных и одной и той же заявки
+	var wg sync.WaitGroup
+	added := make(chan bool, 40)
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			ok, err := svc.Add(User{ID: id})
+			if err != nil {
+				t.Errorf("add %d: %v", id, err)
+			}
+			added <- ok
+		}(int64(i % 20))
+	}
+	wg.Wait()
+	close(added)
+	count := 0
+	for ok := range added {
+		if ok {
+			count++
+		}
+	}
+	if count != 20 {
+		t.Fatalf("each user must be added once, got %d", count)
+	}
+	users, _ := repo.LoadAll()
+	if len(users) != 20 {
+		t.Fatalf("want 20 users in file, got %d", len(users))
+	}
+	if ok, _ := repo.Append(User{ID: 3}); ok {
+		t.Error("Append must not duplicate an existing user")
+	}
+}
+
+func TestFileRepository_AddAndRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist.json")
+	repo, err := NewFileRepository(path)
+	if err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	svc, _ := NewWithRepo(repo, nil)
+	if added, err := svc.Allow(7); err != nil || !added {
+		t.Fatalf("Allow = %v, %v", added, err)
+	}
+	if added, err := repo.Add(7); err != nil || added {
+		t.Fatalf("repeated Add must report existing user, got %v, %v", added, err)
+	}
+	users, _ := repo.LoadAll()
+	if len(users) != 1 || users[0].ID != 7 {
+		t.Fatalf("file must contain user 7, got %+v", users)
+	}
+	if err := svc.Remove(7); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if users, _ := repo.LoadAll(); len(users) != 0 {
+		t.Fatalf("file must be empty after remove, got %+v", users)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file must be renamed, stat err = %v", err)
+	}
+}
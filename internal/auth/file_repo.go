@@ -10,8 +10,9 @@ import (
 )
 
 type FileRepository struct {
-	path string
-	mu   sync.Mutex
+	path    string
+	mu      sync.Mutex
+	auditMu sync.Mutex // журнал аудита пишется независимо от allowlist
 }
 
 func NewFileRepository(path string) (*FileRepository, error) {
@@ -69,6 +70,29 @@ func (r *FileRepository) Upsert(user User) error {
 	return r.saveUnlocked(users)
 }
 
+// Append атомарно добавляет пользователя, если его ещё нет в файле; false — пользователь уже есть
+// (например, повторное нажатие кнопки одобрения)
+func (r *FileRepository) Append(user User) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	users, _ := r.loadUnlocked()
+	for _, u := range users {
+		if u.ID == user.ID {
+			return false, nil
+		}
+	}
+	if err := r.saveUnlocked(append(users, user)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Add добавляет пользователя по ID без имени; файл перезаписывается атомарно, как в Append
+func (r *FileRepository) Add(userID int64) (bool, error) {
+	return r.Append(User{ID: userID})
+}
+
+// Remove удаляет пользователя; файл перезаписывается атомарно (временный файл и переименование)
 func (r *FileRepository) Remove(userID int64) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -100,17 +124,15 @@ func (r *FileRepository) loadUnlocked() ([]User, error) {
 	return users, nil
 }
 
+// saveUnlocked пишет во временный файл и переименовывает его: при сбое файл не остаётся обрезанным
 func (r *FileRepository) saveUnlocked(users []User) error {
-	f, err := os.OpenFile(r.path, os.O_TRUNC|os.O_WRONLY, 0o644)
+	data, err := json.MarshalIndent(users, "", "  ")
 	if err != nil {
 		return err
 	}
-	defer func(f *os.File) {
-		err := f.Close()
-		if err != nil {
-		}
-	}(f)
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	return enc.Encode(users)
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
 }
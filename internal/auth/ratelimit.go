@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotAllowed пользователя нет в allowlist
+	ErrNotAllowed = errors.New("user is not allowed")
+	// ErrRateLimited пользователь исчерпал лимит сообщений; подробности в *RateLimitError
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// RateLimitError лимит сообщений исчерпан: следующее сообщение будет принято через RetryAfter
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RetryAfterSeconds сколько целых секунд ждать до следующего сообщения (не меньше 1)
+func (e *RateLimitError) RetryAfterSeconds() int {
+	return max(1, int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// RateLimiter token bucket на пользователя: ведро на burst сообщений пополняется со скоростью
+// perMinute сообщений в минуту
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // токенов в секунду
+	burst   float64
+	buckets map[int64]*tokenBucket
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter возвращает nil при perMinute <= 0 (без ограничения); burst <= 0 — одно сообщение
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(max(burst, 1)),
+		buckets: make(map[int64]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow забирает токен пользователя; при пустом ведре возвращает false и время до следующего токена
+func (l *RateLimiter) Allow(userID int64) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[userID]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[userID] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	// Округляем вверх, чтобы не назвать время чуть раньше появления токена
+	wait := time.Duration(math.Ceil((1 - b.tokens) / l.rate * float64(time.Second)))
+	return false, wait
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_BurstThenRefill(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(6, 2) // токен каждые 10 секунд
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow(1); !ok {
+			t.Fatalf("message %d within burst must pass", i+1)
+		}
+	}
+	ok, wait := l.Allow(1)
+	if ok || wait != 10*time.Second {
+		t.Fatalf("third message must wait 10s, got ok=%v wait=%s", ok, wait)
+	}
+	if ok, _ := l.Allow(2); !ok {
+		t.Fatal("buckets are per user")
+	}
+
+	now = now.Add(4 * time.Second)
+	if _, wait := l.Allow(1); wait != 6*time.Second {
+		t.Errorf("wait must shrink as the bucket refills, got %s", wait)
+	}
+	now = now.Add(6 * time.Second)
+	if ok, _ := l.Allow(1); !ok {
+		t.Error("refilled token must be accepted")
+	}
+}
+
+func TestService_CheckUsesRateLimiter(t *testing.T) {
+	svc, _ := NewWithRepo(nil, []int64{1})
+	if err := svc.Check(2, ""); !errors.Is(err, ErrNotAllowed) {
+		t.Fatalf("unknown user: got %v", err)
+	}
+	if err := svc.Check(1, ""); err != nil {
+		t.Fatalf("without limiter every message passes: %v", err)
+	}
+
+	svc.SetRateLimiter(NewRateLimiter(1, 1))
+	if err := svc.Check(1, ""); err != nil {
+		t.Fatalf("first message: %v", err)
+	}
+	err := svc.Check(1, "")
+	var limited *RateLimitError
+	if !errors.Is(err, ErrRateLimited) || !errors.As(err, &limited) || limited.RetryAfterSeconds() < 59 {
+		t.Fatalf("second message must be rate limited for ~60s, got %v", err)
+	}
+	if !svc.IsAllowed(1) {
+		t.Error("IsAllowed must not depend on the rate limit")
+	}
+	if NewRateLimiter(0, 5) != nil {
+		t.Error("zero rate disables the limiter")
+	}
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Role уровень доступа пользователя из allowlist
+type Role string
+
+const (
+	// RoleNone пользователь не в allowlist
+	RoleNone Role = ""
+	// RoleReadonly может только запрашивать историю диалога
+	RoleReadonly Role = "readonly"
+	// RoleUser может общаться с ботом; роль по умолчанию для allowlist
+	RoleUser Role = "user"
+	// RoleAdmin дополнительно может менять модель и работать в VibeCoding
+	RoleAdmin Role = "admin"
+)
+
+// ErrForbidden роли пользователя недостаточно для действия
+var ErrForbidden = errors.New("insufficient role")
+
+// ParseRole разбирает роль из команды или файла allowlist
+func ParseRole(s string) (Role, error) {
+	switch r := Role(strings.ToLower(strings.TrimSpace(s))); r {
+	case RoleReadonly, RoleUser, RoleAdmin:
+		return r, nil
+	}
+	return RoleNone, fmt.Errorf("unknown role %q: want admin, user or readonly", s)
+}
+
+func (r Role) rank() int {
+	switch r {
+	case RoleReadonly:
+		return 1
+	case RoleUser:
+		return 2
+	case RoleAdmin:
+		return 3
+	}
+	return 0
+}
+
+// Allows true, если роль не ниже требуемой
+func (r Role) Allows(required Role) bool {
+	return r.rank() >= required.rank()
+}
+
+// RoleOf роль пользователя: RoleNone вне allowlist, RoleUser для записей без роли
+func (s *Service) RoleOf(userID int64) Role {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.allowedUsers[userID]
+	if !ok {
+		return RoleNone
+	}
+	if u.Role == RoleNone {
+		return RoleUser
+	}
+	return u.Role
+}
+
+// RequireRole возвращает ErrForbidden, если роль пользователя ниже требуемой
+func (s *Service) RequireRole(userID int64, required Role) error {
+	if role := s.RoleOf(userID); !role.Allows(required) {
+		return fmt.Errorf("%w: user %d has role %q, %q required", ErrForbidden, userID, role, required)
+	}
+	return nil
+}
+
+// SetRole меняет роль пользователя из allowlist и сохраняет её в репозитории
+func (s *Service) SetRole(userID int64, role Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.allowedUsers[userID]
+	if !ok {
+		return fmt.Errorf("user %d is not in allowlist", userID)
+	}
+	u.Role = role
+	return s.upsertLocked(u)
+}
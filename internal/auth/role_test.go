@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestRoles_DefaultAndPersist(t *testing.T) {
+	repo, err := NewFileRepository(filepath.Join(t.TempDir(), "allowlist.json"))
+	if err != nil {
+		t.Fatalf("repo: %v", err)
+	}
+	// Запись в старом формате без роли
+	if err := repo.Upsert(User{ID: 10, Username: "alice"}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	svc, _ := NewWithRepo(repo, []int64{20})
+
+	if got := svc.RoleOf(10); got != RoleUser {
+		t.Fatalf("existing users must default to user, got %q", got)
+	}
+	if got := svc.RoleOf(30); got != RoleNone {
+		t.Fatalf("unknown user must have no role, got %q", got)
+	}
+	if err := svc.SetRole(30, RoleAdmin); err == nil {
+		t.Fatal("role for a user outside allowlist must be rejected")
+	}
+	if err := svc.SetRole(10, RoleReadonly); err != nil {
+		t.Fatalf("set role: %v", err)
+	}
+	if err := svc.SetRole(20, RoleAdmin); err != nil {
+		t.Fatalf("set role for env user: %v", err)
+	}
+	// Повторное одобрение не сбрасывает роль
+	_ = svc.Upsert(User{ID: 10, Username: "alice2"})
+
+	reloaded, _ := NewWithRepo(repo, nil)
+	if got := reloaded.RoleOf(10); got != RoleReadonly {
+		t.Fatalf("role not persisted: %q", got)
+	}
+	if got := reloaded.RoleOf(20); got != RoleAdmin {
+		t.Fatalf("env user role not persisted: %q", got)
+	}
+	if err := reloaded.RequireRole(10, RoleUser); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("readonly must not pass user check: %v", err)
+	}
+	if err := reloaded.RequireRole(20, RoleUser); err != nil {
+		t.Fatalf("admin must pass user check: %v", err)
+	}
+	if _, err := ParseRole("root"); err == nil {
+		t.Fatal("unknown role must be rejected")
+	}
+}
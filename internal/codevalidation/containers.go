@@ -0,0 +1,126 @@
+package codevalidation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dockerCreatedAtLayout формат {{.CreatedAt}} в docker ps
+const dockerCreatedAtLayout = "2006-01-02 15:04:05 -0700 MST"
+
+// ContainerInfo контейнер из docker ps с метками и размером записываемого слоя
+type ContainerInfo struct {
+	ID        string
+	Labels    map[string]string
+	CreatedAt time.Time
+	SizeBytes int64 // размер записываемого слоя контейнера, 0 — неизвестен
+}
+
+// LabeledContainerCreator DockerManager, который создаёт контейнер с метками (docker run --label)
+type LabeledContainerCreator interface {
+	CreateLabeledContainer(ctx context.Context, analysis *CodeAnalysisResult, labels map[string]string) (string, error)
+}
+
+// ContainerLister DockerManager, который перечисляет контейнеры (в том числе остановленные) с меткой label
+// в форме key или key=value
+type ContainerLister interface {
+	ListContainers(ctx context.Context, label string) ([]ContainerInfo, error)
+}
+
+// labelArgs аргументы --label, отсортированные по ключу
+func labelArgs(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	args := make([]string, 0, 2*len(keys))
+	for _, key := range keys {
+		args = append(args, "--label", key+"="+labels[key])
+	}
+	return args
+}
+
+// ListContainers перечисляет контейнеры через docker ps -a --filter label=...
+func (d *DockerClient) ListContainers(ctx context.Context, label string) ([]ContainerInfo, error) {
+	if strings.TrimSpace(label) == "" {
+		return nil, fmt.Errorf("container label filter is required")
+	}
+	cmd := exec.CommandContext(ctx, d.dockerPath, "ps", "-a", "--no-trunc", "--size",
+		"--filter", "label="+label,
+		"--format", "{{.ID}}\t{{.CreatedAt}}\t{{.Size}}\t{{.Labels}}")
+	output, err := cmd.Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("failed to list containers: %w (stderr: %s)", err, string(exitError.Stderr))
+		}
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	return parseContainerList(string(output)), nil
+}
+
+// ListContainers в mock режиме контейнеров нет
+func (m *MockDockerClient) ListContainers(ctx context.Context, label string) ([]ContainerInfo, error) {
+	return nil, nil
+}
+
+// parseContainerList разбирает вывод docker ps с форматом ID\tCreatedAt\tSize\tLabels
+func parseContainerList(output string) []ContainerInfo {
+	var containers []ContainerInfo
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(strings.TrimRight(line, "\r"), "\t", 4)
+		if len(fields) < 3 || strings.TrimSpace(fields[0]) == "" {
+			continue
+		}
+		info := ContainerInfo{ID: strings.TrimSpace(fields[0]), SizeBytes: parseContainerSize(fields[2])}
+		if len(fields) == 4 {
+			info.Labels = parseLabels(fields[3])
+		}
+		if createdAt, err := time.Parse(dockerCreatedAtLayout, fields[1]); err == nil {
+			info.CreatedAt = createdAt
+		} else {
+			log.Printf("⚠️ Unexpected container creation time %q for %s: %v", fields[1], fields[0], err)
+		}
+		containers = append(containers, info)
+	}
+	return containers
+}
+
+// parseLabels разбирает {{.Labels}}: key=value через запятую
+func parseLabels(s string) map[string]string {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, _ := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); key != "" {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// parseContainerSize размер записываемого слоя из {{.Size}}, например "12.3MB (virtual 1.1GB)"
+func parseContainerSize(s string) int64 {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), " ")
+	units := []struct {
+		suffix     string
+		multiplier float64
+	}{
+		{"kB", 1e3}, {"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12}, {"B", 1},
+	}
+	for _, unit := range units {
+		if number, ok := strings.CutSuffix(s, unit.suffix); ok {
+			value, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0
+			}
+			return int64(value * unit.multiplier)
+		}
+	}
+	return 0
+}
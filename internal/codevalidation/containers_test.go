@@ -0,0 +1,33 @@
+package codevalidation
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseContainerList(t *testing.T) {
+	output := "abc123\t2025-01-02 15:04:05 +0000 UTC\t12.5MB (virtual 1.1GB)\tai-chatter.vibecoding=1,ai-chatter.user_id=42\n" +
+		"def456\tnot a date\t0B (virtual 80MB)\t\n"
+	containers := parseContainerList(output)
+	if len(containers) != 2 {
+		t.Fatalf("expected 2 containers, got %+v", containers)
+	}
+	first := containers[0]
+	if first.ID != "abc123" || first.SizeBytes != 12500000 || first.Labels["ai-chatter.user_id"] != "42" || first.Labels["ai-chatter.vibecoding"] != "1" {
+		t.Errorf("unexpected container: %+v", first)
+	}
+	if !first.CreatedAt.Equal(time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)) {
+		t.Errorf("CreatedAt = %v", first.CreatedAt)
+	}
+	if second := containers[1]; !second.CreatedAt.IsZero() || second.SizeBytes != 0 || len(second.Labels) != 0 {
+		t.Errorf("unexpected container: %+v", second)
+	}
+}
+
+func TestLabelArgs(t *testing.T) {
+	got := strings.Join(labelArgs(map[string]string{"b": "2", "a": "1"}), " ")
+	if got != "--label a=1 --label b=2" {
+		t.Errorf("labelArgs = %q", got)
+	}
+}
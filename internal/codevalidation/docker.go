@@ -110,10 +110,16 @@ func (m *MockDockerClient) RemoveContainer(ctx context.Context, containerID stri
 
 // CreateContainer создает и запускает Docker контейнер
 func (d *DockerClient) CreateContainer(ctx context.Context, analysis *CodeAnalysisResult) (string, error) {
+	return d.CreateLabeledContainer(ctx, analysis, nil)
+}
+
+// CreateLabeledContainer создает и запускает Docker контейнер с метками labels
+func (d *DockerClient) CreateLabeledContainer(ctx context.Context, analysis *CodeAnalysisResult, labels map[string]string) (string, error) {
 	log.Printf("🐳 Creating Docker container with image: %s", analysis.DockerImage)
 
 	// Создаем контейнер с сетевыми настройками и VibeCoding MCP сервером
-	cmd := exec.CommandContext(ctx, d.dockerPath, "run", "-d", "-i",
+	args := append([]string{"run", "-d", "-i"}, labelArgs(labels)...)
+	cmd := exec.CommandContext(ctx, d.dockerPath, append(args,
 		"--workdir=/workspace",
 		"--network=host",  // Используем host сеть для доступа к интернету
 		"--dns=8.8.8.8",   // Добавляем Google DNS
@@ -122,7 +128,7 @@ func (d *DockerClient) CreateContainer(ctx context.Context, analysis *CodeAnalys
 		"-p", "8090:8090", // Порт для VibeCoding MCP сервера
 		"-e", "DEBIAN_FRONTEND=noninteractive",
 		"-v", "/tmp/vibecoding-mcp:/tmp/vibecoding-mcp", // Монтируем директорию для MCP сокетов
-		analysis.DockerImage, "sh")
+		analysis.DockerImage, "sh")...)
 
 	log.Printf("🔧 Docker command: %s", cmd.String())
 
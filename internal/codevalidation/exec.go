@@ -0,0 +1,172 @@
+package codevalidation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// workspaceRoot каталог, в который копируются файлы проекта в контейнере
+const workspaceRoot = "/workspace"
+
+// ErrInvalidExecOptions некорректный каталог или переменная окружения команды
+var ErrInvalidExecOptions = errors.New("invalid exec options")
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ExecOptions параметры одной команды в контейнере
+type ExecOptions struct {
+	WorkDir string            // каталог относительно корня проекта или абсолютный внутри /workspace; пусто — корень проекта
+	Env     map[string]string // дополнительные переменные окружения (GOFLAGS, PYTHONPATH, ...)
+	Timeout time.Duration     // таймаут команды, 0 — без отдельного таймаута
+}
+
+// Validate проверяет, что каталог не выходит за /workspace, а имена переменных допустимы для shell
+func (o ExecOptions) Validate() error {
+	if o.WorkDir != "" {
+		if _, err := ResolveWorkDir(workspaceRoot, o.WorkDir); err != nil {
+			return err
+		}
+	}
+	for name := range o.Env {
+		if !envNamePattern.MatchString(name) {
+			return fmt.Errorf("%w: environment variable name %q", ErrInvalidExecOptions, name)
+		}
+	}
+	return nil
+}
+
+// EnvList переменные окружения в виде KEY=value, отсортированные по имени
+func (o ExecOptions) EnvList() []string {
+	names := make([]string, 0, len(o.Env))
+	for name := range o.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	env := make([]string, 0, len(names))
+	for _, name := range names {
+		env = append(env, name+"="+o.Env[name])
+	}
+	return env
+}
+
+// ResolveWorkDir каталог команды: относительный путь считается от root, абсолютный должен быть внутри /workspace
+func ResolveWorkDir(root, workDir string) (string, error) {
+	if workDir == "" {
+		return root, nil
+	}
+	dir := path.Clean(workDir)
+	if !path.IsAbs(dir) {
+		dir = path.Join(root, dir)
+	}
+	if dir != workspaceRoot && !strings.HasPrefix(dir, workspaceRoot+"/") {
+		return "", fmt.Errorf("%w: workdir %q is outside %s", ErrInvalidExecOptions, workDir, workspaceRoot)
+	}
+	return dir, nil
+}
+
+// ExecResult результат одной команды в контейнере с раздельными stdout и stderr
+type ExecResult struct {
+	Command  string        `json:"command"`           // команда, переданная в sh -c
+	WorkDir  string        `json:"workdir,omitempty"` // каталог, в котором она выполнена
+	Env      []string      `json:"env,omitempty"`     // дополнительные переменные KEY=value
+	Stdout   string        `json:"stdout"`
+	Stderr   string        `json:"stderr"`
+	ExitCode int           `json:"exit_code"`
+	Success  bool          `json:"success"`
+	Duration time.Duration `json:"duration"`
+	// Combined stdout и stderr не разделены: DockerManager не реализует CommandExecutor,
+	// в Stdout — вывод ExecuteValidation как есть
+	Combined bool `json:"combined,omitempty"`
+}
+
+// ValidationResult результат в прежнем формате ExecuteValidation для вызывающих, которым нужен общий вывод
+func (r *ExecResult) ValidationResult() *ValidationResult {
+	result := &ValidationResult{
+		Success:  r.Success,
+		ExitCode: r.ExitCode,
+		Duration: r.Duration.String(),
+		Output:   r.Stdout,
+	}
+	if !r.Combined {
+		result.Output = fmt.Sprintf("=== Command: %s ===\n%s%s\n\n", r.Command, r.Stdout, r.Stderr)
+	}
+	if !r.Success {
+		result.Errors = []string{fmt.Sprintf("Command '%s' failed with exit code %d", r.Command, r.ExitCode)}
+	}
+	return result
+}
+
+// CommandExecutor DockerManager, который выполняет одну команду с каталогом и переменными окружения
+// и возвращает stdout и stderr раздельно
+type CommandExecutor interface {
+	ExecuteCommand(ctx context.Context, containerID string, analysis *CodeAnalysisResult, command string, opts ExecOptions) (*ExecResult, error)
+}
+
+// ExecuteCommand выполняет команду через docker exec -w/-e; ненулевой код выхода — не ошибка, а Success=false
+func (d *DockerClient) ExecuteCommand(ctx context.Context, containerID string, analysis *CodeAnalysisResult, command string, opts ExecOptions) (*ExecResult, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	workDir, err := ResolveWorkDir(d.getWorkingDirectory(ctx, containerID, analysis), opts.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+	result := &ExecResult{Command: command, WorkDir: workDir, Env: opts.EnvList()}
+
+	args := []string{"exec", "-w", workDir}
+	for _, kv := range result.Env {
+		args = append(args, "-e", kv)
+	}
+	args = append(args, containerID, "sh", "-c", command)
+	log.Printf("⚡ Running command in %s: %s", workDir, command)
+
+	var stdout, stderr bytes.Buffer
+	execCmd := exec.CommandContext(ctx, d.dockerPath, args...)
+	execCmd.Stdout = &stdout
+	execCmd.Stderr = &stderr
+	start := time.Now()
+	err = execCmd.Run()
+	result.Duration = time.Since(start)
+	result.Stdout, result.Stderr = stdout.String(), stderr.String()
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("docker exec failed: %w", err)
+		}
+		result.ExitCode = exitErr.ExitCode()
+	}
+	result.Success = result.ExitCode == 0
+	return result, nil
+}
+
+// ExecuteCommand в mock режиме команда не выполняется
+func (m *MockDockerClient) ExecuteCommand(ctx context.Context, containerID string, analysis *CodeAnalysisResult, command string, opts ExecOptions) (*ExecResult, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	log.Printf("🔧 Mock: Executing command: %s", command)
+	workDir, _ := ResolveWorkDir(workspaceRoot, opts.WorkDir)
+	return &ExecResult{
+		Command: command,
+		WorkDir: workDir,
+		Env:     opts.EnvList(),
+		Stdout:  "Mock validation completed - Docker is not available for actual execution",
+		Success: true,
+	}, nil
+}
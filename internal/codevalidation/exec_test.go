@@ -0,0 +1,71 @@
+package codevalidation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolveWorkDir(t *testing.T) {
+	cases := []struct {
+		workDir string
+		want    string
+		wantErr bool
+	}{
+		{"", "/workspace/app", false},
+		{"cmd/server", "/workspace/app/cmd/server", false},
+		{"./lib/../pkg", "/workspace/app/pkg", false},
+		{"/workspace/other", "/workspace/other", false},
+		{"../..", "", true},
+		{"/etc", "", true},
+		{"/workspacex", "", true},
+	}
+	for _, tc := range cases {
+		got, err := ResolveWorkDir("/workspace/app", tc.workDir)
+		if tc.wantErr {
+			if !errors.Is(err, ErrInvalidExecOptions) {
+				t.Errorf("ResolveWorkDir(%q): expected ErrInvalidExecOptions, got %q, %v", tc.workDir, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("ResolveWorkDir(%q) = %q, %v; want %q", tc.workDir, got, err, tc.want)
+		}
+	}
+}
+
+func TestExecOptions_ValidateAndEnvList(t *testing.T) {
+	opts := ExecOptions{Env: map[string]string{"PYTHONPATH": "src", "GOFLAGS": "-mod=mod"}}
+	if err := opts.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if got := strings.Join(opts.EnvList(), " "); got != "GOFLAGS=-mod=mod PYTHONPATH=src" {
+		t.Errorf("EnvList = %q", got)
+	}
+
+	for _, bad := range []ExecOptions{
+		{Env: map[string]string{"BAD-NAME": "x"}},
+		{Env: map[string]string{"A=B": "x"}},
+		{WorkDir: "../../etc"},
+	} {
+		if err := bad.Validate(); !errors.Is(err, ErrInvalidExecOptions) {
+			t.Errorf("Validate(%+v) = %v, want ErrInvalidExecOptions", bad, err)
+		}
+	}
+}
+
+func TestExecResult_ValidationResult(t *testing.T) {
+	split := &ExecResult{Command: "go build ./...", Stdout: "building\n", Stderr: "main.go:3: undefined: x\n", ExitCode: 2}
+	got := split.ValidationResult()
+	if got.Success || got.ExitCode != 2 || len(got.Errors) != 1 {
+		t.Errorf("unexpected result: %+v", got)
+	}
+	if want := "=== Command: go build ./... ===\nbuilding\nmain.go:3: undefined: x\n\n\n"; got.Output != want {
+		t.Errorf("Output = %q, want %q", got.Output, want)
+	}
+
+	combined := &ExecResult{Command: "ls", Stdout: "=== Command: ls ===\na.go\n\n", Success: true, Combined: true}
+	if got := combined.ValidationResult(); got.Output != combined.Stdout || !got.Success {
+		t.Errorf("combined output must be kept as is: %+v", got)
+	}
+}
@@ -2,13 +2,13 @@ package codevalidation
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
 	"ai-chatter/internal/llm"
+	"ai-chatter/internal/llm/jsonextract"
 )
 
 // CodeValidationWorkflow координирует валидацию кода
@@ -957,14 +957,7 @@ Focus on:
 
 // parseJSONResponse парсит JSON ответ от LLM с обработкой ошибок
 func parseJSONResponse(content string, target interface{}) error {
-	// Удаляем возможные markdown блоки
-	content = strings.TrimPrefix(content, "```json")
-	content = strings.TrimPrefix(content, "```")
-	content = strings.TrimSuffix(content, "```")
-	content = strings.TrimSpace(content)
-
-	// Добавляем недостающий import
-	return json.Unmarshal([]byte(content), target)
+	return jsonextract.Decode(content, target, jsonextract.Options{Repair: true})
 }
 
 // estimateAnalysisTokens оценивает количество токенов для анализа проекта
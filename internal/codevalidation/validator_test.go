@@ -10,6 +10,8 @@ import (
 
 // Mock LLM client for testing
 type mockLLMClient struct {
+	llm.NoStreaming
+	llm.LocalTokenCounter
 	response llm.Response
 	err      error
 }
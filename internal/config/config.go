@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 )
@@ -9,14 +12,19 @@ import (
 type LLMProvider string
 
 const (
-	ProviderOpenAI LLMProvider = "openai"
-	ProviderYandex LLMProvider = "yandex"
+	ProviderOpenAI    LLMProvider = "openai"
+	ProviderYandex    LLMProvider = "yandex"
+	ProviderAnthropic LLMProvider = "anthropic"
 )
 
 type Config struct {
-	TelegramBotToken string  `env:"TELEGRAM_BOT_TOKEN,required"`
+	TelegramBotToken string  `env:"TELEGRAM_BOT_TOKEN"`
 	AllowedUsers     []int64 `env:"ALLOWED_USERS" envSeparator:":"`
 	AdminUserID      int64   `env:"ADMIN_USER_ID"`
+	// Ограничение сообщений пользователя (token bucket): AuthRatePerMinute в минуту, до AuthRateBurst подряд;
+	// 0 — без ограничения. Администратор не ограничивается
+	AuthRatePerMinute int `env:"AUTH_RATE_PER_MINUTE" envDefault:"0"`
+	AuthRateBurst     int `env:"AUTH_RATE_BURST" envDefault:"5"`
 
 	// LLM settings
 	LLMProvider      LLMProvider `env:"LLM_PROVIDER" envDefault:"openai"`
@@ -25,36 +33,194 @@ type Config struct {
 	OpenAIModel      string      `env:"OPENAI_MODEL" envDefault:"gpt-3.5-turbo"`
 	YandexOAuthToken string      `env:"YANDEX_OAUTH_TOKEN"`
 	YandexFolderID   string      `env:"YANDEX_FOLDER_ID"`
+	AnthropicAPIKey  string      `env:"ANTHROPIC_API_KEY"`
+	AnthropicModel   string      `env:"ANTHROPIC_MODEL" envDefault:"claude-3-5-sonnet-latest"`
+
+	// Вторая модель (пост-обработка ТЗ); data/model2.txt имеет приоритет
+	SecondaryModel string `env:"LLM_SECONDARY_MODEL"`
+	// Ограничение запросов к LLM на весь бот; 0 — без ограничения
+	LLMRequestsPerMinute int `env:"LLM_REQUESTS_PER_MINUTE" envDefault:"0"`
+	// Резервные модели через запятую ("model" того же провайдера или "provider:model"):
+	// при ошибке основной модели запрос повторяется со следующей
+	LLMFallbackModels []string `env:"LLM_FALLBACK_MODELS" envSeparator:","`
+	// Журнал запросов к LLM (сообщения и ответы, обрезанные до 500 символов) рядом с историей;
+	// просмотр — /admin_llm_log
+	LLMLogRequests bool `env:"LLM_LOG_REQUESTS" envDefault:"false"`
+
+	// Профиль окружения (dev/staging/prod) из файла профилей; пустой — только переменные окружения.
+	// Профиль выбирается при запуске, смена требует перезапуска
+	Profile          string `env:"AI_CHATTER_PROFILE"`
+	ProfilesFilePath string `env:"PROFILES_FILE_PATH" envDefault:"profiles.json"`
 
 	// OpenRouter (optional)
 	OpenRouterReferrer string `env:"OPENROUTER_REFERRER"`
 	OpenRouterTitle    string `env:"OPENROUTER_TITLE"`
+	// Резервные модели через запятую: при 429/502/503/model_not_available запрос повторяется со следующей
+	OpenRouterFallbackModels []string `env:"OPENROUTER_FALLBACK_MODELS" envSeparator:","`
+	// Подпись "ответила резервная модель" под ответом в Telegram
+	OpenRouterFallbackFooter bool `env:"OPENROUTER_FALLBACK_FOOTER" envDefault:"true"`
 
 	// Prompts
 	SystemPromptPath string `env:"SYSTEM_PROMPT_PATH" envDefault:"prompts/system_prompt.txt"`
+	// MessagesFilePath шаблоны сообщений пользователям (приветствие, отказ, лимит, обслуживание);
+	// пусто — встроенные тексты. Файл перечитывается при изменении
+	MessagesFilePath string `env:"MESSAGES_FILE_PATH"`
+	// AdminContact контакт администратора для шаблонов ({{.AdminContact}}), например @username
+	AdminContact string `env:"ADMIN_CONTACT"`
+	// MaintenanceMode всем, кроме администратора, отвечать сообщением maintenance (включает режим при запуске;
+	// выключается командой /maintenance off)
+	MaintenanceMode bool `env:"MAINTENANCE_MODE" envDefault:"false"`
+	// MaintenanceFilePath состояние /maintenance и отложенные на время обслуживания уведомления
+	MaintenanceFilePath string `env:"MAINTENANCE_FILE_PATH" envDefault:"data/maintenance.json"`
 
 	// Storage
 	LogFilePath       string `env:"LOG_FILE_PATH" envDefault:"logs/log.jsonl"`
 	AllowlistFilePath string `env:"ALLOWLIST_FILE_PATH" envDefault:"data/allowlist.json"`
 	PendingFilePath   string `env:"PENDING_FILE_PATH" envDefault:"data/pending.json"`
+	// PendingRequestTTLDays через сколько дней необработанная заявка на доступ удаляется (0 — бессрочно)
+	PendingRequestTTLDays int `env:"PENDING_REQUEST_TTL_DAYS" envDefault:"7"`
+
+	// StorageBackend хранилище истории: file (JSONL в LOG_FILE_PATH) или sqlite (SQLITE_PATH)
+	StorageBackend string `env:"STORAGE_BACKEND" envDefault:"file"`
+	SQLitePath     string `env:"SQLITE_PATH" envDefault:"data/chatter.db"`
+	// SQLiteImportLog переносит LOG_FILE_PATH в пустую базу SQLite при запуске (однократно)
+	SQLiteImportLog bool `env:"SQLITE_IMPORT_LOG" envDefault:"true"`
+
+	// Inline-кнопки подтверждений: файл хранения (пустой — только в памяти) и срок действия кнопки
+	CallbackActionsFilePath string        `env:"CALLBACK_ACTIONS_FILE_PATH" envDefault:"data/callback_actions.json"`
+	CallbackActionTTL       time.Duration `env:"CALLBACK_ACTION_TTL" envDefault:"24h"`
 
 	// Overrides persistence
 	ProviderFilePath string `env:"PROVIDER_FILE_PATH" envDefault:"data/provider.txt"`
 	ModelFilePath    string `env:"MODEL_FILE_PATH" envDefault:"data/model.txt"`
 	Model2FilePath   string `env:"MODEL2_FILE_PATH" envDefault:"data/model2.txt"`
+	// Per-user model overrides (/model <provider> <name>)
+	UserModelsFilePath     string `env:"USER_MODELS_FILE_PATH" envDefault:"data/user_models.json"`
+	AllowUserModelOverride bool   `env:"ALLOW_USER_MODEL_OVERRIDE" envDefault:"false"`
+	// Курируемый список моделей для кнопок /model: "openai=m1,!m2;anthropic=m3" («!» — только для админа)
+	ModelCatalog string `env:"MODEL_CATALOG"`
+	// персональные настройки (язык, часовой пояс, страница Notion) и отметка о пройденном онбординге
+	UserSettingsFilePath string `env:"USER_SETTINGS_FILE_PATH" envDefault:"data/user_settings.json"`
+
+	// Conversation history depth: сообщений на пользователя и примерный бюджет токенов (0 — без ограничения)
+	HistoryMaxMessages int `env:"HISTORY_MAX_MESSAGES" envDefault:"20"`
+	HistoryMaxTokens   int `env:"HISTORY_MAX_TOKENS" envDefault:"0"`
+
+	// Document library ("ask the docs"): пустой DOCS_LIBRARY_DIR отключает режим,
+	// DOCS_AUTO_THRESHOLD 0 отключает автоматическое подмешивание фрагментов
+	DocsLibraryDir      string  `env:"DOCS_LIBRARY_DIR" envDefault:"data/docs"`
+	DocsMaxBytesPerUser int64   `env:"DOCS_MAX_BYTES_PER_USER" envDefault:"5242880"`
+	DocsMaxDocsPerUser  int     `env:"DOCS_MAX_DOCS_PER_USER" envDefault:"20"`
+	DocsAutoThreshold   float64 `env:"DOCS_AUTO_THRESHOLD" envDefault:"0.75"`
+	DocsEmbeddingModel  string  `env:"DOCS_EMBEDDING_MODEL" envDefault:"text-embedding-3-small"`
+
+	// Voice messages: распознавание через Whisper с ключом OPENAI_API_KEY; пустая модель отключает распознавание
+	TranscriptionModel   string        `env:"TRANSCRIPTION_MODEL" envDefault:"whisper-1"`
+	TranscriptionBaseURL string        `env:"TRANSCRIPTION_BASE_URL" envDefault:"https://api.openai.com/v1"`
+	TranscriptionTimeout time.Duration `env:"TRANSCRIPTION_TIMEOUT" envDefault:"60s"`
+	VoiceMaxDuration     time.Duration `env:"VOICE_MAX_DURATION" envDefault:"5m"`
+
+	// Ежедневный отчёт администратору: cron-выражение в UTC
+	ReportSchedule string `env:"REPORT_SCHEDULE" envDefault:"0 21 * * *"`
+	// Разделы отчёта: каждый строится независимо со своим таймаутом
+	ReportChatStatsEnabled  bool          `env:"REPORT_CHAT_STATS_ENABLED" envDefault:"true"`
+	ReportGmailEnabled      bool          `env:"REPORT_GMAIL_ENABLED" envDefault:"true"`
+	ReportGmailQuery        string        `env:"REPORT_GMAIL_QUERY" envDefault:"is:unread is:important"`
+	ReportRuStoreEnabled    bool          `env:"REPORT_RUSTORE_ENABLED" envDefault:"true"`
+	ReportRuStorePackages   []string      `env:"REPORT_RUSTORE_PACKAGES" envSeparator:","`
+	ReportVibeCodingEnabled bool          `env:"REPORT_VIBECODING_ENABLED" envDefault:"true"`
+	ReportSectionTimeout    time.Duration `env:"REPORT_SECTION_TIMEOUT" envDefault:"30s"`
+	// Счётчики использования функций (/stats): только имена команд и функций по пользователям и дням; пустой путь отключает
+	UsageStatsFilePath string `env:"USAGE_STATS_FILE_PATH" envDefault:"data/usage_stats.json"`
+	// Еженедельный дайджест использования функций администратору: cron-выражение в UTC
+	WeeklyDigestSchedule string `env:"WEEKLY_DIGEST_SCHEDULE" envDefault:"0 9 * * 1"`
+
+	// Платежи (/topup): пользователи вне allowlist покупают пакеты сообщений через Telegram Payments.
+	// Без токена провайдера модуль отключён
+	PaymentsProviderToken string `env:"PAYMENTS_PROVIDER_TOKEN"`
+	PaymentsCurrency      string `env:"PAYMENTS_CURRENCY" envDefault:"RUB"`
+	// Пакеты "id:сообщений:цена" через запятую
+	PaymentsPackages string `env:"PAYMENTS_PACKAGES" envDefault:"s:50:99,m:200:299"`
+	PaymentsFilePath string `env:"PAYMENTS_FILE_PATH" envDefault:"data/payments.json"`
+	// Сверка платежей за прошлый месяц администратору: cron-выражение в UTC
+	PaymentsReportSchedule string `env:"PAYMENTS_REPORT_SCHEDULE" envDefault:"0 9 1 * *"`
+
+	// Reminders (/remind): файл хранения и часовой пояс для абсолютного времени; пустой путь отключает команду
+	RemindersFilePath string `env:"REMINDERS_FILE_PATH" envDefault:"data/reminders.json"`
+	RemindersTimezone string `env:"REMINDERS_TIMEZONE" envDefault:"UTC"`
+
+	// Scheduled prompts (/schedule): файл хранения и лимит на пользователя; часовой пояс по умолчанию — REMINDERS_TIMEZONE
+	ScheduledPromptsFilePath   string `env:"SCHEDULED_PROMPTS_FILE_PATH" envDefault:"data/scheduled_prompts.json"`
+	ScheduledPromptsMaxPerUser int    `env:"SCHEDULED_PROMPTS_MAX_PER_USER" envDefault:"5"`
 
 	// Formatting
 	MessageParseMode string `env:"MESSAGE_PARSE_MODE" envDefault:"HTML"`
 
+	// Streaming: ответ показывается по мере генерации редактированием сообщения (интервал не меньше 1s)
+	StreamingEnabled      bool          `env:"STREAMING_ENABLED" envDefault:"true"`
+	StreamingEditInterval time.Duration `env:"STREAMING_EDIT_INTERVAL" envDefault:"1500ms"`
+
 	// Notion integration
 	NotionToken      string `env:"NOTION_TOKEN"`
 	NotionParentPage string `env:"NOTION_PARENT_PAGE_ID"`
+
+	// VibeCoding: попытки исправления/генерации тестов и таймауты (0 — без таймаута)
+	VibeCodingMaxTestFixAttempts        int           `env:"VIBECODING_MAX_TEST_FIX_ATTEMPTS" envDefault:"3"`
+	VibeCodingMaxTestGenAttempts        int           `env:"VIBECODING_MAX_TEST_GEN_ATTEMPTS" envDefault:"5"`
+	VibeCodingMaxTestValidationAttempts int           `env:"VIBECODING_MAX_TEST_VALIDATION_ATTEMPTS" envDefault:"3"`
+	VibeCodingCommandTimeout            time.Duration `env:"VIBECODING_COMMAND_TIMEOUT" envDefault:"0s"`
+	VibeCodingLLMTimeout                time.Duration `env:"VIBECODING_LLM_TIMEOUT" envDefault:"0s"`
+	// Порт веб-интерфейса сессий VibeCoding; 0 — не запускать
+	VibeCodingWebPort int `env:"VIBECODING_WEB_PORT" envDefault:"8080"`
+	// Отдельный порт для /metrics (Prometheus); 0 — метрики только на порту веб-интерфейса
+	VibeCodingMetricsPort int `env:"VIBECODING_METRICS_PORT" envDefault:"0"`
+	// Каталог снимков сессий VibeCoding для восстановления после перезапуска; пусто — не сохранять
+	VibeCodingSessionsPath string `env:"VIBECODING_SESSIONS_PATH" envDefault:"data/vibecoding_sessions"`
+	// Автозакрытие сессии VibeCoding без активности (контейнер удаляется); 0 — не закрывать
+	VibeCodingIdleTimeout time.Duration `env:"VIBECODING_IDLE_TIMEOUT" envDefault:"30m"`
+	// Сборка осиротевших контейнеров VibeCoding: период (0 — только при запуске) и возраст,
+	// после которого контейнер без сессии удаляется
+	VibeCodingContainerGCInterval time.Duration `env:"VIBECODING_CONTAINER_GC_INTERVAL" envDefault:"30m"`
+	VibeCodingContainerGCGrace    time.Duration `env:"VIBECODING_CONTAINER_GC_GRACE" envDefault:"1h"`
+	// Каталог записей автономных запусков /vibecoding_auto для воспроизведения (cmd/vibecoding-replay); пусто — не записывать
+	VibeCodingRecordDir string `env:"VIBECODING_RECORD_DIR"`
+	// Цены за миллион токенов в USD для оценки стоимости сессии VibeCoding; 0 — стоимость не считается
+	VibeCodingPromptPricePerMillion     float64 `env:"VIBECODING_PROMPT_PRICE_PER_1M" envDefault:"0"`
+	VibeCodingCompletionPricePerMillion float64 `env:"VIBECODING_COMPLETION_PRICE_PER_1M" envDefault:"0"`
+	// /vibecoding_matrix: одновременно запущенных контейнеров и всего контейнеров на сессию
+	VibeCodingMatrixParallelism     int `env:"VIBECODING_MATRIX_PARALLELISM" envDefault:"2"`
+	VibeCodingMatrixContainerBudget int `env:"VIBECODING_MATRIX_CONTAINER_BUDGET" envDefault:"10"`
+	// Окно контекста модели в токенах для проверки размера промпта; 0 — по имени модели
+	VibeCodingContextWindow int `env:"VIBECODING_CONTEXT_WINDOW" envDefault:"0"`
+
+	// loadProblems ошибки разбора переменных окружения и профиля; Validate выводит их вместе с остальными
+	loadProblems []string
 }
 
+// New читает конфигурацию из окружения и профиля. Ошибки не прерывают запуск сразу:
+// они накапливаются и возвращаются из Validate вместе с остальными проблемами
 func New() *Config {
 	cfg := &Config{}
 	if err := env.Parse(cfg); err != nil {
-		log.Fatalf("failed to parse config: %v", err)
+		cfg.loadProblems = append(cfg.loadProblems, splitEnvError(err)...)
+	}
+	if cfg.Profile != "" {
+		if err := cfg.ApplyProfile(); err != nil {
+			cfg.loadProblems = append(cfg.loadProblems, fmt.Sprintf("invalid configuration profile: %v", err))
+		} else {
+			log.Printf("%s", cfg.ProfileSummary())
+		}
 	}
 	return cfg
 }
+
+// splitEnvError разбивает сводную ошибку env ("env: a; b") на отдельные проблемы
+func splitEnvError(err error) []string {
+	var problems []string
+	for _, part := range strings.Split(strings.TrimPrefix(err.Error(), "env:"), ";") {
+		if part = strings.TrimSpace(part); part != "" {
+			problems = append(problems, part)
+		}
+	}
+	return problems
+}
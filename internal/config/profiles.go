@@ -0,0 +1,180 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Profile набор настроек окружения (dev/staging/prod) из файла профилей.
+// Пустые поля не меняют значения из переменных окружения; строки поддерживают ${VAR},
+// чтобы ключи оставались в окружении, а не в файле
+type Profile struct {
+	LLMProvider      LLMProvider     `json:"llm_provider"`
+	OpenAIAPIKey     string          `json:"openai_api_key"`
+	OpenAIBaseURL    string          `json:"openai_base_url"`
+	YandexOAuthToken string          `json:"yandex_oauth_token"`
+	YandexFolderID   string          `json:"yandex_folder_id"`
+	AnthropicAPIKey  string          `json:"anthropic_api_key"`
+	AnthropicModel   string          `json:"anthropic_model"`
+	Models           ProfileModels   `json:"models"`
+	RateLimits       ProfileLimits   `json:"rate_limits"`
+	Features         map[string]bool `json:"features"`
+}
+
+// ProfileModels маршруты моделей: основная модель и вторая (пост-обработка ТЗ, /model2)
+type ProfileModels struct {
+	Primary   string `json:"primary"`
+	Secondary string `json:"secondary"`
+}
+
+// ProfileLimits ограничения нагрузки на провайдера
+type ProfileLimits struct {
+	LLMRequestsPerMinute int `json:"llm_requests_per_minute"`
+}
+
+// profileFeatures флаги, которые можно задать в профиле, и поля конфигурации, которые они включают
+var profileFeatures = map[string]func(*Config, bool){
+	"streaming":           func(c *Config, v bool) { c.StreamingEnabled = v },
+	"user_model_override": func(c *Config, v bool) { c.AllowUserModelOverride = v },
+}
+
+// LoadProfiles читает файл профилей: JSON-объект имя профиля -> настройки
+func LoadProfiles(path string) (map[string]Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read profiles file: %w", err)
+	}
+	var profiles map[string]Profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("parse profiles file %s: %w", path, err)
+	}
+	return profiles, nil
+}
+
+// ApplyProfile загружает профиль cfg.Profile из cfg.ProfilesFilePath, применяет его поверх
+// настроек окружения и проверяет, что для выбранного провайдера есть всё необходимое
+func (c *Config) ApplyProfile() error {
+	profiles, err := LoadProfiles(c.ProfilesFilePath)
+	if err != nil {
+		return err
+	}
+	p, ok := profiles[c.Profile]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("profile %q not found in %s (available: %s)", c.Profile, c.ProfilesFilePath, strings.Join(names, ", "))
+	}
+
+	set := func(dst *string, value string) {
+		if v := os.ExpandEnv(value); v != "" {
+			*dst = v
+		}
+	}
+	if p.LLMProvider != "" {
+		c.LLMProvider = p.LLMProvider
+	}
+	set(&c.OpenAIAPIKey, p.OpenAIAPIKey)
+	set(&c.OpenAIBaseURL, p.OpenAIBaseURL)
+	set(&c.YandexOAuthToken, p.YandexOAuthToken)
+	set(&c.YandexFolderID, p.YandexFolderID)
+	set(&c.AnthropicAPIKey, p.AnthropicAPIKey)
+	set(&c.AnthropicModel, p.AnthropicModel)
+	set(&c.OpenAIModel, p.Models.Primary)
+	set(&c.SecondaryModel, p.Models.Secondary)
+	if p.RateLimits.LLMRequestsPerMinute != 0 {
+		c.LLMRequestsPerMinute = p.RateLimits.LLMRequestsPerMinute
+	}
+	for name, value := range p.Features {
+		apply, ok := profileFeatures[name]
+		if !ok {
+			return fmt.Errorf("profile %q: unknown feature flag %q", c.Profile, name)
+		}
+		apply(c, value)
+	}
+	return c.validateLLM()
+}
+
+// validateLLM проверяет провайдера, ключи к нему и ограничения
+func (c *Config) validateLLM() error {
+	switch c.LLMProvider {
+	case ProviderOpenAI:
+		if c.OpenAIAPIKey == "" {
+			return fmt.Errorf("profile %q: provider openai requires openai_api_key", c.Profile)
+		}
+	case ProviderAnthropic:
+		if c.AnthropicAPIKey == "" {
+			return fmt.Errorf("profile %q: provider anthropic requires anthropic_api_key", c.Profile)
+		}
+	case ProviderYandex:
+		if c.YandexOAuthToken == "" || c.YandexFolderID == "" {
+			return fmt.Errorf("profile %q: provider yandex requires yandex_oauth_token and yandex_folder_id", c.Profile)
+		}
+	default:
+		return fmt.Errorf("profile %q: unknown llm provider %q", c.Profile, c.LLMProvider)
+	}
+	if c.LLMRequestsPerMinute < 0 {
+		return fmt.Errorf("profile %q: llm_requests_per_minute must not be negative", c.Profile)
+	}
+	return nil
+}
+
+// ProfileName имя активного профиля для вывода; без профиля — "default"
+func (c *Config) ProfileName() string {
+	if c.Profile == "" {
+		return "default"
+	}
+	return c.Profile
+}
+
+// ProfileSummary сводка активного профиля для лога при запуске; ключи маскируются
+func (c *Config) ProfileSummary() string {
+	model := c.OpenAIModel
+	if c.LLMProvider == ProviderAnthropic {
+		model = c.AnthropicModel
+	}
+	secondary := c.SecondaryModel
+	if secondary == "" {
+		secondary = "-"
+	}
+	limit := "без ограничения"
+	if c.LLMRequestsPerMinute > 0 {
+		limit = fmt.Sprintf("%d запросов/мин", c.LLMRequestsPerMinute)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🧭 Профиль: %s\n", strings.ToUpper(c.ProfileName())))
+	sb.WriteString(fmt.Sprintf("   провайдер: %s, модель: %s, вторая модель: %s\n", c.LLMProvider, model, secondary))
+	switch c.LLMProvider {
+	case ProviderOpenAI:
+		sb.WriteString(fmt.Sprintf("   ключ: %s, base url: %s\n", maskSecret(c.OpenAIAPIKey), orDash(c.OpenAIBaseURL)))
+	case ProviderAnthropic:
+		sb.WriteString(fmt.Sprintf("   ключ: %s\n", maskSecret(c.AnthropicAPIKey)))
+	case ProviderYandex:
+		sb.WriteString(fmt.Sprintf("   токен: %s, folder: %s\n", maskSecret(c.YandexOAuthToken), c.YandexFolderID))
+	}
+	sb.WriteString(fmt.Sprintf("   лимит LLM: %s\n", limit))
+	sb.WriteString(fmt.Sprintf("   streaming: %v, user_model_override: %v", c.StreamingEnabled, c.AllowUserModelOverride))
+	return sb.String()
+}
+
+func maskSecret(s string) string {
+	if s == "" {
+		return "(не задан)"
+	}
+	if len(s) <= 8 {
+		return "****"
+	}
+	return s[:4] + "..." + s[len(s)-4:]
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
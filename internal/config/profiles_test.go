@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeProfiles(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write profiles: %v", err)
+	}
+	return path
+}
+
+func TestApplyProfile(t *testing.T) {
+	t.Setenv("TEST_PROD_KEY", "sk-ant-prod-secret-key")
+	path := writeProfiles(t, `{
+		"dev":  {"llm_provider": "openai", "openai_api_key": "sk-dev"},
+		"prod": {"llm_provider": "anthropic", "anthropic_api_key": "${TEST_PROD_KEY}",
+		         "models": {"primary": "gpt-4o", "secondary": "gpt-4o-mini"},
+		         "rate_limits": {"llm_requests_per_minute": 90},
+		         "features": {"streaming": false, "user_model_override": true}}
+	}`)
+
+	cfg := &Config{Profile: "prod", ProfilesFilePath: path, LLMProvider: ProviderOpenAI, OpenAIModel: "gpt-3.5-turbo", StreamingEnabled: true}
+	if err := cfg.ApplyProfile(); err != nil {
+		t.Fatalf("ApplyProfile: %v", err)
+	}
+	if cfg.LLMProvider != ProviderAnthropic || cfg.AnthropicAPIKey != "sk-ant-prod-secret-key" {
+		t.Errorf("provider settings not applied: %+v", cfg)
+	}
+	if cfg.OpenAIModel != "gpt-4o" || cfg.SecondaryModel != "gpt-4o-mini" || cfg.LLMRequestsPerMinute != 90 {
+		t.Errorf("models/limits not applied: %+v", cfg)
+	}
+	if cfg.StreamingEnabled || !cfg.AllowUserModelOverride {
+		t.Errorf("feature flags not applied: %+v", cfg)
+	}
+	summary := cfg.ProfileSummary()
+	if !strings.HasPrefix(summary, "🧭 Профиль: PROD") || strings.Contains(summary, "prod-secret") {
+		t.Errorf("summary must name the profile and mask keys:\n%s", summary)
+	}
+}
+
+func TestApplyProfile_Validation(t *testing.T) {
+	path := writeProfiles(t, `{
+		"nokey":   {"llm_provider": "anthropic"},
+		"badflag": {"llm_provider": "openai", "openai_api_key": "k", "features": {"turbo": true}},
+		"badprov": {"llm_provider": "gigachat"}
+	}`)
+	cases := map[string]string{
+		"nokey":   "anthropic_api_key",
+		"badflag": "unknown feature flag",
+		"badprov": "unknown llm provider",
+		"staging": "not found",
+	}
+	for profile, want := range cases {
+		cfg := &Config{Profile: profile, ProfilesFilePath: path}
+		err := cfg.ApplyProfile()
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("profile %s: expected error containing %q, got %v", profile, want, err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"ai-chatter/internal/messages"
+)
+
+// telegramTokenPattern формат токена бота от BotFather: <id бота>:<секрет>
+var telegramTokenPattern = regexp.MustCompile(`^\d+:[A-Za-z0-9_-]{30,}$`)
+
+// supportedParseModes значения MESSAGE_PARSE_MODE, которые понимает Telegram
+var supportedParseModes = []string{"HTML", "Markdown", "MarkdownV2"}
+
+// ValidationError все проблемы конфигурации, найденные при запуске
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d configuration problem(s):", len(e.Problems)))
+	for _, p := range e.Problems {
+		sb.WriteString("\n  - " + p)
+	}
+	return sb.String()
+}
+
+// Validate проверяет конфигурацию целиком и возвращает все проблемы сразу (*ValidationError),
+// а не первую. warnings — настройки, с которыми бот запустится, но часть функций работать не будет
+func (c *Config) Validate() (warnings []string, err error) {
+	problems := append([]string(nil), c.loadProblems...)
+	problem := func(format string, args ...any) { problems = append(problems, fmt.Sprintf(format, args...)) }
+	warn := func(format string, args ...any) { warnings = append(warnings, fmt.Sprintf(format, args...)) }
+
+	// Обязательные поля
+	switch {
+	case c.TelegramBotToken == "":
+		problem("TELEGRAM_BOT_TOKEN is required")
+	case !telegramTokenPattern.MatchString(c.TelegramBotToken):
+		problem("TELEGRAM_BOT_TOKEN has invalid format (expected <bot id>:<secret> from BotFather)")
+	}
+	problems = append(problems, c.llmCredentialProblems()...)
+
+	// Форматы
+	if c.AdminUserID < 0 {
+		problem("ADMIN_USER_ID must be a positive Telegram user ID, got %d", c.AdminUserID)
+	} else if c.AdminUserID == 0 {
+		warn("ADMIN_USER_ID is not set: daily reports and access requests will not be delivered")
+	}
+	for _, id := range c.AllowedUsers {
+		if id <= 0 {
+			problem("ALLOWED_USERS contains invalid user ID %d", id)
+		}
+	}
+	if !containsFold(supportedParseModes, c.MessageParseMode) {
+		problem("MESSAGE_PARSE_MODE must be one of %s, got %q", strings.Join(supportedParseModes, ", "), c.MessageParseMode)
+	}
+	if c.StorageBackend != "file" && c.StorageBackend != "sqlite" {
+		problem("STORAGE_BACKEND must be file or sqlite, got %q", c.StorageBackend)
+	}
+	if _, err := time.LoadLocation(c.RemindersTimezone); err != nil {
+		problem("REMINDERS_TIMEZONE %q is not a valid time zone: %v", c.RemindersTimezone, err)
+	}
+	for _, limit := range []struct {
+		env   string
+		value int
+	}{
+		{"LLM_REQUESTS_PER_MINUTE", c.LLMRequestsPerMinute},
+		{"AUTH_RATE_PER_MINUTE", c.AuthRatePerMinute},
+		{"AUTH_RATE_BURST", c.AuthRateBurst},
+		{"HISTORY_MAX_MESSAGES", c.HistoryMaxMessages},
+		{"HISTORY_MAX_TOKENS", c.HistoryMaxTokens},
+		{"PENDING_REQUEST_TTL_DAYS", c.PendingRequestTTLDays},
+	} {
+		if limit.value < 0 {
+			problem("%s must not be negative, got %d", limit.env, limit.value)
+		}
+	}
+	for _, f := range c.dataFiles() {
+		if err := checkCreatable(f.path); err != nil {
+			problem("%s: %v", f.env, err)
+		}
+	}
+
+	// Необязательные интеграции: только предупреждения
+	if c.NotionToken != "" && c.NotionParentPage == "" {
+		warn("NOTION_TOKEN is set but NOTION_PARENT_PAGE_ID is missing: only users with their own Notion page in settings can save to Notion")
+	}
+	if c.NotionToken == "" && c.NotionParentPage != "" {
+		warn("NOTION_PARENT_PAGE_ID is set but NOTION_TOKEN is missing: Notion integration is disabled")
+	}
+	if c.SystemPromptPath != "" {
+		if _, err := os.Stat(c.SystemPromptPath); err != nil {
+			warn("SYSTEM_PROMPT_PATH %s is not readable, the bot will run without a system prompt: %v", c.SystemPromptPath, err)
+		}
+	}
+	if c.MessagesFilePath != "" {
+		if _, err := messages.LoadFile(c.MessagesFilePath); err != nil {
+			problem("MESSAGES_FILE_PATH: %v", err)
+		}
+	}
+	if c.ReportRuStoreEnabled && len(c.ReportRuStorePackages) == 0 {
+		warn("REPORT_RUSTORE_ENABLED is on but REPORT_RUSTORE_PACKAGES is empty: the report section will be empty")
+	}
+
+	if len(problems) > 0 {
+		return warnings, &ValidationError{Problems: problems}
+	}
+	return warnings, nil
+}
+
+// llmCredentialProblems нужен хотя бы один провайдер с ключами; выбранный провайдер должен быть известен
+func (c *Config) llmCredentialProblems() []string {
+	var problems []string
+	switch c.LLMProvider {
+	case ProviderOpenAI, ProviderAnthropic, ProviderYandex:
+	default:
+		problems = append(problems, fmt.Sprintf("LLM_PROVIDER must be one of openai, anthropic, yandex, got %q", c.LLMProvider))
+	}
+	if c.YandexOAuthToken != "" && c.YandexFolderID == "" {
+		problems = append(problems, "YANDEX_OAUTH_TOKEN is set but YANDEX_FOLDER_ID is missing")
+	}
+	if c.OpenAIAPIKey == "" && c.AnthropicAPIKey == "" && c.YandexOAuthToken == "" {
+		problems = append(problems, "no LLM provider credentials: set OPENAI_API_KEY, ANTHROPIC_API_KEY or YANDEX_OAUTH_TOKEN")
+	} else if c.LLMProvider == ProviderOpenAI && c.OpenAIAPIKey == "" ||
+		c.LLMProvider == ProviderAnthropic && c.AnthropicAPIKey == "" ||
+		c.LLMProvider == ProviderYandex && c.YandexOAuthToken == "" {
+		problems = append(problems, fmt.Sprintf("LLM_PROVIDER is %s but its credentials are not set", c.LLMProvider))
+	}
+	return problems
+}
+
+type dataFile struct {
+	env  string
+	path string
+}
+
+// dataFiles файлы состояния, которые бот создаёт при работе; пустой путь отключает функцию
+func (c *Config) dataFiles() []dataFile {
+	files := []dataFile{
+		{"LOG_FILE_PATH", c.LogFilePath},
+		{"ALLOWLIST_FILE_PATH", c.AllowlistFilePath},
+		{"PENDING_FILE_PATH", c.PendingFilePath},
+		{"CALLBACK_ACTIONS_FILE_PATH", c.CallbackActionsFilePath},
+		{"PROVIDER_FILE_PATH", c.ProviderFilePath},
+		{"MODEL_FILE_PATH", c.ModelFilePath},
+		{"MODEL2_FILE_PATH", c.Model2FilePath},
+		{"USER_MODELS_FILE_PATH", c.UserModelsFilePath},
+		{"USER_SETTINGS_FILE_PATH", c.UserSettingsFilePath},
+		{"USAGE_STATS_FILE_PATH", c.UsageStatsFilePath},
+		{"REMINDERS_FILE_PATH", c.RemindersFilePath},
+		{"SCHEDULED_PROMPTS_FILE_PATH", c.ScheduledPromptsFilePath},
+	}
+	if c.StorageBackend == "sqlite" {
+		files = append(files, dataFile{"SQLITE_PATH", c.SQLitePath})
+	}
+	if c.PaymentsProviderToken != "" {
+		files = append(files, dataFile{"PAYMENTS_FILE_PATH", c.PaymentsFilePath})
+	}
+	var set []dataFile
+	for _, f := range files {
+		if f.path != "" {
+			set = append(set, f)
+		}
+	}
+	return set
+}
+
+// checkCreatable можно ли создать или перезаписать файл path: сам путь не каталог,
+// а ближайший существующий родительский каталог доступен на запись
+func checkCreatable(path string) error {
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory, not a file", path)
+		}
+		return nil
+	}
+	dir := filepath.Dir(path)
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("cannot create %s: %s is not a directory", path, dir)
+			}
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("cannot create %s: %v", path, err)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	probe, err := os.CreateTemp(dir, ".ai-chatter-write-check-*")
+	if err != nil {
+		return fmt.Errorf("cannot create %s: directory %s is not writable", path, dir)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
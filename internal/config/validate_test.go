@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validConfig минимальная рабочая конфигурация с файлами данных во временном каталоге
+func validConfig(t *testing.T) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	return &Config{
+		TelegramBotToken:  "123456789:AAHk2x9Q_fakeTokenForTests-0123456789",
+		AdminUserID:       42,
+		LLMProvider:       ProviderOpenAI,
+		OpenAIAPIKey:      "sk-test",
+		MessageParseMode:  "HTML",
+		StorageBackend:    "file",
+		RemindersTimezone: "UTC",
+		LogFilePath:       filepath.Join(dir, "logs", "log.jsonl"),
+		PendingFilePath:   filepath.Join(dir, "pending.json"),
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	warnings, err := validConfig(t).Validate()
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("valid config must pass without warnings: %v, %v", warnings, err)
+	}
+}
+
+func TestValidate_ReportsAllProblemsAtOnce(t *testing.T) {
+	cfg := validConfig(t)
+	blocker := filepath.Join(t.TempDir(), "not-a-dir")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg.loadProblems = splitEnvError(errors.New(`env: parse error on field "AdminUserID" of type "int64": strconv.ParseInt: parsing "abc": invalid syntax; parse error on field "StreamingEnabled" of type "bool": invalid syntax`))
+	cfg.TelegramBotToken = ""
+	cfg.OpenAIAPIKey = ""
+	cfg.MessageParseMode = "markdown2"
+	cfg.RemindersTimezone = "Mars/Olympus"
+	cfg.HistoryMaxMessages = -1
+	cfg.PendingFilePath = filepath.Join(blocker, "pending.json")
+	cfg.NotionToken = "secret_notion"
+
+	warnings, err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	wants := []string{
+		`"AdminUserID"`,
+		`"StreamingEnabled"`,
+		"TELEGRAM_BOT_TOKEN is required",
+		"no LLM provider credentials",
+		"MESSAGE_PARSE_MODE",
+		"REMINDERS_TIMEZONE",
+		"HISTORY_MAX_MESSAGES must not be negative",
+		"PENDING_FILE_PATH: cannot create",
+	}
+	if len(verr.Problems) != len(wants) {
+		t.Fatalf("every problem must be reported once, got %d:\n%v", len(verr.Problems), err)
+	}
+	for i, want := range wants {
+		if !strings.Contains(verr.Problems[i], want) {
+			t.Errorf("problem %d: want %q, got %q", i, want, verr.Problems[i])
+		}
+	}
+	if !strings.HasPrefix(err.Error(), "8 configuration problem(s):\n  - ") {
+		t.Errorf("summary must list the problems line by line:\n%s", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "NOTION_PARENT_PAGE_ID is missing") {
+		t.Errorf("optional integrations must only warn: %v", warnings)
+	}
+}
+
+func TestValidate_SelectedProviderCredentials(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.LLMProvider = ProviderYandex
+	cfg.TelegramBotToken = "not-a-token"
+	_, err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Problems) != 2 ||
+		!strings.Contains(verr.Problems[0], "invalid format") || !strings.Contains(verr.Problems[1], "LLM_PROVIDER is yandex") {
+		t.Fatalf("token format and missing yandex credentials expected, got %v", err)
+	}
+}
+
+func TestValidate_MessagesFileTemplates(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.MessagesFilePath = filepath.Join(t.TempDir(), "messages.txt")
+	if err := os.WriteFile(cfg.MessagesFilePath, []byte("welcome = Привет, {{.Name}}\nquota_exceeded = {{.ResetTime\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Problems) != 1 || !strings.Contains(verr.Problems[0], "messages.txt:2:") {
+		t.Fatalf("invalid template must fail validation with its line, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package docs
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// SupportedExtensions расширения файлов, которые можно загрузить в библиотеку
+var SupportedExtensions = []string{".pdf", ".txt", ".md", ".markdown", ".csv", ".json", ".html", ".rst"}
+
+const (
+	// chunkSize размер фрагмента в символах
+	chunkSize = 1000
+	// chunkOverlap перекрытие соседних фрагментов в символах
+	chunkOverlap = 150
+)
+
+// IsSupported проверяет, можно ли извлечь текст из файла
+func IsSupported(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, e := range SupportedExtensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractPages извлекает текст файла постранично.
+// Для текстовых файлов страницы разделяются символом form feed (\f)
+func ExtractPages(filename string, data []byte) ([]string, error) {
+	if !IsSupported(filename) {
+		return nil, fmt.Errorf("unsupported file type %q (supported: %s)", filepath.Ext(filename), strings.Join(SupportedExtensions, ", "))
+	}
+	if strings.EqualFold(filepath.Ext(filename), ".pdf") {
+		return ExtractPDFPages(data)
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("file is not valid UTF-8 text")
+	}
+
+	var pages []string
+	for _, page := range strings.Split(string(data), "\f") {
+		pages = append(pages, normalizeText(page))
+	}
+	return pages, nil
+}
+
+// normalizeText схлопывает пробелы и лишние пустые строки
+func normalizeText(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			if !blank && len(out) > 0 {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// chunkPages режет страницы на фрагменты с перекрытием, сохраняя номер страницы
+func chunkPages(pages []string) []Chunk {
+	var chunks []Chunk
+	for i, page := range pages {
+		words := strings.Fields(page)
+		start := 0
+		for start < len(words) {
+			size := 0
+			end := start
+			for end < len(words) && (size == 0 || size+len([]rune(words[end]))+1 <= chunkSize) {
+				size += len([]rune(words[end])) + 1
+				end++
+			}
+			chunks = append(chunks, Chunk{Page: i + 1, Text: strings.Join(words[start:end], " ")})
+			if end >= len(words) {
+				break
+			}
+			// Откатываемся назад на chunkOverlap символов для перекрытия
+			next := end
+			overlap := 0
+			for next > start+1 && overlap < chunkOverlap {
+				next--
+				overlap += len([]rune(words[next])) + 1
+			}
+			start = next
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,423 @@
+package docs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"ai-chatter/internal/llm"
+)
+
+var (
+	// ErrQuotaExceeded превышен лимит библиотеки пользователя
+	ErrQuotaExceeded = errors.New("document library quota exceeded")
+	// ErrNotFound документ не найден
+	ErrNotFound = errors.New("document not found")
+	// ErrEmptyDocument в документе нет текста
+	ErrEmptyDocument = errors.New("document has no text")
+)
+
+// embedBatchSize количество фрагментов в одном запросе эмбеддингов
+const embedBatchSize = 64
+
+// Chunk фрагмент текста документа
+type Chunk struct {
+	Page      int       `json:"page"`
+	Text      string    `json:"text"`
+	Embedding []float32 `json:"embedding,omitempty"`
+}
+
+// Document документ в библиотеке пользователя
+type Document struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Pages      int       `json:"pages"`
+	Size       int64     `json:"size"`
+	UploadedAt time.Time `json:"uploaded_at"`
+	Chunks     []Chunk   `json:"chunks"`
+}
+
+// DocumentInfo краткая информация о документе без фрагментов
+type DocumentInfo struct {
+	ID         int
+	Name       string
+	Pages      int
+	Chunks     int
+	Size       int64
+	UploadedAt time.Time
+}
+
+// Match найденный фрагмент документа
+type Match struct {
+	DocumentID   int
+	DocumentName string
+	Page         int
+	Text         string
+	Score        float64
+}
+
+// Library персональные библиотеки документов, по одному JSON файлу на пользователя
+type Library struct {
+	mu       sync.Mutex
+	dir      string
+	embedder llm.Embedder
+	maxBytes int64
+	maxDocs  int
+	cache    map[int64][]Document
+}
+
+// NewLibrary создаёт библиотеку в каталоге dir. embedder может быть nil —
+// тогда поиск выполняется по совпадению слов. Нулевые лимиты означают отсутствие ограничений
+func NewLibrary(dir string, embedder llm.Embedder, maxBytesPerUser int64, maxDocsPerUser int) *Library {
+	return &Library{
+		dir:      dir,
+		embedder: embedder,
+		maxBytes: maxBytesPerUser,
+		maxDocs:  maxDocsPerUser,
+		cache:    make(map[int64][]Document),
+	}
+}
+
+// Limits возвращает лимиты на пользователя
+func (l *Library) Limits() (maxBytes int64, maxDocs int) {
+	return l.maxBytes, l.maxDocs
+}
+
+func (l *Library) userPath(userID int64) string {
+	return filepath.Join(l.dir, strconv.FormatInt(userID, 10)+".json")
+}
+
+func (l *Library) loadUnlocked(userID int64) ([]Document, error) {
+	if docs, ok := l.cache[userID]; ok {
+		return docs, nil
+	}
+	data, err := os.ReadFile(l.userPath(userID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			l.cache[userID] = nil
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read library: %w", err)
+	}
+	var docs []Document
+	if err := json.Unmarshal(data, &docs); err != nil {
+		return nil, fmt.Errorf("parse library: %w", err)
+	}
+	l.cache[userID] = docs
+	return docs, nil
+}
+
+func (l *Library) saveUnlocked(userID int64, docs []Document) error {
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return fmt.Errorf("ensure library dir: %w", err)
+	}
+	data, err := json.Marshal(docs)
+	if err != nil {
+		return fmt.Errorf("encode library: %w", err)
+	}
+	tmp := l.userPath(userID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write library: %w", err)
+	}
+	if err := os.Rename(tmp, l.userPath(userID)); err != nil {
+		return fmt.Errorf("write library: %w", err)
+	}
+	l.cache[userID] = docs
+	return nil
+}
+
+// Add извлекает фрагменты из страниц, строит эмбеддинги и сохраняет документ
+func (l *Library) Add(ctx context.Context, userID int64, name string, pages []string) (DocumentInfo, error) {
+	chunks := chunkPages(pages)
+	if len(chunks) == 0 {
+		return DocumentInfo{}, ErrEmptyDocument
+	}
+	var size int64
+	for _, p := range pages {
+		size += int64(len(p))
+	}
+
+	// Проверяем квоту до дорогого запроса эмбеддингов
+	if err := l.checkQuota(userID, size); err != nil {
+		return DocumentInfo{}, err
+	}
+
+	if l.embedder != nil {
+		if err := l.embedChunks(ctx, chunks); err != nil {
+			// Без эмбеддингов документ всё равно доступен для поиска по словам
+			log.Printf("⚠️ Docs: embeddings failed for %q, falling back to keyword search: %v", name, err)
+			for i := range chunks {
+				chunks[i].Embedding = nil
+			}
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	docs, err := l.loadUnlocked(userID)
+	if err != nil {
+		return DocumentInfo{}, err
+	}
+	if err := quotaError(docs, size, l.maxBytes, l.maxDocs); err != nil {
+		return DocumentInfo{}, err
+	}
+	id := 1
+	for _, d := range docs {
+		if d.ID >= id {
+			id = d.ID + 1
+		}
+	}
+	doc := Document{ID: id, Name: name, Pages: len(pages), Size: size, UploadedAt: time.Now().UTC(), Chunks: chunks}
+	updated := append(append([]Document(nil), docs...), doc)
+	if err := l.saveUnlocked(userID, updated); err != nil {
+		return DocumentInfo{}, err
+	}
+	log.Printf("📚 Docs: user %d added %q (%d pages, %d chunks, %d bytes)", userID, name, doc.Pages, len(chunks), size)
+	return doc.info(), nil
+}
+
+func (l *Library) checkQuota(userID int64, size int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	docs, err := l.loadUnlocked(userID)
+	if err != nil {
+		return err
+	}
+	return quotaError(docs, size, l.maxBytes, l.maxDocs)
+}
+
+func quotaError(docs []Document, size, maxBytes int64, maxDocs int) error {
+	if maxDocs > 0 && len(docs) >= maxDocs {
+		return fmt.Errorf("%w: at most %d documents", ErrQuotaExceeded, maxDocs)
+	}
+	if maxBytes > 0 {
+		var used int64
+		for _, d := range docs {
+			used += d.Size
+		}
+		if used+size > maxBytes {
+			return fmt.Errorf("%w: %d of %d bytes used, document needs %d", ErrQuotaExceeded, used, maxBytes, size)
+		}
+	}
+	return nil
+}
+
+func (l *Library) embedChunks(ctx context.Context, chunks []Chunk) error {
+	for start := 0; start < len(chunks); start += embedBatchSize {
+		end := start + embedBatchSize
+		if end > len(chunks) {
+			end = len(chunks)
+		}
+		texts := make([]string, 0, end-start)
+		for _, c := range chunks[start:end] {
+			texts = append(texts, c.Text)
+		}
+		vectors, err := l.embedder.Embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+		for i, v := range vectors {
+			chunks[start+i].Embedding = v
+		}
+	}
+	return nil
+}
+
+// List возвращает документы пользователя
+func (l *Library) List(userID int64) ([]DocumentInfo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	docs, err := l.loadUnlocked(userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]DocumentInfo, 0, len(docs))
+	for _, d := range docs {
+		out = append(out, d.info())
+	}
+	return out, nil
+}
+
+// Delete удаляет документ по ID
+func (l *Library) Delete(userID int64, id int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	docs, err := l.loadUnlocked(userID)
+	if err != nil {
+		return err
+	}
+	for i, d := range docs {
+		if d.ID == id {
+			updated := append(append([]Document(nil), docs[:i]...), docs[i+1:]...)
+			return l.saveUnlocked(userID, updated)
+		}
+	}
+	return ErrNotFound
+}
+
+// DeleteAll удаляет всю библиотеку пользователя вместе с файлом
+func (l *Library) DeleteAll(userID int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cache, userID)
+	if err := os.Remove(l.userPath(userID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("delete library: %w", err)
+	}
+	return nil
+}
+
+// Search возвращает topK самых релевантных фрагментов по убыванию оценки (0..1).
+// Если у фрагментов есть эмбеддинги — используется косинусная близость, иначе доля совпавших слов запроса
+func (l *Library) Search(ctx context.Context, userID int64, query string, topK int) ([]Match, error) {
+	l.mu.Lock()
+	docs, err := l.loadUnlocked(userID)
+	l.mu.Unlock()
+	if err != nil || len(docs) == 0 {
+		return nil, err
+	}
+
+	var queryVec []float32
+	if l.embedder != nil && hasEmbeddings(docs) {
+		vectors, err := l.embedder.Embed(ctx, []string{query})
+		if err != nil {
+			log.Printf("⚠️ Docs: query embedding failed, falling back to keyword search: %v", err)
+		} else if len(vectors) == 1 {
+			queryVec = vectors[0]
+		}
+	}
+	terms := queryTerms(query)
+
+	var matches []Match
+	for _, d := range docs {
+		for _, c := range d.Chunks {
+			var score float64
+			if queryVec != nil && len(c.Embedding) == len(queryVec) {
+				score = cosine(queryVec, c.Embedding)
+			} else {
+				score = keywordScore(terms, c.Text)
+			}
+			if score <= 0 {
+				continue
+			}
+			matches = append(matches, Match{DocumentID: d.ID, DocumentName: d.Name, Page: c.Page, Text: c.Text, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if topK > 0 && len(matches) > topK {
+		matches = matches[:topK]
+	}
+	return matches, nil
+}
+
+// FormatContext формирует системное сообщение с найденными фрагментами и требованием цитирования
+func FormatContext(matches []Match) string {
+	var sb strings.Builder
+	sb.WriteString("Ниже фрагменты из документов пользователя. Отвечай, опираясь на них, и указывай источники в формате [название документа, стр. N]. ")
+	sb.WriteString("Если во фрагментах нет ответа, прямо скажи об этом.\n")
+	for i, m := range matches {
+		sb.WriteString(fmt.Sprintf("\n[%d] %s, стр. %d:\n%s\n", i+1, m.DocumentName, m.Page, m.Text))
+	}
+	return sb.String()
+}
+
+// FormatSources формирует список цитируемых документов и страниц
+func FormatSources(matches []Match) string {
+	seen := make(map[string]bool)
+	var parts []string
+	for _, m := range matches {
+		key := fmt.Sprintf("%s, стр. %d", m.DocumentName, m.Page)
+		if !seen[key] {
+			seen[key] = true
+			parts = append(parts, key)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (d Document) info() DocumentInfo {
+	return DocumentInfo{ID: d.ID, Name: d.Name, Pages: d.Pages, Chunks: len(d.Chunks), Size: d.Size, UploadedAt: d.UploadedAt}
+}
+
+func hasEmbeddings(docs []Document) bool {
+	for _, d := range docs {
+		for _, c := range d.Chunks {
+			if len(c.Embedding) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// stemLength грубый стемминг: слова сравниваются по первым символам (учитывает окончания в русском)
+const stemLength = 5
+
+func stem(word string) string {
+	r := []rune(word)
+	if len(r) > stemLength {
+		r = r[:stemLength]
+	}
+	return string(r)
+}
+
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, w := range tokenize(query) {
+		if len([]rune(w)) < 3 {
+			continue
+		}
+		if s := stem(w); !seen[s] {
+			seen[s] = true
+			terms = append(terms, s)
+		}
+	}
+	return terms
+}
+
+func keywordScore(terms []string, text string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	present := make(map[string]bool)
+	for _, w := range tokenize(text) {
+		present[stem(w)] = true
+	}
+	matched := 0
+	for _, t := range terms {
+		if present[t] {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(terms))
+}
@@ -0,0 +1,140 @@
+package docs
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func buildTestPDF(t *testing.T, pages ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	for i, content := range pages {
+		var z bytes.Buffer
+		w := zlib.NewWriter(&z)
+		_, _ = w.Write([]byte(content))
+		_ = w.Close()
+		fmt.Fprintf(&buf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", i+4, z.Len())
+		buf.Write(z.Bytes())
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+	buf.WriteString("%%EOF\n")
+	return buf.Bytes()
+}
+
+func TestExtractPDFPages(t *testing.T) {
+	data := buildTestPDF(t,
+		"BT /F1 12 Tf 72 712 Td (Installation guide) Tj 0 -14 Td [(Press the ) -50 (red) -300 (button)] TJ ET",
+		"BT /F1 12 Tf 72 712 Td (Warranty \\(two years\\)) Tj ET",
+	)
+	pages, err := ExtractPDFPages(data)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("expected 2 pages, got %d: %q", len(pages), pages)
+	}
+	if pages[0] != "Installation guide\nPress the red button" {
+		t.Errorf("unexpected first page: %q", pages[0])
+	}
+	if pages[1] != "Warranty (two years)" {
+		t.Errorf("unexpected second page: %q", pages[1])
+	}
+}
+
+func TestChunkPages_KeepsPageNumbers(t *testing.T) {
+	long := strings.Repeat("слово ", 400)
+	chunks := chunkPages([]string{"short page", long})
+	if chunks[0].Page != 1 || chunks[0].Text != "short page" {
+		t.Fatalf("unexpected first chunk: %+v", chunks[0])
+	}
+	if len(chunks) < 3 {
+		t.Fatalf("long page must be split, got %d chunks", len(chunks))
+	}
+	for _, c := range chunks[1:] {
+		if c.Page != 2 || len([]rune(c.Text)) > chunkSize {
+			t.Fatalf("bad chunk: page=%d len=%d", c.Page, len([]rune(c.Text)))
+		}
+	}
+}
+
+func TestLibrary_AddSearchDeleteWithQuota(t *testing.T) {
+	lib := NewLibrary(t.TempDir(), nil, 200, 2)
+	ctx := context.Background()
+
+	info, err := lib.Add(ctx, 7, "manual.pdf", []string{"Общие сведения о приборе", "Чтобы сбросить настройки, удерживайте кнопку питания десять секунд"})
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if info.ID != 1 || info.Pages != 2 {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+
+	matches, err := lib.Search(ctx, 7, "как сбросить настройки прибора?", 3)
+	if err != nil || len(matches) == 0 {
+		t.Fatalf("search: %v, %v", matches, err)
+	}
+	if matches[0].Page != 2 || matches[0].DocumentName != "manual.pdf" {
+		t.Fatalf("unexpected top match: %+v", matches[0])
+	}
+	if got := FormatSources(matches[:1]); got != "manual.pdf, стр. 2" {
+		t.Fatalf("unexpected sources: %q", got)
+	}
+
+	if _, err := lib.Add(ctx, 7, "big.txt", []string{strings.Repeat("x", 150)}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected quota error, got %v", err)
+	}
+
+	// Данные переживают пересоздание библиотеки
+	reopened := NewLibrary(lib.dir, nil, 200, 2)
+	if docs, _ := reopened.List(7); len(docs) != 1 {
+		t.Fatalf("expected persisted document, got %+v", docs)
+	}
+	if err := reopened.Delete(7, 42); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := reopened.DeleteAll(7); err != nil {
+		t.Fatalf("delete all: %v", err)
+	}
+	if docs, _ := reopened.List(7); len(docs) != 0 {
+		t.Fatalf("library must be empty after DeleteAll: %+v", docs)
+	}
+}
+
+type fakeEmbedder struct{}
+
+// Embed кодирует текст вектором [есть "red", есть "blue"]
+func (fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		v := []float32{0.01, 0.01}
+		if strings.Contains(t, "red") {
+			v[0] = 1
+		}
+		if strings.Contains(t, "blue") {
+			v[1] = 1
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func TestLibrary_SearchUsesEmbeddings(t *testing.T) {
+	lib := NewLibrary(t.TempDir(), fakeEmbedder{}, 0, 0)
+	ctx := context.Background()
+	if _, err := lib.Add(ctx, 1, "colors.txt", []string{"the blue page", "the red page"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	matches, err := lib.Search(ctx, 1, "red", 1)
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("search: %v, %v", matches, err)
+	}
+	if matches[0].Page != 2 || matches[0].Score < 0.99 {
+		t.Fatalf("unexpected match: %+v", matches[0])
+	}
+}
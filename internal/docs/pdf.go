@@ -0,0 +1,276 @@
+package docs
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// streamHeaderRe находит словарь объекта перед ключевым словом stream
+var streamHeaderRe = regexp.MustCompile(`(?s)\bobj\b(.{0,1000}?)\bstream\r?\n`)
+
+// ExtractPDFPages извлекает текст из PDF постранично.
+// Это упрощённый извлекатель без поддержки шрифтов/CMap: каждый content stream
+// с текстовыми операторами считается отдельной страницей, сканы не поддерживаются
+func ExtractPDFPages(data []byte) ([]string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF")) {
+		return nil, errors.New("not a PDF file")
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return nil, errors.New("encrypted PDF is not supported")
+	}
+
+	var pages []string
+	for _, loc := range streamHeaderRe.FindAllSubmatchIndex(data, -1) {
+		dict := data[loc[2]:loc[3]]
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			continue
+		}
+		raw := bytes.TrimRight(data[start:start+end], "\r\n")
+
+		// Пропускаем изображения, шрифты и потоки с неподдерживаемыми фильтрами
+		if bytes.Contains(dict, []byte("/Image")) || bytes.Contains(dict, []byte("/Length1")) ||
+			bytes.Contains(dict, []byte("/ObjStm")) || bytes.Contains(dict, []byte("/XRef")) {
+			continue
+		}
+		content := raw
+		if bytes.Contains(dict, []byte("/Filter")) {
+			if !bytes.Contains(dict, []byte("/FlateDecode")) {
+				continue
+			}
+			decoded, ok := inflate(raw)
+			if !ok {
+				continue
+			}
+			content = decoded
+		}
+		if !bytes.Contains(content, []byte("BT")) {
+			continue
+		}
+		if text := extractContentText(content); text != "" {
+			pages = append(pages, text)
+		}
+	}
+
+	if len(pages) == 0 {
+		return nil, errors.New("no extractable text found in PDF (scanned document?)")
+	}
+	return pages, nil
+}
+
+func inflate(raw []byte) ([]byte, bool) {
+	r, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, false
+	}
+	defer r.Close()
+	out, err := io.ReadAll(r)
+	// Повреждённый хвост потока не мешает использовать уже распакованные данные
+	if err != nil && len(out) == 0 {
+		return nil, false
+	}
+	return out, true
+}
+
+// extractContentText разбирает операторы Tj/TJ/'/" content stream страницы
+func extractContentText(content []byte) string {
+	var sb strings.Builder
+	var strs []string
+	var nums []float64
+	inArray := false
+
+	newline := func() {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteString("\n")
+		}
+	}
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '(':
+			s, n := readLiteralString(content[i:])
+			strs = append(strs, decodePDFString(s))
+			i += n
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			s, n := readHexString(content[i:])
+			strs = append(strs, decodePDFString(s))
+			i += n
+		case c == '<' || c == '>' || c == '{' || c == '}':
+			i++
+		case c == '[':
+			inArray = true
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case isPDFSpace(c):
+			i++
+		default:
+			start := i
+			i++
+			for i < len(content) && !isPDFSpace(content[i]) && !isPDFDelimiter(content[i]) {
+				i++
+			}
+			tok := string(content[start:i])
+			if n, err := strconv.ParseFloat(tok, 64); err == nil {
+				// Большой отрицательный кернинг внутри TJ обычно означает пробел между словами
+				if inArray && n < -200 {
+					strs = append(strs, " ")
+				}
+				nums = append(nums, n)
+				continue
+			}
+			if strings.HasPrefix(tok, "/") {
+				continue
+			}
+			switch tok {
+			case "Tj", "TJ":
+				sb.WriteString(strings.Join(strs, ""))
+			case "'", "\"":
+				newline()
+				sb.WriteString(strings.Join(strs, ""))
+			case "T*", "ET":
+				newline()
+			case "Td", "TD":
+				if len(nums) >= 2 && nums[len(nums)-1] != 0 {
+					newline()
+				} else if !strings.HasSuffix(sb.String(), " ") {
+					sb.WriteString(" ")
+				}
+			}
+			strs = nil
+			nums = nil
+		}
+	}
+	return normalizeText(sb.String())
+}
+
+func readLiteralString(b []byte) (string, int) {
+	var sb strings.Builder
+	depth := 0
+	i := 0
+	for i < len(b) {
+		c := b[i]
+		switch c {
+		case '(':
+			if depth > 0 {
+				sb.WriteByte(c)
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return sb.String(), i + 1
+			}
+			sb.WriteByte(c)
+		case '\\':
+			i++
+			if i >= len(b) {
+				break
+			}
+			switch e := b[i]; e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// перенос строки внутри литерала
+			default:
+				if e >= '0' && e <= '7' {
+					val := 0
+					j := 0
+					for j < 3 && i < len(b) && b[i] >= '0' && b[i] <= '7' {
+						val = val*8 + int(b[i]-'0')
+						i++
+						j++
+					}
+					i--
+					sb.WriteByte(byte(val))
+				} else {
+					sb.WriteByte(e)
+				}
+			}
+		default:
+			sb.WriteByte(c)
+		}
+		i++
+	}
+	return sb.String(), len(b)
+}
+
+func readHexString(b []byte) (string, int) {
+	end := bytes.IndexByte(b, '>')
+	if end < 0 {
+		return "", len(b)
+	}
+	var hex []byte
+	for _, c := range b[1:end] {
+		if !isPDFSpace(c) {
+			hex = append(hex, c)
+		}
+	}
+	if len(hex)%2 == 1 {
+		hex = append(hex, '0')
+	}
+	out := make([]byte, 0, len(hex)/2)
+	for i := 0; i+1 < len(hex); i += 2 {
+		v, err := strconv.ParseUint(string(hex[i:i+2]), 16, 8)
+		if err != nil {
+			return "", end + 1
+		}
+		out = append(out, byte(v))
+	}
+	if len(out) >= 2 && out[0] == 0xFE && out[1] == 0xFF {
+		return string(out), end + 1
+	}
+	// Двухбайтовые коды (CID шрифты) без CMap не декодируются
+	for _, c := range out {
+		if c < 0x20 && c != '\n' && c != '\t' {
+			return "", end + 1
+		}
+	}
+	return string(out), end + 1
+}
+
+// decodePDFString переводит строку PDF в UTF-8: UTF-16BE с BOM или однобайтовая кодировка (как Latin-1)
+func decodePDFString(s string) string {
+	if len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF {
+		units := make([]uint16, 0, (len(s)-2)/2)
+		for i := 2; i+1 < len(s); i += 2 {
+			units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	if utf8.ValidString(s) {
+		return s
+	}
+	runes := make([]rune, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		runes = append(runes, rune(s[i]))
+	}
+	return string(runes)
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
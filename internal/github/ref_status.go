@@ -0,0 +1,160 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Итоговый вердикт по статусам и check runs коммита
+const (
+	RefVerdictSuccess  = "success"
+	RefVerdictFailure  = "failure"
+	RefVerdictPending  = "pending"
+	RefVerdictNoChecks = "no_checks"
+)
+
+// Источник проверки: commit status (внешние CI) или check run (GitHub Actions, приложения)
+const (
+	RefCheckSourceStatus   = "status"
+	RefCheckSourceCheckRun = "check_run"
+)
+
+// RefCheck одна проверка коммита
+type RefCheck struct {
+	Name            string  `json:"name"`
+	Source          string  `json:"source"`
+	Status          string  `json:"status"`               // queued, in_progress, completed
+	Conclusion      string  `json:"conclusion,omitempty"` // success, failure, neutral, skipped, ...; пусто, пока не завершена
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	DetailsURL      string  `json:"details_url,omitempty"`
+}
+
+// RefStatus сводка проверок для ref (ветки, тега или SHA)
+type RefStatus struct {
+	Owner   string     `json:"owner"`
+	Repo    string     `json:"repo"`
+	Ref     string     `json:"ref"`
+	SHA     string     `json:"sha,omitempty"`
+	Verdict string     `json:"verdict"`
+	Checks  []RefCheck `json:"checks"`
+}
+
+// Failed true, если проверка завершилась неуспешно
+func (c RefCheck) Failed() bool {
+	switch c.Conclusion {
+	case "failure", "error", "timed_out", "cancelled", "action_required", "startup_failure", "stale":
+		return true
+	}
+	return false
+}
+
+// AggregateRefVerdict вердикт по проверкам: любая упавшая — failure, любая незавершённая — pending.
+// Без проверок вердикт no_checks, чтобы отличать «CI не настроен» от «CI ещё идёт»
+func AggregateRefVerdict(checks []RefCheck) string {
+	if len(checks) == 0 {
+		return RefVerdictNoChecks
+	}
+	pending := false
+	for _, c := range checks {
+		if c.Failed() {
+			return RefVerdictFailure
+		}
+		if c.Status != "completed" {
+			pending = true
+		}
+	}
+	if pending {
+		return RefVerdictPending
+	}
+	return RefVerdictSuccess
+}
+
+// FormatRefStatus текстовая сводка: вердикт и строка на каждую проверку
+func FormatRefStatus(s RefStatus) string {
+	var b strings.Builder
+	icon := map[string]string{
+		RefVerdictSuccess:  "✅",
+		RefVerdictFailure:  "❌",
+		RefVerdictPending:  "⏳",
+		RefVerdictNoChecks: "⚪",
+	}[s.Verdict]
+	fmt.Fprintf(&b, "%s %s/%s@%s: %s", icon, s.Owner, s.Repo, s.Ref, s.Verdict)
+	if s.SHA != "" {
+		fmt.Fprintf(&b, " (%.7s)", s.SHA)
+	}
+	if s.Verdict == RefVerdictNoChecks {
+		b.WriteString("\nNo statuses or check runs are configured for this commit")
+	}
+	for _, c := range s.Checks {
+		result := c.Conclusion
+		if result == "" {
+			result = c.Status
+		}
+		mark := "✅"
+		switch {
+		case c.Failed():
+			mark = "❌"
+		case c.Status != "completed":
+			mark = "⏳"
+		case c.Conclusion == "neutral" || c.Conclusion == "skipped":
+			mark = "➖"
+		}
+		fmt.Fprintf(&b, "\n%s %s: %s", mark, c.Name, result)
+		if c.DurationSeconds > 0 {
+			fmt.Fprintf(&b, " (%.0fs)", c.DurationSeconds)
+		}
+		if c.DetailsURL != "" {
+			fmt.Fprintf(&b, " %s", c.DetailsURL)
+		}
+	}
+	return b.String()
+}
+
+// GetRefStatus получает сводку статусов и check runs для ref через инструмент get_ref_status
+func (g *GitHubMCPClient) GetRefStatus(ctx context.Context, owner, repo, ref string) (*RefStatus, error) {
+	if g.session == nil {
+		return nil, fmt.Errorf("GitHub MCP session not connected")
+	}
+
+	log.Printf("🚦 Getting GitHub ref status via MCP: %s/%s@%s", owner, repo, ref)
+
+	result, err := g.session.CallTool(ctx, &mcp.CallToolParams{
+		Name: "get_ref_status",
+		Arguments: map[string]any{
+			"owner": owner,
+			"repo":  repo,
+			"ref":   ref,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GitHub MCP ref status error: %w", err)
+	}
+	if result.IsError {
+		var text string
+		for _, content := range result.Content {
+			if textContent, ok := content.(*mcp.TextContent); ok {
+				text += textContent.Text
+			}
+		}
+		return nil, fmt.Errorf("get_ref_status failed: %s", text)
+	}
+
+	raw, ok := result.Meta["ref_status"]
+	if !ok {
+		return nil, fmt.Errorf("get_ref_status returned no ref_status")
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ref status: %w", err)
+	}
+	var status RefStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse ref status: %w", err)
+	}
+	return &status, nil
+}
@@ -0,0 +1,77 @@
+package github
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestAggregateRefVerdict(t *testing.T) {
+	done := func(conclusion string) RefCheck {
+		return RefCheck{Name: conclusion, Status: "completed", Conclusion: conclusion}
+	}
+	cases := []struct {
+		name   string
+		checks []RefCheck
+		want   string
+	}{
+		{"no checks configured", nil, RefVerdictNoChecks},
+		{"all green", []RefCheck{done("success"), done("skipped"), done("neutral")}, RefVerdictSuccess},
+		{"still running", []RefCheck{done("success"), {Name: "build", Status: "in_progress"}}, RefVerdictPending},
+		{"failure wins over pending", []RefCheck{{Name: "lint", Status: "queued"}, done("timed_out")}, RefVerdictFailure},
+		{"status error", []RefCheck{{Name: "ci/jenkins", Source: RefCheckSourceStatus, Status: "completed", Conclusion: "error"}}, RefVerdictFailure},
+	}
+	for _, tc := range cases {
+		if got := AggregateRefVerdict(tc.checks); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestGitHubMCPClient_GetRefStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var gotArgs map[string]any
+	server := mcp.NewServer(&mcp.Implementation{Name: "github-stub", Version: "v0.0.1"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "get_ref_status"}, func(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[any], error) {
+		gotArgs = params.Arguments
+		status := RefStatus{Owner: "AndVl1", Repo: "SnakeGame", Ref: "main", SHA: "abcdef123456", Checks: []RefCheck{
+			{Name: "build", Source: RefCheckSourceCheckRun, Status: "completed", Conclusion: "failure", DurationSeconds: 42, DetailsURL: "https://ci/build"},
+		}}
+		status.Verdict = AggregateRefVerdict(status.Checks)
+		return &mcp.CallToolResultFor[any]{
+			Content: []mcp.Content{&mcp.TextContent{Text: FormatRefStatus(status)}},
+			Meta:    map[string]any{"ref_status": status},
+		}, nil
+	})
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	go func() { _ = server.Run(ctx, serverTransport) }()
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "v0.0.1"}, nil).Connect(ctx, clientTransport)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer session.Close()
+
+	client := &GitHubMCPClient{session: session}
+	status, err := client.GetRefStatus(ctx, "AndVl1", "SnakeGame", "main")
+	if err != nil {
+		t.Fatalf("GetRefStatus: %v", err)
+	}
+	if gotArgs["ref"] != "main" || gotArgs["owner"] != "AndVl1" {
+		t.Errorf("unexpected tool args: %v", gotArgs)
+	}
+	if status.Verdict != RefVerdictFailure || len(status.Checks) != 1 || status.Checks[0].DurationSeconds != 42 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	summary := FormatRefStatus(*status)
+	if !strings.Contains(summary, "❌ AndVl1/SnakeGame@main: failure (abcdef1)") || !strings.Contains(summary, "❌ build: failure (42s) https://ci/build") {
+		t.Errorf("unexpected summary:\n%s", summary)
+	}
+
+	if _, err := (&GitHubMCPClient{}).GetRefStatus(ctx, "o", "r", "main"); err == nil {
+		t.Error("GetRefStatus without session must fail")
+	}
+}
@@ -116,6 +116,9 @@ func (m *GmailMCPClient) SearchEmails(ctx context.Context, query string, maxEmai
 					if id, ok := emailData["id"].(string); ok {
 						email.ID = id
 					}
+					if threadID, ok := emailData["thread_id"].(string); ok {
+						email.ThreadID = threadID
+					}
 					if subject, ok := emailData["subject"].(string); ok {
 						email.Subject = subject
 					}
@@ -153,6 +156,379 @@ func (m *GmailMCPClient) SearchEmails(ctx context.Context, query string, maxEmai
 	}
 }
 
+// SendEmail отправляет email через MCP инструмент send_gmail
+func (m *GmailMCPClient) SendEmail(ctx context.Context, req GmailSendRequest) GmailSendResult {
+	if m.session == nil {
+		return GmailSendResult{Success: false, Message: "Gmail MCP session not connected"}
+	}
+
+	log.Printf("📤 Sending Gmail via MCP: to=%v, subject='%s'", req.To, req.Subject)
+
+	args := map[string]any{
+		"to":      req.To,
+		"subject": req.Subject,
+		"body":    req.Body,
+	}
+	if len(req.Cc) > 0 {
+		args["cc"] = req.Cc
+	}
+	if req.BodyType != "" {
+		args["body_type"] = req.BodyType
+	}
+	if req.ReplyToMessageID != "" {
+		args["reply_to_message_id"] = req.ReplyToMessageID
+	}
+
+	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "send_gmail",
+		Arguments: args,
+	})
+	if err != nil {
+		log.Printf("❌ Gmail MCP send error: %v", err)
+		return GmailSendResult{Success: false, Message: fmt.Sprintf("Gmail MCP send error: %v", err)}
+	}
+
+	var responseText string
+	for _, content := range result.Content {
+		if textContent, ok := content.(*mcp.TextContent); ok {
+			responseText += textContent.Text
+		}
+	}
+
+	if result.IsError {
+		return GmailSendResult{Success: false, Message: responseText}
+	}
+
+	sendResult := GmailSendResult{Success: true, Message: responseText}
+	if result.Meta != nil {
+		if id, ok := result.Meta["message_id"].(string); ok {
+			sendResult.MessageID = id
+		}
+		if threadID, ok := result.Meta["thread_id"].(string); ok {
+			sendResult.ThreadID = threadID
+		}
+	}
+	return sendResult
+}
+
+// GetEmailBody получает полное тело письма через MCP инструмент get_gmail_body
+func (m *GmailMCPClient) GetEmailBody(ctx context.Context, messageID string, preferHTML bool) GmailBodyResult {
+	if m.session == nil {
+		return GmailBodyResult{Success: false, Message: "Gmail MCP session not connected"}
+	}
+
+	log.Printf("📨 Getting Gmail body via MCP: message_id=%s", messageID)
+
+	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
+		Name: "get_gmail_body",
+		Arguments: map[string]any{
+			"message_id":  messageID,
+			"prefer_html": preferHTML,
+		},
+	})
+	if err != nil {
+		log.Printf("❌ Gmail MCP get body error: %v", err)
+		return GmailBodyResult{Success: false, Message: fmt.Sprintf("Gmail MCP get body error: %v", err)}
+	}
+
+	var responseText string
+	for _, content := range result.Content {
+		if textContent, ok := content.(*mcp.TextContent); ok {
+			responseText += textContent.Text
+		}
+	}
+
+	if result.IsError {
+		return GmailBodyResult{Success: false, Message: responseText}
+	}
+
+	bodyResult := GmailBodyResult{Success: true, Message: responseText, MessageID: messageID}
+	if result.Meta != nil {
+		if truncated, ok := result.Meta["truncated"].(bool); ok {
+			bodyResult.Truncated = truncated
+		}
+		if mimeType, ok := result.Meta["mime_type"].(string); ok {
+			bodyResult.MimeType = mimeType
+		}
+	}
+	return bodyResult
+}
+
+// ExportResults собирает письма в markdown документ через MCP инструмент export_gmail_results.
+// Если заданы messageIDs, query игнорируется
+func (m *GmailMCPClient) ExportResults(ctx context.Context, req GmailExportRequest) GmailExportResult {
+	if m.session == nil {
+		return GmailExportResult{Success: false, Message: "Gmail MCP session not connected"}
+	}
+
+	log.Printf("🗂 Exporting Gmail results via MCP: query='%s', ids=%d, destination=%s", req.Query, len(req.MessageIDs), req.Destination)
+
+	args := map[string]any{
+		"query":           req.Query,
+		"max_emails":      req.MaxEmails,
+		"time_range":      req.TimeRange,
+		"destination":     req.Destination,
+		"title":           req.Title,
+		"max_total_bytes": req.MaxTotalBytes,
+	}
+	if len(req.MessageIDs) > 0 {
+		args["message_ids"] = req.MessageIDs
+	}
+	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "export_gmail_results",
+		Arguments: args,
+	})
+	if err != nil {
+		log.Printf("❌ Gmail MCP export error: %v", err)
+		return GmailExportResult{Success: false, Message: fmt.Sprintf("Gmail MCP export error: %v", err)}
+	}
+
+	var responseText string
+	for _, content := range result.Content {
+		if textContent, ok := content.(*mcp.TextContent); ok {
+			responseText += textContent.Text
+		}
+	}
+
+	if result.IsError {
+		return GmailExportResult{Success: false, Message: responseText}
+	}
+
+	exportResult := GmailExportResult{Success: true, Markdown: responseText}
+	if result.Meta != nil {
+		if title, ok := result.Meta["title"].(string); ok {
+			exportResult.Title = title
+		}
+		if total, ok := result.Meta["total_exported"].(float64); ok {
+			exportResult.TotalExported = int(total)
+		}
+		// Отчёт по письмам приходит как JSON массив объектов
+		if raw := formatResultMeta(result.Meta["emails"]); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &exportResult.Emails); err != nil {
+				log.Printf("⚠️ Failed to parse export report: %v", err)
+			}
+		}
+	}
+	if exportResult.TotalExported == 0 && len(exportResult.Emails) == 0 {
+		exportResult.Message = responseText
+	}
+	return exportResult
+}
+
+// ModifyLabels добавляет и снимает метки письма через MCP инструмент modify_gmail_labels.
+// Метки — системные (UNREAD, STARRED, ...) в любом регистре или имена пользовательских меток
+func (m *GmailMCPClient) ModifyLabels(ctx context.Context, messageID string, addLabels, removeLabels []string) GmailLabelsResult {
+	if m.session == nil {
+		return GmailLabelsResult{Success: false, Message: "Gmail MCP session not connected"}
+	}
+
+	log.Printf("🏷 Modifying Gmail labels via MCP: message_id=%s, +%v -%v", messageID, addLabels, removeLabels)
+
+	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
+		Name: "modify_gmail_labels",
+		Arguments: map[string]any{
+			"message_id":    messageID,
+			"add_labels":    addLabels,
+			"remove_labels": removeLabels,
+		},
+	})
+	if err != nil {
+		log.Printf("❌ Gmail MCP modify labels error: %v", err)
+		return GmailLabelsResult{Success: false, Message: fmt.Sprintf("Gmail MCP modify labels error: %v", err)}
+	}
+
+	var responseText string
+	for _, content := range result.Content {
+		if textContent, ok := content.(*mcp.TextContent); ok {
+			responseText += textContent.Text
+		}
+	}
+
+	if result.IsError {
+		return GmailLabelsResult{Success: false, Message: responseText}
+	}
+
+	labelsResult := GmailLabelsResult{Success: true, Message: responseText, MessageID: messageID}
+	if result.Meta != nil {
+		if ids, ok := result.Meta["label_ids"].([]any); ok {
+			for _, id := range ids {
+				if s, ok := id.(string); ok {
+					labelsResult.LabelIDs = append(labelsResult.LabelIDs, s)
+				}
+			}
+		}
+		if unread, ok := result.Meta["unread"].(bool); ok {
+			labelsResult.Unread = unread
+		}
+	}
+	return labelsResult
+}
+
+// MarkAsRead снимает метку UNREAD с писем, например после саммари входящих; возвращает число отмеченных
+func (m *GmailMCPClient) MarkAsRead(ctx context.Context, messageIDs []string) (int, error) {
+	marked := 0
+	for _, id := range messageIDs {
+		res := m.ModifyLabels(ctx, id, nil, []string{"UNREAD"})
+		if !res.Success {
+			return marked, fmt.Errorf("message %s: %s", id, res.Message)
+		}
+		marked++
+	}
+	return marked, nil
+}
+
+// GetThread возвращает переписку целиком через MCP инструмент get_gmail_thread:
+// последние maxMessages писем треда по порядку, тексты писем — в Message
+func (m *GmailMCPClient) GetThread(ctx context.Context, threadID string, maxMessages int) GmailThreadResult {
+	if m.session == nil {
+		return GmailThreadResult{Success: false, Message: "Gmail MCP session not connected"}
+	}
+
+	log.Printf("🧵 Getting Gmail thread via MCP: thread_id=%s, max=%d", threadID, maxMessages)
+
+	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
+		Name: "get_gmail_thread",
+		Arguments: map[string]any{
+			"thread_id":    threadID,
+			"max_messages": maxMessages,
+		},
+	})
+	if err != nil {
+		log.Printf("❌ Gmail MCP get thread error: %v", err)
+		return GmailThreadResult{Success: false, Message: fmt.Sprintf("Gmail MCP get thread error: %v", err)}
+	}
+
+	var responseText string
+	for _, content := range result.Content {
+		if textContent, ok := content.(*mcp.TextContent); ok {
+			responseText += textContent.Text
+		}
+	}
+
+	if result.IsError {
+		return GmailThreadResult{Success: false, Message: responseText}
+	}
+
+	threadResult := GmailThreadResult{Success: true, Message: responseText, ThreadID: threadID}
+	if result.Meta != nil {
+		if subject, ok := result.Meta["subject"].(string); ok {
+			threadResult.Subject = subject
+		}
+		if count, ok := result.Meta["message_count"].(float64); ok {
+			threadResult.MessageCount = int(count)
+		}
+		if truncated, ok := result.Meta["truncated"].(bool); ok {
+			threadResult.Truncated = truncated
+		}
+		if items, ok := result.Meta["messages"].([]any); ok {
+			for _, item := range items {
+				data, ok := item.(map[string]any)
+				if !ok {
+					continue
+				}
+				var msg GmailThreadMessage
+				msg.ID, _ = data["id"].(string)
+				msg.From, _ = data["from"].(string)
+				msg.Snippet, _ = data["snippet"].(string)
+				if dateStr, ok := data["date"].(string); ok {
+					if parsedDate, err := time.Parse(time.RFC3339, dateStr); err == nil {
+						msg.Date = parsedDate
+					}
+				}
+				threadResult.Messages = append(threadResult.Messages, msg)
+			}
+		}
+	}
+	return threadResult
+}
+
+// GmailExportRequest параметры экспорта писем
+type GmailExportRequest struct {
+	Query         string   `json:"query,omitempty"`
+	MessageIDs    []string `json:"message_ids,omitempty"`
+	MaxEmails     int      `json:"max_emails,omitempty"`
+	TimeRange     string   `json:"time_range,omitempty"`
+	Destination   string   `json:"destination,omitempty"` // file | notion
+	Title         string   `json:"title,omitempty"`
+	MaxTotalBytes int      `json:"max_total_bytes,omitempty"`
+}
+
+// GmailExportResult markdown документ с письмами и отчёт по каждому письму
+type GmailExportResult struct {
+	Success       bool                `json:"success"`
+	Message       string              `json:"message,omitempty"`
+	Title         string              `json:"title,omitempty"`
+	Markdown      string              `json:"markdown,omitempty"`
+	TotalExported int                 `json:"total_exported"`
+	Emails        []GmailExportReport `json:"emails,omitempty"`
+}
+
+// GmailExportReport сведения об экспорте одного письма
+type GmailExportReport struct {
+	ID            string `json:"id"`
+	Subject       string `json:"subject"`
+	BodyBytes     int    `json:"body_bytes"`
+	ExportedBytes int    `json:"exported_bytes"`
+	Truncated     bool   `json:"truncated"`
+	Skipped       bool   `json:"skipped,omitempty"`
+}
+
+// GmailLabelsResult метки письма после изменения
+type GmailLabelsResult struct {
+	Success   bool     `json:"success"`
+	Message   string   `json:"message"`
+	MessageID string   `json:"message_id,omitempty"`
+	LabelIDs  []string `json:"label_ids,omitempty"`
+	Unread    bool     `json:"unread"`
+}
+
+// GmailThreadResult переписка целиком
+type GmailThreadResult struct {
+	Success  bool   `json:"success"`
+	Message  string `json:"message"`
+	ThreadID string `json:"thread_id,omitempty"`
+	Subject  string `json:"subject,omitempty"`
+	// MessageCount число писем в треде; Messages может содержать только последние из них
+	MessageCount int                  `json:"message_count"`
+	Messages     []GmailThreadMessage `json:"messages,omitempty"`
+	Truncated    bool                 `json:"truncated,omitempty"`
+}
+
+// GmailThreadMessage письмо треда
+type GmailThreadMessage struct {
+	ID      string    `json:"id"`
+	From    string    `json:"from"`
+	Date    time.Time `json:"date"`
+	Snippet string    `json:"snippet"`
+}
+
+// GmailBodyResult полное тело письма
+type GmailBodyResult struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	MessageID string `json:"message_id,omitempty"`
+	MimeType  string `json:"mime_type,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// GmailSendRequest параметры отправки email
+type GmailSendRequest struct {
+	To               []string `json:"to"`
+	Cc               []string `json:"cc,omitempty"`
+	Subject          string   `json:"subject"`
+	Body             string   `json:"body"`
+	BodyType         string   `json:"body_type,omitempty"` // text | html
+	ReplyToMessageID string   `json:"reply_to_message_id,omitempty"`
+}
+
+// GmailSendResult результат отправки email
+type GmailSendResult struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	MessageID string `json:"message_id,omitempty"`
+	ThreadID  string `json:"thread_id,omitempty"`
+}
+
 // GmailMCPResult результат Gmail MCP операции
 type GmailMCPResult struct {
 	Success    bool               `json:"success"`
@@ -164,6 +540,7 @@ type GmailMCPResult struct {
 // GmailEmailResult информация о найденном email
 type GmailEmailResult struct {
 	ID          string    `json:"id"`
+	ThreadID    string    `json:"thread_id,omitempty"`
 	Subject     string    `json:"subject"`
 	From        string    `json:"from"`
 	Date        time.Time `json:"date"`
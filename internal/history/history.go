@@ -14,12 +14,40 @@ type entry struct {
 type Manager struct {
 	mu       sync.RWMutex
 	sessions map[int64][]entry
+	// maxMessages — сколько последних сообщений хранится на пользователя (0 — без ограничения)
+	maxMessages int
+	// maxTokens — примерный бюджет токенов контекста, возвращаемого Get (0 — без ограничения)
+	maxTokens int
 }
 
 func NewManager() *Manager {
 	return &Manager{sessions: make(map[int64][]entry)}
 }
 
+// SetLimits задаёт глубину истории: кольцевой буфер из maxMessages последних сообщений
+// и бюджет токенов (оценка по длине текста) для контекста, отдаваемого в LLM
+func (m *Manager) SetLimits(maxMessages, maxTokens int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxMessages = maxMessages
+	m.maxTokens = maxTokens
+	for userID := range m.sessions {
+		m.trimUnlocked(userID)
+	}
+}
+
+func (m *Manager) trimUnlocked(userID int64) {
+	es := m.sessions[userID]
+	if m.maxMessages > 0 && len(es) > m.maxMessages {
+		m.sessions[userID] = append([]entry(nil), es[len(es)-m.maxMessages:]...)
+	}
+}
+
+// EstimateTokens грубая оценка числа токенов сообщения (~4 символа на токен плюс служебные)
+func EstimateTokens(msg llm.Message) int {
+	return len([]rune(msg.Content))/4 + 4
+}
+
 func (m *Manager) Reset(userID int64) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -55,6 +83,7 @@ func (m *Manager) append(userID int64, msg llm.Message, used bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.sessions[userID] = append(m.sessions[userID], entry{msg: msg, used: used})
+	m.trimUnlocked(userID)
 }
 
 // Get returns only messages that are marked as used in context (backward-compatible behavior)
@@ -70,6 +99,17 @@ func (m *Manager) GetUsed(userID int64) []llm.Message {
 			out = append(out, e.msg)
 		}
 	}
+	if m.maxTokens > 0 {
+		// Отбрасываем самые старые сообщения, пока не уложимся в бюджет (последнее сообщение остаётся всегда)
+		total := 0
+		for _, msg := range out {
+			total += EstimateTokens(msg)
+		}
+		for len(out) > 1 && total > m.maxTokens {
+			total -= EstimateTokens(out[0])
+			out = out[1:]
+		}
+	}
 	return out
 }
 
@@ -50,3 +50,28 @@ func TestHistoryAppendGetReset(t *testing.T) {
 		t.Fatalf("reset should not affect other users")
 	}
 }
+
+func TestHistoryLimits(t *testing.T) {
+	h := NewManager()
+	h.SetLimits(3, 0)
+	for _, c := range []string{"1", "2", "3", "4", "5"} {
+		h.AppendUser(1, c)
+	}
+	all := h.GetAll(1)
+	if len(all) != 3 || all[0].Content != "3" || all[2].Content != "5" {
+		t.Fatalf("ring buffer must keep last 3 messages: %+v", all)
+	}
+
+	h.Reset(1)
+	h.SetLimits(0, 20)
+	h.AppendUser(1, string(make([]rune, 40)))
+	h.AppendAssistant(1, string(make([]rune, 40)))
+	h.AppendUser(1, "short")
+	got := h.Get(1)
+	if len(got) != 2 || got[1].Content != "short" {
+		t.Fatalf("token budget must drop oldest messages, got %d", len(got))
+	}
+	if len(h.GetAll(1)) != 3 {
+		t.Fatalf("token budget must not delete stored messages")
+	}
+}
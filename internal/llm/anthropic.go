@@ -0,0 +1,327 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	anthropicBaseURL      = "https://api.anthropic.com/v1"
+	anthropicVersion      = "2023-06-01"
+	anthropicMaxTokens    = 4096
+	DefaultAnthropicModel = "claude-3-5-sonnet-latest"
+
+	// anthropicMaxAttempts попыток на запрос при 429/529 (перегрузка API)
+	anthropicMaxAttempts = 3
+	// anthropicMaxRetryDelay больше этого retry-after не ждём
+	anthropicMaxRetryDelay = time.Minute
+	// anthropicStatusOverloaded нестандартный статус Anthropic "overloaded"
+	anthropicStatusOverloaded = 529
+)
+
+// AnthropicAPIError ошибка Messages API с HTTP статусом и типом ошибки из ответа
+type AnthropicAPIError struct {
+	StatusCode int
+	Type       string // rate_limit_error, overloaded_error, invalid_request_error, ...
+	Message    string
+	RetryAfter time.Duration // из заголовка retry-after; 0 — не указан
+}
+
+func (e *AnthropicAPIError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("anthropic API error (%d %s): %s", e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("anthropic API error (%d): %s", e.StatusCode, e.Message)
+}
+
+// Retryable true для ограничения частоты и перегрузки API
+func (e *AnthropicAPIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == anthropicStatusOverloaded ||
+		e.Type == "rate_limit_error" || e.Type == "overloaded_error"
+}
+
+// AnthropicClient клиент Anthropic Messages API
+type AnthropicClient struct {
+	NoStreaming
+	LocalTokenCounter
+	apiKey     string
+	model      string
+	baseURL    string
+	httpClient *http.Client
+	// retryDelay пауза перед повтором, если retry-after не пришёл (удваивается с каждой попыткой)
+	retryDelay time.Duration
+}
+
+// anthropicModelAliases имена Claude в формате OpenRouter и короткие псевдонимы -> ID моделей Messages API
+var anthropicModelAliases = map[string]string{
+	"claude-3.5-sonnet": "claude-3-5-sonnet-latest",
+	"claude-3-5-sonnet": "claude-3-5-sonnet-latest",
+	"sonnet":            "claude-3-5-sonnet-latest",
+	"claude-3-haiku":    "claude-3-haiku-20240307",
+	"haiku":             "claude-3-haiku-20240307",
+	"claude-3-opus":     "claude-3-opus-latest",
+	"opus":              "claude-3-opus-latest",
+}
+
+// AnthropicModelName ID модели для Messages API: принимает ID Anthropic (claude-3-opus-20240229),
+// имена OpenRouter (anthropic/claude-3.5-sonnet:beta) и псевдонимы sonnet/haiku/opus.
+// ok=false — имя не похоже на модель Claude
+func AnthropicModelName(model string) (string, bool) {
+	name := strings.ToLower(strings.TrimSpace(model))
+	name = strings.TrimPrefix(name, "anthropic/")
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = name[:i]
+	}
+	if id, ok := anthropicModelAliases[name]; ok {
+		return id, true
+	}
+	if strings.HasPrefix(name, "claude") {
+		return name, true
+	}
+	return "", false
+}
+
+func NewAnthropic(apiKey, model string) *AnthropicClient {
+	if strings.TrimSpace(model) == "" {
+		model = DefaultAnthropicModel
+	}
+	return &AnthropicClient{
+		apiKey:     apiKey,
+		model:      model,
+		baseURL:    anthropicBaseURL,
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		retryDelay: 2 * time.Second,
+	}
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	Temperature *float32           `json:"temperature,omitempty"`
+	TopP        *float32           `json:"top_p,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type  string                 `json:"type"`
+		Text  string                 `json:"text,omitempty"`
+		ID    string                 `json:"id,omitempty"`
+		Name  string                 `json:"name,omitempty"`
+		Input map[string]interface{} `json:"input,omitempty"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+type anthropicErrorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *AnthropicClient) Generate(ctx context.Context, messages []Message) (Response, error) {
+	return c.GenerateWithTools(ctx, messages, nil)
+}
+
+func (c *AnthropicClient) GenerateWithTools(ctx context.Context, messages []Message, tools []Tool) (Response, error) {
+	system, msgs := toAnthropicMessages(messages)
+	req := anthropicRequest{
+		Model:     c.model,
+		MaxTokens: anthropicMaxTokens,
+		System:    system,
+		Messages:  msgs,
+	}
+	opts := OptionsFromContext(ctx)
+	req.Temperature, req.TopP = opts.Temperature, opts.TopP
+	for _, tool := range tools {
+		schema := tool.Function.Parameters
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		req.Tools = append(req.Tools, anthropicTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to marshal anthropic request: %w", err)
+	}
+	var resp anthropicResponse
+	for attempt := 1; ; attempt++ {
+		resp, err = c.send(ctx, body)
+		var apiErr *AnthropicAPIError
+		if err == nil || !errors.As(err, &apiErr) || !apiErr.Retryable() || attempt == anthropicMaxAttempts {
+			break
+		}
+		delay := apiErr.RetryAfter
+		if delay <= 0 {
+			delay = c.retryDelay << (attempt - 1)
+		}
+		if delay > anthropicMaxRetryDelay {
+			break
+		}
+		log.Printf("⏳ Anthropic %d %s, retry %d/%d in %s", apiErr.StatusCode, apiErr.Type, attempt, anthropicMaxAttempts-1, delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return Response{}, ctx.Err()
+		}
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	out := Response{Model: c.model, FinishReason: resp.StopReason}
+	if resp.Model != "" {
+		out.Model = resp.Model
+	}
+	var text strings.Builder
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			args := block.Input
+			if args == nil {
+				args = make(map[string]interface{})
+			}
+			out.ToolCalls = append(out.ToolCalls, ToolCall{
+				ID:       block.ID,
+				Type:     "function",
+				Function: FunctionCall{Name: block.Name, Arguments: args},
+			})
+		}
+	}
+	out.Content = text.String()
+	out.PromptTokens = resp.Usage.InputTokens
+	out.CompletionTokens = resp.Usage.OutputTokens
+	out.TotalTokens = resp.Usage.InputTokens + resp.Usage.OutputTokens
+	return out, nil
+}
+
+// send выполняет один запрос к /messages; ошибки API возвращаются как *AnthropicAPIError
+func (c *AnthropicClient) send(ctx context.Context, body []byte) (anthropicResponse, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/messages", bytes.NewReader(body))
+	if err != nil {
+		return anthropicResponse{}, fmt.Errorf("failed to create anthropic request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return anthropicResponse{}, fmt.Errorf("anthropic request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return anthropicResponse{}, fmt.Errorf("failed to read anthropic response: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		apiErr := &AnthropicAPIError{
+			StatusCode: httpResp.StatusCode,
+			Message:    strings.TrimSpace(string(respBody)),
+			RetryAfter: parseRetryAfter(httpResp.Header.Get("retry-after"), time.Now()),
+		}
+		var parsed anthropicErrorResponse
+		if json.Unmarshal(respBody, &parsed) == nil && parsed.Error.Message != "" {
+			apiErr.Type, apiErr.Message = parsed.Error.Type, parsed.Error.Message
+		}
+		return anthropicResponse{}, apiErr
+	}
+
+	var resp anthropicResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return anthropicResponse{}, fmt.Errorf("failed to parse anthropic response: %w", err)
+	}
+	return resp, nil
+}
+
+// parseRetryAfter разбирает retry-after: число секунд или HTTP-дата
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil && secs >= 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// toAnthropicMessages переводит историю в формат Messages API: system-сообщения собираются
+// в отдельное поле, подряд идущие сообщения одной роли склеиваются (API требует чередования),
+// результаты tool calls передаются как сообщения пользователя
+func toAnthropicMessages(messages []Message) (string, []anthropicMessage) {
+	var system []string
+	var out []anthropicMessage
+	for _, m := range messages {
+		role := m.Role
+		content := m.Content
+		switch role {
+		case "system":
+			if strings.TrimSpace(content) != "" {
+				system = append(system, content)
+			}
+			continue
+		case "assistant":
+			// Вызовы инструментов передаются текстом, как и их результаты ниже
+			if len(m.ToolCalls) > 0 {
+				content = strings.TrimSpace(content + "\n\n" + promptToolCallsText(m.ToolCalls))
+			}
+		case "tool":
+			// В истории нет исходного блока tool_use, поэтому результат передаётся текстом
+			role = "user"
+			content = fmt.Sprintf("Результат вызова инструмента %s:\n%s", m.ToolCallID, content)
+		default:
+			role = "user"
+		}
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		if n := len(out); n > 0 && out[n-1].Role == role {
+			out[n-1].Content += "\n\n" + content
+			continue
+		}
+		out = append(out, anthropicMessage{Role: role, Content: content})
+	}
+	return strings.Join(system, "\n\n"), out
+}
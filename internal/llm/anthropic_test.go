@@ -0,0 +1,202 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestToAnthropicMessages(t *testing.T) {
+	system, msgs := toAnthropicMessages([]Message{
+		{Role: "system", Content: "You are helpful"},
+		{Role: "user", Content: "hi"},
+		{Role: "user", Content: "are you there?"},
+		{Role: "assistant", Content: "yes"},
+		{Role: "system", Content: "Answer in JSON"},
+		{Role: "tool", Content: `{"ok":true}`, ToolCallID: "call_1"},
+	})
+
+	if system != "You are helpful\n\nAnswer in JSON" {
+		t.Errorf("system = %q", system)
+	}
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 alternating messages, got %d: %+v", len(msgs), msgs)
+	}
+	if msgs[0].Role != "user" || msgs[0].Content != "hi\n\nare you there?" {
+		t.Errorf("consecutive user messages not merged: %+v", msgs[0])
+	}
+	if msgs[1].Role != "assistant" || msgs[2].Role != "user" || !strings.Contains(msgs[2].Content, "call_1") {
+		t.Errorf("unexpected mapping: %+v", msgs[1:])
+	}
+}
+
+func TestAnthropicClient_GenerateWithTools(t *testing.T) {
+	var got anthropicRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/messages" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "key" || r.Header.Get("anthropic-version") != anthropicVersion {
+			t.Errorf("missing auth headers: %v", r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Write([]byte(`{
+			"model": "claude-3-5-sonnet-20241022",
+			"content": [
+				{"type": "text", "text": "Searching..."},
+				{"type": "tool_use", "id": "toolu_1", "name": "search_notion", "input": {"query": "go"}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 12, "output_tokens": 5}
+		}`))
+	}))
+	defer srv.Close()
+
+	c := NewAnthropic("key", "")
+	c.baseURL = srv.URL
+
+	resp, err := c.GenerateWithTools(context.Background(), []Message{
+		{Role: "system", Content: "sys"},
+		{Role: "user", Content: "find go notes"},
+	}, GetNotionTools()[1:2])
+	if err != nil {
+		t.Fatalf("GenerateWithTools: %v", err)
+	}
+
+	if got.Model != DefaultAnthropicModel || got.MaxTokens != anthropicMaxTokens || got.System != "sys" {
+		t.Errorf("unexpected request: %+v", got)
+	}
+	if len(got.Tools) != 1 || got.Tools[0].Name != "search_notion" || got.Tools[0].InputSchema["type"] != "object" {
+		t.Errorf("tools not mapped: %+v", got.Tools)
+	}
+	if resp.Content != "Searching..." || resp.Model != "claude-3-5-sonnet-20241022" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if resp.PromptTokens != 12 || resp.CompletionTokens != 5 || resp.TotalTokens != 17 {
+		t.Errorf("usage = %d/%d/%d", resp.PromptTokens, resp.CompletionTokens, resp.TotalTokens)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "toolu_1" || resp.ToolCalls[0].Function.Arguments["query"] != "go" {
+		t.Errorf("tool calls = %+v", resp.ToolCalls)
+	}
+}
+
+func TestAnthropicClient_APIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+	}))
+	defer srv.Close()
+
+	c := NewAnthropic("bad", "claude-3-haiku-20240307")
+	c.baseURL = srv.URL
+	_, err := c.Generate(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	if err == nil || !strings.Contains(err.Error(), "invalid x-api-key") {
+		t.Fatalf("expected API error, got %v", err)
+	}
+}
+
+func TestAnthropicClient_RetriesOverloadedAndRateLimit(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(529)
+			w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+		case 2:
+			w.Header().Set("retry-after", "0.01")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`))
+		default:
+			w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":3,"output_tokens":1}}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewAnthropic("key", "")
+	c.baseURL = srv.URL
+	c.retryDelay = time.Millisecond
+	resp, err := c.Generate(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	if err != nil || resp.Content != "ok" || resp.TotalTokens != 4 {
+		t.Fatalf("expected success after retries, got %+v, %v", resp, err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestAnthropicClient_ErrorMapping(t *testing.T) {
+	newServer := func(status int, body string, calls *int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(calls, 1)
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+	}
+
+	var limited int32
+	srv := newServer(http.StatusTooManyRequests, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`, &limited)
+	defer srv.Close()
+	c := NewAnthropic("key", "")
+	c.baseURL = srv.URL
+	c.retryDelay = time.Millisecond
+	_, err := c.Generate(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	var apiErr *AnthropicAPIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 429 || apiErr.Type != "rate_limit_error" || atomic.LoadInt32(&limited) != anthropicMaxAttempts {
+		t.Fatalf("expected rate limit error after %d attempts, got %v (calls=%d)", anthropicMaxAttempts, err, limited)
+	}
+
+	// Ошибки запроса не повторяются
+	var invalid int32
+	bad := newServer(http.StatusBadRequest, `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: field required"}}`, &invalid)
+	defer bad.Close()
+	c.baseURL = bad.URL
+	_, err = c.Generate(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	if !errors.As(err, &apiErr) || apiErr.Retryable() || apiErr.Type != "invalid_request_error" || atomic.LoadInt32(&invalid) != 1 {
+		t.Fatalf("bad request must fail without retry, got %v (calls=%d)", err, invalid)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"":                              0,
+		"5":                             5 * time.Second,
+		"garbage":                       0,
+		"Sat, 01 Mar 2025 12:00:30 GMT": 30 * time.Second,
+		"Sat, 01 Mar 2025 11:00:00 GMT": 0,
+	}
+	for in, want := range cases {
+		if got := parseRetryAfter(in, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestAnthropicModelName(t *testing.T) {
+	for in, want := range map[string]string{
+		"claude-3-opus-20240229":           "claude-3-opus-20240229",
+		"anthropic/claude-3.5-sonnet:beta": "claude-3-5-sonnet-latest",
+		"anthropic/claude-3-haiku":         "claude-3-haiku-20240307",
+		"Opus":                             "claude-3-opus-latest",
+	} {
+		if got, ok := AnthropicModelName(in); !ok || got != want {
+			t.Errorf("%s: got %q, %v, want %q", in, got, ok, want)
+		}
+	}
+	if _, ok := AnthropicModelName("qwen/qwen3-coder"); ok {
+		t.Error("non-Claude models must fall back to ANTHROPIC_MODEL")
+	}
+
+	c, err := (&Factory{AnthropicAPIKey: "k", AnthropicModel: DefaultAnthropicModel}).createClient(ProviderAnthropic, "anthropic/claude-3-haiku")
+	if err != nil || c.(*AnthropicClient).model != "claude-3-haiku-20240307" {
+		t.Fatalf("factory must resolve OpenRouter Claude names: %v", err)
+	}
+}
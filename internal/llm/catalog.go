@@ -0,0 +1,159 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CatalogModel модель из курируемого списка для выбора через /model
+type CatalogModel struct {
+	Name string
+	// AdminOnly модель показывается и доступна только администратору
+	AdminOnly bool
+}
+
+// ModelCatalog курируемый список моделей по провайдерам
+type ModelCatalog struct {
+	providers []string
+	models    map[string][]CatalogModel
+}
+
+// ParseModelCatalog разбирает MODEL_CATALOG: провайдеры через «;», модели через «,»,
+// префикс «!» помечает модель только для администратора:
+//
+//	openai=qwen/qwen3-coder,!openai/gpt-5-nano;anthropic=claude-3-5-sonnet-latest
+func ParseModelCatalog(spec string) (*ModelCatalog, error) {
+	c := &ModelCatalog{models: make(map[string][]CatalogModel)}
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		provider, list, ok := strings.Cut(part, "=")
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if !ok || provider == "" {
+			return nil, fmt.Errorf("invalid model catalog entry %q: want provider=model1,model2", part)
+		}
+		if !isKnownProvider(provider) {
+			return nil, fmt.Errorf("unknown provider %q in model catalog", provider)
+		}
+		for _, name := range strings.Split(list, ",") {
+			name = strings.TrimSpace(name)
+			adminOnly := strings.HasPrefix(name, "!")
+			name = strings.TrimSpace(strings.TrimPrefix(name, "!"))
+			if name == "" {
+				continue
+			}
+			c.add(provider, CatalogModel{Name: name, AdminOnly: adminOnly})
+		}
+	}
+	return c, nil
+}
+
+// DefaultModelCatalog каталог для настроенных провайдеров, если MODEL_CATALOG не задан:
+// AllowedModels для OpenAI-совместимого API и модели по умолчанию для остальных
+func (f *Factory) DefaultModelCatalog() *ModelCatalog {
+	c := &ModelCatalog{models: make(map[string][]CatalogModel)}
+	for _, provider := range f.ConfiguredProviders() {
+		switch provider {
+		case ProviderOpenAI:
+			models := GetAllowedModels()
+			sort.Strings(models)
+			for _, m := range models {
+				c.add(provider, CatalogModel{Name: m})
+			}
+		case ProviderAnthropic:
+			if f.AnthropicModel != "" {
+				c.add(provider, CatalogModel{Name: f.AnthropicModel})
+			}
+		case ProviderYandex:
+			c.add(provider, CatalogModel{Name: "yandexgpt"})
+		}
+	}
+	return c
+}
+
+// ConfiguredProviders провайдеры, для которых заданы ключи доступа
+func (f *Factory) ConfiguredProviders() []string {
+	var out []string
+	if f.OpenaiAPIKey != "" {
+		out = append(out, ProviderOpenAI)
+	}
+	if f.AnthropicAPIKey != "" {
+		out = append(out, ProviderAnthropic)
+	}
+	if f.YandexOAuthToken != "" {
+		out = append(out, ProviderYandex)
+	}
+	return out
+}
+
+// Restrict оставляет только перечисленных провайдеров (например, настроенных в фабрике)
+func (c *ModelCatalog) Restrict(providers []string) *ModelCatalog {
+	keep := make(map[string]bool, len(providers))
+	for _, p := range providers {
+		keep[p] = true
+	}
+	out := &ModelCatalog{models: make(map[string][]CatalogModel)}
+	for _, p := range c.providers {
+		if keep[p] {
+			out.providers = append(out.providers, p)
+			out.models[p] = c.models[p]
+		}
+	}
+	return out
+}
+
+// Providers провайдеры, в которых есть хотя бы одна модель, доступная пользователю
+func (c *ModelCatalog) Providers(isAdmin bool) []string {
+	var out []string
+	for _, p := range c.providers {
+		if len(c.Models(p, isAdmin)) > 0 {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Models модели провайдера, доступные пользователю; модели AdminOnly видит только администратор
+func (c *ModelCatalog) Models(provider string, isAdmin bool) []CatalogModel {
+	var out []CatalogModel
+	for _, m := range c.models[strings.ToLower(provider)] {
+		if m.AdminOnly && !isAdmin {
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// Allows true, если модель провайдера есть в каталоге и доступна пользователю
+func (c *ModelCatalog) Allows(provider, model string, isAdmin bool) bool {
+	for _, m := range c.Models(provider, isAdmin) {
+		if m.Name == model {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ModelCatalog) add(provider string, m CatalogModel) {
+	if _, ok := c.models[provider]; !ok {
+		c.providers = append(c.providers, provider)
+	}
+	for _, existing := range c.models[provider] {
+		if existing.Name == m.Name {
+			return
+		}
+	}
+	c.models[provider] = append(c.models[provider], m)
+}
+
+func isKnownProvider(provider string) bool {
+	switch provider {
+	case ProviderOpenAI, ProviderYandex, ProviderAnthropic:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseModelCatalog(t *testing.T) {
+	c, err := ParseModelCatalog(" openai = a, !b ,a; Anthropic=!c ;")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := c.Providers(true); !reflect.DeepEqual(got, []string{"openai", "anthropic"}) {
+		t.Fatalf("admin providers: %v", got)
+	}
+	if got := c.Providers(false); !reflect.DeepEqual(got, []string{"openai"}) {
+		t.Fatalf("user providers must skip admin-only ones: %v", got)
+	}
+	if got := c.Models("openai", false); !reflect.DeepEqual(got, []CatalogModel{{Name: "a"}}) {
+		t.Fatalf("user models: %v", got)
+	}
+	if !c.Allows("openai", "b", true) || c.Allows("openai", "b", false) || c.Allows("openai", "x", true) {
+		t.Fatal("unexpected Allows result")
+	}
+	if got := c.Restrict([]string{"anthropic"}).Providers(true); !reflect.DeepEqual(got, []string{"anthropic"}) {
+		t.Fatalf("restrict: %v", got)
+	}
+
+	for _, bad := range []string{"openai", "gemini=x"} {
+		if _, err := ParseModelCatalog(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestFactory_DefaultModelCatalog(t *testing.T) {
+	c := (&Factory{OpenaiAPIKey: "k", AnthropicAPIKey: "k", AnthropicModel: "claude-x"}).DefaultModelCatalog()
+	if got := c.Providers(false); !reflect.DeepEqual(got, []string{"openai", "anthropic"}) {
+		t.Fatalf("providers: %v", got)
+	}
+	if len(c.Models("openai", false)) != len(GetAllowedModels()) {
+		t.Fatalf("openai models must mirror AllowedModels")
+	}
+	if !c.Allows("anthropic", "claude-x", false) {
+		t.Fatal("anthropic default model missing")
+	}
+}
@@ -1,11 +1,16 @@
 package llm
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
 type Message struct {
 	Role       string
 	Content    string
 	ToolCallID string // Для tool response сообщений
+	// ToolCalls вызовы инструментов в ответе assistant, на которые отвечают следующие tool сообщения
+	ToolCalls []ToolCall
 }
 
 // FunctionCall представляет вызов функции от LLM
@@ -46,11 +51,40 @@ type Response struct {
 	PromptTokens     int
 	CompletionTokens int
 	TotalTokens      int
+	// FallbackFrom модель, которую запрашивали, если ответила резервная (Model); пусто — ответила основная
+	FallbackFrom string
+	// FinishReason причина завершения генерации от провайдера ("stop", "content_filter", "refusal", ...)
+	FinishReason string
+	// Refused провайдер отказал по политике и повторная попытка не помогла (см. WithRefusalRetry)
+	Refused bool
 	// Function calling support
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 }
 
+// StreamFunc получает очередной фрагмент ответа при потоковой генерации
+type StreamFunc func(delta string)
+
+// ErrStreamingNotSupported возвращается GenerateStream, если провайдер не поддерживает потоковую генерацию
+var ErrStreamingNotSupported = errors.New("streaming is not supported by this provider")
+
 type Client interface {
 	Generate(ctx context.Context, messages []Message) (Response, error)
+	// GenerateWithTools передаёт модели описания инструментов (имя, описание, JSON schema параметров);
+	// ответ содержит либо текст, либо ToolCalls. Провайдеры без function calling описывают
+	// инструменты в промпте и разбирают вызовы из текста (см. generateWithPromptTools)
 	GenerateWithTools(ctx context.Context, messages []Message, tools []Tool) (Response, error)
+	// GenerateStream генерирует ответ потоково: onDelta вызывается для каждого фрагмента,
+	// итоговый Response содержит весь текст и usage
+	GenerateStream(ctx context.Context, messages []Message, onDelta StreamFunc) (Response, error)
+	// CountTokens размер промпта в токенах до отправки: через API провайдера, если оно есть,
+	// иначе локальной оценкой (см. LocalTokenCounter)
+	CountTokens(messages []Message) (int, error)
+}
+
+// NoStreaming реализация GenerateStream по умолчанию для провайдеров без потоковой генерации;
+// встраивается в клиент, вызывающий код в этом случае использует Generate
+type NoStreaming struct{}
+
+func (NoStreaming) GenerateStream(ctx context.Context, messages []Message, onDelta StreamFunc) (Response, error) {
+	return Response{}, ErrStreamingNotSupported
 }
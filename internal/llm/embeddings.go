@@ -0,0 +1,55 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// DefaultEmbeddingModel модель эмбеддингов по умолчанию
+const DefaultEmbeddingModel = string(openai.SmallEmbedding3)
+
+// Embedder строит векторные представления текстов
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// OpenAIEmbedder эмбеддинги через OpenAI-совместимый API
+type OpenAIEmbedder struct {
+	client *openai.Client
+	model  string
+}
+
+// Embed возвращает векторы в том же порядке, что и тексты
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	resp, err := e.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: texts,
+		Model: openai.EmbeddingModel(e.model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embeddings: %w", err)
+	}
+	out := make([][]float32, len(texts))
+	for _, item := range resp.Data {
+		if item.Index >= 0 && item.Index < len(out) {
+			out[item.Index] = item.Embedding
+		}
+	}
+	return out, nil
+}
+
+// CreateEmbedder создаёт клиент эмбеддингов (поддерживается только OpenAI-совместимый API)
+func (f *Factory) CreateEmbedder(model string) (Embedder, error) {
+	if f.OpenaiAPIKey == "" {
+		return nil, fmt.Errorf("embeddings require OPENAI_API_KEY")
+	}
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+	c := NewOpenAI(f.OpenaiAPIKey, f.OpenaiBaseURL, model, f.OpenRouterReferrer, f.OpenRouterTitle)
+	return &OpenAIEmbedder{client: c.client, model: model}, nil
+}
@@ -5,11 +5,13 @@ import (
 	"strings"
 
 	"ai-chatter/internal/config"
+	"ai-chatter/internal/storage"
 )
 
 const (
-	ProviderOpenAI = "openai"
-	ProviderYandex = "yandex"
+	ProviderOpenAI    = "openai"
+	ProviderYandex    = "yandex"
+	ProviderAnthropic = "anthropic"
 )
 
 var AllowedModels = map[string]bool{
@@ -30,6 +32,18 @@ type Factory struct {
 	OpenRouterTitle    string
 	YandexOAuthToken   string
 	YandexFolderID     string
+	AnthropicAPIKey    string
+	AnthropicModel     string
+	// FallbackModels резервные модели OpenRouter для клиентов провайдера openai
+	FallbackModels []string
+	// FallbackChain резервные модели любого провайдера ("model" или "provider:model"), см. FallbackClient
+	FallbackChain []string
+	// Limiter общий лимит запросов для всех созданных клиентов (nil — без ограничения)
+	Limiter *RateLimiter
+	// Refusals счётчики отказов по политике; nil — клиенты не повторяют запрос после отказа
+	Refusals *RefusalStats
+	// RequestLog журнал запросов (LLM_LOG_REQUESTS); nil — запросы не журналируются
+	RequestLog storage.LLMRequestRecorder
 }
 
 func NewFactory(cfg *config.Config) *Factory {
@@ -40,15 +54,43 @@ func NewFactory(cfg *config.Config) *Factory {
 		OpenRouterTitle:    cfg.OpenRouterTitle,
 		YandexOAuthToken:   cfg.YandexOAuthToken,
 		YandexFolderID:     cfg.YandexFolderID,
+		AnthropicAPIKey:    cfg.AnthropicAPIKey,
+		AnthropicModel:     cfg.AnthropicModel,
+		FallbackModels:     cfg.OpenRouterFallbackModels,
+		FallbackChain:      cfg.LLMFallbackModels,
+		Limiter:            NewRateLimiter(cfg.LLMRequestsPerMinute),
+		Refusals:           NewRefusalStats(),
 	}
 }
 
 func (f *Factory) CreateClient(provider, model string) (Client, error) {
+	client, err := f.createClient(provider, model)
+	if err != nil {
+		return nil, err
+	}
+	client = f.withFallbacks(WithRateLimit(client, f.Limiter), provider, model)
+	return WithRequestLog(WithRefusalRetry(client, provider, f.Refusals), model, f.RequestLog), nil
+}
+
+func (f *Factory) createClient(provider, model string) (Client, error) {
 	switch strings.ToLower(provider) {
 	case ProviderOpenAI:
-		return NewOpenAI(f.OpenaiAPIKey, f.OpenaiBaseURL, model, f.OpenRouterReferrer, f.OpenRouterTitle), nil
+		c := NewOpenAI(f.OpenaiAPIKey, f.OpenaiBaseURL, model, f.OpenRouterReferrer, f.OpenRouterTitle)
+		c.SetFallbackModels(f.FallbackModels)
+		return c, nil
 	case ProviderYandex:
 		return NewYandex(f.YandexOAuthToken, f.YandexFolderID)
+	case ProviderAnthropic:
+		if f.AnthropicAPIKey == "" {
+			return nil, fmt.Errorf("anthropic provider requires ANTHROPIC_API_KEY")
+		}
+		// Общий файл модели хранит имена OpenRouter/OpenAI: имена Claude переводятся в ID Anthropic,
+		// остальные заменяются на ANTHROPIC_MODEL
+		name, ok := AnthropicModelName(model)
+		if !ok {
+			name = f.AnthropicModel
+		}
+		return NewAnthropic(f.AnthropicAPIKey, name), nil
 	default:
 		return nil, fmt.Errorf("unknown llm provider: %s", provider)
 	}
@@ -0,0 +1,154 @@
+package jsonextract
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// analysis форма ответов анализа проекта (как codevalidation.CodeAnalysisResult)
+type analysis struct {
+	Language        string   `json:"language"`
+	Commands        []string `json:"commands"`
+	InstallCommands []string `json:"install_commands"`
+	Reasoning       string   `json:"reasoning"`
+}
+
+// corpus реальные ответы моделей, которые раньше ломали разбор в отдельных местах кода
+var corpus = []struct {
+	name    string
+	content string
+	repair  bool
+	want    analysis
+}{
+	{
+		name:    "plain",
+		content: `{"language":"Go","commands":["go test ./..."]}`,
+		want:    analysis{Language: "Go", Commands: []string{"go test ./..."}},
+	},
+	{
+		name:    "fenced json with preamble",
+		content: "Here is the analysis:\n```json\n{\n  \"language\": \"Python\",\n  \"commands\": [\"pytest -q\"]\n}\n```\nLet me know if you need anything else.",
+		want:    analysis{Language: "Python", Commands: []string{"pytest -q"}},
+	},
+	{
+		name:    "fence without language",
+		content: "```\n{\"language\": \"Go\", \"commands\": [\"go vet ./...\"]}\n```",
+		want:    analysis{Language: "Go", Commands: []string{"go vet ./..."}},
+	},
+	{
+		name:    "trailing commentary with braces",
+		content: `{"language":"Go","commands":["go build ./..."]} Note: replace {pkg} with your package if needed.`,
+		want:    analysis{Language: "Go", Commands: []string{"go build ./..."}},
+	},
+	{
+		name:    "braces inside strings",
+		content: `{"language":"JavaScript","commands":["node -e \"console.log({a: 1})\""],"reasoning":"uses } and { in code"}`,
+		want:    analysis{Language: "JavaScript", Commands: []string{`node -e "console.log({a: 1})"`}, Reasoning: "uses } and { in code"},
+	},
+	{
+		name:    "example object before the answer",
+		content: "The format is {\"language\": ...}.\n\n{\"language\": \"Rust\", \"commands\": [\"cargo test\"]}",
+		want:    analysis{Language: "Rust", Commands: []string{"cargo test"}},
+	},
+	{
+		name:    "unclosed fence of truncated answer",
+		content: "```json\n{\"language\": \"Go\", \"commands\": [\"go test ./...\"]}\n",
+		want:    analysis{Language: "Go", Commands: []string{"go test ./..."}},
+	},
+	{
+		name:    "trailing commas",
+		content: "```json\n{\n  \"language\": \"Go\",\n  \"commands\": [\"go test ./...\",],\n}\n```",
+		repair:  true,
+		want:    analysis{Language: "Go", Commands: []string{"go test ./..."}},
+	},
+	{
+		name:    "single quotes",
+		content: `{'language': 'Python', 'commands': ['python -m pytest'], 'reasoning': 'it\'s a "pytest" project'}`,
+		repair:  true,
+		want:    analysis{Language: "Python", Commands: []string{"python -m pytest"}, Reasoning: `it's a "pytest" project`},
+	},
+	{
+		name:    "apostrophe in double-quoted value",
+		content: `{"language": "Python", "reasoning": "project's tests", "commands": ["pytest",]}`,
+		repair:  true,
+		want:    analysis{Language: "Python", Commands: []string{"pytest"}, Reasoning: "project's tests"},
+	},
+	{
+		name:    "string instead of array",
+		content: `{"language": "Go", "commands": "go test ./...", "install_commands": "go mod download"}`,
+		repair:  true,
+		want:    analysis{Language: "Go", Commands: []string{"go test ./..."}, InstallCommands: []string{"go mod download"}},
+	},
+	{
+		name:    "array instead of string",
+		content: `{"language": ["Go"], "reasoning": ["has go.mod", "has _test.go files"], "commands": ["go test ./..."]}`,
+		repair:  true,
+		want:    analysis{Language: "Go", Commands: []string{"go test ./..."}, Reasoning: "has go.mod\nhas _test.go files"},
+	},
+}
+
+func TestDecodeCorpus(t *testing.T) {
+	for _, tc := range corpus {
+		t.Run(tc.name, func(t *testing.T) {
+			var got analysis
+			if err := Decode(tc.content, &got, Options{Repair: tc.repair}); err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodeRepairIsOptional(t *testing.T) {
+	var got analysis
+	err := Decode(`{"language": "Go", "commands": "go test ./..."}`, &got, Options{})
+	if !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("expected ErrTypeMismatch without repair, got %v", err)
+	}
+	var jerr *Error
+	if !errors.As(err, &jerr) || jerr.Field != "commands" {
+		t.Errorf("expected mismatch on commands field, got %+v", jerr)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Error("original encoding/json error must be unwrappable")
+	}
+
+	if err := Decode(`{"language": "Go",}`, &got, Options{}); !errors.Is(err, ErrSyntax) {
+		t.Errorf("expected ErrSyntax without repair, got %v", err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		kind    error
+	}{
+		{"no json", "I could not analyze the project, sorry.", ErrNoJSON},
+		{"empty", "", ErrNoJSON},
+		{"truncated", "```json\n{\"language\": \"Go\", \"commands\": [\"go te", ErrUnterminated},
+		{"broken beyond repair", `{"language": Go test}`, ErrSyntax},
+		{"wrong type after repair", `{"language": "Go", "commands": [{"run": "go test"}]}`, ErrTypeMismatch},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got analysis
+			err := Decode(tc.content, &got, Options{Repair: true})
+			if !errors.Is(err, tc.kind) {
+				t.Fatalf("expected %v, got %v", tc.kind, err)
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	got, err := Extract("Sure!\n```json\n{\"tool_calls\": [{\"name\": \"x\"}]}\n```")
+	if err != nil || got != `{"tool_calls": [{"name": "x"}]}` {
+		t.Errorf("Extract = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,475 @@
+// Package jsonextract извлекает JSON объект из ответа LLM: снимает markdown обёртку, находит
+// внешний сбалансированный объект, игнорирует пояснения вокруг него и при необходимости
+// исправляет типовые ошибки моделей (одинарные кавычки, висячие запятые, строка вместо массива)
+package jsonextract
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNoJSON в ответе нет JSON объекта
+	ErrNoJSON = errors.New("no JSON object found")
+	// ErrUnterminated объект начат, но скобки не закрыты (обычно обрезанный ответ)
+	ErrUnterminated = errors.New("unterminated JSON object")
+	// ErrSyntax объект найден, но это невалидный JSON
+	ErrSyntax = errors.New("invalid JSON syntax")
+	// ErrTypeMismatch JSON валиден, но не подходит под структуру назначения
+	ErrTypeMismatch = errors.New("JSON does not match target type")
+)
+
+// Error ошибка разбора ответа LLM. Kind — один из ErrNoJSON, ErrUnterminated, ErrSyntax,
+// ErrTypeMismatch; errors.Is работает и с Kind, и с исходной ошибкой encoding/json
+type Error struct {
+	Kind error
+	// Offset позиция ошибки в извлечённом JSON (для ErrSyntax)
+	Offset int64
+	// Field поле структуры, тип которого не совпал (для ErrTypeMismatch)
+	Field string
+	// Repaired ошибка получена уже после попытки исправления
+	Repaired bool
+	// Snippet начало извлечённого JSON для логов
+	Snippet string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	msg := "failed to parse JSON: " + e.Kind.Error()
+	switch {
+	case e.Field != "":
+		msg += fmt.Sprintf(" (field %s)", e.Field)
+	case e.Offset > 0:
+		msg += fmt.Sprintf(" (offset %d)", e.Offset)
+	}
+	if e.Repaired {
+		msg += " after repair"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Options параметры Decode
+type Options struct {
+	// Repair исправлять одинарные кавычки, висячие запятые и несоответствие скаляр/массив
+	// полям структуры назначения, если строгий разбор не удался
+	Repair bool
+}
+
+// snippetLen длина фрагмента JSON в Error.Snippet
+const snippetLen = 120
+
+// Extract возвращает первый сбалансированный JSON объект из ответа: из markdown блока
+// ```json ... ``` (или ``` ... ```), если он есть, иначе из текста целиком
+func Extract(content string) (string, error) {
+	candidates, err := objects(content)
+	if err != nil {
+		return "", err
+	}
+	return candidates[0], nil
+}
+
+// Decode извлекает JSON объект из ответа LLM и декодирует его в target. Если в тексте несколько
+// объектов (например, пример в пояснении перед ответом), используется первый, который декодируется
+func Decode(content string, target any, opts Options) error {
+	candidates, err := objects(content)
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	for _, candidate := range candidates {
+		err := decode(candidate, target, opts)
+		if err == nil {
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func decode(raw string, target any, opts Options) error {
+	err := json.Unmarshal([]byte(raw), target)
+	if err == nil {
+		return nil
+	}
+	if !opts.Repair {
+		return wrapDecodeError(raw, err, false)
+	}
+
+	repaired := repairSyntax(raw)
+	var generic any
+	if err := json.Unmarshal([]byte(repaired), &generic); err != nil {
+		return wrapDecodeError(repaired, err, true)
+	}
+	fixed, err := json.Marshal(coerce(generic, reflect.TypeOf(target)))
+	if err != nil {
+		return wrapDecodeError(repaired, err, true)
+	}
+	if err := json.Unmarshal(fixed, target); err != nil {
+		return wrapDecodeError(repaired, err, true)
+	}
+	return nil
+}
+
+func wrapDecodeError(raw string, err error, repaired bool) error {
+	e := &Error{Kind: ErrSyntax, Repaired: repaired, Snippet: snippet(raw), Err: err}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		e.Offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		e.Kind = ErrTypeMismatch
+		e.Field = typeErr.Field
+	}
+	return e
+}
+
+func snippet(raw string) string {
+	if len(raw) <= snippetLen {
+		return raw
+	}
+	return raw[:snippetLen] + "..."
+}
+
+// objects кандидаты — сбалансированные объекты верхнего уровня из markdown блоков, а затем из текста
+func objects(content string) ([]string, error) {
+	content = strings.TrimSpace(content)
+	var candidates []string
+	unterminated := ""
+	for _, source := range append(fencedBlocks(content), content) {
+		found, partial := balancedObjects(source)
+		candidates = append(candidates, found...)
+		if unterminated == "" {
+			unterminated = partial
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates, nil
+	}
+	if unterminated != "" {
+		return nil, &Error{Kind: ErrUnterminated, Snippet: snippet(unterminated)}
+	}
+	return nil, &Error{Kind: ErrNoJSON, Snippet: snippet(content)}
+}
+
+// fencedBlocks содержимое markdown блоков ``` в порядке появления; строка языка отбрасывается
+func fencedBlocks(content string) []string {
+	var blocks []string
+	rest := content
+	for {
+		start := strings.Index(rest, "```")
+		if start < 0 {
+			return blocks
+		}
+		body := rest[start+3:]
+		if nl := strings.Index(body, "\n"); nl >= 0 && !strings.Contains(body[:nl], "{") {
+			body = body[nl+1:]
+		}
+		end := strings.Index(body, "```")
+		if end < 0 {
+			// Незакрытый блок: ответ обрезан, но объект внутри может быть целым
+			return append(blocks, body)
+		}
+		blocks = append(blocks, body[:end])
+		rest = body[end+3:]
+	}
+}
+
+// balancedObjects объекты верхнего уровня в тексте с учётом строк в двойных и одинарных кавычках.
+// partial — начало объекта, который не закрылся до конца текста
+func balancedObjects(s string) (found []string, partial string) {
+	depth := 0
+	start := -1
+	var quote byte
+	escaped := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == quote:
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			if depth > 0 {
+				quote = c
+			}
+		case '\'':
+			// Апостроф в значении без кавычек ("it's") не открывает строку: только после , : [ {
+			if depth > 0 && opensSingleQuoted(s[:i]) {
+				quote = c
+			}
+		case '{':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth == 0 {
+				found = append(found, s[start:i+1])
+				start = -1
+			}
+		}
+	}
+	if start >= 0 {
+		partial = s[start:]
+	}
+	return found, partial
+}
+
+func opensSingleQuoted(before string) bool {
+	trimmed := strings.TrimRight(before, " \t\r\n")
+	if trimmed == "" {
+		return false
+	}
+	switch trimmed[len(trimmed)-1] {
+	case '{', '[', ',', ':':
+		return true
+	}
+	return false
+}
+
+// repairSyntax заменяет строки в одинарных кавычках на JSON строки и убирает висячие запятые
+func repairSyntax(raw string) string {
+	var b strings.Builder
+	b.Grow(len(raw))
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '"':
+			end := stringEnd(raw, i, '"')
+			b.WriteString(raw[i:end])
+			i = end - 1
+		case c == '\'' && opensSingleQuoted(raw[:i]):
+			end := stringEnd(raw, i, '\'')
+			body := raw[i+1 : end]
+			if end > i+1 && raw[end-1] == '\'' {
+				body = raw[i+1 : end-1]
+			}
+			body = strings.ReplaceAll(body, `\'`, `'`)
+			quoted, _ := json.Marshal(unescapeLoose(body))
+			b.Write(quoted)
+			i = end - 1
+		case c == ',':
+			rest := strings.TrimLeft(raw[i+1:], " \t\r\n")
+			if strings.HasPrefix(rest, "}") || strings.HasPrefix(rest, "]") {
+				continue
+			}
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// stringEnd позиция сразу после закрывающей кавычки строки, начатой в raw[start]
+func stringEnd(raw string, start int, quote byte) int {
+	escaped := false
+	for i := start + 1; i < len(raw); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case raw[i] == '\\':
+			escaped = true
+		case raw[i] == quote:
+			return i + 1
+		}
+	}
+	return len(raw)
+}
+
+// unescapeLoose раскрывает JSON экранирование в строке из одинарных кавычек; если экранирование
+// некорректно, строка остаётся как есть
+func unescapeLoose(s string) string {
+	var out string
+	if err := json.Unmarshal([]byte(`"`+strings.ReplaceAll(s, `"`, `\"`)+`"`), &out); err != nil {
+		return s
+	}
+	return out
+}
+
+// coerce приводит разобранный JSON к форме типа t: скаляр в поле-срезе оборачивается в массив,
+// массив в строковом поле склеивается через перевод строки, массив из одного элемента в
+// скалярном поле разворачивается
+func coerce(v any, t reflect.Type) any {
+	if t == nil {
+		return v
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		arr, ok := v.([]any)
+		if !ok {
+			if v == nil {
+				return nil
+			}
+			arr = []any{v}
+		}
+		for i := range arr {
+			arr[i] = coerce(arr[i], t.Elem())
+		}
+		return arr
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		fields := jsonFields(t)
+		for key, val := range obj {
+			if ft, ok := fields[strings.ToLower(key)]; ok {
+				obj[key] = coerce(val, ft)
+			}
+		}
+		return obj
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		for key, val := range obj {
+			obj[key] = coerce(val, t.Elem())
+		}
+		return obj
+	case reflect.String:
+		arr, ok := v.([]any)
+		if !ok {
+			return v
+		}
+		parts := make([]string, 0, len(arr))
+		for _, item := range arr {
+			if s, ok := item.(string); ok {
+				parts = append(parts, s)
+				continue
+			}
+			data, _ := json.Marshal(item)
+			parts = append(parts, string(data))
+		}
+		return strings.Join(parts, "\n")
+	case reflect.Interface:
+		return v
+	default:
+		if arr, ok := v.([]any); ok && len(arr) == 1 {
+			return coerce(arr[0], t)
+		}
+		return v
+	}
+}
+
+// jsonFields типы полей структуры по JSON имени в нижнем регистре (encoding/json сопоставляет
+// ключи без учёта регистра), включая поля встроенных структур
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range jsonFields(ft) {
+					if _, ok := fields[k]; !ok {
+						fields[k] = v
+					}
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+	return fields
+}
+
+// PartialString декодирует строковое поле из незавершённого JSON (потоковый ответ LLM): возвращает
+// уже полученную часть значения; пустая строка, если поле ещё не началось
+func PartialString(s, field string) string {
+	idx := strings.Index(s, `"`+field+`"`)
+	if idx < 0 {
+		return ""
+	}
+	rest := strings.TrimLeft(s[idx+len(field)+2:], " \t\r\n")
+	if !strings.HasPrefix(rest, ":") {
+		return ""
+	}
+	rest = strings.TrimLeft(rest[1:], " \t\r\n")
+	if !strings.HasPrefix(rest, `"`) {
+		return ""
+	}
+	rest = rest[1:]
+
+	var sb strings.Builder
+	for i := 0; i < len(rest); i++ {
+		c := rest[i]
+		switch {
+		case c == '"':
+			return sb.String()
+		case c != '\\':
+			sb.WriteByte(c)
+		case i+1 >= len(rest):
+			return sb.String()
+		default:
+			i++
+			switch rest[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+			case 'u':
+				if i+4 >= len(rest) {
+					return sb.String()
+				}
+				if r, err := strconv.ParseUint(rest[i+1:i+5], 16, 32); err == nil {
+					sb.WriteRune(rune(r))
+				}
+				i += 4
+			default:
+				sb.WriteByte(rest[i])
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,161 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+)
+
+// ParseFallbackModel разбирает элемент LLM_FALLBACK_MODELS: "provider:model" для модели другого
+// провайдера, иначе — модель провайдера основного клиента. Суффиксы OpenRouter вида ":free"
+// не путаются с провайдером: префиксом считается только известный провайдер
+func ParseFallbackModel(spec, defaultProvider string) (provider, model string) {
+	spec = strings.TrimSpace(spec)
+	if i := strings.Index(spec, ":"); i > 0 {
+		switch prefix := strings.ToLower(spec[:i]); prefix {
+		case ProviderOpenAI, ProviderYandex, ProviderAnthropic:
+			return prefix, strings.TrimSpace(spec[i+1:])
+		}
+	}
+	return strings.ToLower(defaultProvider), spec
+}
+
+// fallbackTarget модель цепочки и её клиент
+type fallbackTarget struct {
+	name   string
+	client Client
+}
+
+// FallbackClient отправляет запрос основной модели, а при ошибке — резервным моделям по порядку.
+// В отличие от цепочки OpenRouter (SetFallbackModels) переключает и провайдера, и на любой ошибке,
+// кроме отмены запроса. Ответ резервной модели помечается FallbackFrom
+type FallbackClient struct {
+	primary   fallbackTarget
+	fallbacks []fallbackTarget
+}
+
+// NewFallbackClient клиент с цепочкой резервных моделей; primaryName — имя основной модели для логов и FallbackFrom
+func NewFallbackClient(primary Client, primaryName string) *FallbackClient {
+	return &FallbackClient{primary: fallbackTarget{name: primaryName, client: primary}}
+}
+
+// AddFallback добавляет резервную модель в конец цепочки
+func (c *FallbackClient) AddFallback(name string, client Client) {
+	c.fallbacks = append(c.fallbacks, fallbackTarget{name: name, client: client})
+}
+
+// withFallbacks оборачивает клиента цепочкой f.FallbackChain; модели без ключей провайдера пропускаются
+func (f *Factory) withFallbacks(primary Client, provider, model string) Client {
+	primaryName := modelName(provider, model)
+	fc := NewFallbackClient(primary, primaryName)
+	for _, spec := range f.FallbackChain {
+		fbProvider, fbModel := ParseFallbackModel(spec, provider)
+		name := modelName(fbProvider, fbModel)
+		if fbModel == "" && fbProvider != ProviderYandex || name == primaryName {
+			continue
+		}
+		client, err := f.createClient(fbProvider, fbModel)
+		if err != nil {
+			log.Printf("⚠️ LLM fallback model %s skipped: %v", name, err)
+			continue
+		}
+		fc.AddFallback(name, WithRateLimit(client, f.Limiter))
+	}
+	if len(fc.fallbacks) == 0 {
+		return primary
+	}
+	return fc
+}
+
+// modelName имя модели в логах и Response.Model; у YandexGPT модель не выбирается
+func modelName(provider, model string) string {
+	if model == "" {
+		return provider
+	}
+	return model
+}
+
+// shouldFallback имеет ли смысл спросить следующую модель: отмену запроса и отсутствие стриминга
+// резервная модель не исправит
+func shouldFallback(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && !errors.Is(err, ErrStreamingNotSupported)
+}
+
+func (c *FallbackClient) generate(ctx context.Context, call func(Client) (Response, error)) (Response, error) {
+	resp, err := call(c.primary.client)
+	if err == nil {
+		return c.answered(c.primary, resp, false), nil
+	}
+	for _, target := range c.fallbacks {
+		if !shouldFallback(ctx, err) {
+			break
+		}
+		log.Printf("⚠️ LLM model failed, trying fallback %s: %v", target.name, err)
+		if resp, err = call(target.client); err == nil {
+			return c.answered(target, resp, true), nil
+		}
+	}
+	return Response{}, err
+}
+
+// answered записывает в ответ модель, которая ответила, и логирует её
+func (c *FallbackClient) answered(target fallbackTarget, resp Response, fallback bool) Response {
+	if resp.Model == "" {
+		resp.Model = target.name
+	}
+	if fallback {
+		resp.FallbackFrom = c.primary.name
+		log.Printf("↪️ LLM request answered by fallback %s instead of %s", resp.Model, c.primary.name)
+	} else {
+		log.Printf("🤖 LLM request answered by %s", resp.Model)
+	}
+	return resp
+}
+
+func (c *FallbackClient) Generate(ctx context.Context, messages []Message) (Response, error) {
+	return c.generate(ctx, func(client Client) (Response, error) {
+		return client.Generate(ctx, messages)
+	})
+}
+
+func (c *FallbackClient) GenerateWithTools(ctx context.Context, messages []Message, tools []Tool) (Response, error) {
+	return c.generate(ctx, func(client Client) (Response, error) {
+		return client.GenerateWithTools(ctx, messages, tools)
+	})
+}
+
+// GenerateStream переключается на резервную модель, только пока пользователю не показан ни один
+// фрагмент ответа. Резервная модель без стриминга отвечает целиком одним фрагментом
+func (c *FallbackClient) GenerateStream(ctx context.Context, messages []Message, onDelta StreamFunc) (Response, error) {
+	streamed := false
+	track := func(delta string) {
+		streamed = true
+		onDelta(delta)
+	}
+	resp, err := c.primary.client.GenerateStream(ctx, messages, track)
+	if err == nil {
+		return c.answered(c.primary, resp, false), nil
+	}
+	for _, target := range c.fallbacks {
+		if streamed || !shouldFallback(ctx, err) {
+			break
+		}
+		log.Printf("⚠️ LLM model failed, trying fallback %s: %v", target.name, err)
+		resp, err = target.client.GenerateStream(ctx, messages, track)
+		if errors.Is(err, ErrStreamingNotSupported) {
+			if resp, err = target.client.Generate(ctx, messages); err == nil {
+				track(resp.Content)
+			}
+		}
+		if err == nil {
+			return c.answered(target, resp, true), nil
+		}
+	}
+	return Response{}, err
+}
+
+// CountTokens считает токены основной моделью
+func (c *FallbackClient) CountTokens(messages []Message) (int, error) {
+	return c.primary.client.CountTokens(messages)
+}
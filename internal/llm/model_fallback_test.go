@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// chainClient отвечает ошибкой err или текстом answer и считает вызовы
+type chainClient struct {
+	LocalTokenCounter
+	answer string
+	err    error
+	deltas []string
+	calls  int
+}
+
+func (c *chainClient) Generate(ctx context.Context, messages []Message) (Response, error) {
+	c.calls++
+	if c.err != nil {
+		return Response{}, c.err
+	}
+	return Response{Content: c.answer}, nil
+}
+
+func (c *chainClient) GenerateWithTools(ctx context.Context, messages []Message, tools []Tool) (Response, error) {
+	return c.Generate(ctx, messages)
+}
+
+func (c *chainClient) GenerateStream(ctx context.Context, messages []Message, onDelta StreamFunc) (Response, error) {
+	for _, d := range c.deltas {
+		onDelta(d)
+	}
+	return c.Generate(ctx, messages)
+}
+
+func TestFallbackClient_TriesChainInOrder(t *testing.T) {
+	primary := &chainClient{err: errors.New("model deprecated")}
+	broken := &chainClient{err: errors.New("503")}
+	backup := &chainClient{answer: "ok"}
+	fc := NewFallbackClient(primary, "openai/gpt-5-nano")
+	fc.AddFallback("qwen/qwen3-coder", broken)
+	fc.AddFallback("claude-3-5-haiku-latest", backup)
+
+	resp, err := fc.Generate(context.Background(), nil)
+	if err != nil || resp.Content != "ok" {
+		t.Fatalf("backup must answer, got %+v, %v", resp, err)
+	}
+	if resp.Model != "claude-3-5-haiku-latest" || resp.FallbackFrom != "openai/gpt-5-nano" {
+		t.Errorf("answering model must be reported: model=%q from=%q", resp.Model, resp.FallbackFrom)
+	}
+	if primary.calls != 1 || broken.calls != 1 || backup.calls != 1 {
+		t.Errorf("each model must be tried once in order: %d %d %d", primary.calls, broken.calls, backup.calls)
+	}
+
+	primary.err = nil
+	primary.answer = "primary"
+	if resp, _ := fc.Generate(context.Background(), nil); resp.FallbackFrom != "" || resp.Model != "openai/gpt-5-nano" {
+		t.Errorf("primary answer must not be marked as fallback: %+v", resp)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	primary.err = context.Canceled
+	if _, err := fc.Generate(ctx, nil); !errors.Is(err, context.Canceled) || broken.calls != 1 {
+		t.Errorf("cancelled request must not fall back, err=%v fallback calls=%d", err, broken.calls)
+	}
+}
+
+func TestFallbackClient_StreamSwitchesOnlyBeforeFirstDelta(t *testing.T) {
+	primary := &chainClient{err: errors.New("stream broke"), deltas: []string{"par"}}
+	backup := &chainClient{answer: "full", deltas: []string{"full"}}
+	fc := NewFallbackClient(primary, "primary")
+	fc.AddFallback("backup", backup)
+
+	var shown string
+	if _, err := fc.GenerateStream(context.Background(), nil, func(d string) { shown += d }); err == nil || backup.calls != 0 {
+		t.Fatalf("partially shown answer must not be continued by another model, err=%v", err)
+	}
+
+	primary.deltas = nil
+	shown = ""
+	resp, err := fc.GenerateStream(context.Background(), nil, func(d string) { shown += d })
+	if err != nil || shown != "full" || resp.FallbackFrom != "primary" {
+		t.Fatalf("fallback must stream its answer: shown=%q resp=%+v err=%v", shown, resp, err)
+	}
+}
+
+func TestParseFallbackModel(t *testing.T) {
+	for _, tc := range []struct{ spec, provider, model string }{
+		{"qwen/qwen3-coder:free", "openai", "qwen/qwen3-coder:free"},
+		{"anthropic:claude-3-5-haiku-latest", "anthropic", "claude-3-5-haiku-latest"},
+		{" Yandex: ", "yandex", ""},
+	} {
+		provider, model := ParseFallbackModel(tc.spec, "OpenAI")
+		if provider != tc.provider || model != tc.model {
+			t.Errorf("ParseFallbackModel(%q) = %q, %q; want %q, %q", tc.spec, provider, model, tc.provider, tc.model)
+		}
+	}
+
+	f := &Factory{OpenaiAPIKey: "k", FallbackChain: []string{"gpt-4o", "anthropic:claude-3-5-haiku-latest", "anthropic:"}}
+	client, err := f.CreateClient(ProviderOpenAI, "gpt-4o")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := client.(*FallbackClient); ok {
+		t.Error("chain without usable models must not wrap the client")
+	}
+	f.AnthropicAPIKey = "k"
+	client, _ = f.CreateClient(ProviderOpenAI, "gpt-4o")
+	if fc, ok := client.(*FallbackClient); !ok || len(fc.fallbacks) != 1 || fc.fallbacks[0].name != "claude-3-5-haiku-latest" {
+		t.Errorf("anthropic fallback expected, got %#v", client)
+	}
+}
@@ -3,8 +3,14 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/sashabaranov/go-openai"
 )
@@ -12,6 +18,13 @@ import (
 type OpenAIClient struct {
 	client *openai.Client
 	model  string
+	// fallbackModels резервные модели OpenRouter по порядку, см. SetFallbackModels
+	fallbackModels []string
+	// tokenizeURL эндпоинт подсчёта токенов OpenRouter; пусто — только локальная оценка
+	tokenizeURL   string
+	apiKey        string
+	httpClient    openai.HTTPDoer
+	noTokenizeAPI atomic.Bool
 }
 
 type headerTransport struct {
@@ -47,31 +60,88 @@ func NewOpenAI(apiKey, baseURL, model, referrer, title string) *OpenAIClient {
 		base := http.DefaultTransport
 		config.HTTPClient = &http.Client{Transport: headerTransport{rt: base, headers: h}}
 	}
-	return &OpenAIClient{
-		client: openai.NewClientWithConfig(config),
-		model:  model,
+	c := &OpenAIClient{
+		client:     openai.NewClientWithConfig(config),
+		model:      model,
+		apiKey:     apiKey,
+		httpClient: config.HTTPClient,
 	}
+	if strings.Contains(config.BaseURL, "openrouter.ai") {
+		c.tokenizeURL = strings.TrimRight(config.BaseURL, "/") + "/tokenize"
+	}
+	return c
 }
 
-func (c *OpenAIClient) Generate(ctx context.Context, messages []Message) (Response, error) {
-	return c.GenerateWithTools(ctx, messages, nil)
+// SetFallbackModels задаёт цепочку резервных моделей: при перегрузке или ошибке провайдера
+// (429, 502, 503, model_not_available) запрос повторяется со следующей моделью
+func (c *OpenAIClient) SetFallbackModels(models []string) {
+	c.fallbackModels = nil
+	seen := map[string]bool{c.model: true}
+	for _, m := range models {
+		m = strings.TrimSpace(m)
+		if m == "" || seen[m] {
+			continue
+		}
+		seen[m] = true
+		c.fallbackModels = append(c.fallbackModels, m)
+	}
 }
 
-func (c *OpenAIClient) GenerateWithTools(ctx context.Context, messages []Message, tools []Tool) (Response, error) {
-	var oaMsgs []openai.ChatCompletionMessage
-	for _, m := range messages {
-		msg := openai.ChatCompletionMessage{Role: m.Role, Content: m.Content}
-		// Для tool response сообщений добавляем ToolCallID
-		if m.Role == "tool" && m.ToolCallID != "" {
-			msg.ToolCallID = m.ToolCallID
+// withModelFallback выполняет запрос основной моделью, а при ошибке провайдера — следующими
+// моделями цепочки. Ответ резервной модели помечается FallbackFrom
+func (c *OpenAIClient) withModelFallback(ctx context.Context, call func(model string) (Response, error)) (Response, error) {
+	chain := append([]string{c.model}, c.fallbackModels...)
+	for i, model := range chain {
+		resp, err := call(model)
+		if err == nil {
+			if i > 0 {
+				resp.FallbackFrom = c.model
+				log.Printf("↪️ LLM fallback: %s answered instead of %s", model, c.model)
+			}
+			return resp, nil
 		}
-		oaMsgs = append(oaMsgs, msg)
+		if i == len(chain)-1 || ctx.Err() != nil || !isFallbackError(err) {
+			return Response{}, err
+		}
+		log.Printf("⚠️ LLM model %s failed, falling back to %s: %v", model, chain[i+1], err)
 	}
+	return Response{}, fmt.Errorf("no models configured")
+}
+
+// isFallbackError ошибки, после которых имеет смысл спросить другую модель: лимит,
+// недоступность провайдера или модели. Ошибки запроса (400, 401) резервная модель не исправит
+func isFallbackError(err error) bool {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		if isFallbackStatus(apiErr.HTTPStatusCode) {
+			return true
+		}
+		if code, ok := apiErr.Code.(string); ok && code == "model_not_available" {
+			return true
+		}
+		return strings.Contains(apiErr.Message, "model_not_available")
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return isFallbackStatus(reqErr.HTTPStatusCode)
+	}
+	return false
+}
+
+func isFallbackStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusBadGateway || status == http.StatusServiceUnavailable
+}
 
+func (c *OpenAIClient) Generate(ctx context.Context, messages []Message) (Response, error) {
+	return c.GenerateWithTools(ctx, messages, nil)
+}
+
+func (c *OpenAIClient) GenerateWithTools(ctx context.Context, messages []Message, tools []Tool) (Response, error) {
 	req := openai.ChatCompletionRequest{
 		Model:    c.model,
-		Messages: oaMsgs,
+		Messages: toOpenAIMessages(messages),
 	}
+	applyOpenAIOptions(ctx, &req)
 
 	// Добавляем tools если они есть
 	if len(tools) > 0 {
@@ -90,14 +160,22 @@ func (c *OpenAIClient) GenerateWithTools(ctx context.Context, messages []Message
 		req.ToolChoice = "auto" // LLM решает сама когда вызывать функции
 	}
 
+	return c.withModelFallback(ctx, func(model string) (Response, error) {
+		req.Model = model
+		return c.createChatCompletion(ctx, req)
+	})
+}
+
+func (c *OpenAIClient) createChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (Response, error) {
 	resp, err := c.client.CreateChatCompletion(ctx, req)
 	if err != nil {
 		return Response{}, fmt.Errorf("failed to create chat completion: %w", err)
 	}
 
 	out := Response{
-		Content: resp.Choices[0].Message.Content,
-		Model:   c.model,
+		Content:      resp.Choices[0].Message.Content,
+		Model:        req.Model,
+		FinishReason: string(resp.Choices[0].FinishReason),
 	}
 	out.PromptTokens = resp.Usage.PromptTokens
 	out.CompletionTokens = resp.Usage.CompletionTokens
@@ -120,6 +198,167 @@ func (c *OpenAIClient) GenerateWithTools(ctx context.Context, messages []Message
 	return out, nil
 }
 
+// GenerateStream генерирует ответ через SSE поток chat completions
+func (c *OpenAIClient) GenerateStream(ctx context.Context, messages []Message, onDelta StreamFunc) (Response, error) {
+	req := openai.ChatCompletionRequest{
+		Model:         c.model,
+		Messages:      toOpenAIMessages(messages),
+		Stream:        true,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+	}
+	applyOpenAIOptions(ctx, &req)
+
+	// Резервная модель подключается только до начала потока: показанные фрагменты не переиграть
+	var stream *openai.ChatCompletionStream
+	out, err := c.withModelFallback(ctx, func(model string) (Response, error) {
+		req.Model = model
+		s, err := c.client.CreateChatCompletionStream(ctx, req)
+		if err != nil {
+			return Response{}, fmt.Errorf("failed to create chat completion stream: %w", err)
+		}
+		stream = s
+		return Response{Model: model}, nil
+	})
+	if err != nil {
+		return Response{}, err
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Response{}, fmt.Errorf("failed to receive chat completion stream: %w", err)
+		}
+		// Последний чанк с include_usage содержит только usage без choices
+		if chunk.Usage != nil {
+			out.PromptTokens = chunk.Usage.PromptTokens
+			out.CompletionTokens = chunk.Usage.CompletionTokens
+			out.TotalTokens = chunk.Usage.TotalTokens
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].FinishReason != "" {
+			out.FinishReason = string(chunk.Choices[0].FinishReason)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		delta := chunk.Choices[0].Delta.Content
+		content.WriteString(delta)
+		if onDelta != nil {
+			onDelta(delta)
+		}
+	}
+	out.Content = content.String()
+	return out, nil
+}
+
+// tokenizeTimeout ограничение на запрос подсчёта токенов: при задержке дешевле оценить локально
+const tokenizeTimeout = 10 * time.Second
+
+// CountTokens считает токены через POST /api/v1/tokenize OpenRouter; если эндпоинт недоступен
+// (404, 405, 501), он больше не запрашивается и используется локальная оценка EstimateTokens
+func (c *OpenAIClient) CountTokens(messages []Message) (int, error) {
+	if c.tokenizeURL == "" || c.noTokenizeAPI.Load() {
+		return EstimateTokens(messages), nil
+	}
+	n, err := c.tokenize(messages)
+	if err != nil {
+		log.Printf("⚠️ OpenRouter tokenize failed, using local estimate: %v", err)
+		return EstimateTokens(messages), nil
+	}
+	return n, nil
+}
+
+func (c *OpenAIClient) tokenize(messages []Message) (int, error) {
+	body, err := json.Marshal(map[string]any{"model": c.model, "messages": toOpenAIMessages(messages)})
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tokenizeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenizeURL, strings.NewReader(string(body)))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		c.noTokenizeAPI.Store(true)
+		return 0, fmt.Errorf("tokenize endpoint is not available (%d)", resp.StatusCode)
+	default:
+		return 0, fmt.Errorf("tokenize returned %d", resp.StatusCode)
+	}
+	var out struct {
+		Count      *int              `json:"count"`
+		TokenCount *int              `json:"token_count"`
+		Tokens     []json.RawMessage `json:"tokens"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&out); err != nil {
+		return 0, fmt.Errorf("decode tokenize response: %w", err)
+	}
+	switch {
+	case out.Count != nil:
+		return *out.Count, nil
+	case out.TokenCount != nil:
+		return *out.TokenCount, nil
+	case out.Tokens != nil:
+		return len(out.Tokens), nil
+	}
+	return 0, fmt.Errorf("tokenize response has no token count")
+}
+
+// applyOpenAIOptions применяет параметры декодирования из контекста (см. WithOptions)
+func applyOpenAIOptions(ctx context.Context, req *openai.ChatCompletionRequest) {
+	opts := OptionsFromContext(ctx)
+	req.Temperature = openAIFloat(opts.Temperature)
+	req.TopP = openAIFloat(opts.TopP)
+}
+
+func toOpenAIMessages(messages []Message) []openai.ChatCompletionMessage {
+	var oaMsgs []openai.ChatCompletionMessage
+	for _, m := range messages {
+		msg := openai.ChatCompletionMessage{Role: m.Role, Content: m.Content}
+		// Для tool response сообщений добавляем ToolCallID
+		if m.Role == "tool" && m.ToolCallID != "" {
+			msg.ToolCallID = m.ToolCallID
+		}
+		for _, tc := range m.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:   tc.ID,
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      tc.Function.Name,
+					Arguments: marshalJSONArgs(tc.Function.Arguments),
+				},
+			})
+		}
+		oaMsgs = append(oaMsgs, msg)
+	}
+	return oaMsgs
+}
+
+// marshalJSONArgs сериализует аргументы функции в JSON строку для истории сообщений
+func marshalJSONArgs(args map[string]interface{}) string {
+	if len(args) == 0 {
+		return "{}"
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
 // parseJSONArgs парсит аргументы функции из JSON строки
 func parseJSONArgs(args string) map[string]interface{} {
 	var result map[string]interface{}
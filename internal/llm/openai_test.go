@@ -0,0 +1,158 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIClient_GenerateWithTools_WireFormat(t *testing.T) {
+	var got struct {
+		Tools []struct {
+			Type     string `json:"type"`
+			Function struct {
+				Name       string                 `json:"name"`
+				Parameters map[string]interface{} `json:"parameters"`
+			} `json:"function"`
+		} `json:"tools"`
+		ToolChoice string `json:"tool_choice"`
+		Messages   []struct {
+			Role       string `json:"role"`
+			ToolCallID string `json:"tool_call_id"`
+			ToolCalls  []struct {
+				ID       string `json:"id"`
+				Type     string `json:"type"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"messages"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "chatcmpl-1",
+			"choices": [{
+				"index": 0,
+				"finish_reason": "tool_calls",
+				"message": {
+					"role": "assistant",
+					"content": "",
+					"tool_calls": [{"id": "call_2", "type": "function", "function": {"name": "search_notion", "arguments": "{\"query\":\"go\"}"}}]
+				}
+			}],
+			"usage": {"prompt_tokens": 20, "completion_tokens": 7, "total_tokens": 27}
+		}`))
+	}))
+	defer srv.Close()
+
+	c := NewOpenAI("key", srv.URL, "gpt-4o-mini", "", "")
+	messages := []Message{
+		{Role: "user", Content: "найди заметки"},
+		{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: FunctionCall{Name: "list_available_pages", Arguments: map[string]interface{}{"limit": float64(5)}}}}},
+		{Role: "tool", ToolCallID: "call_1", Content: "Reports"},
+	}
+	resp, err := c.GenerateWithTools(context.Background(), messages, fallbackTools)
+	if err != nil {
+		t.Fatalf("GenerateWithTools: %v", err)
+	}
+
+	if len(got.Tools) != 2 || got.Tools[0].Type != "function" || got.Tools[0].Function.Name != "search_notion" || got.Tools[0].Function.Parameters["type"] != "object" {
+		t.Errorf("tools = %+v", got.Tools)
+	}
+	if got.ToolChoice != "auto" {
+		t.Errorf("tool_choice = %q", got.ToolChoice)
+	}
+	if len(got.Messages) != 3 {
+		t.Fatalf("messages = %+v", got.Messages)
+	}
+	call := got.Messages[1]
+	if call.Role != "assistant" || len(call.ToolCalls) != 1 || call.ToolCalls[0].ID != "call_1" ||
+		call.ToolCalls[0].Type != "function" || call.ToolCalls[0].Function.Arguments != `{"limit":5}` {
+		t.Errorf("assistant tool call = %+v", call)
+	}
+	if got.Messages[2].Role != "tool" || got.Messages[2].ToolCallID != "call_1" {
+		t.Errorf("tool result = %+v", got.Messages[2])
+	}
+
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "call_2" || resp.ToolCalls[0].Function.Name != "search_notion" ||
+		resp.ToolCalls[0].Function.Arguments["query"] != "go" {
+		t.Errorf("tool calls = %+v", resp.ToolCalls)
+	}
+	if resp.TotalTokens != 27 {
+		t.Errorf("usage = %+v", resp)
+	}
+}
+
+func TestOpenAIClient_FallsBackToNextModel(t *testing.T) {
+	var models []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		models = append(models, req.Model)
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Model {
+		case "qwen/qwen3-coder":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"error": {"code": 502, "message": "Provider returned error"}}`))
+		case "z-ai/glm-4.5-air:free":
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error": {"code": 429, "message": "Rate limit exceeded"}}`))
+		default:
+			w.Write([]byte(`{"id": "chatcmpl-1", "choices": [{"index": 0, "message": {"role": "assistant", "content": "ok"}}], "usage": {"prompt_tokens": 3, "completion_tokens": 1, "total_tokens": 4}}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewOpenAI("key", srv.URL, "qwen/qwen3-coder", "", "")
+	c.SetFallbackModels([]string{" z-ai/glm-4.5-air:free", "qwen/qwen3-coder", "", "deepseek/deepseek-chat"})
+	resp, err := c.Generate(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if resp.Content != "ok" || resp.Model != "deepseek/deepseek-chat" || resp.FallbackFrom != "qwen/qwen3-coder" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	want := []string{"qwen/qwen3-coder", "z-ai/glm-4.5-air:free", "deepseek/deepseek-chat"}
+	if len(models) != len(want) {
+		t.Fatalf("requested models = %v, want %v", models, want)
+	}
+	for i := range want {
+		if models[i] != want[i] {
+			t.Errorf("requested models = %v, want %v", models, want)
+		}
+	}
+}
+
+func TestOpenAIClient_NoFallbackOnRequestError(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"code": 400, "message": "invalid messages"}}`))
+	}))
+	defer srv.Close()
+
+	c := NewOpenAI("key", srv.URL, "qwen/qwen3-coder", "", "")
+	c.SetFallbackModels([]string{"deepseek/deepseek-chat"})
+	if _, err := c.Generate(context.Background(), []Message{{Role: "user", Content: "hi"}}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 {
+		t.Errorf("a bad request must not be retried with another model, got %d calls", calls)
+	}
+}
@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"math"
+)
+
+// GenerateOptions параметры декодирования для одного вызова; nil — значение провайдера по умолчанию
+type GenerateOptions struct {
+	Temperature *float32
+	TopP        *float32
+}
+
+// TaskType тип задачи, под который подбираются параметры декодирования
+type TaskType string
+
+const (
+	// TaskClassification детерминированные ответы: JSON, да/нет, классификация
+	TaskClassification TaskType = "classification"
+	// TaskGeneration генерация кода и текста
+	TaskGeneration TaskType = "generation"
+)
+
+// OptionsForTask параметры по умолчанию для типа задачи
+func OptionsForTask(task TaskType) GenerateOptions {
+	switch task {
+	case TaskClassification:
+		return GenerateOptions{Temperature: Float32(0)}
+	case TaskGeneration:
+		return GenerateOptions{Temperature: Float32(0.7), TopP: Float32(0.95)}
+	default:
+		return GenerateOptions{}
+	}
+}
+
+// Float32 возвращает указатель на значение (для полей GenerateOptions)
+func Float32(v float32) *float32 {
+	return &v
+}
+
+type generateOptionsKey struct{}
+
+// WithOptions передаёт параметры декодирования следующему вызову клиента через контекст,
+// поэтому они проходят через обёртки (таймауты, переопределения моделей) без изменения интерфейса Client
+func WithOptions(ctx context.Context, opts GenerateOptions) context.Context {
+	return context.WithValue(ctx, generateOptionsKey{}, opts)
+}
+
+// OptionsFromContext параметры декодирования, заданные через WithOptions
+func OptionsFromContext(ctx context.Context) GenerateOptions {
+	opts, _ := ctx.Value(generateOptionsKey{}).(GenerateOptions)
+	return opts
+}
+
+// GenerateWithOptions вызывает Generate с параметрами декодирования для этого запроса
+func GenerateWithOptions(ctx context.Context, client Client, messages []Message, opts GenerateOptions) (Response, error) {
+	return client.Generate(WithOptions(ctx, opts), messages)
+}
+
+// openAIFloat переводит значение для go-openai: поле с omitempty не отправляет 0,
+// поэтому нулевая температура передаётся минимальным положительным числом
+func openAIFloat(v *float32) float32 {
+	if v == nil {
+		return 0
+	}
+	if *v == 0 {
+		return math.SmallestNonzeroFloat32
+	}
+	return *v
+}
@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOptionsForTask(t *testing.T) {
+	cls := OptionsForTask(TaskClassification)
+	if cls.Temperature == nil || *cls.Temperature != 0 || cls.TopP != nil {
+		t.Errorf("classification options = %+v", cls)
+	}
+	gen := OptionsForTask(TaskGeneration)
+	if gen.Temperature == nil || *gen.Temperature <= 0 {
+		t.Errorf("generation must use a positive temperature: %+v", gen)
+	}
+	if opts := OptionsForTask("unknown"); opts.Temperature != nil || opts.TopP != nil {
+		t.Errorf("unknown task must keep provider defaults: %+v", opts)
+	}
+}
+
+func TestOptionsFromContext(t *testing.T) {
+	if opts := OptionsFromContext(context.Background()); opts.Temperature != nil {
+		t.Fatalf("expected empty options, got %+v", opts)
+	}
+	ctx := WithOptions(context.Background(), GenerateOptions{TopP: Float32(0.5)})
+	if opts := OptionsFromContext(ctx); opts.TopP == nil || *opts.TopP != 0.5 {
+		t.Fatalf("options not propagated: %+v", opts)
+	}
+}
+
+func TestOpenAIFloat_KeepsZeroTemperature(t *testing.T) {
+	if got := openAIFloat(nil); got != 0 {
+		t.Errorf("nil -> %v, want 0 (omitted)", got)
+	}
+	if got := openAIFloat(Float32(0)); got != math.SmallestNonzeroFloat32 {
+		t.Errorf("0 -> %v, must not be omitted by go-openai", got)
+	}
+	if got := openAIFloat(Float32(0.7)); got != 0.7 {
+		t.Errorf("0.7 -> %v", got)
+	}
+}
+
+func TestAnthropicClient_SendsGenerateOptions(t *testing.T) {
+	var raw map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&raw)
+		w.Write([]byte(`{"content": [{"type": "text", "text": "{}"}]}`))
+	}))
+	defer srv.Close()
+
+	c := NewAnthropic("key", "")
+	c.baseURL = srv.URL
+	if _, err := GenerateWithOptions(context.Background(), c, []Message{{Role: "user", Content: "classify"}}, OptionsForTask(TaskClassification)); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if temp, ok := raw["temperature"]; !ok || temp != float64(0) {
+		t.Errorf("temperature 0 must be sent explicitly, got %v (present=%v)", temp, ok)
+	}
+	if _, ok := raw["top_p"]; ok {
+		t.Errorf("top_p must be omitted when not set: %v", raw)
+	}
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter равномерно распределяет запросы к провайдеру: не больше perMinute в минуту.
+// Один лимитер разделяют все клиенты фабрики
+type RateLimiter struct {
+	mu        sync.Mutex
+	perMinute int
+	interval  time.Duration
+	next      time.Time
+}
+
+// NewRateLimiter возвращает nil при perMinute <= 0 (без ограничения)
+func NewRateLimiter(perMinute int) *RateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &RateLimiter{perMinute: perMinute, interval: time.Minute / time.Duration(perMinute)}
+}
+
+// PerMinute лимит запросов в минуту; 0 — без ограничения
+func (l *RateLimiter) PerMinute() int {
+	if l == nil {
+		return 0
+	}
+	return l.perMinute
+}
+
+// Wait ждёт слота для очередного запроса или отмены контекста
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rateLimitedClient ограничивает частоту запросов вложенного клиента
+type rateLimitedClient struct {
+	client  Client
+	limiter *RateLimiter
+}
+
+// WithRateLimit оборачивает клиента лимитером; при limiter == nil клиент возвращается как есть
+func WithRateLimit(client Client, limiter *RateLimiter) Client {
+	if limiter == nil {
+		return client
+	}
+	return &rateLimitedClient{client: client, limiter: limiter}
+}
+
+func (c *rateLimitedClient) Generate(ctx context.Context, messages []Message) (Response, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return Response{}, err
+	}
+	return c.client.Generate(ctx, messages)
+}
+
+func (c *rateLimitedClient) GenerateWithTools(ctx context.Context, messages []Message, tools []Tool) (Response, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return Response{}, err
+	}
+	return c.client.GenerateWithTools(ctx, messages, tools)
+}
+
+func (c *rateLimitedClient) GenerateStream(ctx context.Context, messages []Message, onDelta StreamFunc) (Response, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return Response{}, err
+	}
+	return c.client.GenerateStream(ctx, messages, onDelta)
+}
+
+// CountTokens не расходует лимит: подсчёт не генерирует ответ
+func (c *rateLimitedClient) CountTokens(messages []Message) (int, error) {
+	return c.client.CountTokens(messages)
+}
@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type stubClient struct {
+	NoStreaming
+	LocalTokenCounter
+}
+
+func (stubClient) Generate(ctx context.Context, messages []Message) (Response, error) {
+	return Response{Content: "ok"}, nil
+}
+
+func (stubClient) GenerateWithTools(ctx context.Context, messages []Message, tools []Tool) (Response, error) {
+	return Response{Content: "ok"}, nil
+}
+
+func TestRateLimiter_SpacesRequests(t *testing.T) {
+	if NewRateLimiter(0) != nil {
+		t.Fatal("zero limit must disable the limiter")
+	}
+	client := &stubClient{}
+	if WithRateLimit(client, nil) != Client(client) {
+		t.Fatal("nil limiter must return the client as is")
+	}
+
+	limited := WithRateLimit(client, NewRateLimiter(600)) // интервал 100ms
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := limited.Generate(context.Background(), nil); err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Fatalf("3 requests at 600/min must take at least 200ms, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := limited.Generate(ctx, nil); err == nil {
+		t.Fatal("cancelled context must abort waiting")
+	}
+}
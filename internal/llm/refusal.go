@@ -0,0 +1,179 @@
+package llm
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// FinishReasonContentFilter ответ остановлен фильтром безопасности провайдера (OpenAI, YandexGPT)
+	FinishReasonContentFilter = "content_filter"
+	// FinishReasonRefusal модель отказалась отвечать (stop_reason Anthropic)
+	FinishReasonRefusal = "refusal"
+)
+
+// refusalMaxRunes отказ — короткий ответ: в длинном тексте фраза-отказ скорее часть ответа по существу
+const refusalMaxRunes = 600
+
+// refusalPhrases типовые формулировки отказа (в нижнем регистре, апостроф ')
+var refusalPhrases = []string{
+	"i'm sorry, but i can't",
+	"i'm sorry, but i cannot",
+	"i'm sorry, i can't",
+	"i am sorry, but i cannot",
+	"i can't help with that",
+	"i cannot help with that",
+	"i can't assist with that",
+	"i cannot assist with that",
+	"i'm unable to help with that",
+	"i'm not able to help with that",
+	"i can't comply with",
+	"i cannot comply with",
+	"извините, но я не могу",
+	"извините, я не могу помочь",
+	"к сожалению, я не могу помочь",
+	"я не могу помочь с этим",
+	"я не могу выполнить этот запрос",
+	"я не могу ответить на этот вопрос",
+}
+
+// refusalRephrasePrompt мета-инструкция для повторной попытки после отказа
+const refusalRephrasePrompt = "Предыдущая попытка ответа на этот запрос была заблокирована фильтром безопасности, " +
+	"хотя запрос, скорее всего, легитимен. Ответь на законную часть запроса по существу, в прежнем формате ответа. " +
+	"Если ответить действительно нельзя, коротко и конкретно объясни, какое правило политики применяется и почему, без общих формулировок."
+
+// IsRefusal ответ — отказ по политике провайдера: finish_reason фильтра безопасности или
+// короткий ответ с типовой фразой отказа. Ответы с вызовами инструментов отказом не считаются
+func IsRefusal(resp Response) bool {
+	if len(resp.ToolCalls) > 0 {
+		return false
+	}
+	if resp.FinishReason == FinishReasonContentFilter || resp.FinishReason == FinishReasonRefusal {
+		return true
+	}
+	text := strings.TrimSpace(resp.Content)
+	if text == "" || utf8.RuneCountInString(text) > refusalMaxRunes {
+		return false
+	}
+	text = strings.ToLower(strings.NewReplacer("’", "'", "`", "'").Replace(text))
+	for _, phrase := range refusalPhrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// RefusalCount отказы провайдера: всего и сколько из них сняла повторная попытка
+type RefusalCount struct {
+	Refusals  int64
+	Recovered int64
+}
+
+// RefusalStats счётчики отказов по провайдерам для /llm_status. Один на фабрику
+type RefusalStats struct {
+	mu     sync.Mutex
+	counts map[string]RefusalCount
+}
+
+func NewRefusalStats() *RefusalStats {
+	return &RefusalStats{counts: make(map[string]RefusalCount)}
+}
+
+func (s *RefusalStats) record(provider string, recovered bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.counts[provider]
+	c.Refusals++
+	if recovered {
+		c.Recovered++
+	}
+	s.counts[provider] = c
+}
+
+// Snapshot копия счётчиков по провайдерам
+func (s *RefusalStats) Snapshot() map[string]RefusalCount {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]RefusalCount, len(s.counts))
+	for p, c := range s.counts {
+		out[p] = c
+	}
+	return out
+}
+
+// refusalRetryClient повторяет запрос один раз с мета-инструкцией, если провайдер отказал
+type refusalRetryClient struct {
+	client   Client
+	provider string
+	stats    *RefusalStats
+}
+
+// WithRefusalRetry оборачивает клиента повтором после отказа; при stats == nil клиент возвращается как есть.
+// Если отказ повторился, возвращается ответ с Refused=true
+func WithRefusalRetry(client Client, provider string, stats *RefusalStats) Client {
+	if stats == nil {
+		return client
+	}
+	return &refusalRetryClient{client: client, provider: strings.ToLower(provider), stats: stats}
+}
+
+func (c *refusalRetryClient) Generate(ctx context.Context, messages []Message) (Response, error) {
+	resp, err := c.client.Generate(ctx, messages)
+	return c.retryRefusal(messages, resp, err, func(msgs []Message) (Response, error) {
+		return c.client.Generate(ctx, msgs)
+	})
+}
+
+func (c *refusalRetryClient) GenerateWithTools(ctx context.Context, messages []Message, tools []Tool) (Response, error) {
+	resp, err := c.client.GenerateWithTools(ctx, messages, tools)
+	return c.retryRefusal(messages, resp, err, func(msgs []Message) (Response, error) {
+		return c.client.GenerateWithTools(ctx, msgs, tools)
+	})
+}
+
+// GenerateStream повторяет отказ без стриминга: отказ уже показан в превью, итоговый ответ его заменит
+func (c *refusalRetryClient) GenerateStream(ctx context.Context, messages []Message, onDelta StreamFunc) (Response, error) {
+	resp, err := c.client.GenerateStream(ctx, messages, onDelta)
+	return c.retryRefusal(messages, resp, err, func(msgs []Message) (Response, error) {
+		return c.client.Generate(ctx, msgs)
+	})
+}
+
+func (c *refusalRetryClient) CountTokens(messages []Message) (int, error) {
+	return c.client.CountTokens(messages)
+}
+
+func (c *refusalRetryClient) retryRefusal(messages []Message, resp Response, err error, generate func([]Message) (Response, error)) (Response, error) {
+	if err != nil || !IsRefusal(resp) {
+		return resp, err
+	}
+	log.Printf("🚫 %s refused the request (model=%s, finish_reason=%s), retrying with rephrase instruction", c.provider, resp.Model, resp.FinishReason)
+
+	retryMsgs := append(append([]Message(nil), messages...), Message{Role: "system", Content: refusalRephrasePrompt})
+	retry, retryErr := generate(retryMsgs)
+	if retryErr != nil {
+		log.Printf("⚠️ Refusal retry failed: %v", retryErr)
+		c.stats.record(c.provider, false)
+		resp.Refused = true
+		return resp, nil
+	}
+	retry.PromptTokens += resp.PromptTokens
+	retry.CompletionTokens += resp.CompletionTokens
+	retry.TotalTokens += resp.TotalTokens
+	if IsRefusal(retry) {
+		log.Printf("🚫 %s refused again after rephrase", c.provider)
+		c.stats.record(c.provider, false)
+		retry.Refused = true
+		return retry, nil
+	}
+	c.stats.record(c.provider, true)
+	log.Printf("✅ %s answered after rephrase instruction", c.provider)
+	return retry, nil
+}
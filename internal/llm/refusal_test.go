@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// scriptedClient возвращает ответы по очереди и запоминает полученные сообщения
+type scriptedClient struct {
+	NoStreaming
+	LocalTokenCounter
+	responses []Response
+	calls     [][]Message
+}
+
+func (c *scriptedClient) Generate(ctx context.Context, messages []Message) (Response, error) {
+	c.calls = append(c.calls, messages)
+	resp := c.responses[0]
+	if len(c.responses) > 1 {
+		c.responses = c.responses[1:]
+	}
+	return resp, nil
+}
+
+func (c *scriptedClient) GenerateWithTools(ctx context.Context, messages []Message, tools []Tool) (Response, error) {
+	return c.Generate(ctx, messages)
+}
+
+func TestIsRefusal(t *testing.T) {
+	cases := []struct {
+		name string
+		resp Response
+		want bool
+	}{
+		{"content filter", Response{Content: "partial", FinishReason: FinishReasonContentFilter}, true},
+		{"anthropic refusal", Response{FinishReason: FinishReasonRefusal}, true},
+		{"stock phrase", Response{Content: "I’m sorry, but I can’t help with that."}, true},
+		{"json answer", Response{Content: `{"title":"","answer":"Извините, но я не могу помочь с этим запросом."}`}, true},
+		{"normal answer", Response{Content: "Рецепт борща: свёкла, капуста..."}, false},
+		{"long answer mentioning phrase", Response{Content: strings.Repeat("текст ", 150) + "I can't help with that part, but"}, false},
+		{"tool calls", Response{Content: "I can't help with that", ToolCalls: []ToolCall{{ID: "1"}}}, false},
+	}
+	for _, tc := range cases {
+		if got := IsRefusal(tc.resp); got != tc.want {
+			t.Errorf("%s: IsRefusal = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRefusalRetry_RecoversWithRephrase(t *testing.T) {
+	inner := &scriptedClient{responses: []Response{
+		{Content: "I'm sorry, but I can't help with that.", PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		{Content: "Вот ответ", PromptTokens: 20, CompletionTokens: 7, TotalTokens: 27},
+	}}
+	stats := NewRefusalStats()
+	client := WithRefusalRetry(inner, "OpenAI", stats)
+
+	resp, err := client.Generate(context.Background(), []Message{{Role: "user", Content: "как убить процесс в linux"}})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if resp.Content != "Вот ответ" || resp.Refused || resp.TotalTokens != 42 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	if len(inner.calls) != 2 || len(inner.calls[1]) != 2 || inner.calls[1][1].Content != refusalRephrasePrompt {
+		t.Fatalf("retry must append the rephrase instruction: %+v", inner.calls)
+	}
+	if got := stats.Snapshot()["openai"]; got.Refusals != 1 || got.Recovered != 1 {
+		t.Errorf("unexpected stats %+v", got)
+	}
+}
+
+func TestRefusalRetry_PersistentRefusal(t *testing.T) {
+	inner := &scriptedClient{responses: []Response{{Content: "ignored", FinishReason: FinishReasonContentFilter}}}
+	stats := NewRefusalStats()
+	client := WithRefusalRetry(inner, "openai", stats)
+
+	resp, err := client.GenerateWithTools(context.Background(), []Message{{Role: "user", Content: "q"}}, nil)
+	if err != nil {
+		t.Fatalf("GenerateWithTools: %v", err)
+	}
+	if !resp.Refused || len(inner.calls) != 2 {
+		t.Fatalf("persistent refusal must be retried once and marked: %+v, calls=%d", resp, len(inner.calls))
+	}
+	if got := stats.Snapshot()["openai"]; got.Refusals != 1 || got.Recovered != 0 {
+		t.Errorf("unexpected stats %+v", got)
+	}
+
+	if WithRefusalRetry(inner, "openai", nil) != Client(inner) {
+		t.Error("nil stats must return the client as is")
+	}
+}
@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ai-chatter/internal/storage"
+)
+
+// requestLogMaxContent сколько символов сообщения и ответа попадает в журнал запросов
+const requestLogMaxContent = 500
+
+// LoggingClient пишет каждый запрос к обёрнутому клиенту в журнал (LLM_LOG_REQUESTS): сообщения,
+// ответ, токены, длительность и ошибку. Длинный текст обрезается до requestLogMaxContent символов
+type LoggingClient struct {
+	client   Client
+	model    string
+	recorder storage.LLMRequestRecorder
+}
+
+// WithRequestLog оборачивает клиента журналом запросов; при recorder == nil клиент возвращается как есть.
+// model подставляется в запись, если провайдер не вернул имя модели
+func WithRequestLog(client Client, model string, recorder storage.LLMRequestRecorder) Client {
+	if recorder == nil {
+		return client
+	}
+	return &LoggingClient{client: client, model: model, recorder: recorder}
+}
+
+func (c *LoggingClient) Generate(ctx context.Context, messages []Message) (Response, error) {
+	start := time.Now()
+	resp, err := c.client.Generate(ctx, messages)
+	c.record(start, messages, resp, err)
+	return resp, err
+}
+
+func (c *LoggingClient) GenerateWithTools(ctx context.Context, messages []Message, tools []Tool) (Response, error) {
+	start := time.Now()
+	resp, err := c.client.GenerateWithTools(ctx, messages, tools)
+	c.record(start, messages, resp, err)
+	return resp, err
+}
+
+func (c *LoggingClient) GenerateStream(ctx context.Context, messages []Message, onDelta StreamFunc) (Response, error) {
+	start := time.Now()
+	resp, err := c.client.GenerateStream(ctx, messages, onDelta)
+	// Провайдер без стриминга: запрос повторят через Generate, он и попадёт в журнал
+	if !errors.Is(err, ErrStreamingNotSupported) {
+		c.record(start, messages, resp, err)
+	}
+	return resp, err
+}
+
+func (c *LoggingClient) CountTokens(messages []Message) (int, error) {
+	return c.client.CountTokens(messages)
+}
+
+func (c *LoggingClient) record(start time.Time, messages []Message, resp Response, err error) {
+	entry := storage.LLMRequestLog{
+		Timestamp: start.UTC(),
+		Model:     resp.Model,
+		Messages:  make([]storage.LLMLogMessage, 0, len(messages)),
+		Response:  truncateRequestLog(resp.Content),
+		Tokens:    resp.TotalTokens,
+		Duration:  time.Since(start),
+	}
+	if entry.Model == "" {
+		entry.Model = c.model
+	}
+	for _, m := range messages {
+		entry.Messages = append(entry.Messages, storage.LLMLogMessage{Role: m.Role, Content: truncateRequestLog(m.Content)})
+	}
+	if entry.Response == "" && len(resp.ToolCalls) > 0 {
+		names := make([]string, 0, len(resp.ToolCalls))
+		for _, call := range resp.ToolCalls {
+			names = append(names, call.Function.Name)
+		}
+		entry.Response = truncateRequestLog("tool calls: " + strings.Join(names, ", "))
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if err := c.recorder.AppendLLMRequest(entry); err != nil {
+		log.Printf("⚠️ Failed to write LLM request log: %v", err)
+	}
+}
+
+func truncateRequestLog(s string) string {
+	if utf8.RuneCountInString(s) <= requestLogMaxContent {
+		return s
+	}
+	return string([]rune(s)[:requestLogMaxContent]) + "…"
+}
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"ai-chatter/internal/storage"
+)
+
+// memoryRequestLog журнал запросов в памяти
+type memoryRequestLog struct {
+	entries []storage.LLMRequestLog
+}
+
+func (m *memoryRequestLog) AppendLLMRequest(entry storage.LLMRequestLog) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *memoryRequestLog) RecentLLMRequests(limit int) ([]storage.LLMRequestLog, error) {
+	return m.entries, nil
+}
+
+// failingClient всегда возвращает ошибку
+type failingClient struct {
+	NoStreaming
+	LocalTokenCounter
+	err error
+}
+
+func (c failingClient) Generate(ctx context.Context, messages []Message) (Response, error) {
+	return Response{}, c.err
+}
+
+func (c failingClient) GenerateWithTools(ctx context.Context, messages []Message, tools []Tool) (Response, error) {
+	return Response{}, c.err
+}
+
+func TestLoggingClient_RecordsTruncatedRequests(t *testing.T) {
+	rec := &memoryRequestLog{}
+	long := strings.Repeat("я", 2000)
+	client := WithRequestLog(&scriptedClient{responses: []Response{{Content: long, Model: "served-model", TotalTokens: 42}}}, "configured", rec)
+
+	if _, err := client.Generate(context.Background(), []Message{{Role: "system", Content: "sys"}, {Role: "user", Content: long}}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(rec.entries) != 1 {
+		t.Fatalf("expected one log entry, got %d", len(rec.entries))
+	}
+	e := rec.entries[0]
+	if e.Model != "served-model" || e.Tokens != 42 || len(e.Messages) != 2 || e.Error != "" {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	if n := utf8.RuneCountInString(e.Messages[1].Content); n != requestLogMaxContent+1 {
+		t.Errorf("message content must be truncated to %d chars, got %d", requestLogMaxContent, n)
+	}
+	if n := utf8.RuneCountInString(e.Response); n != requestLogMaxContent+1 {
+		t.Errorf("response must be truncated to %d chars, got %d", requestLogMaxContent, n)
+	}
+
+	boom := errors.New("upstream 502")
+	client = WithRequestLog(failingClient{err: boom}, "configured", rec)
+	if _, err := client.GenerateStream(context.Background(), nil, func(string) {}); !errors.Is(err, ErrStreamingNotSupported) {
+		t.Fatalf("expected ErrStreamingNotSupported, got %v", err)
+	}
+	if _, err := client.Generate(context.Background(), nil); !errors.Is(err, boom) {
+		t.Fatalf("error must be passed through, got %v", err)
+	}
+	if len(rec.entries) != 2 {
+		t.Fatalf("unsupported streaming must not be logged, got %d entries", len(rec.entries))
+	}
+	if e := rec.entries[1]; e.Error != "upstream 502" || e.Model != "configured" {
+		t.Errorf("failed request must be logged with the configured model: %+v", e)
+	}
+}
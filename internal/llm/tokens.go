@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// tokensPerMessage служебные токены разметки сообщения в формате chat (cl100k_base)
+	tokensPerMessage = 3
+	// tokensPerReply служебные токены начала ответа ассистента
+	tokensPerReply = 3
+	// defaultContextWindow окно контекста неизвестной модели
+	defaultContextWindow = 128000
+)
+
+// cl100kPretokenize разбиение текста перед BPE в cl100k_base (без lookahead, которого нет в RE2)
+var cl100kPretokenize = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+// LocalTokenCounter реализация CountTokens по умолчанию: локальная оценка EstimateTokens;
+// встраивается в клиент провайдера без API подсчёта токенов
+type LocalTokenCounter struct{}
+
+func (LocalTokenCounter) CountTokens(messages []Message) (int, error) {
+	return EstimateTokens(messages), nil
+}
+
+// EstimateTokens эвристическая оценка размера промпта в токенах cl100k_base: текст разбивается так же,
+// как перед BPE, а каждый фрагмент оценивается по длине — английское слово обычно один токен,
+// кириллица и редкие символы дробятся мельче. Словаря BPE нет, поэтому это приближение, а не точный подсчёт
+func EstimateTokens(messages []Message) int {
+	total := tokensPerReply
+	for _, m := range messages {
+		total += tokensPerMessage + estimateTextTokens(m.Role) + estimateTextTokens(m.Content)
+		for _, call := range m.ToolCalls {
+			total += estimateTextTokens(call.Function.Name) + 8*len(call.Function.Arguments)
+		}
+	}
+	return total
+}
+
+func estimateTextTokens(text string) int {
+	tokens := 0
+	for _, piece := range cl100kPretokenize.FindAllString(text, -1) {
+		tokens += estimatePieceTokens(piece)
+	}
+	return tokens
+}
+
+func estimatePieceTokens(piece string) int {
+	if strings.TrimSpace(piece) == "" {
+		return 1
+	}
+	runes := utf8.RuneCountInString(piece)
+	if runes == len(piece) {
+		// ASCII: слова до 6 символов с ведущим пробелом — один токен, длиннее делятся на части
+		return int(math.Ceil(float64(runes) / 6))
+	}
+	// Кириллица и прочий не-ASCII: в среднем два символа на токен
+	return int(math.Ceil(float64(runes) / 2))
+}
+
+// contextWindows окна контекста известных моделей по префиксу имени (без провайдера OpenRouter)
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-3.5-turbo", 16385},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4.1", 1047576},
+	{"gpt-4", 8192},
+	{"gpt-5", 400000},
+	{"claude", 200000},
+	{"qwen3-coder", 262144},
+	{"gemini", 1048576},
+	{"yandexgpt", 32000},
+}
+
+// ContextWindow окно контекста модели в токенах; для неизвестной модели — 128k
+func ContextWindow(model string) int {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, w := range contextWindows {
+		if strings.HasPrefix(name, w.prefix) {
+			return w.tokens
+		}
+	}
+	return defaultContextWindow
+}
@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	// cl100k_base: 9 токенов текста + 3 на сообщение + 3 на начало ответа + 1 на роль
+	msgs := []Message{{Role: "user", Content: "The quick brown fox jumps over the lazy dog."}}
+	if got := EstimateTokens(msgs); got < 14 || got > 20 {
+		t.Errorf("English sentence estimate is off: %d", got)
+	}
+	ru := EstimateTokens([]Message{{Role: "user", Content: strings.Repeat("привет ", 100)}})
+	en := EstimateTokens([]Message{{Role: "user", Content: strings.Repeat("hello ", 100)}})
+	if ru <= en {
+		t.Errorf("Cyrillic must cost more tokens than English: ru=%d en=%d", ru, en)
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	cases := map[string]int{
+		"gpt-4":                 8192,
+		"openai/gpt-4o-mini":    128000,
+		"qwen/qwen3-coder":      262144,
+		"claude-3-5-sonnet":     200000,
+		"some/unknown-model-7b": defaultContextWindow,
+	}
+	for model, want := range cases {
+		if got := ContextWindow(model); got != want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
+
+func TestOpenAICountTokens_TokenizeEndpointWithFallback(t *testing.T) {
+	available := true
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/tokenize" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request %s %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		if !available {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"count": 42}`))
+	}))
+	defer srv.Close()
+
+	c := NewOpenAI("key", srv.URL, "qwen/qwen3-coder", "", "")
+	c.tokenizeURL = srv.URL + "/tokenize"
+	msgs := []Message{{Role: "user", Content: "hi"}}
+	if n, err := c.CountTokens(msgs); err != nil || n != 42 {
+		t.Fatalf("tokenize count expected, got %d, %v", n, err)
+	}
+
+	available = false
+	for i := 0; i < 2; i++ {
+		if n, err := c.CountTokens(msgs); err != nil || n != EstimateTokens(msgs) {
+			t.Fatalf("missing endpoint must fall back to the local estimate, got %d, %v", n, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("unavailable endpoint must not be requested again, got %d calls", calls)
+	}
+
+	if NewOpenAI("key", "https://api.openai.com/v1", "gpt-4o", "", "").tokenizeURL != "" {
+		t.Error("tokenize endpoint is used only for OpenRouter")
+	}
+}
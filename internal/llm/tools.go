@@ -76,7 +76,15 @@ func GetNotionTools() []Tool {
 						},
 						"exact_match": map[string]interface{}{
 							"type":        "boolean",
-							"description": "Если true, искать только точные совпадения названия (по умолчанию false)",
+							"description": "Если true, искать только точные совпадения названия (по умолчанию false). Важнее contains",
+						},
+						"contains": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Если true, искать страницы, в названии которых есть запрос (по умолчанию false)",
+						},
+						"case_insensitive": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Если true, exact_match и contains сравнивают названия без учёта регистра",
 						},
 						"limit": map[string]interface{}{
 							"type":        "integer",
@@ -0,0 +1,139 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"ai-chatter/internal/llm/jsonextract"
+)
+
+// Запасной вариант function calling для провайдеров без нативной поддержки: инструменты
+// описываются в системной инструкции, а модель отвечает JSON объектом {"tool_calls": [...]}
+
+// generateFunc обычная генерация без инструментов
+type generateFunc func(ctx context.Context, messages []Message) (Response, error)
+
+// generateWithPromptTools описывает инструменты в промпте и разбирает вызовы из текста ответа.
+// Если ответ не содержит вызовов известных инструментов, он возвращается как обычный текст
+func generateWithPromptTools(ctx context.Context, generate generateFunc, messages []Message, tools []Tool) (Response, error) {
+	if len(tools) == 0 {
+		return generate(ctx, messages)
+	}
+	resp, err := generate(ctx, withPromptTools(messages, tools))
+	if err != nil {
+		return Response{}, err
+	}
+	if calls, ok := parsePromptToolCalls(resp.Content, tools); ok {
+		resp.Content = ""
+		resp.ToolCalls = calls
+	}
+	return resp, nil
+}
+
+// withPromptTools добавляет инструкцию с описанием инструментов, а вызовы инструментов
+// и их результаты из истории переводит в текст (роль tool такие провайдеры не знают)
+func withPromptTools(messages []Message, tools []Tool) []Message {
+	out := []Message{{Role: "system", Content: promptToolsInstruction(tools)}}
+	names := make(map[string]string)
+	for _, m := range messages {
+		switch {
+		case m.Role == "assistant" && len(m.ToolCalls) > 0:
+			for _, tc := range m.ToolCalls {
+				names[tc.ID] = tc.Function.Name
+			}
+			out = append(out, Message{Role: "assistant", Content: strings.TrimSpace(m.Content + "\n\n" + promptToolCallsText(m.ToolCalls))})
+		case m.Role == "tool":
+			name := names[m.ToolCallID]
+			if name == "" {
+				name = m.ToolCallID
+			}
+			out = append(out, Message{Role: "user", Content: fmt.Sprintf("Результат вызова инструмента %s:\n%s", name, m.Content)})
+		default:
+			out = append(out, Message{Role: m.Role, Content: m.Content})
+		}
+	}
+	return out
+}
+
+// promptToolsInstruction системная инструкция со списком инструментов и форматом вызова
+func promptToolsInstruction(tools []Tool) string {
+	var sb strings.Builder
+	sb.WriteString("Тебе доступны инструменты. Чтобы вызвать их, ответь ТОЛЬКО JSON объектом без пояснений:\n")
+	sb.WriteString(`{"tool_calls": [{"name": "<имя инструмента>", "arguments": {<аргументы по схеме>}}]}`)
+	sb.WriteString("\nЕсли инструменты не нужны, ответь обычным текстом.\n\nИнструменты:\n")
+	for _, t := range tools {
+		params, err := json.Marshal(t.Function.Parameters)
+		if err != nil || len(t.Function.Parameters) == 0 {
+			params = []byte("{}")
+		}
+		fmt.Fprintf(&sb, "- %s: %s\n  Параметры (JSON schema): %s\n", t.Function.Name, t.Function.Description, params)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// promptToolCallsText вызовы инструментов в том же JSON формате, который ожидается от модели
+func promptToolCallsText(calls []ToolCall) string {
+	type call struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	payload := struct {
+		ToolCalls []call `json:"tool_calls"`
+	}{}
+	for _, tc := range calls {
+		payload.ToolCalls = append(payload.ToolCalls, call{Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+	}
+	data, _ := json.Marshal(payload)
+	return string(data)
+}
+
+// parsePromptToolCalls извлекает вызовы инструментов из ответа модели. Допускает обёртку
+// в markdown блок и аргументы, переданные строкой с JSON (как в OpenAI). Вызовы неизвестных
+// инструментов отбрасываются; ok=false, если не осталось ни одного
+func parsePromptToolCalls(content string, tools []Tool) ([]ToolCall, bool) {
+	raw, err := jsonextract.Extract(content)
+	if err != nil {
+		return nil, false
+	}
+	var payload struct {
+		ToolCalls []struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		} `json:"tool_calls"`
+	}
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		return nil, false
+	}
+
+	known := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		known[t.Function.Name] = true
+	}
+	var calls []ToolCall
+	for _, c := range payload.ToolCalls {
+		if !known[c.Name] {
+			continue
+		}
+		calls = append(calls, ToolCall{
+			ID:       fmt.Sprintf("call_%d", len(calls)+1),
+			Type:     "function",
+			Function: FunctionCall{Name: c.Name, Arguments: decodePromptArguments(c.Arguments)},
+		})
+	}
+	return calls, len(calls) > 0
+}
+
+// decodePromptArguments аргументы объектом или строкой с JSON объектом
+func decodePromptArguments(raw json.RawMessage) map[string]interface{} {
+	var args map[string]interface{}
+	if err := json.Unmarshal(raw, &args); err == nil && args != nil {
+		return args
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return parseJSONArgs(s)
+	}
+	return make(map[string]interface{})
+}
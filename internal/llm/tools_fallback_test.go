@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+var fallbackTools = []Tool{
+	{Type: "function", Function: Function{Name: "search_notion", Description: "Search", Parameters: map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"query": map[string]interface{}{"type": "string"}},
+	}}},
+	{Type: "function", Function: Function{Name: "list_available_pages", Description: "List pages"}},
+}
+
+func TestParsePromptToolCalls(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"plain json", `{"tool_calls": [{"name": "search_notion", "arguments": {"query": "go"}}]}`, []string{"search_notion"}},
+		{"markdown fence", "Сейчас поищу.\n```json\n{\"tool_calls\": [{\"name\": \"search_notion\", \"arguments\": {\"query\": \"go\"}}]}\n```", []string{"search_notion"}},
+		{"string arguments", `{"tool_calls": [{"name": "search_notion", "arguments": "{\"query\": \"go\"}"}]}`, []string{"search_notion"}},
+		{"unknown tool dropped", `{"tool_calls": [{"name": "rm_rf", "arguments": {}}, {"name": "list_available_pages"}]}`, []string{"list_available_pages"}},
+		{"plain text", "Ответ без инструментов", nil},
+		{"json without calls", `{"answer": 42}`, nil},
+		{"only unknown tools", `{"tool_calls": [{"name": "rm_rf"}]}`, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls, ok := parsePromptToolCalls(tc.content, fallbackTools)
+			if ok != (len(tc.want) > 0) || len(calls) != len(tc.want) {
+				t.Fatalf("got %+v ok=%v, want %v", calls, ok, tc.want)
+			}
+			for i, name := range tc.want {
+				if calls[i].Function.Name != name || calls[i].ID == "" || calls[i].Type != "function" {
+					t.Errorf("call %d = %+v", i, calls[i])
+				}
+				if calls[i].Function.Arguments == nil {
+					t.Errorf("call %d: arguments must not be nil", i)
+				}
+			}
+			if len(calls) > 0 && calls[0].Function.Name == "search_notion" && calls[0].Function.Arguments["query"] != "go" {
+				t.Errorf("arguments = %v", calls[0].Function.Arguments)
+			}
+		})
+	}
+}
+
+func TestGenerateWithPromptTools_RoundTrip(t *testing.T) {
+	var sent [][]Message
+	replies := []string{
+		"```json\n{\"tool_calls\": [{\"name\": \"search_notion\", \"arguments\": {\"query\": \"go\"}}]}\n```",
+		"Нашёл две страницы про Go",
+	}
+	generate := func(ctx context.Context, messages []Message) (Response, error) {
+		sent = append(sent, messages)
+		return Response{Content: replies[len(sent)-1]}, nil
+	}
+
+	history := []Message{{Role: "user", Content: "найди заметки про go"}}
+	resp, err := generateWithPromptTools(context.Background(), generate, history, fallbackTools)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if resp.Content != "" || len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Function.Arguments["query"] != "go" {
+		t.Fatalf("expected structured tool call, got %+v", resp)
+	}
+	instruction := sent[0][0]
+	if instruction.Role != "system" || !strings.Contains(instruction.Content, "search_notion") || !strings.Contains(instruction.Content, `"query"`) {
+		t.Errorf("tools instruction missing: %+v", instruction)
+	}
+
+	history = append(history,
+		Message{Role: "assistant", ToolCalls: resp.ToolCalls},
+		Message{Role: "tool", ToolCallID: resp.ToolCalls[0].ID, Content: "Go notes, Go tips"},
+	)
+	resp, err = generateWithPromptTools(context.Background(), generate, history, fallbackTools)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if resp.Content != "Нашёл две страницы про Go" || len(resp.ToolCalls) != 0 {
+		t.Errorf("expected text answer, got %+v", resp)
+	}
+	for _, m := range sent[1] {
+		if m.Role == "tool" || len(m.ToolCalls) > 0 {
+			t.Errorf("tool messages must be rendered as text: %+v", m)
+		}
+	}
+	last := sent[1][len(sent[1])-1]
+	if last.Role != "user" || !strings.Contains(last.Content, "search_notion") || !strings.Contains(last.Content, "Go notes") {
+		t.Errorf("tool result = %+v", last)
+	}
+	if call := sent[1][2]; call.Role != "assistant" || !strings.Contains(call.Content, `"tool_calls"`) {
+		t.Errorf("assistant tool call = %+v", call)
+	}
+}
+
+func TestGenerateWithPromptTools_NoTools(t *testing.T) {
+	var got []Message
+	generate := func(ctx context.Context, messages []Message) (Response, error) {
+		got = messages
+		return Response{Content: `{"tool_calls": [{"name": "search_notion"}]}`}, nil
+	}
+	resp, err := generateWithPromptTools(context.Background(), generate, []Message{{Role: "user", Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if len(got) != 1 || len(resp.ToolCalls) != 0 {
+		t.Errorf("without tools the request and reply must pass through unchanged: %+v, %+v", got, resp)
+	}
+}
@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// DefaultTranscriptionModel модель распознавания речи по умолчанию
+	DefaultTranscriptionModel = openai.Whisper1
+	// DefaultTranscriptionBaseURL Whisper доступен только в OpenAI API, а OPENAI_BASE_URL часто указывает на OpenRouter
+	DefaultTranscriptionBaseURL = "https://api.openai.com/v1"
+)
+
+// Transcriber распознаёт речь в аудиофайле. filename нужен API для определения формата (voice.oga, audio.mp3)
+type Transcriber interface {
+	Transcribe(ctx context.Context, filename string, audio io.Reader) (string, error)
+}
+
+// OpenAITranscriber распознавание через OpenAI Whisper API
+type OpenAITranscriber struct {
+	client *openai.Client
+	model  string
+}
+
+func (t *OpenAITranscriber) Transcribe(ctx context.Context, filename string, audio io.Reader) (string, error) {
+	resp, err := t.client.CreateTranscription(ctx, openai.AudioRequest{
+		Model:    t.model,
+		FilePath: filename,
+		Reader:   audio,
+		Format:   openai.AudioResponseFormatJSON,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to transcribe audio: %w", err)
+	}
+	return strings.TrimSpace(resp.Text), nil
+}
+
+// CreateTranscriber создаёт клиент распознавания речи с ключом OpenAI; пустой baseURL — DefaultTranscriptionBaseURL
+func (f *Factory) CreateTranscriber(model, baseURL string) (Transcriber, error) {
+	if f.OpenaiAPIKey == "" {
+		return nil, fmt.Errorf("transcription requires OPENAI_API_KEY")
+	}
+	if model == "" {
+		model = DefaultTranscriptionModel
+	}
+	if baseURL == "" {
+		baseURL = DefaultTranscriptionBaseURL
+	}
+	c := NewOpenAI(f.OpenaiAPIKey, baseURL, model, "", "")
+	return &OpenAITranscriber{client: c.client, model: model}, nil
+}
@@ -8,6 +8,8 @@ import (
 )
 
 type YandexClient struct {
+	NoStreaming
+	LocalTokenCounter
 	ya       yagpt.YaGPTFace
 	iamToken string
 }
@@ -39,9 +41,13 @@ func (c *YandexClient) Generate(ctx context.Context, messages []Message) (Respon
 	return c.GenerateWithTools(ctx, messages, nil)
 }
 
+// GenerateWithTools YandexGPT не поддерживает function calling, поэтому инструменты описываются в промпте
 func (c *YandexClient) GenerateWithTools(ctx context.Context, messages []Message, tools []Tool) (Response, error) {
-	// YandexGPT пока не поддерживает function calling
-	// Игнорируем tools и делаем обычный запрос
+	return generateWithPromptTools(ctx, c.complete, messages, tools)
+}
+
+// complete обычный запрос; GenerateOptions не применяются — yagpt задаёт температуру сам
+func (c *YandexClient) complete(ctx context.Context, messages []Message) (Response, error) {
 	var yaMsgs []yagpt.Message
 	for _, m := range messages {
 		yaMsgs = append(yaMsgs, yagpt.Message{Role: m.Role, Content: m.Content})
@@ -55,10 +61,11 @@ func (c *YandexClient) GenerateWithTools(ctx context.Context, messages []Message
 		return Response{}, fmt.Errorf("yagpt returned empty response")
 	}
 	out := Response{Content: resp.Alternatives[0].Message.Content, Model: yagpt.YaModelLite}
+	if resp.Alternatives[0].Status.String() == "ALTERNATIVE_STATUS_CONTENT_FILTER" {
+		out.FinishReason = FinishReasonContentFilter
+	}
 	out.PromptTokens = int(resp.Usage.InputTextTokens)
 	out.CompletionTokens = int(resp.Usage.CompletionTokens)
 	out.TotalTokens = int(resp.Usage.TotalTokens)
-	// YandexGPT не поддерживает tool calls
-	out.ToolCalls = nil
 	return out, nil
 }
@@ -0,0 +1,228 @@
+// Package messages настраиваемые тексты бота для пользователей: приветствие, отказ в доступе,
+// заявка на рассмотрении, исчерпанный лимит и техническое обслуживание. Тексты — шаблоны
+// text/template из файла MESSAGES_FILE_PATH с переводами по языку пользователя
+package messages
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Ключи сообщений в файле
+const (
+	Welcome        = "welcome"
+	AccessDenied   = "access_denied"
+	PendingRequest = "pending_request"
+	QuotaExceeded  = "quota_exceeded"
+	Maintenance    = "maintenance"
+)
+
+// Data переменные шаблонов
+type Data struct {
+	Name         string // имя пользователя в Telegram (или username)
+	AdminContact string // контакт администратора из ADMIN_CONTACT
+	ResetTime    int    // через сколько секунд можно писать снова (quota_exceeded)
+	Message      string // пояснение администратора из /maintenance on (maintenance)
+	ETA          string // ожидаемое окончание обслуживания, пусто — не указано (maintenance)
+}
+
+// defaults встроенные тексты: key для языка по умолчанию, key.lang — перевод
+var defaults = map[string]string{
+	Welcome:                "Привет! Я LLM-бот. Отвечаю на вопросы с учётом контекста. Под каждым ответом есть кнопки: ‘История’ (саммари диалога) и ‘Сбросить контекст’.",
+	Welcome + ".en":        "Hi! I am an LLM bot. I answer questions keeping the conversation context. Every answer has buttons: ‘History’ (dialog summary) and ‘Reset context’.",
+	AccessDenied:           "Запрос на доступ отправлен администратору. Как только он подтвердит, вы получите уведомление.",
+	AccessDenied + ".en":   "Your access request has been sent to the administrator. You will be notified once it is approved.",
+	PendingRequest:         "Ваш запрос на доступ уже отправлен администратору. Как только он подтвердит, вы получите уведомление.",
+	PendingRequest + ".en": "Your access request is already with the administrator. You will be notified once it is approved.",
+	QuotaExceeded:          "⏳ Слишком много сообщений подряд. Следующее можно отправить через {{.ResetTime}} сек.",
+	QuotaExceeded + ".en":  "⏳ Too many messages in a row. You can send the next one in {{.ResetTime}} s.",
+	Maintenance:            "🛠 Бот на техническом обслуживании{{if .ETA}} примерно до {{.ETA}}{{end}}, попробуйте позже.{{if .Message}} {{.Message}}{{end}}{{if .AdminContact}} Вопросы: {{.AdminContact}}{{end}}",
+	Maintenance + ".en":    "🛠 The bot is under maintenance{{if .ETA}} until about {{.ETA}}{{end}}, please try again later.{{if .Message}} {{.Message}}{{end}}{{if .AdminContact}} Contact: {{.AdminContact}}{{end}}",
+}
+
+// keyPattern ключ сообщения с необязательным кодом языка: welcome, welcome.en
+var keyPattern = regexp.MustCompile(`^([a-z_]+)(\.[a-z]{2})?$`)
+
+// sampleData данные для пробного выполнения шаблонов при загрузке: ошибки в именах
+// переменных ловятся до отправки
+var sampleData = Data{Name: "Имя", AdminContact: "@admin", ResetTime: 60, Message: "Обновление", ETA: "18:00 UTC"}
+
+// Set набор шаблонов сообщений
+type Set struct {
+	templates map[string]*template.Template
+}
+
+var (
+	defaultOnce sync.Once
+	defaultSet  *Set
+)
+
+// Default встроенные тексты
+func Default() *Set {
+	defaultOnce.Do(func() {
+		defaultSet = &Set{templates: make(map[string]*template.Template, len(defaults))}
+		for key, text := range defaults {
+			defaultSet.templates[key] = template.Must(newTemplate(key).Parse(text))
+		}
+	})
+	return defaultSet
+}
+
+// Parse читает файл сообщений: строки `key = шаблон` или `key.lang = шаблон`, # — комментарий,
+// \n в шаблоне — перевод строки. Ошибка указывает имя файла и номер строки
+func Parse(r io.Reader, name string) (*Set, error) {
+	set := &Set{templates: make(map[string]*template.Template)}
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected `key = template`", name, line)
+		}
+		key = strings.TrimSpace(key)
+		m := keyPattern.FindStringSubmatch(key)
+		if m == nil {
+			return nil, fmt.Errorf("%s:%d: invalid key %q", name, line, key)
+		}
+		if _, known := defaults[m[1]]; !known {
+			return nil, fmt.Errorf("%s:%d: unknown message %q (expected one of %s)", name, line, m[1], strings.Join(Keys(), ", "))
+		}
+		if _, dup := set.templates[key]; dup {
+			return nil, fmt.Errorf("%s:%d: duplicate message %q", name, line, key)
+		}
+		value = strings.ReplaceAll(strings.TrimSpace(value), `\n`, "\n")
+		tmpl, err := newTemplate(key).Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+		if err := tmpl.Execute(io.Discard, sampleData); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+		set.templates[key] = tmpl
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return set, nil
+}
+
+// LoadFile читает и проверяет файл сообщений
+func LoadFile(path string) (*Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f, path)
+}
+
+// Keys ключи сообщений, которые можно переопределить
+func Keys() []string {
+	var keys []string
+	for key := range defaults {
+		if !strings.Contains(key, ".") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lookup шаблон для языка: key.lang, затем key
+func (s *Set) lookup(key, lang string) *template.Template {
+	if s == nil {
+		return nil
+	}
+	if lang != "" {
+		if t, ok := s.templates[key+"."+lang]; ok {
+			return t
+		}
+	}
+	return s.templates[key]
+}
+
+// Render текст сообщения на языке пользователя; не заданные в наборе ключи берутся из встроенных текстов
+func (s *Set) Render(key, lang string, data Data) string {
+	for _, set := range []*Set{s, Default()} {
+		t := set.lookup(key, lang)
+		if t == nil {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			log.Printf("⚠️ messages: failed to render %s: %v", t.Name(), err)
+			continue
+		}
+		return buf.String()
+	}
+	return ""
+}
+
+func newTemplate(name string) *template.Template {
+	return template.New(name).Option("missingkey=error")
+}
+
+// File набор сообщений из файла: перечитывается, когда меняется время изменения файла.
+// Ошибка в файле при перезагрузке оставляет прежние тексты
+type File struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	set     *Set
+}
+
+// Open загружает файл сообщений; ошибка шаблона возвращается сразу, чтобы бот не запустился с ней
+func Open(path string) (*File, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	set, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &File{path: path, modTime: info.ModTime(), set: set}, nil
+}
+
+// Render как Set.Render, но сначала перечитывает изменившийся файл; nil — встроенные тексты
+func (f *File) Render(key, lang string, data Data) string {
+	if f == nil {
+		return Default().Render(key, lang, data)
+	}
+	return f.current().Render(key, lang, data)
+}
+
+func (f *File) current() *Set {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info, err := os.Stat(f.path)
+	if err != nil || info.ModTime().Equal(f.modTime) {
+		return f.set
+	}
+	// Запоминаем время изменения и при ошибке, чтобы не разбирать файл на каждом сообщении
+	f.modTime = info.ModTime()
+	set, err := LoadFile(f.path)
+	if err != nil {
+		log.Printf("⚠️ messages: keeping previous texts, %v", err)
+		return f.set
+	}
+	log.Printf("🔄 messages: reloaded %s", f.path)
+	f.set = set
+	return f.set
+}
@@ -0,0 +1,83 @@
+package messages
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse_OverridesAndTranslations(t *testing.T) {
+	set, err := Parse(strings.NewReader(`
+# приветствие
+welcome = Здравствуйте, {{.Name}}!\nПишите вопрос.
+welcome.en = Hello, {{.Name}}!
+`), "messages.txt")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	data := Data{Name: "Анна", AdminContact: "@boss", ResetTime: 15}
+	if got := set.Render(Welcome, "", data); got != "Здравствуйте, Анна!\nПишите вопрос." {
+		t.Errorf("welcome = %q", got)
+	}
+	if got := set.Render(Welcome, "en", data); got != "Hello, Анна!" {
+		t.Errorf("welcome.en = %q", got)
+	}
+	if got := set.Render(Welcome, "de", data); !strings.HasPrefix(got, "Здравствуйте") {
+		t.Errorf("unknown language must fall back to the default text, got %q", got)
+	}
+	if got := set.Render(QuotaExceeded, "", data); !strings.Contains(got, "через 15 сек.") {
+		t.Errorf("keys missing in the file must use built-in texts, got %q", got)
+	}
+	if got := set.Render(Maintenance, "en", data); !strings.Contains(got, "Contact: @boss") {
+		t.Errorf("built-in translation must be used, got %q", got)
+	}
+}
+
+func TestParse_ReportsOffendingLine(t *testing.T) {
+	for _, tc := range []struct{ name, file, want string }{
+		{"syntax", "# ok\nwelcome = Привет\naccess_denied = {{.Name\n", "m.txt:3:"},
+		{"unknown field", "\n\nquota_exceeded = {{.ResetTme}}", "m.txt:3:"},
+		{"unknown key", "greeting = hi", `m.txt:1: unknown message "greeting"`},
+		{"no separator", "welcome", "m.txt:1: expected"},
+		{"duplicate", "welcome = a\nwelcome = b", `m.txt:2: duplicate message "welcome"`},
+	} {
+		_, err := Parse(strings.NewReader(tc.file), "m.txt")
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: error %v must contain %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestFile_HotReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.txt")
+	if err := os.WriteFile(path, []byte("welcome = v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	touch := func(content string, at time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	touch("welcome = v2", time.Now().Add(time.Minute))
+	if got := f.Render(Welcome, "", Data{}); got != "v2" {
+		t.Fatalf("changed file must be reloaded, got %q", got)
+	}
+	touch("welcome = {{.Broken", time.Now().Add(2*time.Minute))
+	if got := f.Render(Welcome, "", Data{}); got != "v2" {
+		t.Errorf("invalid file must keep previous texts, got %q", got)
+	}
+	if got := (*File)(nil).Render(Welcome, "", Data{}); !strings.HasPrefix(got, "Привет!") {
+		t.Errorf("nil file must render built-in texts, got %q", got)
+	}
+}
@@ -0,0 +1,94 @@
+package notion
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrorCategory категория ошибки MCP вызова: по ней бот решает, повторить запрос,
+// сдаться или попросить пользователя исправить данные
+type ErrorCategory string
+
+const (
+	// ErrorAuth токен Notion недействителен или у интеграции нет доступа к странице
+	ErrorAuth ErrorCategory = "auth"
+	// ErrorNotFound страница не найдена (или не расшарена интеграции)
+	ErrorNotFound ErrorCategory = "not_found"
+	// ErrorRateLimited Notion API ограничил частоту запросов
+	ErrorRateLimited ErrorCategory = "rate_limited"
+	// ErrorValidation неверные аргументы или операция требует явного согласия пользователя
+	ErrorValidation ErrorCategory = "validation"
+	// ErrorTransport нет соединения с MCP сервером, таймаут или 5xx от Notion
+	ErrorTransport ErrorCategory = "transport"
+	// ErrorUnknown инструмент вернул ошибку, которую не удалось классифицировать
+	ErrorUnknown ErrorCategory = "unknown"
+)
+
+// MCPError ошибка вызова инструмента Notion MCP сервера
+type MCPError struct {
+	Category ErrorCategory
+	// Tool имя инструмента MCP сервера
+	Tool string
+	// Message текст ошибки для пользователя (как его вернул сервер)
+	Message string
+	// Err исходная ошибка транспорта, если есть
+	Err error
+}
+
+func (e *MCPError) Error() string {
+	return e.Message
+}
+
+func (e *MCPError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable имеет ли смысл повторить вызов позже без изменений
+func (e *MCPError) Retryable() bool {
+	return e.Category == ErrorRateLimited || e.Category == ErrorTransport
+}
+
+// CategoryOf категория ошибки MCP вызова; пустая строка для nil, ErrorUnknown для чужих ошибок
+func CategoryOf(err error) ErrorCategory {
+	if err == nil {
+		return ""
+	}
+	var mcpErr *MCPError
+	if errors.As(err, &mcpErr) {
+		return mcpErr.Category
+	}
+	return ErrorUnknown
+}
+
+// IsRetryable удобная проверка Retryable для произвольной ошибки
+func IsRetryable(err error) bool {
+	var mcpErr *MCPError
+	return errors.As(err, &mcpErr) && mcpErr.Retryable()
+}
+
+// toolErrorCategories признаки категорий в текстах ошибок сервера (cmd/notion-mcp-server);
+// сервер передаёт только текст, поэтому категория определяется на стороне клиента
+var toolErrorCategories = []struct {
+	category ErrorCategory
+	markers  []string
+}{
+	// Отказ move_page без allow_copy_fallback — нужно согласие пользователя, а не повтор
+	{ErrorValidation, []string{"allow_copy_fallback", "is required", "validation_error", "notion api error 400"}},
+	{ErrorAuth, []string{"notion api error 401", "notion api error 403", "unauthorized", "restricted_resource"}},
+	{ErrorNotFound, []string{"notion api error 404", "object_not_found", "no results in response"}},
+	{ErrorRateLimited, []string{"notion api error 429", "rate_limited"}},
+	{ErrorTransport, []string{"notion api error 5", "request failed", "timeout", "deadline exceeded", "connection"}},
+}
+
+// classifyToolError категория ошибки по тексту, который вернул инструмент
+func classifyToolError(text string) ErrorCategory {
+	lower := strings.ToLower(text)
+	for _, c := range toolErrorCategories {
+		for _, marker := range c.markers {
+			if strings.Contains(lower, marker) {
+				return c.category
+			}
+		}
+	}
+	return ErrorUnknown
+}
@@ -64,180 +64,96 @@ func (m *MCPClient) Close() error {
 }
 
 // CreateDialogSummary создает страницу с сохранением диалога через кастомный MCP
-func (m *MCPClient) CreateDialogSummary(ctx context.Context, title, content, userID, username, dialogType, parentPageID string) MCPResult {
-	if m.session == nil {
-		return MCPResult{Success: false, Message: "MCP session not connected"}
-	}
-
-	log.Printf("📝 Creating Notion page via custom MCP: %s", title)
-
+func (m *MCPClient) CreateDialogSummary(ctx context.Context, title, content, userID, username, dialogType, parentPageID string) (MCPResult, error) {
+	const tool = "save_dialog_to_notion"
 	// Проверяем обязательный parent_page_id
 	if parentPageID == "" {
-		return MCPResult{Success: false, Message: "parent_page_id is required - get it from your Notion workspace"}
+		return MCPResult{}, errParentRequired(tool)
 	}
 
-	// Вызываем инструмент save_dialog_to_notion
-	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
-		Name: "save_dialog_to_notion",
-		Arguments: map[string]any{
-			"title":          title,
-			"content":        content,
-			"user_id":        userID,
-			"username":       username,
-			"dialog_type":    dialogType,
-			"parent_page_id": parentPageID,
-		},
-	})
+	log.Printf("📝 Creating Notion page via custom MCP: %s", title)
 
+	result, text, err := m.callTool(ctx, tool, map[string]any{
+		"title":          title,
+		"content":        content,
+		"user_id":        userID,
+		"username":       username,
+		"dialog_type":    dialogType,
+		"parent_page_id": parentPageID,
+	})
 	if err != nil {
-		log.Printf("❌ MCP save_dialog error: %v", err)
-		return MCPResult{Success: false, Message: fmt.Sprintf("MCP error: %v", err)}
-	}
-
-	if result.IsError {
-		return MCPResult{Success: false, Message: "Tool returned error"}
-	}
-
-	// Извлекаем текст из результата
-	var responseText string
-	for _, content := range result.Content {
-		if textContent, ok := content.(*mcp.TextContent); ok {
-			responseText += textContent.Text
-		}
-	}
-
-	var pageID string
-	if result.Meta != nil {
-		if id, ok := result.Meta["page_id"].(string); ok {
-			pageID = id
-		}
+		return MCPResult{}, err
 	}
 
 	return MCPResult{
-		Success: true,
-		Message: responseText,
-		PageID:  pageID,
+		Message: text,
+		Page:    pageFromMeta(result.Meta, "", title),
 		Data:    formatResultMeta(result.Meta),
-	}
+	}, nil
 }
 
 // SearchDialogSummaries ищет сохраненные диалоги через кастомный MCP
-func (m *MCPClient) SearchDialogSummaries(ctx context.Context, query, userID, dialogType string) MCPResult {
-	if m.session == nil {
-		return MCPResult{Success: false, Message: "MCP session not connected"}
-	}
-
+func (m *MCPClient) SearchDialogSummaries(ctx context.Context, query, userID, dialogType string) (MCPResult, error) {
 	log.Printf("🔍 Searching Notion via custom MCP: query='%s'", query)
 
-	// Вызываем инструмент search_pages
-	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
-		Name: "search_pages",
-		Arguments: map[string]any{
-			"query": query,
-			"filter": map[string]any{
-				"property": "Type",
-				"select": map[string]any{
-					"equals": "Dialog",
-				},
+	result, text, err := m.callTool(ctx, "search_pages", map[string]any{
+		"query": query,
+		"filter": map[string]any{
+			"property": "Type",
+			"select": map[string]any{
+				"equals": "Dialog",
 			},
-			"page_size": 20,
 		},
+		"page_size": 20,
 	})
-
 	if err != nil {
-		log.Printf("❌ MCP search error: %v", err)
-		return MCPResult{Success: false, Message: fmt.Sprintf("MCP search error: %v", err)}
-	}
-
-	if result.IsError {
-		return MCPResult{Success: false, Message: "Tool returned error"}
-	}
-
-	// Извлекаем текст из результата
-	var responseText string
-	for _, content := range result.Content {
-		if textContent, ok := content.(*mcp.TextContent); ok {
-			responseText += textContent.Text
-		}
+		return MCPResult{}, err
 	}
 
 	return MCPResult{
-		Success: true,
-		Message: responseText,
+		Message: text,
 		Data:    formatResultMeta(result.Meta),
-	}
+	}, nil
 }
 
 // CreateFreeFormPage создает произвольную страницу через кастомный MCP
-func (m *MCPClient) CreateFreeFormPage(ctx context.Context, title, content, parentPageId string, tags []string) MCPResult {
-	if m.session == nil {
-		return MCPResult{Success: false, Message: "MCP session not connected"}
+func (m *MCPClient) CreateFreeFormPage(ctx context.Context, title, content, parentPageId string, tags []string) (MCPResult, error) {
+	const tool = "create_page"
+	if parentPageId == "" {
+		return MCPResult{}, errParentRequired(tool)
 	}
 
 	log.Printf("📄 Creating free-form page via custom MCP: %s", title)
 
-	// Вызываем инструмент create_page
-	args := map[string]any{
-		"title":   title,
-		"content": content,
-		"properties": map[string]any{
-			"Type":    "Free-form",
-			"Created": time.Now().Format("2006-01-02"),
-		},
-	}
-
-	if parentPageId == "" {
-		return MCPResult{Success: false, Message: "parent_page_id is required - get it from your Notion workspace"}
+	properties := map[string]any{
+		"Type":    "Free-form",
+		"Created": time.Now().Format("2006-01-02"),
 	}
-
-	args["parent_page_id"] = parentPageId
-
 	if len(tags) > 0 {
-		args["properties"].(map[string]any)["Tags"] = tags
+		properties["Tags"] = tags
 	}
 
-	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
-		Name:      "create_page",
-		Arguments: args,
+	result, text, err := m.callTool(ctx, tool, map[string]any{
+		"title":          title,
+		"content":        content,
+		"properties":     properties,
+		"parent_page_id": parentPageId,
 	})
-
 	if err != nil {
-		return MCPResult{Success: false, Message: fmt.Sprintf("MCP error: %v", err)}
-	}
-
-	if result.IsError {
-		return MCPResult{Success: false, Message: fmt.Sprintf("Tool returned error: %v", result.Content)}
-	}
-
-	// Извлекаем текст из результата
-	var responseText string
-	for _, content := range result.Content {
-		if textContent, ok := content.(*mcp.TextContent); ok {
-			responseText += textContent.Text
-		}
-	}
-
-	var pageID string
-	if result.Meta != nil {
-		if id, ok := result.Meta["page_id"].(string); ok {
-			pageID = id
-		}
+		return MCPResult{}, err
 	}
 
+	page := pageFromMeta(result.Meta, "", title)
+	page.ID = strings.ReplaceAll(page.ID, "-", "")
 	return MCPResult{
-		Success: true,
-		Message: responseText,
-		PageID:  strings.Replace(pageID, "-", "", -1),
+		Message: text,
+		Page:    page,
 		Data:    formatResultMeta(result.Meta),
-	}
+	}, nil
 }
 
 // SearchWorkspace выполняет поиск по workspace через кастомный MCP
-func (m *MCPClient) SearchWorkspace(ctx context.Context, query, pageType string, tags []string) MCPResult {
-	if m.session == nil {
-		return MCPResult{Success: false, Message: "MCP session not connected"}
-	}
-
+func (m *MCPClient) SearchWorkspace(ctx context.Context, query, pageType string, tags []string) (MCPResult, error) {
 	args := map[string]any{
 		"query":     query,
 		"page_size": 50,
@@ -253,40 +169,32 @@ func (m *MCPClient) SearchWorkspace(ctx context.Context, query, pageType string,
 		}
 	}
 
-	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
-		Name:      "search_pages",
-		Arguments: args,
-	})
-
+	result, text, err := m.callTool(ctx, "search_pages", args)
 	if err != nil {
-		return MCPResult{Success: false, Message: fmt.Sprintf("MCP search error: %v", err)}
-	}
-
-	if result.IsError {
-		return MCPResult{Success: false, Message: "Tool returned error"}
-	}
-
-	// Извлекаем текст из результата
-	var responseText string
-	for _, content := range result.Content {
-		if textContent, ok := content.(*mcp.TextContent); ok {
-			responseText += textContent.Text
-		}
+		return MCPResult{}, err
 	}
 
 	return MCPResult{
-		Success: true,
-		Message: responseText,
+		Message: text,
 		Data:    formatResultMeta(result.Meta),
-	}
+	}, nil
+}
+
+// SearchPagesOptions сравнение названия страницы с запросом. ExactMatch важнее Contains;
+// без обоих возвращается выдача Notion как есть. CaseInsensitive действует на оба режима
+type SearchPagesOptions struct {
+	ExactMatch      bool
+	Contains        bool
+	CaseInsensitive bool
 }
 
 // SearchPagesWithID ищет страницы в Notion и возвращает их ID, название и URL
-func (m *MCPClient) SearchPagesWithID(ctx context.Context, query string, limit int, exactMatch bool) MCPPageSearchResult {
-	if m.session == nil {
-		return MCPPageSearchResult{Success: false, Message: "MCP session not connected"}
-	}
+func (m *MCPClient) SearchPagesWithID(ctx context.Context, query string, limit int, exactMatch bool) (MCPPageSearchResult, error) {
+	return m.SearchPagesWithOptions(ctx, query, limit, SearchPagesOptions{ExactMatch: exactMatch})
+}
 
+// SearchPagesWithOptions как SearchPagesWithID, но с выбором режима сравнения названий
+func (m *MCPClient) SearchPagesWithOptions(ctx context.Context, query string, limit int, opts SearchPagesOptions) (MCPPageSearchResult, error) {
 	args := map[string]any{
 		"query": query,
 	}
@@ -295,75 +203,40 @@ func (m *MCPClient) SearchPagesWithID(ctx context.Context, query string, limit i
 		args["limit"] = limit
 	}
 
-	if exactMatch {
-		args["exact_match"] = exactMatch
+	if opts.ExactMatch {
+		args["exact_match"] = true
 	}
-
-	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
-		Name:      "search_pages_with_id",
-		Arguments: args,
-	})
-
-	if err != nil {
-		return MCPPageSearchResult{Success: false, Message: fmt.Sprintf("MCP search error: %v", err)}
+	if opts.Contains {
+		args["contains"] = true
 	}
-
-	if result.IsError {
-		return MCPPageSearchResult{Success: false, Message: "Tool returned error"}
+	if opts.CaseInsensitive {
+		args["case_insensitive"] = true
 	}
 
-	// Извлекаем текст из результата
-	var responseText string
-	for _, content := range result.Content {
-		if textContent, ok := content.(*mcp.TextContent); ok {
-			responseText += textContent.Text
-		}
+	result, text, err := m.callTool(ctx, "search_pages_with_id", args)
+	if err != nil {
+		return MCPPageSearchResult{}, err
 	}
 
 	// Извлекаем метаданные с результатами
-	var pages []MCPPageResult
-	var totalFound int
-
-	if result.Meta != nil {
-		// Извлекаем total_found
-		if count, ok := result.Meta["total_found"].(float64); ok {
-			totalFound = int(count)
-		}
-
-		// Извлекаем результаты
-		if resultsData, ok := result.Meta["results"].([]any); ok {
-			for _, item := range resultsData {
-				if pageData, ok := item.(map[string]any); ok {
-					page := MCPPageResult{}
-					if id, ok := pageData["id"].(string); ok {
-						page.ID = id
-					}
-					if title, ok := pageData["title"].(string); ok {
-						page.Title = title
-					}
-					if url, ok := pageData["url"].(string); ok {
-						page.URL = url
-					}
-					pages = append(pages, page)
-				}
+	var pages []PageMeta
+	if resultsData, ok := result.Meta["results"].([]any); ok {
+		for _, item := range resultsData {
+			if pageData, ok := item.(map[string]any); ok {
+				pages = append(pages, pageFromMeta(pageData, "", ""))
 			}
 		}
 	}
 
 	return MCPPageSearchResult{
-		Success:    true,
-		Message:    responseText,
+		Message:    text,
 		Pages:      pages,
-		TotalFound: totalFound,
-	}
+		TotalFound: metaInt(result.Meta, "total_found"),
+	}, nil
 }
 
 // ListAvailablePages получает список доступных страниц в Notion workspace
-func (m *MCPClient) ListAvailablePages(ctx context.Context, limit int, pageType string, parentOnly bool) MCPAvailablePagesResult {
-	if m.session == nil {
-		return MCPAvailablePagesResult{Success: false, Message: "MCP session not connected"}
-	}
-
+func (m *MCPClient) ListAvailablePages(ctx context.Context, limit int, pageType string, parentOnly bool) (MCPAvailablePagesResult, error) {
 	args := map[string]any{}
 
 	if limit > 0 {
@@ -378,17 +251,85 @@ func (m *MCPClient) ListAvailablePages(ctx context.Context, limit int, pageType
 		args["parent_only"] = parentOnly
 	}
 
-	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
-		Name:      "list_available_pages",
-		Arguments: args,
+	result, text, err := m.callTool(ctx, "list_available_pages", args)
+	if err != nil {
+		return MCPAvailablePagesResult{}, err
+	}
+
+	// Извлекаем метаданные с результатами
+	var pages []MCPAvailablePageResult
+	if pagesData, ok := result.Meta["pages"].([]any); ok {
+		for _, item := range pagesData {
+			if pageData, ok := item.(map[string]any); ok {
+				page := MCPAvailablePageResult{PageMeta: pageFromMeta(pageData, "", "")}
+				if canBeParent, ok := pageData["can_be_parent"].(bool); ok {
+					page.CanBeParent = canBeParent
+				}
+				if pageType, ok := pageData["type"].(string); ok {
+					page.Type = pageType
+				}
+				pages = append(pages, page)
+			}
+		}
+	}
+
+	return MCPAvailablePagesResult{
+		Message:    text,
+		Pages:      pages,
+		TotalFound: metaInt(result.Meta, "total_found"),
+	}, nil
+}
+
+// ArchivePage переносит страницу в корзину Notion (например, кнопка «Отменить» после автосохранения).
+// Подтверждение должно быть получено от пользователя до вызова: инструмент вызывается с confirm=true
+func (m *MCPClient) ArchivePage(ctx context.Context, pageID string) (MCPResult, error) {
+	return m.callPageTool(ctx, "archive_page", map[string]any{
+		"page_id": pageID,
+		"confirm": true,
+	})
+}
+
+// MovePage переносит страницу под newParentID. allowCopyFallback разрешает копирование с архивацией
+// оригинала, если API не умеет перемещать: комментарии и вложенные блоки при этом теряются, ID меняется.
+// Отказ сервера без allowCopyFallback возвращается как ErrorValidation с предупреждением для пользователя
+func (m *MCPClient) MovePage(ctx context.Context, pageID, newParentID string, allowCopyFallback bool) (MCPResult, error) {
+	return m.callPageTool(ctx, "move_page", map[string]any{
+		"page_id":             pageID,
+		"new_parent_page_id":  newParentID,
+		"confirm":             true,
+		"allow_copy_fallback": allowCopyFallback,
 	})
+}
 
+// callPageTool вызывает инструмент управления страницей; Page.ID — актуальный ID страницы после операции
+func (m *MCPClient) callPageTool(ctx context.Context, name string, args map[string]any) (MCPResult, error) {
+	result, text, err := m.callTool(ctx, name, args)
 	if err != nil {
-		return MCPAvailablePagesResult{Success: false, Message: fmt.Sprintf("MCP list pages error: %v", err)}
+		return MCPResult{}, err
 	}
 
-	if result.IsError {
-		return MCPAvailablePagesResult{Success: false, Message: "Tool returned error"}
+	pageID, _ := args["page_id"].(string)
+	return MCPResult{
+		Message: text,
+		Page:    pageFromMeta(result.Meta, pageID, ""),
+		Data:    formatResultMeta(result.Meta),
+	}, nil
+}
+
+// callTool вызывает инструмент MCP сервера и возвращает текст ответа.
+// Отсутствие сессии, ошибки транспорта и IsError приводятся к *MCPError с категорией
+func (m *MCPClient) callTool(ctx context.Context, name string, args map[string]any) (*mcp.CallToolResult, string, error) {
+	if m.session == nil {
+		return nil, "", &MCPError{Category: ErrorTransport, Tool: name, Message: "MCP session not connected"}
+	}
+
+	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      name,
+		Arguments: args,
+	})
+	if err != nil {
+		log.Printf("❌ MCP %s error: %v", name, err)
+		return nil, "", &MCPError{Category: ErrorTransport, Tool: name, Message: fmt.Sprintf("MCP error: %v", err), Err: err}
 	}
 
 	// Извлекаем текст из результата
@@ -399,48 +340,55 @@ func (m *MCPClient) ListAvailablePages(ctx context.Context, limit int, pageType
 		}
 	}
 
-	// Извлекаем метаданные с результатами
-	var pages []MCPAvailablePageResult
-	var totalFound int
-
-	if result.Meta != nil {
-		// Извлекаем total_found
-		if count, ok := result.Meta["total_found"].(float64); ok {
-			totalFound = int(count)
+	if result.IsError {
+		message := responseText
+		if message == "" {
+			message = "Tool returned error"
 		}
+		category := classifyToolError(message)
+		log.Printf("❌ MCP %s returned %s error: %s", name, category, message)
+		return nil, responseText, &MCPError{Category: category, Tool: name, Message: message}
+	}
+	return result, responseText, nil
+}
 
-		// Извлекаем результаты
-		if pagesData, ok := result.Meta["pages"].([]any); ok {
-			for _, item := range pagesData {
-				if pageData, ok := item.(map[string]any); ok {
-					page := MCPAvailablePageResult{}
-					if id, ok := pageData["id"].(string); ok {
-						page.ID = id
-					}
-					if title, ok := pageData["title"].(string); ok {
-						page.Title = title
-					}
-					if url, ok := pageData["url"].(string); ok {
-						page.URL = url
-					}
-					if canBeParent, ok := pageData["can_be_parent"].(bool); ok {
-						page.CanBeParent = canBeParent
-					}
-					if pageType, ok := pageData["type"].(string); ok {
-						page.Type = pageType
-					}
-					pages = append(pages, page)
-				}
-			}
+// errParentRequired ошибка вызова без родительской страницы; проверяется до обращения к серверу
+func errParentRequired(tool string) error {
+	return &MCPError{Category: ErrorValidation, Tool: tool, Message: "parent_page_id is required - get it from your Notion workspace"}
+}
+
+// pageFromMeta метаданные страницы из Meta ответа (page_id/id, title, url);
+// id и title используются, если сервер их не вернул. URL без ответа сервера строится по ID
+func pageFromMeta(meta map[string]any, id, title string) PageMeta {
+	page := PageMeta{ID: id, Title: title}
+	for _, key := range []string{"page_id", "id"} {
+		if v, ok := meta[key].(string); ok && v != "" {
+			page.ID = v
+			break
 		}
 	}
+	if v, ok := meta["title"].(string); ok && v != "" {
+		page.Title = v
+	}
+	if v, ok := meta["url"].(string); ok && v != "" {
+		page.URL = v
+	} else if page.ID != "" {
+		page.URL = PageURL(page.ID)
+	}
+	return page
+}
 
-	return MCPAvailablePagesResult{
-		Success:    true,
-		Message:    responseText,
-		Pages:      pages,
-		TotalFound: totalFound,
+// metaInt числовое поле Meta (JSON числа приходят как float64)
+func metaInt(meta map[string]any, key string) int {
+	if v, ok := meta[key].(float64); ok {
+		return int(v)
 	}
+	return 0
+}
+
+// PageURL ссылка на страницу Notion по её ID
+func PageURL(pageID string) string {
+	return "https://www.notion.so/" + strings.ReplaceAll(pageID, "-", "")
 }
 
 // formatResultMeta форматирует метаданные результата в JSON строку
@@ -455,59 +403,30 @@ func formatResultMeta(meta any) string {
 	return string(data)
 }
 
-// MCPResult представляет результат MCP вызова
-type MCPResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Data    string `json:"data,omitempty"`
-	PageID  string `json:"page_id,omitempty"`
-}
-
-// MCPCreatePagesResult результат создания страниц через MCP
-type MCPCreatePagesResult struct {
-	Pages []MCPPageInfo `json:"pages"`
-}
-
-// MCPPageInfo информация о созданной странице
-type MCPPageInfo struct {
-	ID  string `json:"id"`
-	URL string `json:"url"`
-}
-
-// MCPSearchResult результат поиска через MCP
-type MCPSearchResult struct {
-	Results []MCPSearchItem `json:"results"`
-	Type    string          `json:"type"`
+// PageMeta метаданные страницы Notion
+type PageMeta struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
 }
 
-// MCPSearchItem элемент результата поиска
-type MCPSearchItem struct {
-	Title     string `json:"title"`
-	URL       string `json:"url"`
-	Type      string `json:"type"`
-	Highlight string `json:"highlight"`
-	Timestamp string `json:"timestamp"`
-	ID        string `json:"id"`
+// MCPResult результат MCP вызова; Page заполняется инструментами, работающими с одной страницей.
+// Ошибки возвращаются отдельно как *MCPError
+type MCPResult struct {
+	Message string   `json:"message"`
+	Data    string   `json:"data,omitempty"`
+	Page    PageMeta `json:"page,omitempty"`
 }
 
 // MCPPageSearchResult результат поиска страниц с ID
 type MCPPageSearchResult struct {
-	Success    bool            `json:"success"`
-	Message    string          `json:"message"`
-	Pages      []MCPPageResult `json:"pages"`
-	TotalFound int             `json:"total_found"`
-}
-
-// MCPPageResult информация о найденной странице
-type MCPPageResult struct {
-	ID    string `json:"id"`
-	Title string `json:"title"`
-	URL   string `json:"url"`
+	Message    string     `json:"message"`
+	Pages      []PageMeta `json:"pages"`
+	TotalFound int        `json:"total_found"`
 }
 
 // MCPAvailablePagesResult результат получения списка доступных страниц
 type MCPAvailablePagesResult struct {
-	Success    bool                     `json:"success"`
 	Message    string                   `json:"message"`
 	Pages      []MCPAvailablePageResult `json:"pages"`
 	TotalFound int                      `json:"total_found"`
@@ -515,9 +434,7 @@ type MCPAvailablePagesResult struct {
 
 // MCPAvailablePageResult информация о доступной странице
 type MCPAvailablePageResult struct {
-	ID          string `json:"id"`
-	Title       string `json:"title"`
-	URL         string `json:"url"`
+	PageMeta
 	CanBeParent bool   `json:"can_be_parent"`
 	Type        string `json:"type,omitempty"`
 }
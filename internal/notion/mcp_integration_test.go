@@ -56,7 +56,7 @@ func TestMCPIntegration(t *testing.T) {
 - Purpose: Integration testing of MCP Notion integration
 - Expected: Page should be created successfully`
 
-		result := mcpClient.CreateDialogSummary(
+		result, err := mcpClient.CreateDialogSummary(
 			ctx,
 			title,
 			content,
@@ -66,18 +66,18 @@ func TestMCPIntegration(t *testing.T) {
 			testPageID,
 		)
 
-		if !result.Success {
-			t.Errorf("CreateDialogSummary failed: %s", result.Message)
+		if err != nil {
+			t.Errorf("CreateDialogSummary failed (%s): %v", CategoryOf(err), err)
 			return
 		}
 
 		t.Logf("✅ Dialog created successfully: %s", result.Message)
 
 		// Проверяем что вернулся page ID
-		if result.PageID == "" {
-			t.Error("Expected PageID in result, got empty string")
+		if result.Page.ID == "" {
+			t.Error("Expected page ID in result, got empty string")
 		} else {
-			t.Logf("📄 Created page ID: %s", result.PageID)
+			t.Logf("📄 Created page: %s (%s)", result.Page.ID, result.Page.URL)
 		}
 
 		// Проверяем что в сообщении есть упоминание об успехе
@@ -114,7 +114,7 @@ This page was created during integration testing of the Notion MCP server.
 ---
 *This is an automated test page and can be safely deleted.*`, timestamp, testPageID)
 
-		result := mcpClient.CreateFreeFormPage(
+		result, err := mcpClient.CreateFreeFormPage(
 			ctx,
 			title,
 			content,
@@ -122,18 +122,18 @@ This page was created during integration testing of the Notion MCP server.
 			[]string{"integration-test", "mcp", "automated", timestamp},
 		)
 
-		if !result.Success {
-			t.Errorf("CreateFreeFormPage failed: %s", result.Message)
+		if err != nil {
+			t.Errorf("CreateFreeFormPage failed (%s): %v", CategoryOf(err), err)
 			return
 		}
 
 		t.Logf("✅ Free-form page created successfully: %s", result.Message)
 
 		// Проверяем что вернулся page ID
-		if result.PageID == "" {
-			t.Error("Expected PageID in result, got empty string")
+		if result.Page.ID == "" {
+			t.Error("Expected page ID in result, got empty string")
 		} else {
-			t.Logf("📄 Created page ID: %s", result.PageID)
+			t.Logf("📄 Created page: %s (%s)", result.Page.ID, result.Page.URL)
 		}
 
 		// Проверяем что в сообщении есть упоминание об успехе
@@ -146,10 +146,10 @@ This page was created during integration testing of the Notion MCP server.
 		// Ищем только что созданные страницы
 		searchQuery := fmt.Sprintf("Integration Test %s", testSuffix)
 
-		result := mcpClient.SearchWorkspace(ctx, searchQuery, "", []string{})
+		result, err := mcpClient.SearchWorkspace(ctx, searchQuery, "", []string{})
 
-		if !result.Success {
-			t.Errorf("SearchWorkspace failed: %s", result.Message)
+		if err != nil {
+			t.Errorf("SearchWorkspace failed: %v", err)
 			return
 		}
 
@@ -166,10 +166,10 @@ This page was created during integration testing of the Notion MCP server.
 
 	t.Run("SearchPagesWithID", func(t *testing.T) {
 		// Ищем страницы по названию "Test" (могут быть созданы предыдущими тестами)
-		searchResult := mcpClient.SearchPagesWithID(ctx, "Test", 5, false)
+		searchResult, err := mcpClient.SearchPagesWithID(ctx, "Test", 5, false)
 
-		if !searchResult.Success {
-			t.Errorf("Page search failed: %s", searchResult.Message)
+		if err != nil {
+			t.Errorf("Page search failed: %v", err)
 			return
 		}
 
@@ -192,20 +192,20 @@ This page was created during integration testing of the Notion MCP server.
 
 		// Тестируем точное совпадение с только что созданной страницей
 		exactTitle := fmt.Sprintf("Custom Test Page %s", testSuffix)
-		exactSearchResult := mcpClient.SearchPagesWithID(ctx, exactTitle, 1, true)
-		if exactSearchResult.Success {
+		exactSearchResult, err := mcpClient.SearchPagesWithID(ctx, exactTitle, 1, true)
+		if err == nil {
 			t.Logf("✅ Exact match search: found %d pages", len(exactSearchResult.Pages))
 		} else {
-			t.Logf("⚠️  Exact match search failed (page may not be indexed yet): %s", exactSearchResult.Message)
+			t.Logf("⚠️  Exact match search failed (page may not be indexed yet): %v", err)
 		}
 	})
 
 	t.Run("ListAvailablePages", func(t *testing.T) {
 		// Получаем список доступных страниц
-		listResult := mcpClient.ListAvailablePages(ctx, 10, "", false)
+		listResult, err := mcpClient.ListAvailablePages(ctx, 10, "", false)
 
-		if !listResult.Success {
-			t.Errorf("List available pages failed: %s", listResult.Message)
+		if err != nil {
+			t.Errorf("List available pages failed: %v", err)
 			return
 		}
 
@@ -235,19 +235,17 @@ This page was created during integration testing of the Notion MCP server.
 				subPageTitle := fmt.Sprintf("Sub Page Test %s", testSuffix)
 				subPageContent := "This is a test subpage created under a specific parent."
 
-				createResult := mcpClient.CreateFreeFormPage(ctx, subPageTitle, subPageContent, parentPage.ID, nil)
-
-				if createResult.Success {
+				if _, err := mcpClient.CreateFreeFormPage(ctx, subPageTitle, subPageContent, parentPage.ID, nil); err == nil {
 					t.Logf("✅ Subpage created successfully under %s", parentPage.Title)
 				} else {
-					t.Logf("⚠️  Subpage creation failed: %s", createResult.Message)
+					t.Logf("⚠️  Subpage creation failed: %v", err)
 				}
 			}
 		}
 
 		// Тестируем фильтр parent_only
-		parentOnlyResult := mcpClient.ListAvailablePages(ctx, 5, "", true)
-		if parentOnlyResult.Success {
+		parentOnlyResult, err := mcpClient.ListAvailablePages(ctx, 5, "", true)
+		if err == nil {
 			t.Logf("✅ Parent-only filter: found %d pages", len(parentOnlyResult.Pages))
 			for _, page := range parentOnlyResult.Pages {
 				if !page.CanBeParent {
@@ -261,7 +259,7 @@ This page was created during integration testing of the Notion MCP server.
 		// Тест с некорректным parent page ID
 		invalidPageID := "invalid-page-id-format"
 
-		result := mcpClient.CreateFreeFormPage(
+		_, err := mcpClient.CreateFreeFormPage(
 			ctx,
 			"This Should Fail",
 			"This page creation should fail due to invalid parent page ID",
@@ -269,15 +267,14 @@ This page was created during integration testing of the Notion MCP server.
 			[]string{"error-test"},
 		)
 
-		if result.Success {
-			t.Error("Expected CreateFreeFormPage to fail with invalid parent page ID, but it succeeded")
-		} else {
-			t.Logf("✅ Error handling works correctly: %s", result.Message)
+		if err == nil {
+			t.Fatal("Expected CreateFreeFormPage to fail with invalid parent page ID, but it succeeded")
 		}
+		t.Logf("✅ Error handling works correctly (%s): %v", CategoryOf(err), err)
 
-		// Проверяем что ошибка содержит полезную информацию
-		if !strings.Contains(result.Message, "error") && !strings.Contains(result.Message, "Error") {
-			t.Errorf("Expected error message to contain 'error', got: %s", result.Message)
+		// Некорректный ID — ошибка данных, а не транспорта: повторять такой вызов бессмысленно
+		if IsRetryable(err) {
+			t.Errorf("Invalid parent page ID must not be retryable, got %s: %v", CategoryOf(err), err)
 		}
 	})
 
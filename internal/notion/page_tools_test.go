@@ -0,0 +1,146 @@
+package notion
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// connectStubServer подключает MCPClient к серверу в памяти с заглушками инструментов
+func connectStubServer(t *testing.T, tools map[string]mcp.ToolHandlerFor[map[string]any, any]) *MCPClient {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "notion-stub", Version: "v0.0.1"}, nil)
+	for name, handler := range tools {
+		mcp.AddTool(server, &mcp.Tool{Name: name}, handler)
+	}
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	go func() { _ = server.Run(ctx, serverTransport) }()
+
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "v0.0.1"}, nil).Connect(ctx, clientTransport)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { _ = session.Close() })
+	return &MCPClient{session: session}
+}
+
+func TestMCPClient_ArchiveAndMovePage(t *testing.T) {
+	var archiveArgs, moveArgs map[string]any
+	client := connectStubServer(t, map[string]mcp.ToolHandlerFor[map[string]any, any]{
+		"archive_page": func(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[any], error) {
+			archiveArgs = params.Arguments
+			return &mcp.CallToolResultFor[any]{Content: []mcp.Content{&mcp.TextContent{Text: "✅ archived"}}}, nil
+		},
+		"move_page": func(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[any], error) {
+			moveArgs = params.Arguments
+			if params.Arguments["allow_copy_fallback"] != true {
+				return &mcp.CallToolResultFor[any]{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "⚠️ cannot move, comments would be lost"}}}, nil
+			}
+			return &mcp.CallToolResultFor[any]{
+				Content: []mcp.Content{&mcp.TextContent{Text: "✅ copied"}},
+				Meta:    map[string]any{"page_id": "copy-id", "method": "copy_archive"},
+			}, nil
+		},
+	})
+	ctx := context.Background()
+
+	if res, err := client.ArchivePage(ctx, "page-1"); err != nil || res.Page.ID != "page-1" || res.Page.URL != "https://www.notion.so/page1" {
+		t.Fatalf("archive: %+v, %v", res, err)
+	}
+	if archiveArgs["confirm"] != true || archiveArgs["page_id"] != "page-1" {
+		t.Errorf("archive must be confirmed: %v", archiveArgs)
+	}
+
+	_, err := client.MovePage(ctx, "page-1", "parent-2", false)
+	if err == nil || err.Error() != "⚠️ cannot move, comments would be lost" {
+		t.Fatalf("refused fallback must surface the warning: %v", err)
+	}
+	res, err := client.MovePage(ctx, "page-1", "parent-2", true)
+	if err != nil || res.Page.ID != "copy-id" {
+		t.Fatalf("move by copy must return the new page ID: %+v, %v", res, err)
+	}
+	if moveArgs["new_parent_page_id"] != "parent-2" || moveArgs["confirm"] != true {
+		t.Errorf("unexpected move args: %v", moveArgs)
+	}
+
+	if _, err := (&MCPClient{}).ArchivePage(ctx, "page-1"); CategoryOf(err) != ErrorTransport || !IsRetryable(err) {
+		t.Errorf("archive without session must fail with a transport error, got %v", err)
+	}
+}
+
+func TestMCPClient_SearchPagesWithOptions(t *testing.T) {
+	var calls []map[string]any
+	client := connectStubServer(t, map[string]mcp.ToolHandlerFor[map[string]any, any]{
+		"search_pages_with_id": func(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[any], error) {
+			calls = append(calls, params.Arguments)
+			return &mcp.CallToolResultFor[any]{
+				Content: []mcp.Content{&mcp.TextContent{Text: "🔍 Found 1 pages"}},
+				Meta: map[string]any{
+					"total_found": 1,
+					"results":     []any{map[string]any{"id": "p1", "title": "Test Page", "url": "https://notion.so/p1"}},
+				},
+			}, nil
+		},
+	})
+	ctx := context.Background()
+
+	res, err := client.SearchPagesWithOptions(ctx, "test", 3, SearchPagesOptions{Contains: true, CaseInsensitive: true})
+	if err != nil || len(res.Pages) != 1 || res.Pages[0].Title != "Test Page" || res.Pages[0].URL != "https://notion.so/p1" || res.TotalFound != 1 {
+		t.Fatalf("unexpected result: %+v, %v", res, err)
+	}
+	if calls[0]["contains"] != true || calls[0]["case_insensitive"] != true || calls[0]["exact_match"] != nil {
+		t.Errorf("matching options must be sent to the tool: %v", calls[0])
+	}
+
+	client.SearchPagesWithID(ctx, "Test Page", 1, true)
+	if calls[1]["exact_match"] != true || calls[1]["contains"] != nil || calls[1]["case_insensitive"] != nil {
+		t.Errorf("SearchPagesWithID must keep sending only exact_match: %v", calls[1])
+	}
+}
+
+func TestMCPClient_ErrorCategories(t *testing.T) {
+	var reply string
+	client := connectStubServer(t, map[string]mcp.ToolHandlerFor[map[string]any, any]{
+		"create_page": func(ctx context.Context, ss *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (*mcp.CallToolResultFor[any], error) {
+			if reply == "" {
+				return &mcp.CallToolResultFor[any]{
+					Content: []mcp.Content{&mcp.TextContent{Text: "✅ Successfully created page"}},
+					Meta:    map[string]any{"page_id": "aaaa-bbbb", "title": "Notes", "success": true},
+				}, nil
+			}
+			return &mcp.CallToolResultFor[any]{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: reply}}}, nil
+		},
+	})
+	ctx := context.Background()
+
+	res, err := client.CreateFreeFormPage(ctx, "Notes", "text", "parent-1", nil)
+	if err != nil || res.Page != (PageMeta{ID: "aaaabbbb", Title: "Notes", URL: "https://www.notion.so/aaaabbbb"}) {
+		t.Fatalf("created page metadata expected, got %+v, %v", res, err)
+	}
+
+	for text, want := range map[string]ErrorCategory{
+		`❌ Failed to create page: Notion API error 401: {"code":"unauthorized"}`:     ErrorAuth,
+		`❌ Failed to create page: Notion API error 404: {"code":"object_not_found"}`: ErrorNotFound,
+		`❌ Failed to create page: Notion API error 429: {"code":"rate_limited"}`:     ErrorRateLimited,
+		`❌ Failed to create page: Notion API error 400: {"code":"validation_error"}`: ErrorValidation,
+		`❌ Failed to create page: request failed: dial tcp: connection refused`:      ErrorTransport,
+		`❌ Failed to create page: Notion API error 502: bad gateway`:                 ErrorTransport,
+		"❌ something odd": ErrorUnknown,
+	} {
+		reply = text
+		_, err := client.CreateFreeFormPage(ctx, "Notes", "text", "parent-1", nil)
+		var mcpErr *MCPError
+		if !errors.As(err, &mcpErr) || mcpErr.Category != want || mcpErr.Tool != "create_page" || err.Error() != text {
+			t.Errorf("%q: want %s, got %#v", text, want, err)
+		}
+	}
+
+	if _, err := client.CreateFreeFormPage(ctx, "Notes", "text", "", nil); CategoryOf(err) != ErrorValidation || IsRetryable(err) {
+		t.Errorf("missing parent must be a validation error, got %v", err)
+	}
+}
@@ -7,14 +7,30 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"ai-chatter/internal/auth"
 )
 
+// Request заявка на доступ: пользователь, его первое сообщение и время заявки.
+// Banned — администратор заблокировал пользователя, новые заявки от него не принимаются
+type Request struct {
+	auth.User
+	FirstMessage string    `json:"first_message,omitempty"`
+	RequestedAt  time.Time `json:"requested_at,omitempty"`
+	Banned       bool      `json:"banned,omitempty"`
+}
+
 type Repository interface {
 	LoadAll() ([]auth.User, error)
 	Upsert(user auth.User) error
 	Remove(userID int64) error
+	// Requests все записи, включая заблокированных пользователей
+	Requests() ([]Request, error)
+	// Add сохраняет заявку, если от пользователя ещё нет записи; иначе возвращает существующую и false
+	Add(req Request) (Request, bool, error)
+	// Ban помечает пользователя заблокированным
+	Ban(user auth.User) error
 }
 
 type FileRepository struct {
@@ -34,61 +50,100 @@ func NewFileRepository(path string) (*FileRepository, error) {
 	return &FileRepository{path: path}, nil
 }
 
+// LoadAll пользователи с ожидающими заявками (без заблокированных)
 func (r *FileRepository) LoadAll() ([]auth.User, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	f, err := os.Open(r.path)
+	items, err := r.loadUnlocked()
 	if err != nil {
 		return nil, fmt.Errorf("open: %w", err)
 	}
-	defer func(f *os.File) {
-		err := f.Close()
-		if err != nil {
+	users := []auth.User{}
+	for _, item := range items {
+		if !item.Banned {
+			users = append(users, item.User)
 		}
-	}(f)
-	var users []auth.User
-	dec := json.NewDecoder(f)
-	if err := dec.Decode(&users); err != nil {
-		if err == io.EOF {
-			return []auth.User{}, nil
-		}
-		return []auth.User{}, nil
 	}
 	return users, nil
 }
 
+// Requests все записи файла, включая заблокированных пользователей
+func (r *FileRepository) Requests() ([]Request, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	items, err := r.loadUnlocked()
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	return items, nil
+}
+
+// Upsert обновляет данные пользователя, сохраняя первое сообщение и время заявки
 func (r *FileRepository) Upsert(user auth.User) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	users, _ := r.loadUnlocked()
+	items, _ := r.loadUnlocked()
 	updated := false
-	for i, u := range users {
-		if u.ID == user.ID {
-			users[i] = user
+	for i, item := range items {
+		if item.ID == user.ID {
+			items[i].User = user
 			updated = true
 			break
 		}
 	}
 	if !updated {
-		users = append(users, user)
+		items = append(items, Request{User: user, RequestedAt: time.Now().UTC()})
 	}
-	return r.saveUnlocked(users)
+	return r.saveUnlocked(items)
+}
+
+// Add атомарно добавляет заявку: повторная заявка того же пользователя не перезаписывает первую
+func (r *FileRepository) Add(req Request) (Request, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	items, _ := r.loadUnlocked()
+	for _, item := range items {
+		if item.ID == req.ID {
+			return item, false, nil
+		}
+	}
+	if req.RequestedAt.IsZero() {
+		req.RequestedAt = time.Now().UTC()
+	}
+	if err := r.saveUnlocked(append(items, req)); err != nil {
+		return Request{}, false, err
+	}
+	return req, true, nil
+}
+
+// Ban помечает пользователя заблокированным (запись создаётся, если заявки не было)
+func (r *FileRepository) Ban(user auth.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	items, _ := r.loadUnlocked()
+	for i, item := range items {
+		if item.ID == user.ID {
+			items[i].Banned = true
+			return r.saveUnlocked(items)
+		}
+	}
+	return r.saveUnlocked(append(items, Request{User: user, RequestedAt: time.Now().UTC(), Banned: true}))
 }
 
 func (r *FileRepository) Remove(userID int64) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	users, _ := r.loadUnlocked()
-	var out []auth.User
-	for _, u := range users {
-		if u.ID != userID {
-			out = append(out, u)
+	items, _ := r.loadUnlocked()
+	out := []Request{}
+	for _, item := range items {
+		if item.ID != userID {
+			out = append(out, item)
 		}
 	}
 	return r.saveUnlocked(out)
 }
 
-func (r *FileRepository) loadUnlocked() ([]auth.User, error) {
+func (r *FileRepository) loadUnlocked() ([]Request, error) {
 	f, err := os.Open(r.path)
 	if err != nil {
 		return nil, err
@@ -98,25 +153,26 @@ func (r *FileRepository) loadUnlocked() ([]auth.User, error) {
 		if err != nil {
 		}
 	}(f)
-	var users []auth.User
+	var items []Request
 	dec := json.NewDecoder(f)
-	if err := dec.Decode(&users); err != nil {
-		return []auth.User{}, nil
+	if err := dec.Decode(&items); err != nil {
+		if err == io.EOF {
+			return []Request{}, nil
+		}
+		return []Request{}, nil
 	}
-	return users, nil
+	return items, nil
 }
 
-func (r *FileRepository) saveUnlocked(users []auth.User) error {
-	f, err := os.OpenFile(r.path, os.O_TRUNC|os.O_WRONLY, 0o644)
+// saveUnlocked пишет во временный файл и переименовывает его: файл не остаётся обрезанным при сбое
+func (r *FileRepository) saveUnlocked(items []Request) error {
+	data, err := json.MarshalIndent(items, "", "  ")
 	if err != nil {
 		return err
 	}
-	defer func(f *os.File) {
-		err := f.Close()
-		if err != nil {
-		}
-	}(f)
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	return enc.Encode(users)
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
 }
@@ -1,6 +1,7 @@
 package pending
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -40,3 +41,39 @@ func TestPendingFileRepo_CRUD(t *testing.T) {
 		t.Fatalf("unexpected items: %+v", items)
 	}
 }
+
+func TestPendingFileRepo_AddDeduplicatesAndBan(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "pending.json")
+	// Файл старого формата: массив пользователей без времени и первого сообщения
+	if err := os.WriteFile(p, []byte(`[{"id": 7, "username": "old"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewFileRepository(p)
+	if err != nil {
+		t.Fatalf("init: %v", err)
+	}
+
+	first, created, err := repo.Add(Request{User: auth.User{ID: 1, Username: "alice"}, FirstMessage: "hello"})
+	if err != nil || !created || first.RequestedAt.IsZero() {
+		t.Fatalf("first add: %+v created=%v err=%v", first, created, err)
+	}
+	again, created, _ := repo.Add(Request{User: auth.User{ID: 1}, FirstMessage: "second"})
+	if created || again.FirstMessage != "hello" {
+		t.Fatalf("repeat request must keep the first one: %+v created=%v", again, created)
+	}
+
+	if err := repo.Ban(auth.User{ID: 7, Username: "old"}); err != nil {
+		t.Fatalf("ban: %v", err)
+	}
+	users, _ := repo.LoadAll()
+	if len(users) != 1 || users[0].ID != 1 {
+		t.Fatalf("banned users must not be listed as pending: %+v", users)
+	}
+	items, _ := repo.Requests()
+	if len(items) != 2 || !items[0].Banned || items[0].Username != "old" {
+		t.Fatalf("unexpected requests: %+v", items)
+	}
+	if _, created, _ := repo.Add(Request{User: auth.User{ID: 7}}); created {
+		t.Error("banned user must not create a new request")
+	}
+}
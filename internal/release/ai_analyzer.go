@@ -2,13 +2,13 @@ package release
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
 	"ai-chatter/internal/llm"
+	"ai-chatter/internal/llm/jsonextract"
 )
 
 // AIFieldAnalysis результат анализа ИИ для определения недостающих полей
@@ -164,19 +164,9 @@ func (r *ReleaseAgent) buildAnalysisContext(session *ReleaseSession) string {
 
 // parseLLMAnalysis парсит ответ LLM в структурированный формат
 func (r *ReleaseAgent) parseLLMAnalysis(content string) (*AIFieldAnalysis, error) {
-	// Ищем JSON в ответе (может быть обёрнут в markdown блок)
-	jsonStart := strings.Index(content, "{")
-	jsonEnd := strings.LastIndex(content, "}")
-
-	if jsonStart == -1 || jsonEnd == -1 || jsonEnd <= jsonStart {
-		return nil, fmt.Errorf("no JSON found in LLM response")
-	}
-
-	jsonContent := content[jsonStart : jsonEnd+1]
-
 	var analysis AIFieldAnalysis
-	if err := json.Unmarshal([]byte(jsonContent), &analysis); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	if err := jsonextract.Decode(content, &analysis, jsonextract.Options{Repair: true}); err != nil {
+		return nil, fmt.Errorf("failed to parse LLM analysis: %w", err)
 	}
 
 	return &analysis, nil
@@ -434,16 +424,6 @@ func (r *ReleaseAgent) buildValidationContext(session *ReleaseSession) string {
 
 // parseValidationResult парсит результат LLM валидации
 func (r *ReleaseAgent) parseValidationResult(content string) (*ValidationResult, error) {
-	// Ищем JSON в ответе
-	jsonStart := strings.Index(content, "{")
-	jsonEnd := strings.LastIndex(content, "}")
-
-	if jsonStart == -1 || jsonEnd == -1 {
-		return nil, fmt.Errorf("no JSON found in validation response")
-	}
-
-	jsonContent := content[jsonStart : jsonEnd+1]
-
 	var result struct {
 		ReadyForPublication bool     `json:"ready_for_publication"`
 		Analysis            string   `json:"analysis"`
@@ -452,7 +432,7 @@ func (r *ReleaseAgent) parseValidationResult(content string) (*ValidationResult,
 		Recommendations     []string `json:"recommendations"`
 	}
 
-	if err := json.Unmarshal([]byte(jsonContent), &result); err != nil {
+	if err := jsonextract.Decode(content, &result, jsonextract.Options{Repair: true}); err != nil {
 		return nil, fmt.Errorf("failed to parse validation JSON: %w", err)
 	}
 
@@ -657,17 +637,8 @@ func (r *ReleaseAgent) buildErrorAnalysisContext(session *ReleaseSession, publis
 
 // parseErrorAnalysis парсит результат анализа ошибки от LLM
 func (r *ReleaseAgent) parseErrorAnalysis(content string) (*ErrorAnalysisResult, error) {
-	jsonStart := strings.Index(content, "{")
-	jsonEnd := strings.LastIndex(content, "}")
-
-	if jsonStart == -1 || jsonEnd == -1 {
-		return nil, fmt.Errorf("no JSON found in error analysis")
-	}
-
-	jsonContent := content[jsonStart : jsonEnd+1]
-
 	var result ErrorAnalysisResult
-	if err := json.Unmarshal([]byte(jsonContent), &result); err != nil {
+	if err := jsonextract.Decode(content, &result, jsonextract.Options{Repair: true}); err != nil {
 		return nil, fmt.Errorf("failed to parse error analysis JSON: %w", err)
 	}
 
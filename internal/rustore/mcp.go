@@ -324,6 +324,110 @@ func (r *RuStoreMCPClient) UploadAndroidFile(ctx context.Context, appID, version
 	}
 }
 
+// UploadMedia загружает иконку или скриншот (mediaType: icon|screenshot) для версии; content в base64
+func (r *RuStoreMCPClient) UploadMedia(ctx context.Context, appID, versionID, mediaType, content, fileName string) RuStoreMediaResult {
+	if r.session == nil {
+		return RuStoreMediaResult{RuStoreMCPResult: RuStoreMCPResult{Success: false, Message: "RuStore MCP session not connected"}}
+	}
+
+	log.Printf("🖼️ Uploading %s to RuStore via MCP: app=%s, version=%s, file=%s", mediaType, appID, versionID, fileName)
+
+	// Вызываем инструмент rustore_upload_media
+	result, err := r.session.CallTool(ctx, &mcp.CallToolParams{
+		Name: "rustore_upload_media",
+		Arguments: map[string]any{
+			"app_id":     appID,
+			"version_id": versionID,
+			"media_type": mediaType,
+			"content":    content,
+			"file_name":  fileName,
+		},
+	})
+
+	if err != nil {
+		log.Printf("❌ RuStore MCP upload media error: %v", err)
+		return RuStoreMediaResult{RuStoreMCPResult: RuStoreMCPResult{Success: false, Message: fmt.Sprintf("RuStore MCP upload media error: %v", err)}}
+	}
+
+	// Извлекаем текст из результата
+	var responseText string
+	for _, content := range result.Content {
+		if textContent, ok := content.(*mcp.TextContent); ok {
+			responseText += textContent.Text
+		}
+	}
+
+	if result.IsError {
+		return RuStoreMediaResult{RuStoreMCPResult: RuStoreMCPResult{Success: false, Message: responseText}}
+	}
+
+	mediaResult := RuStoreMediaResult{RuStoreMCPResult: RuStoreMCPResult{Success: true, Message: responseText}}
+	if result.Meta != nil {
+		mediaResult.MediaID, _ = result.Meta["media_id"].(string)
+		mediaResult.MediaType, _ = result.Meta["media_type"].(string)
+	}
+	return mediaResult
+}
+
+// GetVersions получает список версий приложения; pageSize <= 0 — размер страницы по умолчанию
+func (r *RuStoreMCPClient) GetVersions(ctx context.Context, appID string, pageSize int) RuStoreVersionListResult {
+	if r.session == nil {
+		return RuStoreVersionListResult{RuStoreMCPResult: RuStoreMCPResult{Success: false, Message: "RuStore MCP session not connected"}}
+	}
+
+	log.Printf("🗂️ Getting RuStore versions via MCP: app=%s", appID)
+
+	arguments := map[string]any{"app_id": appID}
+	if pageSize > 0 {
+		arguments["page_size"] = pageSize
+	}
+
+	// Вызываем инструмент rustore_get_versions
+	result, err := r.session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "rustore_get_versions",
+		Arguments: arguments,
+	})
+
+	if err != nil {
+		log.Printf("❌ RuStore MCP get versions error: %v", err)
+		return RuStoreVersionListResult{RuStoreMCPResult: RuStoreMCPResult{Success: false, Message: fmt.Sprintf("RuStore MCP get versions error: %v", err)}}
+	}
+
+	// Извлекаем текст из результата
+	var responseText string
+	for _, content := range result.Content {
+		if textContent, ok := content.(*mcp.TextContent); ok {
+			responseText += textContent.Text
+		}
+	}
+
+	if result.IsError {
+		return RuStoreVersionListResult{RuStoreMCPResult: RuStoreMCPResult{Success: false, Message: responseText}}
+	}
+
+	versionsResult := RuStoreVersionListResult{RuStoreMCPResult: RuStoreMCPResult{Success: true, Message: responseText}}
+	if result.Meta != nil {
+		versions, _ := result.Meta["versions"].([]interface{})
+		for _, v := range versions {
+			versionMap, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			info := RuStoreVersionInfo{}
+			info.VersionID, _ = versionMap["version_id"].(string)
+			info.VersionName, _ = versionMap["versionName"].(string)
+			info.Status, _ = versionMap["versionStatus"].(string)
+			if created, ok := versionMap["createdDate"].(string); ok {
+				info.CreatedDate = created
+			} else {
+				info.CreatedDate, _ = versionMap["createDate"].(string)
+			}
+			versionsResult.Versions = append(versionsResult.Versions, info)
+		}
+	}
+	return versionsResult
+}
+
 // SubmitForReview отправляет версию на модерацию
 func (r *RuStoreMCPClient) SubmitForReview(ctx context.Context, appID, versionID string) RuStoreMCPResult {
 	if r.session == nil {
@@ -372,6 +476,13 @@ type RuStoreMCPResult struct {
 	Message string `json:"message"`
 }
 
+// RuStoreMediaResult результат загрузки иконки или скриншота
+type RuStoreMediaResult struct {
+	RuStoreMCPResult
+	MediaID   string `json:"media_id"`
+	MediaType string `json:"media_type"`
+}
+
 // RuStoreDraftResult результат создания черновика
 type RuStoreDraftResult struct {
 	RuStoreMCPResult
@@ -526,6 +637,20 @@ type RuStoreAppListResult struct {
 	ContinuationToken string           `json:"continuation_token,omitempty"`
 }
 
+// RuStoreVersionListResult результат получения списка версий приложения
+type RuStoreVersionListResult struct {
+	RuStoreMCPResult
+	Versions []RuStoreVersionInfo `json:"versions"`
+}
+
+// RuStoreVersionInfo версия приложения RuStore
+type RuStoreVersionInfo struct {
+	VersionID   string `json:"version_id"`
+	VersionName string `json:"version_name"`
+	Status      string `json:"status"`
+	CreatedDate string `json:"created_date,omitempty"`
+}
+
 // RuStoreAppInfo информация о приложении RuStore
 type RuStoreAppInfo struct {
 	AppID            string   `json:"app_id"`
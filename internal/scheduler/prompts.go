@@ -0,0 +1,527 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	ErrPromptNotFound = errors.New("scheduled prompt not found")
+	ErrPromptLimit    = errors.New("scheduled prompt limit reached")
+	ErrScheduleSpec   = errors.New("cannot parse schedule")
+)
+
+// ScheduledPrompt запрос пользователя к LLM, выполняемый по расписанию
+type ScheduledPrompt struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	ChatID    int64     `json:"chat_id"`
+	Spec      string    `json:"spec"` // расписание в том виде, как его ввёл пользователь
+	Cron      string    `json:"cron"` // то же расписание в формате cron (5 полей)
+	Timezone  string    `json:"timezone"`
+	Prompt    string    `json:"prompt"`
+	CreatedAt time.Time `json:"created_at"`
+	LastRunAt time.Time `json:"last_run_at,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// cronSpec расписание с часовым поясом для robfig/cron
+func (p ScheduledPrompt) cronSpec() string {
+	if p.Timezone == "" {
+		return p.Cron
+	}
+	return "CRON_TZ=" + p.Timezone + " " + p.Cron
+}
+
+// Next ближайшее время выполнения после now (нулевое, если расписание некорректно)
+func (p ScheduledPrompt) Next(now time.Time) time.Time {
+	schedule, err := cron.ParseStandard(p.cronSpec())
+	if err != nil {
+		return time.Time{}
+	}
+	return schedule.Next(now)
+}
+
+type promptFile struct {
+	NextID    int64             `json:"next_id"`
+	Prompts   []ScheduledPrompt `json:"prompts"`
+	Timezones map[int64]string  `json:"timezones,omitempty"`
+}
+
+// PromptStore хранит запланированные запросы и часовые пояса пользователей в JSON файле
+type PromptStore struct {
+	mu         sync.Mutex
+	path       string
+	maxPerUser int
+	defaultLoc *time.Location
+	nextID     int64
+	prompts    []ScheduledPrompt
+	timezones  map[int64]string
+}
+
+// NewPromptStore загружает запросы из файла (пустой путь — хранение только в памяти).
+// maxPerUser <= 0 снимает ограничение, defaultLoc — часовой пояс пользователей, не выбравших свой
+func NewPromptStore(path string, maxPerUser int, defaultLoc *time.Location) (*PromptStore, error) {
+	if defaultLoc == nil {
+		defaultLoc = time.UTC
+	}
+	s := &PromptStore{path: path, maxPerUser: maxPerUser, defaultLoc: defaultLoc, nextID: 1, timezones: make(map[int64]string)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	var stored promptFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("parse scheduled prompts: %w", err)
+	}
+	s.prompts = stored.Prompts
+	s.nextID = stored.NextID
+	if stored.Timezones != nil {
+		s.timezones = stored.Timezones
+	}
+	for _, p := range s.prompts {
+		if p.ID >= s.nextID {
+			s.nextID = p.ID + 1
+		}
+	}
+	if s.nextID < 1 {
+		s.nextID = 1
+	}
+	return s, nil
+}
+
+func (s *PromptStore) saveUnlocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(promptFile{NextID: s.nextID, Prompts: s.prompts, Timezones: s.timezones}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Add сохраняет запрос с новым ID; превышение лимита на пользователя возвращает ErrPromptLimit
+func (s *PromptStore) Add(p ScheduledPrompt) (ScheduledPrompt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxPerUser > 0 {
+		count := 0
+		for _, existing := range s.prompts {
+			if existing.UserID == p.UserID {
+				count++
+			}
+		}
+		if count >= s.maxPerUser {
+			return ScheduledPrompt{}, fmt.Errorf("%w: %d", ErrPromptLimit, s.maxPerUser)
+		}
+	}
+	p.ID = s.nextID
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now()
+	}
+	s.nextID++
+	s.prompts = append(s.prompts, p)
+	if err := s.saveUnlocked(); err != nil {
+		s.prompts = s.prompts[:len(s.prompts)-1]
+		s.nextID--
+		return ScheduledPrompt{}, err
+	}
+	return p, nil
+}
+
+// Get возвращает запрос по ID
+func (s *PromptStore) Get(id int64) (ScheduledPrompt, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.prompts {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return ScheduledPrompt{}, false
+}
+
+// All возвращает все запросы
+func (s *PromptStore) All() []ScheduledPrompt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ScheduledPrompt(nil), s.prompts...)
+}
+
+// List возвращает запросы пользователя по возрастанию ID
+func (s *PromptStore) List(userID int64) []ScheduledPrompt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []ScheduledPrompt
+	for _, p := range s.prompts {
+		if p.UserID == userID {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Delete удаляет запрос пользователя по ID
+func (s *PromptStore) Delete(userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, p := range s.prompts {
+		if p.ID == id && p.UserID == userID {
+			s.prompts = append(s.prompts[:i:i], s.prompts[i+1:]...)
+			return s.saveUnlocked()
+		}
+	}
+	return ErrPromptNotFound
+}
+
+// DeleteAll удаляет все запросы и часовой пояс пользователя, возвращает ID удалённых запросов
+func (s *PromptStore) DeleteAll(userID int64) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var removed []int64
+	kept := s.prompts[:0:0]
+	for _, p := range s.prompts {
+		if p.UserID == userID {
+			removed = append(removed, p.ID)
+			continue
+		}
+		kept = append(kept, p)
+	}
+	_, hasTZ := s.timezones[userID]
+	if len(removed) == 0 && !hasTZ {
+		return nil, nil
+	}
+	s.prompts = kept
+	delete(s.timezones, userID)
+	return removed, s.saveUnlocked()
+}
+
+// MarkRun запоминает время и ошибку последнего выполнения
+func (s *PromptStore) MarkRun(id int64, at time.Time, runErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.prompts {
+		if s.prompts[i].ID != id {
+			continue
+		}
+		s.prompts[i].LastRunAt = at
+		s.prompts[i].LastError = ""
+		if runErr != nil {
+			s.prompts[i].LastError = runErr.Error()
+		}
+		return s.saveUnlocked()
+	}
+	return nil
+}
+
+// Location часовой пояс пользователя или пояс по умолчанию
+func (s *PromptStore) Location(userID int64) *time.Location {
+	s.mu.Lock()
+	name := s.timezones[userID]
+	s.mu.Unlock()
+	if name == "" {
+		return s.defaultLoc
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return s.defaultLoc
+	}
+	return loc
+}
+
+// SetTimezone сохраняет часовой пояс пользователя и переводит на него его запросы; возвращает обновлённые запросы
+func (s *PromptStore) SetTimezone(userID int64, loc *time.Location) ([]ScheduledPrompt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timezones[userID] = loc.String()
+	var updated []ScheduledPrompt
+	for i := range s.prompts {
+		if s.prompts[i].UserID == userID {
+			s.prompts[i].Timezone = loc.String()
+			updated = append(updated, s.prompts[i])
+		}
+	}
+	return updated, s.saveUnlocked()
+}
+
+var (
+	scheduleClockRe = regexp.MustCompile(`^(\d{1,2})[:.](\d{2})$`)
+	cronFieldRe     = regexp.MustCompile(`^[\d*/,\-A-Za-z?]+$`)
+)
+
+// scheduleWeekdays дни недели для расписаний вида "every monday at 09:00" / "каждый понедельник в 09:00"
+var scheduleWeekdays = map[string]string{
+	"monday": "1", "mon": "1", "mondays": "1", "понедельник": "1", "понедельникам": "1", "пн": "1",
+	"tuesday": "2", "tue": "2", "tuesdays": "2", "вторник": "2", "вторникам": "2", "вт": "2",
+	"wednesday": "3", "wed": "3", "wednesdays": "3", "среду": "3", "среда": "3", "средам": "3", "ср": "3",
+	"thursday": "4", "thu": "4", "thursdays": "4", "четверг": "4", "четвергам": "4", "чт": "4",
+	"friday": "5", "fri": "5", "fridays": "5", "пятницу": "5", "пятница": "5", "пятницам": "5", "пт": "5",
+	"saturday": "6", "sat": "6", "saturdays": "6", "субботу": "6", "суббота": "6", "субботам": "6", "сб": "6",
+	"sunday": "0", "sun": "0", "sundays": "0", "воскресенье": "0", "воскресеньям": "0", "вс": "0",
+	"day": "*", "daily": "*", "день": "*", "ежедневно": "*",
+	"weekday": "1-5", "weekdays": "1-5", "будни": "1-5", "будням": "1-5",
+	"weekend": "0,6", "weekends": "0,6", "выходные": "0,6", "выходным": "0,6",
+}
+
+// scheduleFiller служебные слова, которые не влияют на расписание
+var scheduleFiller = map[string]bool{
+	"every": true, "each": true, "at": true, "on": true, "weekly": true,
+	"каждый": true, "каждую": true, "каждое": true, "в": true, "во": true, "по": true, "еженедельно": true,
+}
+
+// ParseScheduleSpec переводит расписание в cron-выражение из 5 полей. Поддерживаются:
+// "every day at 09:00", "daily 09:00", "каждый день в 09:00", "every monday at 10:30",
+// "weekly fri 18:00", "каждую пятницу в 18:00", "weekdays at 08:00", "по будням в 08:00",
+// готовые cron-выражения ("0 9 * * 1-5") и дескрипторы @daily/@weekly/@hourly
+func ParseScheduleSpec(spec string) (string, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return "", ErrScheduleSpec
+	}
+	if strings.HasPrefix(spec, "@") {
+		if _, err := cron.ParseStandard(spec); err != nil || strings.HasPrefix(spec, "@every") {
+			return "", fmt.Errorf("%w: %q", ErrScheduleSpec, spec)
+		}
+		return spec, nil
+	}
+
+	fields := strings.Fields(strings.ToLower(spec))
+	if len(fields) == 5 && isCronFields(fields) {
+		if _, err := cron.ParseStandard(spec); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrScheduleSpec, err)
+		}
+		return strings.Join(strings.Fields(spec), " "), nil
+	}
+
+	days := ""
+	hour, minute := -1, -1
+	for _, f := range fields {
+		f = strings.Trim(f, ",")
+		if scheduleFiller[f] {
+			continue
+		}
+		if d, ok := scheduleWeekdays[f]; ok && days == "" {
+			days = d
+			continue
+		}
+		if m := scheduleClockRe.FindStringSubmatch(f); m != nil && hour < 0 {
+			hour, _ = strconv.Atoi(m[1])
+			minute, _ = strconv.Atoi(m[2])
+			if hour > 23 || minute > 59 {
+				return "", fmt.Errorf("%w: invalid time %q", ErrScheduleSpec, f)
+			}
+			continue
+		}
+		return "", fmt.Errorf("%w: unexpected %q", ErrScheduleSpec, f)
+	}
+	if days == "" || hour < 0 {
+		return "", fmt.Errorf("%w: %q", ErrScheduleSpec, spec)
+	}
+	return fmt.Sprintf("%d %d * * %s", minute, hour, days), nil
+}
+
+func isCronFields(fields []string) bool {
+	for _, f := range fields {
+		if !cronFieldRe.MatchString(f) || scheduleClockRe.MatchString(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetScheduledPrompts подключает хранилище пользовательских запросов и функцию их выполнения
+func (s *Scheduler) SetScheduledPrompts(store *PromptStore, run func(ctx context.Context, p ScheduledPrompt) error) {
+	s.prompts = store
+	s.runPrompt = run
+}
+
+// schedulePromptsOnStart регистрирует в cron все сохранённые запросы
+func (s *Scheduler) schedulePromptsOnStart() {
+	if s.prompts == nil || s.runPrompt == nil {
+		return
+	}
+	for _, p := range s.prompts.All() {
+		if err := s.addPromptEntry(p); err != nil {
+			log.Printf("⚠️ Scheduled prompt %d of user %d not scheduled: %v", p.ID, p.UserID, err)
+		}
+	}
+}
+
+// addPromptEntry добавляет запрос в cron (повторная регистрация заменяет запись)
+func (s *Scheduler) addPromptEntry(p ScheduledPrompt) error {
+	s.promptMu.Lock()
+	defer s.promptMu.Unlock()
+	if s.promptEntries == nil {
+		s.promptEntries = make(map[int64]cron.EntryID)
+	}
+	if old, ok := s.promptEntries[p.ID]; ok {
+		s.cron.Remove(old)
+		delete(s.promptEntries, p.ID)
+	}
+	id := p.ID
+	entry, err := s.cron.AddFunc(p.cronSpec(), func() { s.RunScheduledPrompt(s.ctx, id) })
+	if err != nil {
+		return err
+	}
+	s.promptEntries[p.ID] = entry
+	return nil
+}
+
+func (s *Scheduler) removePromptEntry(id int64) {
+	s.promptMu.Lock()
+	defer s.promptMu.Unlock()
+	if entry, ok := s.promptEntries[id]; ok {
+		s.cron.Remove(entry)
+		delete(s.promptEntries, id)
+	}
+}
+
+// SchedulePrompt проверяет расписание, сохраняет запрос в часовом поясе пользователя и ставит его в cron
+func (s *Scheduler) SchedulePrompt(userID, chatID int64, spec, prompt string) (ScheduledPrompt, error) {
+	if s.prompts == nil {
+		return ScheduledPrompt{}, errors.New("scheduled prompts are not configured")
+	}
+	expr, err := ParseScheduleSpec(spec)
+	if err != nil {
+		return ScheduledPrompt{}, err
+	}
+	p, err := s.prompts.Add(ScheduledPrompt{
+		UserID:   userID,
+		ChatID:   chatID,
+		Spec:     strings.TrimSpace(spec),
+		Cron:     expr,
+		Timezone: s.prompts.Location(userID).String(),
+		Prompt:   strings.TrimSpace(prompt),
+	})
+	if err != nil {
+		return ScheduledPrompt{}, err
+	}
+	if err := s.addPromptEntry(p); err != nil {
+		_ = s.prompts.Delete(userID, p.ID)
+		return ScheduledPrompt{}, fmt.Errorf("%w: %v", ErrScheduleSpec, err)
+	}
+	log.Printf("🗓 Scheduled prompt %d for user %d: %s (%s)", p.ID, userID, p.Cron, p.Timezone)
+	return p, nil
+}
+
+// UnschedulePrompt удаляет запрос пользователя из хранилища и cron
+func (s *Scheduler) UnschedulePrompt(userID, id int64) error {
+	if s.prompts == nil {
+		return ErrPromptNotFound
+	}
+	if err := s.prompts.Delete(userID, id); err != nil {
+		return err
+	}
+	s.removePromptEntry(id)
+	return nil
+}
+
+// UnscheduleAll удаляет все запросы пользователя и его часовой пояс
+func (s *Scheduler) UnscheduleAll(userID int64) error {
+	if s.prompts == nil {
+		return nil
+	}
+	removed, err := s.prompts.DeleteAll(userID)
+	for _, id := range removed {
+		s.removePromptEntry(id)
+	}
+	return err
+}
+
+// UserPrompts запросы пользователя
+func (s *Scheduler) UserPrompts(userID int64) []ScheduledPrompt {
+	if s.prompts == nil {
+		return nil
+	}
+	return s.prompts.List(userID)
+}
+
+// UserLocation часовой пояс, в котором выполняются запросы пользователя
+func (s *Scheduler) UserLocation(userID int64) *time.Location {
+	if s.prompts == nil {
+		return time.UTC
+	}
+	return s.prompts.Location(userID)
+}
+
+// SetUserTimezone меняет часовой пояс пользователя и перепланирует его запросы
+func (s *Scheduler) SetUserTimezone(userID int64, loc *time.Location) error {
+	if s.prompts == nil {
+		return errors.New("scheduled prompts are not configured")
+	}
+	updated, err := s.prompts.SetTimezone(userID, loc)
+	if err != nil {
+		return err
+	}
+	for _, p := range updated {
+		if err := s.addPromptEntry(p); err != nil {
+			log.Printf("⚠️ Failed to reschedule prompt %d: %v", p.ID, err)
+		}
+	}
+	return nil
+}
+
+// RunScheduledPrompt выполняет запрос; если предыдущий запуск того же запроса ещё идёт, запуск пропускается
+func (s *Scheduler) RunScheduledPrompt(ctx context.Context, id int64) {
+	if s.prompts == nil || s.runPrompt == nil {
+		return
+	}
+	p, ok := s.prompts.Get(id)
+	if !ok {
+		return
+	}
+
+	s.promptMu.Lock()
+	if s.promptRunning == nil {
+		s.promptRunning = make(map[int64]bool)
+	}
+	if s.promptRunning[id] {
+		s.promptMu.Unlock()
+		log.Printf("⏭ Scheduled prompt %d of user %d skipped: previous run still active", id, p.UserID)
+		return
+	}
+	s.promptRunning[id] = true
+	s.promptMu.Unlock()
+	defer func() {
+		s.promptMu.Lock()
+		delete(s.promptRunning, id)
+		s.promptMu.Unlock()
+	}()
+
+	log.Printf("🗓 Running scheduled prompt %d for user %d", id, p.UserID)
+	runErr := s.runPrompt(ctx, p)
+	if runErr != nil {
+		log.Printf("❌ Scheduled prompt %d for user %d failed: %v", id, p.UserID, runErr)
+	}
+	if err := s.prompts.MarkRun(id, time.Now(), runErr); err != nil {
+		log.Printf("❌ Failed to save scheduled prompt %d state: %v", id, err)
+	}
+}
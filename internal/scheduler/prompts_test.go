@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseScheduleSpec(t *testing.T) {
+	cases := map[string]string{
+		"every day at 09:00":      "0 9 * * *",
+		"daily 9:30":              "30 9 * * *",
+		"каждый день в 09:00":     "0 9 * * *",
+		"every monday at 10:30":   "30 10 * * 1",
+		"weekly fri 18:00":        "0 18 * * 5",
+		"каждую пятницу в 18:00":  "0 18 * * 5",
+		"по будням в 08:00":       "0 8 * * 1-5",
+		"weekends at 11:15":       "15 11 * * 0,6",
+		"0 9 * * 1-5":             "0 9 * * 1-5",
+		"@daily":                  "@daily",
+		"  */15 8-18 * * MON-FRI": "*/15 8-18 * * MON-FRI",
+	}
+	for in, want := range cases {
+		got, err := ParseScheduleSpec(in)
+		if err != nil || got != want {
+			t.Errorf("ParseScheduleSpec(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "every day", "at 25:00 daily", "sometimes at 09:00", "@every 1m", "61 * * * *", "every day at 09:00 news"} {
+		if got, err := ParseScheduleSpec(bad); !errors.Is(err, ErrScheduleSpec) {
+			t.Errorf("ParseScheduleSpec(%q) = %q, %v; want ErrScheduleSpec", bad, got, err)
+		}
+	}
+}
+
+func TestPromptStore_LimitTimezoneAndPersistence(t *testing.T) {
+	path := t.TempDir() + "/prompts.json"
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	store, err := NewPromptStore(path, 2, time.UTC)
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := store.Add(ScheduledPrompt{UserID: 1, Cron: "0 9 * * *", Prompt: "news"}); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	if _, err := store.Add(ScheduledPrompt{UserID: 1, Cron: "0 9 * * *"}); !errors.Is(err, ErrPromptLimit) {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+	if _, err := store.Add(ScheduledPrompt{UserID: 2, Cron: "0 9 * * *"}); err != nil {
+		t.Fatalf("other user must not be limited: %v", err)
+	}
+	updated, err := store.SetTimezone(1, moscow)
+	if err != nil || len(updated) != 2 || updated[0].Timezone != "Europe/Moscow" {
+		t.Fatalf("SetTimezone: %+v, %v", updated, err)
+	}
+
+	reopened, err := NewPromptStore(path, 2, time.UTC)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if got := reopened.Location(1); got.String() != "Europe/Moscow" {
+		t.Fatalf("timezone not persisted: %s", got)
+	}
+	if got := reopened.Location(2); got != time.UTC {
+		t.Fatalf("default timezone expected, got %s", got)
+	}
+	next := reopened.List(1)[0].Next(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2025, 3, 1, 6, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Fatalf("next run in Moscow timezone: got %s, want %s", next.UTC(), want)
+	}
+	if err := reopened.Delete(2, 1); !errors.Is(err, ErrPromptNotFound) {
+		t.Fatalf("foreign prompt must not be deleted: %v", err)
+	}
+	removed, err := reopened.DeleteAll(1)
+	if err != nil || len(removed) != 2 || len(reopened.List(1)) != 0 {
+		t.Fatalf("DeleteAll: %v, %v", removed, err)
+	}
+}
+
+func TestScheduler_SkipsPromptWhilePreviousRunActive(t *testing.T) {
+	store, _ := NewPromptStore("", 0, time.UTC)
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	var runs int32
+	s := New()
+	defer s.Stop()
+	s.SetScheduledPrompts(store, func(ctx context.Context, p ScheduledPrompt) error {
+		atomic.AddInt32(&runs, 1)
+		started <- struct{}{}
+		<-release
+		return errors.New("boom")
+	})
+	p, err := s.SchedulePrompt(1, 1, "every day at 09:00", "digest")
+	if err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+	if len(s.cron.Entries()) != 1 {
+		t.Fatalf("prompt must be registered in cron")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.RunScheduledPrompt(context.Background(), p.ID)
+		close(done)
+	}()
+	<-started
+	s.RunScheduledPrompt(context.Background(), p.ID) // пропускается: первый запуск ещё идёт
+	close(release)
+	<-done
+	if got := atomic.LoadInt32(&runs); got != 1 {
+		t.Fatalf("expected a single run, got %d", got)
+	}
+	if got, _ := store.Get(p.ID); got.LastError != "boom" || got.LastRunAt.IsZero() {
+		t.Fatalf("run state not recorded: %+v", got)
+	}
+
+	if err := s.UnschedulePrompt(1, p.ID); err != nil || len(s.cron.Entries()) != 0 {
+		t.Fatalf("unschedule: %v, entries=%d", err, len(s.cron.Entries()))
+	}
+}
@@ -0,0 +1,337 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reminderMaxAttempts сколько раз пытаемся отправить напоминание, прежде чем удалить его
+const reminderMaxAttempts = 3
+
+var (
+	ErrReminderNotFound = errors.New("reminder not found")
+	ErrReminderTime     = errors.New("cannot parse reminder time")
+	ErrReminderInPast   = errors.New("reminder time is in the past")
+)
+
+// Reminder отложенное сообщение пользователю
+type Reminder struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	ChatID    int64     `json:"chat_id"`
+	Text      string    `json:"text"`
+	DueAt     time.Time `json:"due_at"`
+	CreatedAt time.Time `json:"created_at"`
+	Attempts  int       `json:"attempts,omitempty"`
+}
+
+type reminderFile struct {
+	NextID    int64      `json:"next_id"`
+	Reminders []Reminder `json:"reminders"`
+}
+
+// ReminderStore хранит напоминания в JSON файле, чтобы они переживали перезапуск
+type ReminderStore struct {
+	mu        sync.Mutex
+	path      string
+	nextID    int64
+	reminders []Reminder
+}
+
+// NewReminderStore загружает напоминания из файла (пустой путь — хранение только в памяти)
+func NewReminderStore(path string) (*ReminderStore, error) {
+	s := &ReminderStore{path: path, nextID: 1}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	var stored reminderFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("parse reminders: %w", err)
+	}
+	s.reminders = stored.Reminders
+	s.nextID = stored.NextID
+	for _, r := range s.reminders {
+		if r.ID >= s.nextID {
+			s.nextID = r.ID + 1
+		}
+	}
+	if s.nextID < 1 {
+		s.nextID = 1
+	}
+	return s, nil
+}
+
+func (s *ReminderStore) saveUnlocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(reminderFile{NextID: s.nextID, Reminders: s.reminders}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Add сохраняет новое напоминание и возвращает его с присвоенным ID
+func (s *ReminderStore) Add(r Reminder) (Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r.ID = s.nextID
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	s.nextID++
+	s.reminders = append(s.reminders, r)
+	if err := s.saveUnlocked(); err != nil {
+		s.reminders = s.reminders[:len(s.reminders)-1]
+		s.nextID--
+		return Reminder{}, err
+	}
+	return r, nil
+}
+
+// List возвращает напоминания пользователя, отсортированные по времени срабатывания
+func (s *ReminderStore) List(userID int64) []Reminder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Reminder
+	for _, r := range s.reminders {
+		if r.UserID == userID {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DueAt.Before(out[j].DueAt) })
+	return out
+}
+
+// Delete удаляет напоминание пользователя по ID
+func (s *ReminderStore) Delete(userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, r := range s.reminders {
+		if r.ID == id && r.UserID == userID {
+			s.reminders = append(s.reminders[:i:i], s.reminders[i+1:]...)
+			return s.saveUnlocked()
+		}
+	}
+	return ErrReminderNotFound
+}
+
+// DeleteAll удаляет все напоминания пользователя
+func (s *ReminderStore) DeleteAll(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.reminders[:0:0]
+	for _, r := range s.reminders {
+		if r.UserID != userID {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == len(s.reminders) {
+		return nil
+	}
+	s.reminders = kept
+	return s.saveUnlocked()
+}
+
+// Due возвращает напоминания, время которых наступило
+func (s *ReminderStore) Due(now time.Time) []Reminder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Reminder
+	for _, r := range s.reminders {
+		if !r.DueAt.After(now) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Complete удаляет отправленное напоминание
+func (s *ReminderStore) Complete(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, r := range s.reminders {
+		if r.ID == id {
+			s.reminders = append(s.reminders[:i:i], s.reminders[i+1:]...)
+			return s.saveUnlocked()
+		}
+	}
+	return nil
+}
+
+// Fail учитывает неудачную отправку; после reminderMaxAttempts напоминание удаляется.
+// Возвращает true, если напоминание удалено
+func (s *ReminderStore) Fail(id int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.reminders {
+		if s.reminders[i].ID != id {
+			continue
+		}
+		s.reminders[i].Attempts++
+		dropped := s.reminders[i].Attempts >= reminderMaxAttempts
+		if dropped {
+			s.reminders = append(s.reminders[:i:i], s.reminders[i+1:]...)
+		}
+		return dropped, s.saveUnlocked()
+	}
+	return false, nil
+}
+
+var (
+	relativeCompactRe = regexp.MustCompile(`^(\d+(?:\.\d+)?(?:d|h|m|s|д|ч|м|мин|с))+$`)
+	relativePartRe    = regexp.MustCompile(`(\d+(?:\.\d+)?)(мин|d|h|m|s|д|ч|м|с)`)
+	clockRe           = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+)
+
+// relativeUnits единицы измерения для записи вида "in 2 hours" / "через 10 минут"
+var relativeUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"с": time.Second, "сек": time.Second, "секунд": time.Second, "секунду": time.Second, "секунды": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"м": time.Minute, "мин": time.Minute, "минут": time.Minute, "минуту": time.Minute, "минуты": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"ч": time.Hour, "час": time.Hour, "часа": time.Hour, "часов": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"д": 24 * time.Hour, "день": 24 * time.Hour, "дня": 24 * time.Hour, "дней": 24 * time.Hour,
+}
+
+var dateTimeLayouts = []string{"2006-01-02 15:04", "02.01.2006 15:04"}
+
+// ParseReminderTime разбирает время в начале строки и возвращает момент срабатывания и остаток (текст).
+// Поддерживаются: "in 2h", "in 1h30m", "in 10 minutes", "через 2ч", "15:04" (сегодня или завтра),
+// "tomorrow 09:00"/"завтра 09:00", "2006-01-02 15:04", "2006-01-02T15:04", "02.01.2006 15:04".
+// Абсолютное время интерпретируется в часовом поясе now
+func ParseReminderTime(input string, now time.Time) (time.Time, string, error) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return time.Time{}, "", ErrReminderTime
+	}
+	rest := func(n int) string { return strings.Join(fields[n:], " ") }
+	first := strings.ToLower(fields[0])
+
+	// Относительное время
+	if first == "in" || first == "через" {
+		if len(fields) >= 2 {
+			if d, ok := parseCompactDuration(strings.ToLower(fields[1])); ok {
+				return now.Add(d), rest(2), nil
+			}
+		}
+		if len(fields) >= 3 {
+			n, err := strconv.ParseFloat(fields[1], 64)
+			unit, ok := relativeUnits[strings.ToLower(fields[2])]
+			if err == nil && ok && n > 0 {
+				return now.Add(time.Duration(n * float64(unit))), rest(3), nil
+			}
+		}
+		// "через час", "in an hour"
+		if len(fields) >= 2 {
+			word := strings.ToLower(fields[1])
+			if unit, ok := relativeUnits[word]; ok {
+				return now.Add(unit), rest(2), nil
+			}
+			if (word == "a" || word == "an") && len(fields) >= 3 {
+				if unit, ok := relativeUnits[strings.ToLower(fields[2])]; ok {
+					return now.Add(unit), rest(3), nil
+				}
+			}
+		}
+		return time.Time{}, "", ErrReminderTime
+	}
+
+	// Дата и время
+	if len(fields) >= 2 {
+		for _, layout := range dateTimeLayouts {
+			if t, err := time.ParseInLocation(layout, fields[0]+" "+fields[1], now.Location()); err == nil {
+				return checkFuture(t, now, rest(2))
+			}
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04", fields[0], now.Location()); err == nil {
+		return checkFuture(t, now, rest(1))
+	}
+
+	// "завтра 09:00" / "tomorrow 09:00"
+	if (first == "tomorrow" || first == "завтра") && len(fields) >= 2 {
+		if h, m, ok := parseClock(fields[1]); ok {
+			y, mo, d := now.AddDate(0, 0, 1).Date()
+			return time.Date(y, mo, d, h, m, 0, 0, now.Location()), rest(2), nil
+		}
+		return time.Time{}, "", ErrReminderTime
+	}
+
+	// "15:04" — ближайшее такое время
+	if h, m, ok := parseClock(fields[0]); ok {
+		y, mo, d := now.Date()
+		t := time.Date(y, mo, d, h, m, 0, 0, now.Location())
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, rest(1), nil
+	}
+
+	// Сокращённая запись без "in": "2h", "30m"
+	if d, ok := parseCompactDuration(first); ok {
+		return now.Add(d), rest(1), nil
+	}
+	return time.Time{}, "", ErrReminderTime
+}
+
+func parseCompactDuration(s string) (time.Duration, bool) {
+	if !relativeCompactRe.MatchString(s) {
+		return 0, false
+	}
+	var total time.Duration
+	for _, m := range relativePartRe.FindAllStringSubmatch(s, -1) {
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, false
+		}
+		total += time.Duration(n * float64(relativeUnits[m[2]]))
+	}
+	return total, total > 0
+}
+
+func parseClock(s string) (int, int, bool) {
+	m := clockRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0, false
+	}
+	h, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2])
+	if h > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return h, minute, true
+}
+
+func checkFuture(t, now time.Time, rest string) (time.Time, string, error) {
+	if !t.After(now) {
+		return time.Time{}, "", ErrReminderInPast
+	}
+	return t, rest, nil
+}
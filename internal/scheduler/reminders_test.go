@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseReminderTime(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		in   string
+		due  time.Time
+		text string
+	}{
+		{"in 2h позвонить маме", now.Add(2 * time.Hour), "позвонить маме"},
+		{"in 1h30m stand-up", now.Add(90 * time.Minute), "stand-up"},
+		{"in 10 minutes tea", now.Add(10 * time.Minute), "tea"},
+		{"через 3 дня оплатить", now.Add(72 * time.Hour), "оплатить"},
+		{"через час выйти", now.Add(time.Hour), "выйти"},
+		{"45m check oven", now.Add(45 * time.Minute), "check oven"},
+		{"18:30 ужин", time.Date(2025, 3, 1, 18, 30, 0, 0, time.UTC), "ужин"},
+		{"09:00 зарядка", time.Date(2025, 3, 2, 9, 0, 0, 0, time.UTC), "зарядка"},
+		{"завтра 09:15 врач", time.Date(2025, 3, 2, 9, 15, 0, 0, time.UTC), "врач"},
+		{"2025-03-05 10:00 отчёт", time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC), "отчёт"},
+		{"05.03.2025 10:00 отчёт", time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC), "отчёт"},
+		{"2025-03-05T10:00 отчёт", time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC), "отчёт"},
+	}
+	for _, c := range cases {
+		due, text, err := ParseReminderTime(c.in, now)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", c.in, err)
+			continue
+		}
+		if !due.Equal(c.due) || text != c.text {
+			t.Errorf("%q: got %s %q, want %s %q", c.in, due, text, c.due, c.text)
+		}
+	}
+
+	if _, _, err := ParseReminderTime("2025-02-01 10:00 old", now); !errors.Is(err, ErrReminderInPast) {
+		t.Errorf("expected past error, got %v", err)
+	}
+	for _, bad := range []string{"", "tomorrow", "in soon", "25:00 x", "hello world"} {
+		if _, _, err := ParseReminderTime(bad, now); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestReminderStore_PersistsAndDispatches(t *testing.T) {
+	path := t.TempDir() + "/reminders.json"
+	store, err := NewReminderStore(path)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	if _, err := store.Add(Reminder{UserID: 1, ChatID: 1, Text: "due", DueAt: past}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, err := store.Add(Reminder{UserID: 1, ChatID: 1, Text: "later", DueAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	// Перезапуск: напоминания читаются из файла, ID продолжаются
+	reopened, err := NewReminderStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if got := reopened.List(1); len(got) != 2 || got[0].Text != "due" {
+		t.Fatalf("unexpected reminders after reopen: %+v", got)
+	}
+	if r, _ := reopened.Add(Reminder{UserID: 2, Text: "x", DueAt: time.Now().Add(time.Hour)}); r.ID != 3 {
+		t.Fatalf("expected id 3, got %d", r.ID)
+	}
+
+	var sent []string
+	s := New()
+	s.SetReminders(reopened, func(_ context.Context, r Reminder) error {
+		sent = append(sent, r.Text)
+		return nil
+	})
+	s.DispatchDueReminders(context.Background())
+	if len(sent) != 1 || sent[0] != "due" {
+		t.Fatalf("unexpected sent reminders: %v", sent)
+	}
+	if got := reopened.List(1); len(got) != 1 || got[0].Text != "later" {
+		t.Fatalf("sent reminder must be removed: %+v", got)
+	}
+}
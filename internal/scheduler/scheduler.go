@@ -2,7 +2,9 @@ package scheduler
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -14,16 +16,41 @@ type Scheduler struct {
 	ctx        context.Context
 	cancel     context.CancelFunc
 	reportFunc func(ctx context.Context) error
+	reportSpec string
+
+	reminders    *ReminderStore
+	sendReminder func(ctx context.Context, r Reminder) error
+	dispatchMu   sync.Mutex
+
+	// пользовательские запросы по расписанию (/schedule)
+	prompts       *PromptStore
+	runPrompt     func(ctx context.Context, p ScheduledPrompt) error
+	promptMu      sync.Mutex
+	promptEntries map[int64]cron.EntryID
+	promptRunning map[int64]bool
+
+	jobs []job
+}
+
+// job периодическая служебная задача (очистка устаревших данных и т.п.)
+type job struct {
+	spec string
+	name string
+	run  func(ctx context.Context) error
 }
 
+// DefaultReportSchedule расписание ежедневного отчёта по умолчанию: 21:00 UTC
+const DefaultReportSchedule = "0 21 * * *"
+
 // New создает новый планировщик
 func New() *Scheduler {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Scheduler{
-		cron:   cron.New(cron.WithLocation(time.UTC)),
-		ctx:    ctx,
-		cancel: cancel,
+		cron:       cron.New(cron.WithLocation(time.UTC)),
+		ctx:        ctx,
+		cancel:     cancel,
+		reportSpec: DefaultReportSchedule,
 	}
 }
 
@@ -32,30 +59,124 @@ func (s *Scheduler) SetReportFunction(f func(ctx context.Context) error) {
 	s.reportFunc = f
 }
 
+// SetReportSchedule задаёт cron-расписание отчёта в UTC (например "0 9 * * *"); пустое — по умолчанию.
+// Корректность проверяется в Start
+func (s *Scheduler) SetReportSchedule(spec string) {
+	if spec == "" {
+		spec = DefaultReportSchedule
+	}
+	s.reportSpec = spec
+}
+
+// SetReminders подключает хранилище напоминаний и функцию их отправки
+func (s *Scheduler) SetReminders(store *ReminderStore, send func(ctx context.Context, r Reminder) error) {
+	s.reminders = store
+	s.sendReminder = send
+}
+
+// AddJob регистрирует служебную задачу с cron-расписанием (например "@every 10m")
+func (s *Scheduler) AddJob(spec, name string, f func(ctx context.Context) error) {
+	s.jobs = append(s.jobs, job{spec: spec, name: name, run: f})
+}
+
 // Start запускает планировщик
 func (s *Scheduler) Start() error {
-	if s.reportFunc == nil {
+	if s.reportFunc == nil && s.reminders == nil && s.prompts == nil && len(s.jobs) == 0 {
 		log.Println("⚠️ Report function not set, scheduler will not generate reports")
 		return nil
 	}
+	if err := s.validateSpecs(); err != nil {
+		return err
+	}
 
-	// Ежедневно в 21:00 UTC
-	_, err := s.cron.AddFunc("0 21 * * *", func() {
-		log.Println("🕘 Triggered daily report generation at 21:00 UTC")
-		if err := s.reportFunc(s.ctx); err != nil {
-			log.Printf("❌ Daily report generation failed: %v", err)
+	if s.reportFunc != nil {
+		_, err := s.cron.AddFunc(s.reportSpec, func() {
+			log.Printf("🕘 Triggered report generation (%s UTC)", s.reportSpec)
+			if err := s.reportFunc(s.ctx); err != nil {
+				log.Printf("❌ Daily report generation failed: %v", err)
+			}
+		})
+		if err != nil {
+			return err
 		}
-	})
+	} else {
+		log.Println("⚠️ Report function not set, scheduler will not generate reports")
+	}
 
-	if err != nil {
-		return err
+	if s.reminders != nil && s.sendReminder != nil {
+		// Напоминания проверяются раз в 30 секунд; просроченные за время простоя отправятся на первой проверке
+		if _, err := s.cron.AddFunc("@every 30s", func() { s.DispatchDueReminders(s.ctx) }); err != nil {
+			return err
+		}
+		go s.DispatchDueReminders(s.ctx)
+	}
+
+	s.schedulePromptsOnStart()
+
+	for _, j := range s.jobs {
+		j := j
+		if _, err := s.cron.AddFunc(j.spec, func() {
+			if err := j.run(s.ctx); err != nil {
+				log.Printf("❌ Scheduled job %s failed: %v", j.name, err)
+			}
+		}); err != nil {
+			return err
+		}
 	}
 
 	s.cron.Start()
-	log.Println("📅 Scheduler started - daily reports will be generated at 21:00 UTC")
+	if s.reportFunc != nil {
+		log.Printf("📅 Scheduler started - reports will be generated at %q UTC", s.reportSpec)
+	} else {
+		log.Println("📅 Scheduler started")
+	}
+	return nil
+}
+
+// validateSpecs проверяет расписания отчёта и служебных задач до регистрации, чтобы ошибка в одном
+// выражении не оставила планировщик запущенным наполовину
+func (s *Scheduler) validateSpecs() error {
+	if s.reportFunc != nil {
+		if _, err := cron.ParseStandard(s.reportSpec); err != nil {
+			return fmt.Errorf("invalid report schedule %q: %w", s.reportSpec, err)
+		}
+	}
+	for _, j := range s.jobs {
+		if _, err := cron.ParseStandard(j.spec); err != nil {
+			return fmt.Errorf("invalid schedule %q for job %s: %w", j.spec, j.name, err)
+		}
+	}
 	return nil
 }
 
+// DispatchDueReminders отправляет наступившие напоминания и удаляет их из хранилища
+func (s *Scheduler) DispatchDueReminders(ctx context.Context) {
+	if s.reminders == nil || s.sendReminder == nil {
+		return
+	}
+	// Не допускаем параллельных проходов, иначе напоминание может уйти дважды
+	s.dispatchMu.Lock()
+	defer s.dispatchMu.Unlock()
+	for _, r := range s.reminders.Due(time.Now()) {
+		if err := s.sendReminder(ctx, r); err != nil {
+			dropped, saveErr := s.reminders.Fail(r.ID)
+			if saveErr != nil {
+				log.Printf("❌ Failed to save reminder %d state: %v", r.ID, saveErr)
+			}
+			if dropped {
+				log.Printf("❌ Reminder %d for user %d dropped after %d attempts: %v", r.ID, r.UserID, reminderMaxAttempts, err)
+			} else {
+				log.Printf("⚠️ Failed to send reminder %d for user %d, will retry: %v", r.ID, r.UserID, err)
+			}
+			continue
+		}
+		if err := s.reminders.Complete(r.ID); err != nil {
+			log.Printf("❌ Failed to remove sent reminder %d: %v", r.ID, err)
+		}
+		log.Printf("⏰ Reminder %d sent to user %d", r.ID, r.UserID)
+	}
+}
+
 // Stop останавливает планировщик
 func (s *Scheduler) Stop() {
 	if s.cron != nil {
@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestStart_ValidatesSchedules(t *testing.T) {
+	noop := func(ctx context.Context) error { return nil }
+
+	s := New()
+	s.SetReportFunction(noop)
+	s.SetReportSchedule("0 25 * * *")
+	if err := s.Start(); err == nil || !strings.Contains(err.Error(), "invalid report schedule") {
+		t.Fatalf("expected report schedule error, got %v", err)
+	}
+	s.Stop()
+
+	s = New()
+	s.AddJob("@every 10m", "cleanup", noop)
+	s.AddJob("every day", "broken", noop)
+	if err := s.Start(); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("expected job schedule error, got %v", err)
+	}
+	if len(s.cron.Entries()) != 0 {
+		t.Fatalf("nothing must be registered when a schedule is invalid")
+	}
+	s.Stop()
+
+	s = New()
+	defer s.Stop()
+	s.SetReportFunction(noop)
+	s.SetReportSchedule("0 9 * * 1-5")
+	s.AddJob("@hourly", "cleanup", noop)
+	s.AddJob("*/5 * * * *", "sync", noop)
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if got := len(s.cron.Entries()); got != 3 {
+		t.Fatalf("expected report and two jobs, got %d entries", got)
+	}
+}
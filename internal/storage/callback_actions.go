@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CallbackAction отложенное действие, привязанное к inline-кнопке (подтверждение, отказ и т.п.)
+type CallbackAction struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Payload   string    `json:"payload,omitempty"`
+	OwnerID   int64     `json:"owner_id"`
+	ChatID    int64     `json:"chat_id,omitempty"`
+	Group     string    `json:"group,omitempty"` // Кнопки одного сообщения снимаются вместе
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired true, если срок действия кнопки истёк
+func (a CallbackAction) Expired(now time.Time) bool {
+	return !a.ExpiresAt.IsZero() && !now.Before(a.ExpiresAt)
+}
+
+// CallbackActionStore сохраняет действия inline-кнопок между перезапусками бота
+type CallbackActionStore interface {
+	LoadAll() ([]CallbackAction, error)
+	Save(action CallbackAction) error
+	Remove(ids ...string) error
+	// PurgeExpired удаляет просроченные действия и возвращает их количество
+	PurgeExpired(now time.Time) (int, error)
+}
+
+// FileCallbackActionStore хранит действия JSON-массивом в файле
+type FileCallbackActionStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileCallbackActionStore(path string) (*FileCallbackActionStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("ensure callback actions dir: %w", err)
+	}
+	return &FileCallbackActionStore{path: path}, nil
+}
+
+func (s *FileCallbackActionStore) LoadAll() ([]CallbackAction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadUnlocked()
+}
+
+func (s *FileCallbackActionStore) Save(action CallbackAction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	actions, err := s.loadUnlocked()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i, a := range actions {
+		if a.ID == action.ID {
+			actions[i] = action
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		actions = append(actions, action)
+	}
+	return s.saveUnlocked(actions)
+}
+
+func (s *FileCallbackActionStore) Remove(ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	actions, err := s.loadUnlocked()
+	if err != nil {
+		return err
+	}
+	drop := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		drop[id] = true
+	}
+	out := actions[:0]
+	for _, a := range actions {
+		if !drop[a.ID] {
+			out = append(out, a)
+		}
+	}
+	return s.saveUnlocked(out)
+}
+
+func (s *FileCallbackActionStore) PurgeExpired(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	actions, err := s.loadUnlocked()
+	if err != nil {
+		return 0, err
+	}
+	out := actions[:0]
+	for _, a := range actions {
+		if !a.Expired(now) {
+			out = append(out, a)
+		}
+	}
+	purged := len(actions) - len(out)
+	if purged == 0 {
+		return 0, nil
+	}
+	return purged, s.saveUnlocked(out)
+}
+
+func (s *FileCallbackActionStore) loadUnlocked() ([]CallbackAction, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read callback actions: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var actions []CallbackAction
+	if err := json.Unmarshal(data, &actions); err != nil {
+		return nil, fmt.Errorf("parse callback actions: %w", err)
+	}
+	return actions, nil
+}
+
+// saveUnlocked пишет файл через временный, чтобы сбой посреди записи не терял кнопки
+func (s *FileCallbackActionStore) saveUnlocked(actions []CallbackAction) error {
+	if actions == nil {
+		actions = []CallbackAction{}
+	}
+	data, err := json.MarshalIndent(actions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode callback actions: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write callback actions: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileCallbackActionStore_SaveRemovePurge(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "data", "callback_actions.json")
+	store, err := NewFileCallbackActionStore(p)
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	if actions, err := store.LoadAll(); err != nil || len(actions) != 0 {
+		t.Fatalf("expected empty store, got %v, %v", actions, err)
+	}
+
+	now := time.Now()
+	for _, a := range []CallbackAction{
+		{ID: "a", Type: "allowlist_approve", Payload: "1", OwnerID: 9, ExpiresAt: now.Add(time.Hour)},
+		{ID: "b", Type: "allowlist_deny", Payload: "1", OwnerID: 9, ExpiresAt: now.Add(time.Hour)},
+		{ID: "old", Type: "allowlist_deny", Payload: "2", OwnerID: 9, ExpiresAt: now.Add(-time.Minute)},
+	} {
+		if err := store.Save(a); err != nil {
+			t.Fatalf("save %s: %v", a.ID, err)
+		}
+	}
+
+	// Новый экземпляр читает то же состояние, как после перезапуска
+	reopened, _ := NewFileCallbackActionStore(p)
+	actions, err := reopened.LoadAll()
+	if err != nil || len(actions) != 3 || actions[0].Payload != "1" || actions[0].OwnerID != 9 {
+		t.Fatalf("unexpected restored actions: %+v, %v", actions, err)
+	}
+
+	purged, err := reopened.PurgeExpired(now)
+	if err != nil || purged != 1 {
+		t.Fatalf("purge: %d, %v", purged, err)
+	}
+	if err := reopened.Remove("a"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	actions, _ = store.LoadAll()
+	if len(actions) != 1 || actions[0].ID != "b" {
+		t.Fatalf("expected only b to remain, got %+v", actions)
+	}
+}
@@ -79,6 +79,23 @@ func (r *FileRecorder) LoadInteractions() ([]Event, error) {
 }
 
 func (r *FileRecorder) SetAllCanUse(userID int64, canUse bool) error {
+	return r.rewrite(func(ev Event) (Event, bool) {
+		if ev.UserID == userID {
+			ev.CanUse = &canUse
+		}
+		return ev, true
+	})
+}
+
+// DeleteUser удаляет все события пользователя из лога
+func (r *FileRecorder) DeleteUser(userID int64) error {
+	return r.rewrite(func(ev Event) (Event, bool) {
+		return ev, ev.UserID != userID
+	})
+}
+
+// rewrite перезаписывает лог, применяя fn к каждому событию; false — событие удаляется
+func (r *FileRecorder) rewrite(fn func(Event) (Event, bool)) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	// read all
@@ -99,10 +116,9 @@ func (r *FileRecorder) SetAllCanUse(userID int64, canUse bool) error {
 		if err := json.Unmarshal(line, &ev); err != nil {
 			continue
 		}
-		if ev.UserID == userID {
-			ev.CanUse = &canUse
+		if ev, keep := fn(ev); keep {
+			events = append(events, ev)
 		}
-		events = append(events, ev)
 	}
 	_ = f.Close()
 	if err := s.Err(); err != nil {
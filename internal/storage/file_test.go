@@ -41,3 +41,24 @@ func TestFileRecorder_AppendAndLoad(t *testing.T) {
 		t.Fatalf("file not written")
 	}
 }
+
+func TestFileRecorder_DeleteUser(t *testing.T) {
+	rec, err := NewFileRecorder(filepath.Join(t.TempDir(), "log.jsonl"))
+	if err != nil {
+		t.Fatalf("init recorder: %v", err)
+	}
+	_ = rec.AppendInteraction(Event{UserID: 1, UserMessage: "a"})
+	_ = rec.AppendInteraction(Event{UserID: 2, UserMessage: "b"})
+	_ = rec.AppendInteraction(Event{UserID: 1, UserMessage: "c"})
+
+	if err := rec.DeleteUser(1); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	events, err := rec.LoadInteractions()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(events) != 1 || events[0].UserID != 2 {
+		t.Fatalf("unexpected events after delete: %+v", events)
+	}
+}
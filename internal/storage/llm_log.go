@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LLMLogMessage сообщение запроса к LLM в журнале (содержимое уже обрезано)
+type LLMLogMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// LLMRequestLog запись журнала запросов к LLM (LLM_LOG_REQUESTS): что ушло модели и что вернулось
+type LLMRequestLog struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Model     string          `json:"model,omitempty"`
+	Messages  []LLMLogMessage `json:"messages"`
+	Response  string          `json:"response,omitempty"`
+	Tokens    int             `json:"tokens,omitempty"`
+	Duration  time.Duration   `json:"duration"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// LLMRequestRecorder is implemented by recorders that can keep the LLM request log next to the history.
+// RecentLLMRequests returns up to limit latest entries in chronological order.
+type LLMRequestRecorder interface {
+	AppendLLMRequest(entry LLMRequestLog) error
+	RecentLLMRequests(limit int) ([]LLMRequestLog, error)
+}
+
+// LLMLogPath файл журнала запросов рядом с логом истории: logs/log.jsonl -> logs/log.llm.jsonl
+func LLMLogPath(historyPath string) string {
+	ext := filepath.Ext(historyPath)
+	return strings.TrimSuffix(historyPath, ext) + ".llm" + ext
+}
+
+// AppendLLMRequest дописывает запись в отдельный файл LLMLogPath, чтобы не смешивать её с историей
+func (r *FileRecorder) AppendLLMRequest(entry LLMRequestLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, err := os.OpenFile(LLMLogPath(r.path), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open llm log: %w", err)
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(entry); err != nil {
+		return fmt.Errorf("encode llm log: %w", err)
+	}
+	return nil
+}
+
+func (r *FileRecorder) RecentLLMRequests(limit int) ([]LLMRequestLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, err := os.Open(LLMLogPath(r.path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open llm log: %w", err)
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	buf := make([]byte, 0, 1024*1024)
+	s.Buffer(buf, 10*1024*1024)
+	var entries []LLMRequestLog
+	for s.Scan() {
+		var entry LLMRequestLog
+		if err := json.Unmarshal(s.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+		if limit > 0 && len(entries) > limit {
+			entries = entries[1:]
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("scan llm log: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *SQLiteRecorder) AppendLLMRequest(entry LLMRequestLog) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode llm log: %w", err)
+	}
+	if _, err := r.db.Exec(`INSERT INTO llm_requests (created_at, data) VALUES (?, ?)`,
+		entry.Timestamp.UTC().Format(sqliteTimeLayout), string(data)); err != nil {
+		return fmt.Errorf("insert llm log: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteRecorder) RecentLLMRequests(limit int) ([]LLMRequestLog, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := r.db.Query(`SELECT data FROM llm_requests ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query llm log: %w", err)
+	}
+	defer rows.Close()
+	var entries []LLMRequestLog
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("scan llm log: %w", err)
+		}
+		var entry LLMRequestLog
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan llm log: %w", err)
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var _ LLMRequestRecorder = (*FileRecorder)(nil)
+var _ LLMRequestRecorder = (*SQLiteRecorder)(nil)
+
+func TestLLMRequestRecorders_RecentEntries(t *testing.T) {
+	dir := t.TempDir()
+	fr, err := NewFileRecorder(filepath.Join(dir, "logs", "log.jsonl"))
+	if err != nil {
+		t.Fatalf("file recorder: %v", err)
+	}
+	sr, err := NewSQLiteRecorder(filepath.Join(dir, "chatter.db"))
+	if err != nil {
+		t.Fatalf("sqlite recorder: %v", err)
+	}
+	defer sr.Close()
+
+	for name, rec := range map[string]LLMRequestRecorder{"file": fr, "sqlite": sr} {
+		for i := 1; i <= 3; i++ {
+			entry := LLMRequestLog{
+				Timestamp: time.Unix(int64(i), 0).UTC(),
+				Model:     "m",
+				Messages:  []LLMLogMessage{{Role: "user", Content: "q"}},
+				Response:  "a",
+				Tokens:    i,
+				Duration:  time.Duration(i) * time.Second,
+			}
+			if err := rec.AppendLLMRequest(entry); err != nil {
+				t.Fatalf("%s: append: %v", name, err)
+			}
+		}
+		got, err := rec.RecentLLMRequests(2)
+		if err != nil {
+			t.Fatalf("%s: recent: %v", name, err)
+		}
+		if len(got) != 2 || got[0].Tokens != 2 || got[1].Tokens != 3 || got[1].Duration != 3*time.Second {
+			t.Errorf("%s: expected the two latest entries in order, got %+v", name, got)
+		}
+	}
+
+	// Журнал запросов не попадает в историю
+	events, err := fr.LoadInteractions()
+	if err != nil || len(events) != 0 {
+		t.Fatalf("history must stay empty, got %d events, %v", len(events), err)
+	}
+	if p := LLMLogPath("logs/log.jsonl"); p != "logs/log.llm.jsonl" {
+		t.Errorf("unexpected llm log path %s", p)
+	}
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrReceiptNotFound чек с таким ID платежа не найден
+	ErrReceiptNotFound = errors.New("receipt not found")
+	// ErrAlreadyRefunded по чеку уже оформлен возврат
+	ErrAlreadyRefunded = errors.New("receipt already refunded")
+)
+
+// Receipt оплаченный пакет квоты; суммы — в минимальных единицах валюты (копейки, центы)
+type Receipt struct {
+	// ChargeID telegram_payment_charge_id — по нему оформляется возврат
+	ChargeID         string     `json:"charge_id"`
+	ProviderChargeID string     `json:"provider_charge_id,omitempty"`
+	UserID           int64      `json:"user_id"`
+	Username         string     `json:"username,omitempty"`
+	Package          string     `json:"package"`
+	Messages         int        `json:"messages"`
+	Amount           int        `json:"amount"`
+	Currency         string     `json:"currency"`
+	PaidAt           time.Time  `json:"paid_at"`
+	RefundedAt       *time.Time `json:"refunded_at,omitempty"`
+}
+
+// PaymentStore купленная квота сообщений пользователей вне allowlist и чеки для сверки
+type PaymentStore interface {
+	// Credit сохраняет чек и начисляет квоту; повтор с тем же ChargeID ничего не начисляет
+	Credit(r Receipt) (balance int, err error)
+	// Refund отмечает возврат и списывает квоту пакета (баланс не уходит ниже нуля)
+	Refund(chargeID string, at time.Time) (Receipt, int, error)
+	// Consume списывает одно сообщение; false — квота исчерпана
+	Consume(userID int64) (bool, error)
+	// Balance оставшиеся сообщения пользователя
+	Balance(userID int64) int
+	// Receipts чеки, оплаченные в from <= PaidAt < to, по времени оплаты
+	Receipts(from, to time.Time) ([]Receipt, error)
+}
+
+type paymentsFile struct {
+	Balances map[int64]int `json:"balances"`
+	Receipts []Receipt     `json:"receipts"`
+}
+
+// FilePaymentStore хранит балансы и чеки JSON-файлом; каждое изменение сразу пишется на диск,
+// чтобы оплата не потерялась при перезапуске
+type FilePaymentStore struct {
+	path string
+	mu   sync.Mutex
+	data paymentsFile
+}
+
+// NewFilePaymentStore загружает балансы и чеки из path
+func NewFilePaymentStore(path string) (*FilePaymentStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("ensure payments dir: %w", err)
+	}
+	s := &FilePaymentStore{path: path}
+	raw, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read payments: %w", err)
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &s.data); err != nil {
+			return nil, fmt.Errorf("parse payments: %w", err)
+		}
+	}
+	if s.data.Balances == nil {
+		s.data.Balances = make(map[int64]int)
+	}
+	return s, nil
+}
+
+func (s *FilePaymentStore) Credit(r Receipt) (int, error) {
+	if r.ChargeID == "" {
+		return 0, errors.New("receipt without charge id")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findReceipt(r.ChargeID) >= 0 {
+		return s.data.Balances[r.UserID], nil
+	}
+	s.data.Receipts = append(s.data.Receipts, r)
+	s.data.Balances[r.UserID] += r.Messages
+	if err := s.save(); err != nil {
+		s.data.Receipts = s.data.Receipts[:len(s.data.Receipts)-1]
+		s.data.Balances[r.UserID] -= r.Messages
+		return 0, err
+	}
+	return s.data.Balances[r.UserID], nil
+}
+
+func (s *FilePaymentStore) Refund(chargeID string, at time.Time) (Receipt, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.findReceipt(chargeID)
+	if i < 0 {
+		return Receipt{}, 0, ErrReceiptNotFound
+	}
+	r := s.data.Receipts[i]
+	if r.RefundedAt != nil {
+		return r, s.data.Balances[r.UserID], ErrAlreadyRefunded
+	}
+	prevBalance := s.data.Balances[r.UserID]
+	refundedAt := at.UTC()
+	s.data.Receipts[i].RefundedAt = &refundedAt
+	s.data.Balances[r.UserID] = max(prevBalance-r.Messages, 0)
+	if err := s.save(); err != nil {
+		s.data.Receipts[i].RefundedAt = nil
+		s.data.Balances[r.UserID] = prevBalance
+		return r, prevBalance, err
+	}
+	return s.data.Receipts[i], s.data.Balances[r.UserID], nil
+}
+
+func (s *FilePaymentStore) Consume(userID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.Balances[userID] <= 0 {
+		return false, nil
+	}
+	s.data.Balances[userID]--
+	if err := s.save(); err != nil {
+		s.data.Balances[userID]++
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *FilePaymentStore) Balance(userID int64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.Balances[userID]
+}
+
+func (s *FilePaymentStore) Receipts(from, to time.Time) ([]Receipt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Receipt
+	for _, r := range s.data.Receipts {
+		if !r.PaidAt.Before(from) && r.PaidAt.Before(to) {
+			out = append(out, r)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].PaidAt.Before(out[j].PaidAt) })
+	return out, nil
+}
+
+func (s *FilePaymentStore) findReceipt(chargeID string) int {
+	for i, r := range s.data.Receipts {
+		if r.ChargeID == chargeID {
+			return i
+		}
+	}
+	return -1
+}
+
+// save записывает файл целиком через временный файл; вызывается под s.mu
+func (s *FilePaymentStore) save() error {
+	for id, n := range s.data.Balances {
+		if n == 0 {
+			delete(s.data.Balances, id)
+		}
+	}
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode payments: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("write payments: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
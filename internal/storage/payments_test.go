@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFilePaymentStore_CreditConsumeRefund(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "data", "payments.json")
+	store, err := NewFilePaymentStore(p)
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+
+	paid := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	receipt := Receipt{ChargeID: "tg-1", UserID: 7, Package: "s", Messages: 2, Amount: 9900, Currency: "RUB", PaidAt: paid}
+	if balance, err := store.Credit(receipt); err != nil || balance != 2 {
+		t.Fatalf("credit = %d, %v; want 2", balance, err)
+	}
+	// Telegram может прислать successful_payment повторно — квота не удваивается
+	if balance, _ := store.Credit(receipt); balance != 2 {
+		t.Fatalf("duplicate charge must not be credited twice, balance %d", balance)
+	}
+	if ok, err := store.Consume(7); !ok || err != nil {
+		t.Fatalf("consume: %v, %v", ok, err)
+	}
+
+	// Новый экземпляр читает то же состояние, как после перезапуска
+	reopened, err := NewFilePaymentStore(p)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if reopened.Balance(7) != 1 {
+		t.Fatalf("balance after reopen = %d, want 1", reopened.Balance(7))
+	}
+
+	refunded, balance, err := reopened.Refund("tg-1", paid.Add(time.Hour))
+	if err != nil || balance != 0 || refunded.RefundedAt == nil {
+		t.Fatalf("refund must debit the package down to zero: %+v, %d, %v", refunded, balance, err)
+	}
+	if _, _, err := reopened.Refund("tg-1", paid); !errors.Is(err, ErrAlreadyRefunded) {
+		t.Errorf("second refund must fail, got %v", err)
+	}
+	if _, _, err := reopened.Refund("missing", paid); !errors.Is(err, ErrReceiptNotFound) {
+		t.Errorf("unknown charge must fail, got %v", err)
+	}
+	if ok, _ := reopened.Consume(7); ok {
+		t.Error("empty balance must not be consumed")
+	}
+
+	march, _ := reopened.Receipts(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC))
+	april, _ := reopened.Receipts(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC))
+	if len(march) != 1 || march[0].RefundedAt == nil || len(april) != 0 {
+		t.Errorf("receipts by month: march %+v, april %+v", march, april)
+	}
+}
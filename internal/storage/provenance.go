@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// provenanceDigestLen длина хешей в записи происхождения: достаточно, чтобы сверить вызов с логами
+const provenanceDigestLen = 12
+
+// ToolProvenance запись о вызове инструмента, результат которого видела модель при генерации ответа.
+// Аргументы и ответ не хранятся — только их хеши, чтобы аудит не дублировал данные интеграций
+type ToolProvenance struct {
+	Tool           string    `json:"tool"`
+	ArgsHash       string    `json:"args_hash"`
+	ResponseDigest string    `json:"response_digest"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// NewToolProvenance считает хеши аргументов (JSON с отсортированными ключами) и ответа инструмента
+func NewToolProvenance(tool string, args map[string]interface{}, response string, at time.Time) ToolProvenance {
+	data, err := json.Marshal(args)
+	if err != nil {
+		data = nil
+	}
+	return ToolProvenance{
+		Tool:           tool,
+		ArgsHash:       shortDigest(data),
+		ResponseDigest: shortDigest([]byte(response)),
+		Timestamp:      at.UTC(),
+	}
+}
+
+func shortDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:provenanceDigestLen]
+}
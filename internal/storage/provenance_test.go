@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewToolProvenance_HashesArgsAndResponse(t *testing.T) {
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.FixedZone("MSK", 3*3600))
+	p := NewToolProvenance("search_pages", map[string]interface{}{"query": "Reports", "limit": 5}, "found 1 page", at)
+	if p.Tool != "search_pages" || !p.Timestamp.Equal(at) || p.Timestamp.Location() != time.UTC {
+		t.Fatalf("unexpected provenance: %+v", p)
+	}
+	if len(p.ArgsHash) != provenanceDigestLen || len(p.ResponseDigest) != provenanceDigestLen {
+		t.Fatalf("digests must be truncated to %d chars: %+v", provenanceDigestLen, p)
+	}
+	// Порядок ключей аргументов не влияет на хеш, а другой ответ даёт другой digest
+	same := NewToolProvenance("search_pages", map[string]interface{}{"limit": 5, "query": "Reports"}, "found 2 pages", at)
+	if same.ArgsHash != p.ArgsHash {
+		t.Errorf("args hash must not depend on key order: %s vs %s", same.ArgsHash, p.ArgsHash)
+	}
+	if same.ResponseDigest == p.ResponseDigest {
+		t.Error("different responses must have different digests")
+	}
+}
@@ -0,0 +1,101 @@
+package storage
+
+import "time"
+
+// Filter условия выборки событий; нулевые поля не ограничивают выборку
+type Filter struct {
+	From   time.Time // From <= Timestamp
+	To     time.Time // Timestamp < To
+	UserID int64
+	ChatID int64
+	// Limit сколько последних событий вернуть (0 — все); события остаются в хронологическом порядке
+	Limit int
+}
+
+// Match проверяет событие на соответствие фильтру (без учёта Limit)
+func (f Filter) Match(ev Event) bool {
+	if !f.From.IsZero() && ev.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !ev.Timestamp.Before(f.To) {
+		return false
+	}
+	if f.UserID != 0 && ev.UserID != f.UserID {
+		return false
+	}
+	if f.ChatID != 0 && ev.ChatID != f.ChatID {
+		return false
+	}
+	return true
+}
+
+// Totals агрегаты по выборке событий
+type Totals struct {
+	Events            int     // записанных взаимодействий
+	UserMessages      int     // событий с сообщением пользователя
+	AssistantMessages int     // событий с ответом ассистента
+	Users             int     // различных пользователей
+	Tokens            int     // сумма токенов ответов
+	Cost              float64 // сумма стоимости ответов, USD
+}
+
+// Querier реализуют хранилища, которые умеют фильтровать и агрегировать события на своей стороне
+// («что обсуждал пользователь X на прошлой неделе», суточный отчёт)
+type Querier interface {
+	QueryInteractions(f Filter) ([]Event, error)
+	AggregateInteractions(f Filter) (Totals, error)
+}
+
+// Query события рекордера по фильтру в хронологическом порядке. Хранилища без Querier
+// (FileRecorder) читаются целиком и фильтруются в памяти
+func Query(rec Recorder, f Filter) ([]Event, error) {
+	if q, ok := rec.(Querier); ok {
+		return q.QueryInteractions(f)
+	}
+	events, err := rec.LoadInteractions()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Event, 0, len(events))
+	for _, ev := range events {
+		if f.Match(ev) {
+			out = append(out, ev)
+		}
+	}
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out, nil
+}
+
+// Aggregate агрегаты событий рекордера по фильтру (Limit не учитывается)
+func Aggregate(rec Recorder, f Filter) (Totals, error) {
+	if q, ok := rec.(Querier); ok {
+		return q.AggregateInteractions(f)
+	}
+	f.Limit = 0
+	events, err := Query(rec, f)
+	if err != nil {
+		return Totals{}, err
+	}
+	return totalsOf(events), nil
+}
+
+func totalsOf(events []Event) Totals {
+	var t Totals
+	users := make(map[int64]struct{})
+	for _, ev := range events {
+		t.Events++
+		if ev.UserMessage != "" {
+			t.UserMessages++
+		}
+		if ev.AssistantResponse != "" {
+			t.AssistantMessages++
+		}
+		users[ev.UserID] = struct{}{}
+		t.Tokens += ev.Tokens
+		t.Cost += ev.Cost
+	}
+	t.Users = len(users)
+	return t
+}
@@ -0,0 +1,352 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteTimeLayout фиксированной ширины: строки сравниваются так же, как время, и понятны функциям date() SQLite
+const sqliteTimeLayout = "2006-01-02 15:04:05.000"
+
+// sqliteMigrations применяются по порядку; номер последней применённой хранится в PRAGMA user_version
+var sqliteMigrations = []string{
+	`CREATE TABLE messages (
+		id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id           INTEGER NOT NULL,
+		user_id            INTEGER NOT NULL,
+		username           TEXT    NOT NULL DEFAULT '',
+		role               TEXT    NOT NULL,
+		content            TEXT    NOT NULL,
+		tokens             INTEGER NOT NULL DEFAULT 0,
+		cost               REAL    NOT NULL DEFAULT 0,
+		can_use            INTEGER,
+		mcp_function_calls TEXT    NOT NULL DEFAULT '',
+		created_at         TEXT    NOT NULL
+	);
+	CREATE INDEX idx_messages_created_at ON messages(created_at);
+	CREATE INDEX idx_messages_user_created_at ON messages(user_id, created_at);
+	CREATE INDEX idx_messages_event_id ON messages(event_id);`,
+	`ALTER TABLE messages ADD COLUMN chat_id INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE messages ADD COLUMN model TEXT NOT NULL DEFAULT '';
+	CREATE INDEX idx_messages_chat_created_at ON messages(chat_id, created_at);`,
+	// Журнал запросов к LLM (LLM_LOG_REQUESTS): запись LLMRequestLog целиком в JSON
+	`CREATE TABLE llm_requests (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at TEXT    NOT NULL,
+		data       TEXT    NOT NULL
+	);`,
+	// Происхождение ответа: ToolProvenance в JSON, пишется в строку ассистента
+	`ALTER TABLE messages ADD COLUMN provenance TEXT NOT NULL DEFAULT '';`,
+}
+
+// sqliteMessageColumns столбцы messages в порядке, который ожидает queryEvents
+const sqliteMessageColumns = `event_id, user_id, chat_id, username, role, content, tokens, cost, model, can_use, mcp_function_calls, created_at, provenance`
+
+// SQLiteRecorder хранит события в SQLite: одна строка messages на сообщение пользователя или ассистента,
+// строки одного Event связаны event_id
+type SQLiteRecorder struct {
+	db *sql.DB
+}
+
+// NewSQLiteRecorder открывает базу path и применяет недостающие миграции
+func NewSQLiteRecorder(path string) (*SQLiteRecorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to ensure db dir: %w", err)
+	}
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	// Одно соединение: SQLite всё равно сериализует запись, а так не бывает SQLITE_BUSY внутри процесса
+	db.SetMaxOpenConns(1)
+	if err := migrateSQLite(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &SQLiteRecorder{db: db}, nil
+}
+
+func migrateSQLite(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("begin migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("apply migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("set schema version %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (r *SQLiteRecorder) Close() error {
+	return r.db.Close()
+}
+
+func (r *SQLiteRecorder) AppendInteraction(event Event) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin append: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	var eventID int64
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(event_id), 0) + 1 FROM messages`).Scan(&eventID); err != nil {
+		return fmt.Errorf("next event id: %w", err)
+	}
+	if err := insertEvent(tx, eventID, event); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit append: %w", err)
+	}
+	return nil
+}
+
+// insertEvent пишет строки messages одного события
+func insertEvent(tx *sql.Tx, eventID int64, event Event) error {
+	calls := ""
+	if len(event.MCPFunctionCalls) > 0 {
+		data, err := json.Marshal(event.MCPFunctionCalls)
+		if err != nil {
+			return fmt.Errorf("encode mcp calls: %w", err)
+		}
+		calls = string(data)
+	}
+	provenance := ""
+	if len(event.Provenance) > 0 {
+		data, err := json.Marshal(event.Provenance)
+		if err != nil {
+			return fmt.Errorf("encode provenance: %w", err)
+		}
+		provenance = string(data)
+	}
+	var canUse sql.NullBool
+	if event.CanUse != nil {
+		canUse = sql.NullBool{Bool: *event.CanUse, Valid: true}
+	}
+	createdAt := event.Timestamp.UTC().Format(sqliteTimeLayout)
+
+	type row struct {
+		role, content, model, provenance string
+		tokens                           int
+		cost                             float64
+	}
+	// Токены, стоимость, модель и происхождение относятся к ответу, поэтому пишутся в строку ассистента
+	var rows []row
+	switch {
+	case event.UserMessage != "" && event.AssistantResponse != "":
+		rows = []row{{role: "user", content: event.UserMessage}, {role: "assistant", content: event.AssistantResponse, model: event.Model, provenance: provenance, tokens: event.Tokens, cost: event.Cost}}
+	case event.AssistantResponse != "":
+		rows = []row{{role: "assistant", content: event.AssistantResponse, model: event.Model, provenance: provenance, tokens: event.Tokens, cost: event.Cost}}
+	default:
+		rows = []row{{role: "user", content: event.UserMessage, model: event.Model, tokens: event.Tokens, cost: event.Cost}}
+	}
+	for _, rw := range rows {
+		if _, err := tx.Exec(`INSERT INTO messages (`+sqliteMessageColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			eventID, event.UserID, event.ChatID, event.Username, rw.role, rw.content, rw.tokens, rw.cost, rw.model, canUse, calls, createdAt, rw.provenance); err != nil {
+			return fmt.Errorf("insert message: %w", err)
+		}
+	}
+	return nil
+}
+
+// ImportFileLog однократно переносит JSONL лог FileRecorder в пустую базу. Если в базе уже есть
+// сообщения или файла нет, ничего не делает. Возвращает число перенесённых событий
+func (r *SQLiteRecorder) ImportFileLog(path string) (int, error) {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("stat log: %w", err)
+	}
+	var existing int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM messages`).Scan(&existing); err != nil {
+		return 0, fmt.Errorf("count messages: %w", err)
+	}
+	if existing > 0 {
+		return 0, nil
+	}
+	events, err := (&FileRecorder{path: path}).LoadInteractions()
+	if err != nil {
+		return 0, fmt.Errorf("read log: %w", err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	// Одна транзакция: при сбое база остаётся пустой и импорт повторится при следующем запуске
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin import: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	for i, ev := range events {
+		if err := insertEvent(tx, int64(i+1), ev); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit import: %w", err)
+	}
+	return len(events), nil
+}
+
+func (r *SQLiteRecorder) LoadInteractions() ([]Event, error) {
+	return r.queryEvents(`SELECT ` + sqliteMessageColumns + ` FROM messages ORDER BY event_id, id`)
+}
+
+// LoadInteractionsBetween события с from <= Timestamp < to; использует индекс по created_at
+func (r *SQLiteRecorder) LoadInteractionsBetween(from, to time.Time) ([]Event, error) {
+	return r.QueryInteractions(Filter{From: from, To: to})
+}
+
+// sqliteWhere условие WHERE для фильтра; все строки одного события совпадают по проверяемым столбцам
+func sqliteWhere(f Filter) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if !f.From.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, f.From.UTC().Format(sqliteTimeLayout))
+	}
+	if !f.To.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, f.To.UTC().Format(sqliteTimeLayout))
+	}
+	if f.UserID != 0 {
+		conds = append(conds, "user_id = ?")
+		args = append(args, f.UserID)
+	}
+	if f.ChatID != 0 {
+		conds = append(conds, "chat_id = ?")
+		args = append(args, f.ChatID)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// QueryInteractions события по фильтру в хронологическом порядке
+func (r *SQLiteRecorder) QueryInteractions(f Filter) ([]Event, error) {
+	where, args := sqliteWhere(f)
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		return r.queryEvents(`SELECT `+sqliteMessageColumns+` FROM messages WHERE event_id IN (
+			SELECT DISTINCT event_id FROM messages`+where+` ORDER BY event_id DESC LIMIT ?)
+			ORDER BY event_id, id`, args...)
+	}
+	return r.queryEvents(`SELECT `+sqliteMessageColumns+` FROM messages`+where+` ORDER BY event_id, id`, args...)
+}
+
+// AggregateInteractions считает события, пользователей, токены и стоимость по фильтру в SQL
+func (r *SQLiteRecorder) AggregateInteractions(f Filter) (Totals, error) {
+	where, args := sqliteWhere(f)
+	var t Totals
+	err := r.db.QueryRow(`SELECT COUNT(DISTINCT event_id),
+		COUNT(DISTINCT CASE WHEN role = 'user' AND content != '' THEN event_id END),
+		COUNT(DISTINCT CASE WHEN role = 'assistant' THEN event_id END),
+		COUNT(DISTINCT user_id), COALESCE(SUM(tokens), 0), COALESCE(SUM(cost), 0)
+		FROM messages`+where, args...).Scan(&t.Events, &t.UserMessages, &t.AssistantMessages, &t.Users, &t.Tokens, &t.Cost)
+	if err != nil {
+		return Totals{}, fmt.Errorf("aggregate messages: %w", err)
+	}
+	return t, nil
+}
+
+func (r *SQLiteRecorder) SetAllCanUse(userID int64, canUse bool) error {
+	if _, err := r.db.Exec(`UPDATE messages SET can_use = ? WHERE user_id = ?`, canUse, userID); err != nil {
+		return fmt.Errorf("update can_use: %w", err)
+	}
+	return nil
+}
+
+// DeleteUser удаляет все сообщения пользователя
+func (r *SQLiteRecorder) DeleteUser(userID int64) error {
+	if _, err := r.db.Exec(`DELETE FROM messages WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("delete user messages: %w", err)
+	}
+	return nil
+}
+
+// queryEvents собирает строки с одинаковым event_id обратно в Event
+func (r *SQLiteRecorder) queryEvents(query string, args ...interface{}) ([]Event, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query messages: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	lastEventID := int64(-1)
+	for rows.Next() {
+		var (
+			eventID, userID, chatID      int64
+			username, role, text, model  string
+			tokens                       int
+			cost                         float64
+			canUse                       sql.NullBool
+			calls, createdAt, provenance string
+		)
+		if err := rows.Scan(&eventID, &userID, &chatID, &username, &role, &text, &tokens, &cost, &model, &canUse, &calls, &createdAt, &provenance); err != nil {
+			return nil, fmt.Errorf("scan message: %w", err)
+		}
+		if eventID != lastEventID {
+			ts, err := time.ParseInLocation(sqliteTimeLayout, createdAt, time.UTC)
+			if err != nil {
+				return nil, fmt.Errorf("parse created_at %q: %w", createdAt, err)
+			}
+			ev := Event{Timestamp: ts, UserID: userID, ChatID: chatID, Username: username}
+			if canUse.Valid {
+				v := canUse.Bool
+				ev.CanUse = &v
+			}
+			if calls != "" {
+				if err := json.Unmarshal([]byte(calls), &ev.MCPFunctionCalls); err != nil {
+					return nil, fmt.Errorf("decode mcp calls: %w", err)
+				}
+			}
+			events = append(events, ev)
+			lastEventID = eventID
+		}
+		ev := &events[len(events)-1]
+		if role == "assistant" {
+			ev.AssistantResponse = text
+		} else {
+			ev.UserMessage = text
+		}
+		ev.Tokens += tokens
+		ev.Cost += cost
+		if model != "" {
+			ev.Model = model
+		}
+		if provenance != "" {
+			if err := json.Unmarshal([]byte(provenance), &ev.Provenance); err != nil {
+				return nil, fmt.Errorf("decode provenance: %w", err)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate messages: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var _ Recorder = (*SQLiteRecorder)(nil)
+var _ RangeLoader = (*SQLiteRecorder)(nil)
+var _ UserDeleter = (*SQLiteRecorder)(nil)
+var _ Querier = (*SQLiteRecorder)(nil)
+
+func TestSQLiteRecorder_AppendAndLoad(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "data", "chatter.db")
+	rec, err := NewSQLiteRecorder(p)
+	if err != nil {
+		t.Fatalf("init recorder: %v", err)
+	}
+
+	tru, f := true, false
+	events := []Event{
+		{Timestamp: time.Unix(1, 0).UTC(), UserID: 1, Username: "alice", UserMessage: "hi", CanUse: &tru},
+		{Timestamp: time.Unix(2, 0).UTC(), UserID: 1, AssistantResponse: "hello", Tokens: 42, Cost: 0.5, CanUse: &tru, MCPFunctionCalls: []string{"search_pages"},
+			Provenance: []ToolProvenance{NewToolProvenance("search_pages", map[string]interface{}{"query": "Reports"}, "found 1 page", time.Unix(2, 0))}},
+		{Timestamp: time.Unix(3, 0).UTC(), UserID: 2, UserMessage: "[tz_check]", AssistantResponse: "ok", CanUse: &f},
+		{Timestamp: time.Unix(4, 0).UTC(), UserID: 2, UserMessage: "legacy"},
+	}
+	for _, ev := range events {
+		if err := rec.AppendInteraction(ev); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	// Повторное открытие не должно заново применять миграции
+	rec, err = NewSQLiteRecorder(p)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer rec.Close()
+	loaded, err := rec.LoadInteractions()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, events) {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", loaded, events)
+	}
+
+	var rows int
+	if err := rec.db.QueryRow(`SELECT COUNT(*) FROM messages WHERE role = 'assistant' AND tokens = 42`).Scan(&rows); err != nil || rows != 1 {
+		t.Fatalf("assistant row must carry tokens: %d, %v", rows, err)
+	}
+}
+
+func TestSQLiteRecorder_RangeCanUseAndDelete(t *testing.T) {
+	rec, err := NewSQLiteRecorder(filepath.Join(t.TempDir(), "chatter.db"))
+	if err != nil {
+		t.Fatalf("init recorder: %v", err)
+	}
+	defer rec.Close()
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	_ = rec.AppendInteraction(Event{Timestamp: day.Add(-time.Millisecond), UserID: 1, UserMessage: "before"})
+	_ = rec.AppendInteraction(Event{Timestamp: day, UserID: 1, UserMessage: "start"})
+	_ = rec.AppendInteraction(Event{Timestamp: day.Add(23 * time.Hour), UserID: 2, UserMessage: "late"})
+	_ = rec.AppendInteraction(Event{Timestamp: day.Add(24 * time.Hour), UserID: 2, UserMessage: "next day"})
+
+	inDay, err := rec.LoadInteractionsBetween(day, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("range: %v", err)
+	}
+	if len(inDay) != 2 || inDay[0].UserMessage != "start" || inDay[1].UserMessage != "late" {
+		t.Fatalf("unexpected range result: %+v", inDay)
+	}
+
+	if err := rec.SetAllCanUse(1, false); err != nil {
+		t.Fatalf("set can_use: %v", err)
+	}
+	if err := rec.DeleteUser(2); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	all, _ := rec.LoadInteractions()
+	if len(all) != 2 {
+		t.Fatalf("user 2 must be deleted, got %+v", all)
+	}
+	for _, ev := range all {
+		if ev.UserID != 1 || ev.CanUse == nil || *ev.CanUse {
+			t.Fatalf("user 1 events must be excluded from context: %+v", ev)
+		}
+	}
+}
+
+func TestSQLiteRecorder_QueryAndAggregate(t *testing.T) {
+	rec, err := NewSQLiteRecorder(filepath.Join(t.TempDir(), "chatter.db"))
+	if err != nil {
+		t.Fatalf("init recorder: %v", err)
+	}
+	defer rec.Close()
+
+	week := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	_ = rec.AppendInteraction(Event{Timestamp: week.Add(-time.Hour), UserID: 1, ChatID: 10, UserMessage: "old"})
+	_ = rec.AppendInteraction(Event{Timestamp: week.Add(time.Hour), UserID: 1, ChatID: 10, UserMessage: "go generics?", AssistantResponse: "yes", Tokens: 100, Cost: 0.01, Model: "gpt-4o"})
+	_ = rec.AppendInteraction(Event{Timestamp: week.Add(2 * time.Hour), UserID: 2, ChatID: 20, UserMessage: "hi"})
+	_ = rec.AppendInteraction(Event{Timestamp: week.Add(3 * time.Hour), UserID: 1, ChatID: 10, AssistantResponse: "more", Tokens: 50, Cost: 0.02, Model: "claude"})
+
+	lastWeek := Filter{From: week, To: week.AddDate(0, 0, 7), UserID: 1}
+	events, err := Query(rec, lastWeek)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(events) != 2 || events[0].UserMessage != "go generics?" || events[0].Model != "gpt-4o" || events[0].ChatID != 10 || events[1].Model != "claude" {
+		t.Fatalf("unexpected query result: %+v", events)
+	}
+
+	latest, _ := Query(rec, Filter{Limit: 2})
+	if len(latest) != 2 || latest[0].UserMessage != "hi" || latest[1].AssistantResponse != "more" {
+		t.Fatalf("limit must keep the latest events in order: %+v", latest)
+	}
+
+	totals, err := Aggregate(rec, Filter{From: week})
+	if err != nil {
+		t.Fatalf("aggregate: %v", err)
+	}
+	want := Totals{Events: 3, UserMessages: 2, AssistantMessages: 2, Users: 2, Tokens: 150, Cost: 0.03}
+	if totals.Cost < 0.0299 || totals.Cost > 0.0301 {
+		t.Fatalf("unexpected cost: %v", totals.Cost)
+	}
+	totals.Cost = want.Cost
+	if totals != want {
+		t.Fatalf("aggregate mismatch: got %+v want %+v", totals, want)
+	}
+	// В памяти (FileRecorder) агрегаты совпадают с SQL
+	all, _ := Query(rec, Filter{From: week})
+	if mem := totalsOf(all); mem.Events != want.Events || mem.UserMessages != want.UserMessages || mem.Tokens != want.Tokens {
+		t.Fatalf("in-memory totals differ: %+v", mem)
+	}
+}
+
+func TestSQLiteRecorder_ImportFileLogOnce(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "log.jsonl")
+	fr, err := NewFileRecorder(logPath)
+	if err != nil {
+		t.Fatalf("file recorder: %v", err)
+	}
+	tru := true
+	events := []Event{
+		{Timestamp: time.Unix(10, 0).UTC(), UserID: 1, Username: "alice", UserMessage: "hi", CanUse: &tru},
+		{Timestamp: time.Unix(11, 0).UTC(), UserID: 1, AssistantResponse: "hello", Tokens: 7, MCPFunctionCalls: []string{"search_pages"}},
+	}
+	for _, ev := range events {
+		_ = fr.AppendInteraction(ev)
+	}
+
+	rec, err := NewSQLiteRecorder(filepath.Join(dir, "chatter.db"))
+	if err != nil {
+		t.Fatalf("init recorder: %v", err)
+	}
+	defer rec.Close()
+	if n, err := rec.ImportFileLog(filepath.Join(dir, "missing.jsonl")); n != 0 || err != nil {
+		t.Fatalf("missing log must be skipped: %d, %v", n, err)
+	}
+	n, err := rec.ImportFileLog(logPath)
+	if err != nil || n != 2 {
+		t.Fatalf("import: %d, %v", n, err)
+	}
+	loaded, _ := rec.LoadInteractions()
+	if !reflect.DeepEqual(loaded, events) {
+		t.Fatalf("imported events mismatch:\n got %+v\nwant %+v", loaded, events)
+	}
+	if n, err := rec.ImportFileLog(logPath); n != 0 || err != nil {
+		t.Fatalf("second import must be a no-op: %d, %v", n, err)
+	}
+}
@@ -9,6 +9,8 @@ import "time"
 type Event struct {
 	Timestamp         time.Time `json:"timestamp"`
 	UserID            int64     `json:"user_id"`
+	ChatID            int64     `json:"chat_id,omitempty"`
+	Username          string    `json:"username,omitempty"`
 	UserMessage       string    `json:"user_message"`
 	AssistantResponse string    `json:"assistant_response"`
 	// CanUse indicates whether this piece of content should be used in context.
@@ -16,6 +18,13 @@ type Event struct {
 	CanUse *bool `json:"can_use,omitempty"`
 	// MCPFunctionCalls tracks MCP function calls made during this interaction
 	MCPFunctionCalls []string `json:"mcp_function_calls,omitempty"`
+	// Tokens and Cost describe the LLM usage of the assistant response (zero when unknown)
+	Tokens int     `json:"tokens,omitempty"`
+	Cost   float64 `json:"cost,omitempty"`
+	// Model модель, сгенерировавшая ответ (пусто для сообщений пользователя и старых логов)
+	Model string `json:"model,omitempty"`
+	// Provenance вызовы инструментов, на результатах которых построен ответ ассистента
+	Provenance []ToolProvenance `json:"provenance,omitempty"`
 }
 
 // Recorder abstracts persistence of interaction events.
@@ -28,3 +37,14 @@ type Recorder interface {
 	LoadInteractions() ([]Event, error)
 	SetAllCanUse(userID int64, canUse bool) error
 }
+
+// RangeLoader is implemented by recorders that can load events of a time range without a full scan
+// (daily report). Events with from <= Timestamp < to are returned in chronological order.
+type RangeLoader interface {
+	LoadInteractionsBetween(from, to time.Time) ([]Event, error)
+}
+
+// UserDeleter is implemented by recorders that can erase all events of a user (/forget_me).
+type UserDeleter interface {
+	DeleteUser(userID int64) error
+}
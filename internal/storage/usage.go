@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// usageDayLayout ключ дня в счётчиках использования (UTC)
+const usageDayLayout = "2006-01-02"
+
+// UsageRetentionDays сколько дней хранятся счётчики использования
+const UsageRetentionDays = 90
+
+// UsageRecord число вызовов функции бота пользователем за день. Хранится только имя функции
+// ("/notion_search", "vibecoding:upload", "mcp:search_pages"), без содержимого сообщений
+type UsageRecord struct {
+	Day     string `json:"day"` // 2006-01-02, UTC
+	UserID  int64  `json:"user_id"`
+	Feature string `json:"feature"`
+	Count   int    `json:"count"`
+}
+
+// UsageStore агрегированные счётчики использования функций по пользователям и дням
+type UsageStore interface {
+	// AddUsage увеличивает счётчик; вызывается на горячем пути и не должен ходить на диск
+	AddUsage(at time.Time, userID int64, feature string)
+	// LoadUsage записи за дни from <= day < to
+	LoadUsage(from, to time.Time) ([]UsageRecord, error)
+}
+
+type usageKey struct {
+	day     string
+	userID  int64
+	feature string
+}
+
+// FileUsageStore держит счётчики в памяти и сохраняет их JSON-массивом в файл через Flush
+type FileUsageStore struct {
+	path   string
+	mu     sync.Mutex
+	counts map[usageKey]int
+	dirty  bool
+}
+
+// NewFileUsageStore загружает сохранённые счётчики из path
+func NewFileUsageStore(path string) (*FileUsageStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("ensure usage stats dir: %w", err)
+	}
+	s := &FileUsageStore{path: path, counts: make(map[usageKey]int)}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read usage stats: %w", err)
+	}
+	if len(data) > 0 {
+		var records []UsageRecord
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("parse usage stats: %w", err)
+		}
+		for _, r := range records {
+			s.counts[usageKey{day: r.Day, userID: r.UserID, feature: r.Feature}] += r.Count
+		}
+	}
+	return s, nil
+}
+
+func (s *FileUsageStore) AddUsage(at time.Time, userID int64, feature string) {
+	if feature == "" {
+		return
+	}
+	key := usageKey{day: at.UTC().Format(usageDayLayout), userID: userID, feature: feature}
+	s.mu.Lock()
+	s.counts[key]++
+	s.dirty = true
+	s.mu.Unlock()
+}
+
+func (s *FileUsageStore) LoadUsage(from, to time.Time) ([]UsageRecord, error) {
+	fromDay, toDay := from.UTC().Format(usageDayLayout), to.UTC().Format(usageDayLayout)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []UsageRecord
+	for k, n := range s.counts {
+		if k.day >= fromDay && k.day < toDay {
+			out = append(out, UsageRecord{Day: k.day, UserID: k.userID, Feature: k.feature, Count: n})
+		}
+	}
+	sortUsageRecords(out)
+	return out, nil
+}
+
+// Flush сохраняет изменившиеся счётчики и отбрасывает дни старше UsageRetentionDays
+func (s *FileUsageStore) Flush(now time.Time) error {
+	oldest := now.UTC().AddDate(0, 0, -UsageRetentionDays).Format(usageDayLayout)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k := range s.counts {
+		if k.day < oldest {
+			delete(s.counts, k)
+			s.dirty = true
+		}
+	}
+	if !s.dirty {
+		return nil
+	}
+
+	records := make([]UsageRecord, 0, len(s.counts))
+	for k, n := range s.counts {
+		records = append(records, UsageRecord{Day: k.day, UserID: k.userID, Feature: k.feature, Count: n})
+	}
+	sortUsageRecords(records)
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode usage stats: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write usage stats: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+func sortUsageRecords(records []UsageRecord) {
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.Feature != b.Feature {
+			return a.Feature < b.Feature
+		}
+		return a.UserID < b.UserID
+	})
+}
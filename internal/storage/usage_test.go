@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileUsageStore_CountsFlushesAndPrunes(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "data", "usage_stats.json")
+	store, err := NewFileUsageStore(p)
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	store.AddUsage(now, 1, "/stats")
+	store.AddUsage(now.Add(time.Hour), 1, "/stats")
+	store.AddUsage(now, 2, "mcp:search_pages")
+	store.AddUsage(now.AddDate(0, 0, -1), 1, "/stats")
+	store.AddUsage(now.AddDate(0, 0, -UsageRetentionDays-1), 1, "/old")
+	store.AddUsage(now, 1, "")
+
+	if err := store.Flush(now); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	// Новый экземпляр читает то же состояние, как после перезапуска
+	reopened, err := NewFileUsageStore(p)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	records, err := reopened.LoadUsage(now.AddDate(0, 0, -UsageRetentionDays-5), now.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	want := []UsageRecord{
+		{Day: "2026-03-09", UserID: 1, Feature: "/stats", Count: 1},
+		{Day: "2026-03-10", UserID: 1, Feature: "/stats", Count: 2},
+		{Day: "2026-03-10", UserID: 2, Feature: "mcp:search_pages", Count: 1},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("records = %+v, want %+v", records, want)
+	}
+	for i := range want {
+		if records[i] != want[i] {
+			t.Errorf("record %d = %+v, want %+v", i, records[i], want[i])
+		}
+	}
+
+	today, _ := reopened.LoadUsage(now.Truncate(24*time.Hour), now.AddDate(0, 0, 1))
+	if len(today) != 2 {
+		t.Errorf("range must exclude other days, got %+v", today)
+	}
+}
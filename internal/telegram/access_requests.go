@@ -0,0 +1,253 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/pending"
+)
+
+const (
+	// defaultAccessRequestTTL через сколько необработанная заявка на доступ удаляется
+	defaultAccessRequestTTL = 7 * 24 * time.Hour
+	// accessRequestPreviewRunes длина первого сообщения в уведомлении администратору и /pending
+	accessRequestPreviewRunes = 200
+)
+
+// accessRequestState результат обращения пользователя не из allowlist
+type accessRequestState int
+
+const (
+	accessRequestCreated accessRequestState = iota
+	accessRequestDuplicate
+	accessRequestBanned
+)
+
+// SetAccessRequestTTL срок жизни заявки на доступ (PENDING_REQUEST_TTL_DAYS); 0 — заявки не истекают
+func (b *Bot) SetAccessRequestTTL(ttl time.Duration) {
+	b.accessRequestTTL = ttl
+}
+
+// loadAccessRequests восстанавливает заявки и блокировки из репозитория при старте
+func (b *Bot) loadAccessRequests() {
+	if b.pendingRepo == nil {
+		return
+	}
+	items, err := b.pendingRepo.Requests()
+	if err != nil {
+		log.Printf("⚠️ Failed to load access requests: %v", err)
+		return
+	}
+	now := b.nowUTC()
+	for _, req := range items {
+		if req.Banned {
+			b.markBanned(req.User)
+			continue
+		}
+		// У заявок старого формата нет времени: срок отсчитывается от запуска
+		if req.RequestedAt.IsZero() {
+			req.RequestedAt = now
+		}
+		b.rememberAccessRequest(req)
+	}
+}
+
+func (b *Bot) rememberAccessRequest(req pending.Request) {
+	if b.pendingMeta == nil {
+		b.pendingMeta = make(map[int64]pending.Request)
+	}
+	b.pending[req.ID] = req.User
+	b.pendingMeta[req.ID] = req
+}
+
+func (b *Bot) forgetAccessRequest(id int64) {
+	delete(b.pending, id)
+	delete(b.pendingMeta, id)
+	if b.pendingRepo != nil {
+		_ = b.pendingRepo.Remove(id)
+	}
+}
+
+func (b *Bot) markBanned(u auth.User) {
+	if b.banned == nil {
+		b.banned = make(map[int64]auth.User)
+	}
+	b.banned[u.ID] = u
+}
+
+// requestAccess сохраняет заявку пользователя не из allowlist. Повторные обращения не создают
+// новую заявку, обращения заблокированных игнорируются. Администратора уведомляет вызывающий
+// после ответа пользователю (notifyAdminRequest) — только для accessRequestCreated
+func (b *Bot) requestAccess(from *tgbotapi.User, firstMessage string) accessRequestState {
+	b.expireAccessRequests(b.nowUTC())
+	if _, ok := b.banned[from.ID]; ok {
+		log.Printf("🚫 Ignoring message from banned user %d (@%s)", from.ID, from.UserName)
+		return accessRequestBanned
+	}
+	if _, ok := b.pending[from.ID]; ok {
+		return accessRequestDuplicate
+	}
+	req := pending.Request{
+		User:         auth.User{ID: from.ID, Username: from.UserName, FirstName: from.FirstName, LastName: from.LastName},
+		FirstMessage: firstMessage,
+		RequestedAt:  b.nowUTC(),
+	}
+	if b.pendingRepo != nil {
+		stored, created, err := b.pendingRepo.Add(req)
+		switch {
+		case err != nil:
+			log.Printf("⚠️ Failed to store access request of %d: %v", from.ID, err)
+		case stored.Banned:
+			b.markBanned(stored.User)
+			return accessRequestBanned
+		case !created:
+			b.rememberAccessRequest(stored)
+			return accessRequestDuplicate
+		}
+	}
+	b.rememberAccessRequest(req)
+	return accessRequestCreated
+}
+
+// expireAccessRequests удаляет заявки старше accessRequestTTL; пользователь сможет отправить новую
+func (b *Bot) expireAccessRequests(now time.Time) int {
+	if b.accessRequestTTL <= 0 {
+		return 0
+	}
+	expired := 0
+	for id, req := range b.pendingMeta {
+		if req.RequestedAt.IsZero() || now.Sub(req.RequestedAt) < b.accessRequestTTL {
+			continue
+		}
+		b.forgetAccessRequest(id)
+		expired++
+	}
+	if expired > 0 {
+		log.Printf("⌛ Expired %d stale access requests", expired)
+	}
+	return expired
+}
+
+// banUser отклоняет заявку и блокирует пользователя: новые заявки от него не принимаются
+func (b *Bot) banUser(id int64) {
+	u, ok := b.pending[id]
+	if !ok {
+		return
+	}
+	delete(b.pending, id)
+	delete(b.pendingMeta, id)
+	b.markBanned(u)
+	if b.pendingRepo != nil {
+		if err := b.pendingRepo.Ban(u); err != nil {
+			log.Printf("⚠️ Failed to store ban of %d: %v", id, err)
+		}
+	}
+	b.sendMessage(b.adminUserID, fmt.Sprintf("Пользователь @%s (%d) заблокирован, новые заявки от него не принимаются. Снять блокировку: /unban %d", u.Username, u.ID, u.ID))
+}
+
+// unbanUser снимает блокировку; false — пользователь не был заблокирован
+func (b *Bot) unbanUser(id int64) bool {
+	if _, ok := b.banned[id]; !ok {
+		return false
+	}
+	delete(b.banned, id)
+	if b.pendingRepo != nil {
+		_ = b.pendingRepo.Remove(id)
+	}
+	return true
+}
+
+// accessRequestDescription пользователь и первое сообщение заявки
+func (b *Bot) accessRequestDescription(id int64) string {
+	u := b.pending[id]
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	text := fmt.Sprintf("@%s (%d)", u.Username, id)
+	if name != "" {
+		text += " " + name
+	}
+	if first := strings.TrimSpace(b.pendingMeta[id].FirstMessage); first != "" {
+		text += fmt.Sprintf("\nПервое сообщение: «%s»", truncateRunes(first, accessRequestPreviewRunes))
+	}
+	return text
+}
+
+// handleAccessAdminCommand /pending, /ban и /unban; false — команда не относится к заявкам
+func (b *Bot) handleAccessAdminCommand(msg *tgbotapi.Message) bool {
+	switch msg.Command() {
+	case "pending":
+		b.sendMessage(msg.Chat.ID, b.pendingListText(b.nowUTC()))
+	case "ban", "unban":
+		args := strings.Fields(msg.CommandArguments())
+		if len(args) != 1 {
+			b.sendMessage(msg.Chat.ID, fmt.Sprintf("Usage: /%s <user_id>", msg.Command()))
+			return true
+		}
+		uid, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			b.sendMessage(msg.Chat.ID, "Некорректный user_id")
+			return true
+		}
+		if msg.Command() == "ban" {
+			if _, ok := b.pending[uid]; !ok {
+				b.sendMessage(msg.Chat.ID, fmt.Sprintf("Заявки от %d нет", uid))
+				return true
+			}
+			b.banUser(uid)
+			return true
+		}
+		if !b.unbanUser(uid) {
+			b.sendMessage(msg.Chat.ID, fmt.Sprintf("Пользователь %d не заблокирован", uid))
+			return true
+		}
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("Блокировка пользователя %d снята", uid))
+	default:
+		return false
+	}
+	return true
+}
+
+// pendingListText список ожидающих заявок для /pending, старые сначала
+func (b *Bot) pendingListText(now time.Time) string {
+	b.expireAccessRequests(now)
+	if len(b.pending) == 0 {
+		return "Pending заявок нет"
+	}
+	ids := make([]int64, 0, len(b.pending))
+	for id := range b.pending {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		ti, tj := b.pendingMeta[ids[i]].RequestedAt, b.pendingMeta[ids[j]].RequestedAt
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return ids[i] < ids[j]
+	})
+	var bld strings.Builder
+	bld.WriteString(fmt.Sprintf("Pending заявки (%d):\n", len(ids)))
+	for _, id := range ids {
+		bld.WriteString("- " + b.accessRequestDescription(id))
+		if at := b.pendingMeta[id].RequestedAt; !at.IsZero() {
+			bld.WriteString(fmt.Sprintf("\n  %s назад", now.Sub(at).Truncate(time.Minute)))
+		}
+		bld.WriteString("\n")
+	}
+	bld.WriteString("\nОдобрить: /approve <id>, отклонить: /deny <id>, заблокировать: /ban <id>")
+	return bld.String()
+}
+
+// truncateRunes обрезает строку до limit символов, не разрывая UTF-8
+func truncateRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + "…"
+}
@@ -0,0 +1,122 @@
+package telegram
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/history"
+	"ai-chatter/internal/pending"
+)
+
+func adminButton(data string) *tgbotapi.CallbackQuery {
+	return &tgbotapi.CallbackQuery{ID: "cb", Data: data, From: &tgbotapi.User{ID: 1}}
+}
+
+func strangerMessage(text string) *tgbotapi.Message {
+	return &tgbotapi.Message{From: &tgbotapi.User{ID: 50, UserName: "stranger", FirstName: "Иван"}, Chat: &tgbotapi.Chat{ID: 50}, Text: text}
+}
+
+func accessRequestBot(t *testing.T) (*Bot, *fakeSender, *pending.FileRepository) {
+	t.Helper()
+	repo, err := pending.NewFileRepository(filepath.Join(t.TempDir(), "pending.json"))
+	if err != nil {
+		t.Fatalf("pending repo: %v", err)
+	}
+	svc, _ := auth.NewWithRepo(nil, []int64{1})
+	fs := &fakeSender{}
+	b := &Bot{s: fs, authSvc: svc, adminUserID: 1, pending: make(map[int64]auth.User), pendingRepo: repo,
+		history: history.NewManager(), accessRequestTTL: defaultAccessRequestTTL}
+	b.SetCallbackActions(nil, time.Hour)
+	return b, fs, repo
+}
+
+func TestAccessRequest_StoredOnceAndApproved(t *testing.T) {
+	b, fs, repo := accessRequestBot(t)
+
+	b.handleIncomingMessage(context.Background(), strangerMessage("Можно доступ к боту?"))
+	approve := findButton(t, fs.markup, "разрешить")
+	b.handleIncomingMessage(context.Background(), strangerMessage("ну пожалуйста"))
+
+	requests, _ := repo.Requests()
+	if len(requests) != 1 || requests[0].FirstMessage != "Можно доступ к боту?" || requests[0].RequestedAt.IsZero() {
+		t.Fatalf("expected a single stored request with the first message, got %+v", requests)
+	}
+	notices := 0
+	for _, text := range fs.sent {
+		if strings.Contains(text, "Заявка на доступ") {
+			notices++
+			if !strings.Contains(text, "Можно доступ к боту?") {
+				t.Errorf("admin notice must quote the first message: %q", text)
+			}
+		}
+	}
+	if notices != 1 {
+		t.Fatalf("admin must be notified once, got %d notices in %q", notices, fs.sent)
+	}
+
+	b.handleCallback(context.Background(), adminButton(approve))
+	if !b.authSvc.IsAllowed(50) {
+		t.Fatal("approved user must be in allowlist")
+	}
+	if requests, _ := repo.Requests(); len(requests) != 0 {
+		t.Errorf("approved request must be removed, got %+v", requests)
+	}
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "доступ к боту подтвержден") {
+		t.Errorf("user must be notified about approval, got %q", last)
+	}
+}
+
+func TestAccessRequest_BanIgnoresFurtherRequests(t *testing.T) {
+	b, fs, repo := accessRequestBot(t)
+	b.handleIncomingMessage(context.Background(), strangerMessage("hi"))
+	b.handleCallback(context.Background(), adminButton(findButton(t, fs.markup, "заблокировать")))
+
+	sent := len(fs.sent)
+	b.handleIncomingMessage(context.Background(), strangerMessage("hi again"))
+	if len(fs.sent) != sent {
+		t.Fatalf("banned user must be ignored, got %q", fs.sent[sent:])
+	}
+	if requests, _ := repo.Requests(); len(requests) != 1 || !requests[0].Banned {
+		t.Fatalf("ban must be persisted, got %+v", requests)
+	}
+
+	// После перезапуска блокировка восстанавливается из репозитория
+	restarted := &Bot{s: fs, pending: make(map[int64]auth.User), pendingRepo: repo}
+	restarted.loadAccessRequests()
+	if _, ok := restarted.banned[50]; !ok || len(restarted.pending) != 0 {
+		t.Fatalf("ban must survive restart: banned=%v pending=%v", restarted.banned, restarted.pending)
+	}
+
+	b.handleCommand(newAdminCmd("/unban 50"))
+	b.handleIncomingMessage(context.Background(), strangerMessage("hi after unban"))
+	if _, ok := b.pending[50]; !ok {
+		t.Fatal("unbanned user must be able to request access again")
+	}
+}
+
+func TestAccessRequest_ExpiresAndListsPending(t *testing.T) {
+	b, fs, repo := accessRequestBot(t)
+	b.handleIncomingMessage(context.Background(), strangerMessage("старая заявка"))
+	b.pendingMeta[50] = pending.Request{User: b.pending[50], FirstMessage: "старая заявка", RequestedAt: time.Now().UTC().Add(-8 * 24 * time.Hour)}
+
+	b.handleCommand(newAdminCmd("/pending"))
+	if last := fs.sent[len(fs.sent)-1]; last != "Pending заявок нет" {
+		t.Fatalf("stale request must expire before listing, got %q", last)
+	}
+	if requests, _ := repo.Requests(); len(requests) != 0 {
+		t.Fatalf("expired request must be removed from the repository, got %+v", requests)
+	}
+
+	b.handleIncomingMessage(context.Background(), strangerMessage("новая заявка"))
+	b.handleCommand(newAdminCmd("/pending"))
+	last := fs.sent[len(fs.sent)-1]
+	if !strings.Contains(last, "@stranger (50) Иван") || !strings.Contains(last, "«новая заявка»") {
+		t.Errorf("unexpected /pending output: %q", last)
+	}
+}
@@ -0,0 +1,58 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleAllowlistAdminCommand /admin_allow <user_id> и /admin_deny <user_id> — изменение allowlist без
+// перезапуска: файл перезаписывается сразу, проверка доступа учитывает изменение со следующего сообщения.
+// Вызывается только для администратора
+func (b *Bot) handleAllowlistAdminCommand(msg *tgbotapi.Message) {
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) != 1 {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("Usage: /%s <user_id>", msg.Command()))
+		return
+	}
+	uid, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || uid <= 0 {
+		b.sendMessage(msg.Chat.ID, "Некорректный user_id")
+		return
+	}
+
+	if msg.Command() == "admin_allow" {
+		// У пользователя с заявкой есть имя: одобряем заявку, он получит приветствие
+		if _, ok := b.pending[uid]; ok && !b.authSvc.IsAllowed(uid) {
+			b.approveUser(uid)
+			return
+		}
+		added, err := b.authSvc.Allow(uid)
+		if err != nil {
+			b.sendMessage(msg.Chat.ID, fmt.Sprintf("Ошибка добавления: %v", err))
+			return
+		}
+		if !added {
+			b.sendMessage(msg.Chat.ID, fmt.Sprintf("Пользователь %d уже в allowlist", uid))
+			return
+		}
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("Пользователь %d добавлен в allowlist", uid))
+		return
+	}
+
+	if uid == b.adminUserID {
+		b.sendMessage(msg.Chat.ID, "Нельзя удалить администратора из allowlist")
+		return
+	}
+	if !b.authSvc.IsAllowed(uid) {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("Пользователя %d нет в allowlist", uid))
+		return
+	}
+	if err := b.authSvc.Remove(uid); err != nil {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("Ошибка удаления: %v", err))
+		return
+	}
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("Пользователь %d удален из allowlist", uid))
+}
@@ -0,0 +1,75 @@
+package telegram
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ai-chatter/internal/auth"
+)
+
+func TestAdminAllowDeny_UpdatesAllowlistWithoutRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist.json")
+	repo, err := auth.NewFileRepository(path)
+	if err != nil {
+		t.Fatalf("repo: %v", err)
+	}
+	svc, _ := auth.NewWithRepo(repo, nil)
+	fs := &fakeSender{}
+	b := &Bot{s: fs, adminUserID: 1, authSvc: svc}
+
+	b.handleCommand(newAdminCmd("/admin_allow 42"))
+	if !svc.IsAllowed(42) || !strings.Contains(fs.sent[len(fs.sent)-1], "добавлен") {
+		t.Fatalf("user must be allowed immediately, replies %q", fs.sent)
+	}
+	reloaded, _ := auth.NewWithRepo(repo, nil)
+	if !reloaded.IsAllowed(42) {
+		t.Fatal("allowlist file must contain the added user")
+	}
+
+	b.handleCommand(newAdminCmd("/admin_allow 42"))
+	if !strings.Contains(fs.sent[len(fs.sent)-1], "уже в allowlist") {
+		t.Errorf("repeated allow must be reported, got %q", fs.sent[len(fs.sent)-1])
+	}
+
+	b.handleCommand(newAdminCmd("/admin_deny 42"))
+	if svc.IsAllowed(42) || !strings.Contains(fs.sent[len(fs.sent)-1], "удален") {
+		t.Fatalf("user must be removed immediately, replies %q", fs.sent)
+	}
+	if reloaded, _ := auth.NewWithRepo(repo, nil); reloaded.IsAllowed(42) {
+		t.Fatal("allowlist file must not contain the removed user")
+	}
+
+	for cmd, want := range map[string]string{
+		"/admin_deny 42":  "нет в allowlist",
+		"/admin_deny 1":   "Нельзя удалить администратора",
+		"/admin_allow x":  "Некорректный user_id",
+		"/admin_allow":    "Usage: /admin_allow <user_id>",
+		"/admin_deny 1 2": "Usage: /admin_deny <user_id>",
+	} {
+		b.handleCommand(newAdminCmd(cmd))
+		if got := fs.sent[len(fs.sent)-1]; !strings.Contains(got, want) {
+			t.Errorf("%s: reply %q must contain %q", cmd, got, want)
+		}
+	}
+}
+
+func TestAdminAllow_OnlyAdmin(t *testing.T) {
+	repo, err := auth.NewFileRepository(filepath.Join(t.TempDir(), "allowlist.json"))
+	if err != nil {
+		t.Fatalf("repo: %v", err)
+	}
+	svc, _ := auth.NewWithRepo(repo, []int64{5})
+	fs := &fakeSender{}
+	b := &Bot{s: fs, adminUserID: 1, authSvc: svc}
+
+	msg := newAdminCmd("/admin_allow 42")
+	msg.From.ID, msg.Chat.ID = 5, 5
+	b.handleCommand(msg)
+	if svc.IsAllowed(42) {
+		t.Fatal("non-admin must not change the allowlist")
+	}
+	if len(fs.sent) == 0 || !strings.Contains(fs.sent[len(fs.sent)-1], "только администратору") {
+		t.Errorf("non-admin must be refused, got %q", fs.sent)
+	}
+}
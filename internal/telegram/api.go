@@ -5,6 +5,8 @@ import tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 type sender interface {
 	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
 	GetFile(config tgbotapi.FileConfig) (tgbotapi.File, error)
+	// Request для методов без сообщения в ответе (answerCallbackQuery и т.п.)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
 }
 
 type botAPISender struct{ api *tgbotapi.BotAPI }
@@ -16,3 +18,7 @@ func (s botAPISender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
 func (s botAPISender) GetFile(config tgbotapi.FileConfig) (tgbotapi.File, error) {
 	return s.api.GetFile(config)
 }
+
+func (s botAPISender) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	return s.api.Request(c)
+}
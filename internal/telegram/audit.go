@@ -0,0 +1,61 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+)
+
+// auditEntries сколько последних решений о доступе показывает /admin_audit
+const auditEntries = 20
+
+// handleAuditCommand /admin_audit — последние решения проверки доступа из audit.jsonl
+func (b *Bot) handleAuditCommand(msg *tgbotapi.Message) {
+	events, err := b.authSvc.RecentAudit(auditEntries)
+	if errors.Is(err, auth.ErrAuditUnavailable) {
+		b.sendMessage(msg.Chat.ID, "Журнал аудита доступа не ведётся: allowlist хранится не в файле")
+		return
+	}
+	if err != nil {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("Не удалось прочитать журнал аудита: %v", err))
+		return
+	}
+	out := tgbotapi.NewMessage(msg.Chat.ID, b.escapeIfNeeded(formatAudit(events)))
+	out.ParseMode = b.parseModeValue()
+	b.sendLongMessage(out)
+}
+
+// formatAudit события аудита для администратора: время, решение, пользователь и причина отказа
+func formatAudit(events []auth.AuthEvent) string {
+	if len(events) == 0 {
+		return "Журнал аудита доступа пуст"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🛡️ Проверки доступа (последние %d)\n\n", len(events)))
+	for _, e := range events {
+		sb.WriteString(fmt.Sprintf("[%s] %s %s %d", e.Timestamp.UTC().Format("2006-01-02 15:04:05"), auditIcon(e.Action), e.Action, e.UserID))
+		if e.Username != "" {
+			sb.WriteString(" @" + e.Username)
+		}
+		if e.Reason != "" {
+			sb.WriteString(" — " + e.Reason)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func auditIcon(action string) string {
+	switch action {
+	case auth.AuditAllow:
+		return "✅"
+	case auth.AuditRateLimited:
+		return "⏳"
+	default:
+		return "⛔"
+	}
+}
@@ -0,0 +1,46 @@
+package telegram
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ai-chatter/internal/auth"
+)
+
+func TestAdminAuditCommand(t *testing.T) {
+	fs := &fakeSender{}
+	b := &Bot{s: fs, adminUserID: 1, authSvc: &auth.Service{}}
+
+	b.handleCommand(newAdminCmd("/admin_audit"))
+	if len(fs.sent) != 1 || !strings.Contains(fs.sent[0], "не ведётся") {
+		t.Fatalf("missing audit log must be explained, got %q", fs.sent)
+	}
+
+	repo, err := auth.NewFileRepository(filepath.Join(t.TempDir(), "allowlist.json"))
+	if err != nil {
+		t.Fatalf("repo: %v", err)
+	}
+	svc, _ := auth.NewWithRepo(repo, []int64{42})
+	b.authSvc = svc
+	b.handleCommand(newAdminCmd("/admin_audit"))
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "пуст") {
+		t.Fatalf("empty log must be reported, got %q", last)
+	}
+
+	for i := 0; i < auditEntries+5; i++ {
+		_ = svc.Check(42, "alice")
+	}
+	_ = svc.Check(7, "mallory")
+	b.handleCommand(newAdminCmd("/admin_audit"))
+
+	text := fs.sent[len(fs.sent)-1]
+	if n := strings.Count(text, "\n["); n != auditEntries {
+		t.Errorf("must show the last %d entries, got %d:\n%s", auditEntries, n, text)
+	}
+	for _, want := range []string{"✅ allow 42 @alice", "⛔ deny 7 @mallory — not in allowlist"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("audit dump must contain %q:\n%s", want, text)
+		}
+	}
+}
@@ -8,22 +8,25 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
 	"ai-chatter/internal/agents"
-	"ai-chatter/internal/analytics"
 	"ai-chatter/internal/auth"
 	"ai-chatter/internal/codevalidation"
+	"ai-chatter/internal/docs"
 	"ai-chatter/internal/github"
 	"ai-chatter/internal/gmail"
 	"ai-chatter/internal/history"
 	"ai-chatter/internal/llm"
+	"ai-chatter/internal/messages"
 	"ai-chatter/internal/notion"
 	"ai-chatter/internal/pending"
 	"ai-chatter/internal/release"
 	"ai-chatter/internal/rustore"
+	"ai-chatter/internal/scheduler"
 	"ai-chatter/internal/storage"
 	"ai-chatter/internal/vibecoding"
 )
@@ -53,6 +56,32 @@ type Bot struct {
 	parseMode    string
 	provider     string
 	model        string
+	// активный профиль конфигурации (AI_CHATTER_PROFILE)
+	profile string
+	// подпись под ответом резервной модели (OPENROUTER_FALLBACK_FOOTER)
+	fallbackFooter bool
+	// журнал запросов к LLM для /admin_llm_log (LLM_LOG_REQUESTS; nil — выключен)
+	llmRequestLog storage.LLMRequestRecorder
+	// счётчики использования функций для /stats и еженедельного дайджеста (nil — не ведутся)
+	usage storage.UsageStore
+	// купленная квота сообщений и чеки (/topup); модуль включён только с токеном провайдера
+	payments         storage.PaymentStore
+	paymentsToken    string
+	paymentsCurrency string
+	topupPackages    []TopupPackage
+	// первое сообщение и время заявок из pending
+	pendingMeta map[int64]pending.Request
+	// заблокированные администратором пользователи: их заявки не принимаются
+	banned map[int64]auth.User
+	// срок жизни необработанной заявки на доступ (0 — бессрочно)
+	accessRequestTTL time.Duration
+	// тексты сообщений пользователям (MESSAGES_FILE_PATH; nil — встроенные)
+	userMessages *messages.File
+	adminContact string
+	// режим обслуживания (/maintenance) и его файл состояния MAINTENANCE_FILE_PATH
+	maintenanceMu   sync.Mutex
+	maintenance     maintenanceState
+	maintenancePath string
 	// secondary model for post-TS instruction
 	model2           string
 	llmClient2       llm.Client
@@ -63,6 +92,46 @@ type Bot struct {
 	tzMode           map[int64]bool
 	// per-user remaining steps in TZ mode
 	tzRemaining map[int64]int
+	// per-user model overrides (/model <provider> <name>)
+	userModelMu      sync.RWMutex
+	userModels       map[int64]userModelOverride
+	userClients      map[int64]llm.Client
+	userModelsPath   string
+	userModelsForAll bool
+	modelCatalog     *llm.ModelCatalog // курируемый список для /model; nil — каталог по умолчанию фабрики
+	// per-user document library ("ask the docs")
+	docsMu        sync.Mutex
+	docsLibrary   *docs.Library
+	docsThreshold float64
+	docsAwaiting  map[int64]bool
+	// scheduled reminders (/remind)
+	reminders    *scheduler.ReminderStore
+	remindersLoc *time.Location
+	// пользовательские запросы к LLM по расписанию (/schedule)
+	promptScheduler promptScheduler
+	// персональные настройки и онбординг новых пользователей (/onboarding)
+	userSettingsMu   sync.RWMutex
+	userSettings     map[int64]userSettings
+	userSettingsPath string
+	onboarding       map[int64]*onboardingState
+	// inline-кнопки подтверждений: действия переживают перезапуск через callbackStore
+	callbackMu       sync.Mutex
+	callbackActions  map[string]storage.CallbackAction
+	callbackStore    storage.CallbackActionStore
+	callbackTTL      time.Duration
+	unknownCallbacks atomic.Int64
+	expiredCallbacks atomic.Int64
+	// voice messages: распознавание и расшифровка для префикса ответа
+	voiceMu              sync.Mutex
+	transcriber          llm.Transcriber
+	voiceMaxDuration     time.Duration
+	transcriptionTimeout time.Duration
+	voiceTranscripts     map[int64]string
+	fileDownloader       func(tgbotapi.File) ([]byte, error)
+	// streaming responses via message editing
+	streamMu       sync.Mutex
+	streamEnabled  bool
+	streamInterval time.Duration
 	// Notion MCP client
 	mcpClient        *notion.MCPClient
 	notionParentPage string
@@ -79,6 +148,14 @@ type Bot struct {
 	rustoreClient *rustore.RuStoreMCPClient
 	// AI Release Agent
 	releaseAgent *release.ReleaseAgent
+	// Суточный отчёт: разделы и источники интеграций (интерфейсы, чтобы подменять в тестах)
+	reportConfig        *ReportConfig
+	reportGmailClient   reportGmailSearcher
+	reportRuStoreClient reportRuStoreLister
+	// /publish_rustore: источники и состояние пошаговой публикации по пользователям
+	publishGitHubClient  publishGitHub
+	publishRuStoreClient publishRuStore
+	publish              publishFlows
 }
 
 func New(
@@ -113,6 +190,7 @@ func New(
 		adminUserID:      adminUserID,
 		pending:          make(map[int64]auth.User),
 		pendingRepo:      pendingRepo,
+		accessRequestTTL: defaultAccessRequestTTL,
 		parseMode:        parseMode,
 		provider:         provider,
 		model:            model,
@@ -127,6 +205,17 @@ func New(
 		rustoreClient:    rustoreClient,
 	}
 
+	if gmailClient != nil {
+		b.reportGmailClient = gmailClient
+	}
+	if rustoreClient != nil {
+		b.reportRuStoreClient = rustoreClient
+		b.publishRuStoreClient = rustoreClient
+	}
+	if githubClient != nil {
+		b.publishGitHubClient = githubClient
+	}
+
 	// Создаем Release Agent если доступны GitHub и RuStore клиенты
 	if githubClient != nil && rustoreClient != nil {
 		b.releaseAgent = release.NewReleaseAgent(githubClient, rustoreClient, llmClient)
@@ -162,6 +251,8 @@ func New(
 	// Инициализируем VibeCoding handler
 	b.vibeCodingHandler = vibecoding.NewVibeCodingHandler(b.s, b, llmClient)
 	log.Printf("✅ VibeCoding handler initialized")
+	// Алерты администратору о повторяющихся ошибках vibe_* инструментов
+	vibecoding.DefaultToolMetrics.SetFailureAlert(b.notifyToolFailure)
 	// Try to preload model2 from file if present
 	if data, err := os.ReadFile("data/model2.txt"); err == nil {
 		m2 := strings.TrimSpace(string(data))
@@ -193,13 +284,7 @@ func New(
 			}
 		}
 	}
-	if b.pendingRepo != nil {
-		if items, err := b.pendingRepo.LoadAll(); err == nil {
-			for _, u := range items {
-				b.pending[u.ID] = u
-			}
-		}
-	}
+	b.loadAccessRequests()
 	return b, nil
 }
 
@@ -330,13 +415,21 @@ func (b *Bot) Start(ctx context.Context) {
 
 	log.Printf("Bot started")
 	if b.adminUserID != 0 {
-		info := fmt.Sprintf("Бот запущен и готов к работе. Провайдер: %s, модель: %s.", b.provider, b.model)
+		info := fmt.Sprintf("🧭 Профиль: %s\nБот запущен и готов к работе. Провайдер: %s, модель: %s.", strings.ToUpper(b.profileName()), b.provider, b.model)
 		b.sendMessage(b.adminUserID, info)
 	}
 
 	updates := b.api.GetUpdatesChan(u)
 
 	for update := range updates {
+		if update.PreCheckoutQuery != nil {
+			b.handlePreCheckout(update.PreCheckoutQuery)
+			continue
+		}
+		if update.Message != nil && update.Message.SuccessfulPayment != nil {
+			b.handleSuccessfulPayment(update.Message)
+			continue
+		}
 		if update.Message != nil {
 			if update.Message.IsCommand() {
 				if update.Message.Command() == "start" {
@@ -357,15 +450,24 @@ func (b *Bot) Start(ctx context.Context) {
 }
 
 func (b *Bot) handleStart(msg *tgbotapi.Message) {
-	welcome := "Привет! Я LLM-бот. Отвечаю на вопросы с учётом контекста. Под каждым ответом есть кнопки: ‘История’ (саммари диалога) и ‘Сбросить контекст’."
+	if b.replyMaintenance(msg) {
+		return
+	}
+	welcome := b.userMessage(messages.Welcome, msg.From, 0)
 	if b.authSvc.IsAllowed(msg.From.ID) {
 		b.sendMessage(msg.Chat.ID, welcome+"\n\nДоступ уже предоставлен. Можете писать сообщение.")
 		return
 	}
 	// Not allowed: cache and request admin
-	b.pending[msg.From.ID] = auth.User{ID: msg.From.ID, Username: msg.From.UserName, FirstName: msg.From.FirstName, LastName: msg.From.LastName}
+	switch b.requestAccess(msg.From, msg.CommandArguments()) {
+	case accessRequestBanned:
+		return
+	case accessRequestDuplicate:
+		b.sendMessage(msg.Chat.ID, welcome+"\n\n"+b.userMessage(messages.PendingRequest, msg.From, 0)+b.topupHint())
+		return
+	}
+	b.sendMessage(msg.Chat.ID, welcome+"\n\n"+b.userMessage(messages.AccessDenied, msg.From, 0)+b.topupHint())
 	b.notifyAdminRequest(msg.From.ID, msg.From.UserName)
-	b.sendMessage(msg.Chat.ID, welcome+"\n\nЗапрос на доступ отправлен администратору. Как только он подтвердит, вы получите уведомление.")
 }
 
 // handleCommand is implemented in handlers.go
@@ -380,12 +482,12 @@ func (b *Bot) handleAdminConfigCommands(msg *tgbotapi.Message) {
 	switch cmd {
 	case "provider":
 		if len(args) != 1 {
-			b.sendMessage(msg.Chat.ID, "Usage: /provider <openai|yandex>")
+			b.sendMessage(msg.Chat.ID, "Usage: /provider <openai|yandex|anthropic>")
 			return
 		}
 		prov := strings.ToLower(args[0])
-		if prov != "openai" && prov != "yandex" {
-			b.sendMessage(msg.Chat.ID, "Поддерживаются: openai, yandex")
+		if prov != llm.ProviderOpenAI && prov != llm.ProviderYandex && prov != llm.ProviderAnthropic {
+			b.sendMessage(msg.Chat.ID, "Поддерживаются: openai, yandex, anthropic")
 			return
 		}
 		if err := os.WriteFile("data/provider.txt", []byte(prov), 0o644); err != nil {
@@ -500,6 +602,19 @@ func (b *Bot) reformatToSchema(ctx context.Context, userID int64, raw string) (l
 	return p, ok
 }
 
+// SetHistoryLimits задаёт глубину истории диалога: количество хранимых сообщений и бюджет токенов контекста
+func (b *Bot) SetHistoryLimits(maxMessages, maxTokens int) {
+	b.history.SetLimits(maxMessages, maxTokens)
+}
+
+// resetContext очищает контекст диалога (/reset и кнопка "Сбросить контекст"); логи сохраняются, но больше не используются
+func (b *Bot) resetContext(userID int64) {
+	b.history.DisableAll(userID)
+	if b.recorder != nil {
+		_ = b.recorder.SetAllCanUse(userID, false)
+	}
+}
+
 // Context build no longer proactively compresses
 func (b *Bot) buildContextWithOverflow(ctx context.Context, userID int64) []llm.Message {
 	var msgs []llm.Message
@@ -507,6 +622,9 @@ func (b *Bot) buildContextWithOverflow(ctx context.Context, userID int64) []llm.
 	if sys != "" {
 		msgs = append(msgs, llm.Message{Role: "system", Content: sys})
 	}
+	if lang := b.languageInstruction(userID); lang != "" {
+		msgs = append(msgs, llm.Message{Role: "system", Content: lang})
+	}
 	msgs = append(msgs, b.history.Get(userID)...)
 	_ = ctx
 	return msgs
@@ -521,11 +639,10 @@ func (b *Bot) approveUser(id int64) {
 	if u.ID == 0 {
 		return
 	}
-	delete(b.pending, id)
-	if b.pendingRepo != nil {
-		_ = b.pendingRepo.Remove(id)
+	b.forgetAccessRequest(id)
+	if _, err := b.authSvc.Add(u); err != nil {
+		log.Printf("failed to add %d to allowlist: %v", id, err)
 	}
-	_ = b.authSvc.Upsert(u)
 	msg := tgbotapi.NewMessage(b.adminUserID, b.escapeIfNeeded(fmt.Sprintf("Пользователь @%s (%d) добавлен в allowlist", u.Username, u.ID)))
 	msg.ParseMode = b.parseModeValue()
 	if _, err := b.s.Send(msg); err != nil {
@@ -536,6 +653,9 @@ func (b *Bot) approveUser(id int64) {
 	if _, err := b.s.Send(msg2); err != nil {
 		log.Printf("failed to notify user approval: %v", err)
 	}
+	if b.needsOnboarding(u.ID) {
+		b.startOnboarding(u.ID, u.ID, nil)
+	}
 }
 
 func (b *Bot) denyUser(id int64) {
@@ -543,10 +663,7 @@ func (b *Bot) denyUser(id int64) {
 	if u.ID == 0 {
 		return
 	}
-	delete(b.pending, id)
-	if b.pendingRepo != nil {
-		_ = b.pendingRepo.Remove(id)
-	}
+	b.forgetAccessRequest(id)
 	msg := tgbotapi.NewMessage(b.adminUserID, b.escapeIfNeeded(fmt.Sprintf("Пользователю @%s (%d) отказано в доступе", u.Username, u.ID)))
 	msg.ParseMode = b.parseModeValue()
 	if _, err := b.s.Send(msg); err != nil {
@@ -699,28 +816,15 @@ func (b *Bot) generateDailyReport(ctx context.Context, chatID int64) error {
 	// Отправляем уведомление о начале генерации отчёта
 	b.sendMessage(chatID, "📊 Начинаю формирование отчёта об использовании бота за последние сутки...")
 
-	if b.recorder == nil {
-		return fmt.Errorf("recorder не настроен")
-	}
-
-	// Загружаем все события
-	events, err := b.recorder.LoadInteractions()
-	if err != nil {
-		return fmt.Errorf("не удалось загрузить логи: %w", err)
-	}
-
-	// Анализируем данные за вчерашний день
+	// Анализируем вчерашний день; каждый раздел строится независимо, сбой одного не прерывает отчёт
 	yesterday := time.Now().AddDate(0, 0, -1)
-	stats := analytics.AnalyzeDailyLogs(events, yesterday)
-
-	// Генерируем резюме для LLM
-	reportSummary := stats.GenerateReportSummary()
+	reportSummary := renderReportSections(b.buildReportSections(ctx, yesterday))
 
 	// Выполняем генерацию отчёта в изолированном контексте
 	currentDate := yesterday.Format("2006-01-02")
 	reportTitle := fmt.Sprintf("Отчёт за %s", currentDate)
 
-	err = b.executeReportGenerationPipeline(ctx, chatID, reportTitle, reportSummary, currentDate)
+	err := b.executeReportGenerationPipeline(ctx, chatID, reportTitle, reportSummary, currentDate)
 	if err != nil {
 		return fmt.Errorf("ошибка выполнения генерации отчёта: %w", err)
 	}
@@ -749,14 +853,13 @@ func (b *Bot) executeReportGenerationPipeline(ctx context.Context, chatID int64,
 	// Шаг 3: Создание отчёта как подстраницы
 	b.sendMessage(chatID, fmt.Sprintf("📊 Создаю отчёт '%s' в Notion...", reportTitle))
 
-	pageID, err := b.createReportPage(ctx, reportTitle, reportContent, reportsPageID)
+	page, err := b.createReportPage(ctx, reportTitle, reportContent, reportsPageID)
 	if err != nil {
 		return fmt.Errorf("не удалось создать страницу отчёта: %w", err)
 	}
 
 	// Шаг 4: Уведомление о завершении
-	pageURL := fmt.Sprintf("https://notion.so/%s", pageID)
-	successMessage := fmt.Sprintf("✅ Отчёт '%s' успешно создан!\n\n🔗 Ссылка: %s", reportTitle, pageURL)
+	successMessage := fmt.Sprintf("✅ Отчёт '%s' успешно создан!\n\n🔗 Ссылка: %s", reportTitle, page.URL)
 	b.sendMessage(chatID, successMessage)
 
 	return nil
@@ -769,8 +872,14 @@ func (b *Bot) findOrCreateReportsPage(ctx context.Context, chatID int64) (string
 	}
 
 	// Ищем страницу Reports
-	result := b.mcpClient.SearchPagesWithID(ctx, "Reports", 5, true)
-	if result.Success && len(result.Pages) > 0 {
+	result, err := withNotionRetry(ctx, func() (notion.MCPPageSearchResult, error) {
+		return b.mcpClient.SearchPagesWithID(ctx, "Reports", 5, true)
+	})
+	if err != nil && notion.CategoryOf(err) != notion.ErrorNotFound {
+		// Без доступа к Notion создавать вторую страницу Reports бессмысленно
+		return "", fmt.Errorf("поиск страницы Reports: %w", err)
+	}
+	if len(result.Pages) > 0 {
 		b.sendMessage(chatID, fmt.Sprintf("✅ Найдена страница Reports (ID: %s)", result.Pages[0].ID))
 		return result.Pages[0].ID, nil
 	}
@@ -791,13 +900,15 @@ func (b *Bot) findOrCreateReportsPage(ctx context.Context, chatID int64) (string
 ---
 *Создано автоматически*`
 
-	createResult := b.mcpClient.CreateFreeFormPage(ctx, "Reports", reportsContent, b.notionParentPage, nil)
-	if !createResult.Success {
-		return "", fmt.Errorf("не удалось создать страницу Reports: %s", createResult.Message)
+	createResult, err := withNotionRetry(ctx, func() (notion.MCPResult, error) {
+		return b.mcpClient.CreateFreeFormPage(ctx, "Reports", reportsContent, b.notionParentPage, nil)
+	})
+	if err != nil {
+		return "", fmt.Errorf("не удалось создать страницу Reports: %w", err)
 	}
 
-	b.sendMessage(chatID, fmt.Sprintf("✅ Создана страница Reports (ID: %s)", createResult.PageID))
-	return createResult.PageID, nil
+	b.sendMessage(chatID, fmt.Sprintf("✅ Создана страница Reports (ID: %s)", createResult.Page.ID))
+	return createResult.Page.ID, nil
 }
 
 // generateReportContent генерирует содержимое отчёта через LLM
@@ -836,17 +947,81 @@ func (b *Bot) generateReportContent(ctx context.Context, reportSummary, currentD
 }
 
 // createReportPage создаёт страницу отчёта в Notion
-func (b *Bot) createReportPage(ctx context.Context, title, content, parentPageID string) (string, error) {
+func (b *Bot) createReportPage(ctx context.Context, title, content, parentPageID string) (notion.PageMeta, error) {
 	if b.mcpClient == nil {
-		return "", fmt.Errorf("MCP клиент не настроен")
+		return notion.PageMeta{}, fmt.Errorf("MCP клиент не настроен")
+	}
+
+	result, err := withNotionRetry(ctx, func() (notion.MCPResult, error) {
+		return b.mcpClient.CreateFreeFormPage(ctx, title, content, parentPageID, nil)
+	})
+	if err != nil {
+		return notion.PageMeta{}, err
 	}
 
-	result := b.mcpClient.CreateFreeFormPage(ctx, title, content, parentPageID, nil)
-	if !result.Success {
-		return "", fmt.Errorf("не удалось создать страницу отчёта: %s", result.Message)
+	return result.Page, nil
+}
+
+// SetVibeCodingConfig применяет настройки попыток и таймаутов VibeCoding
+func (b *Bot) SetVibeCodingConfig(cfg vibecoding.VibeCodingConfig) {
+	if b.vibeCodingHandler != nil {
+		b.vibeCodingHandler.SetConfig(cfg)
 	}
+}
+
+// StartVibeCodingWebServer запускает веб-интерфейс VibeCoding; занятый порт возвращается ошибкой
+func (b *Bot) StartVibeCodingWebServer(port int) error {
+	if b.vibeCodingHandler == nil {
+		return nil
+	}
+	return b.vibeCodingHandler.StartWebServer(port)
+}
+
+// StartVibeCodingMetricsServer запускает отдельный порт с /metrics VibeCoding; 0 — не запускать
+func (b *Bot) StartVibeCodingMetricsServer(port int) error {
+	if b.vibeCodingHandler == nil {
+		return nil
+	}
+	return b.vibeCodingHandler.StartMetricsServer(port)
+}
 
-	return result.PageID, nil
+// RestoreVibeCodingSessions восстанавливает сессии VibeCoding из хранилища после перезапуска
+func (b *Bot) RestoreVibeCodingSessions(ctx context.Context, store vibecoding.SessionStore) error {
+	if b.vibeCodingHandler == nil {
+		return nil
+	}
+	return b.vibeCodingHandler.RestoreSessions(ctx, store)
+}
+
+// StartVibeCodingContainerGC запускает сборку осиротевших контейнеров VibeCoding;
+// вызывается после RestoreVibeCodingSessions, итоги приходят администратору
+func (b *Bot) StartVibeCodingContainerGC() {
+	if b.vibeCodingHandler == nil {
+		return
+	}
+	b.vibeCodingHandler.StartContainerGC(b.notifyContainerGC)
+}
+
+// notifyContainerGC сообщает администратору об удалённых осиротевших контейнерах VibeCoding
+func (b *Bot) notifyContainerGC(report vibecoding.ContainerGCReport) {
+	if b.adminUserID == 0 {
+		return
+	}
+	text := fmt.Sprintf("🧹 VibeCoding: удалено осиротевших контейнеров: %d, освобождено %s", report.Removed, formatBytes(report.ReclaimedBytes))
+	if report.Failed > 0 {
+		text += fmt.Sprintf("\n⚠️ Не удалось удалить: %d (подробности в логах)", report.Failed)
+	}
+	b.sendMessage(b.adminUserID, text)
+}
+
+// notifyToolFailure уведомляет администратора о повторяющихся ошибках MCP инструмента
+func (b *Bot) notifyToolFailure(alert vibecoding.ToolFailureAlert) {
+	if b.adminUserID == 0 {
+		return
+	}
+	text := fmt.Sprintf("🚨 VibeCoding: инструмент %s упал %d раз за %s\nКатегория: %s\nПоследняя ошибка: %s",
+		alert.Tool, alert.Failures, alert.Window, alert.Category, truncateForLog(alert.LastError, 500))
+	b.sendMessage(b.adminUserID, text)
 }
 
 // GenerateDailyReportForAdmin генерирует отчёт и отправляет админу (для планировщика)
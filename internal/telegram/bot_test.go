@@ -13,18 +13,48 @@ import (
 	"ai-chatter/internal/llm"
 )
 
-type fakeSender struct{ sent []string }
+type fakeSender struct {
+	sent    []string
+	edits   []string
+	answers []string
+	// markup последняя отправленная или изменённая inline-клавиатура
+	markup *tgbotapi.InlineKeyboardMarkup
+	// documents отправленные файлы
+	documents []tgbotapi.DocumentConfig
+	// invoices выставленные счета, preCheckouts ответы на pre_checkout_query
+	invoices     []tgbotapi.InvoiceConfig
+	preCheckouts []tgbotapi.PreCheckoutConfig
+	// deleted удалённые сообщения
+	deleted []int
+}
+
+func (fs *fakeSender) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	if cb, ok := c.(tgbotapi.CallbackConfig); ok {
+		fs.answers = append(fs.answers, cb.Text)
+	}
+	if pc, ok := c.(tgbotapi.PreCheckoutConfig); ok {
+		fs.preCheckouts = append(fs.preCheckouts, pc)
+	}
+	if d, ok := c.(tgbotapi.DeleteMessageConfig); ok {
+		fs.deleted = append(fs.deleted, d.MessageID)
+	}
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
 
 func (fs *fakeSender) GetFile(config tgbotapi.FileConfig) (tgbotapi.File, error) {
 	return tgbotapi.File{}, nil
 }
 
 type fakeLLM struct {
+	llm.NoStreaming
+	llm.LocalTokenCounter
 	resp llm.Response
 	err  error
 }
 
 type fakeLLMSeq struct {
+	llm.NoStreaming
+	llm.LocalTokenCounter
 	seq      []llm.Response
 	calls    int
 	lastMsgs [][]llm.Message
@@ -45,9 +75,26 @@ func (f *fakeLLMSeq) GenerateWithTools(_ context.Context, msgs []llm.Message, _
 }
 
 func (f *fakeSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	if edit, ok := c.(tgbotapi.EditMessageTextConfig); ok {
+		f.edits = append(f.edits, edit.Text)
+		f.markup = edit.ReplyMarkup
+		return tgbotapi.Message{MessageID: edit.MessageID}, nil
+	}
+	if invoice, ok := c.(tgbotapi.InvoiceConfig); ok {
+		f.invoices = append(f.invoices, invoice)
+		return tgbotapi.Message{MessageID: len(f.sent) + len(f.invoices)}, nil
+	}
+	if doc, ok := c.(tgbotapi.DocumentConfig); ok {
+		f.documents = append(f.documents, doc)
+		return tgbotapi.Message{MessageID: len(f.sent) + len(f.documents)}, nil
+	}
 	sw := c.(tgbotapi.MessageConfig)
 	f.sent = append(f.sent, sw.Text)
-	return tgbotapi.Message{}, nil
+	f.markup = nil
+	if kb, ok := sw.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); ok {
+		f.markup = &kb
+	}
+	return tgbotapi.Message{MessageID: len(f.sent)}, nil
 }
 
 func (f fakeLLM) Generate(_ context.Context, _ []llm.Message) (llm.Response, error) {
@@ -349,3 +396,28 @@ func TestTZ_CheckerCorrectsPrimaryResponse(t *testing.T) {
 		t.Fatalf("corrected numbering not applied: %q", out)
 	}
 }
+
+func TestResetCommand_ClearsContext(t *testing.T) {
+	userID := int64(11)
+	svc, _ := auth.NewWithRepo(nil, []int64{userID})
+	fs := &fakeSender{}
+	b := &Bot{s: fs, authSvc: svc, pending: make(map[int64]auth.User), history: history.NewManager()}
+	b.SetHistoryLimits(4, 0)
+	for i := 0; i < 6; i++ {
+		b.history.AppendUser(userID, "msg")
+	}
+	if got := len(b.history.Get(userID)); got != 4 {
+		t.Fatalf("history depth not applied: %d", got)
+	}
+
+	msg := &tgbotapi.Message{From: &tgbotapi.User{ID: userID}, Chat: &tgbotapi.Chat{ID: userID}, Text: "/reset"}
+	msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/reset")}}
+	b.handleCommand(msg)
+
+	if got := len(b.history.Get(userID)); got != 0 {
+		t.Fatalf("context must be empty after /reset, got %d", got)
+	}
+	if len(fs.sent) != 1 || !strings.Contains(fs.sent[0], "Контекст очищен") {
+		t.Fatalf("unexpected reply: %+v", fs.sent)
+	}
+}
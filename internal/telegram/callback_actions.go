@@ -0,0 +1,261 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/storage"
+)
+
+const (
+	callbackActionPrefix     = "act:"
+	defaultCallbackActionTTL = 24 * time.Hour
+
+	callbackAllowlistApprove = "allowlist_approve"
+	callbackAllowlistDeny    = "allowlist_deny"
+	callbackAllowlistBan     = "allowlist_ban"
+
+	callbackExpiredText  = "Это действие устарело, повторите попытку"
+	callbackNotOwnerText = "Это действие доступно только его автору"
+)
+
+// CallbackStats счётчики обработки inline-кнопок
+type CallbackStats struct {
+	Active  int
+	Unknown int64 // нажатия по кнопкам, для которых действие не найдено
+	Expired int64 // нажатия по кнопкам с истёкшим сроком действия
+}
+
+// SetCallbackActions подключает хранилище действий inline-кнопок и восстанавливает
+// незавершённые подтверждения после перезапуска. ttl <= 0 — срок по умолчанию (24h)
+func (b *Bot) SetCallbackActions(store storage.CallbackActionStore, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultCallbackActionTTL
+	}
+	b.callbackMu.Lock()
+	defer b.callbackMu.Unlock()
+	b.callbackStore = store
+	b.callbackTTL = ttl
+	if b.callbackActions == nil {
+		b.callbackActions = make(map[string]storage.CallbackAction)
+	}
+	if store == nil {
+		return
+	}
+	actions, err := store.LoadAll()
+	if err != nil {
+		log.Printf("⚠️ Failed to restore callback actions: %v", err)
+		return
+	}
+	now := time.Now()
+	restored := 0
+	for _, a := range actions {
+		if a.Expired(now) {
+			continue
+		}
+		b.callbackActions[a.ID] = a
+		restored++
+	}
+	if restored > 0 {
+		log.Printf("♻️ Restored %d pending callback actions", restored)
+	}
+}
+
+// registerCallbackAction запоминает действие кнопки и возвращает callback data для неё
+func (b *Bot) registerCallbackAction(actionType, payload string, ownerID, chatID int64, group string) string {
+	id := newCallbackActionID()
+	now := time.Now()
+
+	b.callbackMu.Lock()
+	if b.callbackActions == nil {
+		b.callbackActions = make(map[string]storage.CallbackAction)
+	}
+	ttl := b.callbackTTL
+	if ttl <= 0 {
+		ttl = defaultCallbackActionTTL
+	}
+	action := storage.CallbackAction{
+		ID:        id,
+		Type:      actionType,
+		Payload:   payload,
+		OwnerID:   ownerID,
+		ChatID:    chatID,
+		Group:     group,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	b.callbackActions[id] = action
+	store := b.callbackStore
+	b.callbackMu.Unlock()
+
+	if store != nil {
+		if err := store.Save(action); err != nil {
+			log.Printf("⚠️ Failed to persist callback action %s: %v", actionType, err)
+		}
+	}
+	return callbackActionPrefix + id
+}
+
+func newCallbackActionID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// takeCallbackAction снимает действие кнопки вместе с остальными кнопками его группы
+func (b *Bot) takeCallbackAction(id string) {
+	b.callbackMu.Lock()
+	action, ok := b.callbackActions[id]
+	if !ok {
+		b.callbackMu.Unlock()
+		return
+	}
+	ids := []string{id}
+	delete(b.callbackActions, id)
+	if action.Group != "" {
+		for otherID, other := range b.callbackActions {
+			if other.Group == action.Group {
+				ids = append(ids, otherID)
+				delete(b.callbackActions, otherID)
+			}
+		}
+	}
+	store := b.callbackStore
+	b.callbackMu.Unlock()
+
+	if store != nil {
+		if err := store.Remove(ids...); err != nil {
+			log.Printf("⚠️ Failed to remove callback actions: %v", err)
+		}
+	}
+}
+
+// handleCallbackAction выполняет действие зарегистрированной кнопки
+func (b *Bot) handleCallbackAction(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+	id := strings.TrimPrefix(cb.Data, callbackActionPrefix)
+
+	b.callbackMu.Lock()
+	action, ok := b.callbackActions[id]
+	b.callbackMu.Unlock()
+
+	if !ok {
+		b.unknownCallbacks.Add(1)
+		log.Printf("⚠️ Callback for unknown action %q from user %d", id, cb.From.ID)
+		b.answerCallback(cb, callbackExpiredText)
+		return
+	}
+	if action.Expired(time.Now()) {
+		b.expiredCallbacks.Add(1)
+		b.takeCallbackAction(id)
+		log.Printf("⌛ Callback action %s (%s) expired for user %d", id, action.Type, cb.From.ID)
+		b.answerCallback(cb, callbackExpiredText)
+		return
+	}
+	if action.OwnerID != 0 && action.OwnerID != cb.From.ID {
+		b.answerCallback(cb, callbackNotOwnerText)
+		return
+	}
+
+	b.takeCallbackAction(id)
+	switch action.Type {
+	case callbackAllowlistApprove, callbackAllowlistDeny, callbackAllowlistBan:
+		userID, err := strconv.ParseInt(action.Payload, 10, 64)
+		if err != nil {
+			log.Printf("❌ Invalid allowlist callback payload %q: %v", action.Payload, err)
+			b.answerCallback(cb, callbackExpiredText)
+			return
+		}
+		if _, ok := b.pending[userID]; !ok {
+			b.answerCallback(cb, "Заявка уже обработана")
+			return
+		}
+		switch action.Type {
+		case callbackAllowlistApprove:
+			b.approveUser(userID)
+		case callbackAllowlistBan:
+			b.banUser(userID)
+		default:
+			b.denyUser(userID)
+		}
+	case callbackModelProvider, callbackModelSelect, callbackModelBack, callbackModelReset:
+		b.handleModelPickerCallback(cb, action)
+		return
+	case callbackExportNotion:
+		b.answerCallback(cb, "Сохраняю переписку в Notion...")
+		b.exportTranscriptToNotion(ctx, action.ChatID, cb.From.ID, "")
+		return
+	case callbackOnboarding:
+		b.handleOnboardingCallback(ctx, cb, action.ChatID, action.Payload)
+		return
+	case callbackPublishRelease, callbackPublishApp, callbackPublishConfirm, callbackPublishCancel, callbackPublishRetry:
+		b.handlePublishCallback(ctx, cb, action.Type, action.Payload)
+		return
+	case callbackSources:
+		b.handleSourcesCallback(cb, action)
+		return
+	default:
+		b.unknownCallbacks.Add(1)
+		log.Printf("⚠️ Unsupported callback action type %q", action.Type)
+		b.answerCallback(cb, callbackExpiredText)
+		return
+	}
+	b.answerCallback(cb, "")
+}
+
+// answerCallback отвечает на нажатие кнопки, чтобы клиент не показывал бесконечную загрузку
+func (b *Bot) answerCallback(cb *tgbotapi.CallbackQuery, text string) {
+	if _, err := b.s.Request(tgbotapi.NewCallback(cb.ID, text)); err != nil {
+		log.Printf("failed to answer callback: %v", err)
+	}
+}
+
+// PurgeExpiredCallbackActions удаляет просроченные действия кнопок (вызывается планировщиком)
+func (b *Bot) PurgeExpiredCallbackActions(ctx context.Context) error {
+	now := time.Now()
+	b.callbackMu.Lock()
+	purged := 0
+	for id, a := range b.callbackActions {
+		if a.Expired(now) {
+			delete(b.callbackActions, id)
+			purged++
+		}
+	}
+	store := b.callbackStore
+	b.callbackMu.Unlock()
+
+	if store != nil {
+		n, err := store.PurgeExpired(now)
+		if err != nil {
+			return fmt.Errorf("purge callback actions: %w", err)
+		}
+		if n > purged {
+			purged = n
+		}
+	}
+	if purged > 0 {
+		log.Printf("🧹 Purged %d expired callback actions", purged)
+	}
+	return nil
+}
+
+// CallbackStats возвращает число ожидающих действий и счётчики устаревших нажатий
+func (b *Bot) CallbackStats() CallbackStats {
+	b.callbackMu.Lock()
+	active := len(b.callbackActions)
+	b.callbackMu.Unlock()
+	return CallbackStats{
+		Active:  active,
+		Unknown: b.unknownCallbacks.Load(),
+		Expired: b.expiredCallbacks.Load(),
+	}
+}
@@ -0,0 +1,94 @@
+package telegram
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/storage"
+)
+
+func adminCallback(data string) *tgbotapi.CallbackQuery {
+	return &tgbotapi.CallbackQuery{ID: "cb", Data: data, From: &tgbotapi.User{ID: 999}}
+}
+
+func TestCallbackActions_SurviveRestart(t *testing.T) {
+	store, err := storage.NewFileCallbackActionStore(filepath.Join(t.TempDir(), "callback_actions.json"))
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	svc, _ := auth.NewWithRepo(nil, nil)
+
+	before := &Bot{s: &fakeSender{}, authSvc: svc, pending: map[int64]auth.User{123: {ID: 123, Username: "user"}}, adminUserID: 999}
+	before.SetCallbackActions(store, time.Hour)
+	before.notifyAdminRequest(123, "user")
+	approve := before.registerCallbackAction(callbackAllowlistApprove, "123", 999, 999, "allowlist:123")
+
+	// Перезапуск: новый бот восстанавливает кнопки из хранилища
+	fs := &fakeSender{}
+	after := &Bot{s: fs, authSvc: svc, pending: map[int64]auth.User{123: {ID: 123, Username: "user"}}, adminUserID: 999}
+	after.SetCallbackActions(store, time.Hour)
+	// разрешить, запретить и заблокировать из уведомления плюс отдельная кнопка
+	if st := after.CallbackStats(); st.Active != 4 {
+		t.Fatalf("expected 4 restored actions, got %+v", st)
+	}
+
+	after.handleCallback(context.Background(), adminCallback(approve))
+	if !svc.IsAllowed(123) {
+		t.Fatal("restored approve button did not approve the user")
+	}
+	if st := after.CallbackStats(); st.Active != 0 {
+		t.Fatalf("all buttons of the request must be removed, got %+v", st)
+	}
+	if actions, _ := store.LoadAll(); len(actions) != 0 {
+		t.Fatalf("store still has actions: %+v", actions)
+	}
+	if len(fs.answers) != 1 || fs.answers[0] != "" {
+		t.Fatalf("callback must be answered silently, got %q", fs.answers)
+	}
+}
+
+func TestCallbackActions_ExpiredAndUnknown(t *testing.T) {
+	fs := &fakeSender{}
+	b := &Bot{s: fs, pending: map[int64]auth.User{1: {ID: 1}}, adminUserID: 999}
+	b.SetCallbackActions(nil, time.Millisecond)
+	data := b.registerCallbackAction(callbackAllowlistDeny, "1", 999, 999, "")
+	time.Sleep(5 * time.Millisecond)
+
+	b.handleCallback(context.Background(), adminCallback(data))
+	b.handleCallback(context.Background(), adminCallback(callbackActionPrefix+"missing"))
+	b.handleCallback(context.Background(), adminCallback("garbage"))
+
+	if len(fs.answers) != 3 {
+		t.Fatalf("expected 3 answers, got %q", fs.answers)
+	}
+	for _, a := range fs.answers {
+		if !strings.Contains(a, "устарело") {
+			t.Errorf("unexpected answer %q", a)
+		}
+	}
+	if _, ok := b.pending[1]; !ok {
+		t.Error("expired deny button must not deny the user")
+	}
+	if st := b.CallbackStats(); st.Expired != 1 || st.Unknown != 2 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
+
+func TestPurgeExpiredCallbackActions(t *testing.T) {
+	b := &Bot{s: &fakeSender{}}
+	b.SetCallbackActions(nil, time.Millisecond)
+	b.registerCallbackAction(callbackAllowlistApprove, "1", 0, 0, "")
+	time.Sleep(5 * time.Millisecond)
+	if err := b.PurgeExpiredCallbackActions(context.Background()); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if st := b.CallbackStats(); st.Active != 0 {
+		t.Fatalf("expired action not purged: %+v", st)
+	}
+}
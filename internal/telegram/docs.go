@@ -0,0 +1,341 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/docs"
+	"ai-chatter/internal/llm"
+	"ai-chatter/internal/storage"
+)
+
+const (
+	docsUploadCmd = "docs_upload"
+	// docsTopK количество фрагментов, добавляемых в промпт
+	docsTopK = 4
+)
+
+// SetDocsLibrary включает режим "ask the docs": библиотеку документов пользователей
+// и порог оценки для автоматического подмешивания фрагментов в обычный диалог
+func (b *Bot) SetDocsLibrary(lib *docs.Library, autoThreshold float64) {
+	b.docsMu.Lock()
+	defer b.docsMu.Unlock()
+	b.docsLibrary = lib
+	b.docsThreshold = autoThreshold
+	b.docsAwaiting = make(map[int64]bool)
+}
+
+func (b *Bot) setDocsAwaiting(userID int64, on bool) {
+	b.docsMu.Lock()
+	defer b.docsMu.Unlock()
+	if b.docsAwaiting == nil {
+		b.docsAwaiting = make(map[int64]bool)
+	}
+	if on {
+		b.docsAwaiting[userID] = true
+	} else {
+		delete(b.docsAwaiting, userID)
+	}
+}
+
+// isDocsUpload проверяет, что документ нужно положить в библиотеку:
+// после /docs_upload или с подписью /docs_upload
+func (b *Bot) isDocsUpload(msg *tgbotapi.Message) bool {
+	if b.docsLibrary == nil || msg.Document == nil {
+		return false
+	}
+	if strings.HasPrefix(strings.TrimSpace(msg.Caption), "/"+docsUploadCmd) {
+		return true
+	}
+	b.docsMu.Lock()
+	defer b.docsMu.Unlock()
+	if b.docsAwaiting[msg.From.ID] {
+		delete(b.docsAwaiting, msg.From.ID)
+		return true
+	}
+	return false
+}
+
+// handleDocsUploadCommand ждёт файл следующим сообщением
+func (b *Bot) handleDocsUploadCommand(msg *tgbotapi.Message) {
+	if !b.authSvc.IsAllowed(msg.From.ID) {
+		return
+	}
+	if b.docsLibrary == nil {
+		b.sendMessage(msg.Chat.ID, "📚 Библиотека документов не настроена")
+		return
+	}
+	b.setDocsAwaiting(msg.From.ID, true)
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("📚 Пришлите файл следующим сообщением (%s). Текст будет сохранён в вашей библиотеке для вопросов через /ask_docs.",
+		strings.Join(docs.SupportedExtensions, ", ")))
+}
+
+// handleDocsUpload извлекает текст из присланного файла и сохраняет его в библиотеке
+func (b *Bot) handleDocsUpload(ctx context.Context, msg *tgbotapi.Message) {
+	name := msg.Document.FileName
+	log.Printf("📚 Docs upload from user %d: %s", msg.From.ID, name)
+
+	if !docs.IsSupported(name) {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("❌ Формат файла не поддерживается. Поддерживаются: %s", strings.Join(docs.SupportedExtensions, ", ")))
+		return
+	}
+
+	file, err := b.s.GetFile(tgbotapi.FileConfig{FileID: msg.Document.FileID})
+	if err != nil {
+		b.sendMessage(msg.Chat.ID, "❌ Не удалось получить файл от Telegram")
+		log.Printf("❌ Docs: failed to get file: %v", err)
+		return
+	}
+	data, err := b.downloadFileBytes(file)
+	if err != nil {
+		b.sendMessage(msg.Chat.ID, "❌ Не удалось скачать файл")
+		log.Printf("❌ Docs: %v", err)
+		return
+	}
+
+	pages, err := docs.ExtractPages(name, data)
+	if err != nil {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("❌ Не удалось извлечь текст: %v", err))
+		return
+	}
+
+	info, err := b.docsLibrary.Add(ctx, msg.From.ID, name, pages)
+	switch {
+	case errors.Is(err, docs.ErrQuotaExceeded):
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("❌ Превышен лимит библиотеки: %v\nУдалите ненужные документы через /docs delete <id>", err))
+		return
+	case errors.Is(err, docs.ErrEmptyDocument):
+		b.sendMessage(msg.Chat.ID, "❌ В документе не найден текст")
+		return
+	case err != nil:
+		b.sendMessage(msg.Chat.ID, "❌ Не удалось сохранить документ")
+		log.Printf("❌ Docs: failed to add document: %v", err)
+		return
+	}
+
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("✅ Документ #%d «%s» сохранён: %d стр., %d фрагментов, %s.\nЗадайте вопрос: /ask_docs <вопрос>",
+		info.ID, info.Name, info.Pages, info.Chunks, formatBytes(info.Size)))
+}
+
+// handleDocsCommand /docs — список документов, /docs delete <id|all> — удаление
+func (b *Bot) handleDocsCommand(msg *tgbotapi.Message) {
+	if !b.authSvc.IsAllowed(msg.From.ID) {
+		return
+	}
+	if b.docsLibrary == nil {
+		b.sendMessage(msg.Chat.ID, "📚 Библиотека документов не настроена")
+		return
+	}
+
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) > 0 {
+		if !strings.EqualFold(args[0], "delete") || len(args) != 2 {
+			b.sendMessage(msg.Chat.ID, "Usage: /docs — список документов, /docs delete <id|all> — удаление")
+			return
+		}
+		if strings.EqualFold(args[1], "all") {
+			if err := b.docsLibrary.DeleteAll(msg.From.ID); err != nil {
+				b.sendMessage(msg.Chat.ID, fmt.Sprintf("Ошибка удаления: %v", err))
+				return
+			}
+			b.sendMessage(msg.Chat.ID, "🗑 Библиотека очищена")
+			return
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+		if err != nil {
+			b.sendMessage(msg.Chat.ID, "Некорректный id документа")
+			return
+		}
+		if err := b.docsLibrary.Delete(msg.From.ID, id); err != nil {
+			if errors.Is(err, docs.ErrNotFound) {
+				b.sendMessage(msg.Chat.ID, fmt.Sprintf("Документ #%d не найден", id))
+				return
+			}
+			b.sendMessage(msg.Chat.ID, fmt.Sprintf("Ошибка удаления: %v", err))
+			return
+		}
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("🗑 Документ #%d удалён", id))
+		return
+	}
+
+	list, err := b.docsLibrary.List(msg.From.ID)
+	if err != nil {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("Ошибка чтения библиотеки: %v", err))
+		return
+	}
+	if len(list) == 0 {
+		b.sendMessage(msg.Chat.ID, "📚 Библиотека пуста. Загрузите документ через /docs_upload")
+		return
+	}
+	var used int64
+	var bld strings.Builder
+	bld.WriteString("📚 Ваши документы:\n")
+	for _, d := range list {
+		used += d.Size
+		bld.WriteString(fmt.Sprintf("#%d %s — %d стр., %s, %s\n", d.ID, d.Name, d.Pages, formatBytes(d.Size), d.UploadedAt.Format("2006-01-02")))
+	}
+	maxBytes, maxDocs := b.docsLibrary.Limits()
+	bld.WriteString(fmt.Sprintf("\nИспользовано: %s", formatBytes(used)))
+	if maxBytes > 0 {
+		bld.WriteString(" из " + formatBytes(maxBytes))
+	}
+	if maxDocs > 0 {
+		bld.WriteString(fmt.Sprintf(", документов %d из %d", len(list), maxDocs))
+	}
+	bld.WriteString("\nУдаление: /docs delete <id|all>")
+	b.sendMessage(msg.Chat.ID, bld.String())
+}
+
+// handleAskDocsCommand отвечает на вопрос, всегда опираясь на библиотеку документов
+func (b *Bot) handleAskDocsCommand(ctx context.Context, msg *tgbotapi.Message) {
+	if !b.authSvc.IsAllowed(msg.From.ID) {
+		return
+	}
+	if b.docsLibrary == nil {
+		b.sendMessage(msg.Chat.ID, "📚 Библиотека документов не настроена")
+		return
+	}
+	question := strings.TrimSpace(msg.CommandArguments())
+	if question == "" {
+		b.sendMessage(msg.Chat.ID, "Usage: /ask_docs <вопрос>")
+		return
+	}
+
+	matches, err := b.docsLibrary.Search(ctx, msg.From.ID, question, docsTopK)
+	if err != nil {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("Ошибка поиска по библиотеке: %v", err))
+		return
+	}
+	if len(matches) == 0 {
+		b.sendMessage(msg.Chat.ID, "📚 В библиотеке не нашлось подходящих фрагментов. Список документов: /docs")
+		return
+	}
+
+	b.history.AppendUser(msg.From.ID, question)
+	if b.recorder != nil {
+		tru := true
+		_ = b.recorder.AppendInteraction(storage.Event{Timestamp: b.nowUTC(), UserID: msg.From.ID, ChatID: msg.Chat.ID, Username: msg.From.UserName, UserMessage: question, CanUse: &tru})
+	}
+	contextMsgs := insertDocsContext(b.buildContextWithOverflow(ctx, msg.From.ID), matches)
+	b.logLLMRequest(msg.From.ID, "ask_docs", contextMsgs)
+	resp, err := b.getUserLLMClient(msg.From.ID).Generate(ctx, contextMsgs)
+	if err != nil {
+		b.sendMessage(msg.Chat.ID, "Sorry, something went wrong.")
+		log.Printf("Something went wrong. %v", err)
+		return
+	}
+	b.processLLMAndRespond(ctx, msg.Chat.ID, msg.From.ID, resp)
+	b.sendMessage(msg.Chat.ID, "📚 Источники: "+docs.FormatSources(matches))
+}
+
+// withDocsContext автоматически подмешивает фрагменты библиотеки, если их оценка не ниже порога.
+// Фрагменты не попадают в историю, поэтому не участвуют в её сжатии и очистке
+func (b *Bot) withDocsContext(ctx context.Context, userID int64, question string, msgs []llm.Message) ([]llm.Message, []docs.Match) {
+	if b.docsLibrary == nil || b.docsThreshold <= 0 || strings.TrimSpace(question) == "" {
+		return msgs, nil
+	}
+	matches, err := b.docsLibrary.Search(ctx, userID, question, docsTopK)
+	if err != nil {
+		log.Printf("⚠️ Docs: search failed for user %d: %v", userID, err)
+		return msgs, nil
+	}
+	relevant := matches[:0]
+	for _, m := range matches {
+		if m.Score >= b.docsThreshold {
+			relevant = append(relevant, m)
+		}
+	}
+	if len(relevant) == 0 {
+		return msgs, nil
+	}
+	log.Printf("📚 Docs: adding %d chunks to context for user %d (top score %.2f)", len(relevant), userID, relevant[0].Score)
+	return insertDocsContext(msgs, relevant), relevant
+}
+
+// insertDocsContext вставляет фрагменты системным сообщением перед последним сообщением пользователя
+func insertDocsContext(msgs []llm.Message, matches []docs.Match) []llm.Message {
+	docsMsg := llm.Message{Role: "system", Content: docs.FormatContext(matches)}
+	if len(msgs) == 0 {
+		return []llm.Message{docsMsg}
+	}
+	out := make([]llm.Message, 0, len(msgs)+1)
+	out = append(out, msgs[:len(msgs)-1]...)
+	out = append(out, docsMsg, msgs[len(msgs)-1])
+	return out
+}
+
+// handleForgetMe удаляет все данные пользователя: историю, лог взаимодействий,
+// библиотеку документов, персональные промпт и модель
+func (b *Bot) handleForgetMe(msg *tgbotapi.Message) {
+	userID := msg.From.ID
+	if !b.authSvc.IsAllowed(userID) {
+		return
+	}
+	var failed []string
+
+	b.history.Reset(userID)
+	if b.recorder != nil {
+		if deleter, ok := b.recorder.(storage.UserDeleter); ok {
+			if err := deleter.DeleteUser(userID); err != nil {
+				failed = append(failed, "история")
+				log.Printf("❌ forget_me: failed to delete interactions of %d: %v", userID, err)
+			}
+		} else if err := b.recorder.SetAllCanUse(userID, false); err != nil {
+			failed = append(failed, "история")
+		}
+	}
+	if b.docsLibrary != nil {
+		if err := b.docsLibrary.DeleteAll(userID); err != nil {
+			failed = append(failed, "библиотека документов")
+			log.Printf("❌ forget_me: failed to delete docs of %d: %v", userID, err)
+		}
+		b.setDocsAwaiting(userID, false)
+	}
+	if b.reminders != nil {
+		if err := b.reminders.DeleteAll(userID); err != nil {
+			failed = append(failed, "напоминания")
+			log.Printf("❌ forget_me: failed to delete reminders of %d: %v", userID, err)
+		}
+	}
+	if b.promptScheduler != nil {
+		if err := b.promptScheduler.UnscheduleAll(userID); err != nil {
+			failed = append(failed, "запросы по расписанию")
+			log.Printf("❌ forget_me: failed to delete scheduled prompts of %d: %v", userID, err)
+		}
+	}
+	b.userSysMu.Lock()
+	delete(b.userSystemPrompt, userID)
+	b.userSysMu.Unlock()
+	b.clearTZState(userID)
+	if err := b.clearUserModelOverride(userID); err != nil {
+		failed = append(failed, "персональная модель")
+	}
+	if err := b.clearUserSettings(userID); err != nil {
+		failed = append(failed, "настройки")
+		log.Printf("❌ forget_me: failed to delete settings of %d: %v", userID, err)
+	}
+
+	if len(failed) > 0 {
+		b.sendMessage(msg.Chat.ID, "⚠️ Не удалось удалить: "+strings.Join(failed, ", ")+". Попробуйте ещё раз.")
+		return
+	}
+	log.Printf("🧹 forget_me: all data of user %d deleted", userID)
+	b.sendMessage(msg.Chat.ID, "🧹 Все ваши данные удалены: история диалога, документы, напоминания и персональные настройки.")
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f МБ", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f КБ", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d Б", n)
+	}
+}
@@ -0,0 +1,78 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/docs"
+	"ai-chatter/internal/history"
+	"ai-chatter/internal/llm"
+)
+
+func newDocsTestBot(t *testing.T, userID int64) (*Bot, *fakeSender, *fakeLLMSeq) {
+	t.Helper()
+	svc, _ := auth.NewWithRepo(nil, []int64{userID})
+	fs := &fakeSender{}
+	seq := &fakeLLMSeq{seq: []llm.Response{{Content: `{"title":"T","answer":"Удерживайте кнопку [manual.pdf, стр. 2]"}`, Model: "m"}}}
+	b := &Bot{s: fs, authSvc: svc, llmClient: seq, pending: make(map[int64]auth.User), parseMode: "HTML", history: history.NewManager()}
+	lib := docs.NewLibrary(t.TempDir(), nil, 0, 0)
+	if _, err := lib.Add(context.Background(), userID, "manual.pdf", []string{"Введение", "Чтобы сбросить настройки роутера, удерживайте кнопку reset"}); err != nil {
+		t.Fatalf("add doc: %v", err)
+	}
+	b.SetDocsLibrary(lib, 0.6)
+	return b, fs, seq
+}
+
+func TestHandleIncomingMessage_AddsRelevantDocsOutsideHistory(t *testing.T) {
+	b, fs, seq := newDocsTestBot(t, 5)
+
+	msg := &tgbotapi.Message{From: &tgbotapi.User{ID: 5}, Chat: &tgbotapi.Chat{ID: 5}, Text: "Как сбросить настройки роутера?"}
+	b.handleIncomingMessage(context.Background(), msg)
+
+	if len(seq.lastMsgs) != 1 {
+		t.Fatalf("expected one LLM call, got %d", len(seq.lastMsgs))
+	}
+	sent := seq.lastMsgs[0]
+	if len(sent) < 2 || sent[len(sent)-2].Role != "system" || !strings.Contains(sent[len(sent)-2].Content, "manual.pdf, стр. 2") {
+		t.Fatalf("docs context must precede the user message: %+v", sent)
+	}
+	for _, m := range b.history.GetAll(5) {
+		if strings.Contains(m.Content, "фрагменты из документов") {
+			t.Fatalf("docs context leaked into history: %q", m.Content)
+		}
+	}
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "Источники: manual.pdf, стр. 2") {
+		t.Fatalf("sources not sent: %q", last)
+	}
+
+	// Нерелевантный вопрос идёт без фрагментов
+	b.handleIncomingMessage(context.Background(), &tgbotapi.Message{From: &tgbotapi.User{ID: 5}, Chat: &tgbotapi.Chat{ID: 5}, Text: "Какая сегодня погода?"})
+	for _, m := range seq.lastMsgs[1] {
+		if m.Role == "system" && strings.Contains(m.Content, "manual.pdf") {
+			t.Fatalf("irrelevant question must not get docs context")
+		}
+	}
+}
+
+func TestForgetMe_DeletesLibraryAndHistory(t *testing.T) {
+	b, fs, _ := newDocsTestBot(t, 9)
+	b.history.AppendUser(9, "hello")
+
+	msg := &tgbotapi.Message{From: &tgbotapi.User{ID: 9}, Chat: &tgbotapi.Chat{ID: 9}, Text: "/forget_me"}
+	msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/forget_me")}}
+	b.handleCommand(msg)
+
+	if list, _ := b.docsLibrary.List(9); len(list) != 0 {
+		t.Fatalf("library must be empty: %+v", list)
+	}
+	if h := b.history.GetAll(9); len(h) != 0 {
+		t.Fatalf("history must be empty: %+v", h)
+	}
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "данные удалены") {
+		t.Fatalf("unexpected reply: %q", last)
+	}
+}
@@ -0,0 +1,129 @@
+package telegram
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/storage"
+)
+
+// handleExportCommand присылает архив с записанной историей пользователя: history.json и transcript.txt.
+// Администратор может указать id другого пользователя: /export <user_id>
+func (b *Bot) handleExportCommand(msg *tgbotapi.Message) {
+	userID := msg.From.ID
+	if !b.roleOf(userID).Allows(auth.RoleUser) {
+		return
+	}
+	if b.recorder == nil {
+		b.sendMessage(msg.Chat.ID, "История не записывается: журнал взаимодействий не настроен")
+		return
+	}
+
+	target := userID
+	if arg := strings.TrimSpace(msg.CommandArguments()); arg != "" {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			b.sendMessage(msg.Chat.ID, "Использование: /export [user_id]")
+			return
+		}
+		if id != userID && b.roleOf(userID) != auth.RoleAdmin {
+			b.sendMessage(msg.Chat.ID, "Выгрузка истории другого пользователя доступна только администратору")
+			return
+		}
+		target = id
+	}
+
+	events, err := b.recorder.LoadInteractions()
+	if err != nil {
+		log.Printf("❌ export: failed to load interactions: %v", err)
+		b.sendMessage(msg.Chat.ID, "Не удалось загрузить историю")
+		return
+	}
+	var own []storage.Event
+	for _, ev := range events {
+		if ev.UserID == target {
+			own = append(own, ev)
+		}
+	}
+	if len(own) == 0 {
+		b.sendMessage(msg.Chat.ID, "История пуста, нечего выгружать")
+		return
+	}
+
+	data, err := buildHistoryArchive(own, b.userLocation(userID))
+	if err != nil {
+		log.Printf("❌ export: failed to build archive for %d: %v", target, err)
+		b.sendMessage(msg.Chat.ID, "Не удалось собрать архив истории")
+		return
+	}
+
+	doc := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("chat-history-%d-%s.zip", target, time.Now().Format("20060102-150405")),
+		Bytes: data,
+	})
+	doc.Caption = fmt.Sprintf("📦 История пользователя %d: %d записей", target, len(own))
+	if target == userID && b.mcpClient != nil && b.notionParentFor(userID) != "" {
+		doc.ReplyMarkup = b.exportNotionKeyboard(msg.Chat.ID, userID)
+	}
+	if _, err := b.s.Send(doc); err != nil {
+		log.Printf("❌ export: failed to send archive: %v", err)
+		b.sendMessage(msg.Chat.ID, "Не удалось отправить архив")
+		return
+	}
+	log.Printf("📦 History of user %d exported by %d (%d events)", target, userID, len(own))
+}
+
+// buildHistoryArchive zip с событиями в JSON и читаемой расшифровкой
+func buildHistoryArchive(events []storage.Event, loc *time.Location) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	jsonData, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	w, err := zw.Create("history.json")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(jsonData); err != nil {
+		return nil, err
+	}
+
+	w, err = zw.Create("transcript.txt")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write([]byte(renderHistoryTranscript(events, loc))); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderHistoryTranscript текстовая расшифровка: время, роль и текст каждого сообщения
+func renderHistoryTranscript(events []storage.Event, loc *time.Location) string {
+	var sb strings.Builder
+	for _, ev := range events {
+		at := ev.Timestamp.In(loc).Format("2006-01-02 15:04:05")
+		if strings.TrimSpace(ev.UserMessage) != "" {
+			fmt.Fprintf(&sb, "[%s] Пользователь:\n%s\n\n", at, ev.UserMessage)
+		}
+		if strings.TrimSpace(ev.AssistantResponse) != "" {
+			fmt.Fprintf(&sb, "[%s] Ассистент:\n%s\n\n", at, ev.AssistantResponse)
+		}
+	}
+	return sb.String()
+}
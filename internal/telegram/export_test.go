@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/history"
+	"ai-chatter/internal/storage"
+)
+
+func TestExportCommand_SendsZipArchive(t *testing.T) {
+	svc, _ := auth.NewWithRepo(nil, []int64{3, 4})
+	rec, err := storage.NewFileRecorder(t.TempDir() + "/log.jsonl")
+	if err != nil {
+		t.Fatalf("recorder: %v", err)
+	}
+	at := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	_ = rec.AppendInteraction(storage.Event{Timestamp: at, UserID: 3, UserMessage: "привет"})
+	_ = rec.AppendInteraction(storage.Event{Timestamp: at, UserID: 3, AssistantResponse: "здравствуйте"})
+	_ = rec.AppendInteraction(storage.Event{Timestamp: at, UserID: 4, UserMessage: "чужое"})
+	fs := &fakeSender{}
+	b := &Bot{s: fs, authSvc: svc, adminUserID: 1, pending: make(map[int64]auth.User), history: history.NewManager(), recorder: rec}
+
+	b.handleCommand(newCommand(3, "/export"))
+	if len(fs.documents) != 1 {
+		t.Fatalf("expected archive, got messages %q", fs.sent)
+	}
+	files := readZip(t, fs.documents[0])
+	var events []storage.Event
+	if err := json.Unmarshal(files["history.json"], &events); err != nil || len(events) != 2 {
+		t.Fatalf("history.json: %v, %+v", err, events)
+	}
+	if txt := string(files["transcript.txt"]); !strings.Contains(txt, "[2025-03-01 09:00:00] Пользователь:\nпривет") || strings.Contains(txt, "чужое") {
+		t.Fatalf("unexpected transcript:\n%s", txt)
+	}
+
+	// Чужую историю выгружает только администратор
+	b.handleCommand(newCommand(3, "/export 4"))
+	if len(fs.documents) != 1 || !strings.Contains(fs.sent[len(fs.sent)-1], "только администратору") {
+		t.Fatalf("user must not export another user's history: %q", fs.sent)
+	}
+	b.handleCommand(newCommand(1, "/export 4"))
+	if len(fs.documents) != 2 || !strings.Contains(fs.documents[1].Caption, "пользователя 4: 1 записей") {
+		t.Fatalf("admin export failed: %+v", fs.documents)
+	}
+}
+
+func readZip(t *testing.T, doc tgbotapi.DocumentConfig) map[string][]byte {
+	t.Helper()
+	data := doc.File.(tgbotapi.FileBytes).Bytes
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	out := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		out[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	return out
+}
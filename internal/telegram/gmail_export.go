@@ -0,0 +1,115 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/gmail"
+	"ai-chatter/internal/notion"
+)
+
+const gmailExportUsage = "❌ Использование: /gmail_export [file|notion] <запрос Gmail>\n\n" +
+	"Пример: /gmail_export notion subject:\"Project X\"\n" +
+	"По умолчанию письма сохраняются в markdown файл"
+
+// handleGmailExportCommand экспортирует найденные письма в markdown файл или страницу Notion (только для админа)
+func (b *Bot) handleGmailExportCommand(msg *tgbotapi.Message) {
+	if msg.From.ID != b.adminUserID {
+		b.sendMessage(msg.Chat.ID, "❌ Команда доступна только администратору.")
+		return
+	}
+	if b.gmailClient == nil {
+		b.sendMessage(msg.Chat.ID, "❌ Gmail интеграция не настроена. Проверьте конфигурацию GMAIL_CREDENTIALS_JSON или GMAIL_CREDENTIALS_JSON_PATH.")
+		return
+	}
+
+	destination, query := parseGmailExportArgs(msg.CommandArguments())
+	if query == "" {
+		b.sendMessage(msg.Chat.ID, gmailExportUsage)
+		return
+	}
+	if destination == "notion" && (b.mcpClient == nil || b.notionParentPage == "") {
+		b.sendMessage(msg.Chat.ID, "❌ Для экспорта в Notion нужны NOTION_TOKEN и NOTION_PARENT_PAGE_ID.")
+		return
+	}
+
+	b.sendMessage(msg.Chat.ID, "🗂 Собираю письма для экспорта...")
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		result := b.gmailClient.ExportResults(ctx, gmail.GmailExportRequest{Query: query, Destination: destination})
+		if !result.Success {
+			b.sendMessage(msg.Chat.ID, "❌ Не удалось экспортировать письма: "+result.Message)
+			return
+		}
+		if result.TotalExported == 0 {
+			b.sendMessage(msg.Chat.ID, "📭 Письма по запросу не найдены.")
+			return
+		}
+
+		switch destination {
+		case "notion":
+			page, err := withNotionRetry(ctx, func() (notion.MCPResult, error) {
+				return b.mcpClient.CreateFreeFormPage(ctx, result.Title, result.Markdown, b.notionParentPage, []string{"gmail-export"})
+			})
+			if err != nil {
+				b.sendMessage(msg.Chat.ID, "❌ Не удалось создать страницу в Notion: "+notionErrorText(err))
+				return
+			}
+			b.sendMessage(msg.Chat.ID, strings.TrimSpace(fmt.Sprintf("✅ Экспортировано писем: %d\n📝 Notion: %s\n%s",
+				result.TotalExported, page.Page.URL, formatExportReport(result.Emails))))
+		default:
+			doc := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FileBytes{
+				Name:  fmt.Sprintf("gmail-export-%s.md", time.Now().Format("20060102-150405")),
+				Bytes: []byte(result.Markdown),
+			})
+			doc.Caption = fmt.Sprintf("📧 %s — писем: %d", result.Title, result.TotalExported)
+			if _, err := b.s.Send(doc); err != nil {
+				log.Printf("❌ Failed to send Gmail export document: %v", err)
+				b.sendMessage(msg.Chat.ID, "❌ Не удалось отправить файл экспорта")
+				return
+			}
+			if report := formatExportReport(result.Emails); report != "" {
+				b.sendMessage(msg.Chat.ID, report)
+			}
+		}
+		log.Printf("✅ Gmail export for query '%s' delivered to %s (%d emails)", query, destination, result.TotalExported)
+	}()
+}
+
+// parseGmailExportArgs отделяет необязательное назначение (file|notion) от запроса
+func parseGmailExportArgs(args string) (destination, query string) {
+	args = strings.TrimSpace(args)
+	destination = "file"
+	first, rest, _ := strings.Cut(args, " ")
+	switch strings.ToLower(first) {
+	case "file", "notion":
+		destination = strings.ToLower(first)
+		args = rest
+	}
+	return destination, strings.TrimSpace(args)
+}
+
+// formatExportReport перечисляет письма, попавшие в документ не полностью
+func formatExportReport(emails []gmail.GmailExportReport) string {
+	var sb strings.Builder
+	for _, e := range emails {
+		switch {
+		case e.Skipped:
+			sb.WriteString(fmt.Sprintf("\n- %s: пропущено (лимит размера)", e.Subject))
+		case e.Truncated:
+			sb.WriteString(fmt.Sprintf("\n- %s: обрезано, %d из %d байт", e.Subject, e.ExportedBytes, e.BodyBytes))
+		}
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	return "⚠️ Не полностью экспортированы:" + sb.String()
+}
@@ -0,0 +1,36 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	"ai-chatter/internal/gmail"
+)
+
+func TestParseGmailExportArgs(t *testing.T) {
+	cases := []struct{ in, dest, query string }{
+		{`notion subject:"Project X"`, "notion", `subject:"Project X"`},
+		{"File from:boss@example.com", "file", "from:boss@example.com"},
+		{"label:project-x", "file", "label:project-x"},
+		{"notion", "notion", ""},
+	}
+	for _, c := range cases {
+		dest, query := parseGmailExportArgs(c.in)
+		if dest != c.dest || query != c.query {
+			t.Errorf("parseGmailExportArgs(%q) = %q, %q; want %q, %q", c.in, dest, query, c.dest, c.query)
+		}
+	}
+}
+
+func TestFormatExportReport(t *testing.T) {
+	if got := formatExportReport([]gmail.GmailExportReport{{Subject: "ok", BodyBytes: 10, ExportedBytes: 10}}); got != "" {
+		t.Fatalf("complete export must not produce a report: %q", got)
+	}
+	got := formatExportReport([]gmail.GmailExportReport{
+		{Subject: "Long thread", BodyBytes: 5000, ExportedBytes: 1200, Truncated: true},
+		{Subject: "Late email", BodyBytes: 300, Truncated: true, Skipped: true},
+	})
+	if !strings.Contains(got, "Long thread: обрезано, 1200 из 5000 байт") || !strings.Contains(got, "Late email: пропущено") {
+		t.Fatalf("unexpected report: %q", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"html"
 	"io"
@@ -21,7 +22,11 @@ import (
 
 	"ai-chatter/internal/auth"
 	"ai-chatter/internal/codevalidation"
+	"ai-chatter/internal/docs"
+	"ai-chatter/internal/github"
 	"ai-chatter/internal/llm"
+	"ai-chatter/internal/messages"
+	"ai-chatter/internal/notion"
 	"ai-chatter/internal/release"
 	"ai-chatter/internal/storage"
 )
@@ -159,6 +164,38 @@ func (pt *ProgressTracker) buildProgressMessage() string {
 
 // handleCommand
 func (b *Bot) handleCommand(msg *tgbotapi.Message) {
+	if b.replyMaintenance(msg) {
+		return
+	}
+	// /topup доступна и пользователям вне allowlist, но только при настроенных платежах
+	if msg.Command() == topupCommand && b.paymentsEnabled() {
+		b.trackUsage(msg.From.ID, "/"+topupCommand)
+		b.handleTopupCommand(msg)
+		return
+	}
+	if !b.requireRole(msg.Chat.ID, msg.From.ID, commandRole(msg.Command())) {
+		return
+	}
+	b.trackUsage(msg.From.ID, "/"+msg.Command())
+	if msg.Command() == "onboarding" {
+		b.handleOnboardingCommand(msg)
+		return
+	}
+	if msg.Command() == "stats" {
+		b.handleStatsCommand(msg)
+		return
+	}
+	// /model без аргументов, /model reset и /model <provider> <name> — персональная модель пользователя
+	if msg.Command() == "model" {
+		if args := strings.Fields(msg.CommandArguments()); len(args) != 1 || strings.EqualFold(args[0], "reset") {
+			b.handleUserModelCommand(msg)
+			return
+		}
+	}
+	if msg.Command() == "llm_status" || msg.Command() == "profile" {
+		b.handleProfileCommands(msg)
+		return
+	}
 	if msg.Command() == "provider" || msg.Command() == "model" || msg.Command() == "model2" {
 		b.handleAdminConfigCommands(msg)
 		return
@@ -179,6 +216,14 @@ func (b *Bot) handleCommand(msg *tgbotapi.Message) {
 		b.handleNotionSave(msg)
 		return
 	}
+	if msg.Command() == "export" {
+		b.handleExportCommand(msg)
+		return
+	}
+	if msg.Command() == "export_notion" {
+		b.handleExportNotionCommand(context.Background(), msg)
+		return
+	}
 	if msg.Command() == "notion_search" {
 		b.handleNotionSearch(msg)
 		return
@@ -191,6 +236,10 @@ func (b *Bot) handleCommand(msg *tgbotapi.Message) {
 		b.handleGmailSummaryCommand(msg)
 		return
 	}
+	if msg.Command() == "gmail_export" {
+		b.handleGmailExportCommand(msg)
+		return
+	}
 	if msg.Command() == "release_rc" {
 		b.handleReleaseRCCommand(msg)
 		return
@@ -199,6 +248,49 @@ func (b *Bot) handleCommand(msg *tgbotapi.Message) {
 		b.handleAIReleaseCommand(msg)
 		return
 	}
+	if msg.Command() == "publish_rustore" {
+		b.handlePublishRuStoreCommand(context.Background(), msg)
+		return
+	}
+	if msg.Command() == "reset" {
+		if !b.authSvc.IsAllowed(msg.From.ID) {
+			return
+		}
+		b.resetContext(msg.From.ID)
+		b.sendMessage(msg.Chat.ID, "Контекст очищен")
+		return
+	}
+
+	// Document library commands
+	switch msg.Command() {
+	case docsUploadCmd:
+		b.handleDocsUploadCommand(msg)
+		return
+	case "docs":
+		b.handleDocsCommand(msg)
+		return
+	case "ask_docs":
+		b.handleAskDocsCommand(context.Background(), msg)
+		return
+	case "forget_me":
+		b.handleForgetMe(msg)
+		return
+	}
+	if msg.Command() == "remind" {
+		b.handleRemindCommand(msg)
+		return
+	}
+	switch msg.Command() {
+	case "schedule":
+		b.handleScheduleCommand(msg)
+		return
+	case "schedules":
+		b.handleSchedulesCommand(msg)
+		return
+	case "unschedule":
+		b.handleUnscheduleCommand(msg)
+		return
+	}
 	if msg.Command() == "tz" {
 		if !b.authSvc.IsAllowed(msg.From.ID) {
 			return
@@ -222,7 +314,7 @@ func (b *Bot) handleCommand(msg *tgbotapi.Message) {
 		b.history.AppendUser(msg.From.ID, seed)
 		if b.recorder != nil {
 			tru := true
-			_ = b.recorder.AppendInteraction(storage.Event{Timestamp: b.nowUTC(), UserID: msg.From.ID, UserMessage: seed, CanUse: &tru})
+			_ = b.recorder.AppendInteraction(storage.Event{Timestamp: b.nowUTC(), UserID: msg.From.ID, ChatID: msg.Chat.ID, Username: msg.From.UserName, UserMessage: seed, CanUse: &tru})
 		}
 		ctx := context.Background()
 		contextMsgs := b.buildContextWithOverflow(ctx, msg.From.ID)
@@ -234,7 +326,7 @@ func (b *Bot) handleCommand(msg *tgbotapi.Message) {
 			}
 		}
 		b.logLLMRequest(msg.From.ID, "tz_bootstrap", contextMsgs)
-		resp, err := b.getLLMClient().Generate(ctx, contextMsgs)
+		resp, err := b.getUserLLMClient(msg.From.ID).Generate(ctx, contextMsgs)
 		if err != nil {
 			b.sendMessage(msg.Chat.ID, "Не удалось стартовать режим ТЗ, попробуйте ещё раз.")
 			log.Println(err)
@@ -253,9 +345,11 @@ func (b *Bot) handleCommand(msg *tgbotapi.Message) {
 		var bld strings.Builder
 		bld.WriteString("Allowlist:\n")
 		for _, u := range b.authSvc.List() {
-			bld.WriteString(fmt.Sprintf("- id=%d, @%s %s %s\n", u.ID, u.Username, u.FirstName, u.LastName))
+			bld.WriteString(fmt.Sprintf("- id=%d, @%s %s %s [%s]\n", u.ID, u.Username, u.FirstName, u.LastName, b.authSvc.RoleOf(u.ID)))
 		}
 		b.sendMessage(msg.Chat.ID, bld.String())
+	case "role":
+		b.handleRoleCommand(msg.Chat.ID, strings.Fields(msg.CommandArguments()))
 	case "remove":
 		args := strings.Fields(msg.CommandArguments())
 		if len(args) != 1 {
@@ -272,13 +366,21 @@ func (b *Bot) handleCommand(msg *tgbotapi.Message) {
 			return
 		}
 		b.sendMessage(msg.Chat.ID, fmt.Sprintf("Пользователь %d удален из allowlist", uid))
-	case "pending":
-		var bld strings.Builder
-		bld.WriteString("Pending заявки:\n")
-		for _, u := range b.pending {
-			bld.WriteString(fmt.Sprintf("- id=%d, @%s %s %s\n", u.ID, u.Username, u.FirstName, u.LastName))
-		}
-		b.sendMessage(msg.Chat.ID, bld.String())
+	case "callbacks":
+		st := b.CallbackStats()
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("Inline-кнопки: ожидают %d, устаревших нажатий %d, неизвестных %d", st.Active, st.Expired, st.Unknown))
+	case "pending", "ban", "unban":
+		b.handleAccessAdminCommand(msg)
+	case "refund", "payments":
+		b.handlePaymentsAdminCommand(msg)
+	case "admin_llm_log":
+		b.handleLLMLogCommand(msg)
+	case "admin_audit":
+		b.handleAuditCommand(msg)
+	case "admin_allow", "admin_deny":
+		b.handleAllowlistAdminCommand(msg)
+	case "maintenance":
+		b.handleMaintenanceCommand(msg)
 	case "approve":
 		args := strings.Fields(msg.CommandArguments())
 		if len(args) != 1 {
@@ -306,27 +408,80 @@ func (b *Bot) handleCommand(msg *tgbotapi.Message) {
 	}
 }
 
+// handleUnauthorizedMessage сообщение пользователя вне allowlist: заявка на доступ администратору
+func (b *Bot) handleUnauthorizedMessage(msg *tgbotapi.Message) {
+	log.Printf("Unauthorized access attempt by user ID: %d, username: @%s", msg.From.ID, msg.From.UserName)
+	switch b.requestAccess(msg.From, msg.Text) {
+	case accessRequestDuplicate:
+		b.sendMessage(msg.Chat.ID, b.userMessage(messages.PendingRequest, msg.From, 0)+b.topupHint())
+	case accessRequestCreated:
+		b.sendMessage(msg.Chat.ID, b.userMessage(messages.AccessDenied, msg.From, 0)+b.topupHint())
+		b.notifyAdminRequest(msg.From.ID, msg.From.UserName)
+	}
+}
+
 // handleIncomingMessage
 func (b *Bot) handleIncomingMessage(ctx context.Context, msg *tgbotapi.Message) {
-	if !b.authSvc.IsAllowed(msg.From.ID) {
-		log.Printf("Unauthorized access attempt by user ID: %d, username: @%s", msg.From.ID, msg.From.UserName)
-		if _, ok := b.pending[msg.From.ID]; ok {
-			b.sendMessage(msg.Chat.ID, "Ваш запрос на доступ уже отправлен администратору. Пожалуйста, ожидайте подтверждения. Как только доступ будет предоставлен, я уведомлю вас.")
-			return
+	if b.replyMaintenance(msg) {
+		return
+	}
+	// Пользователь вне allowlist может писать в счёт купленной квоты (/topup): только диалог с моделью
+	paidAccess := !b.authSvc.IsAllowed(msg.From.ID) && b.consumePaidMessage(msg.Chat.ID, msg.From.ID)
+	if !paidAccess {
+		// Решение по каждому сообщению пишется в журнал аудита; купленные сообщения ограничены квотой,
+		// администратор — без ограничения частоты
+		var err error
+		if msg.From.ID != b.adminUserID {
+			err = b.authSvc.Check(msg.From.ID, msg.From.UserName)
+		} else if !b.authSvc.IsAllowed(msg.From.ID) {
+			err = auth.ErrNotAllowed
 		}
-		b.pending[msg.From.ID] = auth.User{ID: msg.From.ID, Username: msg.From.UserName, FirstName: msg.From.FirstName, LastName: msg.From.LastName}
-		if b.pendingRepo != nil {
-			_ = b.pendingRepo.Upsert(b.pending[msg.From.ID])
+		switch {
+		case errors.Is(err, auth.ErrNotAllowed):
+			b.handleUnauthorizedMessage(msg)
+			return
+		case errors.Is(err, auth.ErrRateLimited):
+			var limited *auth.RateLimitError
+			errors.As(err, &limited)
+			log.Printf("⏳ Rate limited user %d (@%s)", msg.From.ID, msg.From.UserName)
+			b.sendMessage(msg.Chat.ID, b.userMessage(messages.QuotaExceeded, msg.From, limited.RetryAfterSeconds()))
+			return
 		}
-		b.sendMessage(msg.Chat.ID, "Запрос на доступ отправлен администратору. Как только он подтвердит, вы получите уведомление.")
-		b.notifyAdminRequest(msg.From.ID, msg.From.UserName)
+	}
+	if b.roleOf(msg.From.ID) == auth.RoleReadonly {
+		m := tgbotapi.NewMessage(msg.Chat.ID, b.escapeIfNeeded(readonlyAccessText))
+		m.ParseMode = b.parseModeValue()
+		m.ReplyMarkup = b.menuKeyboard()
+		_, _ = b.s.Send(m)
+		return
+	}
+	// Ответы на шаги онбординга; первое сообщение нового пользователя запускает онбординг
+	if !paidAccess && b.handleOnboardingInput(ctx, msg) {
+		return
+	}
+	// Загрузка документа в библиотеку (/docs_upload)
+	if !paidAccess && b.isDocsUpload(msg) {
+		b.handleDocsUpload(ctx, msg)
 		return
 	}
+	// Голосовое сообщение обрабатывается как текст его расшифровки
+	if _, _, _, isVoice := voiceSource(msg); isVoice {
+		text, ok := b.transcribeVoice(ctx, msg)
+		if !ok {
+			return
+		}
+		voiceMsg := *msg
+		voiceMsg.Text = text
+		msg = &voiceMsg
+		b.setVoiceTranscript(msg.Chat.ID, text)
+		// Расшифровка нужна только ответу на это сообщение
+		defer b.takeVoiceTranscript(msg.Chat.ID)
+	}
 	log.Printf("Incoming message from %d (@%s): %q", msg.From.ID, msg.From.UserName, msg.Text)
 	b.history.AppendUser(msg.From.ID, msg.Text)
 	if b.recorder != nil {
 		tru := true
-		_ = b.recorder.AppendInteraction(storage.Event{Timestamp: b.nowUTC(), UserID: msg.From.ID, UserMessage: msg.Text, CanUse: &tru})
+		_ = b.recorder.AppendInteraction(storage.Event{Timestamp: b.nowUTC(), UserID: msg.From.ID, ChatID: msg.Chat.ID, Username: msg.From.UserName, UserMessage: msg.Text, CanUse: &tru})
 	}
 
 	if b.isTZMode(msg.From.ID) && b.getTZRemaining(msg.From.ID) <= 0 {
@@ -336,7 +491,7 @@ func (b *Bot) handleIncomingMessage(ctx context.Context, msg *tgbotapi.Message)
 		}
 	}
 
-	contextMsgs := b.buildContextWithOverflow(ctx, msg.From.ID)
+	contextMsgs := withReplyContext(b.buildContextWithOverflow(ctx, msg.From.ID), msg)
 	if b.isTZMode(msg.From.ID) {
 		left := b.getTZRemaining(msg.From.ID)
 		if left > 0 && left <= 2 {
@@ -358,7 +513,10 @@ func (b *Bot) handleIncomingMessage(ctx context.Context, msg *tgbotapi.Message)
 	}
 
 	// Проверяем активную VibeCoding сессию
-	if b.vibeCodingHandler != nil && !b.isTZMode(msg.From.ID) && msg.Document == nil {
+	if b.vibeCodingHandler != nil && !b.isTZMode(msg.From.ID) && msg.Document == nil && b.roleOf(msg.From.ID).Allows(auth.RoleAdmin) {
+		if b.vibeCodingHandler.HasSession(msg.From.ID) {
+			b.trackUsage(msg.From.ID, "vibecoding:message")
+		}
 		// Проверяем, есть ли активная vibecoding сессия у пользователя
 		if err := b.vibeCodingHandler.HandleVibeCodingMessage(ctx, msg.From.ID, msg.Chat.ID, msg.Text); err == nil {
 			// Сообщение было обработано в vibecoding режиме
@@ -389,14 +547,31 @@ func (b *Bot) handleIncomingMessage(ctx context.Context, msg *tgbotapi.Message)
 		}
 	}
 
+	// Подмешиваем релевантные фрагменты из библиотеки документов пользователя
+	var docsMatches []docs.Match
+	if !b.isTZMode(msg.From.ID) {
+		contextMsgs, docsMatches = b.withDocsContext(ctx, msg.From.ID, msg.Text, contextMsgs)
+	}
+
 	// Используем инструменты Notion только если клиент настроен и не в режиме ТЗ
 	var resp llm.Response
 	var err error
+	client := b.getUserLLMClient(msg.From.ID)
 	if b.mcpClient != nil && !b.isTZMode(msg.From.ID) {
 		tools := llm.GetNotionTools()
-		resp, err = b.getLLMClient().GenerateWithTools(ctx, contextMsgs, tools)
+		resp, err = client.GenerateWithTools(ctx, contextMsgs, tools)
 	} else {
-		resp, err = b.getLLMClient().Generate(ctx, contextMsgs)
+		// Потоковый ответ вне режима ТЗ; без поддержки стриминга у провайдера — обычная генерация
+		streamed := false
+		if b.streamingEnabled() && !b.isTZMode(msg.From.ID) {
+			var preview *streamPreview
+			ctx, preview = withStreamPreview(ctx, msg.Chat.ID)
+			defer b.discardStreamPreview(preview)
+			resp, streamed, err = b.generateStreaming(ctx, msg.Chat.ID, client, contextMsgs)
+		}
+		if !streamed {
+			resp, err = client.Generate(ctx, contextMsgs)
+		}
 	}
 
 	if err != nil {
@@ -405,6 +580,9 @@ func (b *Bot) handleIncomingMessage(ctx context.Context, msg *tgbotapi.Message)
 		return
 	}
 	b.processLLMAndRespond(ctx, msg.Chat.ID, msg.From.ID, resp)
+	if len(docsMatches) > 0 {
+		b.sendMessage(msg.Chat.ID, "📚 Источники: "+docs.FormatSources(docsMatches))
+	}
 }
 
 // notifyAdminRequest
@@ -413,10 +591,16 @@ func (b *Bot) notifyAdminRequest(userID int64, username string) {
 		return
 	}
 	text := fmt.Sprintf("Пользователь @%s с id %d хочет пользоваться ботом", username, userID)
+	if _, ok := b.pending[userID]; ok {
+		text = "Заявка на доступ: " + b.accessRequestDescription(userID)
+	}
+	payload := strconv.FormatInt(userID, 10)
+	group := "allowlist:" + payload
 	kb := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("разрешить", approvePrefix+strconv.FormatInt(userID, 10)),
-			tgbotapi.NewInlineKeyboardButtonData("запретить", denyPrefix+strconv.FormatInt(userID, 10)),
+			tgbotapi.NewInlineKeyboardButtonData("разрешить", b.registerCallbackAction(callbackAllowlistApprove, payload, b.adminUserID, b.adminUserID, group)),
+			tgbotapi.NewInlineKeyboardButtonData("запретить", b.registerCallbackAction(callbackAllowlistDeny, payload, b.adminUserID, b.adminUserID, group)),
+			tgbotapi.NewInlineKeyboardButtonData("заблокировать", b.registerCallbackAction(callbackAllowlistBan, payload, b.adminUserID, b.adminUserID, group)),
 		),
 	)
 	msg := tgbotapi.NewMessage(b.adminUserID, b.escapeIfNeeded(text))
@@ -429,10 +613,7 @@ func (b *Bot) notifyAdminRequest(userID int64, username string) {
 func (b *Bot) handleCallback(ctx context.Context, cb *tgbotapi.CallbackQuery) {
 	switch {
 	case cb.Data == resetCmd:
-		b.history.DisableAll(cb.From.ID)
-		if b.recorder != nil {
-			_ = b.recorder.SetAllCanUse(cb.From.ID, false)
-		}
+		b.resetContext(cb.From.ID)
 		msg := tgbotapi.NewMessage(cb.Message.Chat.ID, b.escapeIfNeeded("Контекст очищен"))
 		msg.ParseMode = b.parseModeValue()
 		msg.ReplyMarkup = b.menuKeyboard()
@@ -441,22 +622,46 @@ func (b *Bot) handleCallback(ctx context.Context, cb *tgbotapi.CallbackQuery) {
 		}
 	case cb.Data == summaryCmd:
 		b.handleSummary(ctx, cb)
+	case strings.HasPrefix(cb.Data, callbackActionPrefix):
+		b.handleCallbackAction(ctx, cb)
+	case strings.HasPrefix(cb.Data, topupCallbackPrefix):
+		b.handleTopupCallback(cb)
 	default:
+		// approve:/deny: — кнопки, отправленные до появления хранилища действий
 		switch {
 		case strings.HasPrefix(cb.Data, approvePrefix):
 			idStr := strings.TrimPrefix(cb.Data, approvePrefix)
 			id, _ := strconv.ParseInt(idStr, 10, 64)
+			if _, ok := b.pending[id]; !ok {
+				b.expiredCallbacks.Add(1)
+				b.answerCallback(cb, callbackExpiredText)
+				return
+			}
 			b.approveUser(id)
 		case strings.HasPrefix(cb.Data, denyPrefix):
 			idStr := strings.TrimPrefix(cb.Data, denyPrefix)
 			id, _ := strconv.ParseInt(idStr, 10, 64)
+			if _, ok := b.pending[id]; !ok {
+				b.expiredCallbacks.Add(1)
+				b.answerCallback(cb, callbackExpiredText)
+				return
+			}
 			b.denyUser(id)
+		default:
+			b.unknownCallbacks.Add(1)
+			log.Printf("⚠️ Unknown callback data %q from user %d", cb.Data, cb.From.ID)
+			b.answerCallback(cb, callbackExpiredText)
+			return
 		}
+		b.answerCallback(cb, "")
 	}
 }
 
 // handleSummary
 func (b *Bot) handleSummary(ctx context.Context, cb *tgbotapi.CallbackQuery) {
+	if !b.roleOf(cb.From.ID).Allows(auth.RoleReadonly) {
+		return
+	}
 	h := b.history.Get(cb.From.ID)
 	if len(h) == 0 {
 		m := tgbotapi.NewMessage(cb.Message.Chat.ID, b.escapeIfNeeded("История пуста"))
@@ -467,7 +672,7 @@ func (b *Bot) handleSummary(ctx context.Context, cb *tgbotapi.CallbackQuery) {
 	msgs := b.buildContextWithOverflow(ctx, cb.From.ID)
 	msgs = append([]llm.Message{{Role: "system", Content: "Суммируй переписку. Ответ строго в JSON со схемой {title, answer, compressed_context}."}}, msgs...)
 	b.logLLMRequest(cb.From.ID, "summary", msgs)
-	resp, err := b.getLLMClient().Generate(ctx, msgs)
+	resp, err := b.getUserLLMClient(cb.From.ID).Generate(ctx, msgs)
 	if err != nil {
 		m := tgbotapi.NewMessage(cb.Message.Chat.ID, b.escapeIfNeeded("Не удалось собрать саммари"))
 		m.ParseMode = b.parseModeValue()
@@ -492,7 +697,7 @@ func (b *Bot) handleSummary(ctx context.Context, cb *tgbotapi.CallbackQuery) {
 	b.history.AppendAssistantWithUsed(cb.From.ID, answerToSend, true)
 	if b.recorder != nil {
 		tru := true
-		_ = b.recorder.AppendInteraction(storage.Event{Timestamp: b.nowUTC(), UserID: cb.From.ID, AssistantResponse: answerToSend, CanUse: &tru})
+		_ = b.recorder.AppendInteraction(storage.Event{Timestamp: b.nowUTC(), UserID: cb.From.ID, ChatID: cb.Message.Chat.ID, AssistantResponse: answerToSend, CanUse: &tru, Tokens: resp.TotalTokens, Model: resp.Model})
 	}
 	metaLine := fmt.Sprintf("[model=%s, tokens: prompt=%d, completion=%d, total=%d]", resp.Model, resp.PromptTokens, resp.CompletionTokens, resp.TotalTokens)
 	metaEsc := b.escapeIfNeeded(metaLine)
@@ -500,10 +705,10 @@ func (b *Bot) handleSummary(ctx context.Context, cb *tgbotapi.CallbackQuery) {
 	if ok && parsed.Title != "" {
 		body = b.formatTitleAnswer(parsed.Title, answerToSend)
 	}
-	final := metaEsc + "\n\n" + body
+	final := metaEsc + "\n\n" + body + b.fallbackFooterText(resp)
 	m := tgbotapi.NewMessage(cb.Message.Chat.ID, final)
 	m.ParseMode = b.parseModeValue()
-	m.ReplyMarkup = b.menuKeyboard()
+	m.ReplyMarkup = b.exportNotionKeyboard(cb.Message.Chat.ID, cb.From.ID)
 	_, _ = b.s.Send(m)
 }
 
@@ -544,25 +749,28 @@ func (b *Bot) handleNotionSave(msg *tgbotapi.Message) {
 	ctx := context.Background()
 
 	// Проверяем настройку parent page
-	if b.notionParentPage == "" {
+	parentPage := b.notionParentFor(msg.From.ID)
+	if parentPage == "" {
 		b.sendMessage(msg.Chat.ID, "❌ Не настроен NOTION_PARENT_PAGE_ID. Настройте переменную окружения с ID страницы из Notion.")
 		return
 	}
 
-	result := b.mcpClient.CreateDialogSummary(
-		ctx,
-		args, // title
-		content.String(),
-		fmt.Sprintf("%d", msg.From.ID),
-		msg.From.UserName,
-		"dialog_summary",
-		b.notionParentPage,
-	)
-
-	if result.Success {
-		b.sendMessage(msg.Chat.ID, fmt.Sprintf("✅ Диалог успешно сохранен в Notion!\n\n%s", result.Message))
+	result, err := withNotionRetry(ctx, func() (notion.MCPResult, error) {
+		return b.mcpClient.CreateDialogSummary(
+			ctx,
+			args, // title
+			content.String(),
+			fmt.Sprintf("%d", msg.From.ID),
+			msg.From.UserName,
+			"dialog_summary",
+			parentPage,
+		)
+	})
+
+	if err == nil {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("✅ Диалог успешно сохранен в Notion!\n\n%s\n📝 %s", result.Message, result.Page.URL))
 	} else {
-		b.sendMessage(msg.Chat.ID, fmt.Sprintf("❌ Ошибка сохранения в Notion: %s", result.Message))
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("❌ Ошибка сохранения в Notion: %s", notionErrorText(err)))
 	}
 }
 
@@ -584,17 +792,19 @@ func (b *Bot) handleNotionSearch(msg *tgbotapi.Message) {
 	}
 
 	ctx := context.Background()
-	result := b.mcpClient.SearchDialogSummaries(
-		ctx,
-		args,
-		fmt.Sprintf("%d", msg.From.ID),
-		"dialog_summary",
-	)
-
-	if result.Success {
+	result, err := withNotionRetry(ctx, func() (notion.MCPResult, error) {
+		return b.mcpClient.SearchDialogSummaries(
+			ctx,
+			args,
+			fmt.Sprintf("%d", msg.From.ID),
+			"dialog_summary",
+		)
+	})
+
+	if err == nil {
 		b.sendMessage(msg.Chat.ID, fmt.Sprintf("🔍 Результаты поиска в Notion:\n\n%s", result.Message))
 	} else {
-		b.sendMessage(msg.Chat.ID, fmt.Sprintf("❌ Ошибка поиска в Notion: %s", result.Message))
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("❌ Ошибка поиска в Notion: %s", notionErrorText(err)))
 	}
 }
 
@@ -823,18 +1033,9 @@ func (b *Bot) handleCodeValidation(ctx context.Context, msg *tgbotapi.Message, c
 func (b *Bot) downloadAndProcessFile(file tgbotapi.File, filename string) (map[string]string, error) {
 	log.Printf("📥 Downloading file: %s", filename)
 
-	// Скачиваем файл
-	fileURL := file.Link(b.api.Token)
-	resp, err := http.Get(fileURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to download file: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Читаем весь контент файла в память
-	content, err := io.ReadAll(resp.Body)
+	content, err := b.downloadFileBytes(file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file content: %w", err)
+		return nil, err
 	}
 
 	log.Printf("📁 Processing file: %s, size: %d bytes", filename, len(content))
@@ -858,6 +1059,22 @@ func (b *Bot) downloadAndProcessFile(file tgbotapi.File, filename string) (map[s
 	}
 }
 
+// downloadFileBytes скачивает содержимое файла Telegram в память
+func (b *Bot) downloadFileBytes(file tgbotapi.File) ([]byte, error) {
+	fileURL := file.Link(b.api.Token)
+	resp, err := http.Get(fileURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file content: %w", err)
+	}
+	return content, nil
+}
+
 // processZipArchive обрабатывает ZIP архивы
 func (b *Bot) processZipArchive(data []byte, filename string) (map[string]string, error) {
 	log.Printf("📦 Processing ZIP archive: %s", filename)
@@ -992,6 +1209,9 @@ func isArchiveFile(filename string) bool {
 
 // handleVibeCodingArchive обрабатывает загрузку архива для VibeCoding режима
 func (b *Bot) handleVibeCodingArchive(ctx context.Context, msg *tgbotapi.Message) {
+	if !b.requireRole(msg.Chat.ID, msg.From.ID, auth.RoleAdmin) {
+		return
+	}
 	log.Printf("🔥 Starting VibeCoding archive processing for user %d", msg.From.ID)
 
 	// Получаем файл от Telegram
@@ -1020,6 +1240,7 @@ func (b *Bot) handleVibeCodingArchive(ctx context.Context, msg *tgbotapi.Message
 	}
 
 	// Передаем обработку VibeCoding handler
+	b.trackUsage(msg.From.ID, "vibecoding:upload")
 	err = b.vibeCodingHandler.HandleArchiveUpload(
 		ctx,
 		msg.From.ID,
@@ -1058,12 +1279,46 @@ func (b *Bot) handleReleaseRCCommand(msg *tgbotapi.Message) {
 		"📦 Ищу последний pre-release в репозитории GitHub...\n"+
 		"🎯 Репозиторий: AndVl1/SnakeGame")
 
+	// --force публикует даже при красных проверках CI
+	force := strings.Contains(msg.CommandArguments(), "--force")
+
 	// Запускаем процесс в горутине
-	go b.processReleaseRC(context.Background(), msg.Chat.ID)
+	go b.processReleaseRC(context.Background(), msg.Chat.ID, force)
+}
+
+// checkReleaseRef проверяет CI для тега релиза; на красном или непроверяемом ref публикация
+// продолжается только с --force
+func (b *Bot) checkReleaseRef(ctx context.Context, chatID int64, owner, repo, ref string, force bool) bool {
+	b.updateReleaseStatus(chatID, fmt.Sprintf("🚦 Проверка CI для %s...", ref))
+
+	status, err := b.githubClient.GetRefStatus(ctx, owner, repo, ref)
+	if err != nil {
+		if force {
+			b.updateReleaseStatus(chatID, fmt.Sprintf("⚠️ Не удалось проверить CI: %v\nПродолжаю из-за --force", err))
+			return true
+		}
+		b.updateReleaseStatus(chatID, fmt.Sprintf("❌ Не удалось проверить CI: %v\nЧтобы опубликовать без проверки, используйте /release_rc --force", err))
+		return false
+	}
+
+	summary := github.FormatRefStatus(*status)
+	switch status.Verdict {
+	case github.RefVerdictFailure:
+		if !force {
+			b.updateReleaseStatus(chatID, summary+"\n\n❌ Публикация остановлена: проверки CI не прошли. Чтобы опубликовать всё равно, используйте /release_rc --force")
+			return false
+		}
+		b.updateReleaseStatus(chatID, summary+"\n\n⚠️ Проверки CI не прошли, продолжаю из-за --force")
+	case github.RefVerdictPending:
+		b.updateReleaseStatus(chatID, summary+"\n\n⚠️ Проверки CI ещё выполняются")
+	default:
+		b.updateReleaseStatus(chatID, summary)
+	}
+	return true
 }
 
 // processReleaseRC выполняет весь процесс публикации RC
-func (b *Bot) processReleaseRC(ctx context.Context, chatID int64) {
+func (b *Bot) processReleaseRC(ctx context.Context, chatID int64, force bool) {
 	// Константы
 	const (
 		repoOwner = "AndVl1"
@@ -1082,6 +1337,10 @@ func (b *Bot) processReleaseRC(ctx context.Context, chatID int64) {
 	b.updateReleaseStatus(chatID, fmt.Sprintf("✅ Найден pre-release: **%s** (%s)\n📅 Опубликован: %s",
 		latestPreRelease.Name, latestPreRelease.TagName, latestPreRelease.PublishedAt.Format("2006-01-02 15:04")))
 
+	if !b.checkReleaseRef(ctx, chatID, repoOwner, repoName, latestPreRelease.TagName, force) {
+		return
+	}
+
 	// Шаг 2: Ищем Android файл среди ассетов (AAB предпочтительно, APK как fallback)
 	b.updateReleaseStatus(chatID, "🔍 Поиск Android файла в релизе...")
 
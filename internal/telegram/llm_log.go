@@ -0,0 +1,83 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/storage"
+)
+
+const (
+	// llmLogDefaultEntries сколько записей журнала показывает /admin_llm_log без аргумента
+	llmLogDefaultEntries = 5
+	llmLogMaxEntries     = 20
+	// llmLogPreviewRunes длина промпта и ответа в сообщении: записи журнала обрезаны до 500 символов,
+	// а в Telegram нужно уместить несколько записей
+	llmLogPreviewRunes = 200
+)
+
+// SetLLMRequestLog подключает журнал запросов к LLM для /admin_llm_log
+func (b *Bot) SetLLMRequestLog(rec storage.LLMRequestRecorder) {
+	b.llmRequestLog = rec
+}
+
+// handleLLMLogCommand /admin_llm_log [n] — последние n запросов к LLM из журнала
+func (b *Bot) handleLLMLogCommand(msg *tgbotapi.Message) {
+	if b.llmRequestLog == nil {
+		b.sendMessage(msg.Chat.ID, "Журнал запросов к LLM выключен: включите LLM_LOG_REQUESTS=true")
+		return
+	}
+	limit := llmLogDefaultEntries
+	if arg := strings.TrimSpace(msg.CommandArguments()); arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n <= 0 {
+			b.sendMessage(msg.Chat.ID, "Usage: /admin_llm_log [число записей]")
+			return
+		}
+		limit = min(n, llmLogMaxEntries)
+	}
+	entries, err := b.llmRequestLog.RecentLLMRequests(limit)
+	if err != nil {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("Не удалось прочитать журнал: %v", err))
+		return
+	}
+	out := tgbotapi.NewMessage(msg.Chat.ID, b.escapeIfNeeded(formatLLMLog(entries)))
+	out.ParseMode = b.parseModeValue()
+	b.sendLongMessage(out)
+}
+
+// formatLLMLog записи журнала для администратора: модель, токены, длительность, последнее сообщение запроса и ответ
+func formatLLMLog(entries []storage.LLMRequestLog) string {
+	if len(entries) == 0 {
+		return "Журнал запросов к LLM пуст"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📝 Запросы к LLM (последние %d)\n", len(entries)))
+	for _, e := range entries {
+		sb.WriteString(fmt.Sprintf("\n[%s] %s · %d ток. · %s · %d сообщ.\n",
+			e.Timestamp.UTC().Format("2006-01-02 15:04:05"), e.Model, e.Tokens, e.Duration.Round(time.Millisecond), len(e.Messages)))
+		if n := len(e.Messages); n > 0 {
+			last := e.Messages[n-1]
+			sb.WriteString(fmt.Sprintf("→ %s: %s\n", last.Role, llmLogPreview(last.Content)))
+		}
+		if e.Error != "" {
+			sb.WriteString("❌ " + llmLogPreview(e.Error) + "\n")
+		} else {
+			sb.WriteString("← " + llmLogPreview(e.Response) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+func llmLogPreview(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) > llmLogPreviewRunes {
+		return string([]rune(s)[:llmLogPreviewRunes]) + "…"
+	}
+	return s
+}
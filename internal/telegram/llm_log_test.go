@@ -0,0 +1,53 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ai-chatter/internal/storage"
+)
+
+// memoryLLMLog журнал запросов к LLM в памяти
+type memoryLLMLog struct {
+	entries []storage.LLMRequestLog
+	limit   int
+}
+
+func (m *memoryLLMLog) AppendLLMRequest(entry storage.LLMRequestLog) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *memoryLLMLog) RecentLLMRequests(limit int) ([]storage.LLMRequestLog, error) {
+	m.limit = limit
+	return m.entries, nil
+}
+
+func TestAdminLLMLogCommand(t *testing.T) {
+	fs := &fakeSender{}
+	b := &Bot{s: fs, adminUserID: 1}
+
+	b.handleCommand(newAdminCmd("/admin_llm_log"))
+	if len(fs.sent) != 1 || !strings.Contains(fs.sent[0], "LLM_LOG_REQUESTS") {
+		t.Fatalf("disabled log must be explained, got %q", fs.sent)
+	}
+
+	rec := &memoryLLMLog{entries: []storage.LLMRequestLog{
+		{Timestamp: time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC), Model: "gpt", Tokens: 12, Duration: 1500 * time.Millisecond,
+			Messages: []storage.LLMLogMessage{{Role: "system", Content: "sys"}, {Role: "user", Content: "Привет"}}, Response: "Здравствуйте"},
+		{Timestamp: time.Date(2026, 10, 17, 9, 31, 0, 0, time.UTC), Model: "gpt", Error: "upstream 502"},
+	}}
+	b.SetLLMRequestLog(rec)
+	b.handleCommand(newAdminCmd("/admin_llm_log 100"))
+
+	if rec.limit != llmLogMaxEntries {
+		t.Errorf("entry count must be capped at %d, got %d", llmLogMaxEntries, rec.limit)
+	}
+	text := fs.sent[len(fs.sent)-1]
+	for _, want := range []string{"2026-10-17 09:30:00", "→ user: Привет", "← Здравствуйте", "❌ upstream 502", "1.5s"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("log dump must contain %q:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/messages"
+	"ai-chatter/internal/scheduler"
+)
+
+const maintenanceUsage = "Использование: /maintenance on [время окончания] [сообщение] | off\n" +
+	"Время: in 30m, через 2ч, 18:30, 2025-03-01 10:00 — как в /remind\n" +
+	"/maintenance — текущее состояние"
+
+// maintenanceState режим обслуживания и отложенные на это время уведомления пользователей.
+// Сохраняется в MAINTENANCE_FILE_PATH и переживает перезапуск при деплое
+type maintenanceState struct {
+	On      bool      `json:"on"`
+	Message string    `json:"message,omitempty"` // пояснение для пользователей
+	Until   time.Time `json:"until,omitempty"`   // ожидаемое окончание, нулевое — не указано
+	Since   time.Time `json:"since,omitempty"`
+	// Напоминания и запросы по расписанию пользователей, пришедшиеся на обслуживание;
+	// отправляются при /maintenance off. Запрос по расписанию хранится один раз, сколько бы раз он ни сработал
+	Reminders []scheduler.Reminder        `json:"reminders,omitempty"`
+	Prompts   []scheduler.ScheduledPrompt `json:"prompts,omitempty"`
+}
+
+// SetMaintenanceFile подключает файл состояния режима обслуживания и загружает сохранённое состояние
+func (b *Bot) SetMaintenanceFile(path string) {
+	b.maintenanceMu.Lock()
+	defer b.maintenanceMu.Unlock()
+	b.maintenancePath = path
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("⚠️ Failed to read maintenance state: %v", err)
+		}
+		return
+	}
+	var state maintenanceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		log.Printf("⚠️ Failed to parse maintenance state: %v", err)
+		return
+	}
+	b.maintenance = state
+	b.pauseVibeCodingIdleClose(state.On)
+	if state.On {
+		log.Printf("🛠 Maintenance mode restored (since %s), %d reminders and %d scheduled prompts deferred",
+			state.Since.Format(time.RFC3339), len(state.Reminders), len(state.Prompts))
+	}
+}
+
+// SetMaintenance режим обслуживания: всем, кроме администратора, бот отвечает сообщением maintenance
+// (MAINTENANCE_MODE=true при запуске). Выключение здесь не рассылает отложенные уведомления, см. /maintenance off
+func (b *Bot) SetMaintenance(on bool) {
+	b.maintenanceMu.Lock()
+	defer b.maintenanceMu.Unlock()
+	if on == b.maintenance.On {
+		return
+	}
+	b.maintenance.On = on
+	if on {
+		b.maintenance.Since = time.Now()
+	}
+	b.pauseVibeCodingIdleClose(on)
+	if err := b.saveMaintenanceUnlocked(); err != nil {
+		log.Printf("⚠️ Failed to save maintenance state: %v", err)
+	}
+}
+
+// pauseVibeCodingIdleClose на время обслуживания сессии VibeCoding не закрываются по бездействию:
+// иначе пользователь, которому бот не отвечает, потерял бы сохранённую сессию и получил уведомление о закрытии
+func (b *Bot) pauseVibeCodingIdleClose(on bool) {
+	if b.vibeCodingHandler != nil {
+		b.vibeCodingHandler.SetMaintenance(on)
+	}
+}
+
+// saveMaintenanceUnlocked записывает состояние через временный файл; без MAINTENANCE_FILE_PATH ничего не делает
+func (b *Bot) saveMaintenanceUnlocked() error {
+	if b.maintenancePath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(b.maintenance, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.maintenancePath), 0o755); err != nil {
+		return err
+	}
+	tmp := b.maintenancePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.maintenancePath)
+}
+
+// maintenanceSnapshot копия текущего состояния режима обслуживания
+func (b *Bot) maintenanceSnapshot() maintenanceState {
+	b.maintenanceMu.Lock()
+	defer b.maintenanceMu.Unlock()
+	return b.maintenance
+}
+
+// maintenanceNotice сообщение maintenance для пользователя: его язык, пояснение администратора и время окончания в его часовом поясе
+func (b *Bot) maintenanceNotice(from *tgbotapi.User) string {
+	state := b.maintenanceSnapshot()
+	data := messages.Data{Message: state.Message}
+	if !state.Until.IsZero() {
+		data.ETA = b.formatReminderTime(from.ID, state.Until)
+	}
+	return b.renderUserMessage(messages.Maintenance, from, data)
+}
+
+// replyMaintenance отвечает сообщением maintenance в режиме обслуживания; true — обработка закончена
+func (b *Bot) replyMaintenance(msg *tgbotapi.Message) bool {
+	if msg.From == nil || msg.From.ID == b.adminUserID || !b.maintenanceSnapshot().On {
+		return false
+	}
+	b.sendMessage(msg.Chat.ID, b.maintenanceNotice(msg.From))
+	return true
+}
+
+// deferReminder откладывает напоминание пользователя до конца обслуживания; true — напоминание отложено
+func (b *Bot) deferReminder(r scheduler.Reminder) bool {
+	b.maintenanceMu.Lock()
+	defer b.maintenanceMu.Unlock()
+	if !b.maintenance.On || r.UserID == b.adminUserID {
+		return false
+	}
+	b.maintenance.Reminders = append(b.maintenance.Reminders, r)
+	if err := b.saveMaintenanceUnlocked(); err != nil {
+		log.Printf("⚠️ Failed to save deferred reminder %d: %v", r.ID, err)
+	}
+	log.Printf("🛠 Reminder %d for user %d deferred until maintenance ends", r.ID, r.UserID)
+	return true
+}
+
+// deferScheduledPrompt откладывает запрос по расписанию до конца обслуживания; true — запрос отложен
+func (b *Bot) deferScheduledPrompt(p scheduler.ScheduledPrompt) bool {
+	b.maintenanceMu.Lock()
+	defer b.maintenanceMu.Unlock()
+	if !b.maintenance.On || p.UserID == b.adminUserID {
+		return false
+	}
+	for _, queued := range b.maintenance.Prompts {
+		if queued.ID == p.ID {
+			return true
+		}
+	}
+	b.maintenance.Prompts = append(b.maintenance.Prompts, p)
+	if err := b.saveMaintenanceUnlocked(); err != nil {
+		log.Printf("⚠️ Failed to save deferred scheduled prompt %d: %v", p.ID, err)
+	}
+	log.Printf("🛠 Scheduled prompt %d for user %d deferred until maintenance ends", p.ID, p.UserID)
+	return true
+}
+
+// handleMaintenanceCommand /maintenance on [время] [сообщение] | off — режим обслуживания на время деплоя.
+// Вызывается только для администратора
+func (b *Bot) handleMaintenanceCommand(msg *tgbotapi.Message) {
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) == 0 {
+		b.sendMessage(msg.Chat.ID, b.formatMaintenanceStatus())
+		return
+	}
+	switch strings.ToLower(args[0]) {
+	case "on":
+		b.startMaintenance(msg.Chat.ID, strings.Join(args[1:], " "))
+	case "off":
+		b.stopMaintenance(msg.Chat.ID)
+	default:
+		b.sendMessage(msg.Chat.ID, maintenanceUsage)
+	}
+}
+
+// startMaintenance включает режим обслуживания: время окончания разбирается как в /remind, остаток — сообщение.
+// Активные сессии VibeCoding получают предупреждение и сохраняются, их закрытие по бездействию приостанавливается
+func (b *Bot) startMaintenance(chatID int64, args string) {
+	var until time.Time
+	message := args
+	if args != "" {
+		now := time.Now().In(b.userLocation(b.adminUserID))
+		if t, rest, err := scheduler.ParseReminderTime(args, now); err == nil {
+			until, message = t, rest
+		}
+	}
+
+	b.maintenanceMu.Lock()
+	wasOn := b.maintenance.On
+	b.maintenance.On = true
+	b.maintenance.Message = strings.TrimSpace(message)
+	b.maintenance.Until = until
+	if !wasOn {
+		b.maintenance.Since = time.Now()
+	}
+	err := b.saveMaintenanceUnlocked()
+	b.maintenanceMu.Unlock()
+	b.pauseVibeCodingIdleClose(true)
+	if err != nil {
+		log.Printf("⚠️ Failed to save maintenance state: %v", err)
+		b.sendMessage(chatID, fmt.Sprintf("⚠️ Режим обслуживания включён, но не сохранён и не переживёт перезапуск: %v", err))
+	}
+	log.Printf("🛠 Maintenance mode enabled by admin")
+
+	var sb strings.Builder
+	sb.WriteString("🛠 Режим обслуживания включён. Пользователи видят:\n")
+	sb.WriteString(b.maintenanceNotice(&tgbotapi.User{ID: b.adminUserID}))
+	if b.vibeCodingHandler != nil && !wasOn {
+		notice := "🛠 Бот переходит в режим обслуживания."
+		if !until.IsZero() {
+			notice = fmt.Sprintf("🛠 Бот переходит в режим обслуживания примерно до %s.", b.formatReminderTime(b.adminUserID, until))
+		}
+		if n := b.vibeCodingHandler.WarnMaintenance(notice); n > 0 {
+			sb.WriteString(fmt.Sprintf("\n\nПредупреждено и сохранено сессий VibeCoding: %d", n))
+		}
+	}
+	b.sendMessage(chatID, sb.String())
+}
+
+// stopMaintenance выключает режим обслуживания и отправляет отложенные уведомления: напоминания сразу,
+// запросы по расписанию (ответ LLM) — в фоне
+func (b *Bot) stopMaintenance(chatID int64) {
+	b.maintenanceMu.Lock()
+	if !b.maintenance.On {
+		b.maintenanceMu.Unlock()
+		b.sendMessage(chatID, "Режим обслуживания не включён")
+		return
+	}
+	reminders, prompts := b.maintenance.Reminders, b.maintenance.Prompts
+	b.maintenance = maintenanceState{}
+	err := b.saveMaintenanceUnlocked()
+	b.maintenanceMu.Unlock()
+	b.pauseVibeCodingIdleClose(false)
+	if err != nil {
+		log.Printf("⚠️ Failed to save maintenance state: %v", err)
+	}
+	log.Printf("🛠 Maintenance mode disabled by admin, flushing %d reminders and %d scheduled prompts", len(reminders), len(prompts))
+
+	ctx := context.Background()
+	failed := 0
+	for _, r := range reminders {
+		if err := b.SendReminder(ctx, r); err != nil {
+			failed++
+			log.Printf("❌ Failed to send deferred reminder %d for user %d: %v", r.ID, r.UserID, err)
+		}
+	}
+	text := "✅ Режим обслуживания выключен."
+	if len(reminders) > 0 {
+		text += fmt.Sprintf("\nОтложенных напоминаний отправлено: %d из %d", len(reminders)-failed, len(reminders))
+	}
+	if len(prompts) > 0 {
+		text += fmt.Sprintf("\nОтложенных запросов по расписанию выполняется: %d", len(prompts))
+		go b.runDeferredPrompts(ctx, prompts)
+	}
+	b.sendMessage(chatID, text)
+}
+
+// runDeferredPrompts выполняет запросы по расписанию, отложенные на время обслуживания
+func (b *Bot) runDeferredPrompts(ctx context.Context, prompts []scheduler.ScheduledPrompt) {
+	for _, p := range prompts {
+		if err := b.RunScheduledPrompt(ctx, p); err != nil {
+			log.Printf("❌ Deferred scheduled prompt %d for user %d failed: %v", p.ID, p.UserID, err)
+		}
+	}
+}
+
+// formatMaintenanceStatus состояние режима обслуживания для /maintenance без аргументов
+func (b *Bot) formatMaintenanceStatus() string {
+	state := b.maintenanceSnapshot()
+	if !state.On {
+		return "Режим обслуживания выключен.\n" + maintenanceUsage
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🛠 Режим обслуживания включён с %s", b.formatReminderTime(b.adminUserID, state.Since)))
+	if !state.Until.IsZero() {
+		sb.WriteString(fmt.Sprintf(", окончание примерно в %s", b.formatReminderTime(b.adminUserID, state.Until)))
+	}
+	if state.Message != "" {
+		sb.WriteString("\nСообщение: " + state.Message)
+	}
+	sb.WriteString(fmt.Sprintf("\nОтложено напоминаний: %d, запросов по расписанию: %d", len(state.Reminders), len(state.Prompts)))
+	return sb.String()
+}
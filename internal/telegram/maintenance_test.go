@@ -0,0 +1,93 @@
+package telegram
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/scheduler"
+)
+
+func newMaintenanceBot(t *testing.T, path string) (*Bot, *fakeSender) {
+	t.Helper()
+	svc, _ := auth.NewWithRepo(nil, []int64{1, 5})
+	fs := &fakeSender{}
+	b := &Bot{s: fs, authSvc: svc, adminUserID: 1, pending: make(map[int64]auth.User)}
+	b.SetMessages(nil, "")
+	b.SetMaintenanceFile(path)
+	return b, fs
+}
+
+func TestMaintenanceCommand_NoticeWithMessageAndETA(t *testing.T) {
+	b, fs := newMaintenanceBot(t, filepath.Join(t.TempDir(), "maintenance.json"))
+
+	b.handleCommand(newAdminCmd("/maintenance on in 2h переезд на новый сервер"))
+	state := b.maintenanceSnapshot()
+	if !state.On || state.Message != "переезд на новый сервер" || state.Until.IsZero() {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+	if d := time.Until(state.Until); d < time.Hour || d > 3*time.Hour {
+		t.Errorf("ETA must be about 2h from now, got %v", d)
+	}
+
+	fs.sent = nil
+	b.handleIncomingMessage(context.Background(), &tgbotapi.Message{From: &tgbotapi.User{ID: 5}, Chat: &tgbotapi.Chat{ID: 5}, Text: "hi"})
+	want := "🛠 Бот на техническом обслуживании примерно до " + b.formatReminderTime(5, state.Until) + ", попробуйте позже. переезд на новый сервер"
+	if len(fs.sent) != 1 || fs.sent[0] != want {
+		t.Fatalf("notice = %q, want %q", fs.sent, want)
+	}
+
+	fs.sent = nil
+	b.handleCommand(newAdminCmd("/maintenance"))
+	if len(fs.sent) != 1 || !strings.Contains(fs.sent[0], "Сообщение: переезд на новый сервер") {
+		t.Errorf("status = %q", fs.sent)
+	}
+}
+
+func TestMaintenance_PersistsAndFlushesDeferredReminders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "maintenance.json")
+	b, fs := newMaintenanceBot(t, path)
+	b.handleCommand(newAdminCmd("/maintenance on"))
+
+	ctx := context.Background()
+	if err := b.SendReminder(ctx, scheduler.Reminder{ID: 1, UserID: 5, ChatID: 5, Text: "позвонить"}); err != nil {
+		t.Fatalf("SendReminder: %v", err)
+	}
+	if err := b.SendReminder(ctx, scheduler.Reminder{ID: 2, UserID: 1, ChatID: 1, Text: "проверить деплой"}); err != nil {
+		t.Fatalf("SendReminder: %v", err)
+	}
+	prompt := scheduler.ScheduledPrompt{ID: 3, UserID: 5, ChatID: 5, Prompt: "сводка"}
+	for i := 0; i < 2; i++ {
+		if err := b.RunScheduledPrompt(ctx, prompt); err != nil {
+			t.Fatalf("RunScheduledPrompt: %v", err)
+		}
+	}
+	if got := strings.Join(fs.sent, "|"); strings.Contains(got, "позвонить") || !strings.Contains(got, "проверить деплой") {
+		t.Fatalf("user reminders must wait, admin reminders must not: %q", fs.sent)
+	}
+
+	// Перезапуск: состояние и очередь восстанавливаются из файла
+	restarted, rfs := newMaintenanceBot(t, path)
+	state := restarted.maintenanceSnapshot()
+	if !state.On || len(state.Reminders) != 1 || len(state.Prompts) != 1 {
+		t.Fatalf("state is not restored: %+v", state)
+	}
+
+	// Запрос по расписанию уходит в фоне; здесь проверяется только очередь напоминаний
+	restarted.maintenance.Prompts = nil
+	restarted.handleCommand(newAdminCmd("/maintenance off"))
+	if got := strings.Join(rfs.sent, "|"); !strings.Contains(got, "⏰ Напоминание: позвонить") || !strings.Contains(got, "отправлено: 1 из 1") {
+		t.Errorf("deferred reminder must be sent on off: %q", rfs.sent)
+	}
+	if state := restarted.maintenanceSnapshot(); state.On || len(state.Reminders) != 0 {
+		t.Errorf("maintenance must be cleared: %+v", state)
+	}
+	if again, _ := newMaintenanceBot(t, path); again.maintenanceSnapshot().On {
+		t.Error("off must be persisted")
+	}
+}
@@ -0,0 +1,30 @@
+package telegram
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/messages"
+)
+
+// SetMessages подключает тексты сообщений пользователям из MESSAGES_FILE_PATH (nil — встроенные)
+// и контакт администратора для {{.AdminContact}}
+func (b *Bot) SetMessages(f *messages.File, adminContact string) {
+	b.userMessages = f
+	b.adminContact = adminContact
+}
+
+// userMessage текст сообщения на языке, выбранном пользователем в онбординге
+func (b *Bot) userMessage(key string, from *tgbotapi.User, resetTime int) string {
+	return b.renderUserMessage(key, from, messages.Data{ResetTime: resetTime})
+}
+
+// renderUserMessage как userMessage, но с дополнительными полями шаблона; имя и контакт администратора подставляются здесь
+func (b *Bot) renderUserMessage(key string, from *tgbotapi.User, data messages.Data) string {
+	s, _ := b.getUserSettings(from.ID)
+	data.Name = from.FirstName
+	if data.Name == "" {
+		data.Name = from.UserName
+	}
+	data.AdminContact = b.adminContact
+	return b.userMessages.Render(key, s.Language, data)
+}
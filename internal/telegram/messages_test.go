@@ -0,0 +1,60 @@
+package telegram
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/messages"
+)
+
+func TestUserMessages_FromFileInUserLanguage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.txt")
+	content := "welcome = Добро пожаловать, {{.Name}}!\nwelcome.en = Welcome, {{.Name}}!\naccess_denied = Заявка у администратора {{.AdminContact}}\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mf, err := messages.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	svc, _ := auth.NewWithRepo(nil, nil)
+	fs := &fakeSender{}
+	b := &Bot{s: fs, authSvc: svc, adminUserID: 1, pending: make(map[int64]auth.User)}
+	b.SetMessages(mf, "@owner")
+
+	start := &tgbotapi.Message{From: &tgbotapi.User{ID: 7, FirstName: "Анна"}, Chat: &tgbotapi.Chat{ID: 7}, Text: "/start"}
+	b.handleStart(start)
+	if len(fs.sent) == 0 || fs.sent[0] != "Добро пожаловать, Анна!\n\nЗаявка у администратора @owner" {
+		t.Fatalf("unexpected welcome: %q", fs.sent)
+	}
+
+	b.updateUserSettings(8, func(s *userSettings) { s.Language = "en" })
+	b.handleStart(&tgbotapi.Message{From: &tgbotapi.User{ID: 8, UserName: "bob"}, Chat: &tgbotapi.Chat{ID: 8}, Text: "/start"})
+	if !strings.Contains(strings.Join(fs.sent, "|"), "|Welcome, bob!\n\nЗаявка у администратора @owner|") {
+		t.Errorf("user language must pick the translation, got %q", fs.sent)
+	}
+}
+
+func TestMaintenanceMode_RepliesToEveryoneButAdmin(t *testing.T) {
+	svc, _ := auth.NewWithRepo(nil, []int64{5})
+	fs := &fakeSender{}
+	b := &Bot{s: fs, authSvc: svc, adminUserID: 1, pending: make(map[int64]auth.User)}
+	b.SetMessages(nil, "@owner")
+	b.SetMaintenance(true)
+
+	b.handleIncomingMessage(context.Background(), &tgbotapi.Message{From: &tgbotapi.User{ID: 5}, Chat: &tgbotapi.Chat{ID: 5}, Text: "hi"})
+	b.handleCommand(newCommand(5, "/help"))
+	want := "🛠 Бот на техническом обслуживании, попробуйте позже. Вопросы: @owner"
+	if len(fs.sent) != 2 || fs.sent[0] != want || fs.sent[1] != want {
+		t.Fatalf("users must get the maintenance message, got %q", fs.sent)
+	}
+	if b.replyMaintenance(newAdminCmd("/status")) {
+		t.Error("admin must keep working during maintenance")
+	}
+}
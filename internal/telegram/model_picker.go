@@ -0,0 +1,188 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/llm"
+	"ai-chatter/internal/storage"
+)
+
+const (
+	callbackModelProvider = "model_provider"
+	callbackModelSelect   = "model_select"
+	callbackModelBack     = "model_back"
+	callbackModelReset    = "model_reset"
+)
+
+// SetModelCatalog задаёт курируемый список провайдеров и моделей для /model (nil — каталог по умолчанию фабрики)
+func (b *Bot) SetModelCatalog(catalog *llm.ModelCatalog) {
+	b.userModelMu.Lock()
+	defer b.userModelMu.Unlock()
+	b.modelCatalog = catalog
+}
+
+// modelCatalogFor каталог для выбора через кнопки: заданный в конфиге или построенный по настроенным провайдерам
+func (b *Bot) modelCatalogFor() *llm.ModelCatalog {
+	b.userModelMu.RLock()
+	catalog := b.modelCatalog
+	b.userModelMu.RUnlock()
+	if catalog != nil {
+		return catalog
+	}
+	if b.llmFactory == nil {
+		return nil
+	}
+	return b.llmFactory.DefaultModelCatalog()
+}
+
+// userModelChoices модели провайдера, доступные пользователю: модели только для администратора скрываются от остальных
+func (b *Bot) userModelChoices(userID int64, provider string) []llm.CatalogModel {
+	catalog := b.modelCatalogFor()
+	if catalog == nil {
+		return nil
+	}
+	return catalog.Models(provider, b.roleOf(userID) == auth.RoleAdmin)
+}
+
+// currentModelText описание активной модели пользователя
+func (b *Bot) currentModelText(userID int64) string {
+	if ov, ok := b.getUserModelOverride(userID); ok {
+		return fmt.Sprintf("Ваша модель: %s / %s (персональная)\nОбщая модель бота: %s / %s", ov.Provider, ov.Model, b.provider, b.model)
+	}
+	return fmt.Sprintf("Ваша модель: %s / %s (общая)", b.provider, b.model)
+}
+
+// sendModelPicker показывает текущую модель и клавиатуру выбора провайдера
+func (b *Bot) sendModelPicker(chatID, userID int64) {
+	text, kb := b.modelProvidersView(chatID, userID)
+	msg := tgbotapi.NewMessage(chatID, b.escapeIfNeeded(text))
+	msg.ParseMode = b.parseModeValue()
+	if kb != nil {
+		msg.ReplyMarkup = *kb
+	}
+	if _, err := b.s.Send(msg); err != nil {
+		log.Printf("failed to send model picker: %v", err)
+	}
+}
+
+// modelProvidersView текст и кнопки первого шага: выбор провайдера. Без каталога — только текст и подсказка
+func (b *Bot) modelProvidersView(chatID, userID int64) (string, *tgbotapi.InlineKeyboardMarkup) {
+	text := b.currentModelText(userID)
+	var providers []string
+	if catalog := b.modelCatalogFor(); catalog != nil {
+		providers = catalog.Providers(b.roleOf(userID) == auth.RoleAdmin)
+	}
+	if len(providers) == 0 {
+		return text + "\nUsage: /model <provider> <name>, /model reset", nil
+	}
+
+	group := "model:" + newCallbackActionID()
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, p := range providers {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(p, b.registerCallbackAction(callbackModelProvider, p, userID, chatID, group)))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	if _, ok := b.getUserModelOverride(userID); ok {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Сбросить на общую", b.registerCallbackAction(callbackModelReset, "", userID, chatID, group)),
+		))
+	}
+	kb := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return text + "\n\nВыберите провайдера:", &kb
+}
+
+// modelListView кнопки моделей провайдера; текущая модель отмечена ✅, модели администратора — 🔒
+func (b *Bot) modelListView(chatID, userID int64, provider string) (string, *tgbotapi.InlineKeyboardMarkup) {
+	current, _ := b.getUserModelOverride(userID)
+	group := "model:" + newCallbackActionID()
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, m := range b.userModelChoices(userID, provider) {
+		label := m.Name
+		if m.AdminOnly {
+			label = "🔒 " + label
+		}
+		if current.Provider == provider && current.Model == m.Name {
+			label = "✅ " + label
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, b.registerCallbackAction(callbackModelSelect, provider+"|"+m.Name, userID, chatID, group)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("⬅️ Провайдеры", b.registerCallbackAction(callbackModelBack, "", userID, chatID, group)),
+	))
+	kb := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return fmt.Sprintf("%s\n\nМодели %s:", b.currentModelText(userID), provider), &kb
+}
+
+// handleModelPickerCallback обрабатывает кнопки /model; владелец кнопки уже проверен
+func (b *Bot) handleModelPickerCallback(cb *tgbotapi.CallbackQuery, action storage.CallbackAction) {
+	userID := cb.From.ID
+	if !b.canOverrideModel(userID) {
+		b.answerCallback(cb, "Команда доступна только администратору")
+		return
+	}
+	chatID := action.ChatID
+	if cb.Message != nil {
+		chatID = cb.Message.Chat.ID
+	}
+
+	switch action.Type {
+	case callbackModelProvider:
+		text, kb := b.modelListView(chatID, userID, action.Payload)
+		b.editModelPicker(cb, chatID, text, kb)
+		b.answerCallback(cb, "")
+	case callbackModelBack:
+		text, kb := b.modelProvidersView(chatID, userID)
+		b.editModelPicker(cb, chatID, text, kb)
+		b.answerCallback(cb, "")
+	case callbackModelSelect:
+		prov, model, _ := strings.Cut(action.Payload, "|")
+		if err := b.selectUserModel(userID, prov, model); err != nil {
+			b.answerCallback(cb, err.Error())
+			text, kb := b.modelProvidersView(chatID, userID)
+			b.editModelPicker(cb, chatID, text, kb)
+			return
+		}
+		b.editModelPicker(cb, chatID, fmt.Sprintf("Персональная модель установлена: %s / %s", prov, model), nil)
+		b.answerCallback(cb, "Модель выбрана")
+	case callbackModelReset:
+		if err := b.clearUserModelOverride(userID); err != nil {
+			b.answerCallback(cb, fmt.Sprintf("Ошибка сохранения: %v", err))
+			return
+		}
+		b.editModelPicker(cb, chatID, fmt.Sprintf("Персональная модель сброшена, используется общая: %s / %s", b.provider, b.model), nil)
+		b.answerCallback(cb, "")
+	}
+}
+
+// editModelPicker заменяет сообщение с кнопками; kb=nil убирает клавиатуру
+func (b *Bot) editModelPicker(cb *tgbotapi.CallbackQuery, chatID int64, text string, kb *tgbotapi.InlineKeyboardMarkup) {
+	if cb.Message == nil {
+		msg := tgbotapi.NewMessage(chatID, b.escapeIfNeeded(text))
+		msg.ParseMode = b.parseModeValue()
+		if kb != nil {
+			msg.ReplyMarkup = *kb
+		}
+		_, _ = b.s.Send(msg)
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, b.escapeIfNeeded(text))
+	edit.ParseMode = b.parseModeValue()
+	edit.ReplyMarkup = kb
+	if _, err := b.s.Send(edit); err != nil {
+		log.Printf("failed to update model picker: %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+// Package msgsplit разбивает длинные ответы LLM на сообщения Telegram: не рвёт блоки кода
+// (закрывает ``` в конце части и открывает заново в следующей), предпочитает границы абзацев,
+// учитывает экранирование parse mode и перекладывает не поместившийся остаток в .txt файл
+package msgsplit
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// MaxMessageLength лимит Telegram на текст сообщения в UTF-16 единицах
+	MaxMessageLength = 4096
+	// DefaultLimit длина части по умолчанию: запас до MaxMessageLength под счётчик частей и пометку об обрезке
+	DefaultLimit = 3900
+	// DefaultMaxParts сколько сообщений отправить, прежде чем переложить остаток в файл
+	DefaultMaxParts = 5
+	// DefaultFileName имя файла с остатком ответа
+	DefaultFileName = "answer.txt"
+	// minLimit меньше нельзя: часть должна вместить заново открытый блок кода и хоть немного текста
+	minLimit = 64
+
+	fence            = "```"
+	truncatedNotice  = "✂️ Ответ не поместился в сообщения, продолжение во вложении"
+	overflowCaption  = "📎 Продолжение ответа"
+	partCounterFmt   = "\n\n📄 Часть %d из %d"
+	paragraphDivider = "\n\n"
+)
+
+// Options параметры разбиения
+type Options struct {
+	// Limit максимальная длина части после Escape (без счётчика частей); 0 — DefaultLimit
+	Limit int
+	// MaxParts максимальное число сообщений, остаток уходит в Overflow; 0 — DefaultMaxParts
+	MaxParts int
+	// Escape экранирование parse mode, которое применяется к каждой части при отправке;
+	// nil — текст уже размечен и отправляется как есть
+	Escape func(string) string
+	// ParseMode режим разметки сообщений. Для уже размеченного текста (Escape == nil) не даёт
+	// резать внутри HTML тега или сущности и сразу после '\' в MarkdownV2
+	ParseMode string
+	// FileName имя .txt файла с остатком; по умолчанию DefaultFileName
+	FileName string
+}
+
+func (o Options) withDefaults() Options {
+	if o.Limit == 0 {
+		o.Limit = DefaultLimit
+	}
+	if o.Limit < minLimit {
+		o.Limit = minLimit
+	}
+	if o.MaxParts <= 0 {
+		o.MaxParts = DefaultMaxParts
+	}
+	if o.FileName == "" {
+		o.FileName = DefaultFileName
+	}
+	return o
+}
+
+// Result части текста в исходном виде (до Escape) и остаток, не поместившийся в MaxParts сообщений
+type Result struct {
+	Parts    []string
+	Overflow string
+}
+
+// Split разбивает text на части, которые после Escape не длиннее Limit. Разрез ищется на границе
+// абзаца, затем строки и слова во второй половине части; блок кода, попавший на границу,
+// закрывается в конце части и открывается заново (с тем же языком) в следующей
+func Split(text string, opts Options) Result {
+	opts = opts.withDefaults()
+	closing := "\n" + fence
+	budget := opts.Limit - opts.measure(closing)
+
+	var res Result
+	chunk := text
+	for strings.TrimSpace(chunk) != "" {
+		if opts.measure(chunk) <= opts.Limit {
+			res.Parts = append(res.Parts, chunk)
+			break
+		}
+		if len(res.Parts) == opts.MaxParts {
+			res.Overflow = chunk
+			break
+		}
+		cut := opts.cutPoint(chunk, budget)
+		part := strings.TrimRight(chunk[:cut], " \n")
+		rest := strings.TrimLeft(chunk[cut:], "\n")
+		if opener, _, open := openFence(chunk[:cut]); open {
+			part += closing
+			rest = opener + "\n" + rest
+		}
+		res.Parts = append(res.Parts, part)
+		chunk = rest
+	}
+	return res
+}
+
+// Messages сообщения для отправки text в чат: части Split с применённым Escape, счётчик
+// «Часть i из n», если частей несколько, и документ с остатком, если текст не уместился в MaxParts сообщений
+func Messages(chatID int64, text string, opts Options) ([]tgbotapi.MessageConfig, *tgbotapi.DocumentConfig) {
+	opts = opts.withDefaults()
+	res := Split(text, opts)
+	msgs := make([]tgbotapi.MessageConfig, 0, len(res.Parts))
+	for i, part := range res.Parts {
+		body := part
+		if opts.Escape != nil {
+			body = opts.Escape(part)
+		}
+		if len(res.Parts) > 1 {
+			body += fmt.Sprintf(partCounterFmt, i+1, len(res.Parts))
+		}
+		if res.Overflow != "" && i == len(res.Parts)-1 {
+			body += paragraphDivider + truncatedNotice
+		}
+		msg := tgbotapi.NewMessage(chatID, body)
+		msg.ParseMode = opts.ParseMode
+		msgs = append(msgs, msg)
+	}
+	if res.Overflow == "" {
+		return msgs, nil
+	}
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: opts.FileName, Bytes: []byte(res.Overflow)})
+	doc.Caption = overflowCaption
+	return msgs, &doc
+}
+
+// measure длина текста в том виде, в котором он уйдёт в Telegram
+func (o Options) measure(text string) int {
+	if o.Escape != nil {
+		text = o.Escape(text)
+	}
+	return utf16Len(text)
+}
+
+func (o Options) runeCost(r rune) int {
+	if o.Escape != nil {
+		return utf16Len(o.Escape(string(r)))
+	}
+	return utf16Len(string(r))
+}
+
+// cutPoint байтовая позиция разреза chunk: последняя граница абзаца, строки или слова
+// во второй половине бюджета, иначе жёсткий разрез по бюджету
+func (o Options) cutPoint(chunk string, budget int) int {
+	hard, used := 0, 0
+	for i, r := range chunk {
+		used += o.runeCost(r)
+		if used > budget {
+			break
+		}
+		hard = i + utf8.RuneLen(r)
+	}
+	if hard == 0 {
+		_, size := utf8.DecodeRuneInString(chunk)
+		return size
+	}
+
+	cut := hard
+	head := chunk[:hard]
+	for _, sep := range []string{paragraphDivider, "\n", " "} {
+		if i := strings.LastIndex(head, sep); i > 0 && i >= hard/2 {
+			cut = i + len(sep)
+			break
+		}
+	}
+	if o.Escape == nil {
+		cut = o.avoidMarkupSplit(chunk, cut)
+	}
+	// Не оставляем в конце части пустой блок кода из одной открывающей строки
+	if opener, start, open := openFence(chunk[:cut]); open && start > 0 && strings.TrimSpace(chunk[start:cut]) == opener {
+		cut = start
+	}
+	return cut
+}
+
+// avoidMarkupSplit сдвигает разрез уже размеченного текста назад, чтобы не разорвать HTML тег,
+// сущность вроде &amp; или экранирование \x в MarkdownV2
+func (o Options) avoidMarkupSplit(chunk string, cut int) int {
+	head := chunk[:cut]
+	switch {
+	case strings.EqualFold(o.ParseMode, tgbotapi.ModeHTML):
+		if lt := strings.LastIndex(head, "<"); lt > 0 && lt > strings.LastIndex(head, ">") {
+			cut = lt
+		}
+		if amp := strings.LastIndex(chunk[:cut], "&"); amp > 0 && amp > strings.LastIndex(chunk[:cut], ";") && cut-amp <= len("&#x10FFFF;") {
+			cut = amp
+		}
+	case strings.EqualFold(o.ParseMode, tgbotapi.ModeMarkdownV2):
+		slashes := len(head) - len(strings.TrimRight(head, `\`))
+		if slashes%2 == 1 && cut > 1 {
+			cut--
+		}
+	}
+	return cut
+}
+
+// openFence остался ли в text незакрытый блок кода; opener — его открывающая строка (```go), start — её начало
+func openFence(text string) (opener string, start int, open bool) {
+	pos := 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, fence) {
+			if open {
+				open = false
+			} else {
+				opener, start, open = trimmed, pos, true
+			}
+		}
+		pos += len(line)
+	}
+	return opener, start, open
+}
+
+// utf16Len длина в UTF-16 единицах: так считает лимит сообщения Telegram
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
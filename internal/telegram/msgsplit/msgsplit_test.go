@@ -0,0 +1,115 @@
+package msgsplit
+
+import (
+	"html"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestSplit_ShortTextIsOnePart(t *testing.T) {
+	res := Split("привет", Options{})
+	if len(res.Parts) != 1 || res.Parts[0] != "привет" || res.Overflow != "" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestSplit_PrefersParagraphBoundary(t *testing.T) {
+	first := strings.Repeat("слово ", 12) + "\nвторая строка абзаца"
+	second := strings.Repeat("другое ", 10)
+	res := Split(first+"\n\n"+second, Options{Limit: 120})
+	if len(res.Parts) != 2 {
+		t.Fatalf("expected 2 parts, got %q", res.Parts)
+	}
+	if res.Parts[0] != first || res.Parts[1] != second {
+		t.Errorf("must split between paragraphs, got %q", res.Parts)
+	}
+}
+
+func TestSplit_ReopensCodeFenceWithLanguage(t *testing.T) {
+	var code strings.Builder
+	for i := 0; i < 30; i++ {
+		code.WriteString("fmt.Println(\"line\")\n")
+	}
+	text := "Пример:\n\n```go\n" + code.String() + "```\n\nГотово."
+	res := Split(text, Options{Limit: 200})
+	if len(res.Parts) < 3 {
+		t.Fatalf("expected several parts, got %d", len(res.Parts))
+	}
+	for i, part := range res.Parts {
+		if _, _, open := openFence(part); open {
+			t.Errorf("part %d leaves a code block open:\n%s", i, part)
+		}
+		if utf16Len(part) > 200 {
+			t.Errorf("part %d exceeds the limit: %d", i, utf16Len(part))
+		}
+		if i > 0 && i < len(res.Parts)-1 && !strings.HasPrefix(part, "```go\n") {
+			t.Errorf("continuation part %d must reopen the fence with its language:\n%s", i, part)
+		}
+	}
+	joined := strings.Join(res.Parts, "\n")
+	if strings.Count(joined, "fmt.Println") != 30 || !strings.HasSuffix(joined, "Готово.") {
+		t.Errorf("content lost while splitting:\n%s", joined)
+	}
+}
+
+func TestSplit_DoesNotEndPartWithBareFenceOpener(t *testing.T) {
+	text := strings.Repeat("a", 90) + "\n```python\n" + strings.Repeat("x = 1\n", 20) + "```"
+	res := Split(text, Options{Limit: 100})
+	if strings.HasSuffix(res.Parts[0], "```python\n```") {
+		t.Errorf("first part must not carry an empty code block:\n%s", res.Parts[0])
+	}
+	if !strings.HasPrefix(res.Parts[1], "```python\n") {
+		t.Errorf("second part must start the code block:\n%s", res.Parts[1])
+	}
+}
+
+func TestSplit_MeasuresEscapedLength(t *testing.T) {
+	text := strings.Repeat("a<b & c ", 40)
+	res := Split(text, Options{Limit: 100, Escape: html.EscapeString})
+	for i, part := range res.Parts {
+		if n := utf16Len(html.EscapeString(part)); n > 100 {
+			t.Errorf("escaped part %d is %d long, limit 100", i, n)
+		}
+	}
+}
+
+func TestSplit_KeepsPreEscapedMarkupIntact(t *testing.T) {
+	text := strings.Repeat("x", 95) + "&amp;<b>bold</b>" + strings.Repeat("y", 50)
+	res := Split(text, Options{Limit: 100, ParseMode: tgbotapi.ModeHTML})
+	if !strings.HasPrefix(res.Parts[1], "&amp;") {
+		t.Errorf("HTML entity must not be split, got %q", res.Parts)
+	}
+
+	text = strings.Repeat("x", 95) + `\.` + strings.Repeat("y", 50)
+	res = Split(text, Options{Limit: 100, ParseMode: tgbotapi.ModeMarkdownV2})
+	if strings.HasSuffix(res.Parts[0], `\`) || !strings.HasPrefix(res.Parts[1], `\.`) {
+		t.Errorf("MarkdownV2 escape must stay with its character, got %q", res.Parts)
+	}
+}
+
+func TestMessages_OverflowGoesToDocument(t *testing.T) {
+	text := strings.Repeat("абзац текста\n\n", 100)
+	msgs, doc := Messages(42, text, Options{Limit: 100, MaxParts: 3, ParseMode: tgbotapi.ModeHTML, Escape: html.EscapeString})
+	if len(msgs) != 3 || doc == nil {
+		t.Fatalf("expected 3 messages and a document, got %d, %v", len(msgs), doc)
+	}
+	if !strings.Contains(msgs[0].Text, "Часть 1 из 3") || msgs[0].ParseMode != tgbotapi.ModeHTML {
+		t.Errorf("unexpected first message: %+v", msgs[0])
+	}
+	if !strings.Contains(msgs[2].Text, truncatedNotice) {
+		t.Errorf("last message must mention the attachment: %q", msgs[2].Text)
+	}
+	file, ok := doc.File.(tgbotapi.FileBytes)
+	if !ok || file.Name != DefaultFileName || !strings.HasPrefix(string(file.Bytes), "абзац") {
+		t.Fatalf("unexpected document: %+v", doc.File)
+	}
+	sent := 0
+	for _, m := range msgs {
+		sent += strings.Count(m.Text, "абзац")
+	}
+	if sent+strings.Count(string(file.Bytes), "абзац") != 100 {
+		t.Errorf("messages and attachment must cover the whole text")
+	}
+}
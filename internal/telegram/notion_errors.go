@@ -0,0 +1,65 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ai-chatter/internal/notion"
+)
+
+// notionRateLimitBackoff паузы перед повторами вызова, упёршегося в rate limit Notion.
+// Остальные ошибки не повторяются: создание страницы не идемпотентно, а ошибки данных повтор не исправит
+var notionRateLimitBackoff = []time.Duration{time.Second, 3 * time.Second}
+
+// withNotionRetry выполняет вызов Notion MCP, повторяя его при ErrorRateLimited
+func withNotionRetry[T any](ctx context.Context, call func() (T, error)) (T, error) {
+	res, err := call()
+	for _, delay := range notionRateLimitBackoff {
+		if notion.CategoryOf(err) != notion.ErrorRateLimited {
+			break
+		}
+		log.Printf("⏳ Notion rate limited, retrying in %s", delay)
+		select {
+		case <-ctx.Done():
+			return res, err
+		case <-time.After(delay):
+		}
+		res, err = call()
+	}
+	return res, err
+}
+
+// notionErrorHint что делать пользователю при ошибке данной категории
+func notionErrorHint(err error) string {
+	switch notion.CategoryOf(err) {
+	case notion.ErrorAuth:
+		return "проверьте NOTION_TOKEN и доступ интеграции к странице"
+	case notion.ErrorNotFound:
+		return "страница не найдена: проверьте ID и что она расшарена интеграции"
+	case notion.ErrorRateLimited:
+		return "Notion ограничил частоту запросов, попробуйте через минуту"
+	case notion.ErrorTransport:
+		return "Notion MCP сервер недоступен, попробуйте позже"
+	}
+	return ""
+}
+
+// notionErrorText текст ошибки Notion для пользователя с подсказкой
+func notionErrorText(err error) string {
+	if hint := notionErrorHint(err); hint != "" {
+		return fmt.Sprintf("%v (%s)", err, hint)
+	}
+	return err.Error()
+}
+
+// notionToolError результат tool call с ошибкой Notion: категория помогает модели решить,
+// повторить вызов, исправить аргументы или попросить пользователя
+func notionToolError(prefix string, err error) string {
+	action := "повтор не поможет, исправь аргументы или спроси пользователя"
+	if notion.IsRetryable(err) {
+		action = "можно повторить позже"
+	}
+	return fmt.Sprintf("%s [%s]: %v — %s", prefix, notion.CategoryOf(err), err, action)
+}
@@ -0,0 +1,43 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-chatter/internal/notion"
+)
+
+func TestWithNotionRetry_OnlyRateLimited(t *testing.T) {
+	old := notionRateLimitBackoff
+	notionRateLimitBackoff = []time.Duration{time.Millisecond, time.Millisecond}
+	defer func() { notionRateLimitBackoff = old }()
+
+	calls := 0
+	res, err := withNotionRetry(context.Background(), func() (notion.MCPResult, error) {
+		calls++
+		if calls < 3 {
+			return notion.MCPResult{}, &notion.MCPError{Category: notion.ErrorRateLimited, Message: "Notion API error 429"}
+		}
+		return notion.MCPResult{Page: notion.PageMeta{ID: "p1"}}, nil
+	})
+	if err != nil || calls != 3 || res.Page.ID != "p1" {
+		t.Fatalf("rate limited call must be retried: calls=%d, %+v, %v", calls, res, err)
+	}
+
+	calls = 0
+	_, err = withNotionRetry(context.Background(), func() (notion.MCPResult, error) {
+		calls++
+		return notion.MCPResult{}, &notion.MCPError{Category: notion.ErrorTransport, Message: "MCP error: EOF"}
+	})
+	if calls != 1 {
+		t.Errorf("page creation must not be repeated after a transport error, calls=%d", calls)
+	}
+	if text := notionErrorText(err); !strings.Contains(text, "MCP error: EOF") || !strings.Contains(text, "сервер недоступен") {
+		t.Errorf("user text must keep the error and add a hint: %q", text)
+	}
+	if text := notionToolError("Ошибка сохранения", err); !strings.Contains(text, "[transport]") || !strings.Contains(text, "можно повторить позже") {
+		t.Errorf("tool result must carry the category for the model: %q", text)
+	}
+}
@@ -0,0 +1,126 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/notion"
+)
+
+const callbackExportNotion = "export_notion"
+
+// transcriptEntry сообщение переписки для экспорта; At нулевое, если время неизвестно
+type transcriptEntry struct {
+	Role    string
+	Content string
+	At      time.Time
+}
+
+// conversationTranscript текущая переписка пользователя: из журнала взаимодействий (со временем),
+// а без него — из истории в памяти
+func (b *Bot) conversationTranscript(userID int64) []transcriptEntry {
+	var out []transcriptEntry
+	if b.recorder != nil {
+		events, err := b.recorder.LoadInteractions()
+		if err != nil {
+			log.Printf("⚠️ Failed to load interactions for transcript of %d: %v", userID, err)
+		}
+		for _, ev := range events {
+			if ev.UserID != userID || (ev.CanUse != nil && !*ev.CanUse) {
+				continue
+			}
+			if strings.TrimSpace(ev.UserMessage) != "" {
+				out = append(out, transcriptEntry{Role: "user", Content: ev.UserMessage, At: ev.Timestamp})
+			}
+			if strings.TrimSpace(ev.AssistantResponse) != "" {
+				out = append(out, transcriptEntry{Role: "assistant", Content: ev.AssistantResponse, At: ev.Timestamp})
+			}
+		}
+		if len(out) > 0 {
+			return out
+		}
+	}
+	for _, m := range b.history.Get(userID) {
+		if m.Role == "user" || m.Role == "assistant" {
+			out = append(out, transcriptEntry{Role: m.Role, Content: m.Content})
+		}
+	}
+	return out
+}
+
+// renderTranscriptMarkdown переписка в markdown: оглавление и заголовок с ролью и временем у каждого сообщения
+func renderTranscriptMarkdown(entries []transcriptEntry, loc *time.Location) string {
+	if loc == nil {
+		loc = time.UTC
+	}
+	var sb strings.Builder
+	sb.WriteString("[TOC]\n\n")
+	for i, e := range entries {
+		role := "👤 Пользователь"
+		if e.Role == "assistant" {
+			role = "🤖 Ассистент"
+		}
+		fmt.Fprintf(&sb, "## %d. %s", i+1, role)
+		if !e.At.IsZero() {
+			fmt.Fprintf(&sb, " — %s", e.At.In(loc).Format("2006-01-02 15:04"))
+		}
+		sb.WriteString("\n\n" + strings.TrimSpace(e.Content) + "\n\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// exportNotionKeyboard меню с кнопкой сохранения полной переписки в Notion (если Notion настроен)
+func (b *Bot) exportNotionKeyboard(chatID, userID int64) tgbotapi.InlineKeyboardMarkup {
+	kb := b.menuKeyboard()
+	if b.mcpClient == nil || b.notionParentFor(userID) == "" {
+		return kb
+	}
+	data := b.registerCallbackAction(callbackExportNotion, "", userID, chatID, "export_notion:"+newCallbackActionID())
+	kb.InlineKeyboard = append(kb.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("📤 Сохранить переписку в Notion", data),
+	))
+	return kb
+}
+
+func (b *Bot) handleExportNotionCommand(ctx context.Context, msg *tgbotapi.Message) {
+	if !b.authSvc.IsAllowed(msg.From.ID) {
+		return
+	}
+	b.exportTranscriptToNotion(ctx, msg.Chat.ID, msg.From.ID, strings.TrimSpace(msg.CommandArguments()))
+}
+
+// exportTranscriptToNotion создаёт страницу с полной перепиской под страницей Notion пользователя (или NOTION_PARENT_PAGE_ID) и присылает ссылку
+func (b *Bot) exportTranscriptToNotion(ctx context.Context, chatID, userID int64, title string) {
+	if b.mcpClient == nil {
+		b.sendMessage(chatID, "Notion интеграция не настроена. Установите NOTION_TOKEN в конфигурации.")
+		return
+	}
+	if b.notionParentFor(userID) == "" {
+		b.sendMessage(chatID, "❌ Не настроен NOTION_PARENT_PAGE_ID. Настройте переменную окружения с ID страницы из Notion.")
+		return
+	}
+	entries := b.conversationTranscript(userID)
+	if len(entries) == 0 {
+		b.sendMessage(chatID, "История диалога пуста, нечего сохранять.")
+		return
+	}
+	if title == "" {
+		title = fmt.Sprintf("Переписка %s", time.Now().In(b.userLocation(userID)).Format("2006-01-02 15:04"))
+	}
+
+	markdown := renderTranscriptMarkdown(entries, b.userLocation(userID))
+	result, err := withNotionRetry(ctx, func() (notion.MCPResult, error) {
+		return b.mcpClient.CreateFreeFormPage(ctx, title, markdown, b.notionParentFor(userID), []string{"transcript"})
+	})
+	if err != nil {
+		b.sendMessage(chatID, "❌ Ошибка сохранения в Notion: "+notionErrorText(err))
+		return
+	}
+	log.Printf("📤 Transcript of user %d exported to Notion (%d messages)", userID, len(entries))
+	b.sendMessage(chatID, fmt.Sprintf("✅ Переписка сохранена в Notion (%d сообщений)\n📝 %s", len(entries), result.Page.URL))
+}
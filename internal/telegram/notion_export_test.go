@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ai-chatter/internal/history"
+	"ai-chatter/internal/storage"
+)
+
+func TestConversationTranscript_UsesRecorderTimestamps(t *testing.T) {
+	rec, err := storage.NewFileRecorder(t.TempDir() + "/log.jsonl")
+	if err != nil {
+		t.Fatalf("recorder: %v", err)
+	}
+	at := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	fls := false
+	_ = rec.AppendInteraction(storage.Event{Timestamp: at.Add(-time.Hour), UserID: 4, UserMessage: "старое", CanUse: &fls})
+	_ = rec.AppendInteraction(storage.Event{Timestamp: at, UserID: 4, UserMessage: "привет"})
+	_ = rec.AppendInteraction(storage.Event{Timestamp: at.Add(time.Minute), UserID: 4, AssistantResponse: "**Здравствуйте**"})
+	_ = rec.AppendInteraction(storage.Event{Timestamp: at, UserID: 5, UserMessage: "чужое"})
+	b := &Bot{recorder: rec, history: history.NewManager()}
+
+	entries := b.conversationTranscript(4)
+	if len(entries) != 2 || entries[0].Content != "привет" || entries[1].Role != "assistant" {
+		t.Fatalf("unexpected transcript: %+v", entries)
+	}
+	md := renderTranscriptMarkdown(entries, time.UTC)
+	for _, want := range []string{"[TOC]\n\n", "## 1. 👤 Пользователь — 2025-03-01 09:30\n\nпривет", "## 2. 🤖 Ассистент — 2025-03-01 09:31\n\n**Здравствуйте**"} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("markdown misses %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestConversationTranscript_FallsBackToHistory(t *testing.T) {
+	b := &Bot{history: history.NewManager()}
+	b.history.AppendUser(4, "вопрос")
+	b.history.AppendAssistant(4, "ответ")
+	entries := b.conversationTranscript(4)
+	if len(entries) != 2 || !entries[0].At.IsZero() {
+		t.Fatalf("unexpected transcript: %+v", entries)
+	}
+	if md := renderTranscriptMarkdown(entries, nil); !strings.Contains(md, "## 1. 👤 Пользователь\n\nвопрос") {
+		t.Fatalf("unexpected markdown:\n%s", md)
+	}
+	// Без Notion кнопки экспорта нет
+	if kb := b.exportNotionKeyboard(4, 4); len(kb.InlineKeyboard) != len(b.menuKeyboard().InlineKeyboard) {
+		t.Fatalf("export button must be hidden without Notion: %+v", kb)
+	}
+}
@@ -0,0 +1,480 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+)
+
+const callbackOnboarding = "onboarding"
+
+// Шаги онбординга; шаги отключённых интеграций пропускаются
+const (
+	onboardingStepLanguage = "lang"
+	onboardingStepTimezone = "tz"
+	onboardingStepNotion   = "notion"
+)
+
+// onboardingSkip значение кнопки «Пропустить» в payload callback
+const onboardingSkip = "skip"
+
+// onboardingTimezones часовые пояса на кнопках; любой другой можно прислать текстом
+var onboardingTimezones = []string{"Europe/Moscow", "Europe/Berlin", "Asia/Yekaterinburg", "Asia/Novosibirsk", "UTC"}
+
+// onboardingLanguages языки ответов LLM: код -> подпись кнопки
+var onboardingLanguages = []struct{ Code, Label string }{
+	{"ru", "🇷🇺 Русский"},
+	{"en", "🇬🇧 English"},
+}
+
+// notionPageIDPattern ID страницы Notion: 32 hex символа, с дефисами или без (также в конце URL)
+var notionPageIDPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}`)
+
+// userSettings персональные настройки пользователя, заданные при онбординге или /onboarding
+type userSettings struct {
+	Language         string    `json:"language,omitempty"`           // язык ответов LLM: ru, en; пусто — как в системном промпте
+	Timezone         string    `json:"timezone,omitempty"`           // IANA часовой пояс для напоминаний, расписаний и экспорта
+	NotionParentPage string    `json:"notion_parent_page,omitempty"` // родительская страница Notion; пусто — NOTION_PARENT_PAGE_ID
+	OnboardedAt      time.Time `json:"onboarded_at,omitempty"`       // онбординг пройден; повторно запускается только /onboarding
+}
+
+// onboardingState незавершённый онбординг пользователя (в памяти: после перезапуска начнётся заново)
+type onboardingState struct {
+	chatID int64
+	step   string
+	// сообщение, с которого начался онбординг; обрабатывается после завершения
+	pendingMsg *tgbotapi.Message
+}
+
+// SetUserSettings включает хранение персональных настроек и автоматический онбординг новых
+// пользователей. path пустой — настройки живут до перезапуска, онбординг только по /onboarding
+func (b *Bot) SetUserSettings(path string) {
+	b.userSettingsMu.Lock()
+	defer b.userSettingsMu.Unlock()
+	b.userSettingsPath = path
+	b.userSettings = make(map[int64]userSettings)
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("⚠️ Failed to read user settings: %v", err)
+		}
+		return
+	}
+	var stored map[string]userSettings
+	if err := json.Unmarshal(data, &stored); err != nil {
+		log.Printf("⚠️ Failed to parse user settings: %v", err)
+		return
+	}
+	for key, s := range stored {
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			continue
+		}
+		b.userSettings[id] = s
+	}
+	log.Printf("✅ Loaded settings of %d users", len(b.userSettings))
+}
+
+func (b *Bot) saveUserSettingsUnlocked() error {
+	if b.userSettingsPath == "" {
+		return nil
+	}
+	stored := make(map[string]userSettings, len(b.userSettings))
+	for id, s := range b.userSettings {
+		stored[strconv.FormatInt(id, 10)] = s
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.userSettingsPath), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(b.userSettingsPath, data, 0o644)
+}
+
+func (b *Bot) getUserSettings(userID int64) (userSettings, bool) {
+	b.userSettingsMu.RLock()
+	defer b.userSettingsMu.RUnlock()
+	s, ok := b.userSettings[userID]
+	return s, ok
+}
+
+// updateUserSettings изменяет настройки пользователя и сохраняет их в файл
+func (b *Bot) updateUserSettings(userID int64, update func(*userSettings)) {
+	b.userSettingsMu.Lock()
+	defer b.userSettingsMu.Unlock()
+	if b.userSettings == nil {
+		b.userSettings = make(map[int64]userSettings)
+	}
+	s := b.userSettings[userID]
+	update(&s)
+	b.userSettings[userID] = s
+	if err := b.saveUserSettingsUnlocked(); err != nil {
+		log.Printf("⚠️ Failed to save settings of user %d: %v", userID, err)
+	}
+}
+
+// clearUserSettings удаляет настройки пользователя (/forget_me)
+func (b *Bot) clearUserSettings(userID int64) error {
+	b.userSettingsMu.Lock()
+	defer b.userSettingsMu.Unlock()
+	delete(b.onboarding, userID)
+	if _, ok := b.userSettings[userID]; !ok {
+		return nil
+	}
+	delete(b.userSettings, userID)
+	return b.saveUserSettingsUnlocked()
+}
+
+// userLocation часовой пояс пользователя: из настроек, иначе REMINDERS_TIMEZONE, иначе UTC
+func (b *Bot) userLocation(userID int64) *time.Location {
+	if s, ok := b.getUserSettings(userID); ok && s.Timezone != "" {
+		if loc, err := time.LoadLocation(s.Timezone); err == nil {
+			return loc
+		}
+	}
+	if b.remindersLoc != nil {
+		return b.remindersLoc
+	}
+	return time.UTC
+}
+
+// notionParentFor родительская страница Notion пользователя или общая NOTION_PARENT_PAGE_ID
+func (b *Bot) notionParentFor(userID int64) string {
+	if s, ok := b.getUserSettings(userID); ok && s.NotionParentPage != "" {
+		return s.NotionParentPage
+	}
+	return b.notionParentPage
+}
+
+// languageInstruction системная инструкция о языке ответов; пусто, если язык не выбран
+func (b *Bot) languageInstruction(userID int64) string {
+	s, _ := b.getUserSettings(userID)
+	switch s.Language {
+	case "ru":
+		return "Всегда отвечай пользователю на русском языке."
+	case "en":
+		return "Always answer the user in English."
+	}
+	return ""
+}
+
+// needsOnboarding онбординг запускается автоматически: настройки сохраняются в файл,
+// пользователь ещё его не прошёл и это не владелец бота
+func (b *Bot) needsOnboarding(userID int64) bool {
+	b.userSettingsMu.RLock()
+	defer b.userSettingsMu.RUnlock()
+	if b.userSettingsPath == "" || userID == b.adminUserID {
+		return false
+	}
+	return b.userSettings[userID].OnboardedAt.IsZero()
+}
+
+func (b *Bot) onboardingStateOf(userID int64) (onboardingState, bool) {
+	b.userSettingsMu.RLock()
+	defer b.userSettingsMu.RUnlock()
+	st, ok := b.onboarding[userID]
+	if !ok {
+		return onboardingState{}, false
+	}
+	return *st, true
+}
+
+func (b *Bot) setOnboardingStep(userID int64, step string) {
+	b.userSettingsMu.Lock()
+	defer b.userSettingsMu.Unlock()
+	if st, ok := b.onboarding[userID]; ok {
+		st.step = step
+	}
+}
+
+// startOnboarding приветствует пользователя списком доступных ему функций и начинает настройку.
+// pendingMsg — сообщение, которое будет обработано после завершения онбординга (может быть nil)
+func (b *Bot) startOnboarding(chatID, userID int64, pendingMsg *tgbotapi.Message) {
+	role := b.roleOf(userID)
+	b.sendMessage(chatID, b.onboardingWelcomeText(role))
+	if role == auth.RoleReadonly {
+		// Настраивать нечего: доступна только история диалога
+		b.updateUserSettings(userID, func(s *userSettings) { s.OnboardedAt = b.nowUTC() })
+		return
+	}
+	b.userSettingsMu.Lock()
+	if b.onboarding == nil {
+		b.onboarding = make(map[int64]*onboardingState)
+	}
+	b.onboarding[userID] = &onboardingState{chatID: chatID, pendingMsg: pendingMsg}
+	b.userSettingsMu.Unlock()
+	log.Printf("👋 Onboarding started for user %d", userID)
+	b.askOnboardingStep(context.Background(), chatID, userID, onboardingStepLanguage)
+}
+
+// onboardingWelcomeText приветствие с функциями, которые включены и доступны роли пользователя
+func (b *Bot) onboardingWelcomeText(role auth.Role) string {
+	var sb strings.Builder
+	sb.WriteString("👋 Добро пожаловать! Вот что я умею:\n")
+	if role == auth.RoleReadonly {
+		sb.WriteString("• просмотр истории диалога кнопкой «История» (доступ только для чтения)")
+		return sb.String()
+	}
+	features := []string{
+		"• отвечаю на вопросы с учётом контекста диалога; /reset — начать заново, /export — выгрузить историю",
+	}
+	if b.transcriber != nil {
+		features = append(features, "• понимаю голосовые сообщения")
+	}
+	if b.docsLibrary != nil {
+		features = append(features, "• отвечаю по вашим документам: /docs_upload, /ask_docs")
+	}
+	if b.reminders != nil {
+		features = append(features, "• напоминания: /remind")
+	}
+	if b.promptScheduler != nil {
+		features = append(features, "• регулярные запросы по расписанию: /schedule")
+	}
+	if b.mcpClient != nil {
+		features = append(features, "• сохраняю диалоги в Notion и ищу по нему: /notion_save, /export_notion, /notion_search")
+	}
+	if role == auth.RoleAdmin || b.userModelsForAll {
+		features = append(features, "• выбор своей модели: /model")
+	}
+	if role == auth.RoleAdmin {
+		if b.vibeCodingHandler != nil {
+			features = append(features, "• VibeCoding — разработка в контейнере по архиву проекта или git репозиторию: /vibecoding_help")
+		}
+		if b.gmailWorkflow != nil {
+			features = append(features, "• сводка почты Gmail: /gmail_summary")
+		}
+	}
+	sb.WriteString(strings.Join(features, "\n"))
+	sb.WriteString("\n\nДавайте быстро настроим бота под вас. Повторить настройку можно командой /onboarding")
+	return sb.String()
+}
+
+// nextOnboardingStep следующий шаг после step с учётом включённых интеграций; "" — настройка завершена
+func (b *Bot) nextOnboardingStep(step string) string {
+	switch step {
+	case onboardingStepLanguage:
+		if b.reminders != nil || b.promptScheduler != nil {
+			return onboardingStepTimezone
+		}
+		return b.nextOnboardingStep(onboardingStepTimezone)
+	case onboardingStepTimezone:
+		if b.mcpClient != nil {
+			return onboardingStepNotion
+		}
+	}
+	return ""
+}
+
+// askOnboardingStep отправляет вопрос шага с кнопками; пустой шаг завершает онбординг
+func (b *Bot) askOnboardingStep(ctx context.Context, chatID, userID int64, step string) {
+	if step == "" {
+		b.finishOnboarding(ctx, chatID, userID)
+		return
+	}
+	b.setOnboardingStep(userID, step)
+	group := "onboarding:" + strconv.FormatInt(userID, 10)
+	button := func(label, value string) tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardButtonData(label, b.registerCallbackAction(callbackOnboarding, step+":"+value, userID, chatID, group))
+	}
+
+	var text string
+	var rows [][]tgbotapi.InlineKeyboardButton
+	switch step {
+	case onboardingStepLanguage:
+		text = "🌐 На каком языке вам отвечать?"
+		var row []tgbotapi.InlineKeyboardButton
+		for _, lang := range onboardingLanguages {
+			row = append(row, button(lang.Label, lang.Code))
+		}
+		rows = append(rows, row)
+	case onboardingStepTimezone:
+		text = "🕒 Ваш часовой пояс (для напоминаний и запросов по расписанию)? Выберите или пришлите название, например Asia/Vladivostok"
+		for i := 0; i < len(onboardingTimezones); i += 2 {
+			row := []tgbotapi.InlineKeyboardButton{button(onboardingTimezones[i], onboardingTimezones[i])}
+			if i+1 < len(onboardingTimezones) {
+				row = append(row, button(onboardingTimezones[i+1], onboardingTimezones[i+1]))
+			}
+			rows = append(rows, row)
+		}
+	case onboardingStepNotion:
+		text = "📝 Куда сохранять ваши страницы в Notion? Пришлите ссылку или ID родительской страницы"
+		if b.notionParentPage != "" {
+			text += " или оставьте общую страницу бота"
+		}
+	}
+	skipLabel := "Пропустить"
+	if step == onboardingStepNotion && b.notionParentPage != "" {
+		skipLabel = "Общая страница"
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(button(skipLabel, onboardingSkip)))
+
+	msg := tgbotapi.NewMessage(chatID, b.escapeIfNeeded(text))
+	msg.ParseMode = b.parseModeValue()
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := b.s.Send(msg); err != nil {
+		log.Printf("failed to send onboarding step %s: %v", step, err)
+	}
+}
+
+// handleOnboardingCallback ответ кнопкой на шаг онбординга; payload — "<шаг>:<значение>"
+func (b *Bot) handleOnboardingCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, chatID int64, payload string) {
+	step, value, _ := strings.Cut(payload, ":")
+	st, ok := b.onboardingStateOf(cb.From.ID)
+	if !ok || st.step != step {
+		b.answerCallback(cb, callbackExpiredText)
+		return
+	}
+	b.answerCallback(cb, "")
+	b.applyOnboardingAnswer(ctx, chatID, cb.From.ID, step, value)
+}
+
+// handleOnboardingInput перехватывает сообщения пользователя во время онбординга и запускает
+// онбординг при первом сообщении. true — сообщение обработано здесь
+func (b *Bot) handleOnboardingInput(ctx context.Context, msg *tgbotapi.Message) bool {
+	userID := msg.From.ID
+	st, active := b.onboardingStateOf(userID)
+	if !active {
+		if !b.needsOnboarding(userID) {
+			return false
+		}
+		b.startOnboarding(msg.Chat.ID, userID, msg)
+		return true
+	}
+	text := strings.TrimSpace(msg.Text)
+	switch st.step {
+	case onboardingStepLanguage:
+		code := parseOnboardingLanguage(text)
+		if code == "" {
+			b.sendMessage(msg.Chat.ID, "Выберите язык кнопкой выше или нажмите «Пропустить»")
+			return true
+		}
+		b.applyOnboardingAnswer(ctx, msg.Chat.ID, userID, st.step, code)
+	case onboardingStepTimezone:
+		if _, err := time.LoadLocation(text); err != nil || text == "" || strings.EqualFold(text, "local") {
+			b.sendMessage(msg.Chat.ID, "Не знаю такой часовой пояс. Пришлите название в формате Europe/Moscow или нажмите «Пропустить»")
+			return true
+		}
+		b.applyOnboardingAnswer(ctx, msg.Chat.ID, userID, st.step, text)
+	case onboardingStepNotion:
+		id := notionPageIDPattern.FindString(text)
+		if id == "" {
+			b.sendMessage(msg.Chat.ID, "Не нашёл ID страницы Notion: пришлите ссылку на страницу или её ID из 32 символов")
+			return true
+		}
+		b.applyOnboardingAnswer(ctx, msg.Chat.ID, userID, st.step, strings.ReplaceAll(id, "-", ""))
+	}
+	return true
+}
+
+func parseOnboardingLanguage(text string) string {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "ru", "русский", "russian", "рус":
+		return "ru"
+	case "en", "english", "английский", "eng":
+		return "en"
+	}
+	return ""
+}
+
+// applyOnboardingAnswer сохраняет ответ шага (onboardingSkip — без изменений) и задаёт следующий вопрос
+func (b *Bot) applyOnboardingAnswer(ctx context.Context, chatID, userID int64, step, value string) {
+	if value != onboardingSkip {
+		switch step {
+		case onboardingStepLanguage:
+			b.updateUserSettings(userID, func(s *userSettings) { s.Language = value })
+		case onboardingStepTimezone:
+			loc, err := time.LoadLocation(value)
+			if err != nil {
+				log.Printf("⚠️ Invalid onboarding timezone %q: %v", value, err)
+				break
+			}
+			b.updateUserSettings(userID, func(s *userSettings) { s.Timezone = value })
+			if b.promptScheduler != nil {
+				if err := b.promptScheduler.SetUserTimezone(userID, loc); err != nil {
+					log.Printf("⚠️ Failed to set schedule timezone for user %d: %v", userID, err)
+				}
+			}
+		case onboardingStepNotion:
+			b.updateUserSettings(userID, func(s *userSettings) { s.NotionParentPage = value })
+		}
+	}
+	b.askOnboardingStep(ctx, chatID, userID, b.nextOnboardingStep(step))
+}
+
+// finishOnboarding отмечает онбординг пройденным, предлагает пример запроса и обрабатывает
+// сообщение, с которого онбординг начался
+func (b *Bot) finishOnboarding(ctx context.Context, chatID, userID int64) {
+	b.userSettingsMu.Lock()
+	var pendingMsg *tgbotapi.Message
+	if st, ok := b.onboarding[userID]; ok {
+		pendingMsg = st.pendingMsg
+		delete(b.onboarding, userID)
+	}
+	b.userSettingsMu.Unlock()
+	b.updateUserSettings(userID, func(s *userSettings) { s.OnboardedAt = b.nowUTC() })
+	log.Printf("✅ Onboarding completed for user %d", userID)
+
+	b.sendMessage(chatID, b.onboardingSummaryText(userID))
+	if pendingMsg != nil {
+		b.handleIncomingMessage(ctx, pendingMsg)
+	}
+}
+
+// onboardingSummaryText итог настройки и пример первого запроса
+func (b *Bot) onboardingSummaryText(userID int64) string {
+	s, _ := b.getUserSettings(userID)
+	var sb strings.Builder
+	sb.WriteString("✅ Настройка завершена.")
+	if s.Language != "" {
+		for _, lang := range onboardingLanguages {
+			if lang.Code == s.Language {
+				sb.WriteString("\nЯзык ответов: " + lang.Label)
+			}
+		}
+	}
+	if s.Timezone != "" {
+		sb.WriteString("\nЧасовой пояс: " + s.Timezone)
+	}
+	if s.NotionParentPage != "" {
+		sb.WriteString("\nСтраница Notion: " + s.NotionParentPage)
+	}
+	sb.WriteString("\n\n💡 Попробуйте: " + b.onboardingSamplePrompt(s))
+	return sb.String()
+}
+
+// onboardingSamplePrompt пример запроса, подходящий под включённые функции
+func (b *Bot) onboardingSamplePrompt(s userSettings) string {
+	switch {
+	case s.Language == "en":
+		return "«Explain the difference between a process and a thread with an example»"
+	case b.reminders != nil:
+		return "«Составь план изучения Go на неделю», а затем /remind завтра в 09:00 начать план"
+	case b.docsLibrary != nil:
+		return "/docs_upload и пришлите PDF — потом спросите о нём через /ask_docs"
+	default:
+		return "«Составь план изучения Go на неделю с задачами на каждый день»"
+	}
+}
+
+// handleOnboardingCommand /onboarding — пройти настройку заново
+func (b *Bot) handleOnboardingCommand(msg *tgbotapi.Message) {
+	if !b.authSvc.IsAllowed(msg.From.ID) {
+		return
+	}
+	b.startOnboarding(msg.Chat.ID, msg.From.ID, nil)
+}
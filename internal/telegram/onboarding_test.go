@@ -0,0 +1,91 @@
+package telegram
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/history"
+	"ai-chatter/internal/llm"
+)
+
+func TestOnboarding_FirstMessageRunsOnceAndIsReplayed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user_settings.json")
+	svc, _ := auth.NewWithRepo(nil, []int64{42})
+	fs := &fakeSender{}
+	seq := &fakeLLMSeq{seq: []llm.Response{{Content: `{"title":"t","answer":"Hi!","compressed_context":"","status":"continue"}`, Model: "m"}}}
+	b := &Bot{s: fs, authSvc: svc, llmClient: seq, adminUserID: 1, pending: make(map[int64]auth.User), history: history.NewManager()}
+	b.SetCallbackActions(nil, time.Hour)
+	b.SetUserSettings(path)
+
+	first := &tgbotapi.Message{From: &tgbotapi.User{ID: 42}, Chat: &tgbotapi.Chat{ID: 42}, Text: "hello"}
+	b.handleIncomingMessage(context.Background(), first)
+	if seq.calls != 0 {
+		t.Fatal("first message must wait until onboarding is finished")
+	}
+	if len(fs.sent) < 2 || !strings.Contains(fs.sent[0], "Добро пожаловать") {
+		t.Fatalf("expected welcome and language question, got %q", fs.sent)
+	}
+	// Notion, напоминания и расписания выключены: о них не рассказываем и не спрашиваем
+	if strings.Contains(fs.sent[0], "Notion") || strings.Contains(fs.sent[0], "/remind") {
+		t.Errorf("welcome must list only enabled features: %q", fs.sent[0])
+	}
+
+	english := findButton(t, fs.markup, "English")
+	b.handleIncomingMessage(context.Background(), &tgbotapi.Message{From: &tgbotapi.User{ID: 42}, Chat: &tgbotapi.Chat{ID: 42}, Text: "клингонский"})
+	if !strings.Contains(fs.sent[len(fs.sent)-1], "Выберите язык") {
+		t.Fatalf("unknown language must be asked again, got %q", fs.sent[len(fs.sent)-1])
+	}
+
+	b.handleCallback(context.Background(), &tgbotapi.CallbackQuery{ID: "cb", Data: english, From: &tgbotapi.User{ID: 42}})
+	if seq.calls != 1 {
+		t.Fatalf("pending first message must be answered after onboarding, got %d LLM calls", seq.calls)
+	}
+	foundLang := false
+	for _, m := range seq.lastMsgs[0] {
+		if m.Role == "system" && strings.Contains(m.Content, "English") {
+			foundLang = true
+		}
+	}
+	if !foundLang {
+		t.Errorf("chosen language must be passed to the LLM: %+v", seq.lastMsgs[0])
+	}
+
+	// После перезапуска онбординг не повторяется
+	restarted := &Bot{s: fs, authSvc: svc, llmClient: seq, adminUserID: 1, pending: make(map[int64]auth.User), history: history.NewManager()}
+	restarted.SetUserSettings(path)
+	if restarted.needsOnboarding(42) {
+		t.Fatal("completed onboarding must be persisted")
+	}
+	if s, _ := restarted.getUserSettings(42); s.Language != "en" {
+		t.Errorf("language must be persisted, got %+v", s)
+	}
+
+	b.handleCommand(&tgbotapi.Message{From: &tgbotapi.User{ID: 42}, Chat: &tgbotapi.Chat{ID: 42}, Text: "/onboarding",
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/onboarding")}}})
+	if _, active := b.onboardingStateOf(42); !active {
+		t.Fatal("/onboarding must rerun the flow")
+	}
+}
+
+func TestOnboarding_NotionParentPageStep(t *testing.T) {
+	b := &Bot{notionParentPage: "shared"}
+	if got := b.nextOnboardingStep(onboardingStepLanguage); got != "" {
+		t.Fatalf("disabled integrations must be skipped, got step %q", got)
+	}
+	if id := notionPageIDPattern.FindString("https://www.notion.so/Team-Notes-0123456789abcdef0123456789abcdef?pvs=4"); id != "0123456789abcdef0123456789abcdef" {
+		t.Fatalf("page ID must be extracted from URL, got %q", id)
+	}
+	if got := b.notionParentFor(42); got != "shared" {
+		t.Errorf("without a personal page the shared one is used, got %q", got)
+	}
+	b.updateUserSettings(42, func(s *userSettings) { s.NotionParentPage = "personal" })
+	if got := b.notionParentFor(42); got != "personal" {
+		t.Errorf("personal page must take precedence, got %q", got)
+	}
+}
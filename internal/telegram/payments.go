@@ -0,0 +1,345 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/storage"
+)
+
+const (
+	topupCommand = "topup"
+	// topupCallbackPrefix кнопка пакета в /topup: topup:<id пакета>
+	topupCallbackPrefix = "topup:"
+	// topupPayloadPrefix payload счёта: topup:<id пакета>:<сообщений> — начисляется то, что было продано,
+	// даже если пакеты поменяли между выставлением счёта и оплатой
+	topupPayloadPrefix  = "topup:"
+	paymentsMonthLayout = "2006-01"
+)
+
+// TopupPackage пакет сообщений в /topup; Price — в минимальных единицах валюты
+type TopupPackage struct {
+	ID       string
+	Messages int
+	Price    int
+}
+
+// ParseTopupPackages разбирает PAYMENTS_PACKAGES: "id:сообщений:цена" через запятую, цена в рублях/долларах
+// с копейками через точку (например "s:50:99,m:200:299.90")
+func ParseTopupPackages(spec string) ([]TopupPackage, error) {
+	var packages []TopupPackage
+	seen := map[string]bool{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		if len(parts) != 3 || parts[0] == "" {
+			return nil, fmt.Errorf("package %q: want id:messages:price", item)
+		}
+		messages, err := strconv.Atoi(parts[1])
+		if err != nil || messages <= 0 {
+			return nil, fmt.Errorf("package %q: messages must be a positive number", item)
+		}
+		price, err := strconv.ParseFloat(parts[2], 64)
+		if err != nil || price <= 0 {
+			return nil, fmt.Errorf("package %q: price must be a positive number", item)
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("package %q: duplicate id", item)
+		}
+		seen[parts[0]] = true
+		packages = append(packages, TopupPackage{ID: parts[0], Messages: messages, Price: int(math.Round(price * 100))})
+	}
+	if len(packages) == 0 {
+		return nil, errors.New("no packages")
+	}
+	return packages, nil
+}
+
+// SetPayments включает оплату квоты через Telegram Payments. Без providerToken модуль отключён:
+// /topup не отвечает, пользователям вне allowlist не предлагается покупка
+func (b *Bot) SetPayments(store storage.PaymentStore, providerToken, currency string, packages []TopupPackage) {
+	b.payments = store
+	b.paymentsToken = providerToken
+	b.paymentsCurrency = strings.ToUpper(currency)
+	b.topupPackages = packages
+}
+
+func (b *Bot) paymentsEnabled() bool {
+	return b.payments != nil && b.paymentsToken != "" && len(b.topupPackages) > 0
+}
+
+func (b *Bot) topupPackage(id string) (TopupPackage, bool) {
+	for _, p := range b.topupPackages {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return TopupPackage{}, false
+}
+
+// topupHint подсказка о покупке для пользователей вне allowlist; пусто, если платежи отключены
+func (b *Bot) topupHint() string {
+	if !b.paymentsEnabled() {
+		return ""
+	}
+	return "\n\nНе хотите ждать — купите пакет сообщений: /topup"
+}
+
+// consumePaidMessage списывает сообщение из купленной квоты пользователя вне allowlist
+func (b *Bot) consumePaidMessage(chatID, userID int64) bool {
+	if !b.paymentsEnabled() {
+		return false
+	}
+	if _, banned := b.banned[userID]; banned {
+		return false
+	}
+	ok, err := b.payments.Consume(userID)
+	if err != nil {
+		log.Printf("❌ payments: failed to consume quota of %d: %v", userID, err)
+		return false
+	}
+	if ok && b.payments.Balance(userID) == 0 {
+		b.sendMessage(chatID, "Это последнее сообщение из купленного пакета. Пополнить: /topup")
+	}
+	return ok
+}
+
+func formatPrice(amount int, currency string) string {
+	return fmt.Sprintf("%d.%02d %s", amount/100, amount%100, currency)
+}
+
+// handleTopupCommand /topup — пакеты сообщений с кнопками покупки и текущий баланс
+func (b *Bot) handleTopupCommand(msg *tgbotapi.Message) {
+	if _, banned := b.banned[msg.From.ID]; banned {
+		return
+	}
+	var text strings.Builder
+	text.WriteString("💳 Пакеты сообщений\n")
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, p := range b.topupPackages {
+		label := fmt.Sprintf("%d сообщений — %s", p.Messages, formatPrice(p.Price, b.paymentsCurrency))
+		text.WriteString("\n• " + label)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, topupCallbackPrefix+p.ID)))
+	}
+	text.WriteString(fmt.Sprintf("\n\nОсталось сообщений: %d", b.payments.Balance(msg.From.ID)))
+	m := tgbotapi.NewMessage(msg.Chat.ID, b.escapeIfNeeded(text.String()))
+	m.ParseMode = b.parseModeValue()
+	m.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := b.s.Send(m); err != nil {
+		log.Printf("failed to send topup packages: %v", err)
+	}
+}
+
+// handleTopupCallback выставляет счёт за выбранный пакет
+func (b *Bot) handleTopupCallback(cb *tgbotapi.CallbackQuery) {
+	p, ok := b.topupPackage(strings.TrimPrefix(cb.Data, topupCallbackPrefix))
+	if !b.paymentsEnabled() || !ok {
+		b.answerCallback(cb, "Пакет больше не продаётся, откройте /topup заново")
+		return
+	}
+	b.answerCallback(cb, "")
+	title := fmt.Sprintf("%d сообщений", p.Messages)
+	invoice := tgbotapi.NewInvoice(cb.Message.Chat.ID, title, "Сообщения боту для пользователей без подписки",
+		fmt.Sprintf("%s%s:%d", topupPayloadPrefix, p.ID, p.Messages), b.paymentsToken, topupCommand, b.paymentsCurrency,
+		[]tgbotapi.LabeledPrice{{Label: title, Amount: p.Price}})
+	if _, err := b.s.Send(invoice); err != nil {
+		log.Printf("❌ payments: failed to send invoice to %d: %v", cb.From.ID, err)
+		b.sendMessage(cb.Message.Chat.ID, "Не удалось выставить счёт, попробуйте позже")
+	}
+}
+
+// parseTopupPayload пакет и число сообщений из payload счёта
+func parseTopupPayload(payload string) (packageID string, messages int, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(payload, topupPayloadPrefix), ":")
+	if !strings.HasPrefix(payload, topupPayloadPrefix) || len(parts) != 2 {
+		return "", 0, false
+	}
+	messages, err := strconv.Atoi(parts[1])
+	if err != nil || messages <= 0 {
+		return "", 0, false
+	}
+	return parts[0], messages, true
+}
+
+// handlePreCheckout подтверждает оплату, только если пакет всё ещё продаётся по той же цене
+func (b *Bot) handlePreCheckout(q *tgbotapi.PreCheckoutQuery) {
+	answer := tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: q.ID, OK: true}
+	packageID, messages, ok := parseTopupPayload(q.InvoicePayload)
+	p, known := b.topupPackage(packageID)
+	_, banned := b.banned[q.From.ID]
+	switch {
+	case !b.paymentsEnabled() || !ok || !known || banned:
+		answer = tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: q.ID, ErrorMessage: "Пакет больше не продаётся, откройте /topup заново"}
+	case p.Messages != messages || p.Price != q.TotalAmount || !strings.EqualFold(q.Currency, b.paymentsCurrency):
+		answer = tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: q.ID, ErrorMessage: "Цена пакета изменилась, откройте /topup заново"}
+	}
+	if !answer.OK {
+		log.Printf("⚠️ payments: pre-checkout of %d rejected: %s (payload %q, %d %s)", q.From.ID, answer.ErrorMessage, q.InvoicePayload, q.TotalAmount, q.Currency)
+	}
+	if _, err := b.s.Request(answer); err != nil {
+		log.Printf("❌ payments: failed to answer pre-checkout: %v", err)
+	}
+}
+
+// handleSuccessfulPayment начисляет оплаченную квоту и сохраняет чек для сверки
+func (b *Bot) handleSuccessfulPayment(msg *tgbotapi.Message) {
+	pay := msg.SuccessfulPayment
+	packageID, messages, ok := parseTopupPayload(pay.InvoicePayload)
+	if !ok || b.payments == nil {
+		log.Printf("❌ payments: unexpected successful payment %s from %d (payload %q)", pay.TelegramPaymentChargeID, msg.From.ID, pay.InvoicePayload)
+		b.notifyAdminPayment(fmt.Sprintf("⚠️ Платёж %s от %d не удалось зачислить: неизвестный payload %q", pay.TelegramPaymentChargeID, msg.From.ID, pay.InvoicePayload))
+		return
+	}
+	receipt := storage.Receipt{
+		ChargeID:         pay.TelegramPaymentChargeID,
+		ProviderChargeID: pay.ProviderPaymentChargeID,
+		UserID:           msg.From.ID,
+		Username:         msg.From.UserName,
+		Package:          packageID,
+		Messages:         messages,
+		Amount:           pay.TotalAmount,
+		Currency:         pay.Currency,
+		PaidAt:           b.nowUTC(),
+	}
+	balance, err := b.payments.Credit(receipt)
+	if err != nil {
+		log.Printf("❌ payments: failed to credit %s for %d: %v", receipt.ChargeID, msg.From.ID, err)
+		b.sendMessage(msg.Chat.ID, "Оплата получена, но квоту не удалось начислить. Администратор уже знает и начислит вручную")
+		b.notifyAdminPayment(fmt.Sprintf("❌ Платёж %s от %d (%s) не зачислен: %v", receipt.ChargeID, msg.From.ID, formatPrice(receipt.Amount, receipt.Currency), err))
+		return
+	}
+	log.Printf("💳 payments: user %d paid %s for package %s, balance %d", msg.From.ID, formatPrice(receipt.Amount, receipt.Currency), packageID, balance)
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("✅ Оплата получена: +%d сообщений. Осталось: %d. Можете писать сообщение.", messages, balance))
+}
+
+func (b *Bot) notifyAdminPayment(text string) {
+	if b.adminUserID != 0 {
+		b.sendMessage(b.adminUserID, text)
+	}
+}
+
+// handlePaymentsAdminCommand /refund <charge_id> и /payments [ГГГГ-ММ] для администратора
+func (b *Bot) handlePaymentsAdminCommand(msg *tgbotapi.Message) {
+	if b.payments == nil {
+		b.sendMessage(msg.Chat.ID, "Платежи не настроены: PAYMENTS_PROVIDER_TOKEN не задан")
+		return
+	}
+	arg := strings.TrimSpace(msg.CommandArguments())
+	if msg.Command() == "refund" {
+		b.handleRefundCommand(msg.Chat.ID, arg)
+		return
+	}
+	month := b.nowUTC()
+	if arg != "" {
+		parsed, err := time.Parse(paymentsMonthLayout, arg)
+		if err != nil {
+			b.sendMessage(msg.Chat.ID, "Использование: /payments [ГГГГ-ММ]")
+			return
+		}
+		month = parsed
+	}
+	report, err := b.paymentsReport(month)
+	if err != nil {
+		log.Printf("❌ payments: failed to load receipts: %v", err)
+		b.sendMessage(msg.Chat.ID, "Не удалось загрузить чеки")
+		return
+	}
+	b.sendMessage(msg.Chat.ID, report)
+}
+
+// handleRefundCommand списывает квоту возвращённого платежа; деньги возвращаются в кабинете провайдера
+func (b *Bot) handleRefundCommand(chatID int64, chargeID string) {
+	if chargeID == "" {
+		b.sendMessage(chatID, "Использование: /refund <charge_id> (ID платежа из /payments)")
+		return
+	}
+	receipt, balance, err := b.payments.Refund(chargeID, b.nowUTC())
+	switch {
+	case errors.Is(err, storage.ErrReceiptNotFound):
+		b.sendMessage(chatID, "Платёж не найден: "+chargeID)
+		return
+	case errors.Is(err, storage.ErrAlreadyRefunded):
+		b.sendMessage(chatID, "Возврат по платежу уже оформлен: "+chargeID)
+		return
+	case err != nil:
+		b.sendMessage(chatID, fmt.Sprintf("Ошибка возврата: %v", err))
+		return
+	}
+	log.Printf("↩️ payments: refund %s for user %d, balance %d", chargeID, receipt.UserID, balance)
+	b.sendMessage(chatID, fmt.Sprintf("↩️ Возврат оформлен: у пользователя %d списано до %d сообщений, осталось %d.\nВерните %s в кабинете платёжного провайдера (платёж провайдера %s).",
+		receipt.UserID, receipt.Messages, balance, formatPrice(receipt.Amount, receipt.Currency), receipt.ProviderChargeID))
+	b.sendMessage(receipt.UserID, fmt.Sprintf("↩️ Оформлен возврат %s за пакет %d сообщений. Осталось сообщений: %d", formatPrice(receipt.Amount, receipt.Currency), receipt.Messages, balance))
+}
+
+// paymentsReport чеки за календарный месяц (UTC) с итогами по валютам для сверки с провайдером
+func (b *Bot) paymentsReport(month time.Time) (string, error) {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	receipts, err := b.payments.Receipts(from, to)
+	if err != nil {
+		return "", err
+	}
+	return formatPaymentsReport(from, receipts), nil
+}
+
+func formatPaymentsReport(month time.Time, receipts []storage.Receipt) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("💳 Платежи за %s\n", month.Format(paymentsMonthLayout)))
+	if len(receipts) == 0 {
+		text.WriteString("\nОплат не было")
+		return text.String()
+	}
+
+	paid, refunded := map[string]int{}, map[string]int{}
+	refunds, messages := 0, 0
+	var lines []string
+	for _, r := range receipts {
+		paid[r.Currency] += r.Amount
+		line := fmt.Sprintf("%s | @%s (%d) | %s | %s | %s", r.PaidAt.Format("2006-01-02 15:04"), r.Username, r.UserID, r.Package, formatPrice(r.Amount, r.Currency), r.ChargeID)
+		if r.RefundedAt != nil {
+			refunded[r.Currency] += r.Amount
+			refunds++
+			line += " ↩️ возврат " + r.RefundedAt.Format("2006-01-02")
+		} else {
+			messages += r.Messages
+		}
+		lines = append(lines, line)
+	}
+	text.WriteString(fmt.Sprintf("\nОплат: %d, возвратов: %d, продано сообщений: %d\n", len(receipts), refunds, messages))
+	currencies := make([]string, 0, len(paid))
+	for c := range paid {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+	for _, c := range currencies {
+		text.WriteString(fmt.Sprintf("%s: получено %s, возвращено %s, итого %s\n", c, formatPrice(paid[c], c), formatPrice(refunded[c], c), formatPrice(paid[c]-refunded[c], c)))
+	}
+	text.WriteString("\nЧеки:\n" + strings.Join(lines, "\n"))
+	return text.String()
+}
+
+// SendPaymentsReport присылает администратору сверку платежей за прошлый месяц
+func (b *Bot) SendPaymentsReport(ctx context.Context) error {
+	if b.payments == nil || b.adminUserID == 0 {
+		return nil
+	}
+	now := b.nowUTC()
+	report, err := b.paymentsReport(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0))
+	if err != nil {
+		return fmt.Errorf("load receipts: %w", err)
+	}
+	b.sendMessage(b.adminUserID, report)
+	return nil
+}
@@ -0,0 +1,107 @@
+package telegram
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/storage"
+)
+
+func newPaymentsBot(t *testing.T) (*Bot, *fakeSender, *storage.FilePaymentStore) {
+	t.Helper()
+	store, err := storage.NewFilePaymentStore(filepath.Join(t.TempDir(), "payments.json"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	packages, err := ParseTopupPackages("s:2:99,m:200:299.90")
+	if err != nil {
+		t.Fatalf("parse packages: %v", err)
+	}
+	fs := &fakeSender{}
+	b := &Bot{s: fs, adminUserID: 1, authSvc: &auth.Service{}, pending: make(map[int64]auth.User)}
+	b.SetPayments(store, "provider-token", "rub", packages)
+	return b, fs, store
+}
+
+func TestParseTopupPackages(t *testing.T) {
+	packages, err := ParseTopupPackages(" s:50:99 , m:200:299.90")
+	if err != nil || len(packages) != 2 || packages[1] != (TopupPackage{ID: "m", Messages: 200, Price: 29990}) {
+		t.Fatalf("unexpected packages %+v, %v", packages, err)
+	}
+	for _, spec := range []string{"", "s:50", "s:0:99", "s:50:free", "s:1:1,s:2:2"} {
+		if _, err := ParseTopupPackages(spec); err == nil {
+			t.Errorf("spec %q must be rejected", spec)
+		}
+	}
+}
+
+func TestTopup_PurchaseCreditsQuotaForExternalUser(t *testing.T) {
+	b, fs, store := newPaymentsBot(t)
+	stranger := &tgbotapi.User{ID: 77, UserName: "guest"}
+	chat := &tgbotapi.Chat{ID: 77}
+
+	topup := &tgbotapi.Message{From: stranger, Chat: chat, Text: "/topup"}
+	topup.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 6}}
+	b.handleCommand(topup)
+	if len(fs.sent) != 1 || !strings.Contains(fs.sent[0], "2 сообщений — 99.00 RUB") || fs.markup == nil || len(fs.markup.InlineKeyboard) != 2 {
+		t.Fatalf("/topup must list packages with buttons for users outside the allowlist: %q", fs.sent)
+	}
+
+	b.handleCallback(context.Background(), &tgbotapi.CallbackQuery{ID: "cb", From: stranger, Message: &tgbotapi.Message{Chat: chat}, Data: "topup:s"})
+	if len(fs.invoices) != 1 || fs.invoices[0].Payload != "topup:s:2" || fs.invoices[0].Prices[0].Amount != 9900 || fs.invoices[0].Currency != "RUB" {
+		t.Fatalf("unexpected invoice %+v", fs.invoices)
+	}
+
+	b.handlePreCheckout(&tgbotapi.PreCheckoutQuery{ID: "q1", From: stranger, Currency: "RUB", TotalAmount: 9900, InvoicePayload: "topup:s:2"})
+	b.handlePreCheckout(&tgbotapi.PreCheckoutQuery{ID: "q2", From: stranger, Currency: "RUB", TotalAmount: 50, InvoicePayload: "topup:s:2"})
+	if len(fs.preCheckouts) != 2 || !fs.preCheckouts[0].OK || fs.preCheckouts[1].OK {
+		t.Fatalf("pre-checkout must accept the current price and reject a stale one: %+v", fs.preCheckouts)
+	}
+
+	paid := &tgbotapi.Message{From: stranger, Chat: chat, SuccessfulPayment: &tgbotapi.SuccessfulPayment{
+		Currency: "RUB", TotalAmount: 9900, InvoicePayload: "topup:s:2", TelegramPaymentChargeID: "tg-1", ProviderPaymentChargeID: "prov-1",
+	}}
+	b.handleSuccessfulPayment(paid)
+	b.handleSuccessfulPayment(paid)
+	if store.Balance(77) != 2 {
+		t.Fatalf("payment must be credited once, balance %d", store.Balance(77))
+	}
+
+	if !b.consumePaidMessage(77, 77) || !b.consumePaidMessage(77, 77) || b.consumePaidMessage(77, 77) {
+		t.Fatal("paid quota must allow exactly the bought number of messages")
+	}
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "последнее сообщение") {
+		t.Errorf("user must be warned when the quota runs out, got %q", last)
+	}
+
+	b.handleCommand(newAdminCmd("/payments " + time.Now().UTC().Format("2006-01")))
+	report := fs.sent[len(fs.sent)-1]
+	if !strings.Contains(report, "Оплат: 1") || !strings.Contains(report, "RUB: получено 99.00 RUB") || !strings.Contains(report, "tg-1") {
+		t.Errorf("unexpected monthly report:\n%s", report)
+	}
+
+	b.handleCommand(newAdminCmd("/refund tg-1"))
+	if !strings.Contains(strings.Join(fs.sent, "\n"), "Возврат оформлен") {
+		t.Errorf("refund must be confirmed to the admin: %q", fs.sent)
+	}
+	if r, _ := store.Receipts(time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 1)); len(r) != 1 || r[0].RefundedAt == nil {
+		t.Errorf("receipt must be marked refunded: %+v", r)
+	}
+}
+
+func TestTopup_HiddenWithoutProviderToken(t *testing.T) {
+	fs := &fakeSender{}
+	b := &Bot{s: fs, adminUserID: 1, authSvc: &auth.Service{}}
+	msg := &tgbotapi.Message{From: &tgbotapi.User{ID: 77}, Chat: &tgbotapi.Chat{ID: 77}, Text: "/topup"}
+	msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 6}}
+	b.handleCommand(msg)
+	if len(fs.sent) != 0 || b.topupHint() != "" {
+		t.Errorf("disabled payments must not answer users outside the allowlist: %q", fs.sent)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
 	"ai-chatter/internal/llm"
+	"ai-chatter/internal/notion"
 	"ai-chatter/internal/storage"
 )
 
@@ -195,13 +196,21 @@ func enforceNumberedListIfNeeded(answer string) string {
 // buildContextWithOverflow is defined in bot.go
 
 func (b *Bot) processLLMAndRespond(ctx context.Context, chatID int64, userID int64, resp llm.Response) {
-	b.processLLMAndRespondWithMCP(ctx, chatID, userID, resp, nil)
+	b.processLLMAndRespondWithMCP(ctx, chatID, userID, resp, nil, nil)
 }
 
-func (b *Bot) processLLMAndRespondWithMCP(ctx context.Context, chatID int64, userID int64, resp llm.Response, mcpFunctionCalls []string) {
+// processLLMAndRespondWithMCP отправляет ответ модели; provenance — вызовы инструментов, результаты
+// которых видела модель, они записываются вместе с ответом и доступны по кнопке «ℹ️ Источники»
+func (b *Bot) processLLMAndRespondWithMCP(ctx context.Context, chatID int64, userID int64, resp llm.Response, mcpFunctionCalls []string, provenance []storage.ToolProvenance) {
 	// log inbound
 	b.logResponse(resp)
 
+	// Отказ по политике провайдера показываем отдельно от ошибок и без meta-строки ответа
+	if resp.Refused {
+		b.sendRefusalNotice(ctx, chatID, userID, resp)
+		return
+	}
+
 	// Обрабатываем function calls если они есть
 	if len(resp.ToolCalls) > 0 {
 		b.handleFunctionCalls(ctx, chatID, userID, resp.ToolCalls)
@@ -268,20 +277,25 @@ func (b *Bot) processLLMAndRespondWithMCP(ctx context.Context, chatID int64, use
 
 	// Unified final handling: send via sendFinalTS and stop
 	if b.isTZMode(userID) && status == "final" {
-		b.sendFinalTSWithMCP(chatID, userID, parsed, resp, mcpFunctionCalls)
+		b.sendFinalTSWithMCP(chatID, userID, parsed, resp, mcpFunctionCalls, provenance)
 		return
 	}
 
 	used := !compressed
 	b.history.AppendAssistantWithUsed(userID, answerToSend, used)
+	recordedAt := answerTimestamp()
 	if b.recorder != nil {
 		tru := true
 		_ = b.recorder.AppendInteraction(storage.Event{
-			Timestamp:         time.Now().UTC(),
+			Timestamp:         recordedAt,
 			UserID:            userID,
+			ChatID:            chatID,
 			AssistantResponse: answerToSend,
 			CanUse:            &tru,
 			MCPFunctionCalls:  mcpFunctionCalls,
+			Tokens:            resp.TotalTokens,
+			Model:             resp.Model,
+			Provenance:        provenance,
 		})
 	}
 
@@ -291,31 +305,36 @@ func (b *Bot) processLLMAndRespondWithMCP(ctx context.Context, chatID int64, use
 	if ok && parsed.Title != "" {
 		body = b.formatTitleAnswer(parsed.Title, answerToSend)
 	}
-	final := metaEsc + "\n\n" + body
+	final := metaEsc + "\n\n" + body + b.fallbackFooterText(resp)
 	msgOut := tgbotapi.NewMessage(chatID, final)
-	msgOut.ReplyMarkup = b.menuKeyboard()
+	msgOut.ReplyMarkup = b.answerKeyboard(chatID, userID, recordedAt, provenance)
 	msgOut.ParseMode = b.parseModeValue()
-	_, _ = b.s.Send(msgOut)
+	b.sendResponseMessage(ctx, msgOut)
 }
 
 func (b *Bot) sendFinalTS(chatID, userID int64, p llmJSON, resp llm.Response) {
-	b.sendFinalTSWithMCP(chatID, userID, p, resp, nil)
+	b.sendFinalTSWithMCP(chatID, userID, p, resp, nil, nil)
 }
 
-func (b *Bot) sendFinalTSWithMCP(chatID, userID int64, p llmJSON, resp llm.Response, mcpFunctionCalls []string) {
+func (b *Bot) sendFinalTSWithMCP(chatID, userID int64, p llmJSON, resp llm.Response, mcpFunctionCalls []string, provenance []storage.ToolProvenance) {
 	answerToSend := p.Answer
 	if p.Title != "" {
 		answerToSend = b.formatTitleAnswer(p.Title, p.Answer)
 	}
 	b.history.AppendAssistantWithUsed(userID, answerToSend, true)
+	recordedAt := answerTimestamp()
 	if b.recorder != nil {
 		tru := true
 		_ = b.recorder.AppendInteraction(storage.Event{
-			Timestamp:         time.Now().UTC(),
+			Timestamp:         recordedAt,
 			UserID:            userID,
+			ChatID:            chatID,
 			AssistantResponse: answerToSend,
 			CanUse:            &tru,
 			MCPFunctionCalls:  mcpFunctionCalls,
+			Tokens:            resp.TotalTokens,
+			Model:             resp.Model,
+			Provenance:        provenance,
 		})
 	}
 	metaLine := fmt.Sprintf("[model=%s, tokens: prompt=%d, completion=%d, total=%d]", resp.Model, resp.PromptTokens, resp.CompletionTokens, resp.TotalTokens)
@@ -330,11 +349,11 @@ func (b *Bot) sendFinalTSWithMCP(chatID, userID int64, p llmJSON, resp llm.Respo
 	default:
 		header = "**ТЗ Готово**"
 	}
-	final := metaEsc + "\n\n" + header + "\n\n" + answerToSend
+	final := metaEsc + "\n\n" + header + "\n\n" + answerToSend + b.fallbackFooterText(resp)
 	msgOut := tgbotapi.NewMessage(chatID, final)
-	msgOut.ReplyMarkup = b.menuKeyboard()
+	msgOut.ReplyMarkup = b.answerKeyboard(chatID, userID, recordedAt, provenance)
 	msgOut.ParseMode = b.parseModeValue()
-	_, _ = b.s.Send(msgOut)
+	b.sendLongMessage(msgOut)
 
 	log.Println("Готовим инструкцию")
 	// Announce instruction preparation
@@ -366,12 +385,12 @@ func (b *Bot) sendFinalTSWithMCP(chatID, userID int64, p llmJSON, resp llm.Respo
 		msg2 := tgbotapi.NewMessage(chatID, inst)
 		msg2.ParseMode = b.parseModeValue()
 		msg2.ReplyMarkup = b.menuKeyboard()
-		_, _ = b.s.Send(msg2)
+		b.sendLongMessage(msg2)
 	} else {
 		msg2 := tgbotapi.NewMessage(chatID, resp2.Content)
 		msg2.ParseMode = b.parseModeValue()
 		msg2.ReplyMarkup = b.menuKeyboard()
-		_, _ = b.s.Send(msg2)
+		b.sendLongMessage(msg2)
 	}
 	b.clearTZState(userID)
 }
@@ -406,6 +425,7 @@ func (b *Bot) handleFunctionCalls(ctx context.Context, chatID, userID int64, too
 	mcpFunctionCalls := make([]string, 0, len(toolCalls))
 	for _, tc := range toolCalls {
 		mcpFunctionCalls = append(mcpFunctionCalls, tc.Function.Name)
+		b.trackUsage(userID, "mcp:"+tc.Function.Name)
 	}
 
 	for _, tc := range toolCalls {
@@ -444,7 +464,7 @@ func (b *Bot) handleFunctionCalls(ctx context.Context, chatID, userID int64, too
 			}
 
 			// Проверяем настройку parent page
-			if b.notionParentPage == "" {
+			if b.notionParentFor(userID) == "" {
 				toolResults = append(toolResults, llm.ToolCallResult{
 					ToolCallID: tc.ID,
 					Content:    "Ошибка: не настроен NOTION_PARENT_PAGE_ID",
@@ -452,23 +472,25 @@ func (b *Bot) handleFunctionCalls(ctx context.Context, chatID, userID int64, too
 				continue
 			}
 
-			result := b.mcpClient.CreateDialogSummary(
-				ctx, title, content.String(),
-				fmt.Sprintf("%d", userID),
-				getUsernameFromID(userID),
-				"dialog_summary",
-				b.notionParentPage,
-			)
+			result, err := withNotionRetry(ctx, func() (notion.MCPResult, error) {
+				return b.mcpClient.CreateDialogSummary(
+					ctx, title, content.String(),
+					fmt.Sprintf("%d", userID),
+					getUsernameFromID(userID),
+					"dialog_summary",
+					b.notionParentFor(userID),
+				)
+			})
 
-			if result.Success {
+			if err == nil {
 				toolResults = append(toolResults, llm.ToolCallResult{
 					ToolCallID: tc.ID,
-					Content:    fmt.Sprintf("Диалог успешно сохранён в Notion под названием '%s'. Page ID: %s", title, result.PageID),
+					Content:    fmt.Sprintf("Диалог успешно сохранён в Notion под названием '%s'. Page ID: %s, URL: %s", title, result.Page.ID, result.Page.URL),
 				})
 			} else {
 				toolResults = append(toolResults, llm.ToolCallResult{
 					ToolCallID: tc.ID,
-					Content:    fmt.Sprintf("Ошибка сохранения: %s", result.Message),
+					Content:    notionToolError("Ошибка сохранения", err),
 				})
 			}
 
@@ -485,13 +507,15 @@ func (b *Bot) handleFunctionCalls(ctx context.Context, chatID, userID int64, too
 				continue
 			}
 
-			result := b.mcpClient.SearchDialogSummaries(
-				ctx, query,
-				fmt.Sprintf("%d", userID),
-				"dialog_summary",
-			)
+			result, err := withNotionRetry(ctx, func() (notion.MCPResult, error) {
+				return b.mcpClient.SearchDialogSummaries(
+					ctx, query,
+					fmt.Sprintf("%d", userID),
+					"dialog_summary",
+				)
+			})
 
-			if result.Success {
+			if err == nil {
 				toolResults = append(toolResults, llm.ToolCallResult{
 					ToolCallID: tc.ID,
 					Content:    fmt.Sprintf("Результаты поиска по запросу '%s': %s", query, result.Message),
@@ -499,7 +523,7 @@ func (b *Bot) handleFunctionCalls(ctx context.Context, chatID, userID int64, too
 			} else {
 				toolResults = append(toolResults, llm.ToolCallResult{
 					ToolCallID: tc.ID,
-					Content:    fmt.Sprintf("Ошибка поиска: %s", result.Message),
+					Content:    notionToolError("Ошибка поиска", err),
 				})
 			}
 
@@ -534,27 +558,29 @@ func (b *Bot) handleFunctionCalls(ctx context.Context, chatID, userID int64, too
 				parentPage = parentPageID
 			} else if parentPage == "" {
 				// Если не указан ни parent_page, ни parent_page_id, используем default
-				if b.notionParentPage == "" {
+				if b.notionParentFor(userID) == "" {
 					toolResults = append(toolResults, llm.ToolCallResult{
 						ToolCallID: tc.ID,
 						Content:    "Ошибка: не настроен NOTION_PARENT_PAGE_ID",
 					})
 					continue
 				}
-				parentPage = b.notionParentPage
+				parentPage = b.notionParentFor(userID)
 			}
 
-			result := b.mcpClient.CreateFreeFormPage(ctx, title, content, parentPage, nil)
+			result, err := withNotionRetry(ctx, func() (notion.MCPResult, error) {
+				return b.mcpClient.CreateFreeFormPage(ctx, title, content, parentPage, nil)
+			})
 
-			if result.Success {
+			if err == nil {
 				toolResults = append(toolResults, llm.ToolCallResult{
 					ToolCallID: tc.ID,
-					Content:    fmt.Sprintf("Страница '%s' успешно создана в Notion. Page ID: %s", title, result.PageID),
+					Content:    fmt.Sprintf("Страница '%s' успешно создана в Notion. Page ID: %s, URL: %s", title, result.Page.ID, result.Page.URL),
 				})
 			} else {
 				toolResults = append(toolResults, llm.ToolCallResult{
 					ToolCallID: tc.ID,
-					Content:    fmt.Sprintf("Ошибка создания страницы: %s", result.Message),
+					Content:    notionToolError("Ошибка создания страницы", err),
 				})
 			}
 
@@ -577,14 +603,11 @@ func (b *Bot) handleFunctionCalls(ctx context.Context, chatID, userID int64, too
 				limit = int(limitVal)
 			}
 
-			exactMatch := false
-			if exactVal, ok := tc.Function.Arguments["exact_match"].(bool); ok {
-				exactMatch = exactVal
-			}
-
-			result := b.mcpClient.SearchPagesWithID(ctx, query, limit, exactMatch)
+			result, err := withNotionRetry(ctx, func() (notion.MCPPageSearchResult, error) {
+				return b.mcpClient.SearchPagesWithOptions(ctx, query, limit, searchPagesOptions(tc.Function.Arguments))
+			})
 
-			if result.Success {
+			if err == nil {
 				if len(result.Pages) == 0 {
 					toolResults = append(toolResults, llm.ToolCallResult{
 						ToolCallID: tc.ID,
@@ -603,7 +626,7 @@ func (b *Bot) handleFunctionCalls(ctx context.Context, chatID, userID int64, too
 			} else {
 				toolResults = append(toolResults, llm.ToolCallResult{
 					ToolCallID: tc.ID,
-					Content:    fmt.Sprintf("Ошибка поиска страниц: %s", result.Message),
+					Content:    notionToolError("Ошибка поиска страниц", err),
 				})
 			}
 
@@ -627,9 +650,11 @@ func (b *Bot) handleFunctionCalls(ctx context.Context, chatID, userID int64, too
 				parentOnly = parentVal
 			}
 
-			result := b.mcpClient.ListAvailablePages(ctx, limit, pageType, parentOnly)
+			result, err := withNotionRetry(ctx, func() (notion.MCPAvailablePagesResult, error) {
+				return b.mcpClient.ListAvailablePages(ctx, limit, pageType, parentOnly)
+			})
 
-			if result.Success {
+			if err == nil {
 				if len(result.Pages) == 0 {
 					toolResults = append(toolResults, llm.ToolCallResult{
 						ToolCallID: tc.ID,
@@ -651,7 +676,7 @@ func (b *Bot) handleFunctionCalls(ctx context.Context, chatID, userID int64, too
 			} else {
 				toolResults = append(toolResults, llm.ToolCallResult{
 					ToolCallID: tc.ID,
-					Content:    fmt.Sprintf("Ошибка получения списка страниц: %s", result.Message),
+					Content:    notionToolError("Ошибка получения списка страниц", err),
 				})
 			}
 
@@ -666,17 +691,18 @@ func (b *Bot) handleFunctionCalls(ctx context.Context, chatID, userID int64, too
 
 	// Теперь отправляем результаты обратно в LLM для формирования ответа
 	if len(toolResults) > 0 {
-		b.continueConversationWithToolResults(ctx, chatID, userID, toolResults, mcpFunctionCalls)
+		provenance := toolProvenance(toolCalls, toolResults, time.Now())
+		b.continueConversationWithToolResults(ctx, chatID, userID, toolResults, mcpFunctionCalls, provenance)
 	}
 }
 
 // continueConversationWithToolResults продолжает диалог с результатами tool calls
-func (b *Bot) continueConversationWithToolResults(ctx context.Context, chatID, userID int64, toolResults []llm.ToolCallResult, mcpFunctionCalls []string) {
-	b.continueConversationWithToolResultsRecursive(ctx, chatID, userID, toolResults, mcpFunctionCalls, 0)
+func (b *Bot) continueConversationWithToolResults(ctx context.Context, chatID, userID int64, toolResults []llm.ToolCallResult, mcpFunctionCalls []string, provenance []storage.ToolProvenance) {
+	b.continueConversationWithToolResultsRecursive(ctx, chatID, userID, toolResults, mcpFunctionCalls, provenance, 0)
 }
 
 // continueConversationWithToolResultsRecursive обрабатывает цепочки function calls рекурсивно
-func (b *Bot) continueConversationWithToolResultsRecursive(ctx context.Context, chatID, userID int64, toolResults []llm.ToolCallResult, mcpFunctionCalls []string, depth int) {
+func (b *Bot) continueConversationWithToolResultsRecursive(ctx context.Context, chatID, userID int64, toolResults []llm.ToolCallResult, mcpFunctionCalls []string, provenance []storage.ToolProvenance, depth int) {
 	// Ограничиваем глубину рекурсии для предотвращения бесконечных циклов
 	const maxDepth = 5
 	if depth >= maxDepth {
@@ -714,7 +740,7 @@ func (b *Bot) continueConversationWithToolResultsRecursive(ctx context.Context,
 
 	// Получаем ответ от LLM с tools
 	tools := llm.GetNotionTools()
-	resp, err := b.getLLMClient().GenerateWithTools(ctx, contextMsgs, tools)
+	resp, err := b.getUserLLMClient(userID).GenerateWithTools(ctx, contextMsgs, tools)
 	if err != nil {
 		b.sendMessage(chatID, fmt.Sprintf("Действия выполнены, но произошла ошибка формирования ответа: %v", err))
 		return
@@ -730,6 +756,7 @@ func (b *Bot) continueConversationWithToolResultsRecursive(ctx context.Context,
 
 		for _, tc := range resp.ToolCalls {
 			newMCPFunctionCalls = append(newMCPFunctionCalls, tc.Function.Name)
+			b.trackUsage(userID, "mcp:"+tc.Function.Name)
 		}
 
 		// Выполняем новые function calls
@@ -742,14 +769,15 @@ func (b *Bot) continueConversationWithToolResultsRecursive(ctx context.Context,
 
 		// Объединяем с предыдущими вызовами для логирования
 		allMCPCalls := append(mcpFunctionCalls, newMCPFunctionCalls...)
+		allProvenance := append(provenance, toolProvenance(resp.ToolCalls, newToolResults, time.Now())...)
 
 		// Рекурсивно продолжаем с новыми результатами
-		b.continueConversationWithToolResultsRecursive(ctx, chatID, userID, newToolResults, allMCPCalls, depth+1)
+		b.continueConversationWithToolResultsRecursive(ctx, chatID, userID, newToolResults, allMCPCalls, allProvenance, depth+1)
 		return
 	}
 
 	// Нет новых function calls - завершаем цепочку
-	b.processLLMAndRespondWithMCP(ctx, chatID, userID, resp, mcpFunctionCalls)
+	b.processLLMAndRespondWithMCP(ctx, chatID, userID, resp, mcpFunctionCalls, provenance)
 }
 
 // executeSingleFunctionCall выполняет один вызов функции и возвращает результат
@@ -784,30 +812,32 @@ func (b *Bot) executeSingleFunctionCall(ctx context.Context, chatID, userID int6
 		}
 
 		// Проверяем настройку parent page
-		if b.notionParentPage == "" {
+		if b.notionParentFor(userID) == "" {
 			return llm.ToolCallResult{
 				ToolCallID: tc.ID,
 				Content:    "Ошибка: не настроен NOTION_PARENT_PAGE_ID",
 			}
 		}
 
-		result := b.mcpClient.CreateDialogSummary(
-			ctx, title, content.String(),
-			fmt.Sprintf("%d", userID),
-			getUsernameFromID(userID),
-			"dialog_summary",
-			b.notionParentPage,
-		)
+		result, err := withNotionRetry(ctx, func() (notion.MCPResult, error) {
+			return b.mcpClient.CreateDialogSummary(
+				ctx, title, content.String(),
+				fmt.Sprintf("%d", userID),
+				getUsernameFromID(userID),
+				"dialog_summary",
+				b.notionParentFor(userID),
+			)
+		})
 
-		if result.Success {
+		if err == nil {
 			return llm.ToolCallResult{
 				ToolCallID: tc.ID,
-				Content:    fmt.Sprintf("Диалог успешно сохранён в Notion под названием '%s'. Page ID: %s", title, result.PageID),
+				Content:    fmt.Sprintf("Диалог успешно сохранён в Notion под названием '%s'. Page ID: %s, URL: %s", title, result.Page.ID, result.Page.URL),
 			}
 		} else {
 			return llm.ToolCallResult{
 				ToolCallID: tc.ID,
-				Content:    fmt.Sprintf("Ошибка сохранения: %s", result.Message),
+				Content:    notionToolError("Ошибка сохранения", err),
 			}
 		}
 
@@ -837,26 +867,28 @@ func (b *Bot) executeSingleFunctionCall(ctx context.Context, chatID, userID int6
 			parentPage = parentPageID
 		} else if parentPage == "" {
 			// Если не указан ни parent_page, ни parent_page_id, используем default
-			if b.notionParentPage == "" {
+			if b.notionParentFor(userID) == "" {
 				return llm.ToolCallResult{
 					ToolCallID: tc.ID,
 					Content:    "Ошибка: не настроен NOTION_PARENT_PAGE_ID",
 				}
 			}
-			parentPage = b.notionParentPage
+			parentPage = b.notionParentFor(userID)
 		}
 
-		result := b.mcpClient.CreateFreeFormPage(ctx, title, content, parentPage, nil)
+		result, err := withNotionRetry(ctx, func() (notion.MCPResult, error) {
+			return b.mcpClient.CreateFreeFormPage(ctx, title, content, parentPage, nil)
+		})
 
-		if result.Success {
+		if err == nil {
 			return llm.ToolCallResult{
 				ToolCallID: tc.ID,
-				Content:    fmt.Sprintf("Страница '%s' успешно создана в Notion. Page ID: %s", title, result.PageID),
+				Content:    fmt.Sprintf("Страница '%s' успешно создана в Notion. Page ID: %s, URL: %s", title, result.Page.ID, result.Page.URL),
 			}
 		} else {
 			return llm.ToolCallResult{
 				ToolCallID: tc.ID,
-				Content:    fmt.Sprintf("Ошибка создания страницы: %s", result.Message),
+				Content:    notionToolError("Ошибка создания страницы", err),
 			}
 		}
 
@@ -875,14 +907,11 @@ func (b *Bot) executeSingleFunctionCall(ctx context.Context, chatID, userID int6
 			limit = int(limitVal)
 		}
 
-		exactMatch := false
-		if exactVal, ok := tc.Function.Arguments["exact_match"].(bool); ok {
-			exactMatch = exactVal
-		}
-
-		result := b.mcpClient.SearchPagesWithID(ctx, query, limit, exactMatch)
+		result, err := withNotionRetry(ctx, func() (notion.MCPPageSearchResult, error) {
+			return b.mcpClient.SearchPagesWithOptions(ctx, query, limit, searchPagesOptions(tc.Function.Arguments))
+		})
 
-		if result.Success {
+		if err == nil {
 			if len(result.Pages) == 0 {
 				return llm.ToolCallResult{
 					ToolCallID: tc.ID,
@@ -901,7 +930,7 @@ func (b *Bot) executeSingleFunctionCall(ctx context.Context, chatID, userID int6
 		} else {
 			return llm.ToolCallResult{
 				ToolCallID: tc.ID,
-				Content:    fmt.Sprintf("Ошибка поиска страниц: %s", result.Message),
+				Content:    notionToolError("Ошибка поиска страниц", err),
 			}
 		}
 
@@ -922,9 +951,11 @@ func (b *Bot) executeSingleFunctionCall(ctx context.Context, chatID, userID int6
 			parentOnly = parentVal
 		}
 
-		result := b.mcpClient.ListAvailablePages(ctx, limit, pageType, parentOnly)
+		result, err := withNotionRetry(ctx, func() (notion.MCPAvailablePagesResult, error) {
+			return b.mcpClient.ListAvailablePages(ctx, limit, pageType, parentOnly)
+		})
 
-		if result.Success {
+		if err == nil {
 			if len(result.Pages) == 0 {
 				return llm.ToolCallResult{
 					ToolCallID: tc.ID,
@@ -946,7 +977,7 @@ func (b *Bot) executeSingleFunctionCall(ctx context.Context, chatID, userID int6
 		} else {
 			return llm.ToolCallResult{
 				ToolCallID: tc.ID,
-				Content:    fmt.Sprintf("Ошибка получения списка страниц: %s", result.Message),
+				Content:    notionToolError("Ошибка получения списка страниц", err),
 			}
 		}
 
@@ -958,6 +989,14 @@ func (b *Bot) executeSingleFunctionCall(ctx context.Context, chatID, userID int6
 	}
 }
 
+// searchPagesOptions режим сравнения названий из аргументов вызова search_pages_with_id
+func searchPagesOptions(args map[string]interface{}) notion.SearchPagesOptions {
+	exactMatch, _ := args["exact_match"].(bool)
+	contains, _ := args["contains"].(bool)
+	caseInsensitive, _ := args["case_insensitive"].(bool)
+	return notion.SearchPagesOptions{ExactMatch: exactMatch, Contains: contains, CaseInsensitive: caseInsensitive}
+}
+
 // getUsernameFromID возвращает имя пользователя по ID (упрощённая версия)
 func getUsernameFromID(userID int64) string {
 	return fmt.Sprintf("user_%d", userID)
@@ -0,0 +1,92 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/llm"
+)
+
+// profileSwitchUnsupportedText ответ на попытку сменить профиль без перезапуска
+const profileSwitchUnsupportedText = "❌ Смена профиля во время работы не поддерживается: ключи, модели и лимиты фиксируются при запуске. Задайте AI_CHATTER_PROFILE и перезапустите бота."
+
+// SetProfile задаёт имя активного профиля конфигурации для /llm_status и сообщения о запуске
+func (b *Bot) SetProfile(name string) {
+	b.profile = name
+}
+
+// SetSecondaryModel задаёт вторую модель из конфигурации; сохранённая через /model2 имеет приоритет
+func (b *Bot) SetSecondaryModel(model string) {
+	if strings.TrimSpace(model) == "" || strings.TrimSpace(b.model2) != "" {
+		return
+	}
+	b.model2 = model
+}
+
+// SetFallbackFooter включает подпись под ответом, если его дала резервная модель вместо основной
+func (b *Bot) SetFallbackFooter(enabled bool) {
+	b.fallbackFooter = enabled
+}
+
+// fallbackFooterText подпись "ответила резервная модель" или пусто
+func (b *Bot) fallbackFooterText(resp llm.Response) string {
+	if !b.fallbackFooter || resp.FallbackFrom == "" {
+		return ""
+	}
+	return "\n\n" + b.escapeIfNeeded(fmt.Sprintf("↪️ Ответила резервная модель %s (%s недоступна)", resp.Model, resp.FallbackFrom))
+}
+
+func (b *Bot) profileName() string {
+	if b.profile == "" {
+		return "default"
+	}
+	return b.profile
+}
+
+// handleProfileCommands обрабатывает /llm_status и /profile (только для админа)
+func (b *Bot) handleProfileCommands(msg *tgbotapi.Message) {
+	if msg.From.ID != b.adminUserID {
+		b.sendMessage(msg.Chat.ID, "Команда доступна только администратору")
+		return
+	}
+	switch msg.Command() {
+	case "llm_status":
+		b.sendMessage(msg.Chat.ID, b.llmStatusText())
+	case "profile":
+		args := strings.TrimSpace(msg.CommandArguments())
+		if args != "" && !strings.EqualFold(args, b.profileName()) {
+			b.sendMessage(msg.Chat.ID, profileSwitchUnsupportedText)
+			return
+		}
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("🧭 Активный профиль: %s", strings.ToUpper(b.profileName())))
+	}
+}
+
+// llmStatusText состояние LLM: профиль первой строкой, затем провайдер, модели и лимиты
+func (b *Bot) llmStatusText() string {
+	model2 := b.model2
+	if strings.TrimSpace(model2) == "" {
+		model2 = "-"
+	}
+	limit := "без ограничения"
+	if b.llmFactory != nil {
+		if n := b.llmFactory.Limiter.PerMinute(); n > 0 {
+			limit = fmt.Sprintf("%d запросов/мин", n)
+		}
+	}
+	b.streamMu.Lock()
+	streaming := b.streamEnabled
+	b.streamMu.Unlock()
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🧭 Профиль: %s\n\n", strings.ToUpper(b.profileName())))
+	sb.WriteString(fmt.Sprintf("Провайдер: %s\n", b.provider))
+	sb.WriteString(fmt.Sprintf("Модель: %s\n", b.model))
+	sb.WriteString(fmt.Sprintf("Вторая модель: %s\n", model2))
+	sb.WriteString(fmt.Sprintf("Лимит LLM: %s\n", limit))
+	sb.WriteString(fmt.Sprintf("Streaming: %v\n", streaming))
+	sb.WriteString(b.refusalStatusText())
+	return sb.String()
+}
@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/llm"
+)
+
+func newAdminCmd(text string) *tgbotapi.Message {
+	cmd := strings.Fields(text)[0]
+	msg := &tgbotapi.Message{From: &tgbotapi.User{ID: 1}, Chat: &tgbotapi.Chat{ID: 1}, Text: text}
+	msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(cmd)}}
+	return msg
+}
+
+func TestLLMStatus_ShowsProfileFirst(t *testing.T) {
+	fs := &fakeSender{}
+	b := &Bot{s: fs, adminUserID: 1, provider: "openai", model: "gpt", llmFactory: &llm.Factory{Limiter: llm.NewRateLimiter(30)}}
+	b.SetProfile("prod")
+	b.SetSecondaryModel("gpt-mini")
+
+	b.handleCommand(newAdminCmd("/llm_status"))
+	if len(fs.sent) != 1 {
+		t.Fatalf("expected one reply, got %v", fs.sent)
+	}
+	lines := strings.Split(fs.sent[0], "\n")
+	if !strings.Contains(lines[0], "PROD") {
+		t.Errorf("profile must be the first line: %q", fs.sent[0])
+	}
+	for _, want := range []string{"Модель: gpt", "Вторая модель: gpt-mini", "30 запросов/мин"} {
+		if !strings.Contains(fs.sent[0], want) {
+			t.Errorf("status %q must contain %q", fs.sent[0], want)
+		}
+	}
+}
+
+func TestProfileSwitch_RequiresRestart(t *testing.T) {
+	fs := &fakeSender{}
+	b := &Bot{s: fs, adminUserID: 1}
+	b.SetProfile("staging")
+
+	b.handleCommand(newAdminCmd("/profile prod"))
+	b.handleCommand(newAdminCmd("/profile"))
+	if len(fs.sent) != 2 || !strings.Contains(fs.sent[0], "перезапустите") || !strings.Contains(fs.sent[1], "STAGING") {
+		t.Fatalf("unexpected replies: %q", fs.sent)
+	}
+	if b.profileName() != "staging" {
+		t.Fatalf("profile must not change at runtime, got %s", b.profileName())
+	}
+}
+
+func TestFallbackFooter(t *testing.T) {
+	b := &Bot{}
+	resp := llm.Response{Model: "deepseek/deepseek-chat", FallbackFrom: "qwen/qwen3-coder"}
+	if got := b.fallbackFooterText(resp); got != "" {
+		t.Errorf("footer must be disabled by default, got %q", got)
+	}
+
+	b.SetFallbackFooter(true)
+	if got := b.fallbackFooterText(resp); !strings.Contains(got, "deepseek/deepseek-chat") || !strings.Contains(got, "qwen/qwen3-coder") {
+		t.Errorf("footer must name both models, got %q", got)
+	}
+	if got := b.fallbackFooterText(llm.Response{Model: "qwen/qwen3-coder"}); got != "" {
+		t.Errorf("no footer expected when the primary model answered, got %q", got)
+	}
+}
@@ -0,0 +1,125 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/llm"
+	"ai-chatter/internal/storage"
+)
+
+const (
+	callbackSources   = "sources"
+	sourcesButtonText = "ℹ️ Источники"
+
+	sourcesMissingText = "Источники ответа не найдены"
+)
+
+// toolProvenance записи происхождения для выполненных вызовов инструментов: результат
+// сопоставляется с вызовом по ToolCallID, вызовы без результата пропускаются
+func toolProvenance(calls []llm.ToolCall, results []llm.ToolCallResult, at time.Time) []storage.ToolProvenance {
+	byID := make(map[string]llm.ToolCall, len(calls))
+	for _, tc := range calls {
+		byID[tc.ID] = tc
+	}
+	provenance := make([]storage.ToolProvenance, 0, len(results))
+	for _, r := range results {
+		tc, ok := byID[r.ToolCallID]
+		if !ok {
+			continue
+		}
+		provenance = append(provenance, storage.NewToolProvenance(tc.Function.Name, tc.Function.Arguments, r.Content, at))
+	}
+	return provenance
+}
+
+// answerTimestamp время записи ответа: с точностью до миллисекунд, как в SQLite, чтобы по нему
+// можно было найти событие в рекордере
+func answerTimestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// answerKeyboard клавиатура под ответом; если ответ построен на результатах инструментов и
+// событие записано рекордером, добавляется кнопка «ℹ️ Источники»
+func (b *Bot) answerKeyboard(chatID, userID int64, recordedAt time.Time, provenance []storage.ToolProvenance) tgbotapi.InlineKeyboardMarkup {
+	kb := b.menuKeyboard()
+	if len(provenance) == 0 || b.recorder == nil {
+		return kb
+	}
+	data := b.registerCallbackAction(callbackSources, recordedAt.Format(time.RFC3339Nano), userID, chatID, "")
+	kb.InlineKeyboard = append(kb.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(sourcesButtonText, data),
+	))
+	return kb
+}
+
+// handleSourcesCallback показывает, какие интеграции и вызовы инструментов использовались в ответе
+func (b *Bot) handleSourcesCallback(cb *tgbotapi.CallbackQuery, action storage.CallbackAction) {
+	provenance, err := b.answerProvenance(action.OwnerID, action.Payload)
+	if err != nil {
+		log.Printf("⚠️ Failed to load answer provenance: %v", err)
+	}
+	if len(provenance) == 0 {
+		b.answerCallback(cb, sourcesMissingText)
+		return
+	}
+	b.answerCallback(cb, "")
+	b.sendMessage(action.ChatID, formatProvenance(provenance))
+}
+
+// answerProvenance происхождение ответа пользователя, записанного в момент recordedAt (RFC3339)
+func (b *Bot) answerProvenance(userID int64, recordedAt string) ([]storage.ToolProvenance, error) {
+	if b.recorder == nil {
+		return nil, nil
+	}
+	ts, err := time.Parse(time.RFC3339Nano, recordedAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid answer timestamp %q: %w", recordedAt, err)
+	}
+	events, err := storage.Query(b.recorder, storage.Filter{From: ts, To: ts.Add(time.Millisecond), UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	for _, ev := range events {
+		if len(ev.Provenance) > 0 {
+			return ev.Provenance, nil
+		}
+	}
+	return nil, nil
+}
+
+// formatProvenance компактный список вызовов по интеграциям: инструмент, хеши аргументов и ответа, время
+func formatProvenance(provenance []storage.ToolProvenance) string {
+	var order []string
+	byIntegration := make(map[string][]storage.ToolProvenance)
+	for _, p := range provenance {
+		name := toolIntegration(p.Tool)
+		if _, ok := byIntegration[name]; !ok {
+			order = append(order, name)
+		}
+		byIntegration[name] = append(byIntegration[name], p)
+	}
+	var sb strings.Builder
+	sb.WriteString("ℹ️ Источники ответа: " + strings.Join(order, ", "))
+	for _, name := range order {
+		sb.WriteString("\n\n" + name + ":")
+		for _, p := range byIntegration[name] {
+			sb.WriteString(fmt.Sprintf("\n• %s — args#%s, resp#%s, %s UTC", p.Tool, p.ArgsHash, p.ResponseDigest, p.Timestamp.UTC().Format("2006-01-02 15:04:05")))
+		}
+	}
+	return sb.String()
+}
+
+// toolIntegration интеграция, которой принадлежит инструмент
+func toolIntegration(tool string) string {
+	for _, t := range llm.GetNotionTools() {
+		if t.Function.Name == tool {
+			return "Notion"
+		}
+	}
+	return "MCP"
+}
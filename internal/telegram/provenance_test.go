@@ -0,0 +1,67 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/history"
+	"ai-chatter/internal/llm"
+	"ai-chatter/internal/storage"
+)
+
+func TestToolProvenance_MatchesResultsToCalls(t *testing.T) {
+	calls := []llm.ToolCall{
+		{ID: "c1", Function: llm.FunctionCall{Name: "search_notion", Arguments: map[string]interface{}{"query": "Reports"}}},
+		{ID: "c2", Function: llm.FunctionCall{Name: "list_available_pages"}},
+	}
+	results := []llm.ToolCallResult{{ToolCallID: "c1", Content: "found"}, {ToolCallID: "unknown", Content: "?"}}
+	got := toolProvenance(calls, results, time.Unix(100, 0))
+	if len(got) != 1 || got[0].Tool != "search_notion" || got[0].ArgsHash == "" || got[0].ResponseDigest == "" {
+		t.Fatalf("only executed calls must be recorded: %+v", got)
+	}
+}
+
+func TestAnswerWithToolResults_RecordsProvenanceAndShowsSources(t *testing.T) {
+	rec, err := storage.NewFileRecorder(t.TempDir() + "/log.jsonl")
+	if err != nil {
+		t.Fatalf("recorder: %v", err)
+	}
+	fs := &fakeSender{}
+	b := &Bot{s: fs, history: history.NewManager(), recorder: rec}
+	b.SetCallbackActions(nil, time.Hour)
+
+	calls := []llm.ToolCall{{ID: "c1", Function: llm.FunctionCall{Name: "search_notion", Arguments: map[string]interface{}{"query": "Reports"}}}}
+	provenance := toolProvenance(calls, []llm.ToolCallResult{{ToolCallID: "c1", Content: "found 1 page"}}, time.Now())
+	resp := llm.Response{Model: "m", Content: `{"title":"Отчёт","answer":"Страница найдена","compressed_context":"","status":""}`}
+	b.processLLMAndRespondWithMCP(context.Background(), 7, 7, resp, []string{"search_notion"}, provenance)
+
+	events, _ := rec.LoadInteractions()
+	if len(events) != 1 || len(events[0].Provenance) != 1 || events[0].Provenance[0] != provenance[0] {
+		t.Fatalf("answer must be recorded with provenance: %+v", events)
+	}
+	data := findButton(t, fs.markup, sourcesButtonText)
+
+	b.handleCallback(context.Background(), &tgbotapi.CallbackQuery{ID: "cb", Data: data, From: &tgbotapi.User{ID: 7}})
+	last := fs.sent[len(fs.sent)-1]
+	if !strings.Contains(last, "Notion") || !strings.Contains(last, "search_notion") || !strings.Contains(last, provenance[0].ArgsHash) {
+		t.Fatalf("sources must list the consulted integrations compactly, got %q", last)
+	}
+}
+
+func TestAnswerWithoutTools_HasNoSourcesButton(t *testing.T) {
+	rec, _ := storage.NewFileRecorder(t.TempDir() + "/log.jsonl")
+	fs := &fakeSender{}
+	b := &Bot{s: fs, history: history.NewManager(), recorder: rec}
+	b.processLLMAndRespondWithMCP(context.Background(), 7, 7, llm.Response{Content: `{"title":"","answer":"Привет","compressed_context":"","status":""}`}, nil, nil)
+	for _, row := range fs.markup.InlineKeyboard {
+		for _, btn := range row {
+			if btn.Text == sourcesButtonText {
+				t.Fatal("sources button must appear only for answers built on tool results")
+			}
+		}
+	}
+}
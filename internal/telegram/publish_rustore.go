@@ -0,0 +1,443 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/github"
+	"ai-chatter/internal/llm"
+	"ai-chatter/internal/rustore"
+)
+
+const (
+	callbackPublishRelease = "publish_release"
+	callbackPublishApp     = "publish_app"
+	callbackPublishConfirm = "publish_confirm"
+	callbackPublishCancel  = "publish_cancel"
+	callbackPublishRetry   = "publish_retry"
+
+	// publishDefaultRepo репозиторий /publish_rustore без аргумента (тот же, что у /release_rc)
+	publishDefaultRepo = "AndVl1/SnakeGame"
+	// publishMaxReleases сколько последних релизов предлагается на выбор
+	publishMaxReleases = 5
+	// publishMaxApps сколько приложений RuStore показывается кнопками
+	publishMaxApps = 10
+	// publishWhatsNewRunes ограничение текста «Что нового» (RuStore принимает до 5000 символов)
+	publishWhatsNewRunes = 500
+)
+
+// publishGitHub источник релизов для /publish_rustore (интерфейс, чтобы подменять в тестах)
+type publishGitHub interface {
+	GetReleases(ctx context.Context, owner, repo string, maxReleases int, includeDrafts, preReleaseOnly bool) github.GitHubMCPResult
+	DownloadAsset(ctx context.Context, owner, repo string, releaseID int64, assetName, targetPath string) github.GitHubDownloadResult
+}
+
+// publishRuStore операции RuStore, которые выполняет /publish_rustore
+type publishRuStore interface {
+	GetAppList(ctx context.Context, params rustore.GetAppListParams) rustore.RuStoreAppListResult
+	CreateDraft(ctx context.Context, params rustore.CreateDraftParams) rustore.RuStoreDraftResult
+	UploadAndroidFile(ctx context.Context, appID, versionID, fileData, fileName string) rustore.RuStoreMCPResult
+	SubmitForReview(ctx context.Context, appID, versionID string) rustore.RuStoreMCPResult
+}
+
+// publishStage этап диалога публикации
+type publishStage int
+
+const (
+	publishStageRelease publishStage = iota // выбор релиза GitHub
+	publishStageApp                         // выбор приложения RuStore
+	publishStageConfirm                     // карточка с параметрами ждёт подтверждения
+	publishStageRunning                     // шаги публикации выполняются
+	publishStageFailed                      // шаг упал, можно повторить только его
+)
+
+// publishStep шаг публикации после подтверждения
+type publishStep string
+
+const (
+	publishStepDraft  publishStep = "draft"
+	publishStepUpload publishStep = "upload"
+	publishStepSubmit publishStep = "submit"
+)
+
+var publishStepTitles = map[publishStep]string{
+	publishStepDraft:  "создание черновика",
+	publishStepUpload: "загрузка сборки",
+	publishStepSubmit: "отправка на модерацию",
+}
+
+// publishFlow состояние /publish_rustore пользователя между нажатиями кнопок
+type publishFlow struct {
+	ChatID      int64
+	Owner, Repo string
+	Stage       publishStage
+	Releases    []github.GitHubRelease
+	Release     *github.GitHubRelease
+	Asset       *github.GitHubReleaseAsset
+	Apps        []rustore.RuStoreAppInfo
+	App         *rustore.RuStoreAppInfo
+	WhatsNew    string
+	// Результаты выполненных шагов: повтор после ошибки продолжает с упавшего шага
+	VersionID  string
+	Uploaded   bool
+	FailedStep publishStep
+	// seq номер набора кнопок: нажатие устаревшей кнопки другого этапа игнорируется
+	seq int
+}
+
+type publishFlows struct {
+	mu    sync.Mutex
+	flows map[int64]*publishFlow
+}
+
+func (b *Bot) publishFlow(userID int64) (*publishFlow, bool) {
+	b.publish.mu.Lock()
+	defer b.publish.mu.Unlock()
+	f, ok := b.publish.flows[userID]
+	return f, ok
+}
+
+func (b *Bot) setPublishFlow(userID int64, f *publishFlow) {
+	b.publish.mu.Lock()
+	defer b.publish.mu.Unlock()
+	if b.publish.flows == nil {
+		b.publish.flows = make(map[int64]*publishFlow)
+	}
+	if f == nil {
+		delete(b.publish.flows, userID)
+		return
+	}
+	b.publish.flows[userID] = f
+}
+
+// publishStage этап читается под блокировкой: шаги публикации идут в отдельной горутине
+func (b *Bot) publishStage(f *publishFlow) publishStage {
+	b.publish.mu.Lock()
+	defer b.publish.mu.Unlock()
+	return f.Stage
+}
+
+func (b *Bot) setPublishStage(f *publishFlow, stage publishStage) {
+	b.publish.mu.Lock()
+	defer b.publish.mu.Unlock()
+	f.Stage = stage
+}
+
+// handlePublishRuStoreCommand /publish_rustore [owner/repo] — пошаговая публикация релиза GitHub в RuStore:
+// релиз → приложение → «Что нового» от LLM → подтверждение → черновик, загрузка сборки, модерация
+func (b *Bot) handlePublishRuStoreCommand(ctx context.Context, msg *tgbotapi.Message) {
+	if msg.From.ID != b.adminUserID {
+		b.sendMessage(msg.Chat.ID, "❌ Команда доступна только администратору.")
+		return
+	}
+	if b.publishGitHubClient == nil || b.publishRuStoreClient == nil {
+		b.sendMessage(msg.Chat.ID, "❌ Для публикации нужны GitHub и RuStore интеграции (GITHUB_TOKEN, RUSTORE_KEY).")
+		return
+	}
+	if f, ok := b.publishFlow(msg.From.ID); ok && b.publishStage(f) == publishStageRunning {
+		b.sendMessage(msg.Chat.ID, "⏳ Публикация уже выполняется, дождитесь результата.")
+		return
+	}
+
+	repoArg := strings.TrimSpace(msg.CommandArguments())
+	if repoArg == "" {
+		repoArg = publishDefaultRepo
+	}
+	owner, repo, ok := strings.Cut(repoArg, "/")
+	if !ok || owner == "" || repo == "" {
+		b.sendMessage(msg.Chat.ID, "Использование: /publish_rustore [owner/repo]")
+		return
+	}
+
+	res := b.publishGitHubClient.GetReleases(ctx, owner, repo, publishMaxReleases*2, false, false)
+	if !res.Success {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("❌ Не удалось получить релизы %s/%s: %s", owner, repo, res.Message))
+		return
+	}
+	var releases []github.GitHubRelease
+	for _, rel := range res.Releases {
+		if androidAsset(rel) != nil {
+			releases = append(releases, rel)
+		}
+		if len(releases) == publishMaxReleases {
+			break
+		}
+	}
+	if len(releases) == 0 {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("❌ В последних релизах %s/%s нет файлов .aab или .apk", owner, repo))
+		return
+	}
+
+	f := &publishFlow{ChatID: msg.Chat.ID, Owner: owner, Repo: repo, Stage: publishStageRelease, Releases: releases}
+	b.setPublishFlow(msg.From.ID, f)
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	group := b.nextPublishGroup(msg.From.ID, f)
+	for _, rel := range releases {
+		label := rel.TagName
+		if rel.IsPrerelease {
+			label += " (pre-release)"
+		}
+		data := b.registerCallbackAction(callbackPublishRelease, strconv.FormatInt(rel.ID, 10), msg.From.ID, msg.Chat.ID, group)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, data)))
+	}
+	rows = append(rows, b.publishCancelRow(msg.From.ID, msg.Chat.ID, group))
+	b.sendPublishMessage(msg.Chat.ID, fmt.Sprintf("🚀 Публикация в RuStore\n📦 Репозиторий: %s/%s\n\nШаг 1/3: выберите релиз", owner, repo), rows)
+}
+
+// handlePublishCallback нажатия кнопок /publish_rustore
+func (b *Bot) handlePublishCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, actionType, payload string) {
+	userID := cb.From.ID
+	f, ok := b.publishFlow(userID)
+	if !ok {
+		b.answerCallback(cb, callbackExpiredText)
+		return
+	}
+	if actionType == callbackPublishCancel {
+		if b.publishStage(f) == publishStageRunning {
+			b.answerCallback(cb, "Шаги уже выполняются")
+			return
+		}
+		b.setPublishFlow(userID, nil)
+		b.answerCallback(cb, "")
+		b.sendMessage(f.ChatID, "🛑 Публикация в RuStore отменена")
+		return
+	}
+
+	expected := map[string]publishStage{
+		callbackPublishRelease: publishStageRelease,
+		callbackPublishApp:     publishStageApp,
+		callbackPublishConfirm: publishStageConfirm,
+		callbackPublishRetry:   publishStageFailed,
+	}
+	if stage, ok := expected[actionType]; !ok || stage != b.publishStage(f) {
+		b.answerCallback(cb, callbackExpiredText)
+		return
+	}
+
+	switch actionType {
+	case callbackPublishRelease:
+		b.answerCallback(cb, "")
+		b.selectPublishRelease(ctx, userID, f, payload)
+	case callbackPublishApp:
+		b.answerCallback(cb, "Готовлю текст «Что нового»...")
+		b.selectPublishApp(ctx, userID, f, payload)
+	case callbackPublishConfirm, callbackPublishRetry:
+		b.answerCallback(cb, "")
+		b.setPublishStage(f, publishStageRunning)
+		go b.runPublishSteps(context.Background(), userID)
+	}
+}
+
+// selectPublishRelease запоминает релиз и предлагает выбрать приложение RuStore
+func (b *Bot) selectPublishRelease(ctx context.Context, userID int64, f *publishFlow, payload string) {
+	id, _ := strconv.ParseInt(payload, 10, 64)
+	for i := range f.Releases {
+		if f.Releases[i].ID == id {
+			f.Release = &f.Releases[i]
+		}
+	}
+	if f.Release == nil {
+		b.sendMessage(f.ChatID, "❌ Релиз не найден, начните заново: /publish_rustore")
+		b.setPublishFlow(userID, nil)
+		return
+	}
+	f.Asset = androidAsset(*f.Release)
+
+	apps := b.publishRuStoreClient.GetAppList(ctx, rustore.GetAppListParams{PageSize: publishMaxApps})
+	if !apps.Success || len(apps.Applications) == 0 {
+		reason := "список приложений пуст"
+		if !apps.Success {
+			reason = apps.Message
+		}
+		b.sendMessage(f.ChatID, fmt.Sprintf("❌ Не удалось получить приложения RuStore: %s", reason))
+		b.setPublishFlow(userID, nil)
+		return
+	}
+	f.Apps = apps.Applications
+	b.setPublishStage(f, publishStageApp)
+
+	group := b.nextPublishGroup(userID, f)
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, app := range f.Apps {
+		data := b.registerCallbackAction(callbackPublishApp, app.PackageName, userID, f.ChatID, group)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%s (%s)", app.Name, app.PackageName), data)))
+	}
+	rows = append(rows, b.publishCancelRow(userID, f.ChatID, group))
+	b.sendPublishMessage(f.ChatID, fmt.Sprintf("✅ Релиз %s, файл %s\n\nШаг 2/3: выберите приложение RuStore", f.Release.TagName, f.Asset.Name), rows)
+}
+
+// selectPublishApp запоминает приложение, готовит «Что нового» и показывает карточку подтверждения
+func (b *Bot) selectPublishApp(ctx context.Context, userID int64, f *publishFlow, packageName string) {
+	for i := range f.Apps {
+		if f.Apps[i].PackageName == packageName {
+			f.App = &f.Apps[i]
+		}
+	}
+	if f.App == nil {
+		b.sendMessage(f.ChatID, "❌ Приложение не найдено, начните заново: /publish_rustore")
+		b.setPublishFlow(userID, nil)
+		return
+	}
+	f.WhatsNew = b.draftWhatsNew(ctx, *f.Release)
+	b.setPublishStage(f, publishStageConfirm)
+
+	group := b.nextPublishGroup(userID, f)
+	rows := [][]tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Опубликовать", b.registerCallbackAction(callbackPublishConfirm, "", userID, f.ChatID, group)),
+		tgbotapi.NewInlineKeyboardButtonData("✖️ Отмена", b.registerCallbackAction(callbackPublishCancel, "", userID, f.ChatID, group)),
+	)}
+	b.sendPublishMessage(f.ChatID, formatPublishCard(f), rows)
+}
+
+// draftWhatsNew текст «Что нового» по заметкам релиза; без LLM — начало заметок
+func (b *Bot) draftWhatsNew(ctx context.Context, rel github.GitHubRelease) string {
+	notes := strings.TrimSpace(rel.Body)
+	if notes == "" {
+		return "Исправления ошибок и улучшения (" + rel.TagName + ")"
+	}
+	fallback := truncateRunes(notes, publishWhatsNewRunes)
+	prompt := fmt.Sprintf("Составь текст «Что нового» для страницы приложения в RuStore по заметкам релиза %s. "+
+		"Пиши по-русски для пользователей, без технических деталей и ссылок, не длиннее %d символов. Ответь только текстом.\n\nЗаметки релиза:\n%s",
+		rel.TagName, publishWhatsNewRunes, notes)
+	msgs := []llm.Message{{Role: "user", Content: prompt}}
+	b.logLLMRequest(b.adminUserID, "rustore_whats_new", msgs)
+	resp, err := b.getLLMClient().Generate(ctx, msgs)
+	if err != nil || strings.TrimSpace(resp.Content) == "" {
+		log.Printf("⚠️ Failed to draft whatsNew for %s: %v", rel.TagName, err)
+		return fallback
+	}
+	return truncateRunes(strings.TrimSpace(resp.Content), publishWhatsNewRunes)
+}
+
+// runPublishSteps выполняет шаги публикации начиная с первого невыполненного и сообщает итог каждого;
+// при ошибке предлагает повторить только упавший шаг
+func (b *Bot) runPublishSteps(ctx context.Context, userID int64) {
+	f, ok := b.publishFlow(userID)
+	if !ok {
+		return
+	}
+	pkg := f.App.PackageName
+	for _, step := range []publishStep{publishStepDraft, publishStepUpload, publishStepSubmit} {
+		var err error
+		switch step {
+		case publishStepDraft:
+			if f.VersionID != "" {
+				continue
+			}
+			res := b.publishRuStoreClient.CreateDraft(ctx, rustore.CreateDraftParams{PackageName: pkg, WhatsNew: f.WhatsNew})
+			switch {
+			case !res.Success:
+				err = fmt.Errorf("%s", res.Message)
+			case res.VersionID == "":
+				err = fmt.Errorf("RuStore не вернул ID версии")
+			default:
+				f.VersionID = res.VersionID
+				b.sendMessage(f.ChatID, fmt.Sprintf("✅ Черновик создан: версия %s", f.VersionID))
+			}
+		case publishStepUpload:
+			if f.Uploaded {
+				continue
+			}
+			err = b.uploadPublishAsset(ctx, f)
+			if err == nil {
+				f.Uploaded = true
+				b.sendMessage(f.ChatID, fmt.Sprintf("✅ Сборка %s загружена", f.Asset.Name))
+			}
+		case publishStepSubmit:
+			res := b.publishRuStoreClient.SubmitForReview(ctx, pkg, f.VersionID)
+			if !res.Success {
+				err = fmt.Errorf("%s", res.Message)
+			}
+		}
+		if err != nil {
+			log.Printf("❌ RuStore publish step %s failed for %s: %v", step, pkg, err)
+			b.setPublishStage(f, publishStageFailed)
+			f.FailedStep = step
+			group := b.nextPublishGroup(userID, f)
+			rows := [][]tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔁 Повторить шаг", b.registerCallbackAction(callbackPublishRetry, string(step), userID, f.ChatID, group)),
+				tgbotapi.NewInlineKeyboardButtonData("✖️ Отмена", b.registerCallbackAction(callbackPublishCancel, "", userID, f.ChatID, group)),
+			)}
+			b.sendPublishMessage(f.ChatID, fmt.Sprintf("❌ Шаг «%s» не выполнен: %v\n\nВыполненные шаги повторяться не будут.", publishStepTitles[step], err), rows)
+			return
+		}
+	}
+	b.setPublishFlow(userID, nil)
+	b.sendMessage(f.ChatID, fmt.Sprintf("🎉 %s %s отправлено на модерацию RuStore (версия %s)", f.App.Name, f.Release.TagName, f.VersionID))
+	log.Printf("🚀 %s %s submitted to RuStore by %d", pkg, f.Release.TagName, userID)
+}
+
+// uploadPublishAsset скачивает сборку из релиза GitHub и загружает её в черновик
+func (b *Bot) uploadPublishAsset(ctx context.Context, f *publishFlow) error {
+	dl := b.publishGitHubClient.DownloadAsset(ctx, f.Owner, f.Repo, f.Release.ID, f.Asset.Name, "")
+	if !dl.Success {
+		return fmt.Errorf("скачивание %s: %s", f.Asset.Name, dl.Message)
+	}
+	if dl.Base64Content == "" {
+		return fmt.Errorf("скачивание %s: пустой файл", f.Asset.Name)
+	}
+	res := b.publishRuStoreClient.UploadAndroidFile(ctx, f.App.PackageName, f.VersionID, dl.Base64Content, f.Asset.Name)
+	if !res.Success {
+		return fmt.Errorf("%s", res.Message)
+	}
+	return nil
+}
+
+// formatPublishCard карточка со всеми параметрами публикации перед подтверждением
+func formatPublishCard(f *publishFlow) string {
+	var sb strings.Builder
+	sb.WriteString("Шаг 3/3: проверьте параметры публикации\n\n")
+	sb.WriteString(fmt.Sprintf("📦 Репозиторий: %s/%s\n", f.Owner, f.Repo))
+	sb.WriteString(fmt.Sprintf("🏷️ Релиз: %s", f.Release.TagName))
+	if f.Release.Name != "" && f.Release.Name != f.Release.TagName {
+		sb.WriteString(fmt.Sprintf(" (%s)", f.Release.Name))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("📱 Сборка: %s, %s, %.2f MB\n", f.Asset.Name, github.GetAssetType(f.Asset.Name), float64(f.Asset.Size)/(1024*1024)))
+	sb.WriteString(fmt.Sprintf("🏪 Приложение: %s (%s)\n", f.App.Name, f.App.PackageName))
+	sb.WriteString(fmt.Sprintf("📝 Что нового:\n%s\n\n", f.WhatsNew))
+	sb.WriteString("После подтверждения: создание черновика → загрузка сборки → отправка на модерацию")
+	return sb.String()
+}
+
+// androidAsset сборка релиза для RuStore: AAB, а при его отсутствии APK
+func androidAsset(rel github.GitHubRelease) *github.GitHubReleaseAsset {
+	var apk *github.GitHubReleaseAsset
+	for i := range rel.Assets {
+		switch github.GetAssetType(rel.Assets[i].Name) {
+		case "AAB":
+			return &rel.Assets[i]
+		case "APK":
+			if apk == nil {
+				apk = &rel.Assets[i]
+			}
+		}
+	}
+	return apk
+}
+
+// nextPublishGroup группа кнопок нового этапа: нажатие одной снимает остальные
+func (b *Bot) nextPublishGroup(userID int64, f *publishFlow) string {
+	f.seq++
+	return fmt.Sprintf("publish:%d:%d", userID, f.seq)
+}
+
+func (b *Bot) publishCancelRow(userID, chatID int64, group string) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("✖️ Отмена", b.registerCallbackAction(callbackPublishCancel, "", userID, chatID, group)))
+}
+
+func (b *Bot) sendPublishMessage(chatID int64, text string, rows [][]tgbotapi.InlineKeyboardButton) {
+	msg := tgbotapi.NewMessage(chatID, b.escapeIfNeeded(text))
+	msg.ParseMode = b.parseModeValue()
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := b.s.Send(msg); err != nil {
+		log.Printf("⚠️ Failed to send publish step: %v", err)
+	}
+}
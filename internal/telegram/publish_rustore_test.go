@@ -0,0 +1,171 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/github"
+	"ai-chatter/internal/llm"
+	"ai-chatter/internal/rustore"
+)
+
+type fakePublishGitHub struct {
+	releases  []github.GitHubRelease
+	downloads int
+}
+
+func (f *fakePublishGitHub) GetReleases(_ context.Context, _, _ string, _ int, _, _ bool) github.GitHubMCPResult {
+	return github.GitHubMCPResult{Success: true, Releases: f.releases}
+}
+
+func (f *fakePublishGitHub) DownloadAsset(_ context.Context, _, _ string, _ int64, assetName, _ string) github.GitHubDownloadResult {
+	f.downloads++
+	return github.GitHubDownloadResult{Success: true, AssetName: assetName, Base64Content: "UEsDBA=="}
+}
+
+type fakePublishRuStore struct {
+	drafts, uploads, submits int
+	// failUploads сколько первых загрузок завершится ошибкой
+	failUploads int
+	whatsNew    string
+}
+
+func (f *fakePublishRuStore) GetAppList(_ context.Context, _ rustore.GetAppListParams) rustore.RuStoreAppListResult {
+	return rustore.RuStoreAppListResult{RuStoreMCPResult: rustore.RuStoreMCPResult{Success: true}, Applications: []rustore.RuStoreAppInfo{
+		{AppID: "100", Name: "Snake", PackageName: "com.example.snake"},
+	}}
+}
+
+func (f *fakePublishRuStore) CreateDraft(_ context.Context, params rustore.CreateDraftParams) rustore.RuStoreDraftResult {
+	f.drafts++
+	f.whatsNew = params.WhatsNew
+	return rustore.RuStoreDraftResult{RuStoreMCPResult: rustore.RuStoreMCPResult{Success: true}, VersionID: "777"}
+}
+
+func (f *fakePublishRuStore) UploadAndroidFile(_ context.Context, _, versionID, _, _ string) rustore.RuStoreMCPResult {
+	f.uploads++
+	if f.uploads <= f.failUploads {
+		return rustore.RuStoreMCPResult{Message: "413 Payload Too Large"}
+	}
+	if versionID != "777" {
+		return rustore.RuStoreMCPResult{Message: "unknown version " + versionID}
+	}
+	return rustore.RuStoreMCPResult{Success: true}
+}
+
+func (f *fakePublishRuStore) SubmitForReview(_ context.Context, _, _ string) rustore.RuStoreMCPResult {
+	f.submits++
+	return rustore.RuStoreMCPResult{Success: true}
+}
+
+func publishBot(t *testing.T, rs *fakePublishRuStore) (*Bot, *fakeSender) {
+	t.Helper()
+	fs := &fakeSender{}
+	gh := &fakePublishGitHub{releases: []github.GitHubRelease{
+		{ID: 1, TagName: "v1.1.0", Body: "no builds"},
+		{ID: 2, TagName: "v1.0.0", Body: "- новый режим игры", Assets: []github.GitHubReleaseAsset{
+			{Name: "app-release.apk", Size: 1024}, {Name: "app-release.aab", Size: 2048},
+		}},
+	}}
+	b := &Bot{s: fs, adminUserID: 1, publishGitHubClient: gh, publishRuStoreClient: rs,
+		llmClient: fakeLLM{resp: llm.Response{Content: "Новый режим игры"}}}
+	b.SetCallbackActions(nil, time.Hour)
+	return b, fs
+}
+
+// pressButton нажимает кнопку последней клавиатуры по началу подписи
+func pressButton(t *testing.T, b *Bot, fs *fakeSender, label string) {
+	t.Helper()
+	if fs.markup == nil {
+		t.Fatalf("no keyboard to press %q", label)
+	}
+	for _, row := range fs.markup.InlineKeyboard {
+		for _, btn := range row {
+			if strings.HasPrefix(btn.Text, label) {
+				b.handleCallback(context.Background(), &tgbotapi.CallbackQuery{ID: "cb", Data: *btn.CallbackData, From: &tgbotapi.User{ID: 1}})
+				return
+			}
+		}
+	}
+	t.Fatalf("button %q not found", label)
+}
+
+func TestPublishRuStore_GuidedFlowReachesConfirmationCard(t *testing.T) {
+	rs := &fakePublishRuStore{}
+	b, fs := publishBot(t, rs)
+
+	b.handleCommand(newAdminCmd("/publish_rustore"))
+	if len(fs.markup.InlineKeyboard) != 2 {
+		t.Fatalf("only the release with an Android build and cancel are expected: %+v", fs.markup.InlineKeyboard)
+	}
+	pressButton(t, b, fs, "v1.0.0")
+	pressButton(t, b, fs, "Snake")
+
+	card := fs.sent[len(fs.sent)-1]
+	for _, want := range []string{"AndVl1/SnakeGame", "v1.0.0", "app-release.aab", "com.example.snake", "Новый режим игры"} {
+		if !strings.Contains(card, want) {
+			t.Errorf("confirmation card misses %q:\n%s", want, card)
+		}
+	}
+	f, _ := b.publishFlow(1)
+	if f.Stage != publishStageConfirm {
+		t.Fatalf("expected confirm stage, got %d", f.Stage)
+	}
+	if rs.drafts != 0 {
+		t.Errorf("nothing must be created before confirmation")
+	}
+
+	pressButton(t, b, fs, "✖️ Отмена")
+	if _, ok := b.publishFlow(1); ok {
+		t.Errorf("cancel must drop the flow")
+	}
+}
+
+func TestPublishRuStore_RetryRepeatsOnlyFailedStep(t *testing.T) {
+	rs := &fakePublishRuStore{failUploads: 1}
+	b, fs := publishBot(t, rs)
+
+	b.handleCommand(newAdminCmd("/publish_rustore"))
+	pressButton(t, b, fs, "v1.0.0")
+	pressButton(t, b, fs, "Snake")
+	f, _ := b.publishFlow(1)
+	b.setPublishStage(f, publishStageRunning)
+	b.runPublishSteps(context.Background(), 1)
+
+	if f.Stage != publishStageFailed || f.FailedStep != publishStepUpload {
+		t.Fatalf("expected failed upload step, got stage %d step %q", f.Stage, f.FailedStep)
+	}
+	if !strings.Contains(fs.sent[len(fs.sent)-1], "413 Payload Too Large") {
+		t.Errorf("failure must be reported: %q", fs.sent[len(fs.sent)-1])
+	}
+
+	b.setPublishStage(f, publishStageRunning)
+	b.runPublishSteps(context.Background(), 1)
+	if rs.drafts != 1 || rs.uploads != 2 || rs.submits != 1 {
+		t.Errorf("retry must resume from the upload: drafts=%d uploads=%d submits=%d", rs.drafts, rs.uploads, rs.submits)
+	}
+	if rs.whatsNew != "Новый режим игры" {
+		t.Errorf("draft must carry the drafted whatsNew, got %q", rs.whatsNew)
+	}
+	if !strings.Contains(fs.sent[len(fs.sent)-1], "отправлено на модерацию") {
+		t.Errorf("success must be reported: %q", fs.sent[len(fs.sent)-1])
+	}
+	if _, ok := b.publishFlow(1); ok {
+		t.Errorf("finished flow must be dropped")
+	}
+}
+
+func TestPublishRuStore_StaleButtonIsRejected(t *testing.T) {
+	b, fs := publishBot(t, &fakePublishRuStore{})
+	b.handleCommand(newAdminCmd("/publish_rustore"))
+	data := b.registerCallbackAction(callbackPublishConfirm, "", 1, 1, "stale")
+
+	b.handleCallback(context.Background(), &tgbotapi.CallbackQuery{ID: "cb", Data: data, From: &tgbotapi.User{ID: 1}})
+	if got := fs.answers[len(fs.answers)-1]; got != callbackExpiredText {
+		t.Errorf("confirm during release selection must be rejected, got %q", got)
+	}
+}
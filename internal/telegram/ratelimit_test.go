@@ -0,0 +1,29 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/history"
+)
+
+func TestIncomingMessage_RateLimitedUserGetsCooldown(t *testing.T) {
+	svc, _ := auth.NewWithRepo(nil, []int64{1, 5})
+	svc.SetRateLimiter(auth.NewRateLimiter(1, 1))
+	_ = svc.SetRole(5, auth.RoleReadonly)
+	fs := &fakeSender{}
+	b := &Bot{s: fs, authSvc: svc, adminUserID: 1, pending: make(map[int64]auth.User), history: history.NewManager()}
+
+	msg := &tgbotapi.Message{From: &tgbotapi.User{ID: 5}, Chat: &tgbotapi.Chat{ID: 5}, Text: "hello"}
+	b.handleIncomingMessage(context.Background(), msg)
+	b.handleIncomingMessage(context.Background(), msg)
+	if len(fs.sent) != 2 || fs.sent[0] != readonlyAccessText {
+		t.Fatalf("unexpected replies: %q", fs.sent)
+	}
+	if want := "⏳ Слишком много сообщений подряд. Следующее можно отправить через 60 сек."; fs.sent[1] != want {
+		t.Errorf("second message must get the cooldown, got %q want %q", fs.sent[1], want)
+	}
+}
@@ -0,0 +1,87 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/llm"
+)
+
+// userProvider провайдер, которым отвечает пользователю бот: персональный или общий
+func (b *Bot) userProvider(userID int64) string {
+	if ov, ok := b.getUserModelOverride(userID); ok {
+		return ov.Provider
+	}
+	return b.provider
+}
+
+// refusalFallbackModel первая модель другого провайдера из каталога /model, на которую можно
+// переключиться после отказа; ok=false, если пользователь не может менять модель или замены нет
+func (b *Bot) refusalFallbackModel(userID int64) (provider, model string, ok bool) {
+	if !b.canOverrideModel(userID) {
+		return "", "", false
+	}
+	catalog := b.modelCatalogFor()
+	if catalog == nil {
+		return "", "", false
+	}
+	current := strings.ToLower(b.userProvider(userID))
+	for _, p := range catalog.Providers(b.roleOf(userID) == auth.RoleAdmin) {
+		if strings.EqualFold(p, current) {
+			continue
+		}
+		if models := b.userModelChoices(userID, p); len(models) > 0 {
+			return p, models[0].Name, true
+		}
+	}
+	return "", "", false
+}
+
+// sendRefusalNotice сообщает, что провайдер отказал по своей политике (а не произошла ошибка),
+// и предлагает кнопкой переключиться на другого провайдера
+func (b *Bot) sendRefusalNotice(ctx context.Context, chatID, userID int64, resp llm.Response) {
+	text := fmt.Sprintf("🚫 Провайдер %s отказался отвечать по своей политике безопасности (модель %s). "+
+		"Это не ошибка бота: повторная попытка с уточнением запроса тоже получила отказ.\n\n"+
+		"Попробуйте переформулировать запрос", b.userProvider(userID), resp.Model)
+	msg := tgbotapi.NewMessage(chatID, "")
+	if prov, model, ok := b.refusalFallbackModel(userID); ok {
+		text += " или переключитесь на другого провайдера и отправьте его ещё раз."
+		kb := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🔀 %s / %s", prov, model),
+				b.registerCallbackAction(callbackModelSelect, prov+"|"+model, userID, chatID, "")),
+		))
+		msg.ReplyMarkup = kb
+	} else {
+		text += "."
+	}
+	msg.Text = b.escapeIfNeeded(text)
+	msg.ParseMode = b.parseModeValue()
+	b.sendResponseMessage(ctx, msg)
+}
+
+// refusalStatusText строка /llm_status с отказами по провайдерам
+func (b *Bot) refusalStatusText() string {
+	if b.llmFactory == nil || b.llmFactory.Refusals == nil {
+		return "Отказы по политике: не отслеживаются"
+	}
+	stats := b.llmFactory.Refusals.Snapshot()
+	if len(stats) == 0 {
+		return "Отказы по политике: нет"
+	}
+	providers := make([]string, 0, len(stats))
+	for p := range stats {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	parts := make([]string, 0, len(providers))
+	for _, p := range providers {
+		c := stats[p]
+		parts = append(parts, fmt.Sprintf("%s %d (снято повтором %d)", p, c.Refusals, c.Recovered))
+	}
+	return "Отказы по политике: " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,44 @@
+package telegram
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/llm"
+)
+
+func TestRefusalNotice_OffersFallbackProvider(t *testing.T) {
+	catalog, err := llm.ParseModelCatalog("openai=qwen/qwen3-coder;anthropic=claude-3-5-sonnet-latest")
+	if err != nil {
+		t.Fatalf("catalog: %v", err)
+	}
+	fs := &fakeSender{}
+	b := &Bot{s: fs, adminUserID: 1, provider: "openai", model: "qwen/qwen3-coder", llmClient: fakeLLM{}, llmFactory: &llm.Factory{AnthropicAPIKey: "test"}}
+	b.SetUserModelOverrides(filepath.Join(t.TempDir(), "user_models.json"), false)
+	b.SetModelCatalog(catalog)
+
+	b.processLLMAndRespond(context.Background(), 1, 1, llm.Response{Model: "qwen/qwen3-coder", Content: "I can't help with that", Refused: true})
+	if len(fs.sent) != 1 || !strings.Contains(fs.sent[0], "политике безопасности") || strings.Contains(fs.sent[0], "[model=") {
+		t.Fatalf("refusal must be reported as a policy refusal, got %q", fs.sent)
+	}
+	data := findButton(t, fs.markup, "anthropic / claude-3-5-sonnet-latest")
+
+	b.handleCallback(context.Background(), &tgbotapi.CallbackQuery{ID: "cb", Data: data, From: &tgbotapi.User{ID: 1},
+		Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: 1}}})
+	if ov, ok := b.getUserModelOverride(1); !ok || ov.Provider != "anthropic" {
+		t.Fatalf("button must switch the user to the fallback provider, got %+v %v", ov, ok)
+	}
+}
+
+func TestLLMStatus_ShowsRefusals(t *testing.T) {
+	fs := &fakeSender{}
+	b := &Bot{s: fs, adminUserID: 1, provider: "openai", model: "gpt", llmFactory: &llm.Factory{Refusals: llm.NewRefusalStats()}}
+	b.handleCommand(newAdminCmd("/llm_status"))
+	if !strings.Contains(fs.sent[0], "Отказы по политике: нет") {
+		t.Errorf("status must show refusal counters: %q", fs.sent[0])
+	}
+}
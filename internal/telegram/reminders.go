@@ -0,0 +1,121 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/scheduler"
+)
+
+const remindUsage = "Использование: /remind <время> <текст>\n" +
+	"Время: in 2h, in 30m, in 1h30m, через 10 минут, 18:30, завтра 09:00, 2025-03-01 10:00\n" +
+	"/remind — список напоминаний, /remind cancel <id> — отменить"
+
+// SetReminders включает команду /remind: хранилище напоминаний и часовой пояс для абсолютного времени
+func (b *Bot) SetReminders(store *scheduler.ReminderStore, loc *time.Location) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	b.reminders = store
+	b.remindersLoc = loc
+}
+
+// SendReminder отправляет наступившее напоминание (вызывается планировщиком).
+// В режиме обслуживания напоминание откладывается до /maintenance off
+func (b *Bot) SendReminder(ctx context.Context, r scheduler.Reminder) error {
+	if b.deferReminder(r) {
+		return nil
+	}
+	msg := tgbotapi.NewMessage(r.ChatID, b.escapeIfNeeded("⏰ Напоминание: "+r.Text))
+	msg.ParseMode = b.parseModeValue()
+	_, err := b.s.Send(msg)
+	return err
+}
+
+func (b *Bot) handleRemindCommand(msg *tgbotapi.Message) {
+	userID := msg.From.ID
+	if !b.authSvc.IsAllowed(userID) {
+		return
+	}
+	if b.reminders == nil {
+		b.sendMessage(msg.Chat.ID, "Напоминания не настроены")
+		return
+	}
+
+	args := strings.TrimSpace(msg.CommandArguments())
+	fields := strings.Fields(args)
+	switch {
+	case args == "" || args == "list":
+		b.sendMessage(msg.Chat.ID, b.formatReminders(userID))
+		return
+	case fields[0] == "cancel":
+		if len(fields) != 2 {
+			b.sendMessage(msg.Chat.ID, remindUsage)
+			return
+		}
+		id, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			b.sendMessage(msg.Chat.ID, remindUsage)
+			return
+		}
+		if err := b.reminders.Delete(userID, id); err != nil {
+			if errors.Is(err, scheduler.ErrReminderNotFound) {
+				b.sendMessage(msg.Chat.ID, fmt.Sprintf("Напоминание #%d не найдено", id))
+				return
+			}
+			log.Printf("❌ Failed to cancel reminder %d: %v", id, err)
+			b.sendMessage(msg.Chat.ID, "Не удалось отменить напоминание")
+			return
+		}
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("🗑 Напоминание #%d отменено", id))
+		return
+	}
+
+	now := time.Now().In(b.userLocation(userID))
+	due, text, err := scheduler.ParseReminderTime(args, now)
+	if err != nil {
+		if errors.Is(err, scheduler.ErrReminderInPast) {
+			b.sendMessage(msg.Chat.ID, "Это время уже прошло")
+			return
+		}
+		b.sendMessage(msg.Chat.ID, "Не удалось разобрать время.\n"+remindUsage)
+		return
+	}
+	if strings.TrimSpace(text) == "" {
+		b.sendMessage(msg.Chat.ID, "Укажите текст напоминания.\n"+remindUsage)
+		return
+	}
+
+	r, err := b.reminders.Add(scheduler.Reminder{UserID: userID, ChatID: msg.Chat.ID, Text: text, DueAt: due})
+	if err != nil {
+		log.Printf("❌ Failed to save reminder for user %d: %v", userID, err)
+		b.sendMessage(msg.Chat.ID, "Не удалось сохранить напоминание")
+		return
+	}
+	log.Printf("⏰ Reminder %d scheduled for user %d at %s", r.ID, userID, due.Format(time.RFC3339))
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("⏰ Напоминание #%d установлено на %s", r.ID, b.formatReminderTime(userID, due)))
+}
+
+func (b *Bot) formatReminders(userID int64) string {
+	list := b.reminders.List(userID)
+	if len(list) == 0 {
+		return "Активных напоминаний нет.\n" + remindUsage
+	}
+	var sb strings.Builder
+	sb.WriteString("⏰ Ваши напоминания:\n")
+	for _, r := range list {
+		sb.WriteString(fmt.Sprintf("#%d — %s: %s\n", r.ID, b.formatReminderTime(userID, r.DueAt), r.Text))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func (b *Bot) formatReminderTime(userID int64, t time.Time) string {
+	return t.In(b.userLocation(userID)).Format("2006-01-02 15:04 MST")
+}
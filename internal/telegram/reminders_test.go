@@ -0,0 +1,63 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/history"
+	"ai-chatter/internal/scheduler"
+)
+
+func remindMsg(userID int64, text string) *tgbotapi.Message {
+	msg := &tgbotapi.Message{From: &tgbotapi.User{ID: userID}, Chat: &tgbotapi.Chat{ID: userID}, Text: text}
+	msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/remind")}}
+	return msg
+}
+
+func TestRemindCommand_SchedulesAndSends(t *testing.T) {
+	svc, _ := auth.NewWithRepo(nil, []int64{3})
+	fs := &fakeSender{}
+	b := &Bot{s: fs, authSvc: svc, pending: make(map[int64]auth.User), parseMode: "HTML", history: history.NewManager()}
+	path := t.TempDir() + "/reminders.json"
+	store, err := scheduler.NewReminderStore(path)
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	b.SetReminders(store, time.UTC)
+
+	b.handleCommand(remindMsg(3, "/remind in 2h позвонить маме"))
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "Напоминание #1 установлено") {
+		t.Fatalf("unexpected reply: %q", last)
+	}
+	reopened, _ := scheduler.NewReminderStore(path)
+	list := reopened.List(3)
+	if len(list) != 1 || list[0].Text != "позвонить маме" || time.Until(list[0].DueAt) < 119*time.Minute {
+		t.Fatalf("reminder not persisted correctly: %+v", list)
+	}
+
+	b.handleCommand(remindMsg(3, "/remind в обед"))
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "Не удалось разобрать время") {
+		t.Fatalf("unexpected reply for bad time: %q", last)
+	}
+
+	// Планировщик отправляет наступившее напоминание и удаляет его
+	if _, err := store.Add(scheduler.Reminder{UserID: 3, ChatID: 3, Text: "выпить воды", DueAt: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	sched := scheduler.New()
+	sched.SetReminders(store, b.SendReminder)
+	sched.DispatchDueReminders(context.Background())
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "Напоминание: выпить воды") {
+		t.Fatalf("reminder not sent: %q", last)
+	}
+
+	b.handleCommand(remindMsg(3, "/remind cancel 1"))
+	if got := store.List(3); len(got) != 0 {
+		t.Fatalf("all reminders must be gone: %+v", got)
+	}
+}
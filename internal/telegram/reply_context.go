@@ -0,0 +1,53 @@
+package telegram
+
+import (
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/llm"
+)
+
+// replyContextMaxRunes ограничение цитируемого ответа в контексте запроса
+const replyContextMaxRunes = 4000
+
+// replyContextPrompt инструкция модели: ответ пользователя относится к процитированному сообщению
+const replyContextPrompt = "Пользователь ответил на твоё предыдущее сообщение (ниже). Считай его основным контекстом нового запроса: " +
+	"если пользователь просит раскрыть, уточнить или продолжить — речь именно об этом сообщении, а не о последнем ответе в диалоге.\n\n" +
+	"Процитированное сообщение:\n"
+
+// repliedBotText текст сообщения бота, на которое ответил пользователь; false, если это не ответ на сообщение бота
+func repliedBotText(msg *tgbotapi.Message) (string, bool) {
+	reply := msg.ReplyToMessage
+	if reply == nil || reply.From == nil || !reply.From.IsBot {
+		return "", false
+	}
+	text := reply.Text
+	if text == "" {
+		text = reply.Caption
+	}
+	if text == "" {
+		return "", false
+	}
+	if utf8.RuneCountInString(text) > replyContextMaxRunes {
+		text = string([]rune(text)[:replyContextMaxRunes]) + "…"
+	}
+	return text, true
+}
+
+// withReplyContext добавляет процитированное сообщение бота как системный контекст перед последним
+// сообщением пользователя. История диалога не меняется: контекст действует только для этого запроса
+func withReplyContext(msgs []llm.Message, msg *tgbotapi.Message) []llm.Message {
+	text, ok := repliedBotText(msg)
+	if !ok {
+		return msgs
+	}
+	focus := llm.Message{Role: "system", Content: replyContextPrompt + text}
+	if len(msgs) == 0 || msgs[len(msgs)-1].Role != "user" {
+		return append(msgs, focus)
+	}
+	out := make([]llm.Message, 0, len(msgs)+1)
+	out = append(out, msgs[:len(msgs)-1]...)
+	out = append(out, focus, msgs[len(msgs)-1])
+	return out
+}
@@ -0,0 +1,60 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/history"
+	"ai-chatter/internal/llm"
+)
+
+func TestHandleIncomingMessage_ReplyToBotMessageAddsFocusedContext(t *testing.T) {
+	userID := int64(5151)
+	svc, _ := auth.NewWithRepo(nil, []int64{userID})
+	seq := &fakeLLMSeq{seq: []llm.Response{{Content: `{"title":"t","answer":"a"}`, Model: "m"}}}
+	b := &Bot{s: &fakeSender{}, authSvc: svc, llmClient: seq, pending: make(map[int64]auth.User), parseMode: "HTML", history: history.NewManager()}
+
+	msg := &tgbotapi.Message{
+		From: &tgbotapi.User{ID: userID},
+		Chat: &tgbotapi.Chat{ID: userID},
+		Text: "раскрой подробнее",
+		ReplyToMessage: &tgbotapi.Message{
+			From: &tgbotapi.User{ID: 1, IsBot: true},
+			Text: "Горутины\n\nГорутины — лёгкие потоки Go",
+		},
+	}
+	b.handleIncomingMessage(context.Background(), msg)
+
+	if len(seq.lastMsgs) != 1 {
+		t.Fatalf("expected one LLM call, got %d", len(seq.lastMsgs))
+	}
+	msgs := seq.lastMsgs[0]
+	last := msgs[len(msgs)-1]
+	focus := msgs[len(msgs)-2]
+	if last.Role != "user" || last.Content != "раскрой подробнее" {
+		t.Fatalf("user query must stay last, got %+v", last)
+	}
+	if focus.Role != "system" || !strings.Contains(focus.Content, "лёгкие потоки Go") {
+		t.Fatalf("referenced bot message must precede the query, got %+v", focus)
+	}
+	for _, m := range b.history.Get(userID) {
+		if strings.Contains(m.Content, replyContextPrompt) {
+			t.Fatal("reply context must not be stored in history")
+		}
+	}
+}
+
+func TestWithReplyContext_IgnoresRepliesToUsers(t *testing.T) {
+	msgs := []llm.Message{{Role: "user", Content: "q"}}
+	userReply := &tgbotapi.Message{ReplyToMessage: &tgbotapi.Message{From: &tgbotapi.User{ID: 2}, Text: "мой текст"}}
+	if got := withReplyContext(msgs, userReply); len(got) != 1 {
+		t.Errorf("reply to a user message must not add context, got %+v", got)
+	}
+	if got := withReplyContext(msgs, &tgbotapi.Message{}); len(got) != 1 {
+		t.Errorf("plain message must not add context, got %+v", got)
+	}
+}
@@ -0,0 +1,231 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ai-chatter/internal/analytics"
+	"ai-chatter/internal/gmail"
+	"ai-chatter/internal/rustore"
+	"ai-chatter/internal/storage"
+)
+
+const (
+	defaultReportGmailQuery     = "is:unread is:important"
+	defaultReportSectionTimeout = 30 * time.Second
+	reportGmailMaxEmails        = 10
+)
+
+// ReportConfig разделы суточного отчёта администратору
+type ReportConfig struct {
+	ChatStats  bool
+	Gmail      bool
+	GmailQuery string
+	RuStore    bool
+	// RuStorePackages package name приложений, статус которых попадает в отчёт
+	RuStorePackages []string
+	VibeCoding      bool
+	// SectionTimeout сколько ждать каждый раздел; раздел, не уложившийся в срок, помечается ошибкой
+	SectionTimeout time.Duration
+}
+
+func defaultReportConfig() ReportConfig {
+	return ReportConfig{ChatStats: true, Gmail: true, GmailQuery: defaultReportGmailQuery, RuStore: true, VibeCoding: true,
+		SectionTimeout: defaultReportSectionTimeout}
+}
+
+// SetReportConfig задаёт разделы суточного отчёта
+func (b *Bot) SetReportConfig(cfg ReportConfig) {
+	if cfg.GmailQuery == "" {
+		cfg.GmailQuery = defaultReportGmailQuery
+	}
+	if cfg.SectionTimeout <= 0 {
+		cfg.SectionTimeout = defaultReportSectionTimeout
+	}
+	b.reportConfig = &cfg
+}
+
+func (b *Bot) reportSettings() ReportConfig {
+	if b.reportConfig == nil {
+		return defaultReportConfig()
+	}
+	return *b.reportConfig
+}
+
+// reportGmailSearcher часть Gmail MCP клиента, нужная отчёту
+type reportGmailSearcher interface {
+	SearchEmails(ctx context.Context, query string, maxEmails int, timeRange string) gmail.GmailMCPResult
+}
+
+// reportRuStoreLister часть RuStore MCP клиента, нужная отчёту
+type reportRuStoreLister interface {
+	GetAppList(ctx context.Context, params rustore.GetAppListParams) rustore.RuStoreAppListResult
+}
+
+// reportSection раздел отчёта; Err заменяет содержимое заметкой об ошибке
+type reportSection struct {
+	Title string
+	Body  string
+	Err   error
+}
+
+// runReportSection строит раздел с отдельным таймаутом; ошибка, паника или таймаут
+// одного раздела не прерывают остальные
+func runReportSection(ctx context.Context, title string, timeout time.Duration, build func(context.Context) (string, error)) reportSection {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		body string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: fmt.Errorf("паника: %v", r)}
+			}
+		}()
+		body, err := build(ctx)
+		done <- result{body: body, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			log.Printf("⚠️ Report section %q failed: %v", title, r.err)
+		}
+		return reportSection{Title: title, Body: r.body, Err: r.err}
+	case <-ctx.Done():
+		err := ctx.Err()
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("не уложился в %s", timeout)
+		}
+		log.Printf("⚠️ Report section %q failed: %v", title, err)
+		return reportSection{Title: title, Err: err}
+	}
+}
+
+// buildReportSections собирает включённые разделы суточного отчёта за день day
+func (b *Bot) buildReportSections(ctx context.Context, day time.Time) []reportSection {
+	cfg := b.reportSettings()
+	var sections []reportSection
+	if cfg.ChatStats {
+		sections = append(sections, runReportSection(ctx, "Статистика чата", cfg.SectionTimeout, func(ctx context.Context) (string, error) {
+			return b.reportChatStats(day)
+		}))
+	}
+	if cfg.Gmail {
+		sections = append(sections, runReportSection(ctx, "Gmail: непрочитанные важные письма", cfg.SectionTimeout, func(ctx context.Context) (string, error) {
+			return b.reportGmail(ctx, cfg.GmailQuery)
+		}))
+	}
+	if cfg.RuStore {
+		sections = append(sections, runReportSection(ctx, "RuStore: статусы приложений", cfg.SectionTimeout, func(ctx context.Context) (string, error) {
+			return b.reportRuStoreApps(ctx, cfg.RuStorePackages)
+		}))
+	}
+	if cfg.VibeCoding {
+		sections = append(sections, runReportSection(ctx, "VibeCoding", cfg.SectionTimeout, func(ctx context.Context) (string, error) {
+			return b.reportVibeCoding(), nil
+		}))
+	}
+	return sections
+}
+
+// renderReportSections текст разделов для LLM, генерирующей отчёт
+func renderReportSections(sections []reportSection) string {
+	parts := make([]string, 0, len(sections))
+	for _, s := range sections {
+		body := strings.TrimSpace(s.Body)
+		if s.Err != nil {
+			body = fmt.Sprintf("⚠️ Раздел недоступен: %v", s.Err)
+		}
+		parts = append(parts, s.Title+":\n"+body)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// reportChatStats статистика переписки за сутки day
+func (b *Bot) reportChatStats(day time.Time) (string, error) {
+	if b.recorder == nil {
+		return "", fmt.Errorf("recorder не настроен")
+	}
+	// SQLite фильтрует и агрегирует события на своей стороне
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	f := storage.Filter{From: from, To: from.AddDate(0, 0, 1)}
+	events, err := storage.Query(b.recorder, f)
+	if err != nil {
+		return "", fmt.Errorf("не удалось загрузить логи: %w", err)
+	}
+	totals, err := storage.Aggregate(b.recorder, f)
+	if err != nil {
+		return "", fmt.Errorf("не удалось посчитать итоги: %w", err)
+	}
+	summary := analytics.AnalyzeDailyLogs(events, day).GenerateReportSummary()
+	summary += fmt.Sprintf("\nОтветов ассистента: %d, токенов LLM: %d, стоимость: $%.4f", totals.AssistantMessages, totals.Tokens, totals.Cost)
+	return summary, nil
+}
+
+// reportGmail непрочитанные важные письма за неделю по запросу query
+func (b *Bot) reportGmail(ctx context.Context, query string) (string, error) {
+	if b.reportGmailClient == nil {
+		return "Gmail не подключён", nil
+	}
+	res := b.reportGmailClient.SearchEmails(ctx, query, reportGmailMaxEmails, "week")
+	if !res.Success {
+		return "", fmt.Errorf("поиск писем: %s", res.Message)
+	}
+	if len(res.Emails) == 0 {
+		return fmt.Sprintf("Писем по запросу %q нет", query), nil
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Найдено писем: %d\n", max(res.TotalFound, len(res.Emails))))
+	for _, e := range res.Emails {
+		sb.WriteString(fmt.Sprintf("- %s — %s (%s)\n", e.From, e.Subject, e.Date.Format("2006-01-02 15:04")))
+	}
+	return sb.String(), nil
+}
+
+// reportRuStoreApps статусы приложений RuStore; ошибка по одному пакету не скрывает остальные
+func (b *Bot) reportRuStoreApps(ctx context.Context, packages []string) (string, error) {
+	if b.reportRuStoreClient == nil {
+		return "RuStore не подключён", nil
+	}
+	if len(packages) == 0 {
+		return "Пакеты приложений не заданы (REPORT_RUSTORE_PACKAGES)", nil
+	}
+	var sb strings.Builder
+	for _, pkg := range packages {
+		if err := ctx.Err(); err != nil {
+			return sb.String(), err
+		}
+		res := b.reportRuStoreClient.GetAppList(ctx, rustore.GetAppListParams{AppPackage: pkg, PageSize: 1})
+		switch {
+		case !res.Success:
+			sb.WriteString(fmt.Sprintf("- %s: ⚠️ ошибка: %s\n", pkg, res.Message))
+		case len(res.Applications) == 0:
+			sb.WriteString(fmt.Sprintf("- %s: приложение не найдено\n", pkg))
+		default:
+			app := res.Applications[0]
+			status := app.Status
+			if status == "" {
+				status = "статус неизвестен"
+			}
+			sb.WriteString(fmt.Sprintf("- %s (%s): %s\n", app.Name, pkg, status))
+		}
+	}
+	return sb.String(), nil
+}
+
+// reportVibeCoding сводка сессий и инструментов VibeCoding
+func (b *Bot) reportVibeCoding() string {
+	if b.vibeCodingHandler == nil {
+		return "VibeCoding не инициализирован"
+	}
+	return b.vibeCodingHandler.SessionsSummary(time.Now())
+}
@@ -0,0 +1,78 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-chatter/internal/gmail"
+	"ai-chatter/internal/rustore"
+)
+
+type stubGmail struct {
+	res   gmail.GmailMCPResult
+	query string
+	hang  bool
+}
+
+func (s *stubGmail) SearchEmails(ctx context.Context, query string, maxEmails int, timeRange string) gmail.GmailMCPResult {
+	s.query = query
+	if s.hang {
+		<-ctx.Done()
+	}
+	return s.res
+}
+
+type stubRuStore struct {
+	apps map[string]rustore.RuStoreAppListResult
+}
+
+func (s *stubRuStore) GetAppList(ctx context.Context, params rustore.GetAppListParams) rustore.RuStoreAppListResult {
+	return s.apps[params.AppPackage]
+}
+
+func TestDailyReport_SectionFailureIsIsolated(t *testing.T) {
+	ok := rustore.RuStoreAppListResult{RuStoreMCPResult: rustore.RuStoreMCPResult{Success: true},
+		Applications: []rustore.RuStoreAppInfo{{Name: "Chatter", PackageName: "com.example.chatter", Status: "PUBLISHED"}}}
+	failed := rustore.RuStoreAppListResult{RuStoreMCPResult: rustore.RuStoreMCPResult{Message: "401 Unauthorized"}}
+	gm := &stubGmail{hang: true}
+	b := &Bot{
+		reportGmailClient:   gm,
+		reportRuStoreClient: &stubRuStore{apps: map[string]rustore.RuStoreAppListResult{"com.example.chatter": ok, "com.example.broken": failed}},
+	}
+	b.SetReportConfig(ReportConfig{ChatStats: true, Gmail: true, GmailQuery: "label:alerts", RuStore: true,
+		RuStorePackages: []string{"com.example.chatter", "com.example.broken"}, SectionTimeout: 50 * time.Millisecond})
+
+	sections := b.buildReportSections(context.Background(), time.Now())
+	if len(sections) != 3 {
+		t.Fatalf("only enabled sections must be built, got %+v", sections)
+	}
+	if sections[0].Err == nil || sections[1].Err == nil {
+		t.Fatalf("missing recorder and hanging Gmail must be reported as section errors: %+v", sections)
+	}
+	if gm.query != "label:alerts" {
+		t.Errorf("configured Gmail query must be used, got %q", gm.query)
+	}
+
+	text := renderReportSections(sections)
+	for _, want := range []string{
+		"Статистика чата:\n⚠️ Раздел недоступен: recorder не настроен",
+		"Gmail: непрочитанные важные письма:\n⚠️ Раздел недоступен: не уложился в 50ms",
+		"- Chatter (com.example.chatter): PUBLISHED",
+		"- com.example.broken: ⚠️ ошибка: 401 Unauthorized",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("report must contain %q, got:\n%s", want, text)
+		}
+	}
+}
+
+func TestRunReportSection_RecoversPanic(t *testing.T) {
+	s := runReportSection(context.Background(), "x", time.Second, func(context.Context) (string, error) {
+		panic("boom")
+	})
+	if s.Err == nil || !strings.Contains(s.Err.Error(), "boom") {
+		t.Fatalf("panic must become a section error, got %+v", s)
+	}
+}
@@ -0,0 +1,72 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ai-chatter/internal/auth"
+)
+
+const readonlyAccessText = "Доступ только для чтения: можно запросить историю диалога кнопкой «История»"
+
+// roleOf роль пользователя; владелец бота (ADMIN_USER_ID) всегда admin
+func (b *Bot) roleOf(userID int64) auth.Role {
+	if userID == b.adminUserID && userID != 0 {
+		return auth.RoleAdmin
+	}
+	if b.authSvc == nil {
+		return auth.RoleNone
+	}
+	return b.authSvc.RoleOf(userID)
+}
+
+// commandRole минимальная роль для команды: VibeCoding — admin, /onboarding — readonly, остальные — user
+func commandRole(command string) auth.Role {
+	if strings.HasPrefix(command, "vibecoding_") {
+		return auth.RoleAdmin
+	}
+	if command == "onboarding" {
+		return auth.RoleReadonly
+	}
+	return auth.RoleUser
+}
+
+// requireRole проверяет роль и сообщает об отказе; пользователям вне allowlist не отвечает
+func (b *Bot) requireRole(chatID, userID int64, required auth.Role) bool {
+	role := b.roleOf(userID)
+	if role.Allows(required) {
+		return true
+	}
+	switch role {
+	case auth.RoleNone:
+	case auth.RoleReadonly:
+		b.sendMessage(chatID, readonlyAccessText)
+	default:
+		b.sendMessage(chatID, fmt.Sprintf("Недостаточно прав: нужна роль %s", required))
+	}
+	return false
+}
+
+// handleRoleCommand назначает роль пользователю из allowlist: /role <user_id> <admin|user|readonly>
+func (b *Bot) handleRoleCommand(chatID int64, args []string) {
+	if len(args) != 2 {
+		b.sendMessage(chatID, "Usage: /role <user_id> <admin|user|readonly>")
+		return
+	}
+	uid, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		b.sendMessage(chatID, "Некорректный user_id")
+		return
+	}
+	role, err := auth.ParseRole(args[1])
+	if err != nil {
+		b.sendMessage(chatID, "Поддерживаются роли: admin, user, readonly")
+		return
+	}
+	if err := b.authSvc.SetRole(uid, role); err != nil {
+		b.sendMessage(chatID, fmt.Sprintf("Ошибка назначения роли: %v", err))
+		return
+	}
+	b.sendMessage(chatID, fmt.Sprintf("Пользователю %d назначена роль %s", uid, role))
+}
@@ -0,0 +1,60 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/history"
+	"ai-chatter/internal/llm"
+)
+
+func newCommand(userID int64, text string) *tgbotapi.Message {
+	cmd, _, _ := strings.Cut(text, " ")
+	msg := &tgbotapi.Message{From: &tgbotapi.User{ID: userID}, Chat: &tgbotapi.Chat{ID: userID}, Text: text}
+	msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(cmd)}}
+	return msg
+}
+
+func TestRoles_GateCommandsAndChat(t *testing.T) {
+	svc, _ := auth.NewWithRepo(nil, []int64{5, 6, 7})
+	fs := &fakeSender{}
+	b := &Bot{s: fs, authSvc: svc, adminUserID: 1, pending: make(map[int64]auth.User), history: history.NewManager(),
+		llmClient: fakeLLM{resp: llm.Response{Content: "answer"}}, llmFactory: &llm.Factory{}}
+
+	// Владелец назначает роли через /role
+	b.handleCommand(newCommand(1, "/role 5 readonly"))
+	b.handleCommand(newCommand(1, "/role 6 admin"))
+	b.handleCommand(newCommand(1, "/role 8 admin"))
+	if svc.RoleOf(5) != auth.RoleReadonly || svc.RoleOf(6) != auth.RoleAdmin {
+		t.Fatalf("roles not assigned: 5=%q 6=%q", svc.RoleOf(5), svc.RoleOf(6))
+	}
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "Ошибка назначения роли") {
+		t.Fatalf("user outside allowlist must be rejected: %q", last)
+	}
+
+	// readonly не общается с ботом и не выполняет команды
+	sent := len(fs.sent)
+	b.handleIncomingMessage(context.Background(), &tgbotapi.Message{From: &tgbotapi.User{ID: 5}, Chat: &tgbotapi.Chat{ID: 5}, Text: "hello"})
+	b.handleCommand(newCommand(5, "/reset"))
+	if len(fs.sent) != sent+2 || fs.sent[sent] != readonlyAccessText || fs.sent[sent+1] != readonlyAccessText {
+		t.Fatalf("readonly user must get read-only notice: %+v", fs.sent[sent:])
+	}
+	if len(b.history.Get(5)) != 0 {
+		t.Fatal("readonly message must not reach history")
+	}
+
+	// VibeCoding только для admin
+	b.handleCommand(newCommand(7, "/vibecoding_info"))
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "нужна роль admin") {
+		t.Fatalf("vibecoding must require admin: %q", last)
+	}
+
+	// /model: admin по роли может менять модель, обычный пользователь — нет
+	if !b.canOverrideModel(6) || b.canOverrideModel(7) || b.canOverrideModel(5) {
+		t.Fatal("unexpected /model permissions")
+	}
+}
@@ -0,0 +1,275 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/llm"
+	"ai-chatter/internal/scheduler"
+	"ai-chatter/internal/storage"
+)
+
+const scheduleUsage = "Использование: /schedule \"<расписание>\" \"<запрос>\"\n" +
+	"Расписание: every day at 09:00, каждый день в 09:00, every monday at 10:30, по будням в 08:00, 0 9 * * 1-5\n" +
+	"/schedules — список, /unschedule <id> — удалить, /schedule tz <Europe/Moscow> — часовой пояс"
+
+// scheduleMaxSpecWords сколько первых слов без кавычек пробуем разобрать как расписание
+const scheduleMaxSpecWords = 6
+
+// promptScheduler планировщик пользовательских запросов (реализуется scheduler.Scheduler)
+type promptScheduler interface {
+	SchedulePrompt(userID, chatID int64, spec, prompt string) (scheduler.ScheduledPrompt, error)
+	UnschedulePrompt(userID, id int64) error
+	UnscheduleAll(userID int64) error
+	UserPrompts(userID int64) []scheduler.ScheduledPrompt
+	UserLocation(userID int64) *time.Location
+	SetUserTimezone(userID int64, loc *time.Location) error
+}
+
+// SetPromptScheduler включает команды /schedule, /schedules и /unschedule
+func (b *Bot) SetPromptScheduler(s promptScheduler) {
+	b.promptScheduler = s
+}
+
+func (b *Bot) handleScheduleCommand(msg *tgbotapi.Message) {
+	userID := msg.From.ID
+	if !b.authSvc.IsAllowed(userID) {
+		return
+	}
+	if b.promptScheduler == nil {
+		b.sendMessage(msg.Chat.ID, "Запросы по расписанию не настроены")
+		return
+	}
+
+	args := strings.TrimSpace(msg.CommandArguments())
+	fields := strings.Fields(args)
+	switch {
+	case args == "" || args == "list":
+		b.sendMessage(msg.Chat.ID, b.formatScheduledPrompts(userID))
+		return
+	case fields[0] == "tz":
+		b.handleScheduleTimezone(msg.Chat.ID, userID, fields[1:])
+		return
+	}
+
+	spec, prompt, ok := splitScheduleArgs(args)
+	if !ok {
+		b.sendMessage(msg.Chat.ID, "Не удалось разобрать расписание.\n"+scheduleUsage)
+		return
+	}
+	if prompt == "" {
+		b.sendMessage(msg.Chat.ID, "Укажите текст запроса.\n"+scheduleUsage)
+		return
+	}
+
+	p, err := b.promptScheduler.SchedulePrompt(userID, msg.Chat.ID, spec, prompt)
+	if err != nil {
+		switch {
+		case errors.Is(err, scheduler.ErrPromptLimit):
+			b.sendMessage(msg.Chat.ID, "Достигнут лимит запросов по расписанию. Удалите ненужные: /schedules, /unschedule <id>")
+		case errors.Is(err, scheduler.ErrScheduleSpec):
+			b.sendMessage(msg.Chat.ID, "Не удалось разобрать расписание.\n"+scheduleUsage)
+		default:
+			log.Printf("❌ Failed to schedule prompt for user %d: %v", userID, err)
+			b.sendMessage(msg.Chat.ID, "Не удалось сохранить запрос")
+		}
+		return
+	}
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("🗓 Запрос #%d запланирован (%s). Следующий запуск: %s", p.ID, p.Spec, formatPromptNext(p)))
+}
+
+func (b *Bot) handleScheduleTimezone(chatID, userID int64, args []string) {
+	if len(args) == 0 {
+		b.sendMessage(chatID, fmt.Sprintf("Часовой пояс запросов: %s\nИзменить: /schedule tz <Europe/Moscow>", b.promptScheduler.UserLocation(userID)))
+		return
+	}
+	loc, err := time.LoadLocation(args[0])
+	if err != nil {
+		b.sendMessage(chatID, fmt.Sprintf("Неизвестный часовой пояс %q. Пример: Europe/Moscow, Asia/Yekaterinburg, UTC", args[0]))
+		return
+	}
+	if err := b.promptScheduler.SetUserTimezone(userID, loc); err != nil {
+		log.Printf("❌ Failed to set schedule timezone for user %d: %v", userID, err)
+		b.sendMessage(chatID, "Не удалось сохранить часовой пояс")
+		return
+	}
+	b.sendMessage(chatID, fmt.Sprintf("🌍 Часовой пояс запросов: %s", loc))
+}
+
+func (b *Bot) handleSchedulesCommand(msg *tgbotapi.Message) {
+	if !b.authSvc.IsAllowed(msg.From.ID) {
+		return
+	}
+	if b.promptScheduler == nil {
+		b.sendMessage(msg.Chat.ID, "Запросы по расписанию не настроены")
+		return
+	}
+	b.sendMessage(msg.Chat.ID, b.formatScheduledPrompts(msg.From.ID))
+}
+
+func (b *Bot) handleUnscheduleCommand(msg *tgbotapi.Message) {
+	userID := msg.From.ID
+	if !b.authSvc.IsAllowed(userID) {
+		return
+	}
+	if b.promptScheduler == nil {
+		b.sendMessage(msg.Chat.ID, "Запросы по расписанию не настроены")
+		return
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(msg.CommandArguments()), "#"), 10, 64)
+	if err != nil {
+		b.sendMessage(msg.Chat.ID, "Использование: /unschedule <id>")
+		return
+	}
+	if err := b.promptScheduler.UnschedulePrompt(userID, id); err != nil {
+		if errors.Is(err, scheduler.ErrPromptNotFound) {
+			b.sendMessage(msg.Chat.ID, fmt.Sprintf("Запрос #%d не найден", id))
+			return
+		}
+		log.Printf("❌ Failed to unschedule prompt %d: %v", id, err)
+		b.sendMessage(msg.Chat.ID, "Не удалось удалить запрос")
+		return
+	}
+	b.sendMessage(msg.Chat.ID, fmt.Sprintf("🗑 Запрос #%d удалён", id))
+}
+
+func (b *Bot) formatScheduledPrompts(userID int64) string {
+	list := b.promptScheduler.UserPrompts(userID)
+	if len(list) == 0 {
+		return "Запросов по расписанию нет.\n" + scheduleUsage
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🗓 Ваши запросы (%s):\n", b.promptScheduler.UserLocation(userID)))
+	for _, p := range list {
+		sb.WriteString(fmt.Sprintf("#%d — %s, следующий: %s\n   %s\n", p.ID, p.Spec, formatPromptNext(p), p.Prompt))
+		if p.LastError != "" {
+			sb.WriteString(fmt.Sprintf("   ⚠️ последний запуск: %s\n", p.LastError))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func formatPromptNext(p scheduler.ScheduledPrompt) string {
+	next := p.Next(time.Now())
+	if next.IsZero() {
+		return "—"
+	}
+	return next.Format("2006-01-02 15:04 MST")
+}
+
+// splitScheduleArgs отделяет расписание от текста запроса: расписание в кавычках
+// или кратчайший префикс из первых слов, который разбирается как расписание
+func splitScheduleArgs(args string) (spec, prompt string, ok bool) {
+	if quoted, rest, found := cutQuoted(args); found {
+		if _, err := scheduler.ParseScheduleSpec(quoted); err != nil {
+			return "", "", false
+		}
+		if p, _, inner := cutQuoted(rest); inner {
+			rest = p
+		}
+		return quoted, strings.TrimSpace(rest), true
+	}
+	words := strings.Fields(args)
+	for n := 1; n <= min(len(words), scheduleMaxSpecWords); n++ {
+		candidate := strings.Join(words[:n], " ")
+		if _, err := scheduler.ParseScheduleSpec(candidate); err == nil {
+			return candidate, strings.Join(words[n:], " "), true
+		}
+	}
+	return "", "", false
+}
+
+// cutQuoted возвращает текст в начальных кавычках ("", «», “”) и остаток строки
+func cutQuoted(s string) (quoted, rest string, ok bool) {
+	s = strings.TrimSpace(s)
+	for _, pair := range [][2]string{{`"`, `"`}, {"«", "»"}, {"“", "”"}} {
+		if !strings.HasPrefix(s, pair[0]) {
+			continue
+		}
+		body := s[len(pair[0]):]
+		end := strings.Index(body, pair[1])
+		if end < 0 {
+			return "", "", false
+		}
+		return strings.TrimSpace(body[:end]), body[end+len(pair[1]):], true
+	}
+	return "", "", false
+}
+
+// isMailPrompt запрос про почту: для администратора выполняется через Gmail workflow
+func isMailPrompt(prompt string) bool {
+	lower := strings.ToLower(prompt)
+	for _, kw := range []string{"gmail", "почт", "письм", "inbox", "email", "e-mail"} {
+		if strings.Contains(lower, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunScheduledPrompt выполняет запланированный запрос с контекстом пользователя и присылает ответ в его чат
+// (вызывается планировщиком). В режиме обслуживания запрос откладывается до /maintenance off
+func (b *Bot) RunScheduledPrompt(ctx context.Context, p scheduler.ScheduledPrompt) error {
+	if b.deferScheduledPrompt(p) {
+		return nil
+	}
+	if !b.roleOf(p.UserID).Allows(auth.RoleUser) {
+		return fmt.Errorf("user %d is no longer allowed", p.UserID)
+	}
+	if b.isTZMode(p.UserID) {
+		return errors.New("skipped: technical specification mode is active")
+	}
+
+	if b.gmailWorkflow != nil && b.roleOf(p.UserID) == auth.RoleAdmin && isMailPrompt(p.Prompt) {
+		return b.runScheduledGmailPrompt(ctx, p)
+	}
+
+	seed := "🗓 " + p.Prompt
+	b.history.AppendUser(p.UserID, seed)
+	if b.recorder != nil {
+		tru := true
+		_ = b.recorder.AppendInteraction(storage.Event{Timestamp: b.nowUTC(), UserID: p.UserID, ChatID: p.ChatID, UserMessage: seed, CanUse: &tru})
+	}
+	contextMsgs := b.buildContextWithOverflow(ctx, p.UserID)
+	b.logLLMRequest(p.UserID, "scheduled_prompt", contextMsgs)
+
+	client := b.getUserLLMClient(p.UserID)
+	var resp llm.Response
+	var err error
+	if b.mcpClient != nil {
+		resp, err = client.GenerateWithTools(ctx, contextMsgs, llm.GetNotionTools())
+	} else {
+		resp, err = client.Generate(ctx, contextMsgs)
+	}
+	if err != nil {
+		b.sendMessage(p.ChatID, fmt.Sprintf("🗓 Запрос #%d по расписанию не выполнен: %v", p.ID, err))
+		return err
+	}
+	b.processLLMAndRespond(ctx, p.ChatID, p.UserID, resp)
+	return nil
+}
+
+// runScheduledGmailPrompt выполняет почтовый запрос администратора через Gmail workflow
+func (b *Bot) runScheduledGmailPrompt(ctx context.Context, p scheduler.ScheduledPrompt) error {
+	initial := tgbotapi.NewMessage(p.ChatID, fmt.Sprintf("🗓 Запрос #%d по расписанию\n\n⏳ Обработка Gmail запроса...", p.ID))
+	sent, err := b.s.Send(initial)
+	if err != nil {
+		return fmt.Errorf("failed to send progress message: %w", err)
+	}
+	tracker := NewProgressTracker(b, p.ChatID, sent.MessageID)
+	pageURL, err := b.gmailWorkflow.ProcessGmailSummaryRequestWithProgress(ctx, p.Prompt, tracker)
+	if err != nil {
+		b.sendMessage(p.ChatID, fmt.Sprintf("🗓 Запрос #%d по расписанию не выполнен: %v", p.ID, err))
+		return err
+	}
+	tracker.SetFinalResult(pageURL)
+	return nil
+}
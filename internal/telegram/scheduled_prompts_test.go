@@ -0,0 +1,73 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/history"
+	"ai-chatter/internal/llm"
+	"ai-chatter/internal/scheduler"
+)
+
+func TestSplitScheduleArgs(t *testing.T) {
+	cases := []struct{ in, spec, prompt string }{
+		{`"every day at 09:00" "сводка новостей"`, "every day at 09:00", "сводка новостей"},
+		{"«по будням в 08:00» план на день", "по будням в 08:00", "план на день"},
+		{"every monday at 10:30 weekly report", "every monday at 10:30", "weekly report"},
+		{"0 9 * * 1-5 stand-up notes", "0 9 * * 1-5", "stand-up notes"},
+	}
+	for _, c := range cases {
+		spec, prompt, ok := splitScheduleArgs(c.in)
+		if !ok || spec != c.spec || prompt != c.prompt {
+			t.Errorf("splitScheduleArgs(%q) = %q, %q, %v", c.in, spec, prompt, ok)
+		}
+	}
+	if _, _, ok := splitScheduleArgs(`"sometimes" "news"`); ok {
+		t.Errorf("invalid quoted schedule must be rejected")
+	}
+}
+
+func TestScheduleCommand_RunsPromptAndManagesList(t *testing.T) {
+	svc, _ := auth.NewWithRepo(nil, []int64{3})
+	fs := &fakeSender{}
+	b := &Bot{s: fs, authSvc: svc, pending: make(map[int64]auth.User), parseMode: "HTML", history: history.NewManager(),
+		llmClient: fakeLLM{resp: llm.Response{Content: "утренняя сводка"}}, llmFactory: &llm.Factory{}}
+	store, err := scheduler.NewPromptStore(t.TempDir()+"/prompts.json", 1, time.UTC)
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	sched := scheduler.New()
+	defer sched.Stop()
+	sched.SetScheduledPrompts(store, b.RunScheduledPrompt)
+	b.SetPromptScheduler(sched)
+
+	b.handleCommand(newCommand(3, `/schedule "every day at 09:00" "что нового"`))
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "Запрос #1 запланирован") {
+		t.Fatalf("unexpected reply: %q", last)
+	}
+	b.handleCommand(newCommand(3, `/schedule "every day at 10:00" "ещё один"`))
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "лимит") {
+		t.Fatalf("limit must be enforced: %q", last)
+	}
+	b.handleCommand(newCommand(3, "/schedules"))
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "#1 — every day at 09:00") || !strings.Contains(last, "что нового") {
+		t.Fatalf("unexpected list: %q", last)
+	}
+
+	// Планировщик выполняет запрос через LLM и присылает ответ в чат
+	sched.RunScheduledPrompt(context.Background(), 1)
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "утренняя сводка") {
+		t.Fatalf("scheduled answer not delivered: %q", last)
+	}
+	if p, _ := store.Get(1); p.LastRunAt.IsZero() || p.LastError != "" {
+		t.Fatalf("run not recorded: %+v", p)
+	}
+
+	b.handleCommand(newCommand(3, "/unschedule 1"))
+	if got := store.List(3); len(got) != 0 {
+		t.Fatalf("prompt must be removed: %+v", got)
+	}
+}
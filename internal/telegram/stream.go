@@ -0,0 +1,240 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/llm"
+	"ai-chatter/internal/telegram/msgsplit"
+	"ai-chatter/internal/telegram/streamedit"
+)
+
+// streamEditChars прирост текста, после которого превью обновляется, не дожидаясь интервала
+const streamEditChars = 200
+
+// SetStreaming включает потоковые ответы: плейсхолдер редактируется накопленным текстом не чаще interval
+func (b *Bot) SetStreaming(enabled bool, interval time.Duration) {
+	if interval < streamedit.MinEditInterval {
+		interval = streamedit.MinEditInterval
+	}
+	b.streamMu.Lock()
+	defer b.streamMu.Unlock()
+	b.streamEnabled = enabled
+	b.streamInterval = interval
+}
+
+func (b *Bot) streamingEnabled() bool {
+	b.streamMu.Lock()
+	defer b.streamMu.Unlock()
+	return b.streamEnabled
+}
+
+// streamPreview плейсхолдер стриминга одного ответа. Передаётся через context запроса до
+// sendResponseMessage, поэтому параллельные ответы в одном чате не заменяют чужие превью
+type streamPreview struct {
+	mu        sync.Mutex
+	chatID    int64
+	messageID int // 0 — плейсхолдера нет или он уже заменён ответом
+}
+
+type streamPreviewKey struct{}
+
+// withStreamPreview заводит плейсхолдер для ответа в чат chatID; его нужно закрыть discardStreamPreview
+func withStreamPreview(ctx context.Context, chatID int64) (context.Context, *streamPreview) {
+	p := &streamPreview{chatID: chatID}
+	return context.WithValue(ctx, streamPreviewKey{}, p), p
+}
+
+func streamPreviewFrom(ctx context.Context) *streamPreview {
+	p, _ := ctx.Value(streamPreviewKey{}).(*streamPreview)
+	return p
+}
+
+func (p *streamPreview) set(messageID int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messageID = messageID
+}
+
+// take забирает плейсхолдер для замены ответом; повторный вызов ничего не возвращает
+func (p *streamPreview) take() (int, bool) {
+	if p == nil {
+		return 0, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := p.messageID
+	p.messageID = 0
+	return id, id != 0
+}
+
+// discardStreamPreview удаляет превью, которое так и не заменили ответом (например, ответ ушёл
+// вызовом инструмента или отдельным сообщением финального ТЗ)
+func (b *Bot) discardStreamPreview(p *streamPreview) {
+	id, ok := p.take()
+	if !ok {
+		return
+	}
+	if _, err := b.s.Request(tgbotapi.NewDeleteMessage(p.chatID, id)); err != nil {
+		log.Printf("⚠️ Failed to delete orphaned streaming preview: %v", err)
+	}
+}
+
+// generateStreaming генерирует ответ потоково, показывая накопленный текст в сообщении-плейсхолдере.
+// streamed=false означает, что провайдер не поддерживает стриминг и нужно вызвать Generate
+// (плейсхолдер в этом случае не создаётся). Плейсхолдер запоминается в streamPreview из ctx
+// (withStreamPreview) и заменяется финальным ответом в sendResponseMessage
+func (b *Bot) generateStreaming(ctx context.Context, chatID int64, client llm.Client, msgs []llm.Message) (resp llm.Response, streamed bool, err error) {
+	var (
+		mu      sync.Mutex
+		acc     strings.Builder
+		once    sync.Once
+		started = make(chan struct{})
+		grown   = make(chan struct{}, 1)
+		pending int
+		done    = make(chan struct{})
+		result  = make(chan streamPlaceholder, 1)
+	)
+	snapshot := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return acc.String()
+	}
+	go func() {
+		result <- b.runStreamEditor(chatID, started, done, grown, snapshot)
+	}()
+
+	resp, err = client.GenerateStream(ctx, msgs, func(delta string) {
+		mu.Lock()
+		acc.WriteString(delta)
+		pending += len(delta)
+		notify := pending >= streamEditChars
+		if notify {
+			pending = 0
+		}
+		mu.Unlock()
+		once.Do(func() { close(started) })
+		if notify {
+			select {
+			case grown <- struct{}{}:
+			default:
+			}
+		}
+	})
+	close(done)
+	placeholder := <-result
+
+	if errors.Is(err, llm.ErrStreamingNotSupported) {
+		return resp, false, nil
+	}
+	if err != nil {
+		if placeholder.ok {
+			b.editStreamMessage(chatID, placeholder.id, previewText(snapshot())+"\n\n⚠️ Ответ прерван")
+		}
+		return resp, true, err
+	}
+	if preview := streamPreviewFrom(ctx); placeholder.ok && preview != nil {
+		preview.set(placeholder.id)
+	}
+	return resp, true, nil
+}
+
+type streamPlaceholder struct {
+	id int
+	ok bool
+}
+
+// runStreamEditor ведёт плейсхолдер ответа (streamedit): обновляет его по интервалу или после
+// прироста текста на streamEditChars
+func (b *Bot) runStreamEditor(chatID int64, started, done, grown <-chan struct{}, snapshot func() string) streamPlaceholder {
+	b.streamMu.Lock()
+	interval := b.streamInterval
+	b.streamMu.Unlock()
+	editor := streamedit.Editor{
+		ChatID:   chatID,
+		Interval: interval,
+		Started:  started,
+		Done:     done,
+		Grown:    grown,
+		Snapshot: func() string { return previewText(snapshot()) },
+	}
+	id, ok := editor.Run(b.s)
+	return streamPlaceholder{id: id, ok: ok}
+}
+
+func (b *Bot) editStreamMessage(chatID int64, messageID int, text string) error {
+	_, err := b.s.Send(tgbotapi.NewEditMessageText(chatID, messageID, text))
+	if err != nil {
+		log.Printf("⚠️ Failed to edit streaming message: %v", err)
+	}
+	return err
+}
+
+// sendResponseMessage отправляет финальный ответ, разбивая длинный текст на части; если у запроса
+// есть плейсхолдер стриминга (withStreamPreview), первая часть заменяет его текст вместо отправки нового сообщения
+func (b *Bot) sendResponseMessage(ctx context.Context, msg tgbotapi.MessageConfig) {
+	msg.Text = b.withVoiceTranscript(msg.ChatID, msg.Text)
+	parts, overflow := splitResponse(msg)
+	if id, ok := streamPreviewFrom(ctx).take(); ok {
+		first := parts[0]
+		edit := tgbotapi.NewEditMessageText(first.ChatID, id, first.Text)
+		edit.ParseMode = first.ParseMode
+		if kb, ok := first.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); ok {
+			edit.ReplyMarkup = &kb
+		}
+		if _, err := b.s.Send(edit); err == nil {
+			parts = parts[1:]
+		} else {
+			log.Printf("⚠️ Failed to replace streaming placeholder, sending a new message")
+		}
+	}
+	b.sendResponseParts(parts, overflow)
+}
+
+// sendLongMessage отправляет ответ, который может не поместиться в одно сообщение Telegram
+func (b *Bot) sendLongMessage(msg tgbotapi.MessageConfig) {
+	b.sendResponseParts(splitResponse(msg))
+}
+
+func (b *Bot) sendResponseParts(parts []tgbotapi.MessageConfig, overflow *tgbotapi.DocumentConfig) {
+	for _, part := range parts {
+		if _, err := b.s.Send(part); err != nil {
+			log.Printf("⚠️ Failed to send response part: %v", err)
+		}
+	}
+	if overflow != nil {
+		if _, err := b.s.Send(*overflow); err != nil {
+			log.Printf("⚠️ Failed to send response overflow file: %v", err)
+		}
+	}
+}
+
+// splitResponse делит уже размеченный текст msg на части (msgsplit) с сохранением остальных полей
+// сообщения; клавиатура остаётся только под последней частью
+func splitResponse(msg tgbotapi.MessageConfig) ([]tgbotapi.MessageConfig, *tgbotapi.DocumentConfig) {
+	split, overflow := msgsplit.Messages(msg.ChatID, msg.Text, msgsplit.Options{ParseMode: msg.ParseMode})
+	if len(split) <= 1 {
+		return []tgbotapi.MessageConfig{msg}, overflow
+	}
+	parts := make([]tgbotapi.MessageConfig, len(split))
+	for i, s := range split {
+		parts[i] = msg
+		parts[i].Text = s.Text
+		if i < len(split)-1 {
+			parts[i].ReplyMarkup = nil
+		}
+	}
+	return parts, overflow
+}
+
+// previewText текст превью: ответы модели приходят в JSON ({"title": ..., "answer": ...}),
+// поэтому показываем уже полученную часть поля answer
+func previewText(raw string) string {
+	return streamedit.PreviewText(raw, "answer")
+}
@@ -0,0 +1,216 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/history"
+	"ai-chatter/internal/llm"
+	"ai-chatter/internal/telegram/streamedit"
+)
+
+// fakeStreamLLM отдаёт ответ фрагментами с паузой между ними
+type fakeStreamLLM struct {
+	fakeLLM
+	chunks []string
+	delay  time.Duration
+}
+
+func (f fakeStreamLLM) GenerateStream(_ context.Context, _ []llm.Message, onDelta llm.StreamFunc) (llm.Response, error) {
+	var sb strings.Builder
+	for _, c := range f.chunks {
+		time.Sleep(f.delay)
+		sb.WriteString(c)
+		onDelta(c)
+	}
+	return llm.Response{Content: sb.String(), Model: "m"}, nil
+}
+
+func newStreamTestBot(client llm.Client) (*Bot, *fakeSender) {
+	svc, _ := auth.NewWithRepo(nil, []int64{4})
+	fs := &fakeSender{}
+	b := &Bot{s: fs, authSvc: svc, llmClient: client, pending: make(map[int64]auth.User), parseMode: "HTML", history: history.NewManager()}
+	b.streamEnabled = true
+	b.streamInterval = 5 * time.Millisecond
+	return b, fs
+}
+
+func TestHandleIncomingMessage_StreamsIntoPlaceholder(t *testing.T) {
+	client := fakeStreamLLM{
+		chunks: []string{`{"title":"T","ans`, `wer":"Первая часть`, `, вторая часть\n`, `и конец"}`},
+		delay:  20 * time.Millisecond,
+	}
+	b, fs := newStreamTestBot(client)
+
+	b.handleIncomingMessage(context.Background(), &tgbotapi.Message{From: &tgbotapi.User{ID: 4}, Chat: &tgbotapi.Chat{ID: 4}, Text: "hi"})
+
+	if len(fs.sent) != 1 {
+		t.Fatalf("expected only the placeholder to be sent, got %q", fs.sent)
+	}
+	if len(fs.edits) < 2 {
+		t.Fatalf("expected intermediate edits and final replacement, got %q", fs.edits)
+	}
+	for _, e := range fs.edits[:len(fs.edits)-1] {
+		if strings.Contains(e, `"answer"`) {
+			t.Fatalf("raw JSON leaked into preview: %q", e)
+		}
+	}
+	final := fs.edits[len(fs.edits)-1]
+	if !strings.Contains(final, "и конец") || strings.Contains(final, streamedit.Cursor) {
+		t.Fatalf("placeholder must be replaced with the final answer: %q", final)
+	}
+	if len(fs.deleted) != 0 {
+		t.Fatalf("replaced placeholder must not be deleted, got %v", fs.deleted)
+	}
+}
+
+func TestHandleIncomingMessage_FallsBackWithoutStreaming(t *testing.T) {
+	b, fs := newStreamTestBot(fakeLLM{resp: llm.Response{Content: `{"title":"T","answer":"one-shot"}`, Model: "m"}})
+
+	b.handleIncomingMessage(context.Background(), &tgbotapi.Message{From: &tgbotapi.User{ID: 4}, Chat: &tgbotapi.Chat{ID: 4}, Text: "hi"})
+
+	if len(fs.edits) != 0 || len(fs.sent) != 1 || !strings.Contains(fs.sent[0], "one-shot") {
+		t.Fatalf("expected a single one-shot message, sent=%q edits=%q", fs.sent, fs.edits)
+	}
+}
+
+func TestSendResponseMessage_SplitsLongAnswer(t *testing.T) {
+	b, fs := newStreamTestBot(fakeLLM{})
+	ctx, preview := withStreamPreview(context.Background(), 4)
+	preview.set(7)
+	long := "Ответ:\n\n```go\n" + strings.Repeat("fmt.Println(\"line\")\n", 400) + "```"
+	msg := tgbotapi.NewMessage(4, long)
+	msg.ParseMode = tgbotapi.ModeHTML
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("ok", "ok")))
+
+	b.sendResponseMessage(ctx, msg)
+
+	if len(fs.edits) != 1 || !strings.Contains(fs.edits[0], "Часть 1 из 3") {
+		t.Fatalf("first part must replace the placeholder, got %q", fs.edits)
+	}
+	if len(fs.sent) != 2 || !strings.HasPrefix(fs.sent[0], "```go\n") {
+		t.Fatalf("continuation must reopen the code block, got %q", fs.sent)
+	}
+	if fs.markup == nil {
+		t.Error("keyboard must stay under the last part")
+	}
+}
+
+func TestStreamPreview_PerRequestInSameChat(t *testing.T) {
+	b, fs := newStreamTestBot(fakeLLM{})
+	first, firstPreview := withStreamPreview(context.Background(), 4)
+	firstPreview.set(10)
+	second, secondPreview := withStreamPreview(context.Background(), 4)
+	secondPreview.set(20)
+
+	b.sendResponseMessage(second, tgbotapi.NewMessage(4, "второй ответ"))
+	b.sendResponseMessage(first, tgbotapi.NewMessage(4, "первый ответ"))
+	b.discardStreamPreview(firstPreview)
+	b.discardStreamPreview(secondPreview)
+
+	if len(fs.edits) != 2 || fs.edits[0] != "второй ответ" || fs.edits[1] != "первый ответ" || len(fs.sent) != 0 {
+		t.Fatalf("each answer must replace its own placeholder, edits=%q sent=%q", fs.edits, fs.sent)
+	}
+	if len(fs.deleted) != 0 {
+		t.Errorf("replaced placeholders must not be deleted, got %v", fs.deleted)
+	}
+}
+
+func TestStreamPreview_DiscardsOrphanedPlaceholder(t *testing.T) {
+	b, fs := newStreamTestBot(fakeLLM{})
+	_, preview := withStreamPreview(context.Background(), 4)
+	preview.set(30)
+
+	// Ответ ушёл мимо sendResponseMessage (например, вызовом инструмента): превью удаляется
+	b.discardStreamPreview(preview)
+	b.discardStreamPreview(preview)
+	if len(fs.deleted) != 1 || fs.deleted[0] != 30 {
+		t.Fatalf("orphaned preview must be deleted once, got %v", fs.deleted)
+	}
+}
+
+func TestPreviewText_PartialJSON(t *testing.T) {
+	cases := map[string]string{
+		`{"title":"T","answer":"Привет, \"мир\"\nдальше`: "Привет, \"мир\"\nдальше",
+		"```json\n{\"title\":\"T\",\"ans":                "",
+		"plain streamed text":                            "plain streamed text",
+		`{"answer":"café \u00`:                           "café",
+	}
+	for in, want := range cases {
+		if got := previewText(in); got != want {
+			t.Errorf("previewText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestHandleIncomingMessage_EditsAfterCharThreshold(t *testing.T) {
+	long := strings.Repeat("a", streamEditChars)
+	client := fakeStreamLLM{
+		chunks: []string{`{"title":"T","answer":"` + long, long, long + `"}`},
+		delay:  30 * time.Millisecond,
+	}
+	b, fs := newStreamTestBot(client)
+	// Интервал не успевает сработать: промежуточное обновление вызывает только прирост текста
+	b.streamInterval = time.Hour
+
+	b.handleIncomingMessage(context.Background(), &tgbotapi.Message{From: &tgbotapi.User{ID: 4}, Chat: &tgbotapi.Chat{ID: 4}, Text: "hi"})
+
+	if len(fs.edits) < 2 {
+		t.Fatalf("expected a char-triggered edit before the final replacement, got %d edits", len(fs.edits))
+	}
+	if !strings.HasSuffix(fs.edits[0], streamedit.Cursor) {
+		t.Fatalf("intermediate edit must be a preview: %q", fs.edits[0])
+	}
+}
+
+// floodSender отклоняет редактирования ошибкой 429 с retry_after
+type floodSender struct {
+	fakeSender
+	attempts int
+}
+
+func (f *floodSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	if _, ok := c.(tgbotapi.EditMessageTextConfig); ok {
+		f.attempts++
+		return tgbotapi.Message{}, &tgbotapi.Error{Code: 429, Message: "Too Many Requests", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 30}}
+	}
+	return f.fakeSender.Send(c)
+}
+
+func TestRunStreamEditor_BacksOffOnFloodLimit(t *testing.T) {
+	fs := &floodSender{}
+	b := &Bot{s: fs, streamInterval: 5 * time.Millisecond}
+	started, done, grown := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	close(started)
+
+	text := "первый"
+	var mu sync.Mutex
+	snapshot := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return text
+	}
+	result := make(chan streamPlaceholder, 1)
+	go func() { result <- b.runStreamEditor(4, started, done, grown, snapshot) }()
+	for i := 0; i < 5; i++ {
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		text += " ещё"
+		mu.Unlock()
+	}
+	close(done)
+	placeholder := <-result
+
+	if !placeholder.ok {
+		t.Fatal("placeholder must be kept despite failed edits")
+	}
+	if fs.attempts != 1 {
+		t.Fatalf("edits must pause for retry_after after a flood error, got %d attempts", fs.attempts)
+	}
+}
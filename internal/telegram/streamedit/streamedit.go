@@ -0,0 +1,123 @@
+// Package streamedit показывает потоковый ответ LLM в одном сообщении Telegram: превью отправляется
+// с первым фрагментом и редактируется по таймеру с учётом лимита Telegram на редактирования и retry_after
+package streamedit
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/llm/jsonextract"
+)
+
+const (
+	// MinEditInterval Telegram допускает примерно одно редактирование сообщения в секунду на чат
+	MinEditInterval = time.Second
+	// MaxPreviewRunes запас до лимита Telegram в 4096 символов
+	MaxPreviewRunes = 3800
+	// Cursor добавляется к превью, пока ответ генерируется
+	Cursor = " ▍"
+)
+
+// Sender отправка сообщений Telegram
+type Sender interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+}
+
+// Editor превью одного потокового ответа
+type Editor struct {
+	ChatID int64
+	// Prefix добавляется перед текстом превью
+	Prefix string
+	// Interval период обновления превью; 0 — MinEditInterval. Меньше MinEditInterval задавать не стоит
+	Interval time.Duration
+	// Started закрывается с первым фрагментом ответа, Done — когда генерация закончена
+	Started, Done <-chan struct{}
+	// Grown прирост текста: превью обновляется, не дожидаясь интервала, но не чаще MinEditInterval;
+	// nil — только по интервалу
+	Grown <-chan struct{}
+	// Snapshot текущий текст превью (обычно PreviewText от накопленного ответа)
+	Snapshot func() string
+}
+
+// Run создаёт превью при первом фрагменте и редактирует его до закрытия Done, пропуская
+// неизменившийся текст. Возвращает ID сообщения превью; ok=false, если превью не создавалось
+func (e Editor) Run(s Sender) (messageID int, ok bool) {
+	select {
+	case <-e.Started:
+	case <-e.Done:
+		return 0, false
+	}
+
+	last := e.Snapshot()
+	initial := last
+	if initial == "" {
+		initial = "⏳"
+	}
+	// Превью отправляется без parse mode: незавершённая разметка ломает разбор сущностей
+	sent, err := s.Send(tgbotapi.NewMessage(e.ChatID, e.Prefix+initial+Cursor))
+	if err != nil {
+		log.Printf("⚠️ Failed to send streaming preview: %v", err)
+		return 0, false
+	}
+
+	interval := e.Interval
+	if interval <= 0 {
+		interval = MinEditInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var pauseUntil, lastEdit time.Time
+	for {
+		var now time.Time
+		select {
+		case <-e.Done:
+			return sent.MessageID, true
+		case now = <-ticker.C:
+		case <-e.Grown:
+			now = time.Now()
+			// Прирост текста ускоряет обновление, но не чаще лимита Telegram
+			if now.Sub(lastEdit) < MinEditInterval {
+				continue
+			}
+		}
+		if now.Before(pauseUntil) {
+			continue
+		}
+		text := e.Snapshot()
+		if text == "" || text == last {
+			continue
+		}
+		if _, err := s.Send(tgbotapi.NewEditMessageText(e.ChatID, sent.MessageID, e.Prefix+text+Cursor)); err != nil {
+			log.Printf("⚠️ Failed to edit streaming preview: %v", err)
+			var tgErr *tgbotapi.Error
+			if errors.As(err, &tgErr) && tgErr.RetryAfter > 0 {
+				pauseUntil = now.Add(time.Duration(tgErr.RetryAfter) * time.Second)
+				log.Printf("⏳ Telegram rate limit while streaming, pausing edits for %ds", tgErr.RetryAfter)
+			}
+			continue
+		}
+		last = text
+		lastEdit = now
+	}
+}
+
+// PreviewText достаёт текст для превью из накопленного ответа. Если модель отвечает JSON, показывается
+// уже полученная часть строкового поля field; текст обрезается до MaxPreviewRunes
+func PreviewText(raw, field string) string {
+	text := strings.TrimSpace(raw)
+	text = strings.TrimPrefix(text, "```json")
+	text = strings.TrimSpace(strings.TrimPrefix(text, "```"))
+	if strings.HasPrefix(text, "{") {
+		text = jsonextract.PartialString(text, field)
+	}
+	if utf8.RuneCountInString(text) > MaxPreviewRunes {
+		text = string([]rune(text)[:MaxPreviewRunes]) + "…"
+	}
+	return strings.TrimSpace(text)
+}
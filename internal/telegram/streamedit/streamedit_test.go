@@ -0,0 +1,95 @@
+package streamedit
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// recordingSender запоминает отправленные сообщения и редактирования
+type recordingSender struct {
+	mu    sync.Mutex
+	sent  []string
+	edits []string
+}
+
+func (r *recordingSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch m := c.(type) {
+	case tgbotapi.MessageConfig:
+		r.sent = append(r.sent, m.Text)
+	case tgbotapi.EditMessageTextConfig:
+		r.edits = append(r.edits, m.Text)
+	}
+	return tgbotapi.Message{MessageID: 42}, nil
+}
+
+func TestPreviewText_PartialJSONField(t *testing.T) {
+	cases := map[string]string{
+		"```json\n{\"status\":\"success\",\"response\":\"Функция main запус": "Функция main запус",
+		`{"answer":"не то поле"}`:                                            "",
+		"plain streamed text":                                                "plain streamed text",
+	}
+	for in, want := range cases {
+		if got := PreviewText(in, "response"); got != want {
+			t.Errorf("PreviewText(%q) = %q, want %q", in, got, want)
+		}
+	}
+	long := PreviewText(strings.Repeat("я", MaxPreviewRunes+10), "response")
+	if utf8.RuneCountInString(long) != MaxPreviewRunes+1 || !strings.HasSuffix(long, "…") {
+		t.Fatalf("long preview must be truncated to MaxPreviewRunes, got %d runes", utf8.RuneCountInString(long))
+	}
+}
+
+func TestEditorRun_NoPreviewWithoutChunks(t *testing.T) {
+	s := &recordingSender{}
+	done := make(chan struct{})
+	close(done)
+	if id, ok := (Editor{ChatID: 1, Started: make(chan struct{}), Done: done, Snapshot: func() string { return "" }}).Run(s); ok || id != 0 {
+		t.Fatalf("no preview expected, got %d, %v", id, ok)
+	}
+	if len(s.sent) != 0 {
+		t.Fatalf("nothing must be sent, got %q", s.sent)
+	}
+}
+
+func TestEditorRun_EditsWithPrefix(t *testing.T) {
+	s := &recordingSender{}
+	started, done := make(chan struct{}), make(chan struct{})
+	close(started)
+	var mu sync.Mutex
+	text := "первый"
+	snapshot := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return text
+	}
+	result := make(chan int, 1)
+	go func() {
+		id, _ := Editor{ChatID: 1, Prefix: "[p] ", Interval: 5 * time.Millisecond, Started: started, Done: done, Snapshot: snapshot}.Run(s)
+		result <- id
+	}()
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	text = "первый второй"
+	mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	close(done)
+
+	if id := <-result; id != 42 {
+		t.Fatalf("preview message id expected, got %d", id)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.sent) != 1 || s.sent[0] != "[p] первый"+Cursor {
+		t.Fatalf("unexpected preview: %q", s.sent)
+	}
+	if len(s.edits) != 1 || s.edits[0] != "[p] первый второй"+Cursor {
+		t.Fatalf("only changed text must be edited, got %q", s.edits)
+	}
+}
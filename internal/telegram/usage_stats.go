@@ -0,0 +1,91 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/analytics"
+	"ai-chatter/internal/storage"
+)
+
+const (
+	// usageStatsDefaultDays период /stats по умолчанию
+	usageStatsDefaultDays = 7
+	// usageStatsMaxDays вместе с предыдущим периодом для тренда укладывается в storage.UsageRetentionDays
+	usageStatsMaxDays = 45
+)
+
+// SetUsageStore подключает счётчики использования функций для /stats и еженедельного дайджеста
+func (b *Bot) SetUsageStore(store storage.UsageStore) {
+	b.usage = store
+}
+
+// trackUsage учитывает вызов функции пользователем: только имя функции, без содержимого.
+// Счётчик в памяти, запись на диск — отдельной задачей планировщика
+func (b *Bot) trackUsage(userID int64, feature string) {
+	if b.usage == nil {
+		return
+	}
+	b.usage.AddUsage(time.Now(), userID, feature)
+}
+
+// usageReport использование функций за days полных дней до начала сегодняшнего (UTC)
+func (b *Bot) usageReport(days int, now time.Time) (*analytics.FeatureUsageReport, error) {
+	to := now.UTC().Truncate(24 * time.Hour)
+	from, prevFrom := analytics.UsagePeriodStart(to, days)
+	records, err := b.usage.LoadUsage(prevFrom, to)
+	if err != nil {
+		return nil, err
+	}
+	return analytics.BuildFeatureUsageReport(records, from, to), nil
+}
+
+// handleStatsCommand /stats [дней] — таблица использования функций для администратора
+func (b *Bot) handleStatsCommand(msg *tgbotapi.Message) {
+	if msg.From.ID != b.adminUserID {
+		b.sendMessage(msg.Chat.ID, "Команда доступна только администратору")
+		return
+	}
+	if b.usage == nil {
+		b.sendMessage(msg.Chat.ID, "Статистика использования не ведётся: USAGE_STATS_FILE_PATH не задан")
+		return
+	}
+	days := usageStatsDefaultDays
+	if arg := strings.TrimSpace(msg.CommandArguments()); arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > usageStatsMaxDays {
+			b.sendMessage(msg.Chat.ID, fmt.Sprintf("Использование: /stats [дней, 1-%d]", usageStatsMaxDays))
+			return
+		}
+		days = n
+	}
+
+	report, err := b.usageReport(days, time.Now())
+	if err != nil {
+		log.Printf("❌ stats: failed to load usage: %v", err)
+		b.sendMessage(msg.Chat.ID, "Не удалось загрузить статистику")
+		return
+	}
+	header := fmt.Sprintf("📊 Использование функций за %d дн. (тренд — к предыдущим %d дн.)\n\n", days, days)
+	b.sendMessage(msg.Chat.ID, header+report.FormatTable())
+}
+
+// SendWeeklyDigest присылает администратору еженедельный дайджест использования функций
+func (b *Bot) SendWeeklyDigest(ctx context.Context) error {
+	if b.usage == nil || b.adminUserID == 0 {
+		return nil
+	}
+	report, err := b.usageReport(7, time.Now())
+	if err != nil {
+		return fmt.Errorf("load usage: %w", err)
+	}
+	b.sendMessage(b.adminUserID, "🗓 Еженедельный дайджест\n\n"+report.FormatDigestSection())
+	log.Printf("🗓 Weekly digest sent to admin (%d features)", len(report.Features))
+	return nil
+}
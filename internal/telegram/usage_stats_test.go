@@ -0,0 +1,46 @@
+package telegram
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-chatter/internal/storage"
+)
+
+func TestStatsCommand_CountsCommandsAndRendersTable(t *testing.T) {
+	store, err := storage.NewFileUsageStore(filepath.Join(t.TempDir(), "usage.json"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	yesterday := time.Now().AddDate(0, 0, -1)
+	store.AddUsage(yesterday, 5, "/export")
+	store.AddUsage(yesterday, 6, "/export")
+	store.AddUsage(yesterday, 5, "mcp:search_pages")
+
+	fs := &fakeSender{}
+	b := &Bot{s: fs, adminUserID: 1}
+	b.SetUsageStore(store)
+
+	b.handleCommand(newAdminCmd("/stats"))
+	if len(fs.sent) != 1 {
+		t.Fatalf("expected one reply, got %q", fs.sent)
+	}
+	for _, want := range []string{"за 7 дн.", "/export", "mcp:search_pages", "новое"} {
+		if !strings.Contains(fs.sent[0], want) {
+			t.Errorf("stats reply must contain %q:\n%s", want, fs.sent[0])
+		}
+	}
+
+	// Сам вызов /stats засчитан сегодняшним днём, без текста сообщения
+	today, _ := store.LoadUsage(time.Now().UTC().Truncate(24*time.Hour), time.Now().AddDate(0, 0, 1))
+	if len(today) != 1 || today[0].Feature != "/stats" || today[0].UserID != 1 || today[0].Count != 1 {
+		t.Errorf("unexpected usage of today: %+v", today)
+	}
+
+	b.handleCommand(newAdminCmd("/stats 0"))
+	if !strings.Contains(fs.sent[len(fs.sent)-1], "Использование: /stats") {
+		t.Errorf("invalid period must show usage, got %q", fs.sent[len(fs.sent)-1])
+	}
+}
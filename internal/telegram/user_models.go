@@ -0,0 +1,210 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/llm"
+)
+
+// userModelOverride персональный выбор провайдера и модели пользователя
+type userModelOverride struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// SetUserModelOverrides включает персональные модели: загружает overrides из файла
+// и задаёт, доступна ли команда всем пользователям или только администратору
+func (b *Bot) SetUserModelOverrides(path string, allowAll bool) {
+	b.userModelMu.Lock()
+	defer b.userModelMu.Unlock()
+	b.userModelsPath = path
+	b.userModelsForAll = allowAll
+	b.userModels = make(map[int64]userModelOverride)
+	b.userClients = make(map[int64]llm.Client)
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("⚠️ Failed to read user model overrides: %v", err)
+		}
+		return
+	}
+	var stored map[string]userModelOverride
+	if err := json.Unmarshal(data, &stored); err != nil {
+		log.Printf("⚠️ Failed to parse user model overrides: %v", err)
+		return
+	}
+	for key, ov := range stored {
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			continue
+		}
+		b.userModels[id] = ov
+	}
+	log.Printf("✅ Loaded %d user model overrides", len(b.userModels))
+}
+
+func (b *Bot) saveUserModelsUnlocked() error {
+	if b.userModelsPath == "" {
+		return nil
+	}
+	stored := make(map[string]userModelOverride, len(b.userModels))
+	for id, ov := range b.userModels {
+		stored[strconv.FormatInt(id, 10)] = ov
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.userModelsPath), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(b.userModelsPath, data, 0o644)
+}
+
+func (b *Bot) getUserModelOverride(userID int64) (userModelOverride, bool) {
+	b.userModelMu.RLock()
+	defer b.userModelMu.RUnlock()
+	ov, ok := b.userModels[userID]
+	return ov, ok
+}
+
+// getUserLLMClient возвращает клиент пользователя с учётом персонального override,
+// при отсутствии override или ошибке создания — общий клиент бота
+func (b *Bot) getUserLLMClient(userID int64) llm.Client {
+	b.userModelMu.RLock()
+	ov, ok := b.userModels[userID]
+	cli := b.userClients[userID]
+	b.userModelMu.RUnlock()
+	if !ok {
+		return b.getLLMClient()
+	}
+	if cli != nil {
+		return cli
+	}
+	if b.llmFactory == nil {
+		return b.getLLMClient()
+	}
+	newCli, err := b.llmFactory.CreateClient(ov.Provider, ov.Model)
+	if err != nil {
+		log.Printf("⚠️ Failed to create client %s/%s for user %d: %v", ov.Provider, ov.Model, userID, err)
+		return b.getLLMClient()
+	}
+	b.userModelMu.Lock()
+	if existing := b.userClients[userID]; existing != nil {
+		newCli = existing
+	} else {
+		if b.userClients == nil {
+			b.userClients = make(map[int64]llm.Client)
+		}
+		b.userClients[userID] = newCli
+	}
+	b.userModelMu.Unlock()
+	return newCli
+}
+
+func (b *Bot) setUserModelOverride(userID int64, ov userModelOverride, cli llm.Client) error {
+	b.userModelMu.Lock()
+	defer b.userModelMu.Unlock()
+	if b.userModels == nil {
+		b.userModels = make(map[int64]userModelOverride)
+	}
+	if b.userClients == nil {
+		b.userClients = make(map[int64]llm.Client)
+	}
+	b.userModels[userID] = ov
+	b.userClients[userID] = cli
+	return b.saveUserModelsUnlocked()
+}
+
+func (b *Bot) clearUserModelOverride(userID int64) error {
+	b.userModelMu.Lock()
+	defer b.userModelMu.Unlock()
+	delete(b.userModels, userID)
+	delete(b.userClients, userID)
+	return b.saveUserModelsUnlocked()
+}
+
+func (b *Bot) canOverrideModel(userID int64) bool {
+	role := b.roleOf(userID)
+	if role == auth.RoleAdmin {
+		return true
+	}
+	b.userModelMu.RLock()
+	allowAll := b.userModelsForAll
+	b.userModelMu.RUnlock()
+	return allowAll && role.Allows(auth.RoleUser)
+}
+
+// handleUserModelCommand обрабатывает /model без аргументов (показ текущего выбора)
+// и /model <provider> <name> (персональный override); /model reset сбрасывает override
+func (b *Bot) handleUserModelCommand(msg *tgbotapi.Message) {
+	userID := msg.From.ID
+	if !b.canOverrideModel(userID) {
+		b.sendMessage(msg.Chat.ID, "Команда доступна только администратору")
+		return
+	}
+	args := strings.Fields(msg.CommandArguments())
+	switch {
+	case len(args) == 0:
+		b.sendModelPicker(msg.Chat.ID, userID)
+	case len(args) == 1 && strings.EqualFold(args[0], "reset"):
+		if err := b.clearUserModelOverride(userID); err != nil {
+			b.sendMessage(msg.Chat.ID, fmt.Sprintf("Ошибка сохранения: %v", err))
+			return
+		}
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("Персональная модель сброшена, используется общая: %s / %s", b.provider, b.model))
+	case len(args) == 2:
+		prov, model := strings.ToLower(args[0]), args[1]
+		if err := b.selectUserModel(userID, prov, model); err != nil {
+			b.sendMessage(msg.Chat.ID, err.Error())
+			return
+		}
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("Персональная модель установлена: %s / %s", prov, model))
+	default:
+		b.sendMessage(msg.Chat.ID, "Usage: /model <provider> <name>, /model reset, /model")
+	}
+}
+
+// selectUserModel проверяет выбор пользователя, создаёт клиент через фабрику и сохраняет override.
+// Ошибка содержит текст для пользователя
+func (b *Bot) selectUserModel(userID int64, prov, model string) error {
+	if prov != llm.ProviderOpenAI && prov != llm.ProviderYandex && prov != llm.ProviderAnthropic {
+		return fmt.Errorf("Поддерживаются: openai, yandex, anthropic")
+	}
+	b.userModelMu.RLock()
+	catalog := b.modelCatalog
+	b.userModelMu.RUnlock()
+	switch {
+	case catalog != nil:
+		if !catalog.Allows(prov, model, b.roleOf(userID) == auth.RoleAdmin) {
+			return fmt.Errorf("Модель %s / %s недоступна, выберите из списка: /model", prov, model)
+		}
+	case prov == llm.ProviderOpenAI && !llm.IsModelAllowed(model):
+		allowedModels := strings.Join(llm.GetAllowedModels(), ", ")
+		return fmt.Errorf("Неподдерживаемая модель. Доступные: %s", allowedModels)
+	}
+	if b.llmFactory == nil {
+		return fmt.Errorf("Фабрика LLM клиентов не настроена")
+	}
+	cli, err := b.llmFactory.CreateClient(prov, model)
+	if err != nil {
+		return fmt.Errorf("Ошибка создания клиента: %v", err)
+	}
+	if err := b.setUserModelOverride(userID, userModelOverride{Provider: prov, Model: model}, cli); err != nil {
+		return fmt.Errorf("Ошибка сохранения: %v", err)
+	}
+	log.Printf("🔀 User %d switched model to %s / %s", userID, prov, model)
+	return nil
+}
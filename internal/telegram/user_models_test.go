@@ -0,0 +1,146 @@
+package telegram
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/llm"
+)
+
+func newModelCmd(userID int64, text string) *tgbotapi.Message {
+	msg := &tgbotapi.Message{From: &tgbotapi.User{ID: userID}, Chat: &tgbotapi.Chat{ID: userID}, Text: text}
+	msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/model")}}
+	return msg
+}
+
+func TestUserModelOverride_PersistsAndReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user_models.json")
+	svc, _ := auth.NewWithRepo(nil, []int64{7})
+	global := fakeLLM{resp: llm.Response{Content: "global"}}
+	fs := &fakeSender{}
+	b := &Bot{s: fs, authSvc: svc, adminUserID: 1, provider: "openai", model: "base", llmClient: global, llmFactory: &llm.Factory{}}
+	b.SetUserModelOverrides(path, false)
+
+	// Non-admin without ALLOW_USER_MODEL_OVERRIDE is rejected
+	b.handleCommand(newModelCmd(7, "/model openai qwen/qwen3-coder"))
+	if _, ok := b.getUserModelOverride(7); ok {
+		t.Fatalf("override must not be set for non-admin")
+	}
+
+	b.handleCommand(newModelCmd(1, "/model openai qwen/qwen3-coder"))
+	ov, ok := b.getUserModelOverride(1)
+	if !ok || ov.Provider != "openai" || ov.Model != "qwen/qwen3-coder" {
+		t.Fatalf("unexpected override: %+v %v", ov, ok)
+	}
+	if _, isGlobal := b.getUserLLMClient(1).(fakeLLM); isGlobal {
+		t.Fatalf("admin should get personal client")
+	}
+	if _, isGlobal := b.getUserLLMClient(7).(fakeLLM); !isGlobal {
+		t.Fatalf("other users should keep global client")
+	}
+
+	b.handleCommand(newModelCmd(1, "/model"))
+	if last := fs.sent[len(fs.sent)-1]; !strings.Contains(last, "qwen/qwen3-coder") {
+		t.Fatalf("current selection not shown: %q", last)
+	}
+
+	// Reload from file
+	b2 := &Bot{s: &fakeSender{}, authSvc: svc, adminUserID: 1, llmClient: global, llmFactory: &llm.Factory{}}
+	b2.SetUserModelOverrides(path, true)
+	if ov, ok := b2.getUserModelOverride(1); !ok || ov.Model != "qwen/qwen3-coder" {
+		t.Fatalf("override not persisted: %+v %v", ov, ok)
+	}
+
+	// With allowAll, allowed users may set their own model
+	b2.handleCommand(newModelCmd(7, "/model openai z-ai/glm-4.5-air:free"))
+	if ov, ok := b2.getUserModelOverride(7); !ok || ov.Model != "z-ai/glm-4.5-air:free" {
+		t.Fatalf("allowed user override not set: %+v %v", ov, ok)
+	}
+	b2.handleCommand(newModelCmd(7, "/model reset"))
+	if _, ok := b2.getUserModelOverride(7); ok {
+		t.Fatalf("override must be cleared after reset")
+	}
+}
+
+// findButton возвращает callback data кнопки, подпись которой содержит label
+func findButton(t *testing.T, kb *tgbotapi.InlineKeyboardMarkup, label string) string {
+	t.Helper()
+	if kb == nil {
+		t.Fatalf("no inline keyboard, looking for %q", label)
+	}
+	for _, row := range kb.InlineKeyboard {
+		for _, btn := range row {
+			if strings.Contains(btn.Text, label) && btn.CallbackData != nil {
+				return *btn.CallbackData
+			}
+		}
+	}
+	t.Fatalf("button %q not found in %+v", label, kb.InlineKeyboard)
+	return ""
+}
+
+func TestUserModelPicker_SelectsFromCatalog(t *testing.T) {
+	catalog, err := llm.ParseModelCatalog("openai=qwen/qwen3-coder,!openai/gpt-5-nano;anthropic=!claude-3-5-sonnet-latest")
+	if err != nil {
+		t.Fatalf("catalog: %v", err)
+	}
+	svc, _ := auth.NewWithRepo(nil, []int64{7})
+	fs := &fakeSender{}
+	b := &Bot{s: fs, authSvc: svc, adminUserID: 1, provider: "openai", model: "base", llmClient: fakeLLM{}, llmFactory: &llm.Factory{AnthropicAPIKey: "test"}}
+	b.SetUserModelOverrides(filepath.Join(t.TempDir(), "user_models.json"), true)
+	b.SetModelCatalog(catalog)
+	ctx := context.Background()
+	press := func(userID int64, data string) {
+		b.handleCallback(ctx, &tgbotapi.CallbackQuery{ID: "cb", Data: data, From: &tgbotapi.User{ID: userID},
+			Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: userID}}})
+	}
+
+	// Обычный пользователь не видит провайдера, где все модели только для админа
+	b.handleCommand(newModelCmd(7, "/model"))
+	if !strings.Contains(fs.sent[len(fs.sent)-1], "openai / base (общая)") {
+		t.Fatalf("current model not shown: %q", fs.sent[len(fs.sent)-1])
+	}
+	for _, row := range fs.markup.InlineKeyboard {
+		for _, btn := range row {
+			if btn.Text == "anthropic" {
+				t.Fatalf("admin-only provider must be hidden")
+			}
+		}
+	}
+	press(7, findButton(t, fs.markup, "openai"))
+	for _, row := range fs.markup.InlineKeyboard {
+		for _, btn := range row {
+			if strings.Contains(btn.Text, "gpt-5-nano") {
+				t.Fatalf("admin-only model must be hidden: %+v", fs.markup.InlineKeyboard)
+			}
+		}
+	}
+	press(7, findButton(t, fs.markup, "qwen/qwen3-coder"))
+	if ov, ok := b.getUserModelOverride(7); !ok || ov.Provider != "openai" || ov.Model != "qwen/qwen3-coder" {
+		t.Fatalf("override not set from picker: %+v %v", ov, ok)
+	}
+
+	// Ручной ввод модели вне каталога тоже отклоняется
+	b.handleCommand(newModelCmd(7, "/model openai openai/gpt-5-nano"))
+	if ov, _ := b.getUserModelOverride(7); ov.Model != "qwen/qwen3-coder" {
+		t.Fatalf("admin-only model must be rejected for user: %+v", ov)
+	}
+
+	// Админ видит свои модели и может сбросить выбор
+	b.handleCommand(newModelCmd(1, "/model"))
+	press(1, findButton(t, fs.markup, "anthropic"))
+	press(1, findButton(t, fs.markup, "claude-3-5-sonnet-latest"))
+	if ov, ok := b.getUserModelOverride(1); !ok || ov.Provider != "anthropic" {
+		t.Fatalf("admin override not set: %+v %v", ov, ok)
+	}
+	b.handleCommand(newModelCmd(1, "/model"))
+	press(1, findButton(t, fs.markup, "Сбросить"))
+	if _, ok := b.getUserModelOverride(1); ok {
+		t.Fatalf("override must be cleared by reset button")
+	}
+}
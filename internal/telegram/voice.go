@@ -0,0 +1,153 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/llm"
+)
+
+const (
+	defaultVoiceMaxDuration     = 5 * time.Minute
+	defaultTranscriptionTimeout = time.Minute
+	// voiceTranscriptPreviewRunes длина расшифровки в начале ответа: ответ должен уместиться в лимит Telegram
+	voiceTranscriptPreviewRunes = 500
+)
+
+// SetVoiceTranscription включает распознавание голосовых и аудио сообщений. transcriber=nil — голосовые
+// отклоняются с подсказкой; maxDuration ограничивает длину записи, timeout — время распознавания
+func (b *Bot) SetVoiceTranscription(transcriber llm.Transcriber, maxDuration, timeout time.Duration) {
+	if maxDuration <= 0 {
+		maxDuration = defaultVoiceMaxDuration
+	}
+	if timeout <= 0 {
+		timeout = defaultTranscriptionTimeout
+	}
+	b.voiceMu.Lock()
+	defer b.voiceMu.Unlock()
+	b.transcriber = transcriber
+	b.voiceMaxDuration = maxDuration
+	b.transcriptionTimeout = timeout
+}
+
+// voiceSource файл и длительность голосового или аудио сообщения
+func voiceSource(msg *tgbotapi.Message) (fileID string, duration time.Duration, filename string, ok bool) {
+	switch {
+	case msg.Voice != nil:
+		return msg.Voice.FileID, time.Duration(msg.Voice.Duration) * time.Second, "voice.ogg", true
+	case msg.Audio != nil:
+		name := msg.Audio.FileName
+		if name == "" {
+			name = "audio.mp3"
+		}
+		return msg.Audio.FileID, time.Duration(msg.Audio.Duration) * time.Second, name, true
+	}
+	return "", 0, "", false
+}
+
+// transcribeVoice распознаёт голосовое сообщение. Ошибки сообщаются пользователю здесь же,
+// ok=false означает, что обработку сообщения нужно прекратить
+func (b *Bot) transcribeVoice(ctx context.Context, msg *tgbotapi.Message) (string, bool) {
+	fileID, duration, filename, _ := voiceSource(msg)
+
+	b.voiceMu.Lock()
+	transcriber, maxDuration, timeout := b.transcriber, b.voiceMaxDuration, b.transcriptionTimeout
+	b.voiceMu.Unlock()
+	if maxDuration <= 0 {
+		maxDuration = defaultVoiceMaxDuration
+	}
+	if timeout <= 0 {
+		timeout = defaultTranscriptionTimeout
+	}
+
+	if transcriber == nil {
+		b.sendMessage(msg.Chat.ID, "🎤 Распознавание голосовых сообщений не настроено. Напишите, пожалуйста, текстом.")
+		return "", false
+	}
+	if duration > maxDuration {
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("🎤 Запись слишком длинная (%s). Пришлите голосовое не длиннее %s или напишите текстом.", duration, maxDuration))
+		return "", false
+	}
+
+	file, err := b.s.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	if err != nil {
+		log.Printf("❌ Voice: failed to get file: %v", err)
+		b.sendMessage(msg.Chat.ID, "❌ Не удалось получить голосовое сообщение от Telegram")
+		return "", false
+	}
+	if file.FilePath != "" {
+		filename = path.Base(file.FilePath)
+	}
+	data, err := b.fetchFile(file)
+	if err != nil {
+		log.Printf("❌ Voice: %v", err)
+		b.sendMessage(msg.Chat.ID, "❌ Не удалось скачать голосовое сообщение")
+		return "", false
+	}
+
+	// Отдельный таймаут: недоступность Whisper не должна задерживать обработку остальных сообщений
+	tctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	started := time.Now()
+	text, err := transcriber.Transcribe(tctx, filename, bytes.NewReader(data))
+	if err != nil {
+		log.Printf("❌ Voice: transcription failed after %s: %v", time.Since(started).Round(time.Millisecond), err)
+		reason := "сервис распознавания недоступен"
+		if errors.Is(err, context.DeadlineExceeded) {
+			reason = "сервис распознавания не ответил вовремя"
+		}
+		b.sendMessage(msg.Chat.ID, fmt.Sprintf("❌ Не удалось распознать голосовое: %s. Попробуйте позже или напишите текстом.", reason))
+		return "", false
+	}
+	if text == "" {
+		b.sendMessage(msg.Chat.ID, "🎤 В записи не удалось разобрать речь. Попробуйте ещё раз или напишите текстом.")
+		return "", false
+	}
+	log.Printf("🎤 Voice from %d transcribed in %s (%d chars)", msg.From.ID, time.Since(started).Round(time.Millisecond), len(text))
+	return text, true
+}
+
+// fetchFile скачивает файл Telegram; в тестах подменяется через fileDownloader
+func (b *Bot) fetchFile(file tgbotapi.File) ([]byte, error) {
+	if b.fileDownloader != nil {
+		return b.fileDownloader(file)
+	}
+	return b.downloadFileBytes(file)
+}
+
+func (b *Bot) setVoiceTranscript(chatID int64, text string) {
+	b.voiceMu.Lock()
+	defer b.voiceMu.Unlock()
+	if b.voiceTranscripts == nil {
+		b.voiceTranscripts = make(map[int64]string)
+	}
+	b.voiceTranscripts[chatID] = text
+}
+
+func (b *Bot) takeVoiceTranscript(chatID int64) (string, bool) {
+	b.voiceMu.Lock()
+	defer b.voiceMu.Unlock()
+	text, ok := b.voiceTranscripts[chatID]
+	delete(b.voiceTranscripts, chatID)
+	return text, ok
+}
+
+// withVoiceTranscript добавляет расшифровку голосового в начало ответа, чтобы было видно, что понял бот
+func (b *Bot) withVoiceTranscript(chatID int64, text string) string {
+	transcript, ok := b.takeVoiceTranscript(chatID)
+	if !ok {
+		return text
+	}
+	if utf8.RuneCountInString(transcript) > voiceTranscriptPreviewRunes {
+		transcript = string([]rune(transcript)[:voiceTranscriptPreviewRunes]) + "…"
+	}
+	return b.escapeIfNeeded("🎤 «"+transcript+"»") + "\n\n" + text
+}
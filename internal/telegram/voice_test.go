@@ -0,0 +1,99 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/auth"
+	"ai-chatter/internal/history"
+	"ai-chatter/internal/llm"
+)
+
+type fakeTranscriber struct {
+	text     string
+	err      error
+	calls    int
+	filename string
+}
+
+func (f *fakeTranscriber) Transcribe(_ context.Context, filename string, audio io.Reader) (string, error) {
+	f.calls++
+	f.filename = filename
+	_, _ = io.ReadAll(audio)
+	return f.text, f.err
+}
+
+func newVoiceTestBot(t *fakeTranscriber) (*Bot, *fakeSender, *fakeLLMSeq) {
+	svc, _ := auth.NewWithRepo(nil, []int64{7})
+	fs := &fakeSender{}
+	seq := &fakeLLMSeq{seq: []llm.Response{{Content: `{"title":"T","answer":"ответ"}`, Model: "m"}}}
+	b := &Bot{s: fs, authSvc: svc, llmClient: seq, pending: make(map[int64]auth.User), parseMode: "HTML", history: history.NewManager()}
+	b.fileDownloader = func(tgbotapi.File) ([]byte, error) { return []byte("OggS"), nil }
+	if t != nil {
+		b.SetVoiceTranscription(t, 0, 0)
+	}
+	return b, fs, seq
+}
+
+func voiceMessage(duration int) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		From:  &tgbotapi.User{ID: 7},
+		Chat:  &tgbotapi.Chat{ID: 7},
+		Voice: &tgbotapi.Voice{FileID: "voice-1", Duration: duration},
+	}
+}
+
+func TestHandleIncomingMessage_VoiceIsTranscribed(t *testing.T) {
+	tr := &fakeTranscriber{text: "какая погода <завтра>"}
+	b, fs, seq := newVoiceTestBot(tr)
+
+	b.handleIncomingMessage(context.Background(), voiceMessage(10))
+
+	if tr.calls != 1 || tr.filename != "voice.ogg" {
+		t.Fatalf("expected one transcription of voice.ogg, got %d calls (%q)", tr.calls, tr.filename)
+	}
+	if len(seq.lastMsgs) != 1 {
+		t.Fatalf("transcript must go through the normal pipeline, got %d LLM calls", len(seq.lastMsgs))
+	}
+	msgs := seq.lastMsgs[0]
+	if last := msgs[len(msgs)-1]; last.Role != "user" || last.Content != "какая погода <завтра>" {
+		t.Fatalf("LLM must receive the transcript as the user message, got %+v", last)
+	}
+	if len(fs.sent) != 1 || !strings.HasPrefix(fs.sent[0], "🎤 «какая погода &lt;завтра&gt;»\n\n") || !strings.Contains(fs.sent[0], "ответ") {
+		t.Fatalf("reply must start with the escaped transcript, got %q", fs.sent)
+	}
+	if _, ok := b.takeVoiceTranscript(7); ok {
+		t.Fatal("transcript must not leak into later replies")
+	}
+}
+
+func TestHandleIncomingMessage_VoiceRejected(t *testing.T) {
+	cases := map[string]struct {
+		transcriber *fakeTranscriber
+		duration    int
+		want        string
+	}{
+		"too long":       {&fakeTranscriber{text: "x"}, int((6 * time.Minute).Seconds()), "слишком длинная"},
+		"backend down":   {&fakeTranscriber{err: errors.New("503")}, 5, "Не удалось распознать"},
+		"backend slow":   {&fakeTranscriber{err: context.DeadlineExceeded}, 5, "не ответил вовремя"},
+		"not configured": {nil, 5, "не настроено"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			b, fs, seq := newVoiceTestBot(c.transcriber)
+			b.handleIncomingMessage(context.Background(), voiceMessage(c.duration))
+			if len(seq.lastMsgs) != 0 {
+				t.Fatal("rejected voice must not reach the LLM")
+			}
+			if len(fs.sent) != 1 || !strings.Contains(fs.sent[0], c.want) {
+				t.Fatalf("expected a friendly %q message, got %q", c.want, fs.sent)
+			}
+		})
+	}
+}
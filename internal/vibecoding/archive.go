@@ -1,8 +1,10 @@
 package vibecoding
 
 import (
+	"archive/tar"
 	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io"
 	"log"
@@ -17,7 +19,16 @@ const (
 	MaxFiles     = 1000             // Максимальное количество файлов
 )
 
-// ExtractFilesFromArchive извлекает файлы из ZIP архива
+// archiveEntry файл архива: имя, размер после распаковки и чтение содержимого
+type archiveEntry struct {
+	name  string
+	isDir bool
+	size  int64
+	open  func() (io.ReadCloser, error)
+}
+
+// ExtractFilesFromArchive извлекает файлы из ZIP или tar.gz архива. Формат определяется
+// по сигнатуре в начале данных, а не по расширению имени
 func ExtractFilesFromArchive(archiveData []byte, archiveName string) (map[string]string, string, error) {
 	log.Printf("🔥 Extracting files from archive: %s (%d bytes)", archiveName, len(archiveData))
 
@@ -25,50 +36,72 @@ func ExtractFilesFromArchive(archiveData []byte, archiveName string) (map[string
 		return nil, "", fmt.Errorf("архив слишком большой: %d bytes (максимум %d)", len(archiveData), MaxTotalSize)
 	}
 
-	reader := bytes.NewReader(archiveData)
-	zipReader, err := zip.NewReader(reader, int64(len(archiveData)))
+	var entries []archiveEntry
+	var err error
+	switch {
+	case bytes.HasPrefix(archiveData, zipMagic):
+		entries, err = zipEntries(archiveData)
+	case bytes.HasPrefix(archiveData, gzipMagic):
+		entries, err = tarGzEntries(archiveData)
+	case isTarData(archiveData):
+		entries, err = tarEntries(bytes.NewReader(archiveData))
+	default:
+		return nil, "", fmt.Errorf("неподдерживаемый формат архива: ожидается ZIP или tar.gz")
+	}
 	if err != nil {
-		return nil, "", fmt.Errorf("не удалось открыть ZIP архив: %w", err)
+		return nil, "", err
 	}
 
-	files := make(map[string]string)
-	var projectName string
-
 	// Определяем название проекта из имени архива
-	projectName = strings.TrimSuffix(archiveName, filepath.Ext(archiveName))
-	if projectName == "" {
-		projectName = "vibecoding-project"
-	}
+	projectName := archiveProjectName(archiveName)
 
-	if len(zipReader.File) > MaxFiles {
-		return nil, "", fmt.Errorf("слишком много файлов в архиве: %d (максимум %d)", len(zipReader.File), MaxFiles)
+	files, err := filesFromEntries(entries)
+	if err != nil {
+		return nil, "", err
 	}
 
-	totalSize := 0
-	for _, file := range zipReader.File {
-		// Пропускаем директории и служебные файлы
-		if file.FileInfo().IsDir() {
+	log.Printf("🔥 Successfully extracted %d files from %s", len(files), archiveName)
+	return files, projectName, nil
+}
+
+// filesFromEntries отбирает файлы проекта: без служебных и бинарных файлов, в пределах
+// MaxFileSize и MaxTotalSize. Общий путь для архивов и клонированных репозиториев
+func filesFromEntries(entries []archiveEntry) (map[string]string, error) {
+	// Пропускаем директории и служебные файлы
+	var candidates []archiveEntry
+	for _, file := range entries {
+		if file.isDir {
 			continue
 		}
-
-		filename := file.Name
-		if shouldSkipFile(filename) {
-			log.Printf("🔥 Skipping file: %s", filename)
+		if shouldSkipFile(file.name) {
+			log.Printf("🔥 Skipping file: %s", file.name)
 			continue
 		}
+		candidates = append(candidates, file)
+	}
+	names := make([]string, 0, len(candidates))
+	for _, file := range candidates {
+		names = append(names, file.name)
+	}
+	root := commonRootDir(names)
+
+	files := make(map[string]string)
+	totalSize := 0
+	for _, file := range candidates {
+		filename := file.name
 
-		if file.UncompressedSize64 > MaxFileSize {
-			log.Printf("⚠️ File %s is too large (%d bytes), skipping", filename, file.UncompressedSize64)
+		if file.size > MaxFileSize {
+			log.Printf("⚠️ File %s is too large (%d bytes), skipping", filename, file.size)
 			continue
 		}
 
-		totalSize += int(file.UncompressedSize64)
+		totalSize += int(file.size)
 		if totalSize > MaxTotalSize {
 			log.Printf("⚠️ Total archive size exceeded limit, stopping extraction")
 			break
 		}
 
-		rc, err := file.Open()
+		rc, err := file.open()
 		if err != nil {
 			log.Printf("⚠️ Failed to open file %s: %v", filename, err)
 			continue
@@ -82,19 +115,109 @@ func ExtractFilesFromArchive(archiveData []byte, archiveName string) (map[string
 			continue
 		}
 
-		// Нормализуем путь файла (убираем префиксы директорий если есть)
-		normalizedName := normalizeFilename(filename)
+		// Нормализуем путь файла (убираем общую корневую папку, если она есть)
+		normalizedName := normalizeFilename(filename, root)
 		files[normalizedName] = string(content)
 
 		log.Printf("🔥 Extracted file: %s (%d bytes)", normalizedName, len(content))
 	}
 
 	if len(files) == 0 {
-		return nil, "", fmt.Errorf("архив не содержит подходящих файлов для анализа")
+		return nil, fmt.Errorf("архив не содержит подходящих файлов для анализа")
 	}
+	return files, nil
+}
 
-	log.Printf("🔥 Successfully extracted %d files from %s", len(files), archiveName)
-	return files, projectName, nil
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// archiveProjectName название проекта из имени архива без расширения (.zip, .tar.gz, .tgz, .tar)
+func archiveProjectName(archiveName string) string {
+	name := archiveName
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(lower, ext) {
+			name = name[:len(name)-len(ext)]
+			break
+		}
+	}
+	if name == archiveName {
+		name = strings.TrimSuffix(archiveName, filepath.Ext(archiveName))
+	}
+	if name == "" {
+		name = "vibecoding-project"
+	}
+	return name
+}
+
+func zipEntries(archiveData []byte) ([]archiveEntry, error) {
+	zipReader, err := zip.NewReader(bytes.NewReader(archiveData), int64(len(archiveData)))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть ZIP архив: %w", err)
+	}
+	if len(zipReader.File) > MaxFiles {
+		return nil, fmt.Errorf("слишком много файлов в архиве: %d (максимум %d)", len(zipReader.File), MaxFiles)
+	}
+	entries := make([]archiveEntry, 0, len(zipReader.File))
+	for _, file := range zipReader.File {
+		entries = append(entries, archiveEntry{
+			name:  file.Name,
+			isDir: file.FileInfo().IsDir(),
+			size:  int64(file.UncompressedSize64),
+			open:  file.Open,
+		})
+	}
+	return entries, nil
+}
+
+func tarGzEntries(archiveData []byte) ([]archiveEntry, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archiveData))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть gzip архив: %w", err)
+	}
+	defer gz.Close()
+	return tarEntries(gz)
+}
+
+// tarEntries читает tar поток целиком: содержимое файлов не больше MaxFileSize и MaxTotalSize в сумме
+// (tar нельзя читать выборочно, поэтому лимиты проверяются уже при чтении)
+func tarEntries(r io.Reader) ([]archiveEntry, error) {
+	tr := tar.NewReader(r)
+	var entries []archiveEntry
+	total := int64(0)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать tar архив: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeDir {
+			continue
+		}
+		if len(entries) >= MaxFiles {
+			return nil, fmt.Errorf("слишком много файлов в архиве: больше %d", MaxFiles)
+		}
+		entry := archiveEntry{name: hdr.Name, isDir: hdr.Typeflag == tar.TypeDir, size: hdr.Size}
+		var content []byte
+		if !entry.isDir && hdr.Size <= MaxFileSize && total+hdr.Size <= MaxTotalSize && !shouldSkipFile(hdr.Name) {
+			content, err = io.ReadAll(io.LimitReader(tr, hdr.Size))
+			if err != nil {
+				return nil, fmt.Errorf("не удалось прочитать %s из tar архива: %w", hdr.Name, err)
+			}
+			total += hdr.Size
+		}
+		entry.open = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(content)), nil }
+		entries = append(entries, entry)
+	}
+}
+
+// isTarData tar без сжатия: сигнатура "ustar" по смещению 257 заголовка
+func isTarData(data []byte) bool {
+	return len(data) >= 262 && string(data[257:262]) == "ustar"
 }
 
 // shouldSkipFile определяет, нужно ли пропустить файл
@@ -137,28 +260,55 @@ func shouldSkipFile(filename string) bool {
 	return false
 }
 
-// normalizeFilename нормализует имя файла, убирая лишние префиксы
-func normalizeFilename(filename string) string {
-	// Убираем ведущие слеши и точки
+// normalizeFilename нормализует имя файла: убирает ведущие "/" и "./" и корневую папку root,
+// общую для всех файлов архива (см. commonRootDir)
+func normalizeFilename(filename, root string) string {
 	filename = strings.TrimPrefix(filename, "/")
 	filename = strings.TrimPrefix(filename, "./")
-
-	// Если файл находится в единой корневой директории проекта,
-	// убираем этот префикс
-	parts := strings.Split(filename, "/")
-	if len(parts) > 1 {
-		// Если все файлы начинаются с одной директории, это корень проекта
-		return strings.Join(parts[1:], "/")
+	if root != "" {
+		filename = strings.TrimPrefix(filename, root+"/")
 	}
-
 	return filename
 }
 
+// commonRootDir папка, в которой лежат все файлы архива (обёртка вроде project/), или пусто,
+// если файлы лежат в корне или в разных папках — тогда пути сохраняются как есть
+func commonRootDir(names []string) string {
+	root := ""
+	for i, name := range names {
+		name = strings.TrimPrefix(strings.TrimPrefix(name, "/"), "./")
+		dir, _, nested := strings.Cut(name, "/")
+		if !nested || (i > 0 && dir != root) {
+			return ""
+		}
+		root = dir
+	}
+	return root
+}
+
 // CreateResultArchive создает архив с результатами сессии
 func CreateResultArchive(session *VibeCodingSession) ([]byte, error) {
+	return CreateResultArchiveWithReport(session, nil)
+}
+
+// CreateResultArchiveWithReport создает архив с результатами сессии; при report != nil
+// в архив добавляется SESSION_REPORT.md с итогами проверки качества
+func CreateResultArchiveWithReport(session *VibeCodingSession, report *QualityGateReport) ([]byte, error) {
+	return CreateResultArchiveWithOptions(session, ResultArchiveOptions{Report: report})
+}
+
+// ResultArchiveOptions дополнительное содержимое архива результатов
+type ResultArchiveOptions struct {
+	Report     *QualityGateReport // SESSION_REPORT.md с итогами проверки качества
+	GitHistory []byte             // tar.gz каталога .git (ExportGitDir), по умолчанию не добавляется
+}
+
+// CreateResultArchiveWithOptions создает архив с результатами сессии и дополнительным содержимым из opts
+func CreateResultArchiveWithOptions(session *VibeCodingSession, opts ResultArchiveOptions) ([]byte, error) {
+	report := opts.Report
 	log.Printf("🔥 Creating result archive for session: %s", session.ProjectName)
 
-	allFiles := session.GetAllFiles()
+	allFiles := session.ResultFiles()
 	if len(allFiles) == 0 {
 		return nil, fmt.Errorf("нет файлов для архивирования")
 	}
@@ -211,12 +361,49 @@ Session ended: %s
 		session.TestCommand,
 		time.Now().Format("2006-01-02 15:04:05"))
 
+	if session.Origin != nil {
+		sessionInfo += fmt.Sprintf("\n## Origin\n\nRepository: %s\nCommit: %s\n", session.Origin.URL, session.Origin.Commit)
+		if session.Origin.Branch != "" {
+			sessionInfo += "Branch: " + session.Origin.Branch + "\n"
+		}
+	}
+
+	if conflicts := session.PreventedConflicts(); len(conflicts) > 0 {
+		sessionInfo += "\n## Prevented conflicts\n\nGenerated code tried to overwrite these original files; originals were kept\n" +
+			"and generated versions (if any) are saved as <name>.generated.<ext>:\n\n"
+		for _, filename := range conflicts {
+			sessionInfo += "- " + filename + "\n"
+		}
+	}
+
+	if hidden := session.hiddenSecretFindings(); len(hidden) > 0 {
+		sessionInfo += "\n## Excluded files with secrets\n\nThese uploaded files look like they contain secrets and were left out of the archive\n" +
+			"(allow a file with /vibecoding_allow_secret to include it):\n\n"
+		for _, f := range hidden {
+			sessionInfo += fmt.Sprintf("- %s:%d (%s)\n", f.File, f.Line, f.Kind)
+		}
+	}
+
 	// Создаем файл с информацией о сессии
 	infoWriter, err := zipWriter.Create("VIBECODING_SESSION.md")
 	if err == nil {
 		infoWriter.Write([]byte(sessionInfo))
 	}
 
+	if report != nil {
+		if reportWriter, err := zipWriter.Create("SESSION_REPORT.md"); err == nil {
+			reportWriter.Write([]byte(FormatSessionReport(session, report)))
+		}
+	}
+
+	if len(opts.GitHistory) > 0 {
+		added, err := addGitHistory(zipWriter, opts.GitHistory)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("🔥 Added .git to archive: %d files", added)
+	}
+
 	err = zipWriter.Close()
 	if err != nil {
 		return nil, fmt.Errorf("failed to close zip writer: %w", err)
@@ -0,0 +1,152 @@
+package vibecoding
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"testing"
+)
+
+var archiveTestFiles = []struct{ name, content string }{
+	{"proj/main.go", "package main\n\nfunc main() {}\n"},
+	{"proj/go.mod", "module proj\n\ngo 1.23\n"},
+	{"proj/internal/util.go", "package internal\n"},
+	{"proj/.DS_Store", "junk"},
+}
+
+func buildTestZip(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if _, err := zw.Create("proj/"); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range archiveTestFiles {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(f.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func buildTestTar(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "proj/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "proj/link.go", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range archiveTestFiles {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(f.content))}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(f.content))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func buildTestTarGz(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(buildTestTar(t))
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractFilesFromArchive_ZipAndTarGzMatch(t *testing.T) {
+	want := map[string]string{
+		"main.go":          "package main\n\nfunc main() {}\n",
+		"go.mod":           "module proj\n\ngo 1.23\n",
+		"internal/util.go": "package internal\n",
+	}
+
+	cases := []struct {
+		name, archiveName string
+		data              []byte
+	}{
+		{"zip", "proj.zip", buildTestZip(t)},
+		{"tar.gz", "proj.tar.gz", buildTestTarGz(t)},
+		{"tgz", "proj.tgz", buildTestTarGz(t)},
+		{"tar", "proj.tar", buildTestTar(t)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			files, projectName, err := ExtractFilesFromArchive(tc.data, tc.archiveName)
+			if err != nil {
+				t.Fatalf("extract: %v", err)
+			}
+			if projectName != "proj" {
+				t.Errorf("projectName = %q", projectName)
+			}
+			if !reflect.DeepEqual(files, want) {
+				t.Errorf("files = %v, want %v", files, want)
+			}
+		})
+	}
+}
+
+func TestExtractFilesFromArchive_DetectsFormatByHeader(t *testing.T) {
+	// Имя говорит zip, но данные — tar.gz: формат определяется по сигнатуре
+	files, projectName, err := ExtractFilesFromArchive(buildTestTarGz(t), "artifact.zip")
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if projectName != "artifact" || files["main.go"] == "" {
+		t.Errorf("got %q %v", projectName, files)
+	}
+
+	if _, _, err := ExtractFilesFromArchive([]byte("not an archive at all"), "proj.zip"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if _, _, err := ExtractFilesFromArchive(append([]byte{0x1f, 0x8b}, []byte("broken")...), "proj.tar.gz"); err == nil {
+		t.Error("expected error for broken gzip")
+	}
+}
+
+func TestExtractFilesFromArchive_KeepsTopLevelDirsWithoutWrapper(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"frontend/package.json": `{"name": "web"}`,
+		"frontend/src/app.js":   "console.log(1)",
+		"backend/go.mod":        "module api\n",
+		"backend/main.go":       "package main\n",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _, err := ExtractFilesFromArchive(buf.Bytes(), "mono.zip")
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if files["frontend/package.json"] == "" || files["backend/go.mod"] == "" || files["go.mod"] != "" {
+		t.Fatalf("top-level directories must be kept without a wrapper directory, got %v", files)
+	}
+	subprojects := DetectSubprojects(files)
+	if len(subprojects) != 2 || subprojects[0].Path != "backend" || subprojects[1].Path != "frontend" {
+		t.Errorf("expected backend and frontend subprojects, got %+v", subprojects)
+	}
+}
@@ -0,0 +1,145 @@
+package vibecoding
+
+import (
+	"context"
+	"fmt"
+
+	"ai-chatter/internal/codevalidation"
+	"ai-chatter/internal/llm"
+)
+
+// maxToolResultChars сколько символов результата инструмента возвращать модели
+const maxToolResultChars = 8000
+
+// vibeCodingTools описания vibe_* инструментов для function calling в автономной работе.
+// user_id в схемах нет — его подставляет клиент, модель не может обратиться к чужой сессии
+func vibeCodingTools() []llm.Tool {
+	tool := func(name, description string, properties map[string]interface{}, required ...string) llm.Tool {
+		params := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			params["required"] = required
+		}
+		return llm.Tool{Type: "function", Function: llm.Function{Name: name, Description: description, Parameters: params}}
+	}
+	str := func(description string) map[string]interface{} {
+		return map[string]interface{}{"type": "string", "description": description}
+	}
+	boolean := func(description string) map[string]interface{} {
+		return map[string]interface{}{"type": "boolean", "description": description}
+	}
+
+	return []llm.Tool{
+		tool("vibe_list_files", "List all files in the session", map[string]interface{}{}),
+		tool("vibe_read_file", "Read file content", map[string]interface{}{
+			"filename": str("Path of the file relative to the project root"),
+		}, "filename"),
+		tool("vibe_write_file", "Create or update a file", map[string]interface{}{
+			"filename":           str("Path of the file relative to the project root"),
+			"content":            str("Full file content"),
+			"generated":          boolean("Mark the file as generated (default true)"),
+			"overwrite_original": boolean("Allow a generated file to replace an original project file"),
+		}, "filename", "content"),
+		tool("vibe_rename_file", "Rename or move a file, keeping its original/generated status. Fails if new_path exists", map[string]interface{}{
+			"old_path": str("Current path of the file"),
+			"new_path": str("New path of the file"),
+		}, "old_path", "new_path"),
+		tool("vibe_execute_command", "Execute a shell command in the project container. The result shows stdout and stderr separately", map[string]interface{}{
+			"command": str("Shell command"),
+			"workdir": str("Directory relative to the project root to run the command in (default: project root)"),
+			"env": map[string]interface{}{
+				"type":                 "object",
+				"description":          "Extra environment variables, e.g. {\"GOFLAGS\": \"-mod=mod\"}",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+		}, "command"),
+		tool("vibe_validate_code", "Validate code syntax of a file or the whole project", map[string]interface{}{
+			"filename": str("File to validate; empty validates the whole project"),
+		}),
+		tool("vibe_run_tests", "Run project tests", map[string]interface{}{
+			"test_file": str("Test file to run; empty runs all tests"),
+		}),
+		tool("vibe_get_session_info", "Get session information", map[string]interface{}{}),
+	}
+}
+
+// VibeToolBackend исполнитель vibe_* инструментов: MCP клиент или записанные результаты при воспроизведении
+type VibeToolBackend interface {
+	ListFiles(ctx context.Context, userID int64) VibeCodingMCPResult
+	ReadFile(ctx context.Context, userID int64, filename string) VibeCodingMCPResult
+	WriteFile(ctx context.Context, userID int64, filename, content string, generated, overwriteOriginal bool) VibeCodingMCPResult
+	RenameFile(ctx context.Context, userID int64, oldPath, newPath string) VibeCodingMCPResult
+	ExecuteCommandWithOptions(ctx context.Context, userID int64, command string, opts codevalidation.ExecOptions) VibeCodingMCPResult
+	ValidateCode(ctx context.Context, userID int64, filename string) VibeCodingMCPResult
+	RunTests(ctx context.Context, userID int64, testFile string) VibeCodingMCPResult
+	GetSessionInfo(ctx context.Context, userID int64) VibeCodingMCPResult
+}
+
+// executeVibeTool выполняет вызов vibe_* инструмента от модели в сессии userID
+func executeVibeTool(ctx context.Context, tools VibeToolBackend, userID int64, call llm.FunctionCall) (VibeCodingMCPResult, error) {
+	str := func(key string) string {
+		s, _ := call.Arguments[key].(string)
+		return s
+	}
+
+	switch call.Name {
+	case "vibe_list_files":
+		return tools.ListFiles(ctx, userID), nil
+	case "vibe_read_file":
+		return tools.ReadFile(ctx, userID, str("filename")), nil
+	case "vibe_write_file":
+		generated := true
+		if g, ok := call.Arguments["generated"].(bool); ok {
+			generated = g
+		}
+		overwriteOriginal, _ := call.Arguments["overwrite_original"].(bool)
+		return tools.WriteFile(ctx, userID, str("filename"), str("content"), generated, overwriteOriginal), nil
+	case "vibe_rename_file":
+		return tools.RenameFile(ctx, userID, str("old_path"), str("new_path")), nil
+	case "vibe_execute_command":
+		opts := codevalidation.ExecOptions{WorkDir: str("workdir"), Env: EnvArgument(call.Arguments["env"])}
+		return tools.ExecuteCommandWithOptions(ctx, userID, str("command"), opts), nil
+	case "vibe_validate_code":
+		return tools.ValidateCode(ctx, userID, str("filename")), nil
+	case "vibe_run_tests":
+		return tools.RunTests(ctx, userID, str("test_file")), nil
+	case "vibe_get_session_info":
+		return tools.GetSessionInfo(ctx, userID), nil
+	}
+	return VibeCodingMCPResult{}, fmt.Errorf("unknown MCP tool: %s", call.Name)
+}
+
+// EnvArgument переменные окружения из аргумента инструмента: объект имя → значение, значения не-строки
+// приводятся к строке
+func EnvArgument(arg interface{}) map[string]string {
+	raw, ok := arg.(map[string]interface{})
+	if !ok || len(raw) == 0 {
+		return nil
+	}
+	env := make(map[string]string, len(raw))
+	for name, value := range raw {
+		if s, ok := value.(string); ok {
+			env[name] = s
+		} else {
+			env[name] = fmt.Sprint(value)
+		}
+	}
+	return env
+}
+
+// vibeToolResultContent текст результата инструмента для tool сообщения модели
+func vibeToolResultContent(result VibeCodingMCPResult, err error) string {
+	if err != nil {
+		return "ERROR: " + err.Error()
+	}
+	content := result.Message
+	if content == "" {
+		content = result.Data
+	}
+	if !result.Success {
+		content = "FAILED: " + content
+	}
+	if runes := []rune(content); len(runes) > maxToolResultChars {
+		content = string(runes[:maxToolResultChars]) + fmt.Sprintf("\n... (truncated, %d chars total)", len(runes))
+	}
+	return content
+}
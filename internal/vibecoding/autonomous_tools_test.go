@@ -0,0 +1,99 @@
+package vibecoding
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ai-chatter/internal/llm"
+)
+
+// scriptedToolLLM отвечает заранее заданными ответами и запоминает запросы
+type scriptedToolLLM struct {
+	llm.NoStreaming
+	llm.LocalTokenCounter
+	replies  []llm.Response
+	requests [][]llm.Message
+	tools    []llm.Tool
+}
+
+func (s *scriptedToolLLM) Generate(ctx context.Context, messages []llm.Message) (llm.Response, error) {
+	return s.GenerateWithTools(ctx, messages, nil)
+}
+
+func (s *scriptedToolLLM) GenerateWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (llm.Response, error) {
+	s.requests = append(s.requests, append([]llm.Message(nil), messages...))
+	s.tools = tools
+	resp := s.replies[0]
+	s.replies = s.replies[1:]
+	return resp, nil
+}
+
+func TestVibeCodingTools_Schemas(t *testing.T) {
+	tools := vibeCodingTools()
+	names := make(map[string]bool)
+	for _, tool := range tools {
+		names[tool.Function.Name] = true
+		props, ok := tool.Function.Parameters["properties"].(map[string]interface{})
+		if tool.Type != "function" || !ok || tool.Function.Parameters["type"] != "object" {
+			t.Errorf("%s: invalid schema %+v", tool.Function.Name, tool.Function.Parameters)
+		}
+		if _, ok := props["user_id"]; ok {
+			t.Errorf("%s: user_id must be filled by the client, not the model", tool.Function.Name)
+		}
+	}
+	for _, name := range []string{"vibe_list_files", "vibe_read_file", "vibe_write_file", "vibe_rename_file", "vibe_execute_command", "vibe_validate_code", "vibe_run_tests", "vibe_get_session_info"} {
+		if !names[name] {
+			t.Errorf("missing tool %s", name)
+		}
+	}
+}
+
+func TestProcessAutonomousWork_UsesToolCalls(t *testing.T) {
+	fake := &scriptedToolLLM{replies: []llm.Response{
+		{Content: "Looking at the project", ToolCalls: []llm.ToolCall{
+			{ID: "call_1", Type: "function", Function: llm.FunctionCall{Name: "vibe_list_files", Arguments: map[string]interface{}{}}},
+			{ID: "call_2", Type: "function", Function: llm.FunctionCall{Name: "vibe_delete_everything", Arguments: map[string]interface{}{}}},
+		}},
+		{Content: "Nothing to change"},
+	}}
+	client := NewVibeCodingLLMClient(fake)
+	client.SetMCPClient(NewVibeCodingMCPClient())
+
+	resp, err := client.ProcessRequest(context.Background(), VibeCodingRequest{
+		Action:  "autonomous_work",
+		Query:   "check the project",
+		Options: map[string]interface{}{"user_id": int64(7)},
+	})
+	if err != nil {
+		t.Fatalf("ProcessRequest: %v", err)
+	}
+	if len(fake.tools) == 0 {
+		t.Fatal("tools must be passed to the LLM")
+	}
+	if resp.Response != "Nothing to change" {
+		t.Errorf("summary = %q", resp.Response)
+	}
+	if len(fake.requests) != 2 {
+		t.Fatalf("expected 2 LLM requests, got %d", len(fake.requests))
+	}
+
+	second := fake.requests[1]
+	if len(second) != 5 {
+		t.Fatalf("expected system, user, assistant and two tool messages, got %+v", second)
+	}
+	if second[2].Role != "assistant" || len(second[2].ToolCalls) != 2 {
+		t.Errorf("assistant message must carry tool calls: %+v", second[2])
+	}
+	if second[3].Role != "tool" || second[3].ToolCallID != "call_1" || !strings.HasPrefix(second[3].Content, "FAILED:") {
+		t.Errorf("list files result = %+v", second[3])
+	}
+	if second[4].ToolCallID != "call_2" || !strings.Contains(second[4].Content, "unknown MCP tool") {
+		t.Errorf("unknown tool result = %+v", second[4])
+	}
+
+	finished, note := autoWorkFinished(resp)
+	if finished || !strings.Contains(note, "unknown MCP tool") {
+		t.Errorf("unknown tool must mark the work unfinished: %v %q", finished, note)
+	}
+}
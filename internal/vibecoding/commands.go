@@ -2,14 +2,17 @@ package vibecoding
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"time"
 
 	"ai-chatter/internal/codevalidation"
 	"ai-chatter/internal/llm"
+	"ai-chatter/internal/llm/jsonextract"
+	"ai-chatter/internal/telegram/msgsplit"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -34,11 +37,16 @@ type VibeCodingHandler struct {
 	llmClient        llm.Client
 	protocolClient   *VibeCodingLLMClient
 	awaitingAutoTask map[int64]bool // Пользователи, ожидающие ввода задачи для автономной работы
+	config           VibeCodingConfig
+	cloner           RepoCloner // клонирование репозиториев для /vibecoding_clone, nil — GitCloner
+	pusher           RepoPusher // публикация изменений для /vibecoding_push, nil — GitHubPusher
 }
 
 // NewVibeCodingHandler создает новый обработчик vibecoding
 func NewVibeCodingHandler(sender TelegramSender, formatter MessageFormatter, llmClient llm.Client) *VibeCodingHandler {
-	sessionManager := NewSessionManager()
+	// Веб-интерфейс запускается отдельно через StartWebServer, чтобы занятый порт стал ошибкой запуска бота
+	sessionManager := NewSessionManagerWithoutWebServer()
+	llmClient = withUsageTracking(llmClient)
 	protocolClient := NewVibeCodingLLMClient(llmClient)
 
 	// Создаем MCP клиент и подключаем его к LLM клиенту
@@ -49,7 +57,7 @@ func NewVibeCodingHandler(sender TelegramSender, formatter MessageFormatter, llm
 	SetGlobalSessionManager(sessionManager)
 	SetGlobalMCPClient(mcpClient)
 
-	return &VibeCodingHandler{
+	h := &VibeCodingHandler{
 		sessionManager:   sessionManager,
 		sender:           sender,
 		formatter:        formatter,
@@ -57,6 +65,113 @@ func NewVibeCodingHandler(sender TelegramSender, formatter MessageFormatter, llm
 		protocolClient:   protocolClient,
 		awaitingAutoTask: make(map[int64]bool),
 	}
+	sessionManager.SetIdleCloseHandler(h.notifyIdleClosed)
+	return h
+}
+
+// webInterfaceLine ссылка на страницу сессии с фактическим портом веб-сервера
+func (h *VibeCodingHandler) webInterfaceLine(userID int64) string {
+	port := h.sessionManager.WebPort()
+	if port == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n🌐 Веб-интерфейс: http://localhost:%d/vibe_%d\n", port, userID)
+}
+
+// StartWebServer запускает веб-интерфейс сессий на порту port (0 — без веб-интерфейса)
+func (h *VibeCodingHandler) StartWebServer(port int) error {
+	return h.sessionManager.StartWebServer(port)
+}
+
+// StartMetricsServer запускает отдельный порт с /metrics для Prometheus; 0 — не запускать
+func (h *VibeCodingHandler) StartMetricsServer(port int) error {
+	return h.sessionManager.StartMetricsServer(port)
+}
+
+// RestoreSessions подключает хранилище сессий и восстанавливает сессии, сохранённые до перезапуска.
+// Контейнеры пересоздаются в фоне, пользователю приходит уведомление о результате
+func (h *VibeCodingHandler) RestoreSessions(ctx context.Context, store SessionStore) error {
+	restored, err := h.sessionManager.RestoreSessions(store, h.llmClient)
+	if err != nil {
+		return err
+	}
+	for _, session := range restored {
+		go h.recreateRestoredContainer(ctx, session)
+	}
+	return nil
+}
+
+// StartContainerGC запускает сборку осиротевших контейнеров VibeCoding: сразу и далее каждые
+// ContainerGCInterval. onGC получает итог прохода, удалившего контейнеры (например, для администратора)
+func (h *VibeCodingHandler) StartContainerGC(onGC ContainerGCFunc) {
+	h.sessionManager.SetContainerGCHandler(onGC)
+	h.sessionManager.StartContainerGC(newSessionDockerAdapter(), h.config.ContainerGCInterval)
+}
+
+// recreateRestoredContainer пересоздаёт контейнер восстановленной сессии и сообщает пользователю
+func (h *VibeCodingHandler) recreateRestoredContainer(ctx context.Context, session *VibeCodingSession) {
+	text := fmt.Sprintf("[vibecoding] ♻️ Сессия «%s» восстановлена после перезапуска бота, окружение пересоздано.", session.ProjectName)
+	if err := session.RecreateContainer(ctx); err != nil {
+		log.Printf("⚠️ Failed to recreate container for restored session of user %d: %v", session.UserID, err)
+		text = fmt.Sprintf("[vibecoding] ♻️ Сессия «%s» восстановлена после перезапуска бота, но окружение пересоздать не удалось: %v\nФайлы сохранены, команды в контейнере недоступны — завершите сессию через /vibecoding_end и загрузите архив заново.",
+			session.ProjectName, err)
+	}
+	if err := h.sendMessage(session.ChatID, text); err != nil {
+		log.Printf("⚠️ Failed to notify user %d about restored session: %v", session.UserID, err)
+	}
+}
+
+// WarnMaintenance предупреждает чаты активных сессий о режиме обслуживания бота и сохраняет снимки сессий,
+// чтобы они пережили перезапуск при деплое. Возвращает число предупреждённых сессий
+func (h *VibeCodingHandler) WarnMaintenance(notice string) int {
+	sessions := h.sessionManager.GetAllSessions()
+	for _, session := range sessions {
+		text := fmt.Sprintf("[vibecoding] %s\nСессия «%s» сохранена и будет восстановлена после перезапуска бота.", notice, session.ProjectName)
+		if err := h.sessionManager.CheckpointSession(session); err != nil {
+			log.Printf("⚠️ Failed to checkpoint vibecoding session of user %d before maintenance: %v", session.UserID, err)
+			text = fmt.Sprintf("[vibecoding] %s\nСессию «%s» сохранить не удалось: после перезапуска бота загрузите архив заново.", notice, session.ProjectName)
+		}
+		if err := h.sendMessage(session.ChatID, text); err != nil {
+			log.Printf("⚠️ Failed to warn user %d about maintenance: %v", session.UserID, err)
+		}
+	}
+	return len(sessions)
+}
+
+// SetMaintenance приостанавливает закрытие сессий по бездействию на время обслуживания бота:
+// без этого сессии, сохранённые WarnMaintenance, закрылись бы через VIBECODING_IDLE_TIMEOUT
+// вместе со снимком, а пользователь получил бы уведомление посреди обслуживания
+func (h *VibeCodingHandler) SetMaintenance(on bool) {
+	h.sessionManager.PauseIdleClose(on)
+}
+
+// notifyIdleClosed предупреждает пользователя, что сессия закрывается по бездействию
+func (h *VibeCodingHandler) notifyIdleClosed(session *VibeCodingSession, idle time.Duration) {
+	text := fmt.Sprintf("[vibecoding] ⏰ Сессия «%s» закрывается автоматически: нет активности %s. Контейнер будет удалён, несохранённые изменения потеряны. Загрузите архив заново, чтобы продолжить.",
+		session.ProjectName, idle)
+	if err := h.sendMessage(session.ChatID, text); err != nil {
+		log.Printf("⚠️ Failed to notify user %d about idle session close: %v", session.UserID, err)
+	}
+}
+
+// Close останавливает фоновое закрытие неактивных сессий
+func (h *VibeCodingHandler) Close() error {
+	return h.sessionManager.Close()
+}
+
+// SetConfig применяет настройки попыток и таймаутов к обработчику и новым сессиям
+func (h *VibeCodingHandler) SetConfig(cfg VibeCodingConfig) {
+	h.config = cfg
+	h.llmClient = withLLMTimeout(h.llmClient, cfg.LLMTimeout)
+	if h.sessionManager != nil {
+		h.sessionManager.SetConfig(cfg)
+	}
+	if h.protocolClient != nil {
+		h.protocolClient.SetRecordDir(cfg.RecordDir)
+		h.protocolClient.SetLLMTimeout(cfg.LLMTimeout)
+	}
+	log.Printf("🔧 VibeCoding config: test fix attempts=%d, test gen attempts=%d, validation attempts=%d, command timeout=%s, LLM timeout=%s, idle timeout=%s",
+		cfg.testFixAttempts(), cfg.testGenAttempts(), cfg.testValidationAttempts(), cfg.CommandTimeout, cfg.LLMTimeout, cfg.idleTimeout())
 }
 
 // HandleArchiveUpload обрабатывает загрузку архива для создания vibecoding сессии
@@ -89,16 +204,74 @@ func (h *VibeCodingHandler) HandleArchiveUpload(ctx context.Context, userID, cha
 		return fmt.Errorf("invalid project archive")
 	}
 
+	return h.startSession(ctx, userID, chatID, projectName, files, nil)
+}
+
+// HandleCloneCommand /vibecoding_clone <git-url> [ветка] — создаёт сессию из git репозитория
+// вместо архива: файлы отбираются по тем же правилам, а в сессии запоминаются URL и коммит
+func (h *VibeCodingHandler) HandleCloneCommand(ctx context.Context, userID, chatID int64, args string) error {
+	if h.sessionManager.HasActiveSession(userID) {
+		text := "[vibecoding] ❌ У вас уже есть активная сессия вайбкодинга. Завершите её командой /vibecoding_end перед созданием новой."
+		return h.sendMessage(chatID, text)
+	}
+	fields := strings.Fields(args)
+	if len(fields) == 0 || len(fields) > 2 {
+		return h.sendMessage(chatID, "[vibecoding] ℹ️ Использование: /vibecoding_clone <git-url> [ветка]\nНапример: /vibecoding_clone https://github.com/owner/repo main")
+	}
+	repoURL, branch := fields[0], ""
+	if len(fields) == 2 {
+		branch = fields[1]
+	}
+
+	if err := h.sendMessage(chatID, fmt.Sprintf("[vibecoding] 📥 Клонирую %s...", repoURL)); err != nil {
+		log.Printf("⚠️ Failed to send clone progress: %v", err)
+	}
+	repo, err := h.repoCloner().Clone(ctx, repoURL, branch)
+	if err != nil {
+		h.sendMessage(chatID, fmt.Sprintf("[vibecoding] ❌ Не удалось клонировать репозиторий: %s", err.Error()))
+		return err
+	}
+	if !IsValidProjectArchive(repo.Files) {
+		h.sendMessage(chatID, "[vibecoding] ❌ Репозиторий не содержит подходящих файлов для анализа кода.")
+		return fmt.Errorf("invalid project repository")
+	}
+
+	return h.startSession(ctx, userID, chatID, repo.ProjectName, repo.Files, &repo.Origin)
+}
+
+// repoCloner клонирование для /vibecoding_clone: git на хосте бота с GITHUB_TOKEN из настроек
+func (h *VibeCodingHandler) repoCloner() RepoCloner {
+	if h.cloner != nil {
+		return h.cloner
+	}
+	return &GitCloner{Token: h.config.GitHubToken}
+}
+
+// repoPusher публикация для /vibecoding_push: GitHub API с GITHUB_TOKEN из настроек
+func (h *VibeCodingHandler) repoPusher() RepoPusher {
+	if h.pusher != nil {
+		return h.pusher
+	}
+	return &GitHubPusher{Token: h.config.GitHubToken}
+}
+
+// startSession создаёт сессию из файлов проекта (архив или клон репозитория) и настраивает окружение
+func (h *VibeCodingHandler) startSession(ctx context.Context, userID, chatID int64, projectName string, files map[string]string, origin *SessionOrigin) error {
 	// Отправляем сообщение о начале настройки
 	stats := GetProjectStats(files)
+	source := ""
+	if origin != nil {
+		source = "\nИсточник: " + origin.String()
+	}
 	startMsg := fmt.Sprintf(`[vibecoding] 🔥 Запуск сессии вайбкодинга
 
-Проект: %s
+Проект: %s%s
 Файлов: %d
 Размер: %d bytes
 
 🔧 Настройка окружения... (до 3 попыток)`,
 		projectName,
+		source,
 		stats["total_files"].(int),
 		stats["total_size"].(int))
 
@@ -113,7 +286,29 @@ func (h *VibeCodingHandler) HandleArchiveUpload(ctx context.Context, userID, cha
 		h.updateMessage(chatID, setupMsg.MessageID, errorMsg)
 		return err
 	}
+	ctx = withSessionUsage(ctx, session)
+	if origin != nil {
+		session.SetOrigin(*origin)
+	}
+
+	// Предупреждаем о секретах: только файлы и строки, без значений
+	if findings := session.SecretFindings(); len(findings) > 0 {
+		if err := h.sendMessage(chatID, "[vibecoding] "+FormatSecretFindings(findings)); err != nil {
+			log.Printf("⚠️ Failed to send secret findings: %v", err)
+		}
+	}
+
+	// В монорепозитории анализ всего дерева бесполезен: просим выбрать подпроект
+	if session.IsMonorepo() {
+		h.updateMessage(chatID, setupMsg.MessageID, formatSubprojectPrompt(session))
+		return nil
+	}
+
+	return h.setupSessionEnvironment(ctx, userID, chatID, session, setupMsg.MessageID)
+}
 
+// setupSessionEnvironment подключает MCP, настраивает окружение сессии и сообщает результат в messageID
+func (h *VibeCodingHandler) setupSessionEnvironment(ctx context.Context, userID, chatID int64, session *VibeCodingSession, messageID int) error {
 	// Подключаем MCP клиент через HTTP для прямых вызовов от LLM
 	if h.protocolClient != nil && h.protocolClient.mcpClient != nil {
 		log.Printf("🔗 Connecting MCP client for VibeCoding session user %d", userID)
@@ -126,7 +321,8 @@ func (h *VibeCodingHandler) HandleArchiveUpload(ctx context.Context, userID, cha
 	}
 
 	// Настраиваем окружение
-	if err := session.SetupEnvironment(ctx); err != nil {
+	progress := h.newProgressReporter(chatID, messageID, fmt.Sprintf("🔧 Настройка окружения: %s", session.ProjectName), 0)
+	if err := session.SetupEnvironmentWithProgress(ctx, progress.Report); err != nil {
 		// Очищаем сессию при ошибке
 		h.sessionManager.EndSession(userID)
 
@@ -137,44 +333,180 @@ func (h *VibeCodingHandler) HandleArchiveUpload(ctx context.Context, userID, cha
 Сессия завершена. Проверьте содержимое архива и попробуйте снова.`,
 			err.Error())
 
-		h.updateMessage(chatID, setupMsg.MessageID, errorMsg)
+		progress.Finish(errorMsg)
 		return err
 	}
 
+	projectName := session.ProjectName
+	if session.Subproject != "" && session.Subproject != "." {
+		projectName += " / " + session.Subproject
+	}
+
 	// Окружение настроено успешно
 	successMsg := fmt.Sprintf(`[vibecoding] 🔥 Сессия вайбкодинга готова!
 
 Проект: %s
 Язык: %s
 Команда тестов: %s
-
-🌐 Веб-интерфейс: http://localhost:3000?user=%d
-
+%s
 Доступные команды:
 /vibecoding_info - информация о сессии
 /vibecoding_context - обновить контекст проекта
 /vibecoding_test - запустить тесты
+/vibecoding_validate_all - тесты и дополнительные проверки из .vibecoding.yml
+/vibecoding_coverage - покрытие кода тестами по файлам
+/vibecoding_matrix - тесты в нескольких версиях среды (например, python 3.9/3.11/3.12)
 /vibecoding_generate_tests - сгенерировать тесты
 /vibecoding_auto - автономная работа с проектом
-/vibecoding_end - завершить сессию
+/vibecoding_diff - изменения сгенерированных файлов относительно исходных
+/vibecoding_rollback - откатить последнее изменение сгенерированных файлов
+/vibecoding_push [owner/repo] [ветка] - опубликовать изменения на GitHub веткой и pull request
+/vibecoding_allow_secret - показать файл с найденными секретами в веб-интерфейсе и архиве
+/vibecoding_end - завершить сессию с проверкой качества (--fast без проверки, --with-git с историей .git)%s
 
 Теперь вы можете задавать вопросы по коду и запрашивать изменения!`,
-		session.ProjectName,
+		projectName,
 		session.Analysis.Language,
 		session.TestCommand,
-		userID)
+		h.webInterfaceLine(userID),
+		subprojectCommandHint(session))
 
-	h.updateMessage(chatID, setupMsg.MessageID, successMsg)
+	progress.Finish(successMsg)
 	return nil
 }
 
+// handlePushCommand /vibecoding_push [owner/repo] [ветка] — изменённые и новые файлы сессии
+// публикуются веткой в репозитории (по умолчанию — откуда сделан /vibecoding_clone) и pull request
+func (h *VibeCodingHandler) handlePushCommand(ctx context.Context, chatID int64, session *VibeCodingSession, args string) error {
+	fields := strings.Fields(args)
+	if len(fields) > 2 || len(fields) == 0 && session.Origin == nil {
+		return h.sendMessage(chatID, "[vibecoding] ℹ️ Использование: /vibecoding_push [owner/repo] [ветка]\nДля сессии из архива репозиторий обязателен; для /vibecoding_clone по умолчанию — исходный репозиторий и ветка.")
+	}
+	target, base := "", ""
+	if len(fields) > 0 {
+		target = fields[0]
+	}
+	if len(fields) == 2 {
+		base = fields[1]
+	}
+
+	if err := h.sendMessage(chatID, "[vibecoding] ⬆️ Готовлю изменения для GitHub..."); err != nil {
+		log.Printf("⚠️ Failed to send push progress: %v", err)
+	}
+	plan, result, err := PushSessionToGitHub(ctx, session, h.repoPusher(), target, base)
+	if err != nil {
+		h.sendMessage(chatID, fmt.Sprintf("[vibecoding] ❌ Не удалось опубликовать изменения: %s", err.Error()))
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("[vibecoding] ✅ Открыт pull request #%d: %s\n\nВетка: %s → %s\nФайлов: %d", result.Number, result.URL, result.Branch, plan.Base, len(plan.Changes)))
+	if plan.BaseMoved || result.BaseCommit != plan.BaseCommit {
+		sb.WriteString(fmt.Sprintf("\n🔀 Ветка %s ушла вперёд с начала сессии: изменения основаны на %s", plan.Base, shortCommit(result.BaseCommit)))
+	}
+	if len(plan.Conflicts) > 0 {
+		sb.WriteString("\n⚠️ Изменены и в ветке, и в сессии (в PR версия сессии): " + strings.Join(plan.Conflicts, ", "))
+	}
+	return h.sendMessage(chatID, sb.String())
+}
+
+// handleSubprojectCommand выбирает подпроект монорепозитория и настраивает окружение для него
+func (h *VibeCodingHandler) handleSubprojectCommand(ctx context.Context, userID, chatID int64, session *VibeCodingSession, args string) error {
+	if !session.IsMonorepo() {
+		return h.sendMessage(chatID, "[vibecoding] ℹ️ В проекте один подпроект, выбирать нечего.")
+	}
+	if args == "" {
+		return h.sendMessage(chatID, formatSubprojectPrompt(session))
+	}
+
+	if err := session.SelectSubproject(ctx, args); err != nil {
+		return h.sendMessage(chatID, fmt.Sprintf("[vibecoding] ❌ %s\n\n%s", err.Error(), formatSubprojectPrompt(session)))
+	}
+
+	text := fmt.Sprintf("[vibecoding] 📂 Подпроект: %s\nФайлов: %d\n\n🔧 Настройка окружения... (до 3 попыток)", session.Subproject, len(session.Files))
+	msg := tgbotapi.NewMessage(chatID, h.formatter.EscapeText(text))
+	msg.ParseMode = h.formatter.ParseModeValue()
+	sentMsg, _ := h.sender.Send(msg)
+
+	return h.setupSessionEnvironment(ctx, userID, chatID, session, sentMsg.MessageID)
+}
+
+// handleAllowSecretCommand /vibecoding_allow_secret <файл> — пользователь подтверждает, что файл можно
+// показывать в веб-интерфейсе и добавить в архив результатов. В промптах LLM значения остаются замаскированными
+func (h *VibeCodingHandler) handleAllowSecretCommand(chatID int64, session *VibeCodingSession, filename string) error {
+	if filename == "" {
+		findings := session.SecretFindings()
+		if len(findings) == 0 {
+			return h.sendMessage(chatID, "[vibecoding] ℹ️ В файлах сессии секреты не найдены.")
+		}
+		return h.sendMessage(chatID, "[vibecoding] "+FormatSecretFindings(findings))
+	}
+	allowed, err := session.AllowSecretFile(filename)
+	if err != nil {
+		return h.sendMessage(chatID, fmt.Sprintf("[vibecoding] ❌ %s", err.Error()))
+	}
+	return h.sendMessage(chatID, fmt.Sprintf("[vibecoding] 🔓 Файл %s будет показан в веб-интерфейсе и добавлен в архив результатов. "+
+		"В запросах к LLM значения секретов по-прежнему заменяются плейсхолдерами.", allowed))
+}
+
+// formatSubprojectPrompt предлагает выбрать подпроект из найденных
+func formatSubprojectPrompt(session *VibeCodingSession) string {
+	return fmt.Sprintf(`[vibecoding] 📂 Найдено несколько подпроектов в %s:
+
+%s
+
+Выберите подпроект: /vibecoding_subproject <путь>
+Весь архив целиком: /vibecoding_subproject .`,
+		session.ProjectName,
+		FormatSubprojects(session.Subprojects, session.Subproject))
+}
+
+func subprojectCommandHint(session *VibeCodingSession) string {
+	if !session.IsMonorepo() {
+		return ""
+	}
+	return "\n/vibecoding_subproject - сменить подпроект монорепозитория"
+}
+
 // HandleVibeCodingCommand обрабатывает команды vibecoding режима
 func (h *VibeCodingHandler) HandleVibeCodingCommand(ctx context.Context, userID, chatID int64, command string) error {
+	// Клон репозитория создаёт новую сессию, поэтому обрабатывается до проверки активной
+	if strings.HasPrefix(command, "/vibecoding_clone") {
+		return h.HandleCloneCommand(ctx, userID, chatID, strings.TrimSpace(strings.TrimPrefix(command, "/vibecoding_clone")))
+	}
+
 	session := h.sessionManager.GetSession(userID)
 	if session == nil {
-		text := "[vibecoding] ❌ У вас нет активной сессии вайбкодинга. Загрузите архив с кодом для начала."
+		text := "[vibecoding] ❌ У вас нет активной сессии вайбкодинга. Загрузите архив с кодом или используйте /vibecoding_clone <git-url> для начала."
 		return h.sendMessage(chatID, text)
 	}
+	session.touch()
+	ctx = withSessionUsage(ctx, session)
+
+	if strings.HasPrefix(command, "/vibecoding_subproject") {
+		return h.handleSubprojectCommand(ctx, userID, chatID, session, strings.TrimSpace(strings.TrimPrefix(command, "/vibecoding_subproject")))
+	}
+	if strings.HasPrefix(command, "/vibecoding_allow_secret") {
+		return h.handleAllowSecretCommand(chatID, session, strings.TrimSpace(strings.TrimPrefix(command, "/vibecoding_allow_secret")))
+	}
+	// Пока подпроект не выбран, окружения нет: доступно только завершение сессии
+	if session.NeedsSubprojectSelection() && !strings.HasPrefix(command, "/vibecoding_end") {
+		return h.sendMessage(chatID, formatSubprojectPrompt(session))
+	}
+
+	if strings.HasPrefix(command, "/vibecoding_end") {
+		args := strings.Fields(strings.TrimPrefix(command, "/vibecoding_end"))
+		return h.handleEndCommand(ctx, chatID, userID, session, slices.Contains(args, "--fast"), slices.Contains(args, "--with-git"))
+	}
+	if strings.HasPrefix(command, "/vibecoding_push") {
+		return h.handlePushCommand(ctx, chatID, session, strings.TrimSpace(strings.TrimPrefix(command, "/vibecoding_push")))
+	}
+	if strings.HasPrefix(command, "/vibecoding_matrix") {
+		return h.handleMatrixCommand(ctx, chatID, session, strings.TrimSpace(strings.TrimPrefix(command, "/vibecoding_matrix")))
+	}
+	if strings.HasPrefix(command, "/vibecoding_validate_add") {
+		return h.handleValidateAddCommand(chatID, session, strings.TrimSpace(strings.TrimPrefix(command, "/vibecoding_validate_add")))
+	}
 
 	switch command {
 	case "/vibecoding_info":
@@ -182,25 +514,72 @@ func (h *VibeCodingHandler) HandleVibeCodingCommand(ctx context.Context, userID,
 	case "/vibecoding_context":
 		return h.handleContextCommand(ctx, chatID, session)
 	case "/vibecoding_test":
-		return h.handleTestCommand(ctx, chatID, session)
+		err := h.handleTestCommand(ctx, chatID, session)
+		// Дополнительные проверки проекта выполняются вместе с тестами
+		if len(session.GetValidationChecks()) > 0 {
+			results := session.RunValidationChecks(ctx, false)
+			if sendErr := h.sendMessage(chatID, FormatValidationCheckResults(results)); sendErr != nil {
+				log.Printf("⚠️ Failed to send validation results: %v", sendErr)
+			}
+		}
+		return err
+	case "/vibecoding_validate_all":
+		return h.handleValidateAllCommand(ctx, chatID, session)
+	case "/vibecoding_coverage":
+		return h.handleCoverageCommand(ctx, chatID, session)
 	case "/vibecoding_generate_tests":
 		return h.handleGenerateTestsCommand(ctx, chatID, session)
 	case "/vibecoding_auto":
 		return h.handleAutoCommand(ctx, chatID, userID, session)
-	case "/vibecoding_end":
-		return h.handleEndCommand(ctx, chatID, userID, session)
+	case "/vibecoding_diff":
+		return h.handleDiffCommand(chatID, session)
+	case "/vibecoding_rollback":
+		return h.handleRollbackCommand(ctx, chatID, session)
 	default:
 		text := "[vibecoding] ❓ Неизвестная команда. Используйте /vibecoding_info для списка доступных команд."
 		return h.sendMessage(chatID, text)
 	}
 }
 
+// HasSession true, если у пользователя есть активная сессия вайбкодинга
+func (h *VibeCodingHandler) HasSession(userID int64) bool {
+	return h.sessionManager.GetSession(userID) != nil
+}
+
+// SessionsSummary сводка активных сессий и вызовов vibe_* инструментов для суточного отчёта
+func (h *VibeCodingHandler) SessionsSummary(now time.Time) string {
+	sessions := h.sessionManager.GetAllSessions()
+	userIDs := make([]int64, 0, len(sessions))
+	for id := range sessions {
+		userIDs = append(userIDs, id)
+	}
+	slices.Sort(userIDs)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Активных сессий: %d\n", len(sessions)))
+	for _, id := range userIDs {
+		s := sessions[id]
+		prompt, completion, cost := s.TokenUsage()
+		sb.WriteString(fmt.Sprintf("- %s (пользователь %d): длительность %s, простой %s, токенов %d, $%.4f\n",
+			s.ProjectName, id, now.Sub(s.CreatedAt()).Round(time.Minute), now.Sub(s.LastActivity()).Round(time.Minute),
+			prompt+completion, cost))
+	}
+	sb.WriteString(DefaultToolMetrics.Format())
+	return sb.String()
+}
+
 // HandleVibeCodingMessage обрабатывает текстовые сообщения в vibecoding режиме
 func (h *VibeCodingHandler) HandleVibeCodingMessage(ctx context.Context, userID, chatID int64, messageText string) error {
 	session := h.sessionManager.GetSession(userID)
 	if session == nil {
 		return nil // Не наша задача если нет сессии
 	}
+	session.touch()
+	ctx = withSessionUsage(ctx, session)
+
+	if session.NeedsSubprojectSelection() {
+		return h.sendMessage(chatID, formatSubprojectPrompt(session))
+	}
 
 	// Проверяем, ожидается ли задача для автономной работы
 	if h.awaitingAutoTask[userID] {
@@ -210,15 +589,23 @@ func (h *VibeCodingHandler) HandleVibeCodingMessage(ctx context.Context, userID,
 
 	log.Printf("🔥 Processing vibecoding message from user %d: %s", userID, messageText)
 
-	// Генерируем ответ через LLM
-	response, err := h.generateCodeResponse(ctx, session, messageText)
+	// Генерируем ответ через LLM, показывая его по мере генерации
+	response, previewID, err := h.streamAnswer(ctx, chatID, func(ctx context.Context) (string, error) {
+		return h.generateCodeResponse(ctx, session, messageText)
+	})
 	if err != nil {
 		errorMsg := fmt.Sprintf("[vibecoding] ❌ Ошибка генерации ответа: %s", err.Error())
+		if previewID != 0 && h.updateMessage(chatID, previewID, errorMsg) == nil {
+			return nil
+		}
 		return h.sendMessage(chatID, errorMsg)
 	}
 
-	// Отправляем ответ пользователю
-	return h.sendLongMessage(chatID, fmt.Sprintf("[vibecoding] %s", response))
+	// Отправляем ответ пользователю; ответ резервной модели подписывается
+	if note := formatFallbackNote(session.ActiveModel()); note != "" {
+		response += "\n\n" + note
+	}
+	return h.sendStreamedAnswer(chatID, previewID, fmt.Sprintf("[vibecoding] %s", response))
 }
 
 // handleInfoCommand обрабатывает команду получения информации о сессии
@@ -226,6 +613,11 @@ func (h *VibeCodingHandler) handleInfoCommand(chatID int64, session *VibeCodingS
 	info := session.GetSessionInfo()
 	duration := time.Since(session.StartTime).Round(time.Second)
 
+	projectName := info["project_name"].(string)
+	if subproject, ok := info["subproject"].(string); ok && subproject != "." {
+		projectName += " / " + subproject
+	}
+
 	infoMsg := fmt.Sprintf(`[vibecoding] 📊 Информация о сессии
 
 Проект: %s
@@ -235,7 +627,7 @@ func (h *VibeCodingHandler) handleInfoCommand(chatID int64, session *VibeCodingS
 Файлов: %d исходных + %d сгенерированных
 Команда тестов: %s
 Контейнер: %s`,
-		info["project_name"].(string),
+		projectName,
 		info["language"].(string),
 		info["start_time"].(time.Time).Format("15:04:05"),
 		duration,
@@ -244,6 +636,14 @@ func (h *VibeCodingHandler) handleInfoCommand(chatID int64, session *VibeCodingS
 		info["test_command"].(string),
 		info["container_id"].(string))
 
+	if url, ok := info["origin_url"].(string); ok {
+		infoMsg += "\nРепозиторий: " + url
+		if branch, ok := info["origin_branch"].(string); ok {
+			infoMsg += " (" + branch + ")"
+		}
+		infoMsg += "\nКоммит: " + info["origin_commit"].(string)
+	}
+
 	// Добавляем информацию о контексте
 	if contextAvailable, exists := info["context_available"].(bool); exists && contextAvailable {
 		infoMsg += fmt.Sprintf(`
@@ -254,9 +654,24 @@ func (h *VibeCodingHandler) handleInfoCommand(chatID int64, session *VibeCodingS
 Полный контекст: PROJECT_CONTEXT.md`,
 			info["context_generated_at"].(time.Time).Format("15:04:05"),
 			info["context_files_count"].(int))
+
+		if central, ok := info["central_files"].([]FileCentrality); ok {
+			infoMsg += "\n\n🕸 Центральные файлы:"
+			for i, f := range central {
+				infoMsg += fmt.Sprintf("\n%d. %s — зависят %d (напрямую %d)", i+1, f.Path, f.TransitiveDependents, f.DirectDependents)
+			}
+		}
 	} else {
 		infoMsg += "\n\n📋 Контекст проекта: не доступен"
 	}
+	promptTokens, completionTokens, cost := session.TokenUsage()
+	infoMsg += "\n\n" + formatTokenUsage(promptTokens, completionTokens, cost)
+	if model, fallbackFrom := session.ActiveModel(); model != "" {
+		infoMsg += "\n🤖 Модель последнего ответа: " + model
+		if fallbackFrom != "" {
+			infoMsg += fmt.Sprintf(" (резервная, основная %s недоступна)", fallbackFrom)
+		}
+	}
 
 	return h.sendMessage(chatID, infoMsg)
 }
@@ -314,7 +729,7 @@ func (h *VibeCodingHandler) handleTestCommand(ctx context.Context, chatID int64,
 	msg.ParseMode = h.formatter.ParseModeValue()
 	sentMsg, _ := h.sender.Send(msg)
 
-	maxAttempts := 3
+	maxAttempts := h.config.testFixAttempts()
 	var lastResult *codevalidation.ValidationResult
 	var lastError error
 
@@ -342,6 +757,9 @@ func (h *VibeCodingHandler) handleTestCommand(ctx context.Context, chatID int64,
 			}
 
 			errorMsg := fmt.Sprintf("[vibecoding] ❌ Ошибка выполнения тестов после %d попыток: %s", maxAttempts, err.Error())
+			if errors.Is(err, ErrCommandTimeout) {
+				errorMsg = fmt.Sprintf("[vibecoding] ⏱️ Тесты не уложились в таймаут (попыток: %d): %s", maxAttempts, err.Error())
+			}
 			h.updateMessage(chatID, sentMsg.MessageID, errorMsg)
 			return err
 		}
@@ -355,9 +773,20 @@ func (h *VibeCodingHandler) handleTestCommand(ctx context.Context, chatID int64,
 			break
 		}
 
-		// Если тесты не прошли и есть еще попытки - пытаемся исправить
+		// Если тесты не прошли и есть еще попытки - пытаемся исправить.
+		// Тип падения определяет, что чинить: окружение или сами тесты
 		if attempt < maxAttempts {
-			log.Printf("🔧 Tests failed on attempt %d, attempting to fix with LLM", attempt)
+			classification := h.testFailureClassifier().Classify(ctx, session.TestCommand, result.ExitCode, result.Output)
+			if classification.Kind.NeedsEnvironmentFix() {
+				log.Printf("🔧 Tests failed on attempt %d with %s, fixing execution environment", attempt, classification.Kind)
+				execErr := fmt.Errorf("%s (exit code %d): %s\n%s", classification.Kind, result.ExitCode, classification.Reason, result.Output)
+				if fixErr := h.fixTestExecutionIssues(ctx, session, execErr); fixErr != nil {
+					log.Printf("⚠️ Could not fix test execution issues on attempt %d: %v", attempt, fixErr)
+					lastError = fixErr
+				}
+				continue
+			}
+			log.Printf("🔧 Tests failed on attempt %d with %s, attempting to fix with LLM", attempt, classification.Kind)
 			if fixErr := h.fixFailingTests(ctx, session, result); fixErr != nil {
 				log.Printf("⚠️ Could not fix failing tests on attempt %d: %v", attempt, fixErr)
 				lastError = fixErr
@@ -386,11 +815,13 @@ func (h *VibeCodingHandler) handleTestCommand(ctx context.Context, chatID int64,
 
 		resultMsg := fmt.Sprintf(`[vibecoding] 🧪 Тесты выполнены %s%s
 
+%s
 Код выхода: %d
 Вывод:
 %s`,
 			status,
 			attempts,
+			FormatTestSummary(lastResult.Output, lastResult.ExitCode, lastResult.Success),
 			lastResult.ExitCode,
 			lastResult.Output)
 
@@ -409,6 +840,109 @@ func (h *VibeCodingHandler) handleTestCommand(ctx context.Context, chatID int64,
 	return nil
 }
 
+// handleValidateAllCommand запускает тесты и все дополнительные проверки проекта
+func (h *VibeCodingHandler) handleValidateAllCommand(ctx context.Context, chatID int64, session *VibeCodingSession) error {
+	text := "[vibecoding] 🔍 Запуск тестов и дополнительных проверок..."
+	msg := tgbotapi.NewMessage(chatID, h.formatter.EscapeText(text))
+	msg.ParseMode = h.formatter.ParseModeValue()
+	sentMsg, _ := h.sender.Send(msg)
+
+	results := session.RunValidationChecks(ctx, true)
+	h.updateMessage(chatID, sentMsg.MessageID, FormatValidationCheckResults(results))
+
+	for _, r := range results {
+		if !r.Success {
+			return fmt.Errorf("validation check %s failed", r.Name)
+		}
+	}
+	return nil
+}
+
+// handleCoverageCommand запускает тесты с покрытием и присылает таблицу покрытия по файлам
+func (h *VibeCodingHandler) handleCoverageCommand(ctx context.Context, chatID int64, session *VibeCodingSession) error {
+	plan, ok := CoveragePlanFor(session.TestCommand)
+	if !ok {
+		return h.sendMessage(chatID, fmt.Sprintf("[vibecoding] ❌ Покрытие поддерживается для go test, pytest и jest/vitest, команда тестов: %s", session.TestCommand))
+	}
+
+	text := "[vibecoding] 📊 Запуск тестов с покрытием..."
+	msg := tgbotapi.NewMessage(chatID, h.formatter.EscapeText(text))
+	msg.ParseMode = h.formatter.ParseModeValue()
+	sentMsg, _ := h.sender.Send(msg)
+
+	log.Printf("📊 Running coverage for user %d: %s", session.UserID, plan.Run)
+	runResult, err := session.ExecuteCommand(ctx, plan.Run)
+	if err != nil {
+		h.updateMessage(chatID, sentMsg.MessageID, fmt.Sprintf("[vibecoding] ❌ Ошибка запуска тестов с покрытием: %v", err))
+		return err
+	}
+	reportOutput := runResult.Output
+	if plan.Report != "" {
+		reportResult, err := session.ExecuteCommand(ctx, plan.Report)
+		if err != nil {
+			h.updateMessage(chatID, sentMsg.MessageID, fmt.Sprintf("[vibecoding] ❌ Ошибка построения отчёта о покрытии: %v", err))
+			return err
+		}
+		reportOutput = reportResult.Output
+	}
+
+	report, ok := plan.Measure(reportOutput)
+	if !ok {
+		h.updateMessage(chatID, sentMsg.MessageID, fmt.Sprintf("[vibecoding] ❌ Не удалось получить покрытие (%s, код выхода тестов %d):\n%s", plan.Runner, runResult.ExitCode, reportOutput))
+		return fmt.Errorf("coverage report not found in %s output", plan.Runner)
+	}
+
+	status := "✅ тесты прошли"
+	if !runResult.Success {
+		status = fmt.Sprintf("❌ тесты упали (код выхода %d), покрытие по выполненным тестам", runResult.ExitCode)
+	}
+	h.updateMessage(chatID, sentMsg.MessageID, fmt.Sprintf("[vibecoding] 📊 Покрытие кода (%s)\n%s\n\n%s", plan.Runner, status, FormatCoverageReport(report)))
+	return nil
+}
+
+// handleDiffCommand показывает unified diff сгенерированных файлов относительно исходных
+func (h *VibeCodingHandler) handleDiffCommand(chatID int64, session *VibeCodingSession) error {
+	diff := session.GeneratedDiff()
+	if diff == "" {
+		return h.sendMessage(chatID, "[vibecoding] ℹ️ Сгенерированных изменений нет.")
+	}
+	return h.sendLongMessage(chatID, "[vibecoding] 📝 Изменения сгенерированных файлов:\n\n```diff\n"+diff+"```")
+}
+
+// handleValidateAddCommand добавляет проверку: /vibecoding_validate_add <name>: <command>
+func (h *VibeCodingHandler) handleValidateAddCommand(chatID int64, session *VibeCodingSession, args string) error {
+	name, command, ok := strings.Cut(args, ":")
+	name = strings.TrimSpace(name)
+	command = strings.TrimSpace(command)
+	if !ok || name == "" || command == "" {
+		return h.sendMessage(chatID, "[vibecoding] Usage: /vibecoding_validate_add <name>: <command>\nНапример: /vibecoding_validate_add typecheck: mypy .")
+	}
+
+	session.AddValidationCheck(ValidationCheck{Name: name, Command: command})
+	return h.sendMessage(chatID, fmt.Sprintf("[vibecoding] ✅ Проверка '%s' добавлена: %s\nЗапуск: /vibecoding_validate_all", name, command))
+}
+
+// handleRollbackCommand возвращает сгенерированные файлы к предыдущей версии
+func (h *VibeCodingHandler) handleRollbackCommand(ctx context.Context, chatID int64, session *VibeCodingSession) error {
+	result, err := session.RollbackGeneratedFiles(ctx)
+	if err != nil {
+		return h.sendMessage(chatID, fmt.Sprintf("[vibecoding] ❌ Не удалось откатить изменения: %v", err))
+	}
+	if len(result.Restored) == 0 && len(result.Removed) == 0 {
+		return h.sendMessage(chatID, "[vibecoding] ℹ️ Нет сгенерированных изменений для отката.")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("[vibecoding] ⏪ Изменения откачены")
+	if len(result.Restored) > 0 {
+		sb.WriteString("\n\nВосстановлена предыдущая версия:\n- " + strings.Join(result.Restored, "\n- "))
+	}
+	if len(result.Removed) > 0 {
+		sb.WriteString("\n\nУдалены новые файлы:\n- " + strings.Join(result.Removed, "\n- "))
+	}
+	return h.sendMessage(chatID, sb.String())
+}
+
 // handleGenerateTestsCommand обрабатывает команду генерации тестов
 func (h *VibeCodingHandler) handleGenerateTestsCommand(ctx context.Context, chatID int64, session *VibeCodingSession) error {
 	text := "[vibecoding] 🧠 Генерация тестов..."
@@ -424,10 +958,12 @@ func (h *VibeCodingHandler) handleGenerateTestsCommand(ctx context.Context, chat
 		return err
 	}
 
-	// Сохраняем сгенерированные тесты в сессии
+	// Сохраняем сгенерированные тесты в сессии (без перезаписи исходных файлов)
+	stored := make(map[string]string, len(tests))
 	for filename, content := range tests {
-		session.AddGeneratedFile(filename, content)
+		stored[session.AddGeneratedFile(filename, content)] = content
 	}
+	tests = stored
 
 	// Копируем тесты в контейнер
 	if err := session.Docker.CopyFilesToContainer(ctx, session.ContainerID, tests); err != nil {
@@ -451,15 +987,33 @@ func (h *VibeCodingHandler) handleGenerateTestsCommand(ctx context.Context, chat
 	return nil
 }
 
-// handleEndCommand обрабатывает команду завершения сессии
-func (h *VibeCodingHandler) handleEndCommand(ctx context.Context, chatID int64, userID int64, session *VibeCodingSession) error {
-	text := "[vibecoding] 📦 Создание итогового архива..."
+// handleEndCommand обрабатывает команду завершения сессии. Перед сборкой архива выполняется
+// проверка качества, fast (/vibecoding_end --fast) её пропускает. withGit (--with-git) коммитит
+// незакоммиченные изменения и добавляет в архив каталог .git; по умолчанию архив без истории
+func (h *VibeCodingHandler) handleEndCommand(ctx context.Context, chatID int64, userID int64, session *VibeCodingSession, fast, withGit bool) error {
+	text := "[vibecoding] 🚦 Проверка качества проекта..."
+	if fast {
+		text = "[vibecoding] 📦 Создание итогового архива..."
+	}
 	msg := tgbotapi.NewMessage(chatID, h.formatter.EscapeText(text))
 	msg.ParseMode = h.formatter.ParseModeValue()
 	sentMsg, _ := h.sender.Send(msg)
 
+	report := &QualityGateReport{Skipped: true}
+	if !fast {
+		report = RunQualityGate(ctx, session)
+		report.NextSteps = h.generateNextSteps(ctx, session, report)
+		h.updateMessage(chatID, sentMsg.MessageID, "[vibecoding] 📦 Создание итогового архива...")
+	}
+
+	opts := ResultArchiveOptions{Report: report}
+	gitNote := ""
+	if withGit {
+		opts.GitHistory, gitNote = h.exportGitHistory(ctx, session)
+	}
+
 	// Создаем архив с результатами
-	archiveData, err := CreateResultArchive(session)
+	archiveData, err := CreateResultArchiveWithOptions(session, opts)
 	if err != nil {
 		errorMsg := fmt.Sprintf("[vibecoding] ❌ Ошибка создания архива: %s", err.Error())
 		h.updateMessage(chatID, sentMsg.MessageID, errorMsg)
@@ -497,10 +1051,21 @@ func (h *VibeCodingHandler) handleEndCommand(ctx context.Context, chatID int64,
 Длительность: %s
 Файлов в архиве: %d
 
-Архив содержит все исходные и сгенерированные файлы.`,
+Архив содержит все исходные и сгенерированные файлы, отчёт — в SESSION_REPORT.md.`,
 		session.ProjectName,
 		duration,
 		len(session.GetAllFiles()))
+	if conflicts := session.PreventedConflicts(); len(conflicts) > 0 {
+		caption += fmt.Sprintf("\n\n🛡️ Исходные файлы не перезаписаны: %s", strings.Join(conflicts, ", "))
+	}
+	if gitNote != "" {
+		caption += "\n\n" + gitNote
+	}
+	caption += "\n\n" + FormatQualityGateSummary(report)
+	// Полный отчёт — в SESSION_REPORT.md
+	if runes := []rune(caption); len(runes) > maxCaptionLength {
+		caption = string(runes[:maxCaptionLength-1]) + "…"
+	}
 	documentMsg.Caption = h.formatter.EscapeText(caption)
 	documentMsg.ParseMode = h.formatter.ParseModeValue()
 
@@ -508,6 +1073,30 @@ func (h *VibeCodingHandler) handleEndCommand(ctx context.Context, chatID int64,
 	return err
 }
 
+// exportGitHistory коммитит текущее состояние и выгружает .git для архива. При ошибке архив
+// собирается без истории, а note объясняет причину в подписи
+func (h *VibeCodingHandler) exportGitHistory(ctx context.Context, session *VibeCodingSession) (gitArchive []byte, note string) {
+	hash, err := session.GitCommit(ctx, sessionEndCommitMessage)
+	if err != nil {
+		log.Printf("⚠️ Failed to commit workspace before export: %v", err)
+		return nil, "⚠️ История git не добавлена: " + collapseError(err.Error())
+	}
+	gitArchive, err = session.ExportGitDir(ctx)
+	if err != nil {
+		log.Printf("⚠️ Failed to export .git: %v", err)
+		return nil, "⚠️ История git не добавлена: " + collapseError(err.Error())
+	}
+	return gitArchive, fmt.Sprintf("📝 История git в архиве, последний коммит: %s", shortCommitHash(hash))
+}
+
+// shortCommitHash первые 8 символов хэша коммита для сообщений
+func shortCommitHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
+
 // handleAutoCommand обрабатывает команду автономной работы
 func (h *VibeCodingHandler) handleAutoCommand(ctx context.Context, chatID int64, userID int64, session *VibeCodingSession) error {
 	h.awaitingAutoTask[userID] = true
@@ -522,11 +1111,13 @@ func (h *VibeCodingHandler) HandleAutoWorkRequest(ctx context.Context, userID, c
 		text := "[vibecoding] ❌ У вас нет активной сессии вайбкодинга."
 		return h.sendMessage(chatID, text)
 	}
+	ctx = withSessionUsage(ctx, session)
 
 	text := fmt.Sprintf("[vibecoding] 🤖 Запуск автономной работы...\n\nЗадача: %s", task)
 	msg := tgbotapi.NewMessage(chatID, h.formatter.EscapeText(text))
 	msg.ParseMode = h.formatter.ParseModeValue()
 	sentMsg, _ := h.sender.Send(msg)
+	progress := h.newProgressReporter(chatID, sentMsg.MessageID, "🤖 Автономная работа: "+collapseError(task), 0)
 
 	// Создаем запрос для автономной работы
 	options := map[string]interface{}{
@@ -543,12 +1134,13 @@ func (h *VibeCodingHandler) HandleAutoWorkRequest(ctx context.Context, userID, c
 		Context: VibeCodingContext{
 			ProjectName:     session.ProjectName,
 			Language:        session.Analysis.Language,
-			Files:           session.Files,
+			Files:           session.PromptFiles(),
 			GeneratedFiles:  session.GeneratedFiles,
 			SessionDuration: time.Since(session.StartTime).Round(time.Second).String(),
 		},
-		Query:   task,
-		Options: options,
+		Query:    task,
+		Options:  options,
+		Progress: progress.Report,
 	}
 
 	log.Printf("🤖 Starting autonomous work for user %d: %s", userID, task)
@@ -557,11 +1149,15 @@ func (h *VibeCodingHandler) HandleAutoWorkRequest(ctx context.Context, userID, c
 	response, err := h.protocolClient.ProcessRequest(ctx, request)
 	if err != nil {
 		log.Printf("❌ Autonomous work failed: %v", err)
+		session.RecordAutoWork(task, false, err.Error())
 		errorMsg := fmt.Sprintf("[vibecoding] ❌ Ошибка автономной работы: %s", err.Error())
-		h.updateMessage(chatID, sentMsg.MessageID, errorMsg)
+		progress.Finish(errorMsg)
 		return err
 	}
 
+	finished, note := autoWorkFinished(response)
+	session.RecordAutoWork(task, finished, note)
+
 	// Формируем результат
 	var resultMsg strings.Builder
 	resultMsg.WriteString("[vibecoding] 🤖 Автономная работа завершена\n\n")
@@ -604,7 +1200,7 @@ func (h *VibeCodingHandler) HandleAutoWorkRequest(ctx context.Context, userID, c
 		resultMsg.WriteString(fmt.Sprintf("Ошибка: %s\n", response.Error))
 	}
 
-	h.updateMessage(chatID, sentMsg.MessageID, resultMsg.String())
+	progress.Finish(resultMsg.String())
 	return nil
 }
 
@@ -616,12 +1212,13 @@ func (h *VibeCodingHandler) generateCodeResponse(ctx context.Context, session *V
 		Context: VibeCodingContext{
 			ProjectName:     session.ProjectName,
 			Language:        session.Analysis.Language,
-			Files:           session.Files,
+			Files:           session.PromptFiles(),
 			GeneratedFiles:  session.GeneratedFiles,
 			SessionDuration: time.Since(session.StartTime).Round(time.Second).String(),
 		},
 		Query: question,
 	}
+	h.fitProjectContext(session, &request)
 
 	// Обрабатываем запрос через протокол клиент
 	response, err := h.protocolClient.ProcessRequest(ctx, request)
@@ -692,7 +1289,7 @@ func (h *VibeCodingHandler) generateCodeResponseLegacy(ctx context.Context, sess
 		projectContext,
 		question)
 
-	resp, err := h.llmClient.Generate(ctx, []llm.Message{{Role: "user", Content: prompt}})
+	resp, err := generateStreamed(ctx, h.llmClient, []llm.Message{{Role: "user", Content: prompt}}, llm.OptionsForTask(llm.TaskGeneration))
 	if err != nil {
 		return "", fmt.Errorf("failed to generate response: %w", err)
 	}
@@ -700,16 +1297,14 @@ func (h *VibeCodingHandler) generateCodeResponseLegacy(ctx context.Context, sess
 	return resp.Content, nil
 }
 
-// generateTestsWithProgress генерирует тесты с детальным логированием в Telegram
+// generateTestsWithProgress генерирует тесты с отображением прогресса в Telegram
 func (h *VibeCodingHandler) generateTestsWithProgress(ctx context.Context, session *VibeCodingSession, chatID int64, messageID int) (map[string]string, error) {
-	maxAttempts := 5
+	maxAttempts := h.config.testGenAttempts()
+	progress := h.newProgressReporter(chatID, messageID, "🧠 Генерация тестов", maxAttempts)
 	var lastError error
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		// Обновляем сообщение с прогрессом
-		progressMsg := fmt.Sprintf("[vibecoding] 🧠 Генерация тестов... (попытка %d/%d)", attempt, maxAttempts)
-		h.updateMessage(chatID, messageID, progressMsg)
-
+		progress.Step(attempt, "генерация")
 		log.Printf("🧪 Test generation attempt %d/%d", attempt, maxAttempts)
 
 		// Генерируем тесты
@@ -717,72 +1312,49 @@ func (h *VibeCodingHandler) generateTestsWithProgress(ctx context.Context, sessi
 		if err != nil {
 			lastError = fmt.Errorf("test generation failed: %w", err)
 			log.Printf("❌ Test generation attempt %d failed: %v", attempt, err)
-
-			// Обновляем сообщение об ошибке
-			errorMsg := fmt.Sprintf("[vibecoding] ⚠️ Попытка %d/%d не удалась: %s", attempt, maxAttempts, err.Error())
-			h.updateMessage(chatID, messageID, errorMsg)
-			time.Sleep(2 * time.Second) // Дать пользователю прочитать ошибку
+			progress.Fail(attempt, fmt.Errorf("попытка %d: %w", attempt, err))
 			continue
 		}
 
 		if len(tests) == 0 {
 			lastError = fmt.Errorf("no tests generated")
 			log.Printf("⚠️ No tests generated on attempt %d", attempt)
-
-			// Обновляем сообщение
-			h.updateMessage(chatID, messageID, fmt.Sprintf("[vibecoding] ⚠️ Попытка %d/%d: тесты не сгенерированы", attempt, maxAttempts))
-			time.Sleep(2 * time.Second)
+			progress.Fail(attempt, fmt.Errorf("попытка %d: тесты не сгенерированы", attempt))
 			continue
 		}
 
 		// Валидируем сгенерированные тесты
-		validationMsg := fmt.Sprintf("[vibecoding] 🔍 Валидация %d тестовых файлов... (попытка %d/%d)", len(tests), attempt, maxAttempts)
-		h.updateMessage(chatID, messageID, validationMsg)
+		progress.Step(attempt, fmt.Sprintf("валидация %d тестовых файлов", len(tests)))
 		log.Printf("🔍 Validating %d generated test files", len(tests))
 
 		validationResult, err := h.validateGeneratedTests(ctx, session, tests)
 		if err != nil {
 			log.Printf("❌ Test validation failed on attempt %d: %v", attempt, err)
 			lastError = err
-
-			// Обновляем сообщение об ошибке валидации
-			h.updateMessage(chatID, messageID, fmt.Sprintf("[vibecoding] ❌ Валидация не прошла (попытка %d/%d): %s", attempt, maxAttempts, err.Error()))
-			time.Sleep(2 * time.Second)
+			progress.Fail(attempt, fmt.Errorf("попытка %d: валидация не прошла: %w", attempt, err))
 			continue
 		}
 
 		if validationResult.Success {
 			log.Printf("✅ All tests passed validation on attempt %d", attempt)
-
-			// Обновляем сообщение об успехе
-			successMsg := fmt.Sprintf("[vibecoding] ✅ Тесты успешно сгенерированы и проверены (попытка %d/%d)", attempt, maxAttempts)
-			h.updateMessage(chatID, messageID, successMsg)
-
+			progress.Finish(fmt.Sprintf("[vibecoding] ✅ Тесты успешно сгенерированы и проверены (попытка %d/%d)", attempt, maxAttempts))
 			return validationResult.ValidTests, nil
 		}
 
 		// Если валидация не прошла, пытаемся исправить тесты
 		if attempt < maxAttempts {
 			log.Printf("🔧 Attempting to fix test issues on attempt %d", attempt)
-
-			// Обновляем сообщение об исправлении
-			fixMsg := fmt.Sprintf("[vibecoding] 🔧 Исправление ошибок тестов... (попытка %d/%d)", attempt, maxAttempts)
-			h.updateMessage(chatID, messageID, fixMsg)
+			progress.Step(attempt, "исправление ошибок тестов")
 
 			fixedTests, err := h.fixTestIssues(ctx, session, tests, validationResult)
 			if err != nil {
 				log.Printf("⚠️ Could not fix test issues: %v", err)
 				lastError = fmt.Errorf("test fixing failed: %w", err)
-
-				h.updateMessage(chatID, messageID, fmt.Sprintf("[vibecoding] ⚠️ Не удалось исправить ошибки (попытка %d/%d)", attempt, maxAttempts))
-				time.Sleep(2 * time.Second)
+				progress.Fail(attempt, fmt.Errorf("попытка %d: не удалось исправить ошибки: %w", attempt, err))
 				continue
 			}
 			// Используем исправленные тесты для следующей итерации
 			tests = fixedTests
-
-			// Обновляем сообщение об успешном исправлении
-			h.updateMessage(chatID, messageID, fmt.Sprintf("[vibecoding] ✅ Ошибки исправлены, повтор валидации... (попытка %d/%d)", attempt, maxAttempts))
 		} else {
 			lastError = fmt.Errorf("test validation failed after %d attempts", maxAttempts)
 		}
@@ -790,15 +1362,14 @@ func (h *VibeCodingHandler) generateTestsWithProgress(ctx context.Context, sessi
 
 	// Если все попытки неудачны, возвращаем ошибку
 	log.Printf("❌ Test generation and validation failed after %d attempts", maxAttempts)
-	finalErrorMsg := fmt.Sprintf("[vibecoding] ❌ Не удалось сгенерировать тесты после %d попыток: %s", maxAttempts, lastError.Error())
-	h.updateMessage(chatID, messageID, finalErrorMsg)
+	progress.Finish(fmt.Sprintf("[vibecoding] ❌ Не удалось сгенерировать тесты после %d попыток: %s", maxAttempts, collapseError(lastError.Error())))
 
 	return nil, fmt.Errorf("test generation failed after %d attempts: %w", maxAttempts, lastError)
 }
 
 // generateTests генерирует тесты для проекта через JSON протокол с валидацией и исправлением (без Telegram updates)
 func (h *VibeCodingHandler) generateTests(ctx context.Context, session *VibeCodingSession) (map[string]string, error) {
-	maxAttempts := 5
+	maxAttempts := h.config.testGenAttempts()
 	var lastError error
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
@@ -877,7 +1448,7 @@ func (h *VibeCodingHandler) generateTestsOnce(ctx context.Context, session *Vibe
 		Context: VibeCodingContext{
 			ProjectName:     session.ProjectName,
 			Language:        session.Analysis.Language,
-			Files:           session.Files,
+			Files:           session.PromptFiles(),
 			GeneratedFiles:  session.GeneratedFiles,
 			SessionDuration: time.Since(session.StartTime).Round(time.Second).String(),
 		},
@@ -920,7 +1491,7 @@ func (h *VibeCodingHandler) buildProjectContext(session *VibeCodingSession) stri
 	var context strings.Builder
 	context.WriteString("⚠️ Project context not available, using file excerpts:\n\n")
 
-	for filename, content := range session.Files {
+	for filename, content := range session.PromptFiles() {
 		context.WriteString(fmt.Sprintf("\n=== %s ===\n", filename))
 
 		// Ограничиваем размер файла в контексте
@@ -936,171 +1507,43 @@ func (h *VibeCodingHandler) buildProjectContext(session *VibeCodingSession) stri
 	return context.String()
 }
 
-// buildCompressedContext строит сжатый LLM-генерируемый контекст для LLM
+// buildCompressedContext строит сжатый LLM-генерируемый контекст в пределах TokensLimit контекста
 func (h *VibeCodingHandler) buildCompressedContext(session *VibeCodingSession) string {
-	var context strings.Builder
-
-	ctx := session.Context
-
-	context.WriteString("# LLM-Generated Compressed Project Context\n\n")
-	context.WriteString(fmt.Sprintf("**Project:** %s | **Language:** %s | **Files:** %d | **Tokens:** %d/%d\n",
-		ctx.ProjectName, ctx.Language, ctx.TotalFiles, ctx.TokensUsed, ctx.TokensLimit))
-
-	if ctx.Description != "" {
-		context.WriteString(fmt.Sprintf("**Description:** %s\n", ctx.Description))
-	}
-	context.WriteString("\n")
-
-	// Краткая структура проекта
-	context.WriteString("## Project Structure:\n")
-	for i, dir := range ctx.Structure.Directories {
-		if i >= 5 { // Показываем только топ-5 директорий
-			context.WriteString(fmt.Sprintf("- ... и еще %d директорий\n", len(ctx.Structure.Directories)-5))
-			break
-		}
-		context.WriteString(fmt.Sprintf("- **%s** (%d files) - %s\n", dir.Path, dir.FileCount, dir.Purpose))
-	}
-	context.WriteString("\n")
-
-	// LLM-сгенерированные описания файлов
-	context.WriteString("## Key Files & LLM-Generated Descriptions:\n")
-	fileCount := 0
-	for filePath, fileCtx := range ctx.Files {
-		if fileCount >= 10 { // Показываем только первые 10 файлов
-			// Проверяем доступность MCP для рекомендации
-			mcpAvailable, _ := session.getMCPToolsInfo()
-			if mcpAvailable {
-				context.WriteString(fmt.Sprintf("- ... и еще %d файлов (используйте MCP tools для доступа)\n", len(ctx.Files)-10))
-			} else {
-				context.WriteString(fmt.Sprintf("- ... и еще %d файлов (MCP недоступен)\n", len(ctx.Files)-10))
-			}
-			break
-		}
-		fileCount++
-
-		context.WriteString(fmt.Sprintf("\n### %s (%s, %d bytes, %d tokens)\n", filePath, fileCtx.Type, fileCtx.Size, fileCtx.TokensUsed))
-
-		if fileCtx.Summary != "" {
-			context.WriteString(fmt.Sprintf("**Summary:** %s\n", fileCtx.Summary))
-		}
-
-		if fileCtx.Purpose != "" {
-			context.WriteString(fmt.Sprintf("**Purpose:** %s\n", fileCtx.Purpose))
-		}
-
-		// Показываем ключевые элементы (LLM-определенные)
-		if len(fileCtx.KeyElements) > 0 {
-			context.WriteString(fmt.Sprintf("**Key Elements (%d):** ", len(fileCtx.KeyElements)))
-			elementNames := make([]string, 0, len(fileCtx.KeyElements))
-			for j, element := range fileCtx.KeyElements {
-				if j >= 5 { // Показываем только первые 5 элементов
-					elementNames = append(elementNames, "...")
-					break
-				}
-				elementNames = append(elementNames, element)
-			}
-			context.WriteString(strings.Join(elementNames, ", ") + "\n")
-		}
-
-		// Зависимости файла
-		if len(fileCtx.Dependencies) > 0 {
-			context.WriteString(fmt.Sprintf("**Dependencies:** %s\n", strings.Join(fileCtx.Dependencies, ", ")))
-		}
-	}
-
-	// Проверяем доступность MCP для инструкций
 	mcpAvailable, mcpTools := session.getMCPToolsInfo()
-	if mcpAvailable {
-		context.WriteString("\n## 🔧 MCP Tools Available:\n")
-		for _, tool := range mcpTools {
-			context.WriteString(fmt.Sprintf("- `%s`\n", tool))
-		}
-		context.WriteString("\n**ВАЖНО:** Этот контекст генерируется LLM с ограничением токенов. ")
-		context.WriteString("Используйте MCP tools для получения полного содержимого файлов и выполнения операций. ")
-		context.WriteString("Для больших файлов LLM может запросить содержимое через MCP по мере необходимости.\n")
-
-		// Добавляем PROJECT_CONTEXT.md в сгенерированные файлы для доступа через MCP
-		if _, exists := session.GeneratedFiles["PROJECT_CONTEXT.md"]; exists {
-			context.WriteString("\n📋 Полный LLM-контекст доступен в файле PROJECT_CONTEXT.md (используйте vibe_read_file)\n")
-		}
-	} else {
-		context.WriteString("\n## ⚠️ MCP Server Not Available\n")
-		context.WriteString("MCP tools are not accessible in this session. Work only with the provided context information.\n\n")
-		context.WriteString("**ВАЖНО:** Этот контекст генерируется LLM с ограничением токенов. ")
-		context.WriteString("MCP сервер недоступен - работайте только с предоставленной информацией о файлах. ")
-		context.WriteString("Для получения дополнительной информации о файлах обратитесь к администратору.\n")
-
-		// PROJECT_CONTEXT.md недоступен без MCP
-		if _, exists := session.GeneratedFiles["PROJECT_CONTEXT.md"]; exists {
-			context.WriteString("\n📋 Полный LLM-контекст сохранен в файле PROJECT_CONTEXT.md (недоступен без MCP)\n")
-		}
-	}
-
-	return context.String()
+	_, hasContextFile := session.GeneratedFiles["PROJECT_CONTEXT.md"]
+
+	text, selection := renderCompressedContext(session.Context, session.PromptFiles(), session.Context.TokensLimit, compressedContextOptions{
+		MCPAvailable:   mcpAvailable,
+		MCPTools:       mcpTools,
+		HasContextFile: hasContextFile,
+	})
+	log.Printf("📋 Compressed context for user %d: %d files included, %d omitted, ~%d/%d tokens",
+		session.UserID, len(selection.Included), len(selection.Omitted), selection.TokensUsed, selection.Budget)
+	return text
 }
 
-// sendLongMessage отправляет длинное сообщение, разбивая его при необходимости
+// sendLongMessage отправляет длинное сообщение, разбивая его при необходимости (msgsplit):
+// блоки кода не рвутся, а не поместившийся остаток уходит файлом
 func (h *VibeCodingHandler) sendLongMessage(chatID int64, text string) error {
-	maxLength := 4000 // Оставляем немного места для заголовка
-
-	if len(text) <= maxLength {
-		msg := tgbotapi.NewMessage(chatID, h.formatter.EscapeText(text))
-		msg.ParseMode = h.formatter.ParseModeValue()
-		_, err := h.sender.Send(msg)
-		return err
-	}
-
-	// Разбиваем сообщение на части
-	parts := h.splitMessage(text, maxLength)
-	for i, part := range parts {
-		partText := part
-		if len(parts) > 1 {
-			partText = fmt.Sprintf("%s\n\n<i>Часть %d из %d</i>", part, i+1, len(parts))
-		}
-
-		msg := tgbotapi.NewMessage(chatID, h.formatter.EscapeText(partText))
-		msg.ParseMode = h.formatter.ParseModeValue()
-		_, err := h.sender.Send(msg)
-		if err != nil {
+	parts, overflow := msgsplit.Messages(chatID, text, msgsplit.Options{
+		Escape:    h.formatter.EscapeText,
+		ParseMode: h.formatter.ParseModeValue(),
+		FileName:  "vibecoding-answer.txt",
+	})
+	for i, msg := range parts {
+		if i > 0 {
+			time.Sleep(100 * time.Millisecond) // Небольшая задержка между сообщениями
+		}
+		if _, err := h.sender.Send(msg); err != nil {
 			return err
 		}
-
-		time.Sleep(100 * time.Millisecond) // Небольшая задержка между сообщениями
-	}
-
-	return nil
-}
-
-// splitMessage разбивает сообщение на логические части
-func (h *VibeCodingHandler) splitMessage(text string, maxLength int) []string {
-	if len(text) <= maxLength {
-		return []string{text}
 	}
-
-	var parts []string
-	remaining := text
-
-	for len(remaining) > maxLength {
-		// Ищем подходящее место для разрыва (конец строки, блока кода и т.д.)
-		breakPoint := maxLength
-
-		// Ищем последний перенос строки в пределах лимита
-		for i := maxLength - 1; i > maxLength/2; i-- {
-			if remaining[i] == '\n' {
-				breakPoint = i + 1
-				break
-			}
+	if overflow != nil {
+		if _, err := h.sender.Send(*overflow); err != nil {
+			return err
 		}
-
-		parts = append(parts, remaining[:breakPoint])
-		remaining = remaining[breakPoint:]
 	}
-
-	if len(remaining) > 0 {
-		parts = append(parts, remaining)
-	}
-
-	return parts
+	return nil
 }
 
 // TestValidationResult представляет результат валидации тестов
@@ -1114,7 +1557,7 @@ type TestValidationResult struct {
 // TestIssue представляет проблему в тесте
 type TestIssue struct {
 	Filename    string `json:"filename"`
-	Type        string `json:"type"` // "syntax_error", "runtime_error", "missing_dependency", "invalid_test"
+	Type        string `json:"type"` // TestFailureKind, "execution_error" или "configuration_error"
 	Description string `json:"description"`
 	Line        int    `json:"line,omitempty"`
 }
@@ -1265,27 +1708,12 @@ func (h *VibeCodingHandler) executeTestForValidation(ctx context.Context, sessio
 	if result.ExitCode != 0 {
 		log.Printf("❌ Test validation FAILED for %s with exit code %d", filename, result.ExitCode)
 
-		// Анализируем тип ошибки для более точной диагностики
-		errorType := "test_failure"
-		if strings.Contains(result.Output, "SyntaxError") ||
-			strings.Contains(result.Output, "IndentationError") ||
-			strings.Contains(result.Output, "compilation error") ||
-			strings.Contains(result.Output, "syntax error") {
-			errorType = "syntax_error"
-		} else if strings.Contains(result.Output, "ModuleNotFoundError") ||
-			strings.Contains(result.Output, "cannot find module") ||
-			strings.Contains(result.Output, "ImportError") ||
-			strings.Contains(result.Output, "package") && strings.Contains(result.Output, "not found") {
-			errorType = "missing_dependency"
-		} else if strings.Contains(result.Output, "NameError") ||
-			strings.Contains(result.Output, "AttributeError") ||
-			strings.Contains(result.Output, "is not defined") {
-			errorType = "invalid_reference"
-		}
+		// Определяем тип ошибки для более точной диагностики
+		classification := h.testFailureClassifier().Classify(ctx, command, result.ExitCode, result.Output)
 
 		return false, &TestIssue{
 			Filename:    filename,
-			Type:        errorType,
+			Type:        string(classification.Kind),
 			Description: fmt.Sprintf("Test failed with exit code %d: %s", result.ExitCode, result.Output),
 		}
 	}
@@ -1395,7 +1823,7 @@ Determine if the command can properly execute tests in this file.`, language, co
 		{Role: "user", Content: userPrompt},
 	}
 
-	response, err := h.llmClient.Generate(ctx, messages)
+	response, err := llm.GenerateWithOptions(ctx, h.llmClient, messages, llm.OptionsForTask(llm.TaskClassification))
 	if err != nil {
 		log.Printf("⚠️ LLM command suitability check failed for %s: %v, assuming suitable", filename, err)
 		return true // Fallback: assume suitable
@@ -1407,16 +1835,7 @@ Determine if the command can properly execute tests in this file.`, language, co
 		Reasoning  string `json:"reasoning"`
 	}
 
-	content := response.Content
-	if strings.Contains(content, "```json") {
-		start := strings.Index(content, "```json") + 7
-		end := strings.Index(content[start:], "```")
-		if end > 0 {
-			content = strings.TrimSpace(content[start : start+end])
-		}
-	}
-
-	if err := json.Unmarshal([]byte(content), &suitabilityResponse); err != nil {
+	if err := jsonextract.Decode(response.Content, &suitabilityResponse, jsonextract.Options{Repair: true}); err != nil {
 		log.Printf("⚠️ Failed to parse LLM suitability response for %s: %v, assuming suitable", filename, err)
 		return true
 	}
@@ -1457,7 +1876,7 @@ Modify the command to specifically target this test file while maintaining prope
 		{Role: "user", Content: userPrompt},
 	}
 
-	response, err := h.llmClient.Generate(ctx, messages)
+	response, err := llm.GenerateWithOptions(ctx, h.llmClient, messages, llm.OptionsForTask(llm.TaskClassification))
 	if err != nil {
 		log.Printf("⚠️ LLM command adaptation failed for %s: %v, using original command", filename, err)
 		return command // Fallback: use original command
@@ -1469,16 +1888,7 @@ Modify the command to specifically target this test file while maintaining prope
 		Reasoning      string `json:"reasoning"`
 	}
 
-	content := response.Content
-	if strings.Contains(content, "```json") {
-		start := strings.Index(content, "```json") + 7
-		end := strings.Index(content[start:], "```")
-		if end > 0 {
-			content = strings.TrimSpace(content[start : start+end])
-		}
-	}
-
-	if err := json.Unmarshal([]byte(content), &adaptationResponse); err != nil {
+	if err := jsonextract.Decode(response.Content, &adaptationResponse, jsonextract.Options{Repair: true}); err != nil {
 		log.Printf("⚠️ Failed to parse LLM adaptation response for %s: %v, using original command", filename, err)
 		return command
 	}
@@ -1514,7 +1924,7 @@ func (h *VibeCodingHandler) fixTestIssues(ctx context.Context, session *VibeCodi
 		Context: VibeCodingContext{
 			ProjectName:     session.ProjectName,
 			Language:        session.Analysis.Language,
-			Files:           session.Files,
+			Files:           session.PromptFiles(),
 			GeneratedFiles:  session.GeneratedFiles,
 			SessionDuration: time.Since(session.StartTime).Round(time.Second).String(),
 		},
@@ -1642,7 +2052,7 @@ Carefully cross-reference all test code against the available functions and clas
 		session.Analysis.Language,
 		session.Analysis.Language,
 		session.ProjectName,
-		h.formatProjectFilesForValidation(session.Files),
+		h.formatProjectFilesForValidation(session.PromptFiles()),
 		h.formatTestFilesForValidation(tests),
 		session.Analysis.Language)
 
@@ -1653,11 +2063,11 @@ Carefully cross-reference all test code against the available functions and clas
 
 	log.Printf("🔍 Requesting test validation from LLM")
 
-	maxAttempts := 3
+	maxAttempts := h.config.testValidationAttempts()
 	var lastError error
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		response, err := h.llmClient.Generate(ctx, messages)
+		response, err := llm.GenerateWithOptions(ctx, h.llmClient, messages, llm.OptionsForTask(llm.TaskClassification))
 		if err != nil {
 			lastError = fmt.Errorf("LLM validation request failed: %w", err)
 			log.Printf("❌ LLM validation attempt %d failed: %v", attempt, err)
@@ -1666,23 +2076,11 @@ Carefully cross-reference all test code against the available functions and clas
 
 		// Парсим JSON ответ
 		var validationResponse TestLLMValidationResponse
-		if err := json.Unmarshal([]byte(response.Content), &validationResponse); err != nil {
-			// Пытаемся извлечь JSON из markdown блока
-			content := response.Content
-			if strings.Contains(content, "```json") {
-				start := strings.Index(content, "```json") + 7
-				end := strings.Index(content[start:], "```")
-				if end > 0 {
-					content = strings.TrimSpace(content[start : start+end])
-				}
-			}
-
-			if err := json.Unmarshal([]byte(content), &validationResponse); err != nil {
-				lastError = fmt.Errorf("failed to parse LLM validation response: %w", err)
-				log.Printf("⚠️ Failed to parse LLM response attempt %d: %v", attempt, err)
-				log.Printf("Raw response: %s", response.Content)
-				continue
-			}
+		if err := jsonextract.Decode(response.Content, &validationResponse, jsonextract.Options{Repair: true}); err != nil {
+			lastError = fmt.Errorf("failed to parse LLM validation response: %w", err)
+			log.Printf("⚠️ Failed to parse LLM response attempt %d: %v", attempt, err)
+			log.Printf("Raw response: %s", response.Content)
+			continue
 		}
 
 		log.Printf("🔍 LLM validation result: status=%s, issues=%d", validationResponse.Status, len(validationResponse.Issues))
@@ -1885,6 +2283,11 @@ func (h *VibeCodingHandler) sendMessage(chatID int64, text string) error {
 	return err
 }
 
+// testFailureClassifier возвращает классификатор падений тестов поверх текущего LLM клиента
+func (h *VibeCodingHandler) testFailureClassifier() *TestFailureClassifier {
+	return NewTestFailureClassifier(h.llmClient)
+}
+
 // fixFailingTests исправляет проваливающиеся тесты через анализ вывода LLM
 func (h *VibeCodingHandler) fixFailingTests(ctx context.Context, session *VibeCodingSession, testResult *codevalidation.ValidationResult) error {
 	log.Printf("🔧 Analyzing failing tests for user %d", session.UserID)
@@ -1923,7 +2326,7 @@ Provide updated test files that will pass.`, testResult.ExitCode, testResult.Out
 		Context: VibeCodingContext{
 			ProjectName:     session.ProjectName,
 			Language:        session.Analysis.Language,
-			Files:           session.Files,
+			Files:           session.PromptFiles(),
 			GeneratedFiles:  session.GeneratedFiles,
 			SessionDuration: time.Since(session.StartTime).Round(time.Second).String(),
 		},
@@ -1952,13 +2355,15 @@ Provide updated test files that will pass.`, testResult.ExitCode, testResult.Out
 	}
 
 	// Обновляем тестовые файлы в сессии
+	updated := make(map[string]string, len(response.Code))
 	for filename, content := range response.Code {
-		session.AddGeneratedFile(filename, content)
-		log.Printf("🔧 Updated test file: %s (%d bytes)", filename, len(content))
+		target := session.AddGeneratedFile(filename, content)
+		updated[target] = content
+		log.Printf("🔧 Updated test file: %s (%d bytes)", target, len(content))
 	}
 
 	// Копируем обновленные файлы в контейнер
-	if err := session.Docker.CopyFilesToContainer(ctx, session.ContainerID, response.Code); err != nil {
+	if err := session.Docker.CopyFilesToContainer(ctx, session.ContainerID, updated); err != nil {
 		log.Printf("⚠️ Failed to copy fixed tests to container: %v", err)
 		return fmt.Errorf("failed to update tests in container: %w", err)
 	}
@@ -1989,7 +2394,7 @@ Provide specific commands or configuration changes needed to fix the execution e
 		Context: VibeCodingContext{
 			ProjectName:     session.ProjectName,
 			Language:        session.Analysis.Language,
-			Files:           session.Files,
+			Files:           session.PromptFiles(),
 			GeneratedFiles:  session.GeneratedFiles,
 			SessionDuration: time.Since(session.StartTime).Round(time.Second).String(),
 		},
@@ -2068,7 +2473,7 @@ Please determine if this filename follows test file naming conventions for %s.`,
 		{Role: "user", Content: userPrompt},
 	}
 
-	response, err := h.llmClient.Generate(ctx, messages)
+	response, err := llm.GenerateWithOptions(ctx, h.llmClient, messages, llm.OptionsForTask(llm.TaskClassification))
 	if err != nil {
 		log.Printf("⚠️ LLM test file detection failed for %s: %v, falling back to basic detection", filename, err)
 		// Fallback: очень базовое определение
@@ -2082,16 +2487,7 @@ Please determine if this filename follows test file naming conventions for %s.`,
 		Reasoning  string `json:"reasoning"`
 	}
 
-	content := response.Content
-	if strings.Contains(content, "```json") {
-		start := strings.Index(content, "```json") + 7
-		end := strings.Index(content[start:], "```")
-		if end > 0 {
-			content = strings.TrimSpace(content[start : start+end])
-		}
-	}
-
-	if err := json.Unmarshal([]byte(content), &testFileResponse); err != nil {
+	if err := jsonextract.Decode(response.Content, &testFileResponse, jsonextract.Options{Repair: true}); err != nil {
 		log.Printf("⚠️ Failed to parse LLM test file response for %s: %v, falling back to basic detection", filename, err)
 		return strings.Contains(strings.ToLower(filename), "test")
 	}
@@ -2160,7 +2556,7 @@ Generate a detailed prompt that will help an AI write tests that actually work a
 		session.Analysis.WorkingDir,
 		len(session.Analysis.InstallCommands),
 		len(session.Analysis.TestCommands),
-		h.extractProjectStructureForPrompt(session.Files),
+		h.extractProjectStructureForPrompt(session.PromptFiles()),
 		session.Analysis.Language,
 		session.Analysis.Language)
 
@@ -2171,7 +2567,7 @@ Generate a detailed prompt that will help an AI write tests that actually work a
 
 	log.Printf("🔍 Requesting specialized test prompt from LLM")
 
-	response, err := h.llmClient.Generate(ctx, messages)
+	response, err := llm.GenerateWithOptions(ctx, h.llmClient, messages, llm.OptionsForTask(llm.TaskGeneration))
 	if err != nil {
 		return "", fmt.Errorf("LLM test prompt generation failed: %w", err)
 	}
@@ -2186,16 +2582,7 @@ Generate a detailed prompt that will help an AI write tests that actually work a
 		CommonPitfalls   []string `json:"common_pitfalls"`
 	}
 
-	content := response.Content
-	if strings.Contains(content, "```json") {
-		start := strings.Index(content, "```json") + 7
-		end := strings.Index(content[start:], "```")
-		if end > 0 {
-			content = strings.TrimSpace(content[start : start+end])
-		}
-	}
-
-	if err := json.Unmarshal([]byte(content), &promptResponse); err != nil {
+	if err := jsonextract.Decode(response.Content, &promptResponse, jsonextract.Options{Repair: true}); err != nil {
 		log.Printf("⚠️ Failed to parse LLM prompt response: %v", err)
 		return "", fmt.Errorf("failed to parse test prompt response: %w", err)
 	}
@@ -2236,7 +2623,7 @@ Generate a detailed prompt that will help an AI write tests that actually work a
 	}
 
 	// Добавляем контекст проекта
-	finalPrompt.WriteString(fmt.Sprintf("\nPROJECT CONTEXT:\n%s", h.formatProjectFilesForValidation(session.Files)))
+	finalPrompt.WriteString(fmt.Sprintf("\nPROJECT CONTEXT:\n%s", h.formatProjectFilesForValidation(session.PromptFiles())))
 
 	log.Printf("✅ Generated specialized test prompt (%d chars) with framework: %s",
 		len(finalPrompt.String()), promptResponse.TestingFramework)
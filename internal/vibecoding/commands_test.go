@@ -2,7 +2,9 @@ package vibecoding
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"html"
 	"strings"
 	"testing"
 	"time"
@@ -15,6 +17,8 @@ import (
 
 // MockLLMClient для тестирования
 type MockLLMClient struct {
+	llm.NoStreaming
+	llm.LocalTokenCounter
 	responses   map[string]string
 	callCount   int
 	shouldError bool
@@ -149,6 +153,37 @@ func (m *MockMessageFormatter) ParseModeValue() string {
 	return "MarkdownV2"
 }
 
+// htmlFormatter экранирует текст как бот в режиме HTML
+type htmlFormatter struct{}
+
+func (htmlFormatter) EscapeText(text string) string { return html.EscapeString(text) }
+
+func (htmlFormatter) ParseModeValue() string { return tgbotapi.ModeHTML }
+
+func TestSendLongMessage_SplitsAndEscapesParts(t *testing.T) {
+	sender := &recordingSender{}
+	h := &VibeCodingHandler{sender: sender, formatter: htmlFormatter{}}
+	text := "Результат:\n\n```python\n" + strings.Repeat("if a < b and c > d: pass\n", 300) + "```"
+
+	if err := h.sendLongMessage(42, text); err != nil {
+		t.Fatalf("sendLongMessage: %v", err)
+	}
+	if len(sender.texts) < 2 {
+		t.Fatalf("expected several parts, got %d", len(sender.texts))
+	}
+	for i, part := range sender.texts {
+		if n := len([]rune(part)); n > 4096 {
+			t.Errorf("part %d is %d characters long", i, n)
+		}
+		if strings.Contains(part, "a < b") || strings.Count(part, "```")%2 != 0 {
+			t.Errorf("part %d must be escaped and keep code blocks balanced:\n%s", i, part)
+		}
+		if !strings.Contains(part, fmt.Sprintf("Часть %d из %d", i+1, len(sender.texts))) {
+			t.Errorf("part %d has no counter", i)
+		}
+	}
+}
+
 // TestGenerateTestWritingPrompt тестирует генерацию специализированного промпта
 func TestGenerateTestWritingPrompt(t *testing.T) {
 	mockLLM := NewMockLLMClient()
@@ -584,6 +619,49 @@ func TestHandleInfoCommand_WithContext(t *testing.T) {
 	}
 }
 
+// blockingLLM отвечает только после отмены контекста запроса
+type blockingLLM struct {
+	llm.NoStreaming
+	llm.LocalTokenCounter
+}
+
+func (c *blockingLLM) Generate(ctx context.Context, messages []llm.Message) (llm.Response, error) {
+	<-ctx.Done()
+	return llm.Response{}, ctx.Err()
+}
+
+func (c *blockingLLM) GenerateWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (llm.Response, error) {
+	return c.Generate(ctx, messages)
+}
+
+func TestVibeCodingHandler_SetConfigLimitsProtocolLLMTimeout(t *testing.T) {
+	handler := NewVibeCodingHandler(&MockTelegramSender{}, &MockMessageFormatter{}, &blockingLLM{})
+	defer handler.Close()
+	handler.SetConfig(VibeCodingConfig{LLMTimeout: 50 * time.Millisecond})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := handler.protocolClient.ProcessRequest(context.Background(), VibeCodingRequest{
+			Action: "answer_question",
+			Query:  "what does main.py do?",
+			Context: VibeCodingContext{
+				ProjectName: "demo",
+				Files:       map[string]string{"main.py": "print(1)"},
+			},
+		})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the LLM timeout, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("protocol request ignored VIBECODING_LLM_TIMEOUT")
+	}
+}
+
 // BenchmarkGenerateTestWritingPrompt бенчмарк для генерации промптов
 func BenchmarkGenerateTestWritingPrompt(b *testing.B) {
 	mockLLM := NewMockLLMClient()
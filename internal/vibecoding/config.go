@@ -0,0 +1,149 @@
+package vibecoding
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ai-chatter/internal/config"
+	"ai-chatter/internal/llm"
+)
+
+const (
+	defaultMaxTestFixAttempts        = 3
+	defaultMaxTestGenAttempts        = 5
+	defaultMaxTestValidationAttempts = 3
+)
+
+// ErrCommandTimeout возвращается, если команда в контейнере не уложилась в таймаут
+var ErrCommandTimeout = errors.New("command timed out")
+
+// VibeCodingConfig настройки циклов исправления тестов и таймаутов.
+// Нулевые значения означают поведение по умолчанию (без таймаутов)
+type VibeCodingConfig struct {
+	MaxTestFixAttempts        int           // попытки запуска/исправления тестов в /vibecoding_test
+	MaxTestGenAttempts        int           // попытки генерации тестов
+	MaxTestValidationAttempts int           // попытки LLM валидации тестов
+	CommandTimeout            time.Duration // таймаут одной команды в контейнере
+	LLMTimeout                time.Duration // таймаут одного LLM запроса
+	IdleTimeout               time.Duration // автозакрытие сессии без активности: 0 — 30 минут, < 0 — отключено
+	RecordDir                 string        // каталог записей автономных запусков для cmd/vibecoding-replay; пусто — не записывать
+	PromptPricePerMillion     float64       // цена миллиона prompt токенов в USD для EstimatedCostUSD
+	CompletionPricePerMillion float64       // цена миллиона completion токенов в USD
+	MatrixParallelism         int           // одновременных контейнеров /vibecoding_matrix, 0 — 2
+	MatrixContainerBudget     int           // контейнеров матрицы на сессию, 0 — 10
+	ContextWindow             int           // окно контекста модели в токенах, 0 — 128k
+	GitHubToken               string        // GITHUB_TOKEN для /vibecoding_clone приватных репозиториев и /vibecoding_push
+	ContainerGCInterval       time.Duration // период сборки осиротевших контейнеров, 0 — только при запуске
+	ContainerGCGrace          time.Duration // возраст осиротевшего контейнера до удаления, 0 — 1 час
+}
+
+// NewVibeCodingConfig создаёт настройки VibeCoding из общей конфигурации
+func NewVibeCodingConfig(cfg *config.Config) VibeCodingConfig {
+	return VibeCodingConfig{
+		MaxTestFixAttempts:        cfg.VibeCodingMaxTestFixAttempts,
+		MaxTestGenAttempts:        cfg.VibeCodingMaxTestGenAttempts,
+		MaxTestValidationAttempts: cfg.VibeCodingMaxTestValidationAttempts,
+		CommandTimeout:            cfg.VibeCodingCommandTimeout,
+		LLMTimeout:                cfg.VibeCodingLLMTimeout,
+		IdleTimeout:               idleTimeoutFromEnv(cfg.VibeCodingIdleTimeout),
+		RecordDir:                 cfg.VibeCodingRecordDir,
+		PromptPricePerMillion:     cfg.VibeCodingPromptPricePerMillion,
+		CompletionPricePerMillion: cfg.VibeCodingCompletionPricePerMillion,
+		MatrixParallelism:         cfg.VibeCodingMatrixParallelism,
+		MatrixContainerBudget:     cfg.VibeCodingMatrixContainerBudget,
+		ContextWindow:             contextWindowFromConfig(cfg),
+		ContainerGCInterval:       cfg.VibeCodingContainerGCInterval,
+		ContainerGCGrace:          cfg.VibeCodingContainerGCGrace,
+	}
+}
+
+// contextWindowFromConfig VIBECODING_CONTEXT_WINDOW или окно модели провайдера по умолчанию
+func contextWindowFromConfig(cfg *config.Config) int {
+	if cfg.VibeCodingContextWindow > 0 {
+		return cfg.VibeCodingContextWindow
+	}
+	switch cfg.LLMProvider {
+	case config.ProviderAnthropic:
+		return llm.ContextWindow(cfg.AnthropicModel)
+	case config.ProviderYandex:
+		return llm.ContextWindow("yandexgpt")
+	default:
+		return llm.ContextWindow(cfg.OpenAIModel)
+	}
+}
+
+// idleTimeoutFromEnv VIBECODING_IDLE_TIMEOUT=0 отключает автозакрытие
+func idleTimeoutFromEnv(d time.Duration) time.Duration {
+	if d <= 0 {
+		return -1
+	}
+	return d
+}
+
+func (c VibeCodingConfig) contextWindow() int {
+	if c.ContextWindow > 0 {
+		return c.ContextWindow
+	}
+	return llm.ContextWindow("")
+}
+
+func (c VibeCodingConfig) testFixAttempts() int {
+	if c.MaxTestFixAttempts > 0 {
+		return c.MaxTestFixAttempts
+	}
+	return defaultMaxTestFixAttempts
+}
+
+func (c VibeCodingConfig) testGenAttempts() int {
+	if c.MaxTestGenAttempts > 0 {
+		return c.MaxTestGenAttempts
+	}
+	return defaultMaxTestGenAttempts
+}
+
+func (c VibeCodingConfig) testValidationAttempts() int {
+	if c.MaxTestValidationAttempts > 0 {
+		return c.MaxTestValidationAttempts
+	}
+	return defaultMaxTestValidationAttempts
+}
+
+// timeoutLLMClient ограничивает время каждого LLM запроса
+type timeoutLLMClient struct {
+	inner   llm.Client
+	timeout time.Duration
+}
+
+// withLLMTimeout оборачивает клиент таймаутом; при timeout <= 0 возвращает клиент как есть
+func withLLMTimeout(client llm.Client, timeout time.Duration) llm.Client {
+	if client == nil || timeout <= 0 {
+		return client
+	}
+	if wrapped, ok := client.(*timeoutLLMClient); ok {
+		client = wrapped.inner
+	}
+	return &timeoutLLMClient{inner: client, timeout: timeout}
+}
+
+func (c *timeoutLLMClient) Generate(ctx context.Context, messages []llm.Message) (llm.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.inner.Generate(ctx, messages)
+}
+
+func (c *timeoutLLMClient) GenerateWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (llm.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.inner.GenerateWithTools(ctx, messages, tools)
+}
+
+func (c *timeoutLLMClient) GenerateStream(ctx context.Context, messages []llm.Message, onDelta llm.StreamFunc) (llm.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.inner.GenerateStream(ctx, messages, onDelta)
+}
+
+func (c *timeoutLLMClient) CountTokens(messages []llm.Message) (int, error) {
+	return c.inner.CountTokens(messages)
+}
@@ -0,0 +1,219 @@
+package vibecoding
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Метки контейнеров VibeCoding; сборщик удаляет только контейнеры с LabelVibeCoding=1
+const (
+	LabelVibeCoding = "ai-chatter.vibecoding"
+	LabelUserID     = "ai-chatter.user_id"
+	LabelSessionID  = "ai-chatter.session_id"
+	LabelCreatedAt  = "ai-chatter.created_at"
+)
+
+const (
+	// defaultContainerGCGrace возраст, после которого контейнер без сессии считается осиротевшим
+	defaultContainerGCGrace = time.Hour
+	// containerGCTimeout ограничение одного прохода сборщика
+	containerGCTimeout = 5 * time.Minute
+)
+
+// ContainerOwner сессия, которой принадлежит контейнер; нулевое значение — контейнер без сессии
+type ContainerOwner struct {
+	UserID    int64
+	SessionID string
+}
+
+// labels метки контейнера, созданного в момент now
+func (o ContainerOwner) labels(now time.Time) map[string]string {
+	labels := map[string]string{
+		LabelVibeCoding: "1",
+		LabelCreatedAt:  now.UTC().Format(time.RFC3339),
+	}
+	if o.UserID != 0 {
+		labels[LabelUserID] = strconv.FormatInt(o.UserID, 10)
+	}
+	if o.SessionID != "" {
+		labels[LabelSessionID] = o.SessionID
+	}
+	return labels
+}
+
+// SessionID идентификатор сессии: пользователь и время начала, не меняется при восстановлении из снимка
+func (s *VibeCodingSession) SessionID() string {
+	return fmt.Sprintf("%d-%d", s.UserID, s.StartTime.UnixNano())
+}
+
+// containerOwner владелец контейнеров сессии для меток
+func (s *VibeCodingSession) containerOwner() ContainerOwner {
+	return ContainerOwner{UserID: s.UserID, SessionID: s.SessionID()}
+}
+
+// ContainerGCReport итог одного прохода сборщика осиротевших контейнеров
+type ContainerGCReport struct {
+	Removed        int   // удалено осиротевших контейнеров
+	ReclaimedBytes int64 // освобождено места (записываемые слои удалённых контейнеров)
+	Kept           int   // контейнеры живых и восстанавливаемых сессий, а также моложе grace периода
+	Failed         int   // осиротевшие контейнеры, которые не удалось удалить
+}
+
+// ContainerGCFunc вызывается после прохода сборщика, удалившего хотя бы один контейнер или
+// столкнувшегося с ошибками удаления (например, чтобы сообщить администратору)
+type ContainerGCFunc func(report ContainerGCReport)
+
+// containerGC фоновая горутина, удаляющая осиротевшие контейнеры
+type containerGC struct {
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func (c VibeCodingConfig) containerGCGrace() time.Duration {
+	if c.ContainerGCGrace > 0 {
+		return c.ContainerGCGrace
+	}
+	return defaultContainerGCGrace
+}
+
+// SetContainerGCHandler задаёт уведомление об удалённых осиротевших контейнерах
+func (sm *SessionManager) SetContainerGCHandler(f ContainerGCFunc) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	sm.onContainerGC = f
+}
+
+// StartContainerGC сразу собирает осиротевшие контейнеры и затем повторяет сборку каждые interval
+// (interval <= 0 — только при запуске). Вызывается после RestoreSessions, чтобы контейнеры
+// восстанавливаемых сессий не считались осиротевшими
+func (sm *SessionManager) StartContainerGC(docker *DockerAdapter, interval time.Duration) {
+	sm.mutex.Lock()
+	if sm.gc != nil {
+		sm.mutex.Unlock()
+		return
+	}
+	gc := &containerGC{stop: make(chan struct{}), done: make(chan struct{})}
+	sm.gc = gc
+	sm.mutex.Unlock()
+
+	go func() {
+		defer close(gc.done)
+		sm.runContainerGC(docker)
+		if interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-gc.stop:
+				return
+			case <-ticker.C:
+				sm.runContainerGC(docker)
+			}
+		}
+	}()
+}
+
+// runContainerGC один проход сборщика с уведомлением через SetContainerGCHandler
+func (sm *SessionManager) runContainerGC(docker *DockerAdapter) {
+	ctx, cancel := context.WithTimeout(context.Background(), containerGCTimeout)
+	defer cancel()
+	report, err := sm.CollectOrphanedContainers(ctx, docker, time.Now())
+	if err != nil {
+		log.Printf("⚠️ VibeCoding container GC skipped: %v", err)
+		return
+	}
+	if report.Removed == 0 && report.Failed == 0 {
+		return
+	}
+	log.Printf("🧹 VibeCoding container GC: removed %d orphaned containers (%d bytes), %d failed, %d kept",
+		report.Removed, report.ReclaimedBytes, report.Failed, report.Kept)
+	sm.mutex.RLock()
+	onGC := sm.onContainerGC
+	sm.mutex.RUnlock()
+	if onGC != nil {
+		onGC(report)
+	}
+}
+
+// CollectOrphanedContainers удаляет контейнеры с меткой LabelVibeCoding=1, которые не принадлежат ни живой,
+// ни сохранённой в хранилище сессии и созданы раньше, чем now минус ContainerGCGrace.
+// Контейнеры без метки не затрагиваются
+func (sm *SessionManager) CollectOrphanedContainers(ctx context.Context, docker *DockerAdapter, now time.Time) (ContainerGCReport, error) {
+	var report ContainerGCReport
+	// Контейнеры читаются до списка сессий: сессия, созданная между шагами, окажется в списке,
+	// а её контейнер, появившийся позже, в проход не попадёт
+	containers, err := docker.ListContainers(ctx, LabelVibeCoding+"=1")
+	if err != nil {
+		return report, err
+	}
+
+	sm.mutex.RLock()
+	grace := sm.config.containerGCGrace()
+	store := sm.store
+	ownedSessions := make(map[string]bool, len(sm.sessions))
+	ownedContainers := make(map[string]bool, len(sm.sessions))
+	for _, session := range sm.sessions {
+		ownedSessions[session.SessionID()] = true
+		if id := session.ContainerID; id != "" {
+			ownedContainers[id] = true
+		}
+	}
+	sm.mutex.RUnlock()
+	if store != nil {
+		// Без списка сохранённых сессий нельзя отличить осиротевший контейнер от восстанавливаемого
+		saved, err := store.LoadAll()
+		if err != nil {
+			return report, fmt.Errorf("load saved sessions: %w", err)
+		}
+		for _, session := range saved {
+			ownedSessions[session.SessionID()] = true
+		}
+	}
+
+	for _, container := range containers {
+		if container.Labels[LabelVibeCoding] != "1" {
+			continue
+		}
+		if ownedContainers[container.ID] || ownedSessions[container.Labels[LabelSessionID]] {
+			report.Kept++
+			continue
+		}
+		createdAt := container.CreatedAt
+		if labeled, err := time.Parse(time.RFC3339, container.Labels[LabelCreatedAt]); err == nil {
+			createdAt = labeled
+		}
+		if createdAt.IsZero() || now.Sub(createdAt) < grace {
+			report.Kept++
+			continue
+		}
+		if err := docker.RemoveContainer(ctx, container.ID); err != nil {
+			log.Printf("⚠️ Failed to remove orphaned container %s: %v", container.ID, err)
+			report.Failed++
+			continue
+		}
+		log.Printf("🧹 Removed orphaned vibecoding container %s (user %s, session %s)",
+			container.ID, container.Labels[LabelUserID], container.Labels[LabelSessionID])
+		report.Removed++
+		report.ReclaimedBytes += container.SizeBytes
+	}
+	return report, nil
+}
+
+// stopContainerGC останавливает периодическую сборку и ждёт завершения текущего прохода
+func (sm *SessionManager) stopContainerGC() {
+	sm.mutex.RLock()
+	gc := sm.gc
+	sm.mutex.RUnlock()
+	if gc == nil {
+		return
+	}
+	gc.stopOnce.Do(func() { close(gc.stop) })
+	<-gc.done
+}
@@ -0,0 +1,145 @@
+package vibecoding
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ai-chatter/internal/codevalidation"
+)
+
+// labeledDocker DockerManager с метками и списком контейнеров; удалённые контейнеры запоминаются
+type labeledDocker struct {
+	codevalidation.DockerManager
+	mu         sync.Mutex
+	labels     map[string]string
+	containers []codevalidation.ContainerInfo
+	removed    []string
+}
+
+func (d *labeledDocker) CreateLabeledContainer(ctx context.Context, analysis *codevalidation.CodeAnalysisResult, labels map[string]string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.labels = labels
+	return "labeled-container", nil
+}
+
+func (d *labeledDocker) ListContainers(ctx context.Context, label string) ([]codevalidation.ContainerInfo, error) {
+	if label != LabelVibeCoding+"=1" {
+		return nil, nil
+	}
+	return d.containers, nil
+}
+
+func (d *labeledDocker) RemoveContainer(ctx context.Context, containerID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.removed = append(d.removed, containerID)
+	return nil
+}
+
+func (d *labeledDocker) removedIDs() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ids := append([]string(nil), d.removed...)
+	sort.Strings(ids)
+	return ids
+}
+
+func TestDockerAdapter_CreateContainerLabelsOwner(t *testing.T) {
+	docker := &labeledDocker{DockerManager: codevalidation.NewMockDockerClient()}
+	session := &VibeCodingSession{UserID: 42, StartTime: time.Unix(1700000000, 5)}
+
+	id, err := NewDockerAdapter(docker).CreateContainer(context.Background(), &codevalidation.CodeAnalysisResult{DockerImage: "golang:1.23"}, session.containerOwner())
+	if err != nil || id != "labeled-container" {
+		t.Fatalf("CreateContainer = %q, %v", id, err)
+	}
+	if docker.labels[LabelVibeCoding] != "1" || docker.labels[LabelUserID] != "42" || docker.labels[LabelSessionID] != "42-1700000000000000005" {
+		t.Errorf("unexpected labels: %v", docker.labels)
+	}
+	if _, err := time.Parse(time.RFC3339, docker.labels[LabelCreatedAt]); err != nil {
+		t.Errorf("created-at label: %v", err)
+	}
+}
+
+func TestSessionManager_CollectOrphanedContainers(t *testing.T) {
+	store, err := NewFileSessionStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileSessionStore: %v", err)
+	}
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+	sm.SetConfig(VibeCodingConfig{ContainerGCGrace: time.Hour})
+	if _, err := sm.RestoreSessions(store, nil); err != nil {
+		t.Fatalf("RestoreSessions: %v", err)
+	}
+	live, err := sm.CreateSession(1, 10, "live", map[string]string{"main.go": "package main"}, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	live.ContainerID = "live-current"
+	// Сессия сохранена, но ещё не восстановлена этим менеджером
+	saved := &VibeCodingSession{UserID: 2, ChatID: 20, ProjectName: "saved", StartTime: time.Now().Add(-3 * time.Hour)}
+	if err := store.Save(saved); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	now := time.Now()
+	old := now.Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	ours := func(sessionID, createdAt string) map[string]string {
+		return map[string]string{LabelVibeCoding: "1", LabelSessionID: sessionID, LabelCreatedAt: createdAt}
+	}
+	docker := &labeledDocker{DockerManager: codevalidation.NewMockDockerClient(), containers: []codevalidation.ContainerInfo{
+		{ID: "live-current", Labels: ours("", old)},
+		{ID: "live-matrix", Labels: ours(live.SessionID(), old)},
+		{ID: "saved-old", Labels: ours(saved.SessionID(), old)},
+		{ID: "orphan", Labels: ours("9-1", old), SizeBytes: 1000},
+		{ID: "orphan-docker-time", Labels: map[string]string{LabelVibeCoding: "1"}, CreatedAt: now.Add(-5 * time.Hour), SizeBytes: 500},
+		{ID: "young", Labels: ours("9-2", now.Add(-10*time.Minute).UTC().Format(time.RFC3339))},
+		{ID: "foreign", Labels: map[string]string{"com.example.app": "db"}, CreatedAt: now.Add(-48 * time.Hour)},
+	}}
+
+	report, err := sm.CollectOrphanedContainers(context.Background(), NewDockerAdapter(docker), now)
+	if err != nil {
+		t.Fatalf("CollectOrphanedContainers: %v", err)
+	}
+	if got := strings.Join(docker.removedIDs(), ","); got != "orphan,orphan-docker-time" {
+		t.Errorf("removed = %s", got)
+	}
+	want := ContainerGCReport{Removed: 2, ReclaimedBytes: 1500, Kept: 4}
+	if report != want {
+		t.Errorf("report = %+v, want %+v", report, want)
+	}
+}
+
+func TestSessionManager_StartContainerGCReports(t *testing.T) {
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+	docker := &labeledDocker{DockerManager: codevalidation.NewMockDockerClient(), containers: []codevalidation.ContainerInfo{
+		{ID: "orphan", Labels: map[string]string{LabelVibeCoding: "1"}, CreatedAt: time.Now().Add(-2 * time.Hour), SizeBytes: 2048},
+	}}
+	reports := make(chan ContainerGCReport, 1)
+	sm.SetContainerGCHandler(func(report ContainerGCReport) { reports <- report })
+	sm.StartContainerGC(NewDockerAdapter(docker), 0)
+
+	select {
+	case report := <-reports:
+		if report.Removed != 1 || report.ReclaimedBytes != 2048 {
+			t.Errorf("unexpected report: %+v", report)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("container GC did not report at startup")
+	}
+}
+
+func TestSessionManager_CollectOrphanedContainersWithoutLister(t *testing.T) {
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+	docker := &commandRecordingDocker{DockerManager: codevalidation.NewMockDockerClient()}
+	if _, err := sm.CollectOrphanedContainers(context.Background(), NewDockerAdapter(docker), time.Now()); err == nil {
+		t.Error("expected an error when the docker manager cannot list containers")
+	}
+}
@@ -0,0 +1,290 @@
+package vibecoding
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"sort"
+	"strings"
+
+	"ai-chatter/internal/llm"
+)
+
+// defaultContextTokenBudget бюджет сжатого контекста, если TokensLimit не задан
+const defaultContextTokenBudget = 5000
+
+// promptWindowShare доля окна модели, которую может занять промпт; остаток — на ответ
+const promptWindowShare = 0.9
+
+// ContextSelection какие описания файлов вошли в сжатый контекст, а какие не поместились в бюджет
+type ContextSelection struct {
+	Budget     int      // бюджет токенов (TokensLimit контекста)
+	TokensUsed int      // оценка токенов итогового текста
+	Included   []string // файлы с описанием в контексте, по убыванию важности
+	Omitted    []string // файлы, не поместившиеся в бюджет; модель читает их через MCP
+}
+
+// compressedContextOptions окружение, от которого зависят подсказки в конце контекста
+type compressedContextOptions struct {
+	MCPAvailable   bool
+	MCPTools       []string
+	HasContextFile bool // PROJECT_CONTEXT.md есть среди сгенерированных файлов
+}
+
+// rankContextFiles файлы контекста по убыванию важности: сначала число зависящих файлов
+// в графе импортов, затем эвристика calculateFileImportance, затем путь
+func rankContextFiles(pc *ProjectContextLLM, contents map[string]string) []string {
+	centrality := make(map[string]int)
+	if pc.DependencyGraph != nil {
+		for _, fc := range pc.DependencyGraph.Ranking {
+			centrality[fc.Path] = fc.TransitiveDependents
+		}
+	}
+	gen := &LLMContextGenerator{}
+	score := make(map[string]int, len(pc.Files))
+	paths := make([]string, 0, len(pc.Files))
+	for path := range pc.Files {
+		paths = append(paths, path)
+		score[path] = gen.calculateFileImportance(path, contents[path])
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		a, b := paths[i], paths[j]
+		if centrality[a] != centrality[b] {
+			return centrality[a] > centrality[b]
+		}
+		if score[a] != score[b] {
+			return score[a] > score[b]
+		}
+		return a < b
+	})
+	return paths
+}
+
+// renderCompressedContext собирает сжатый контекст в пределах бюджета токенов (chars/4):
+// заголовок и инструкции обязательны, каталоги занимают не больше пятой части остатка,
+// описания файлов добавляются жадно по важности — не поместившееся пропускается, следующие
+// файлы ещё могут войти. Не вошедшие файлы перечисляются в подсказке для чтения через MCP
+func renderCompressedContext(pc *ProjectContextLLM, contents map[string]string, budget int, opts compressedContextOptions) (string, ContextSelection) {
+	if budget <= 0 {
+		budget = defaultContextTokenBudget
+	}
+	selection := ContextSelection{Budget: budget}
+
+	var header strings.Builder
+	header.WriteString("# LLM-Generated Compressed Project Context\n\n")
+	header.WriteString(fmt.Sprintf("**Project:** %s | **Language:** %s | **Files:** %d | **Tokens:** %d/%d\n",
+		pc.ProjectName, pc.Language, pc.TotalFiles, pc.TokensUsed, pc.TokensLimit))
+	if pc.Description != "" {
+		header.WriteString(fmt.Sprintf("**Description:** %s\n", pc.Description))
+	}
+	header.WriteString("\n")
+	footer := compressedContextFooter(opts)
+
+	// Подсказке о пропущенных файлах оставляем запас, чтобы список имён не съел описания
+	hintReserve := min(budget/10, 200)
+	remaining := budget - sectionTokens(header.String()+footer) - hintReserve
+
+	// Краткая структура проекта: крупные каталоги первыми
+	dirs := append([]Directory(nil), pc.Structure.Directories...)
+	sort.SliceStable(dirs, func(i, j int) bool { return dirs[i].FileCount > dirs[j].FileCount })
+	var structure strings.Builder
+	structure.WriteString("## Project Structure:\n")
+	dirBudget := remaining / 5
+	shownDirs := 0
+	for _, dir := range dirs {
+		line := fmt.Sprintf("- **%s** (%d files) - %s\n", dir.Path, dir.FileCount, dir.Purpose)
+		cost := sectionTokens(line)
+		if cost > dirBudget {
+			break
+		}
+		dirBudget -= cost
+		structure.WriteString(line)
+		shownDirs++
+	}
+	if hidden := len(dirs) - shownDirs; hidden > 0 {
+		structure.WriteString(fmt.Sprintf("- ... и еще %d директорий\n", hidden))
+	}
+	structure.WriteString("\n")
+	remaining -= sectionTokens(structure.String())
+
+	// LLM-сгенерированные описания файлов
+	var filesSection strings.Builder
+	filesSection.WriteString("## Key Files & LLM-Generated Descriptions:\n")
+	remaining -= sectionTokens(filesSection.String())
+	for _, path := range rankContextFiles(pc, contents) {
+		section := formatFileContextSection(path, pc.Files[path])
+		cost := sectionTokens(section)
+		if cost > remaining {
+			selection.Omitted = append(selection.Omitted, path)
+			continue
+		}
+		remaining -= cost
+		filesSection.WriteString(section)
+		selection.Included = append(selection.Included, path)
+	}
+	if len(selection.Omitted) > 0 {
+		filesSection.WriteString(omittedFilesHint(selection.Omitted, remaining+hintReserve, opts.MCPAvailable))
+	}
+
+	text := header.String() + structure.String() + filesSection.String() + footer
+	selection.TokensUsed = (&TokenEstimator{}).EstimateTokens(text)
+	return text, selection
+}
+
+// sectionTokens оценка токенов части контекста как в TokenEstimator, но с округлением вверх:
+// сумма оценок частей не меньше оценки всего текста, поэтому бюджет не превышается
+func sectionTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// formatFileContextSection описание одного файла в сжатом контексте
+func formatFileContextSection(filePath string, fileCtx LLMFileContext) string {
+	var section strings.Builder
+	section.WriteString(fmt.Sprintf("\n### %s (%s, %d bytes, %d tokens)\n", filePath, fileCtx.Type, fileCtx.Size, fileCtx.TokensUsed))
+
+	if fileCtx.Summary != "" {
+		section.WriteString(fmt.Sprintf("**Summary:** %s\n", fileCtx.Summary))
+	}
+
+	if fileCtx.Purpose != "" {
+		section.WriteString(fmt.Sprintf("**Purpose:** %s\n", fileCtx.Purpose))
+	}
+
+	// Показываем ключевые элементы (LLM-определенные)
+	if len(fileCtx.KeyElements) > 0 {
+		section.WriteString(fmt.Sprintf("**Key Elements (%d):** ", len(fileCtx.KeyElements)))
+		elementNames := make([]string, 0, len(fileCtx.KeyElements))
+		for j, element := range fileCtx.KeyElements {
+			if j >= 5 { // Показываем только первые 5 элементов
+				elementNames = append(elementNames, "...")
+				break
+			}
+			elementNames = append(elementNames, element)
+		}
+		section.WriteString(strings.Join(elementNames, ", ") + "\n")
+	}
+
+	// Зависимости файла
+	if len(fileCtx.Dependencies) > 0 {
+		section.WriteString(fmt.Sprintf("**Dependencies:** %s\n", strings.Join(fileCtx.Dependencies, ", ")))
+	}
+	return section.String()
+}
+
+// omittedFilesHint перечисляет не вошедшие файлы, пока хватает бюджета, остальные — числом
+func omittedFilesHint(omitted []string, budget int, mcpAvailable bool) string {
+	var hint strings.Builder
+	if mcpAvailable {
+		hint.WriteString(fmt.Sprintf("\n**Описания не вошли в бюджет токенов (%d файлов), читайте их через vibe_read_file:** ", len(omitted)))
+	} else {
+		hint.WriteString(fmt.Sprintf("\n**Описания не вошли в бюджет токенов (%d файлов, MCP недоступен):** ", len(omitted)))
+	}
+	// Запас на хвост "... и еще N" и перевод строки
+	budget -= sectionTokens(hint.String()) + sectionTokens(fmt.Sprintf(", ... и еще %d\n", len(omitted)))
+
+	listed := 0
+	for _, path := range omitted {
+		item := path
+		if listed > 0 {
+			item = ", " + path
+		}
+		cost := sectionTokens(item)
+		if cost > budget {
+			break
+		}
+		budget -= cost
+		hint.WriteString(item)
+		listed++
+	}
+	if rest := len(omitted) - listed; rest > 0 {
+		if listed > 0 {
+			hint.WriteString(", ")
+		}
+		hint.WriteString(fmt.Sprintf("... и еще %d", rest))
+	}
+	hint.WriteString("\n")
+	return hint.String()
+}
+
+// compressedContextFooter инструкции о доступе к файлам в зависимости от доступности MCP
+func compressedContextFooter(opts compressedContextOptions) string {
+	var footer strings.Builder
+	if opts.MCPAvailable {
+		footer.WriteString("\n## 🔧 MCP Tools Available:\n")
+		for _, tool := range opts.MCPTools {
+			footer.WriteString(fmt.Sprintf("- `%s`\n", tool))
+		}
+		footer.WriteString("\n**ВАЖНО:** Этот контекст генерируется LLM с ограничением токенов. ")
+		footer.WriteString("Используйте MCP tools для получения полного содержимого файлов и выполнения операций. ")
+		footer.WriteString("Для больших файлов LLM может запросить содержимое через MCP по мере необходимости.\n")
+
+		if opts.HasContextFile {
+			footer.WriteString("\n📋 Полный LLM-контекст доступен в файле PROJECT_CONTEXT.md (используйте vibe_read_file)\n")
+		}
+		return footer.String()
+	}
+
+	footer.WriteString("\n## ⚠️ MCP Server Not Available\n")
+	footer.WriteString("MCP tools are not accessible in this session. Work only with the provided context information.\n\n")
+	footer.WriteString("**ВАЖНО:** Этот контекст генерируется LLM с ограничением токенов. ")
+	footer.WriteString("MCP сервер недоступен - работайте только с предоставленной информацией о файлах. ")
+	footer.WriteString("Для получения дополнительной информации о файлах обратитесь к администратору.\n")
+
+	// PROJECT_CONTEXT.md недоступен без MCP
+	if opts.HasContextFile {
+		footer.WriteString("\n📋 Полный LLM-контекст сохранен в файле PROJECT_CONTEXT.md (недоступен без MCP)\n")
+	}
+	return footer.String()
+}
+
+// fitProjectContext добавляет в вопрос сжатый контекст проекта и проверяет размер промпта через
+// CountTokens: если он больше 90% окна модели, из копии ProjectContextLLM убираются наименее
+// важные файлы, пока промпт не поместится
+func (h *VibeCodingHandler) fitProjectContext(session *VibeCodingSession, request *VibeCodingRequest) {
+	if session.Context == nil || h.protocolClient == nil {
+		return
+	}
+	limit := int(float64(h.config.contextWindow()) * promptWindowShare)
+	mcpAvailable, mcpTools := session.getMCPToolsInfo()
+	_, hasContextFile := session.GeneratedFiles["PROJECT_CONTEXT.md"]
+	opts := compressedContextOptions{MCPAvailable: mcpAvailable, MCPTools: mcpTools, HasContextFile: hasContextFile}
+
+	pc := *session.Context
+	pc.Files = maps.Clone(session.Context.Files)
+	ranked := rankContextFiles(&pc, session.Files)
+	for {
+		text, _ := renderCompressedContext(&pc, session.Files, pc.TokensLimit, opts)
+		request.Context.ProjectContext = text
+		tokens := h.countRequestTokens(*request)
+		if tokens <= limit {
+			if dropped := len(session.Context.Files) - len(pc.Files); dropped > 0 {
+				log.Printf("✂️ Prompt for user %d trimmed to ~%d/%d tokens: %d files dropped from project context", session.UserID, tokens, limit, dropped)
+			}
+			return
+		}
+		if len(ranked) == 0 {
+			log.Printf("⚠️ Prompt for user %d is ~%d tokens, over %d even without file descriptions", session.UserID, tokens, limit)
+			return
+		}
+		// Убираем долю файлов, пропорциональную превышению, но не меньше одного
+		drop := max(1, len(ranked)*(tokens-limit)/tokens)
+		for _, path := range ranked[len(ranked)-drop:] {
+			delete(pc.Files, path)
+		}
+		ranked = ranked[:len(ranked)-drop]
+	}
+}
+
+// countRequestTokens размер промпта запроса по CountTokens клиента; при ошибке — локальная оценка
+func (h *VibeCodingHandler) countRequestTokens(request VibeCodingRequest) int {
+	messages, err := h.protocolClient.requestMessages(request)
+	if err != nil {
+		return 0
+	}
+	if h.protocolClient.llmClient != nil {
+		if n, err := h.protocolClient.llmClient.CountTokens(messages); err == nil {
+			return n
+		}
+	}
+	return llm.EstimateTokens(messages)
+}
@@ -0,0 +1,104 @@
+package vibecoding
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRenderCompressedContext_GreedyByImportanceWithinBudget(t *testing.T) {
+	pc := &ProjectContextLLM{ProjectName: "demo", Language: "Go", Files: map[string]LLMFileContext{}}
+	contents := map[string]string{}
+	for i := 0; i < 30; i++ {
+		path := fmt.Sprintf("pkg/util%02d.go", i)
+		pc.Files[path] = LLMFileContext{Type: "go", Summary: strings.Repeat("helper ", 20)}
+		contents[path] = "package pkg"
+	}
+	pc.Files["cmd/app/main.go"] = LLMFileContext{Type: "go", Summary: "entry point"}
+	contents["cmd/app/main.go"] = "package main\nfunc main() {}"
+	pc.Files["internal/store/db.go"] = LLMFileContext{Type: "go", Summary: "database"}
+	pc.DependencyGraph = &DependencyGraph{Ranking: []FileCentrality{{Path: "internal/store/db.go", TransitiveDependents: 5}}}
+	pc.TotalFiles = len(pc.Files)
+
+	const budget = 600
+	text, sel := renderCompressedContext(pc, contents, budget, compressedContextOptions{MCPAvailable: true, MCPTools: []string{"vibe_read_file"}})
+
+	if len(sel.Included) == 0 || len(sel.Omitted) == 0 {
+		t.Fatalf("expected a partial selection, got %d included / %d omitted", len(sel.Included), len(sel.Omitted))
+	}
+	if len(sel.Included)+len(sel.Omitted) != len(pc.Files) {
+		t.Errorf("every file must be either included or omitted: %d + %d != %d", len(sel.Included), len(sel.Omitted), len(pc.Files))
+	}
+	if sel.Included[0] != "internal/store/db.go" || sel.Included[1] != "cmd/app/main.go" {
+		t.Errorf("most important files must come first, got %v", sel.Included[:2])
+	}
+	if sel.TokensUsed > budget {
+		t.Errorf("context uses ~%d tokens, budget %d", sel.TokensUsed, budget)
+	}
+	for _, path := range sel.Included {
+		if !strings.Contains(text, "### "+path+" ") {
+			t.Errorf("included file %s has no description in the context", path)
+		}
+	}
+	if !strings.Contains(text, "vibe_read_file:** "+sel.Omitted[0]) {
+		t.Errorf("hint must name omitted files:\n%s", text)
+	}
+	if strings.Contains(text, "### "+sel.Omitted[0]+" ") {
+		t.Errorf("omitted file %s must not be described", sel.Omitted[0])
+	}
+}
+
+func TestRenderCompressedContext_EverythingFitsWithoutHint(t *testing.T) {
+	pc := &ProjectContextLLM{Files: map[string]LLMFileContext{
+		"a.py": {Summary: "a"},
+		"b.py": {Summary: "b"},
+	}}
+	text, sel := renderCompressedContext(pc, nil, 0, compressedContextOptions{})
+	if sel.Budget != defaultContextTokenBudget || len(sel.Included) != 2 || len(sel.Omitted) != 0 {
+		t.Errorf("unexpected selection: %+v", sel)
+	}
+	if strings.Contains(text, "не вошли в бюджет") {
+		t.Errorf("no omitted-files hint expected:\n%s", text)
+	}
+}
+
+func TestFitProjectContext_DropsLeastImportantFilesToFitWindow(t *testing.T) {
+	pc := &ProjectContextLLM{ProjectName: "demo", Language: "Go", Files: map[string]LLMFileContext{}, TokensLimit: 100000}
+	files := map[string]string{}
+	for i := 0; i < 40; i++ {
+		path := fmt.Sprintf("pkg/util%02d.go", i)
+		pc.Files[path] = LLMFileContext{Type: "go", Summary: strings.Repeat("helper function ", 30)}
+		files[path] = "package pkg"
+	}
+	pc.Files["internal/store/db.go"] = LLMFileContext{Type: "go", Summary: "database"}
+	files["internal/store/db.go"] = "package store"
+	pc.DependencyGraph = &DependencyGraph{Ranking: []FileCentrality{{Path: "internal/store/db.go", TransitiveDependents: 5}}}
+
+	client := NewMockLLMClient()
+	session := &VibeCodingSession{UserID: 1, ProjectName: "demo", Files: files, GeneratedFiles: map[string]string{}, Context: pc}
+	h := &VibeCodingHandler{protocolClient: NewVibeCodingLLMClient(client), config: VibeCodingConfig{ContextWindow: 3000}}
+	request := VibeCodingRequest{Action: "answer_question", Query: "что делает db.go?",
+		Context: VibeCodingContext{ProjectName: "demo", Language: "Go", Files: files}}
+
+	h.fitProjectContext(session, &request)
+
+	if n := h.countRequestTokens(request); n > 2700 {
+		t.Fatalf("prompt must fit into 90%% of the window, got ~%d tokens", n)
+	}
+	if !strings.Contains(request.Context.ProjectContext, "### internal/store/db.go ") {
+		t.Errorf("most important file must stay in the context")
+	}
+	if strings.Contains(request.Context.ProjectContext, "### pkg/util39.go ") {
+		t.Errorf("least important files must be dropped first")
+	}
+	if len(session.Context.Files) != 41 {
+		t.Errorf("session context must not be modified, got %d files", len(session.Context.Files))
+	}
+
+	h.config.ContextWindow = 1000000
+	request.Context.ProjectContext = ""
+	h.fitProjectContext(session, &request)
+	if !strings.Contains(request.Context.ProjectContext, "### pkg/util39.go ") {
+		t.Errorf("nothing must be dropped when the prompt fits")
+	}
+}
@@ -0,0 +1,183 @@
+package vibecoding
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Файлы покрытия пишутся во /tmp контейнера, чтобы не попадать в проект и архив результата
+const (
+	goCoverProfile = "/tmp/vibecoding_cover.out"
+	pyCoverageFile = "/tmp/vibecoding.coverage"
+)
+
+// maxCoverageFiles сколько файлов показывать в отчёте о покрытии
+const maxCoverageFiles = 40
+
+// CoveragePlan команды для измерения покрытия: запуск тестов и построение отчёта.
+// Report пуст, если раннер печатает отчёт сам (jest)
+type CoveragePlan struct {
+	Runner  string // go, pytest, jest
+	Run     string
+	Report  string
+	Measure func(output string) (CoverageReport, bool)
+}
+
+// FileCoverage покрытие одного файла в процентах
+type FileCoverage struct {
+	File    string
+	Percent float64
+}
+
+// CoverageReport покрытие по файлам и общий итог
+type CoverageReport struct {
+	Files    []FileCoverage
+	Total    float64
+	HasTotal bool
+}
+
+var (
+	goTestCmdRe = regexp.MustCompile(`\bgo test\b`)
+	pytestCmdRe = regexp.MustCompile(`\bpytest\b`)
+	jestCmdRe   = regexp.MustCompile(`\b(jest|vitest|npm test|npm run test|yarn test|pnpm test)\b`)
+	// goCoverFuncRe "pkg/file.go:12:	Handle		80.0%" и "total:	(statements)	75.0%"
+	goCoverFuncRe  = regexp.MustCompile(`^(\S+?):\d+:\s+\S+\s+([\d.]+)%$`)
+	goCoverTotalRe = regexp.MustCompile(`^total:\s+\(statements\)\s+([\d.]+)%$`)
+)
+
+// CoveragePlanFor строит вариант команды тестов с покрытием для go test, pytest и jest/vitest.
+// ok=false, если раннер не распознан
+func CoveragePlanFor(testCommand string) (CoveragePlan, bool) {
+	switch {
+	case goTestCmdRe.MatchString(testCommand):
+		run := goTestCmdRe.ReplaceAllLiteralString(testCommand, "go test -coverprofile="+goCoverProfile)
+		return CoveragePlan{
+			Runner:  "go",
+			Run:     run,
+			Report:  "go tool cover -func=" + goCoverProfile,
+			Measure: ParseGoCoverFunc,
+		}, true
+	case pytestCmdRe.MatchString(testCommand):
+		return CoveragePlan{
+			Runner:  "pytest",
+			Run:     fmt.Sprintf("COVERAGE_FILE=%s %s --cov=. --cov-report=", pyCoverageFile, testCommand),
+			Report:  fmt.Sprintf("COVERAGE_FILE=%s python -m coverage report", pyCoverageFile),
+			Measure: ParseCoveragePyReport,
+		}, true
+	case jestCmdRe.MatchString(testCommand):
+		run := testCommand + " --coverage --coverageReporters=text"
+		if strings.HasPrefix(strings.TrimSpace(testCommand), "npm ") && !strings.Contains(testCommand, " -- ") {
+			run = testCommand + " -- --coverage --coverageReporters=text"
+		}
+		if strings.Contains(testCommand, "vitest") {
+			run = testCommand + " --coverage --coverage.reporter=text"
+		}
+		return CoveragePlan{Runner: "jest", Run: run, Measure: ParseIstanbulTextReport}, true
+	}
+	return CoveragePlan{}, false
+}
+
+// ParseGoCoverFunc разбирает вывод go tool cover -func. Покрытие файла — среднее по его функциям
+// (в выводе нет числа операторов), общий итог берётся из строки total
+func ParseGoCoverFunc(output string) (CoverageReport, bool) {
+	var report CoverageReport
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if m := goCoverTotalRe.FindStringSubmatch(line); m != nil {
+			report.Total, _ = strconv.ParseFloat(m[1], 64)
+			report.HasTotal = true
+			continue
+		}
+		if m := goCoverFuncRe.FindStringSubmatch(line); m != nil {
+			pct, err := strconv.ParseFloat(m[2], 64)
+			if err != nil {
+				continue
+			}
+			sums[m[1]] += pct
+			counts[m[1]]++
+		}
+	}
+	for file, sum := range sums {
+		report.Files = append(report.Files, FileCoverage{File: file, Percent: sum / float64(counts[file])})
+	}
+	return report, report.HasTotal || len(report.Files) > 0
+}
+
+// ParseCoveragePyReport разбирает таблицу coverage report: "Name Stmts Miss Cover" и строку TOTAL
+func ParseCoveragePyReport(output string) (CoverageReport, bool) {
+	var report CoverageReport
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		last := fields[len(fields)-1]
+		if !strings.HasSuffix(last, "%") {
+			continue
+		}
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(last, "%"), 64)
+		if err != nil {
+			continue
+		}
+		if fields[0] == "TOTAL" {
+			report.Total, report.HasTotal = pct, true
+			continue
+		}
+		report.Files = append(report.Files, FileCoverage{File: fields[0], Percent: pct})
+	}
+	return report, report.HasTotal || len(report.Files) > 0
+}
+
+// ParseIstanbulTextReport разбирает текстовую таблицу jest/vitest: "File | % Stmts | ..." и строку "All files"
+func ParseIstanbulTextReport(output string) (CoverageReport, bool) {
+	var report CoverageReport
+	for _, line := range strings.Split(output, "\n") {
+		cols := strings.Split(line, "|")
+		if len(cols) < 3 {
+			continue
+		}
+		name := strings.TrimSpace(cols[0])
+		pct, err := strconv.ParseFloat(strings.TrimSpace(cols[1]), 64)
+		if err != nil || name == "" {
+			continue
+		}
+		if name == "All files" {
+			report.Total, report.HasTotal = pct, true
+			continue
+		}
+		report.Files = append(report.Files, FileCoverage{File: name, Percent: pct})
+	}
+	return report, report.HasTotal || len(report.Files) > 0
+}
+
+// FormatCoverageReport таблица покрытия: сначала наименее покрытые файлы, затем общий итог
+func FormatCoverageReport(report CoverageReport) string {
+	files := append([]FileCoverage(nil), report.Files...)
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Percent != files[j].Percent {
+			return files[i].Percent < files[j].Percent
+		}
+		return files[i].File < files[j].File
+	})
+
+	var sb strings.Builder
+	shown := files
+	if len(shown) > maxCoverageFiles {
+		shown = shown[:maxCoverageFiles]
+	}
+	for _, f := range shown {
+		fmt.Fprintf(&sb, "%6.1f%%  %s\n", f.Percent, f.File)
+	}
+	if hidden := len(files) - len(shown); hidden > 0 {
+		fmt.Fprintf(&sb, "... и ещё %d файлов\n", hidden)
+	}
+	if report.HasTotal {
+		fmt.Fprintf(&sb, "Итого: %.1f%%", report.Total)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
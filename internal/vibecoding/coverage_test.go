@@ -0,0 +1,57 @@
+package vibecoding
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCoveragePlanFor(t *testing.T) {
+	cases := map[string]string{
+		"go test ./...":        "go test -coverprofile=/tmp/vibecoding_cover.out ./...",
+		"python -m pytest -q":  "COVERAGE_FILE=/tmp/vibecoding.coverage python -m pytest -q --cov=. --cov-report=",
+		"npm test":             "npm test -- --coverage --coverageReporters=text",
+		"npx jest --runInBand": "npx jest --runInBand --coverage --coverageReporters=text",
+	}
+	for cmd, want := range cases {
+		plan, ok := CoveragePlanFor(cmd)
+		if !ok || plan.Run != want {
+			t.Errorf("CoveragePlanFor(%q).Run = %q, %v; want %q", cmd, plan.Run, ok, want)
+		}
+	}
+	if _, ok := CoveragePlanFor("cargo test"); ok {
+		t.Errorf("cargo test must not be supported")
+	}
+}
+
+func TestParseGoCoverFunc(t *testing.T) {
+	out := "example.com/app/calc.go:5:\t\tAdd\t\t100.0%\n" +
+		"example.com/app/calc.go:9:\t\tDiv\t\t50.0%\n" +
+		"example.com/app/main.go:3:\t\tmain\t\t0.0%\n" +
+		"total:\t\t\t\t(statements)\t60.0%\n"
+	report, ok := ParseGoCoverFunc(out)
+	if !ok || !report.HasTotal || report.Total != 60 || len(report.Files) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	formatted := FormatCoverageReport(report)
+	if !strings.HasPrefix(formatted, "   0.0%  example.com/app/main.go\n  75.0%  example.com/app/calc.go") || !strings.HasSuffix(formatted, "Итого: 60.0%") {
+		t.Fatalf("unexpected table:\n%s", formatted)
+	}
+}
+
+func TestParseCoverageTables(t *testing.T) {
+	py := "Name      Stmts   Miss  Cover\n---------------------------\napp.py       10      2    80%\nutil.py       4      0   100%\n---------------------------\nTOTAL        14      2    86%\n"
+	report, ok := ParseCoveragePyReport(py)
+	if !ok || report.Total != 86 || len(report.Files) != 2 || report.Files[0] != (FileCoverage{File: "app.py", Percent: 80}) {
+		t.Fatalf("unexpected pytest report: %+v", report)
+	}
+
+	jest := "----------|---------|----------|\nFile      | % Stmts | % Branch |\n----------|---------|----------|\nAll files |   66.67 |       50 |\n sum.js   |     100 |      100 |\n div.js   |   33.33 |        0 |\n"
+	report, ok = ParseIstanbulTextReport(jest)
+	if !ok || report.Total != 66.67 || len(report.Files) != 2 || report.Files[1].File != "div.js" {
+		t.Fatalf("unexpected jest report: %+v", report)
+	}
+
+	if _, ok := ParseGoCoverFunc("no coverage here"); ok {
+		t.Fatalf("output without coverage must not parse")
+	}
+}
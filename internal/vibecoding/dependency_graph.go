@@ -0,0 +1,339 @@
+package vibecoding
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// dependencyGraphTopFiles количество центральных файлов в PROJECT_CONTEXT.md
+	dependencyGraphTopFiles = 10
+	// dependencyGraphMaxEdges ограничение на число рёбер в mermaid-сводке
+	dependencyGraphMaxEdges = 150
+)
+
+var (
+	goImportSingleRe = regexp.MustCompile(`(?m)^\s*import\s+(?:[\w.]+\s+)?"([^"]+)"`)
+	goImportBlockRe  = regexp.MustCompile(`(?s)import\s*\((.*?)\)`)
+	goImportLineRe   = regexp.MustCompile(`(?m)^\s*(?:[\w.]+\s+)?"([^"]+)"`)
+	goModuleRe       = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+	pyImportRe = regexp.MustCompile(`(?m)^\s*import\s+([\w., ]+)`)
+	pyFromRe   = regexp.MustCompile(`(?m)^\s*from\s+(\.*[\w.]*)\s+import\s+\(?([\w, *]+)`)
+
+	jsImportRe = regexp.MustCompile(`(?:import|export)\s+(?:[^'"]*?\s+from\s+)?['"]([^'"]+)['"]|require\(\s*['"]([^'"]+)['"]\s*\)|import\(\s*['"]([^'"]+)['"]\s*\)`)
+)
+
+var jsExtensions = []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs"}
+
+// FileCentrality показатель "центральности" файла в графе зависимостей
+type FileCentrality struct {
+	Path                 string `json:"path"`
+	DirectDependents     int    `json:"direct_dependents"`     // файлы, напрямую импортирующие этот
+	TransitiveDependents int    `json:"transitive_dependents"` // все файлы, зависящие от него через цепочку импортов
+}
+
+// DependencyGraph граф импортов между файлами проекта (только разрешимые импорты)
+type DependencyGraph struct {
+	Edges   map[string][]string `json:"edges"`   // файл -> файлы проекта, которые он импортирует
+	Ranking []FileCentrality    `json:"ranking"` // файлы по убыванию числа зависящих от них
+}
+
+// BuildDependencyGraph строит граф зависимостей лёгким разбором импортов (Go, Python, JS/TS) без обращения к LLM
+func BuildDependencyGraph(files map[string]string) *DependencyGraph {
+	graph := &DependencyGraph{Edges: make(map[string][]string)}
+
+	// Модули Go по каталогам go.mod: в репозитории их может быть несколько
+	goModules := make(map[string]string)
+	for p, content := range files {
+		if path.Base(p) == "go.mod" {
+			if m := goModuleRe.FindStringSubmatch(content); m != nil {
+				goModules[m[1]] = path.Dir(p)
+			}
+		}
+	}
+
+	// Индекс Go файлов по каталогам (пакет = каталог)
+	goDirs := make(map[string][]string)
+	for p := range files {
+		if strings.HasSuffix(p, ".go") && !strings.HasSuffix(p, "_test.go") {
+			dir := path.Dir(p)
+			goDirs[dir] = append(goDirs[dir], p)
+		}
+	}
+
+	for p, content := range files {
+		var deps []string
+		switch ext := strings.ToLower(path.Ext(p)); {
+		case ext == ".go":
+			deps = resolveGoImports(content, goModules, goDirs)
+		case ext == ".py":
+			deps = resolvePythonImports(p, content, files)
+		case isJSExtension(ext):
+			deps = resolveJSImports(p, content, files)
+		}
+
+		seen := map[string]bool{p: true}
+		var unique []string
+		for _, d := range deps {
+			if !seen[d] {
+				seen[d] = true
+				unique = append(unique, d)
+			}
+		}
+		if len(unique) > 0 {
+			sort.Strings(unique)
+			graph.Edges[p] = unique
+		}
+	}
+
+	graph.Ranking = rankDependencyGraph(graph.Edges)
+	return graph
+}
+
+// resolveGoImports разрешает импорты модулей проекта; вложенный модуль (самый длинный путь модуля,
+// являющийся префиксом импорта) имеет приоритет, поэтому результат не зависит от порядка обхода файлов
+func resolveGoImports(content string, modules map[string]string, goDirs map[string][]string) []string {
+	if len(modules) == 0 {
+		return nil
+	}
+	var imports []string
+	for _, m := range goImportSingleRe.FindAllStringSubmatch(content, -1) {
+		imports = append(imports, m[1])
+	}
+	for _, block := range goImportBlockRe.FindAllStringSubmatch(content, -1) {
+		for _, m := range goImportLineRe.FindAllStringSubmatch(block[1], -1) {
+			imports = append(imports, m[1])
+		}
+	}
+
+	var deps []string
+	for _, imp := range imports {
+		module := ""
+		for candidate := range modules {
+			if (imp == candidate || strings.HasPrefix(imp, candidate+"/")) && len(candidate) > len(module) {
+				module = candidate
+			}
+		}
+		if module == "" {
+			continue
+		}
+		dir := path.Clean(path.Join(modules[module], strings.TrimPrefix(strings.TrimPrefix(imp, module), "/")))
+		deps = append(deps, goDirs[dir]...)
+	}
+	return deps
+}
+
+func resolvePythonImports(file, content string, files map[string]string) []string {
+	var deps []string
+	fileDir := path.Dir(file)
+
+	resolve := func(module string, baseDirs ...string) (string, bool) {
+		rel := strings.ReplaceAll(module, ".", "/")
+		for _, base := range baseDirs {
+			for _, candidate := range []string{path.Join(base, rel+".py"), path.Join(base, rel, "__init__.py")} {
+				if _, ok := files[candidate]; ok {
+					return candidate, true
+				}
+			}
+		}
+		return "", false
+	}
+
+	for _, m := range pyImportRe.FindAllStringSubmatch(content, -1) {
+		for _, name := range strings.Split(m[1], ",") {
+			name = strings.Fields(strings.TrimSpace(name) + " ")[0]
+			if dep, ok := resolve(name, ".", fileDir, "src"); ok {
+				deps = append(deps, dep)
+			}
+		}
+	}
+
+	for _, m := range pyFromRe.FindAllStringSubmatch(content, -1) {
+		module := m[1]
+		bases := []string{".", fileDir, "src"}
+		if strings.HasPrefix(module, ".") {
+			// Относительный импорт: каждая точка после первой поднимает на уровень вверх
+			dots := len(module) - len(strings.TrimLeft(module, "."))
+			base := fileDir
+			for i := 1; i < dots; i++ {
+				base = path.Dir(base)
+			}
+			module = strings.TrimLeft(module, ".")
+			bases = []string{base}
+		}
+
+		if module != "" {
+			if dep, ok := resolve(module, bases...); ok {
+				deps = append(deps, dep)
+				// from pkg import module — импортированные имена могут быть модулями пакета
+				if !strings.HasSuffix(dep, "__init__.py") {
+					continue
+				}
+			}
+		}
+		for _, name := range strings.Split(m[2], ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || name == "*" {
+				continue
+			}
+			sub := name
+			if module != "" {
+				sub = module + "." + name
+			}
+			if dep, ok := resolve(sub, bases...); ok {
+				deps = append(deps, dep)
+			}
+		}
+	}
+	return deps
+}
+
+func resolveJSImports(file, content string, files map[string]string) []string {
+	var deps []string
+	for _, m := range jsImportRe.FindAllStringSubmatch(content, -1) {
+		spec := m[1] + m[2] + m[3]
+		// Пакеты из node_modules не относятся к файлам проекта
+		if !strings.HasPrefix(spec, ".") {
+			continue
+		}
+		target := path.Clean(path.Join(path.Dir(file), spec))
+		candidates := []string{target}
+		for _, ext := range jsExtensions {
+			candidates = append(candidates, target+ext)
+		}
+		for _, ext := range jsExtensions {
+			candidates = append(candidates, path.Join(target, "index"+ext))
+		}
+		for _, c := range candidates {
+			if _, ok := files[c]; ok {
+				deps = append(deps, c)
+				break
+			}
+		}
+	}
+	return deps
+}
+
+func isJSExtension(ext string) bool {
+	for _, e := range jsExtensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// rankDependencyGraph считает прямых и транзитивных зависимых для каждого файла.
+// Обход в ширину с множеством посещённых вершин, поэтому циклы не приводят к зацикливанию
+func rankDependencyGraph(edges map[string][]string) []FileCentrality {
+	reverse := make(map[string][]string)
+	for from, tos := range edges {
+		for _, to := range tos {
+			reverse[to] = append(reverse[to], from)
+		}
+	}
+
+	ranking := make([]FileCentrality, 0, len(reverse))
+	for node, direct := range reverse {
+		visited := map[string]bool{node: true}
+		queue := append([]string(nil), direct...)
+		for _, d := range direct {
+			visited[d] = true
+		}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, next := range reverse[current] {
+				if !visited[next] {
+					visited[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+		ranking = append(ranking, FileCentrality{
+			Path:                 node,
+			DirectDependents:     len(direct),
+			TransitiveDependents: len(visited) - 1,
+		})
+	}
+
+	sort.Slice(ranking, func(i, j int) bool {
+		a, b := ranking[i], ranking[j]
+		if a.TransitiveDependents != b.TransitiveDependents {
+			return a.TransitiveDependents > b.TransitiveDependents
+		}
+		if a.DirectDependents != b.DirectDependents {
+			return a.DirectDependents > b.DirectDependents
+		}
+		return a.Path < b.Path
+	})
+	return ranking
+}
+
+// TopFiles возвращает n самых центральных файлов
+func (g *DependencyGraph) TopFiles(n int) []FileCentrality {
+	if g == nil {
+		return nil
+	}
+	if n > len(g.Ranking) {
+		n = len(g.Ranking)
+	}
+	return append([]FileCentrality(nil), g.Ranking[:n]...)
+}
+
+// FormatMarkdown формирует секцию PROJECT_CONTEXT.md: рейтинг центральных файлов и mermaid-граф
+func (g *DependencyGraph) FormatMarkdown() string {
+	if g == nil || len(g.Edges) == 0 {
+		return ""
+	}
+	var md strings.Builder
+	md.WriteString("## Dependency Graph\n\n")
+
+	md.WriteString("### Most Depended-Upon Files\n\n")
+	for i, f := range g.TopFiles(dependencyGraphTopFiles) {
+		md.WriteString(fmt.Sprintf("%d. `%s` — %d direct, %d transitive dependents\n", i+1, f.Path, f.DirectDependents, f.TransitiveDependents))
+	}
+	md.WriteString("\n")
+
+	sources := make([]string, 0, len(g.Edges))
+	for from := range g.Edges {
+		sources = append(sources, from)
+	}
+	sort.Strings(sources)
+
+	ids := make(map[string]string)
+	nodeID := func(p string) string {
+		if id, ok := ids[p]; ok {
+			return id
+		}
+		id := fmt.Sprintf("n%d", len(ids))
+		ids[p] = id
+		return id
+	}
+
+	md.WriteString("### Adjacency\n\n```mermaid\ngraph LR\n")
+	written := 0
+	for _, from := range sources {
+		for _, to := range g.Edges[from] {
+			if written >= dependencyGraphMaxEdges {
+				break
+			}
+			md.WriteString(fmt.Sprintf("  %s[\"%s\"] --> %s[\"%s\"]\n", nodeID(from), from, nodeID(to), to))
+			written++
+		}
+	}
+	md.WriteString("```\n")
+	total := 0
+	for _, tos := range g.Edges {
+		total += len(tos)
+	}
+	if total > written {
+		md.WriteString(fmt.Sprintf("\n_%d of %d edges shown_\n", written, total))
+	}
+	md.WriteString("\n")
+	return md.String()
+}
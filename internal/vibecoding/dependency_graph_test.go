@@ -0,0 +1,103 @@
+package vibecoding
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildDependencyGraph_Go(t *testing.T) {
+	files := map[string]string{
+		"go.mod":                   "module example.com/app\n\ngo 1.22\n",
+		"main.go":                  "package main\n\nimport (\n\t\"fmt\"\n\tsvc \"example.com/app/internal/service\"\n)\n",
+		"internal/service/a.go":    "package service\n\nimport \"example.com/app/internal/store\"\n",
+		"internal/service/b.go":    "package service\n",
+		"internal/store/db.go":     "package store\n\nimport \"database/sql\"\n",
+		"internal/store/x_test.go": "package store\n",
+	}
+	g := BuildDependencyGraph(files)
+
+	if got := g.Edges["main.go"]; !reflect.DeepEqual(got, []string{"internal/service/a.go", "internal/service/b.go"}) {
+		t.Fatalf("unexpected main.go edges: %v", got)
+	}
+	if got := g.Edges["internal/service/a.go"]; !reflect.DeepEqual(got, []string{"internal/store/db.go"}) {
+		t.Fatalf("unexpected service edges: %v", got)
+	}
+	top := g.TopFiles(1)
+	if len(top) != 1 || top[0].Path != "internal/store/db.go" || top[0].TransitiveDependents != 2 {
+		t.Fatalf("unexpected top file: %+v", top)
+	}
+}
+
+func TestBuildDependencyGraph_GoSeveralModules(t *testing.T) {
+	files := map[string]string{
+		"go.mod":               "module example.com/app\n",
+		"main.go":              "package main\n\nimport (\n\t\"example.com/app/api\"\n\t\"example.com/app/tools/gen\"\n)\n",
+		"api/api.go":           "package api\n",
+		"tools/go.mod":         "module example.com/app/tools\n",
+		"tools/gen/gen.go":     "package gen\n\nimport \"example.com/lib/util\"\n",
+		"lib/go.mod":           "module example.com/lib\n",
+		"lib/util/util.go":     "package util\n",
+		"services/go.mod":      "module example.com/services\n",
+		"services/worker/w.go": "package worker\n\nimport \"example.com/services/queue\"\n",
+		"services/queue/q.go":  "package queue\n",
+	}
+	// Результат не должен зависеть от порядка обхода карты
+	for i := 0; i < 20; i++ {
+		g := BuildDependencyGraph(files)
+		if got := g.Edges["main.go"]; !reflect.DeepEqual(got, []string{"api/api.go", "tools/gen/gen.go"}) {
+			t.Fatalf("unexpected main.go edges: %v", got)
+		}
+		if got := g.Edges["tools/gen/gen.go"]; !reflect.DeepEqual(got, []string{"lib/util/util.go"}) {
+			t.Fatalf("unexpected nested module edges: %v", got)
+		}
+		if got := g.Edges["services/worker/w.go"]; !reflect.DeepEqual(got, []string{"services/queue/q.go"}) {
+			t.Fatalf("unexpected services edges: %v", got)
+		}
+	}
+}
+
+func TestBuildDependencyGraph_PythonAndJS(t *testing.T) {
+	files := map[string]string{
+		"app/__init__.py":     "",
+		"app/main.py":         "import os\nfrom app.models import User\nfrom . import utils\n",
+		"app/models.py":       "from .utils import slugify\n",
+		"app/utils.py":        "import re\n",
+		"web/index.ts":        "import React from 'react'\nimport { api } from './lib/api'\nconst cfg = require('./config')\n",
+		"web/lib/api.ts":      "export * from '../config'\n",
+		"web/config/index.js": "module.exports = {}\n",
+	}
+	g := BuildDependencyGraph(files)
+
+	if got := g.Edges["app/main.py"]; !reflect.DeepEqual(got, []string{"app/models.py", "app/utils.py"}) {
+		t.Fatalf("unexpected python edges: %v", got)
+	}
+	if got := g.Edges["app/models.py"]; !reflect.DeepEqual(got, []string{"app/utils.py"}) {
+		t.Fatalf("unexpected relative import edges: %v", got)
+	}
+	if got := g.Edges["web/index.ts"]; !reflect.DeepEqual(got, []string{"web/config/index.js", "web/lib/api.ts"}) {
+		t.Fatalf("unexpected js edges: %v", got)
+	}
+	if _, ok := g.Edges["app/utils.py"]; ok {
+		t.Fatalf("stdlib imports must not produce edges")
+	}
+}
+
+func TestBuildDependencyGraph_CycleDoesNotHang(t *testing.T) {
+	files := map[string]string{
+		"a.js": "import './b'\n",
+		"b.js": "import './c'\n",
+		"c.js": "import './a'\n",
+		"d.js": "import './a'\n",
+	}
+	g := BuildDependencyGraph(files)
+
+	top := g.TopFiles(3)
+	if len(top) != 3 || top[0].Path != "a.js" || top[0].TransitiveDependents != 3 {
+		t.Fatalf("unexpected ranking: %+v", top)
+	}
+	md := g.FormatMarkdown()
+	if !strings.Contains(md, "Most Depended-Upon Files") || !strings.Contains(md, "```mermaid") || !strings.Contains(md, `["a.js"] --> `) {
+		t.Fatalf("unexpected markdown:\n%s", md)
+	}
+}
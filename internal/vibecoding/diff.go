@@ -0,0 +1,163 @@
+package vibecoding
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// diffContextLines строк контекста вокруг изменений в unified diff
+const diffContextLines = 3
+
+// diffLine строка diff: ' ' — без изменений, '-' — удалена, '+' — добавлена
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// GeneratedDiff unified diff изменений, внесённых генерацией: name.generated.ext сравнивается с исходным
+// name.ext, исходные файлы, перезаписанные с overwrite_original, — с загруженной версией, остальные
+// сгенерированные файлы показываются добавленными целиком. Значения секретов замаскированы. Пусто — изменений нет
+func (s *VibeCodingSession) GeneratedDiff() string {
+	s.mutex.RLock()
+	original := redactFiles(s.Files)
+	generated := redactFiles(s.GeneratedFiles)
+	uploaded := redactFiles(s.uploadedOriginals)
+	s.mutex.RUnlock()
+
+	type fileDiff struct{ oldName, newName, before, after string }
+	var diffs []fileDiff
+	for name, content := range generated {
+		if base, ok := originalNameForGenerated(name); ok {
+			if before, exists := original[base]; exists {
+				diffs = append(diffs, fileDiff{oldName: base, newName: name, before: before, after: content})
+				continue
+			}
+		}
+		diffs = append(diffs, fileDiff{newName: name, after: content})
+	}
+	for name, before := range uploaded {
+		// Удалённый после перезаписи файл не показывается
+		if after, exists := original[name]; exists {
+			diffs = append(diffs, fileDiff{oldName: name, newName: name, before: before, after: after})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].newName < diffs[j].newName })
+
+	var sb strings.Builder
+	for _, d := range diffs {
+		sb.WriteString(unifiedDiff(d.oldName, d.newName, d.before, d.after))
+	}
+	return sb.String()
+}
+
+// unifiedDiff diff одного файла в формате diff -u; пустой oldName — новый файл.
+// Для совпадающего содержимого существующего файла пусто
+func unifiedDiff(oldName, newName, before, after string) string {
+	isNew := oldName == ""
+	if before == after && !isNew {
+		return ""
+	}
+	lines := diffLines(before, after)
+
+	var sb strings.Builder
+	if isNew {
+		sb.WriteString("--- /dev/null\n")
+	} else {
+		sb.WriteString("--- a/" + oldName + "\n")
+	}
+	sb.WriteString("+++ b/" + newName + "\n")
+	for _, h := range diffHunks(lines) {
+		oldStart, newStart, oldCount, newCount := h.position(lines)
+		sb.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount)))
+		for _, l := range lines[h.start:h.end] {
+			sb.WriteByte(l.op)
+			sb.WriteString(l.text)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// diffLines построчный diff через diffmatchpatch (строки кодируются символами, чтобы сравнивать целые строки)
+func diffLines(before, after string) []diffLine {
+	dmp := diffmatchpatch.New()
+	a, b, lineArray := dmp.DiffLinesToChars(before, after)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lineArray)
+
+	var lines []diffLine
+	for _, d := range diffs {
+		op := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		}
+		for _, text := range strings.SplitAfter(d.Text, "\n") {
+			if text != "" {
+				lines = append(lines, diffLine{op: op, text: strings.TrimSuffix(text, "\n")})
+			}
+		}
+	}
+	return lines
+}
+
+// diffHunk диапазон [start, end) строк diff, выводимый одним блоком @@
+type diffHunk struct {
+	start, end int
+}
+
+// diffHunks группирует изменения с diffContextLines строк контекста; близкие изменения сливаются в один блок
+func diffHunks(lines []diffLine) []diffHunk {
+	var hunks []diffHunk
+	for i, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		start := max(i-diffContextLines, 0)
+		end := min(i+diffContextLines+1, len(lines))
+		if n := len(hunks); n > 0 && start <= hunks[n-1].end {
+			hunks[n-1].end = max(hunks[n-1].end, end)
+			continue
+		}
+		hunks = append(hunks, diffHunk{start: start, end: end})
+	}
+	return hunks
+}
+
+// position начальные строки (с 1) и длины блока в старой и новой версиях файла
+func (h diffHunk) position(lines []diffLine) (oldStart, newStart, oldCount, newCount int) {
+	oldStart, newStart = 1, 1
+	for _, l := range lines[:h.start] {
+		if l.op != '+' {
+			oldStart++
+		}
+		if l.op != '-' {
+			newStart++
+		}
+	}
+	for _, l := range lines[h.start:h.end] {
+		if l.op != '+' {
+			oldCount++
+		}
+		if l.op != '-' {
+			newCount++
+		}
+	}
+	return oldStart, newStart, oldCount, newCount
+}
+
+// hunkRange диапазон строк в заголовке @@: для пустого диапазона — строка перед ним, как в diff -u
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	default:
+		return fmt.Sprintf("%d,%d", start, count)
+	}
+}
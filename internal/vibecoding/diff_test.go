@@ -0,0 +1,89 @@
+package vibecoding
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestUnifiedDiff_ModifiedFile(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	after := "a\nb\nc\nd\nE\nf\ng\nh\ni\nj\nk\n"
+	want := "--- a/x.txt\n+++ b/x.txt\n" +
+		"@@ -2,9 +2,10 @@\n b\n c\n d\n-e\n+E\n f\n g\n h\n i\n j\n+k\n"
+	if got := unifiedDiff("x.txt", "x.txt", before, after); got != want {
+		t.Errorf("unifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiff_SeparateHunks(t *testing.T) {
+	before := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	after := "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n"
+	want := "--- a/n\n+++ b/n\n" +
+		"@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+		"@@ -9,4 +9,3 @@\n 9\n 10\n 11\n-12\n"
+	if got := unifiedDiff("n", "n", before, after); got != want {
+		t.Errorf("unifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGeneratedDiff_ThroughWriteAPI(t *testing.T) {
+	ctx := context.Background()
+	s := &VibeCodingSession{
+		Files: map[string]string{
+			"main.go":   "package main\n",
+			"config.go": "package main\n\nconst port = 80\n",
+			"same.go":   "package same\n",
+		},
+		GeneratedFiles: make(map[string]string),
+	}
+	generated := WriteFileOptions{Generated: true}
+	if err := s.WriteFileWithOptions(ctx, "main_test.go", "package main\n\nfunc TestX() {}\n", generated); err != nil {
+		t.Fatalf("write new file: %v", err)
+	}
+	if err := s.WriteFileWithOptions(ctx, "main.go", "package main\n\nfunc main() {}\n", generated); !errors.Is(err, ErrOriginalFileProtected) {
+		t.Fatalf("generated write over an original must be refused, got %v", err)
+	}
+	if target := s.AddGeneratedFile("main.go", "package main\n\nfunc main() {}\n"); target != "main.generated.go" {
+		t.Fatalf("conflicting generated file must be renamed, got %s", target)
+	}
+	overwrite := WriteFileOptions{Generated: true, OverwriteOriginal: true}
+	for _, content := range []string{"package main\n\nconst port = 8080\n", "package main\n\nconst port = 8081\n"} {
+		if err := s.WriteFileWithOptions(ctx, "config.go", content, overwrite); err != nil {
+			t.Fatalf("overwrite original: %v", err)
+		}
+	}
+	if err := s.WriteFileWithOptions(ctx, "same.go", "package same\n", overwrite); err != nil {
+		t.Fatalf("overwrite original: %v", err)
+	}
+
+	want := "--- a/config.go\n+++ b/config.go\n@@ -1,3 +1,3 @@\n package main\n \n-const port = 80\n+const port = 8081\n" +
+		"--- a/main.go\n+++ b/main.generated.go\n@@ -1 +1,3 @@\n package main\n+\n+func main() {}\n" +
+		"--- /dev/null\n+++ b/main_test.go\n@@ -0,0 +1,3 @@\n+package main\n+\n+func TestX() {}\n"
+	if got := s.GeneratedDiff(); got != want {
+		t.Errorf("GeneratedDiff =\n%s\nwant\n%s", got, want)
+	}
+	if got := (&VibeCodingSession{}).GeneratedDiff(); got != "" {
+		t.Errorf("session without generated files must have an empty diff, got %q", got)
+	}
+}
+
+func TestOriginalNameForGenerated(t *testing.T) {
+	for generated, want := range map[string]string{
+		"main.generated.py":     "main.py",
+		"src/app.generated.ts":  "src/app.ts",
+		".env.generated":        ".env",
+		"Makefile.generated":    "Makefile",
+		"archive.generated.tar": "archive.tar",
+	} {
+		if got, ok := originalNameForGenerated(generated); !ok || got != want {
+			t.Errorf("originalNameForGenerated(%q) = %q, %v; want %q", generated, got, ok, want)
+		}
+		if back := generatedConflictName(want); back != generated {
+			t.Errorf("generatedConflictName(%q) = %q, want %q", want, back, generated)
+		}
+	}
+	if _, ok := originalNameForGenerated("main.go"); ok {
+		t.Error("ordinary file must not map to an original")
+	}
+}
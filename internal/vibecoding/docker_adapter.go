@@ -2,6 +2,10 @@ package vibecoding
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"ai-chatter/internal/codevalidation"
 )
@@ -18,11 +22,28 @@ func NewDockerAdapter(dockerManager codevalidation.DockerManager) *DockerAdapter
 	}
 }
 
-// CreateContainer создает контейнер напрямую используя CodeAnalysisResult
-func (a *DockerAdapter) CreateContainer(ctx context.Context, analysis *codevalidation.CodeAnalysisResult) (string, error) {
+// CreateContainer создает контейнер напрямую используя CodeAnalysisResult. Контейнер получает метки
+// LabelVibeCoding, владельца и времени создания, по которым его находит CollectOrphanedContainers;
+// если DockerManager не реализует LabeledContainerCreator, контейнер создаётся без меток
+func (a *DockerAdapter) CreateContainer(ctx context.Context, analysis *codevalidation.CodeAnalysisResult, owner ContainerOwner) (string, error) {
+	if creator, ok := a.dockerManager.(codevalidation.LabeledContainerCreator); ok {
+		return creator.CreateLabeledContainer(ctx, analysis, owner.labels(time.Now()))
+	}
 	return a.dockerManager.CreateContainer(ctx, analysis)
 }
 
+// errContainerListUnsupported DockerManager не умеет перечислять контейнеры
+var errContainerListUnsupported = errors.New("docker manager does not support listing containers")
+
+// ListContainers перечисляет контейнеры с меткой label (key или key=value)
+func (a *DockerAdapter) ListContainers(ctx context.Context, label string) ([]codevalidation.ContainerInfo, error) {
+	lister, ok := a.dockerManager.(codevalidation.ContainerLister)
+	if !ok {
+		return nil, errContainerListUnsupported
+	}
+	return lister.ListContainers(ctx, label)
+}
+
 // CopyFilesToContainer копирует файлы в контейнер
 func (a *DockerAdapter) CopyFilesToContainer(ctx context.Context, containerID string, files map[string]string) error {
 	return a.dockerManager.CopyFilesToContainer(ctx, containerID, files)
@@ -38,6 +59,53 @@ func (a *DockerAdapter) ExecuteValidation(ctx context.Context, containerID strin
 	return a.dockerManager.ExecuteValidation(ctx, containerID, analysis)
 }
 
+// ExecuteCommand выполняет одну команду с каталогом и переменными окружения из opts. Если DockerManager
+// не реализует CommandExecutor, команда выполняется через ExecuteValidation с cd и export в начале,
+// а stdout и stderr остаются общим выводом (Combined)
+func (a *DockerAdapter) ExecuteCommand(ctx context.Context, containerID string, analysis *codevalidation.CodeAnalysisResult, command string, opts codevalidation.ExecOptions) (*codevalidation.ExecResult, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if executor, ok := a.dockerManager.(codevalidation.CommandExecutor); ok {
+		return executor.ExecuteCommand(ctx, containerID, analysis, command, opts)
+	}
+
+	shellCommand := command
+	if prefix := shellPrefix(opts); prefix != "" {
+		shellCommand = prefix + command
+	}
+	commandAnalysis := *analysis
+	commandAnalysis.Commands = []string{shellCommand}
+	start := time.Now()
+	validation, err := a.dockerManager.ExecuteValidation(ctx, containerID, &commandAnalysis)
+	if err != nil {
+		return nil, err
+	}
+	return &codevalidation.ExecResult{
+		Command:  shellCommand,
+		WorkDir:  opts.WorkDir,
+		Env:      opts.EnvList(),
+		Stdout:   validation.Output,
+		ExitCode: validation.ExitCode,
+		Success:  validation.Success,
+		Duration: time.Since(start),
+		Combined: true,
+	}, nil
+}
+
+// shellPrefix export переменных и cd в каталог для ExecuteValidation, где их нельзя передать docker exec
+func shellPrefix(opts codevalidation.ExecOptions) string {
+	var prefix strings.Builder
+	for _, kv := range opts.EnvList() {
+		name, value, _ := strings.Cut(kv, "=")
+		prefix.WriteString(fmt.Sprintf("export %s=%s; ", name, shellQuote(value)))
+	}
+	if opts.WorkDir != "" {
+		prefix.WriteString(fmt.Sprintf("cd %s && ", shellQuote(opts.WorkDir)))
+	}
+	return prefix.String()
+}
+
 // RemoveContainer удаляет контейнер
 func (a *DockerAdapter) RemoveContainer(ctx context.Context, containerID string) error {
 	return a.dockerManager.RemoveContainer(ctx, containerID)
@@ -0,0 +1,109 @@
+package vibecoding
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"ai-chatter/internal/codevalidation"
+	"ai-chatter/internal/llm"
+)
+
+func TestExecuteCommandWithOptions_FallbackPrefixesWorkdirAndEnv(t *testing.T) {
+	docker := &commandRecordingDocker{DockerManager: codevalidation.NewMockDockerClient()}
+	session := protectedSession()
+	session.Docker = NewDockerAdapter(docker)
+	session.ContainerID = "container-1"
+
+	result, err := session.ExecuteCommandWithOptions(context.Background(), "go build ./...", codevalidation.ExecOptions{
+		WorkDir: "cmd/server",
+		Env:     map[string]string{"GOFLAGS": "-mod=mod", "CGO_ENABLED": "0"},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteCommandWithOptions: %v", err)
+	}
+	want := "export CGO_ENABLED='0'; export GOFLAGS='-mod=mod'; cd 'cmd/server' && go build ./..."
+	if len(docker.commands) != 1 || docker.commands[0] != want {
+		t.Fatalf("commands = %q, want %q", docker.commands, want)
+	}
+	if !result.Combined || result.Command != want || !result.Success {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestExecuteCommandWithOptions_SeparateStreams(t *testing.T) {
+	session := protectedSession()
+	session.Docker = NewDockerAdapter(codevalidation.NewMockDockerClient())
+	session.ContainerID = "container-1"
+
+	result, err := session.ExecuteCommandWithOptions(context.Background(), "pytest", codevalidation.ExecOptions{
+		WorkDir: "tests",
+		Env:     map[string]string{"PYTHONPATH": "src"},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteCommandWithOptions: %v", err)
+	}
+	if result.Combined || result.WorkDir != "/workspace/tests" || strings.Join(result.Env, ",") != "PYTHONPATH=src" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	// Прежний API возвращает общий вывод в формате ExecuteValidation
+	legacy, err := session.ExecuteCommand(context.Background(), "pytest")
+	if err != nil {
+		t.Fatalf("ExecuteCommand: %v", err)
+	}
+	if !legacy.Success || !strings.HasPrefix(legacy.Output, "=== Command: pytest ===\n") {
+		t.Errorf("unexpected legacy result: %+v", legacy)
+	}
+}
+
+func TestExecuteCommandWithOptions_RejectsInvalidOptions(t *testing.T) {
+	docker := &commandRecordingDocker{DockerManager: codevalidation.NewMockDockerClient()}
+	session := protectedSession()
+	session.Docker = NewDockerAdapter(docker)
+	session.ContainerID = "container-1"
+
+	for _, opts := range []codevalidation.ExecOptions{
+		{WorkDir: "../../etc"},
+		{Env: map[string]string{"BAD NAME": "x"}},
+	} {
+		if _, err := session.ExecuteCommandWithOptions(context.Background(), "ls", opts); !errors.Is(err, codevalidation.ErrInvalidExecOptions) {
+			t.Errorf("options %+v: expected ErrInvalidExecOptions, got %v", opts, err)
+		}
+	}
+	if len(docker.commands) != 0 {
+		t.Errorf("invalid options must not reach the container: %q", docker.commands)
+	}
+}
+
+// optionsRecordingBackend запоминает параметры vibe_execute_command
+type optionsRecordingBackend struct {
+	VibeToolBackend
+	command string
+	opts    codevalidation.ExecOptions
+}
+
+func (b *optionsRecordingBackend) ExecuteCommandWithOptions(ctx context.Context, userID int64, command string, opts codevalidation.ExecOptions) VibeCodingMCPResult {
+	b.command, b.opts = command, opts
+	return VibeCodingMCPResult{Success: true}
+}
+
+func TestExecuteVibeTool_PassesWorkdirAndEnv(t *testing.T) {
+	backend := &optionsRecordingBackend{}
+	_, err := executeVibeTool(context.Background(), backend, 1, llm.FunctionCall{
+		Name: "vibe_execute_command",
+		Arguments: map[string]interface{}{
+			"command": "go test ./...",
+			"workdir": "services/api",
+			"env":     map[string]interface{}{"GOFLAGS": "-count=1", "RETRIES": float64(3)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("executeVibeTool: %v", err)
+	}
+	if backend.command != "go test ./..." || backend.opts.WorkDir != "services/api" ||
+		backend.opts.Env["GOFLAGS"] != "-count=1" || backend.opts.Env["RETRIES"] != "3" {
+		t.Errorf("unexpected call: %q %+v", backend.command, backend.opts)
+	}
+}
@@ -0,0 +1,119 @@
+package vibecoding
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"ai-chatter/internal/codevalidation"
+)
+
+// maxFileHistory сколько предыдущих версий сгенерированного файла хранится для отката
+const maxFileHistory = 5
+
+// recordFileHistoryLocked запоминает предыдущую версию сгенерированного файла перед перезаписью.
+// Для нового файла заводится пустая история: при откате такой файл удаляется
+func (s *VibeCodingSession) recordFileHistoryLocked(filename string) {
+	if s.FileHistory == nil {
+		s.FileHistory = make(map[string][]string)
+	}
+	previous, exists := s.GeneratedFiles[filename]
+	if !exists {
+		s.FileHistory[filename] = nil
+		return
+	}
+	history := append(s.FileHistory[filename], previous)
+	if len(history) > maxFileHistory {
+		history = history[len(history)-maxFileHistory:]
+	}
+	s.FileHistory[filename] = history
+}
+
+// RollbackResult итог отката сгенерированных файлов
+type RollbackResult struct {
+	Restored []string // Файлы, возвращённые к предыдущей версии
+	Removed  []string // Созданные генерацией файлы, которые удалены
+}
+
+// RollbackGeneratedFiles откатывает каждый сгенерированный файл на одну версию назад: предыдущее
+// содержимое копируется в контейнер, а файлы без предыдущей версии удаляются из сессии и контейнера
+func (s *VibeCodingSession) RollbackGeneratedFiles(ctx context.Context) (RollbackResult, error) {
+	s.touch()
+	defer s.persist()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var result RollbackResult
+	restored := make(map[string]string)
+	for filename := range s.GeneratedFiles {
+		history, tracked := s.FileHistory[filename]
+		if !tracked {
+			continue
+		}
+		if len(history) == 0 {
+			result.Removed = append(result.Removed, filename)
+			continue
+		}
+		restored[filename] = history[len(history)-1]
+		result.Restored = append(result.Restored, filename)
+	}
+	if len(restored) == 0 && len(result.Removed) == 0 {
+		return result, nil
+	}
+	sort.Strings(result.Restored)
+	sort.Strings(result.Removed)
+
+	if s.ContainerID != "" {
+		if len(restored) > 0 {
+			if err := s.Docker.CopyFilesToContainer(ctx, s.ContainerID, restored); err != nil {
+				return RollbackResult{}, fmt.Errorf("failed to copy restored files to container: %w", err)
+			}
+		}
+		if len(result.Removed) > 0 {
+			if err := s.removeFromContainerLocked(ctx, result.Removed); err != nil {
+				return RollbackResult{}, err
+			}
+		}
+	}
+
+	for filename, content := range restored {
+		history := s.FileHistory[filename]
+		s.FileHistory[filename] = history[:len(history)-1]
+		s.GeneratedFiles[filename] = content
+	}
+	for _, filename := range result.Removed {
+		delete(s.GeneratedFiles, filename)
+		delete(s.FileHistory, filename)
+	}
+	if s.Context != nil {
+		s.refreshDependencyGraph()
+	}
+
+	log.Printf("⏪ Rolled back generated files for user %d: %d restored, %d removed", s.UserID, len(result.Restored), len(result.Removed))
+	return result, nil
+}
+
+// removeFromContainerLocked удаляет файлы из рабочего каталога контейнера
+func (s *VibeCodingSession) removeFromContainerLocked(ctx context.Context, files []string) error {
+	quoted := make([]string, 0, len(files))
+	for _, f := range files {
+		quoted = append(quoted, shellQuote(path.Join("/workspace", f)))
+	}
+	command := "rm -f " + strings.Join(quoted, " ")
+	analysis := &codevalidation.CodeAnalysisResult{Commands: []string{command}}
+	if s.Analysis != nil {
+		analysis.Language = s.Analysis.Language
+		analysis.DockerImage = s.Analysis.DockerImage
+	}
+	res, err := s.executeWithTimeout(ctx, analysis, command)
+	if err != nil {
+		return fmt.Errorf("failed to remove files in container: %w", err)
+	}
+	if res != nil && !res.Success {
+		return fmt.Errorf("failed to remove files in container: %s", strings.TrimSpace(res.Output))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package vibecoding
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ai-chatter/internal/codevalidation"
+)
+
+func TestRollbackGeneratedFiles(t *testing.T) {
+	docker := &commandRecordingDocker{DockerManager: codevalidation.NewMockDockerClient()}
+	session := protectedSession()
+	session.Docker = NewDockerAdapter(docker)
+	session.ContainerID = "container-1"
+	ctx := context.Background()
+
+	for i := 1; i <= maxFileHistory+2; i++ {
+		session.AddGeneratedFile("test_calc.py", strings.Repeat("#", i))
+	}
+	session.AddGeneratedFile("test_new.py", "new")
+	// Файлы, записанные не генерацией, откат не трогает
+	session.GeneratedFiles["PROJECT_CONTEXT.md"] = "context"
+
+	if got := len(session.FileHistory["test_calc.py"]); got != maxFileHistory {
+		t.Fatalf("history must keep %d versions, got %d", maxFileHistory, got)
+	}
+
+	result, err := session.RollbackGeneratedFiles(ctx)
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	want := RollbackResult{Restored: []string{"test_calc.py"}, Removed: []string{"test_new.py"}}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if session.GeneratedFiles["test_calc.py"] != strings.Repeat("#", maxFileHistory+1) {
+		t.Errorf("previous version not restored: %q", session.GeneratedFiles["test_calc.py"])
+	}
+	if _, exists := session.GeneratedFiles["test_new.py"]; exists {
+		t.Error("newly created file must be removed")
+	}
+	if session.GeneratedFiles["PROJECT_CONTEXT.md"] != "context" {
+		t.Error("untracked generated files must be kept")
+	}
+	if len(docker.commands) != 1 || docker.commands[0] != "rm -f '/workspace/test_new.py'" {
+		t.Errorf("unexpected container commands: %v", docker.commands)
+	}
+
+	// Восстановленная версия снова откатывается по оставшейся истории
+	if _, err := session.RollbackGeneratedFiles(ctx); err != nil {
+		t.Fatalf("second rollback: %v", err)
+	}
+	if session.GeneratedFiles["test_calc.py"] != strings.Repeat("#", maxFileHistory) {
+		t.Errorf("second rollback restored %q", session.GeneratedFiles["test_calc.py"])
+	}
+
+	// Сгенерированная версия исходного файла сохраняется под другим именем и откатывается отдельно
+	target := session.AddGeneratedFile("main.py", "generated")
+	result, _ = session.RollbackGeneratedFiles(ctx)
+	if !reflect.DeepEqual(result.Removed, []string{target}) || session.Files["main.py"] == "" {
+		t.Errorf("rollback must remove only the generated copy: %+v", result)
+	}
+}
@@ -0,0 +1,90 @@
+package vibecoding
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ErrOriginalFileProtected сгенерированный файл пытается заменить исходный файл пользователя
+var ErrOriginalFileProtected = errors.New("original file is protected")
+
+// WriteFileOptions параметры записи файла в сессию
+type WriteFileOptions struct {
+	Generated         bool // Файл создан LLM, а не загружен пользователем
+	OverwriteOriginal bool // Явное разрешение заменить исходный файл сгенерированным содержимым
+}
+
+// generatedConflictName имя для сгенерированной версии исходного файла: main.py -> main.generated.py
+func generatedConflictName(filename string) string {
+	dir, base := path.Split(filename)
+	ext := path.Ext(base)
+	if ext == base {
+		// .env, Makefile и т.п.
+		return filename + ".generated"
+	}
+	return dir + strings.TrimSuffix(base, ext) + ".generated" + ext
+}
+
+// originalNameForGenerated имя исходного файла для сгенерированной версии: main.generated.py -> main.py
+func originalNameForGenerated(filename string) (string, bool) {
+	dir, base := path.Split(filename)
+	if name, ok := strings.CutSuffix(base, ".generated"); ok && name != "" {
+		return dir + name, true
+	}
+	ext := path.Ext(base)
+	if name, ok := strings.CutSuffix(strings.TrimSuffix(base, ext), ".generated"); ok && name != "" {
+		return dir + name + ext, true
+	}
+	return "", false
+}
+
+// recordUploadedOriginalLocked запоминает загруженную версию исходного файла перед первой перезаписью
+// сгенерированным содержимым, чтобы /vibecoding_diff показал изменения относительно неё
+func (s *VibeCodingSession) recordUploadedOriginalLocked(filename string) {
+	if _, recorded := s.uploadedOriginals[filename]; recorded {
+		return
+	}
+	if s.uploadedOriginals == nil {
+		s.uploadedOriginals = make(map[string]string)
+	}
+	s.uploadedOriginals[filename] = s.Files[filename]
+}
+
+// recordConflictLocked запоминает предотвращённую перезапись исходного файла
+func (s *VibeCodingSession) recordConflictLocked(filename string) {
+	for _, existing := range s.preventedConflicts {
+		if existing == filename {
+			return
+		}
+	}
+	s.preventedConflicts = append(s.preventedConflicts, filename)
+	log.Printf("🛡️ Prevented generated file from overwriting original %s (user %d)", filename, s.UserID)
+}
+
+// PreventedConflicts исходные файлы, которые сгенерированный код пытался перезаписать
+func (s *VibeCodingSession) PreventedConflicts() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	result := append([]string(nil), s.preventedConflicts...)
+	sort.Strings(result)
+	return result
+}
+
+// generatedTargetLocked возвращает имя, под которым сгенерированный файл попадёт в сессию и архив,
+// не затирая исходный файл с тем же именем
+func (s *VibeCodingSession) generatedTargetLocked(filename string) string {
+	if _, ok := s.Files[filename]; !ok {
+		return filename
+	}
+	s.recordConflictLocked(filename)
+	return generatedConflictName(filename)
+}
+
+// protectedWriteError ошибка для попытки перезаписать исходный файл без overwrite_original
+func protectedWriteError(filename string) error {
+	return fmt.Errorf("%w: %s is an original project file, pass overwrite_original=true to replace it", ErrOriginalFileProtected, filename)
+}
@@ -0,0 +1,150 @@
+package vibecoding
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"ai-chatter/internal/codevalidation"
+)
+
+func protectedSession() *VibeCodingSession {
+	return &VibeCodingSession{
+		UserID:         1,
+		ProjectName:    "calc",
+		Files:          map[string]string{"main.py": "def add(a, b): return a + b", "tests/test_main.py": "# user test"},
+		GeneratedFiles: make(map[string]string),
+		Analysis:       &codevalidation.CodeAnalysisResult{Language: "Python"},
+	}
+}
+
+func TestGeneratedConflictName(t *testing.T) {
+	for in, want := range map[string]string{
+		"main.py":               "main.generated.py",
+		"src/app/index.test.js": "src/app/index.test.generated.js",
+		"Makefile":              "Makefile.generated",
+		"config/.env":           "config/.env.generated",
+	} {
+		if got := generatedConflictName(in); got != want {
+			t.Errorf("generatedConflictName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAddGeneratedFile_DoesNotShadowOriginal(t *testing.T) {
+	session := protectedSession()
+
+	if name := session.AddGeneratedFile("main.py", "def add(a, b): return 0"); name != "main.generated.py" {
+		t.Fatalf("conflicting generated file stored as %q", name)
+	}
+	if name := session.AddGeneratedFile("test_calc.py", "import main"); name != "test_calc.py" {
+		t.Fatalf("new generated file renamed to %q", name)
+	}
+	if session.Files["main.py"] != "def add(a, b): return a + b" {
+		t.Fatal("original file was modified")
+	}
+
+	archive, err := CreateResultArchive(session)
+	if err != nil {
+		t.Fatalf("CreateResultArchive: %v", err)
+	}
+	files := readZip(t, archive)
+	if files["main.py"] != "def add(a, b): return a + b" || files["main.generated.py"] != "def add(a, b): return 0" {
+		t.Errorf("archive must keep the original and the generated version side by side: %v", files)
+	}
+	if !strings.Contains(files["VIBECODING_SESSION.md"], "Prevented conflicts") || !strings.Contains(files["VIBECODING_SESSION.md"], "- main.py") {
+		t.Errorf("session summary must list prevented conflicts:\n%s", files["VIBECODING_SESSION.md"])
+	}
+}
+
+func TestWriteFile_GeneratedCannotOverwriteOriginal(t *testing.T) {
+	session := protectedSession()
+	ctx := context.Background()
+
+	err := session.WriteFile(ctx, "tests/test_main.py", "# rewritten", true)
+	if !errors.Is(err, ErrOriginalFileProtected) {
+		t.Fatalf("expected ErrOriginalFileProtected, got %v", err)
+	}
+	if session.Files["tests/test_main.py"] != "# user test" || len(session.GeneratedFiles) != 0 {
+		t.Fatal("rejected write must not change the session")
+	}
+	if got := session.PreventedConflicts(); len(got) != 1 || got[0] != "tests/test_main.py" {
+		t.Errorf("PreventedConflicts = %v", got)
+	}
+
+	// Явная перезапись и обычная запись пользовательского файла разрешены
+	if err := session.WriteFileWithOptions(ctx, "tests/test_main.py", "# rewritten", WriteFileOptions{Generated: true, OverwriteOriginal: true}); err != nil {
+		t.Fatalf("overwrite_original write failed: %v", err)
+	}
+	if err := session.WriteFile(ctx, "main.py", "# edited", false); err != nil {
+		t.Fatalf("plain write failed: %v", err)
+	}
+	if session.Files["tests/test_main.py"] != "# rewritten" || session.Files["main.py"] != "# edited" {
+		t.Errorf("unexpected files: %v", session.Files)
+	}
+}
+
+func TestMCPWriteFile_OverwriteOriginalFlag(t *testing.T) {
+	session := protectedSession()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Сервер повторяет обработчик vibe_write_file из cmd/vibecoding-mcp-server
+	server := mcp.NewServer(&mcp.Implementation{Name: "vibe-test", Version: "v0.0.1"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "vibe_write_file"}, func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]interface{}]) (*mcp.CallToolResultFor[any], error) {
+		filename, _ := params.Arguments["filename"].(string)
+		content, _ := params.Arguments["content"].(string)
+		if err := session.WriteFileWithOptions(ctx, filename, content, WriteFileOptionsFromArgs(params.Arguments)); err != nil {
+			return &mcp.CallToolResultFor[any]{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("❌ Failed to write file: %v", err)}}}, nil
+		}
+		return &mcp.CallToolResultFor[any]{Content: []mcp.Content{&mcp.TextContent{Text: "✅ written"}}}, nil
+	})
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	go func() { _ = server.Run(ctx, serverTransport) }()
+
+	clientSession, err := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "v0.0.1"}, nil).Connect(ctx, clientTransport)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer clientSession.Close()
+	client := &VibeCodingMCPClient{session: clientSession}
+
+	result := client.WriteFile(ctx, 1, "main.py", "def add(a, b): return 0", true, false)
+	if result.Success || !strings.Contains(result.Message, "overwrite_original") {
+		t.Fatalf("expected protected write to fail with a hint, got %+v", result)
+	}
+	if session.Files["main.py"] != "def add(a, b): return a + b" {
+		t.Fatal("original file was overwritten via MCP")
+	}
+
+	result = client.WriteFile(ctx, 1, "main.py", "def add(a, b): return 0", true, true)
+	if !result.Success || session.Files["main.py"] != "def add(a, b): return 0" {
+		t.Fatalf("overwrite_original=true must replace the file: %+v", result)
+	}
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
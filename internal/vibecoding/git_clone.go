@@ -0,0 +1,377 @@
+package vibecoding
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxCloneSize максимальный размер дерева клонируемого репозитория: больше — клон не выполняется.
+// В сессию попадают только файлы в пределах MaxFileSize и MaxTotalSize, как из архива
+const MaxCloneSize = 200 * 1024 * 1024
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+	cloneTimeout        = 2 * time.Minute
+)
+
+var (
+	// ErrRepoNotFound репозиторий или ветка не существуют (или репозиторий приватный, а токена нет)
+	ErrRepoNotFound = errors.New("репозиторий не найден")
+	// ErrRepoAuth нет доступа к репозиторию с настроенным GITHUB_TOKEN
+	ErrRepoAuth = errors.New("нет доступа к репозиторию")
+	// ErrRepoTooLarge репозиторий больше MaxCloneSize
+	ErrRepoTooLarge = errors.New("репозиторий слишком большой")
+)
+
+// RepoCloner получает файлы проекта из git репозитория; подменяется в тестах
+type RepoCloner interface {
+	Clone(ctx context.Context, repoURL, branch string) (*ClonedRepo, error)
+}
+
+// ClonedRepo файлы рабочего дерева репозитория и его происхождение
+type ClonedRepo struct {
+	Files       map[string]string
+	ProjectName string
+	Origin      SessionOrigin
+}
+
+// SessionOrigin откуда взят проект сессии, созданной через /vibecoding_clone
+type SessionOrigin struct {
+	URL    string `json:"url"`
+	Branch string `json:"branch,omitempty"`
+	Commit string `json:"commit"`
+}
+
+// SetOrigin запоминает репозиторий и коммит, из которых создана сессия
+func (s *VibeCodingSession) SetOrigin(origin SessionOrigin) {
+	s.mutex.Lock()
+	s.Origin = &origin
+	s.mutex.Unlock()
+	s.persist()
+}
+
+// String URL, ветка и короткий SHA коммита для сообщений пользователю
+func (o SessionOrigin) String() string {
+	text := o.URL
+	if o.Branch != "" {
+		text += " (" + o.Branch + ")"
+	}
+	return text + " @ " + shortCommit(o.Commit)
+}
+
+// GitCloner клонирует репозиторий git с хоста бота (shallow, без checkout) и читает дерево
+// через git archive. Для github.com размер и доступ проверяются через GitHub API до клонирования
+type GitCloner struct {
+	Token      string       // GITHUB_TOKEN для приватных репозиториев github.com
+	GitPath    string       // пусто — git из PATH
+	APIBaseURL string       // пусто — https://api.github.com
+	HTTPClient *http.Client // nil — http.DefaultClient
+	MaxSize    int64        // 0 — MaxCloneSize
+}
+
+// repoRef разобранный URL репозитория
+type repoRef struct {
+	url         string // URL без учётных данных
+	host        string
+	owner, name string // для github.com
+}
+
+// parseRepoURL принимает https URL репозитория или короткую форму github.com/owner/repo.
+// Другие схемы (ssh, file) не поддерживаются: клон выполняется на хосте бота
+func parseRepoURL(raw string) (repoRef, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.HasPrefix(raw, "-") {
+		return repoRef{}, fmt.Errorf("укажите URL репозитория: /vibecoding_clone <git-url> [ветка]")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return repoRef{}, fmt.Errorf("некорректный URL репозитория: %s", raw)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return repoRef{}, fmt.Errorf("поддерживаются только https URL репозиториев, получено %s://", u.Scheme)
+	}
+	u.User = nil
+	u.RawQuery, u.Fragment = "", ""
+	path := strings.Trim(u.Path, "/")
+	if path == "" {
+		return repoRef{}, fmt.Errorf("в URL не указан репозиторий: %s", u.String())
+	}
+	ref := repoRef{url: u.String(), host: strings.ToLower(u.Host)}
+	if ref.host == "github.com" || ref.host == "www.github.com" {
+		parts := strings.Split(strings.TrimSuffix(path, ".git"), "/")
+		if len(parts) < 2 {
+			return repoRef{}, fmt.Errorf("ожидается URL вида https://github.com/owner/repo, получено %s", u.String())
+		}
+		ref.host = "github.com"
+		ref.owner, ref.name = parts[0], parts[1]
+		ref.url = fmt.Sprintf("https://github.com/%s/%s.git", ref.owner, ref.name)
+	}
+	return ref, nil
+}
+
+// projectName название проекта: последний сегмент пути без .git
+func (r repoRef) projectName() string {
+	if r.name != "" {
+		return r.name
+	}
+	path := strings.TrimSuffix(strings.TrimRight(r.url, "/"), ".git")
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		path = path[i+1:]
+	}
+	if path == "" {
+		return "vibecoding-project"
+	}
+	return path
+}
+
+// Clone клонирует ветку branch (пусто — ветка по умолчанию) и возвращает файлы по тем же правилам, что и архив
+func (c *GitCloner) Clone(ctx context.Context, repoURL, branch string) (*ClonedRepo, error) {
+	ref, err := parseRepoURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(branch, "-") {
+		return nil, fmt.Errorf("некорректное имя ветки: %s", branch)
+	}
+	ctx, cancel := context.WithTimeout(ctx, cloneTimeout)
+	defer cancel()
+
+	if ref.host == "github.com" {
+		if err := c.checkGitHubRepo(ctx, ref); err != nil {
+			return nil, err
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "vibecoding-clone-*")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать временный каталог: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	log.Printf("📥 Cloning %s (branch %q)", ref.url, branch)
+	args := []string{"-c", "credential.helper="}
+	if c.Token != "" && ref.host == "github.com" {
+		// Токен передаётся заголовком, а не в URL: так он не попадёт в сообщения об ошибках git
+		basic := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + c.Token))
+		args = append(args, "-c", "http.extraHeader=Authorization: Basic "+basic)
+	}
+	args = append(args, "clone", "--depth", "1", "--single-branch", "--no-checkout")
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	args = append(args, "--", ref.url, dir)
+	if _, err := c.git(ctx, "", args...); err != nil {
+		return nil, classifyCloneError(err, branch)
+	}
+
+	files, commit, err := filesFromGitDir(ctx, c.gitPath(), dir, ref.projectName(), c.maxSize())
+	if err != nil {
+		return nil, err
+	}
+	return &ClonedRepo{
+		Files:       files,
+		ProjectName: ref.projectName(),
+		Origin:      SessionOrigin{URL: ref.url, Branch: branch, Commit: commit},
+	}, nil
+}
+
+// checkGitHubRepo проверяет доступ и размер репозитория через GitHub API до клонирования.
+// Если API недоступен (лимит запросов, сеть), проверка пропускается: размер всё равно проверяется по дереву
+func (c *GitCloner) checkGitHubRepo(ctx context.Context, ref repoRef) error {
+	base := c.APIBaseURL
+	if base == "" {
+		base = defaultGitHubAPIURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/repos/%s/%s", strings.TrimRight(base, "/"), ref.owner, ref.name), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("⚠️ GitHub API check for %s/%s failed: %v", ref.owner, ref.name, err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		if c.Token == "" {
+			return fmt.Errorf("%w: %s/%s (для приватного репозитория нужен GITHUB_TOKEN)", ErrRepoNotFound, ref.owner, ref.name)
+		}
+		return fmt.Errorf("%w: %s/%s", ErrRepoNotFound, ref.owner, ref.name)
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("%w: GitHub отклонил GITHUB_TOKEN", ErrRepoAuth)
+	case resp.StatusCode != http.StatusOK:
+		log.Printf("⚠️ GitHub API check for %s/%s returned %d, skipping", ref.owner, ref.name, resp.StatusCode)
+		return nil
+	}
+
+	var repo struct {
+		Size int64 `json:"size"` // КБ
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&repo); err != nil {
+		log.Printf("⚠️ Failed to decode GitHub repo info for %s/%s: %v", ref.owner, ref.name, err)
+		return nil
+	}
+	if size := repo.Size * 1024; size > c.maxSize() {
+		return fmt.Errorf("%w: %s/%s занимает %s (максимум %s)", ErrRepoTooLarge, ref.owner, ref.name, formatBytes(size), formatBytes(c.maxSize()))
+	}
+	return nil
+}
+
+// filesFromGitDir читает дерево HEAD клона без checkout: сначала суммирует размер файлов
+// (git ls-tree), затем извлекает файлы через git archive теми же фильтрами, что и архив
+func filesFromGitDir(ctx context.Context, gitPath, dir, projectName string, maxSize int64) (map[string]string, string, error) {
+	out, err := runGit(ctx, gitPath, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, "", fmt.Errorf("не удалось определить коммит: %w", err)
+	}
+	commit := strings.TrimSpace(out)
+
+	tree, err := runGit(ctx, gitPath, dir, "ls-tree", "-r", "-l", "HEAD")
+	if err != nil {
+		return nil, "", fmt.Errorf("не удалось прочитать дерево репозитория: %w", err)
+	}
+	if size := treeSize(tree); size > maxSize {
+		return nil, "", fmt.Errorf("%w: файлы занимают %s (максимум %s)", ErrRepoTooLarge, formatBytes(size), formatBytes(maxSize))
+	}
+
+	// Каталог-префикс нужен normalizeFilename: как и в архиве, корневая папка проекта отбрасывается
+	cmd := exec.CommandContext(ctx, gitPath, "archive", "--format=tar", "--prefix="+projectName+"/", "HEAD")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, "", fmt.Errorf("не удалось запустить git archive: %w", err)
+	}
+	entries, readErr := tarEntries(stdout)
+	if readErr != nil {
+		// Останавливаем git, чтобы он не завис на записи в непрочитанный pipe
+		_ = cmd.Process.Kill()
+	}
+	if err := cmd.Wait(); err != nil && readErr == nil {
+		return nil, "", fmt.Errorf("git archive: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if readErr != nil {
+		return nil, "", readErr
+	}
+
+	files, err := filesFromEntries(entries)
+	if err != nil {
+		return nil, "", fmt.Errorf("репозиторий не содержит подходящих файлов для анализа")
+	}
+	log.Printf("📥 Read %d files from %s @ %s", len(files), projectName, shortCommit(commit))
+	return files, commit, nil
+}
+
+// treeSize сумма размеров файлов из вывода git ls-tree -l: "<mode> blob <sha> <size>\t<path>"
+func treeSize(lsTree string) int64 {
+	var total int64
+	for _, line := range strings.Split(lsTree, "\n") {
+		meta, _, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 4 || fields[1] != "blob" {
+			continue
+		}
+		if size, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+			total += size
+		}
+	}
+	return total
+}
+
+// classifyCloneError переводит ошибку git clone в понятное пользователю сообщение
+func classifyCloneError(err error, branch string) error {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case branch != "" && strings.Contains(msg, "remote branch") && strings.Contains(msg, "not found"):
+		return fmt.Errorf("%w: ветка %s", ErrRepoNotFound, branch)
+	case strings.Contains(msg, "authentication failed"), strings.Contains(msg, "could not read username"),
+		strings.Contains(msg, "terminal prompts disabled"), strings.Contains(msg, "403"):
+		return fmt.Errorf("%w: репозиторий приватный или токен не подходит", ErrRepoAuth)
+	case strings.Contains(msg, "not found"), strings.Contains(msg, "does not appear to be a git repository"),
+		strings.Contains(msg, "404"):
+		return ErrRepoNotFound
+	case strings.Contains(msg, "could not resolve host"):
+		return fmt.Errorf("не удалось подключиться к серверу репозитория: %v", err)
+	default:
+		return fmt.Errorf("не удалось клонировать репозиторий: %v", err)
+	}
+}
+
+func (c *GitCloner) git(ctx context.Context, dir string, args ...string) (string, error) {
+	return runGit(ctx, c.gitPath(), dir, args...)
+}
+
+func (c *GitCloner) gitPath() string {
+	if c.GitPath != "" {
+		return c.GitPath
+	}
+	return "git"
+}
+
+func (c *GitCloner) maxSize() int64 {
+	if c.MaxSize > 0 {
+		return c.MaxSize
+	}
+	return MaxCloneSize
+}
+
+// runGit выполняет git без интерактивных запросов; stderr добавляется к ошибке
+func runGit(ctx context.Context, gitPath, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, gitPath, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+func formatBytes(n int64) string {
+	const mb = 1024 * 1024
+	if n >= mb {
+		return fmt.Sprintf("%.1f MB", float64(n)/mb)
+	}
+	return fmt.Sprintf("%d KB", n/1024)
+}
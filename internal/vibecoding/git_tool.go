@@ -0,0 +1,167 @@
+package vibecoding
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// gitCommitMarker строка вывода с хэшем коммита: вывод команды в контейнере смешан со stderr
+	gitCommitMarker = "VIBE_GIT_COMMIT="
+	// gitArchiveBegin и gitArchiveEnd обрамляют base64 tar.gz каталога .git в выводе команды
+	gitArchiveBegin = "VIBE_GIT_ARCHIVE_BEGIN"
+	gitArchiveEnd   = "VIBE_GIT_ARCHIVE_END"
+	// gitAuthorName и gitAuthorEmail автор коммитов сессии: в контейнере git обычно не настроен
+	gitAuthorName  = "VibeCoding"
+	gitAuthorEmail = "vibecoding@ai-chatter.local"
+	// sessionEndCommitMessage сообщение коммита незакоммиченных изменений при /vibecoding_end --with-git
+	sessionEndCommitMessage = "VibeCoding: session end"
+)
+
+var gitCommitHashPattern = regexp.MustCompile(gitCommitMarker + `([0-9a-f]{7,64})`)
+
+// GitCommit коммитит текущее состояние рабочего каталога в контейнере: git init (если репозитория
+// ещё нет), git add -A и git commit. Без изменений новый коммит не создаётся и возвращается HEAD
+func (s *VibeCodingSession) GitCommit(ctx context.Context, message string) (string, error) {
+	if strings.TrimSpace(message) == "" {
+		return "", fmt.Errorf("commit message is required")
+	}
+	command := fmt.Sprintf("([ -d .git ] || git init -q) && git add -A && "+
+		"(git diff --cached --quiet && git rev-parse -q --verify HEAD >/dev/null || "+
+		"git -c user.name=%s -c user.email=%s commit -q -m %s) && echo %s$(git rev-parse HEAD)",
+		shellQuote(gitAuthorName), shellQuote(gitAuthorEmail), shellQuote(message), gitCommitMarker)
+
+	result, err := s.ExecuteCommand(ctx, command)
+	if err != nil {
+		return "", fmt.Errorf("git commit failed: %w", err)
+	}
+	if !result.Success {
+		return "", fmt.Errorf("git commit failed: %s", strings.TrimSpace(result.Output))
+	}
+	match := gitCommitHashPattern.FindStringSubmatch(result.Output)
+	if match == nil {
+		return "", fmt.Errorf("git commit hash not found in command output")
+	}
+	log.Printf("📝 Committed VibeCoding workspace for session %s: %s", s.ProjectName, match[1])
+	return match[1], nil
+}
+
+// ExportGitDir выгружает каталог .git из контейнера как tar.gz (через base64 в выводе команды)
+func (s *VibeCodingSession) ExportGitDir(ctx context.Context) ([]byte, error) {
+	command := fmt.Sprintf("[ -d .git ] && echo %s && tar -czf - .git | base64 | tr -d '\\n' && echo && echo %s",
+		gitArchiveBegin, gitArchiveEnd)
+	result, err := s.ExecuteCommand(ctx, command)
+	if err != nil {
+		return nil, fmt.Errorf("export .git failed: %w", err)
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("export .git failed: no git repository in workspace")
+	}
+	// Маркеры ищем как отдельные строки: текст самой команды тоже может попасть в вывод
+	begin := strings.Index(result.Output, gitArchiveBegin+"\n")
+	end := strings.LastIndex(result.Output, "\n"+gitArchiveEnd)
+	if begin < 0 || end < begin {
+		return nil, fmt.Errorf("export .git failed: archive not found in command output")
+	}
+	encoded := strings.Join(strings.Fields(result.Output[begin+len(gitArchiveBegin):end]), "")
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("export .git failed: %w", err)
+	}
+	return data, nil
+}
+
+// addGitHistory распаковывает tar.gz каталога .git в zip архив результата. Возвращает число
+// добавленных файлов; лимиты MaxFiles и MaxTotalSize действуют и здесь
+func addGitHistory(zipWriter *zip.Writer, gitArchive []byte) (int, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(gitArchive))
+	if err != nil {
+		return 0, fmt.Errorf("open .git archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	added := 0
+	total := int64(0)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return added, nil
+		}
+		if err != nil {
+			return added, fmt.Errorf("read .git archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if name != ".git" && !strings.HasPrefix(name, ".git/") {
+			continue
+		}
+		if added >= MaxFiles || total+hdr.Size > MaxTotalSize {
+			return added, fmt.Errorf(".git is too large for the result archive")
+		}
+		w, err := zipWriter.Create(name)
+		if err != nil {
+			return added, fmt.Errorf("add %s: %w", name, err)
+		}
+		if _, err := io.Copy(w, io.LimitReader(tr, hdr.Size)); err != nil {
+			return added, fmt.Errorf("add %s: %w", name, err)
+		}
+		total += hdr.Size
+		added++
+	}
+}
+
+// GitCommitToolHandler реализация vibe_git_commit, общая для stdio и HTTP MCP серверов
+func GitCommitToolHandler(sm *SessionManager) mcp.ToolHandlerFor[map[string]interface{}, any] {
+	return func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]interface{}]) (*mcp.CallToolResultFor[any], error) {
+		userIDArg, ok := params.Arguments["user_id"]
+		if !ok {
+			return toolError("❌ user_id parameter is required"), nil
+		}
+		userID, err := ParseUserID(userIDArg)
+		if err != nil {
+			return toolError("❌ Invalid user_id format"), nil
+		}
+		message, ok := params.Arguments["message"].(string)
+		if !ok || strings.TrimSpace(message) == "" {
+			return toolError("❌ message parameter is required and must be a string"), nil
+		}
+
+		log.Printf("📝 MCP Server: Committing workspace for user %d", userID)
+
+		vibeCodingSession := sm.GetSession(userID)
+		if vibeCodingSession == nil {
+			return toolError("❌ No VibeCoding session found for user"), nil
+		}
+
+		hash, err := vibeCodingSession.GitCommit(ctx, message)
+		if err != nil {
+			return toolError(fmt.Sprintf("❌ Failed to commit: %v", err)), nil
+		}
+
+		return &mcp.CallToolResultFor[any]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("✅ Committed workspace: %s", hash)},
+			},
+			Meta: map[string]interface{}{
+				"user_id": userID,
+				"commit":  hash,
+				"success": true,
+			},
+		}, nil
+	}
+}
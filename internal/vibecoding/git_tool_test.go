@@ -0,0 +1,132 @@
+package vibecoding
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"ai-chatter/internal/codevalidation"
+)
+
+// scriptedGitDocker отвечает на команды git как контейнер: вывод предваряется текстом команды
+type scriptedGitDocker struct {
+	codevalidation.DockerManager
+	commands []string
+	output   func(command string) string
+}
+
+func (d *scriptedGitDocker) ExecuteValidation(ctx context.Context, containerID string, analysis *codevalidation.CodeAnalysisResult) (*codevalidation.ValidationResult, error) {
+	command := analysis.Commands[0]
+	d.commands = append(d.commands, command)
+	return &codevalidation.ValidationResult{
+		Success: true,
+		Output:  fmt.Sprintf("=== Command: %s ===\n%s\n\n", command, d.output(command)),
+	}, nil
+}
+
+func gitSession(docker *scriptedGitDocker) *VibeCodingSession {
+	session := protectedSession()
+	docker.DockerManager = codevalidation.NewMockDockerClient()
+	session.Docker = NewDockerAdapter(docker)
+	session.ContainerID = "container-1"
+	return session
+}
+
+func gitDirArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: ".git/", Typeflag: tar.TypeDir, Mode: 0o755})
+	for name, content := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestGitCommit_InitsAddsAndReturnsHash(t *testing.T) {
+	docker := &scriptedGitDocker{output: func(string) string {
+		return "Initialized empty Git repository\nVIBE_GIT_COMMIT=3f2a9c1d4e5b6a7980112233445566778899aabb"
+	}}
+	session := gitSession(docker)
+
+	hash, err := session.GitCommit(context.Background(), "Add calculator's tests")
+	if err != nil {
+		t.Fatalf("GitCommit: %v", err)
+	}
+	if hash != "3f2a9c1d4e5b6a7980112233445566778899aabb" {
+		t.Errorf("unexpected hash %q", hash)
+	}
+	command := docker.commands[0]
+	for _, want := range []string{"git init -q", "git add -A", `commit -q -m 'Add calculator'\''s tests'`} {
+		if !strings.Contains(command, want) {
+			t.Errorf("command must contain %q: %s", want, command)
+		}
+	}
+
+	if _, err := session.GitCommit(context.Background(), "  "); err == nil {
+		t.Error("empty message must fail")
+	}
+}
+
+func TestGitCommit_HashMissing(t *testing.T) {
+	// Текст команды попадает в вывод, но "$(git rev-parse HEAD)" не должен приниматься за хэш
+	session := gitSession(&scriptedGitDocker{output: func(string) string { return "fatal: not a git repository" }})
+	if _, err := session.GitCommit(context.Background(), "msg"); err == nil {
+		t.Fatal("missing hash must fail")
+	}
+}
+
+func TestExportGitDirIntoResultArchive(t *testing.T) {
+	gitArchive := gitDirArchive(t, map[string]string{".git/HEAD": "ref: refs/heads/master\n", ".git/config": "[core]\n"})
+	docker := &scriptedGitDocker{output: func(string) string {
+		encoded := base64.StdEncoding.EncodeToString(gitArchive)
+		return gitArchiveBegin + "\n" + encoded[:10] + "\n" + encoded[10:] + "\n" + gitArchiveEnd
+	}}
+	session := gitSession(docker)
+
+	exported, err := session.ExportGitDir(context.Background())
+	if err != nil {
+		t.Fatalf("ExportGitDir: %v", err)
+	}
+	if !bytes.Equal(exported, gitArchive) {
+		t.Fatal("exported archive must match the container output")
+	}
+
+	data, err := CreateResultArchiveWithOptions(session, ResultArchiveOptions{GitHistory: exported})
+	if err != nil {
+		t.Fatalf("CreateResultArchiveWithOptions: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open result archive: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	if files[".git/HEAD"] != "ref: refs/heads/master\n" || files[".git/config"] != "[core]\n" || files["main.py"] == "" {
+		t.Errorf("archive must contain project files and .git: %v", files)
+	}
+
+	plain, err := CreateResultArchive(session)
+	if err != nil {
+		t.Fatalf("CreateResultArchive: %v", err)
+	}
+	if bytes.Contains(plain, []byte(".git/HEAD")) {
+		t.Error(".git must not be included by default")
+	}
+}
@@ -0,0 +1,519 @@
+package vibecoding
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"ai-chatter/internal/llm"
+)
+
+const (
+	pushTimeout = 2 * time.Minute
+	// pushSummaryMaxChars сколько символов изменённых файлов показывается LLM для описания PR
+	pushSummaryMaxChars = 12000
+)
+
+var (
+	// ErrTokenReadOnly у GITHUB_TOKEN нет права записи в целевой репозиторий
+	ErrTokenReadOnly = errors.New("у GITHUB_TOKEN нет права записи в репозиторий")
+	// ErrNoChanges файлы сессии совпадают с исходными: публиковать нечего
+	ErrNoChanges = errors.New("нет изменений относительно исходных файлов")
+)
+
+// RepoPusher публикует изменения сессии веткой и pull request; подменяется в тестах
+type RepoPusher interface {
+	// Prepare проверяет право записи и отбирает файлы, отличающиеся от исходных
+	Prepare(ctx context.Context, target, base string, origin *SessionOrigin, files map[string]string) (*PushPlan, error)
+	// Push создаёт ветку от последнего коммита базовой ветки и открывает pull request
+	Push(ctx context.Context, plan *PushPlan, branch, title, body string) (*PushResult, error)
+}
+
+// FileChange файл, который попадёт в pull request
+type FileChange struct {
+	Path    string
+	New     bool   // файла нет в базовой ветке
+	content string // содержимое из сессии
+	mode    string // режим файла в базовой ветке (100644, 100755)
+}
+
+// PushPlan изменения, подготовленные к публикации
+type PushPlan struct {
+	Owner, Repo string
+	Base        string       // базовая ветка pull request
+	BaseCommit  string       // последний коммит базовой ветки при подготовке
+	BaseMoved   bool         // базовая ветка ушла вперёд с коммита, с которого начата сессия
+	Changes     []FileChange // новые и изменённые файлы по алфавиту
+	Conflicts   []string     // файлы, изменённые и в сессии, и в базовой ветке после начала сессии
+}
+
+// PushResult открытый pull request
+type PushResult struct {
+	URL        string
+	Number     int
+	Branch     string
+	Commit     string
+	BaseCommit string // коммит базовой ветки, от которого создана ветка
+}
+
+// GitHubPusher публикует изменения через GitHub API (blobs → tree → commit → ref → pull request)
+// без git на хосте бота
+type GitHubPusher struct {
+	Token      string       // GITHUB_TOKEN с правом записи (Contents и Pull requests)
+	APIBaseURL string       // пусто — https://api.github.com
+	HTTPClient *http.Client // nil — http.DefaultClient
+}
+
+// gitTreeEntry файл дерева git из GitHub API
+type gitTreeEntry struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+}
+
+// ownerRepoPattern короткая форма owner/repo
+var ownerRepoPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// parseGitHubTarget репозиторий для публикации: owner/repo или URL github.com
+func parseGitHubTarget(target string) (owner, repo string, err error) {
+	target = strings.TrimSpace(target)
+	if ownerRepoPattern.MatchString(target) && !strings.Contains(strings.SplitN(target, "/", 2)[0], ".") {
+		target = "github.com/" + target
+	}
+	ref, err := parseRepoURL(target)
+	if err != nil {
+		return "", "", err
+	}
+	if ref.owner == "" {
+		return "", "", fmt.Errorf("публикация поддерживается только для github.com, получено %s", ref.url)
+	}
+	return ref.owner, ref.name, nil
+}
+
+// sameGitHubRepo origin сессии указывает на тот же репозиторий
+func sameGitHubRepo(origin *SessionOrigin, owner, repo string) bool {
+	if origin == nil {
+		return false
+	}
+	o, r, err := parseGitHubTarget(origin.URL)
+	return err == nil && strings.EqualFold(o, owner) && strings.EqualFold(r, repo)
+}
+
+// Prepare проверяет токен (право push) и сравнивает файлы сессии с исходными по SHA blob'ов git:
+// для сессии из /vibecoding_clone того же репозитория — с деревом исходного коммита, иначе —
+// с последним коммитом базовой ветки. Удаления не публикуются: в сессию попадают не все файлы репозитория
+func (p *GitHubPusher) Prepare(ctx context.Context, target, base string, origin *SessionOrigin, files map[string]string) (*PushPlan, error) {
+	owner, repo, err := parseGitHubTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	if p.Token == "" {
+		return nil, fmt.Errorf("%w: GITHUB_TOKEN не задан", ErrTokenReadOnly)
+	}
+	ctx, cancel := context.WithTimeout(ctx, pushTimeout)
+	defer cancel()
+
+	var info struct {
+		DefaultBranch string `json:"default_branch"`
+		Permissions   struct {
+			Push bool `json:"push"`
+		} `json:"permissions"`
+	}
+	if err := p.api(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/%s", owner, repo), nil, &info); err != nil {
+		return nil, err
+	}
+	if !info.Permissions.Push {
+		return nil, fmt.Errorf("%w %s/%s: нужен токен с доступом Contents и Pull requests на запись (scope repo)", ErrTokenReadOnly, owner, repo)
+	}
+
+	fromOrigin := sameGitHubRepo(origin, owner, repo)
+	if base == "" {
+		base = info.DefaultBranch
+		if fromOrigin && origin.Branch != "" {
+			base = origin.Branch
+		}
+	}
+	head, err := p.branchHead(ctx, owner, repo, base)
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := p.tree(ctx, owner, repo, head)
+	if err != nil {
+		return nil, err
+	}
+	originTree := headTree
+	plan := &PushPlan{Owner: owner, Repo: repo, Base: base, BaseCommit: head}
+	if fromOrigin && origin.Commit != "" && origin.Commit != head {
+		// Базовая ветка ушла вперёд: исходные файлы — дерево коммита, с которого начата сессия
+		if originTree, err = p.tree(ctx, owner, repo, origin.Commit); err != nil {
+			return nil, err
+		}
+		plan.BaseMoved = true
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		content := files[path]
+		if len(content) == 0 || len(content) > MaxFileSize {
+			continue
+		}
+		sha := gitBlobSHA(content)
+		orig, inOrigin := originTree[path]
+		current, inHead := headTree[path]
+		if inOrigin && orig.SHA == sha || inHead && current.SHA == sha {
+			continue
+		}
+		change := FileChange{Path: path, New: !inHead, content: content, mode: "100644"}
+		if inHead {
+			change.mode = current.Mode
+		}
+		plan.Changes = append(plan.Changes, change)
+		if inHead && (!inOrigin || orig.SHA != current.SHA) {
+			plan.Conflicts = append(plan.Conflicts, path)
+		}
+	}
+	if len(plan.Changes) == 0 {
+		return nil, ErrNoChanges
+	}
+	return plan, nil
+}
+
+// Push создаёт blob'ы, дерево поверх последнего коммита базовой ветки (она могла уйти вперёд
+// и после Prepare), коммит, ветку branch и pull request
+func (p *GitHubPusher) Push(ctx context.Context, plan *PushPlan, branch, title, body string) (*PushResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, pushTimeout)
+	defer cancel()
+	repoPath := fmt.Sprintf("/repos/%s/%s", plan.Owner, plan.Repo)
+
+	head, err := p.branchHead(ctx, plan.Owner, plan.Repo, plan.Base)
+	if err != nil {
+		return nil, err
+	}
+	if head != plan.BaseCommit {
+		log.Printf("🔀 Base branch %s moved %s -> %s before push, basing on the latest head", plan.Base, shortCommit(plan.BaseCommit), shortCommit(head))
+	}
+	var headCommit struct {
+		Tree struct {
+			SHA string `json:"sha"`
+		} `json:"tree"`
+	}
+	if err := p.api(ctx, http.MethodGet, repoPath+"/git/commits/"+head, nil, &headCommit); err != nil {
+		return nil, err
+	}
+
+	entries := make([]gitTreeEntry, 0, len(plan.Changes))
+	for _, change := range plan.Changes {
+		var blob struct {
+			SHA string `json:"sha"`
+		}
+		payload := map[string]string{"content": base64.StdEncoding.EncodeToString([]byte(change.content)), "encoding": "base64"}
+		if err := p.api(ctx, http.MethodPost, repoPath+"/git/blobs", payload, &blob); err != nil {
+			return nil, fmt.Errorf("загрузка %s: %w", change.Path, err)
+		}
+		entries = append(entries, gitTreeEntry{Path: change.Path, Mode: change.mode, Type: "blob", SHA: blob.SHA})
+	}
+
+	var tree, commit struct {
+		SHA string `json:"sha"`
+	}
+	if err := p.api(ctx, http.MethodPost, repoPath+"/git/trees", map[string]interface{}{"base_tree": headCommit.Tree.SHA, "tree": entries}, &tree); err != nil {
+		return nil, fmt.Errorf("создание дерева: %w", err)
+	}
+	if err := p.api(ctx, http.MethodPost, repoPath+"/git/commits", map[string]interface{}{"message": title, "tree": tree.SHA, "parents": []string{head}}, &commit); err != nil {
+		return nil, fmt.Errorf("создание коммита: %w", err)
+	}
+	if err := p.api(ctx, http.MethodPost, repoPath+"/git/refs", map[string]string{"ref": "refs/heads/" + branch, "sha": commit.SHA}, nil); err != nil {
+		return nil, fmt.Errorf("создание ветки %s: %w", branch, err)
+	}
+	var pr struct {
+		HTMLURL string `json:"html_url"`
+		Number  int    `json:"number"`
+	}
+	if err := p.api(ctx, http.MethodPost, repoPath+"/pulls", map[string]string{"title": title, "head": branch, "base": plan.Base, "body": body}, &pr); err != nil {
+		return nil, fmt.Errorf("создание pull request: %w", err)
+	}
+	log.Printf("✅ Opened pull request %s (%d files)", pr.HTMLURL, len(plan.Changes))
+	return &PushResult{URL: pr.HTMLURL, Number: pr.Number, Branch: branch, Commit: commit.SHA, BaseCommit: head}, nil
+}
+
+// branchHead последний коммит ветки
+func (p *GitHubPusher) branchHead(ctx context.Context, owner, repo, branch string) (string, error) {
+	var ref struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	segments := strings.Split(branch, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	err := p.api(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/%s/git/ref/heads/%s", owner, repo, strings.Join(segments, "/")), nil, &ref)
+	if errors.Is(err, ErrRepoNotFound) {
+		return "", fmt.Errorf("%w: ветка %s в %s/%s", ErrRepoNotFound, branch, owner, repo)
+	}
+	return ref.Object.SHA, err
+}
+
+// tree файлы дерева коммита: путь -> запись
+func (p *GitHubPusher) tree(ctx context.Context, owner, repo, commit string) (map[string]gitTreeEntry, error) {
+	var resp struct {
+		Tree      []gitTreeEntry `json:"tree"`
+		Truncated bool           `json:"truncated"`
+	}
+	if err := p.api(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/%s/git/trees/%s?recursive=1", owner, repo, commit), nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Truncated {
+		return nil, fmt.Errorf("дерево %s/%s@%s слишком большое для сравнения через GitHub API", owner, repo, shortCommit(commit))
+	}
+	files := make(map[string]gitTreeEntry, len(resp.Tree))
+	for _, e := range resp.Tree {
+		if e.Type == "blob" {
+			files[e.Path] = e
+		}
+	}
+	return files, nil
+}
+
+// api запрос к GitHub API: 404 — ErrRepoNotFound, 401 — ErrRepoAuth, 403 — ErrTokenReadOnly
+func (p *GitHubPusher) api(ctx context.Context, method, path string, payload, out interface{}) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultGitHubAPIURL
+	}
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(base, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+p.Token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitHub API: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w (%s)", ErrRepoNotFound, strings.SplitN(path, "?", 2)[0])
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("%w: GitHub отклонил GITHUB_TOKEN", ErrRepoAuth)
+	case resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrTokenReadOnly, githubMessage(data))
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("GitHub API %s %s: %d %s", method, path, resp.StatusCode, githubMessage(data))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("GitHub API %s: %w", path, err)
+	}
+	return nil
+}
+
+// githubMessage текст ошибки из ответа GitHub API
+func githubMessage(data []byte) string {
+	var e struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &e) == nil && e.Message != "" {
+		return e.Message
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// gitBlobSHA SHA-1 объекта blob git: совпадает с sha в деревьях GitHub API
+func gitBlobSHA(content string) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	io.WriteString(h, content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// PushSessionToGitHub публикует изменения сессии в target (пусто — репозиторий /vibecoding_clone):
+// проверяет токен, отбирает изменённые файлы, описывает их через LLM и открывает pull request
+func PushSessionToGitHub(ctx context.Context, session *VibeCodingSession, pusher RepoPusher, target, base string) (*PushPlan, *PushResult, error) {
+	session.mutex.RLock()
+	origin := session.Origin
+	projectName := session.ProjectName
+	session.mutex.RUnlock()
+	if target == "" {
+		if origin == nil {
+			return nil, nil, fmt.Errorf("укажите репозиторий: сессия создана из архива")
+		}
+		target = origin.URL
+	}
+
+	plan, err := pusher.Prepare(ctx, target, base, origin, session.ResultFiles())
+	if err != nil {
+		return nil, nil, err
+	}
+	title, body := pullRequestSummary(ctx, session, plan)
+	branch := fmt.Sprintf("vibecoding/%s-%s", branchSlug(projectName), time.Now().Format("20060102-150405"))
+	result, err := pusher.Push(ctx, plan, branch, title, body)
+	if err != nil {
+		return plan, nil, err
+	}
+	return plan, result, nil
+}
+
+var branchSlugPattern = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+func branchSlug(name string) string {
+	slug := strings.Trim(branchSlugPattern.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+	if slug == "" {
+		return "session"
+	}
+	return slug
+}
+
+// pullRequestSummary заголовок и описание PR: LLM описывает изменения по задачам автономной работы
+// и содержимому файлов; без LLM или при ошибке — список файлов
+func pullRequestSummary(ctx context.Context, session *VibeCodingSession, plan *PushPlan) (title, body string) {
+	session.mutex.RLock()
+	client := session.LLMClient
+	projectName := session.ProjectName
+	autoWork := append([]AutoWorkItem(nil), session.autoWork...)
+	session.mutex.RUnlock()
+
+	title = "VibeCoding: изменения в " + projectName
+	if client != nil {
+		var prompt strings.Builder
+		prompt.WriteString(fmt.Sprintf("Project: %s\n", projectName))
+		for _, item := range autoWork {
+			prompt.WriteString(fmt.Sprintf("Task: %s (finished: %v) %s\n", item.Task, item.Finished, item.Note))
+		}
+		prompt.WriteString("\nChanged files:\n")
+		budget := pushSummaryMaxChars
+		for _, change := range plan.Changes {
+			status := "modified"
+			if change.New {
+				status = "new"
+			}
+			prompt.WriteString(fmt.Sprintf("\n--- %s (%s)\n", change.Path, status))
+			if budget > 0 {
+				snippet := change.content
+				if len(snippet) > budget {
+					snippet = snippet[:budget] + "\n..."
+				}
+				budget -= len(snippet)
+				prompt.WriteString(snippet + "\n")
+			}
+		}
+		resp, err := client.Generate(ctx, []llm.Message{
+			{Role: "system", Content: "You write GitHub pull request descriptions. Answer in Markdown: the first line is a short PR title (under 72 characters, no prefix), then a blank line, then a summary of what changed and why, grouped by area. Do not invent changes that are not in the files."},
+			{Role: "user", Content: prompt.String()},
+		})
+		if err != nil {
+			log.Printf("⚠️ Failed to generate pull request summary: %v", err)
+		} else if t, b := splitSummary(resp.Content); t != "" {
+			title, body = t, b
+		}
+	}
+
+	var files strings.Builder
+	files.WriteString("\n\n### Files\n\n")
+	for _, change := range plan.Changes {
+		marker := "M"
+		if change.New {
+			marker = "A"
+		}
+		files.WriteString(fmt.Sprintf("- `%s` %s\n", marker, change.Path))
+	}
+	if len(plan.Conflicts) > 0 {
+		files.WriteString("\n⚠️ Changed in the base branch since the session started (the session version is used): " + strings.Join(plan.Conflicts, ", ") + "\n")
+	}
+	files.WriteString("\nGenerated by AI Chatter VibeCoding Mode\n")
+	return title, strings.TrimSpace(body + files.String())
+}
+
+// splitSummary первая строка ответа LLM — заголовок, остальное — описание
+func splitSummary(text string) (title, body string) {
+	text = strings.TrimSpace(text)
+	first, rest, _ := strings.Cut(text, "\n")
+	title = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(first), "#"))
+	title = strings.TrimSpace(strings.TrimPrefix(title, "Title:"))
+	if r := []rune(title); len(r) > 100 {
+		title = string(r[:100])
+	}
+	return title, strings.TrimSpace(rest)
+}
+
+// GitHubPushToolHandler реализация vibe_push_github, общая для stdio и HTTP MCP серверов
+func GitHubPushToolHandler(sm *SessionManager) mcp.ToolHandlerFor[map[string]interface{}, any] {
+	return func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]interface{}]) (*mcp.CallToolResultFor[any], error) {
+		userIDArg, ok := params.Arguments["user_id"]
+		if !ok {
+			return toolError("❌ user_id parameter is required"), nil
+		}
+		userID, err := ParseUserID(userIDArg)
+		if err != nil {
+			return toolError("❌ Invalid user_id format"), nil
+		}
+		repo, _ := params.Arguments["repo"].(string)
+		base, _ := params.Arguments["base"].(string)
+
+		log.Printf("⬆️ MCP Server: Pushing workspace of user %d to GitHub", userID)
+
+		vibeCodingSession := sm.GetSession(userID)
+		if vibeCodingSession == nil {
+			return toolError("❌ No VibeCoding session found for user"), nil
+		}
+		pusher := &GitHubPusher{Token: vibeCodingSession.Config.GitHubToken}
+		plan, result, err := PushSessionToGitHub(ctx, vibeCodingSession, pusher, strings.TrimSpace(repo), strings.TrimSpace(base))
+		if err != nil {
+			return toolError(fmt.Sprintf("❌ Failed to push: %v", err)), nil
+		}
+
+		return &mcp.CallToolResultFor[any]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("✅ Opened pull request %s (%d files)", result.URL, len(plan.Changes))},
+			},
+			Meta: map[string]interface{}{
+				"user_id":       userID,
+				"pr_url":        result.URL,
+				"branch":        result.Branch,
+				"files_changed": len(plan.Changes),
+				"base_moved":    plan.BaseMoved,
+				"success":       true,
+			},
+		}, nil
+	}
+}
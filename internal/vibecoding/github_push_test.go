@@ -0,0 +1,225 @@
+package vibecoding
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"ai-chatter/internal/llm"
+)
+
+// fakeGitHub минимальный GitHub API: репозиторий owner/app, ветка main и записанные запросы на запись
+type fakeGitHub struct {
+	mu       sync.Mutex
+	push     bool
+	head     string                             // текущий коммит main
+	trees    map[string]map[string]gitTreeEntry // коммит -> файлы
+	blobs    map[string]string                  // sha -> содержимое
+	tree     map[string]interface{}             // последний POST /git/trees
+	commit   map[string]interface{}             // последний POST /git/commits
+	ref      map[string]string                  // последний POST /git/refs
+	pull     map[string]string                  // последний POST /pulls
+	moveHead string                             // после первого чтения ветки main уходит на этот коммит
+}
+
+func newFakeGitHub(t *testing.T, push bool) (*fakeGitHub, *httptest.Server) {
+	gh := &fakeGitHub{push: push, head: "c1", trees: map[string]map[string]gitTreeEntry{}, blobs: map[string]string{}}
+	srv := httptest.NewServer(http.HandlerFunc(gh.serve))
+	t.Cleanup(srv.Close)
+	return gh, srv
+}
+
+// setTree дерево коммита из файлов
+func (gh *fakeGitHub) setTree(commit string, files map[string]string) {
+	tree := map[string]gitTreeEntry{}
+	for path, content := range files {
+		tree[path] = gitTreeEntry{Path: path, Mode: "100644", Type: "blob", SHA: gitBlobSHA(content)}
+	}
+	gh.trees[commit] = tree
+}
+
+func (gh *fakeGitHub) serve(w http.ResponseWriter, r *http.Request) {
+	gh.mu.Lock()
+	defer gh.mu.Unlock()
+	var body map[string]interface{}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+	reply := func(v interface{}) { _ = json.NewEncoder(w).Encode(v) }
+	path := strings.TrimPrefix(r.URL.Path, "/repos/owner/app")
+	switch {
+	case r.URL.Path == "/repos/owner/app":
+		reply(map[string]interface{}{"default_branch": "main", "permissions": map[string]bool{"pull": true, "push": gh.push}})
+	case path == "/git/ref/heads/main":
+		reply(map[string]interface{}{"object": map[string]string{"sha": gh.head}})
+		if gh.moveHead != "" {
+			gh.head, gh.moveHead = gh.moveHead, ""
+		}
+	case strings.HasPrefix(path, "/git/trees/"):
+		commit := strings.TrimPrefix(path, "/git/trees/")
+		var entries []gitTreeEntry
+		for _, e := range gh.trees[commit] {
+			entries = append(entries, e)
+		}
+		reply(map[string]interface{}{"tree": entries})
+	case strings.HasPrefix(path, "/git/commits/"):
+		reply(map[string]interface{}{"tree": map[string]string{"sha": "tree-of-" + strings.TrimPrefix(path, "/git/commits/")}})
+	case path == "/git/blobs":
+		data, _ := base64.StdEncoding.DecodeString(body["content"].(string))
+		sha := gitBlobSHA(string(data))
+		gh.blobs[sha] = string(data)
+		reply(map[string]string{"sha": sha})
+	case path == "/git/trees":
+		gh.tree = body
+		reply(map[string]string{"sha": "new-tree"})
+	case path == "/git/commits":
+		gh.commit = body
+		reply(map[string]string{"sha": "new-commit"})
+	case path == "/git/refs":
+		gh.ref = map[string]string{"ref": body["ref"].(string), "sha": body["sha"].(string)}
+		w.WriteHeader(http.StatusCreated)
+		reply(map[string]string{"ref": body["ref"].(string)})
+	case path == "/pulls":
+		gh.pull = map[string]string{"title": body["title"].(string), "head": body["head"].(string), "base": body["base"].(string), "body": body["body"].(string)}
+		w.WriteHeader(http.StatusCreated)
+		reply(map[string]interface{}{"html_url": "https://github.com/owner/app/pull/7", "number": 7})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestGitHubPusher_PrepareKeepsOnlyChangedFiles(t *testing.T) {
+	gh, srv := newFakeGitHub(t, true)
+	original := map[string]string{"main.go": "package main\n", "util.go": "package main // v1\n", "README.md": "# app\n"}
+	gh.setTree("c0", original)
+	// После начала сессии в main изменили util.go и добавили CHANGELOG.md
+	gh.setTree("c1", map[string]string{"main.go": "package main\n", "util.go": "package main // upstream\n", "README.md": "# app\n", "CHANGELOG.md": "v2\n"})
+
+	files := map[string]string{
+		"main.go":      "package main\n",            // без изменений
+		"util.go":      "package main // session\n", // изменён и в сессии, и в ветке
+		"README.md":    "# app\n\nUsage\n",          // изменён в сессии
+		"app_test.go":  "package main // tests\n",   // новый
+		"CHANGELOG.md": "v2\n",                      // совпадает с веткой
+		"empty.txt":    "",
+	}
+	p := &GitHubPusher{Token: "t", APIBaseURL: srv.URL}
+	plan, err := p.Prepare(context.Background(), "", "", &SessionOrigin{URL: "https://github.com/owner/app.git", Commit: "c0"}, files)
+	if err == nil {
+		t.Fatal("empty target must be rejected")
+	}
+	plan, err = p.Prepare(context.Background(), "owner/app", "", &SessionOrigin{URL: "https://github.com/owner/app.git", Commit: "c0"}, files)
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	var got []string
+	for _, c := range plan.Changes {
+		got = append(got, fmt.Sprintf("%s:%v", c.Path, c.New))
+	}
+	if strings.Join(got, ",") != "README.md:false,app_test.go:true,util.go:false" {
+		t.Errorf("changes = %v", got)
+	}
+	if plan.Base != "main" || plan.BaseCommit != "c1" || !plan.BaseMoved {
+		t.Errorf("plan must be based on the latest head of the default branch: %+v", plan)
+	}
+	if len(plan.Conflicts) != 1 || plan.Conflicts[0] != "util.go" {
+		t.Errorf("conflicts = %v", plan.Conflicts)
+	}
+
+	gh.setTree("c1", files)
+	if _, err := p.Prepare(context.Background(), "owner/app", "main", nil, files); !errors.Is(err, ErrNoChanges) {
+		t.Errorf("identical files must give ErrNoChanges, got %v", err)
+	}
+}
+
+func TestGitHubPusher_RejectsReadOnlyToken(t *testing.T) {
+	_, srv := newFakeGitHub(t, false)
+	p := &GitHubPusher{Token: "t", APIBaseURL: srv.URL}
+	_, err := p.Prepare(context.Background(), "https://github.com/owner/app", "", nil, map[string]string{"a.go": "x"})
+	if !errors.Is(err, ErrTokenReadOnly) || !strings.Contains(err.Error(), "owner/app") {
+		t.Errorf("read-only token must be rejected up front, got %v", err)
+	}
+	if _, err := (&GitHubPusher{APIBaseURL: srv.URL}).Prepare(context.Background(), "owner/app", "", nil, nil); !errors.Is(err, ErrTokenReadOnly) {
+		t.Errorf("missing token must be rejected, got %v", err)
+	}
+	if _, err := p.Prepare(context.Background(), "gitlab.com/owner/app", "", nil, nil); err == nil {
+		t.Error("only github.com can be a push target")
+	}
+}
+
+func TestHandlePushCommand_OpensPullRequest(t *testing.T) {
+	gh, srv := newFakeGitHub(t, true)
+	gh.setTree("c1", map[string]string{"main.py": "print('v1')\n"})
+	gh.moveHead = "c2" // main уходит вперёд между подготовкой и публикацией
+	gh.setTree("c2", map[string]string{"main.py": "print('v1')\n", "NEWS": "x\n"})
+
+	sender := &recordingSender{}
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+	llmClient := fakeSummaryLLM{content: "Add greeting\n\nPrints a friendly greeting."}
+	session, err := sm.CreateSession(1, 1, "My App", map[string]string{"main.py": "print('v1')\n"}, llmClient)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	session.SetOrigin(SessionOrigin{URL: "https://github.com/owner/app.git", Branch: "main", Commit: "c1"})
+	session.Files["main.py"] = "print('hello')\n"
+	session.GeneratedFiles["test_main.py"] = "def test_main(): pass\n"
+	h := &VibeCodingHandler{sessionManager: sm, sender: sender, formatter: &MockMessageFormatter{},
+		pusher: &GitHubPusher{Token: "t", APIBaseURL: srv.URL}}
+
+	if err := h.HandleVibeCodingCommand(context.Background(), 1, 1, "/vibecoding_push"); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if gh.tree["base_tree"] != "tree-of-c2" {
+		t.Errorf("branch must be based on the latest head, tree request: %v", gh.tree)
+	}
+	if parents := gh.commit["parents"].([]interface{}); len(parents) != 1 || parents[0] != "c2" {
+		t.Errorf("commit parents = %v", parents)
+	}
+	if entries := gh.tree["tree"].([]interface{}); len(entries) != 2 {
+		t.Errorf("only changed files must be pushed: %v", entries)
+	}
+	if !strings.HasPrefix(gh.ref["ref"], "refs/heads/vibecoding/my-app-") || gh.ref["sha"] != "new-commit" {
+		t.Errorf("ref = %v", gh.ref)
+	}
+	if gh.pull["title"] != "Add greeting" || gh.pull["base"] != "main" || !strings.Contains(gh.pull["body"], "Prints a friendly greeting.") ||
+		!strings.Contains(gh.pull["body"], "`A` test_main.py") {
+		t.Errorf("pull request = %v", gh.pull)
+	}
+	last := sender.texts[len(sender.texts)-1]
+	if !strings.Contains(last, "https://github.com/owner/app/pull/7") || !strings.Contains(last, "Файлов: 2") || !strings.Contains(last, "основаны на c2") {
+		t.Errorf("Telegram report = %q", last)
+	}
+
+	sender.texts = nil
+	h.pusher = &GitHubPusher{Token: "t", APIBaseURL: srv.URL}
+	gh.push = false
+	if err := h.HandleVibeCodingCommand(context.Background(), 1, 1, "/vibecoding_push owner/app"); !errors.Is(err, ErrTokenReadOnly) {
+		t.Fatalf("read-only token must fail, got %v", err)
+	}
+	if last := sender.texts[len(sender.texts)-1]; !strings.Contains(last, "нет права записи") {
+		t.Errorf("user must see the token error, got %q", last)
+	}
+}
+
+// fakeSummaryLLM LLM, который всегда отвечает content
+type fakeSummaryLLM struct {
+	llm.NoStreaming
+	llm.LocalTokenCounter
+	content string
+}
+
+func (f fakeSummaryLLM) Generate(ctx context.Context, messages []llm.Message) (llm.Response, error) {
+	return llm.Response{Content: f.content}, nil
+}
+
+func (f fakeSummaryLLM) GenerateWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (llm.Response, error) {
+	return f.Generate(ctx, messages)
+}
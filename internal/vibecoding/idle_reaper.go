@@ -0,0 +1,160 @@
+package vibecoding
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultIdleTimeout время бездействия, после которого сессия закрывается автоматически
+	defaultIdleTimeout = 30 * time.Minute
+	// idleReaperInterval период проверки сессий на бездействие
+	idleReaperInterval = time.Minute
+)
+
+// IdleCloseFunc вызывается перед автоматическим закрытием сессии по бездействию,
+// пока сессия ещё зарегистрирована (например, чтобы предупредить чат)
+type IdleCloseFunc func(session *VibeCodingSession, idle time.Duration)
+
+// idleReaper фоновая горутина, закрывающая неактивные сессии
+type idleReaper struct {
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func (c VibeCodingConfig) idleTimeout() time.Duration {
+	if c.IdleTimeout == 0 {
+		return defaultIdleTimeout
+	}
+	return c.IdleTimeout
+}
+
+// touch отмечает активность в сессии (команды, запись файлов, сообщения пользователя)
+func (s *VibeCodingSession) touch() {
+	s.lastActivity.Store(time.Now().UnixNano())
+}
+
+// LastActivity время последней активности в сессии
+func (s *VibeCodingSession) LastActivity() time.Time {
+	if ns := s.lastActivity.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return s.StartTime
+}
+
+// SetIdleCloseHandler задаёт уведомление о сессиях, закрытых по бездействию
+func (sm *SessionManager) SetIdleCloseHandler(f IdleCloseFunc) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	sm.onIdleClose = f
+}
+
+// PauseIdleClose приостанавливает закрытие сессий по бездействию (режим обслуживания бота: пользователи
+// не могут писать, и сессии не должны закрываться без них). При возобновлении активность всех сессий
+// обновляется, чтобы время обслуживания не засчитывалось в бездействие
+func (sm *SessionManager) PauseIdleClose(paused bool) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	if sm.idlePaused == paused {
+		return
+	}
+	sm.idlePaused = paused
+	if !paused {
+		for _, session := range sm.sessions {
+			session.touch()
+		}
+	}
+	log.Printf("⏸ Idle close of vibecoding sessions paused=%t", paused)
+}
+
+// startIdleReaper запускает проверку бездействия с заданным периодом
+func (sm *SessionManager) startIdleReaper(interval time.Duration) {
+	r := &idleReaper{stop: make(chan struct{}), done: make(chan struct{})}
+	sm.reaper = r
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case now := <-ticker.C:
+				sm.ReapIdleSessions(now)
+			}
+		}
+	}()
+}
+
+// ReapIdleSessions закрывает сессии, неактивные дольше IdleTimeout, и возвращает их пользователей
+func (sm *SessionManager) ReapIdleSessions(now time.Time) []int64 {
+	sm.mutex.RLock()
+	timeout := sm.config.idleTimeout()
+	sm.mutex.RUnlock()
+	return sm.cleanupStaleSessions(now, timeout)
+}
+
+// CleanupStaleSessions закрывает сессии, неактивные дольше maxIdleTime (контейнеры удаляются),
+// и возвращает их пользователей. Перед закрытием чат уведомляется через SetIdleCloseHandler
+func (sm *SessionManager) CleanupStaleSessions(maxIdleTime time.Duration) []int64 {
+	return sm.cleanupStaleSessions(time.Now(), maxIdleTime)
+}
+
+func (sm *SessionManager) cleanupStaleSessions(now time.Time, maxIdleTime time.Duration) []int64 {
+	if maxIdleTime <= 0 {
+		return nil
+	}
+	sm.mutex.RLock()
+	if sm.idlePaused {
+		sm.mutex.RUnlock()
+		return nil
+	}
+	onClose := sm.onIdleClose
+	var idle []*VibeCodingSession
+	for _, session := range sm.sessions {
+		if now.Sub(session.LastActivity()) >= maxIdleTime {
+			idle = append(idle, session)
+		}
+	}
+	sm.mutex.RUnlock()
+
+	var closed []int64
+	for _, session := range idle {
+		// Сессия могла быть закрыта или пересоздана пользователем между проверками
+		if sm.GetSession(session.UserID) != session {
+			continue
+		}
+		idleFor := now.Sub(session.LastActivity()).Round(time.Second)
+		if onClose != nil {
+			onClose(session, idleFor)
+		}
+		if err := sm.EndSession(session.UserID); err != nil {
+			log.Printf("⚠️ Failed to close idle session for user %d: %v", session.UserID, err)
+			continue
+		}
+		log.Printf("⏰ Closed idle vibecoding session for user %d after %s", session.UserID, idleFor)
+		closed = append(closed, session.UserID)
+	}
+	return closed
+}
+
+// Close останавливает фоновую проверку бездействия, сборку осиротевших контейнеров и веб-сервер, если он запущен
+func (sm *SessionManager) Close() error {
+	if r := sm.reaper; r != nil {
+		r.stopOnce.Do(func() { close(r.stop) })
+		<-r.done
+	}
+	sm.stopContainerGC()
+	if sm.metricsServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = sm.metricsServer.Shutdown(ctx)
+	}
+	if sm.webServer != nil {
+		return sm.webServer.Stop()
+	}
+	return nil
+}
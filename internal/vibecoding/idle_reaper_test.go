@@ -0,0 +1,198 @@
+package vibecoding
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/codevalidation"
+)
+
+// recordingSender запоминает тексты отправленных сообщений
+type recordingSender struct {
+	chats []int64
+	texts []string
+}
+
+func (r *recordingSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	if msg, ok := c.(tgbotapi.MessageConfig); ok {
+		r.chats = append(r.chats, msg.ChatID)
+		r.texts = append(r.texts, msg.Text)
+	}
+	return tgbotapi.Message{MessageID: len(r.texts)}, nil
+}
+
+func (r *recordingSender) GetFile(config tgbotapi.FileConfig) (tgbotapi.File, error) {
+	return tgbotapi.File{}, nil
+}
+
+func TestSessionManager_ReapIdleSessions(t *testing.T) {
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+	sm.SetConfig(VibeCodingConfig{IdleTimeout: 10 * time.Minute})
+
+	sender := &recordingSender{}
+	h := &VibeCodingHandler{sessionManager: sm, sender: sender, formatter: &MockMessageFormatter{}}
+	sm.SetIdleCloseHandler(h.notifyIdleClosed)
+
+	idle, err := sm.CreateSession(1, 100, "idle-project", map[string]string{"main.go": "package main"}, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	mock := codevalidation.NewMockDockerClient()
+	idle.Docker = NewDockerAdapter(mock)
+	idle.ContainerID = "container-1"
+	active, err := sm.CreateSession(2, 200, "active-project", map[string]string{"main.go": "package main"}, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	active.Docker = NewDockerAdapter(mock)
+
+	now := time.Now()
+	idle.lastActivity.Store(now.Add(-11 * time.Minute).UnixNano())
+	// Запись файла продлевает сессию
+	active.lastActivity.Store(now.Add(-11 * time.Minute).UnixNano())
+	if err := active.WriteFile(context.Background(), "util.go", "package main", false); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	closed := sm.ReapIdleSessions(now)
+	if len(closed) != 1 || closed[0] != 1 {
+		t.Fatalf("expected only user 1 to be reaped, got %v", closed)
+	}
+	if sm.HasActiveSession(1) || !sm.HasActiveSession(2) {
+		t.Fatal("idle session must be ended, active one kept")
+	}
+	if idle.ContainerID != "" {
+		t.Error("container of the idle session must be removed")
+	}
+	if len(sender.texts) != 1 || sender.chats[0] != 100 || !strings.Contains(sender.texts[0], "idle-project") || !strings.HasPrefix(sender.texts[0], "[vibecoding]") {
+		t.Errorf("unexpected notification: %v %q", sender.chats, sender.texts)
+	}
+}
+
+func TestVibeCodingHandler_MaintenanceKeepsIdleSessions(t *testing.T) {
+	store, err := NewFileSessionStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileSessionStore: %v", err)
+	}
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+	sm.SetConfig(VibeCodingConfig{IdleTimeout: 10 * time.Minute})
+	if _, err := sm.RestoreSessions(store, nil); err != nil {
+		t.Fatalf("RestoreSessions: %v", err)
+	}
+	sender := &recordingSender{}
+	h := &VibeCodingHandler{sessionManager: sm, sender: sender, formatter: &MockMessageFormatter{}}
+	sm.SetIdleCloseHandler(h.notifyIdleClosed)
+
+	session, err := sm.CreateSession(1, 100, "deploy-project", map[string]string{"main.go": "package main"}, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if n := h.WarnMaintenance("🛠 maintenance"); n != 1 {
+		t.Fatalf("WarnMaintenance = %d, want 1", n)
+	}
+	h.SetMaintenance(true)
+
+	// Обслуживание дольше VIBECODING_IDLE_TIMEOUT: сессия и её снимок остаются, уведомления о закрытии нет
+	session.lastActivity.Store(time.Now().Add(-2 * time.Hour).UnixNano())
+	if closed := sm.ReapIdleSessions(time.Now()); len(closed) != 0 {
+		t.Fatalf("sessions must not be reaped during maintenance, got %v", closed)
+	}
+	if _, err := store.Load(1); err != nil {
+		t.Errorf("checkpoint must survive maintenance: %v", err)
+	}
+	if len(sender.texts) != 1 || !strings.Contains(sender.texts[0], "сохранена") {
+		t.Errorf("only the maintenance warning must be sent, got %q", sender.texts)
+	}
+
+	// После обслуживания отсчёт бездействия начинается заново
+	h.SetMaintenance(false)
+	if closed := sm.ReapIdleSessions(time.Now()); len(closed) != 0 || !sm.HasActiveSession(1) {
+		t.Errorf("maintenance time must not count as inactivity, closed %v", closed)
+	}
+}
+
+func TestSessionManager_IdleTimeoutDisabled(t *testing.T) {
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+	sm.SetConfig(VibeCodingConfig{IdleTimeout: -1})
+
+	session, err := sm.CreateSession(1, 1, "p", map[string]string{"a.py": "print(1)"}, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	session.lastActivity.Store(time.Now().Add(-24 * time.Hour).UnixNano())
+	if closed := sm.ReapIdleSessions(time.Now()); len(closed) != 0 {
+		t.Fatalf("disabled idle timeout must not close sessions, got %v", closed)
+	}
+	if (VibeCodingConfig{}).idleTimeout() != 30*time.Minute {
+		t.Error("zero config must default to 30 minutes")
+	}
+}
+
+func TestSessionManager_CloseStopsReaper(t *testing.T) {
+	sm := NewSessionManagerWithoutWebServer()
+	done := make(chan struct{})
+	go func() {
+		_ = sm.Close()
+		_ = sm.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not stop the reaper goroutine")
+	}
+	select {
+	case <-sm.reaper.done:
+	default:
+		t.Fatal("reaper goroutine still running")
+	}
+}
+
+func TestSessionManager_CleanupStaleSessionsNotifiesBeforeEviction(t *testing.T) {
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+
+	var activeOnNotify []bool
+	sm.SetIdleCloseHandler(func(session *VibeCodingSession, idle time.Duration) {
+		activeOnNotify = append(activeOnNotify, sm.HasActiveSession(session.UserID))
+	})
+
+	stale, err := sm.CreateSession(1, 10, "stale", map[string]string{"a.py": "print(1)"}, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	stale.Docker = NewDockerAdapter(codevalidation.NewMockDockerClient())
+	stale.lastActivity.Store(time.Now().Add(-2 * time.Hour).UnixNano())
+
+	read, err := sm.CreateSession(2, 20, "read", map[string]string{"a.py": "print(1)"}, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	read.Docker = NewDockerAdapter(codevalidation.NewMockDockerClient())
+	read.lastActivity.Store(time.Now().Add(-2 * time.Hour).UnixNano())
+	// Чтение файла тоже считается активностью
+	if _, err := read.ReadFile(context.Background(), "a.py"); err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	closed := sm.CleanupStaleSessions(time.Hour)
+	if len(closed) != 1 || closed[0] != 1 {
+		t.Fatalf("expected only the stale session to be evicted, got %v", closed)
+	}
+	if len(activeOnNotify) != 1 || !activeOnNotify[0] {
+		t.Fatalf("chat must be notified before eviction, got %v", activeOnNotify)
+	}
+	if sm.HasActiveSession(1) || !sm.HasActiveSession(2) {
+		t.Fatal("stale session must be ended, recently read one kept")
+	}
+	if closed := sm.CleanupStaleSessions(0); len(closed) != 0 {
+		t.Fatalf("zero max idle time must not evict sessions, got %v", closed)
+	}
+}
@@ -135,6 +135,8 @@ type ProjectContextLLM struct {
 	Structure    ProjectStructure          `json:"structure"`
 	TokensUsed   int                       `json:"tokens_used"`
 	TokensLimit  int                       `json:"tokens_limit"`
+	// DependencyGraph граф импортов, строится без LLM и пересчитывается при изменении файлов
+	DependencyGraph *DependencyGraph `json:"dependency_graph,omitempty"`
 }
 
 // LLMFileContext содержит LLM-генерируемое описание файла
@@ -213,6 +215,7 @@ func (g *LLMContextGenerator) GenerateContext(ctx context.Context, projectName s
 	step1Start := time.Now()
 	context.Language = g.detectMainLanguage(files)
 	log.Printf("🧠 [STEP 2] Main language detected: %s (%.2fs)", context.Language, time.Since(step1Start).Seconds())
+	context.DependencyGraph = BuildDependencyGraph(files)
 
 	step2Start := time.Now()
 	g.analyzeProjectStructure(files, &context.Structure)
@@ -543,6 +546,18 @@ func (g *LLMContextGenerator) RemoveFileContext(projectContext *ProjectContextLL
 	}
 }
 
+// RenameFileContext переносит описание файла на новый путь без повторного обращения к LLM
+func (g *LLMContextGenerator) RenameFileContext(projectContext *ProjectContextLLM, oldPath, newPath string) {
+	existingContext, exists := projectContext.Files[oldPath]
+	if !exists {
+		return
+	}
+	delete(projectContext.Files, oldPath)
+	existingContext.Path = newPath
+	projectContext.Files[newPath] = existingContext
+	log.Printf("📝 Moved context %s -> %s", oldPath, newPath)
+}
+
 // Вспомогательные методы
 
 func (g *LLMContextGenerator) detectMainLanguage(files map[string]string) string {
@@ -897,6 +912,8 @@ func (g *LLMContextGenerator) GenerateContextWithPreloadedFiles(ctx context.Cont
 		log.Printf("🧠 [PARALLEL] ✅ File processed: %s (%d tokens used, %d remaining, %.2fs)", filePath, fileContext.TokensUsed, tokenBudget, time.Since(fileStart).Seconds())
 	}
 
+	context.DependencyGraph = BuildDependencyGraph(fileContentMap)
+
 	log.Printf("✅ [PARALLEL] LLM context generation completed: %d/%d files processed, %d/%d tokens used (%.2fs total)",
 		len(context.Files), len(fileList), context.TokensUsed, context.TokensLimit, time.Since(start).Seconds())
 	return context, nil
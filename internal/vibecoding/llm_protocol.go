@@ -2,12 +2,14 @@ package vibecoding
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"ai-chatter/internal/llm"
+	"ai-chatter/internal/llm/jsonextract"
 )
 
 // VibeCodingRequest представляет запрос к LLM для вайбкодинга
@@ -16,6 +18,8 @@ type VibeCodingRequest struct {
 	Context VibeCodingContext      `json:"context"`           // Контекст сессии
 	Query   string                 `json:"query"`             // Вопрос или запрос пользователя
 	Options map[string]interface{} `json:"options,omitempty"` // Дополнительные опции
+	// Progress получает ход многошаговых действий (autonomous_work); nil — без отчёта
+	Progress ProgressFunc `json:"-"`
 }
 
 // VibeCodingContext содержит контекст сессии
@@ -25,6 +29,8 @@ type VibeCodingContext struct {
 	Files           map[string]string `json:"files"`
 	GeneratedFiles  map[string]string `json:"generated_files,omitempty"`
 	SessionDuration string            `json:"session_duration"`
+	// ProjectContext сжатый контекст проекта (ProjectContextLLM), подогнанный под окно модели
+	ProjectContext string `json:"project_context,omitempty"`
 }
 
 // VibeCodingResponse представляет ответ от LLM
@@ -42,6 +48,8 @@ type VibeCodingLLMClient struct {
 	llmClient  llm.Client
 	maxRetries int
 	mcpClient  *VibeCodingMCPClient // MCP клиент для прямого доступа к файлам
+	recordDir  string               // каталог записей автономных запусков; пусто — не записывать
+	now        func() time.Time     // часы для меток времени записи (подменяются при воспроизведении)
 }
 
 // NewVibeCodingLLMClient создает новый клиент с JSON протоколом
@@ -50,9 +58,25 @@ func NewVibeCodingLLMClient(llmClient llm.Client) *VibeCodingLLMClient {
 		llmClient:  llmClient,
 		maxRetries: 3,
 		mcpClient:  nil, // будет установлен через SetMCPClient
+		now:        time.Now,
 	}
 }
 
+// SetRecordDir включает запись автономных запусков в каталог dir для cmd/vibecoding-replay
+func (c *VibeCodingLLMClient) SetRecordDir(dir string) {
+	c.recordDir = dir
+}
+
+// SetLLMTimeout ограничивает время каждого LLM запроса протокола (VIBECODING_LLM_TIMEOUT); 0 — без ограничения
+func (c *VibeCodingLLMClient) SetLLMTimeout(timeout time.Duration) {
+	c.llmClient = withLLMTimeout(c.llmClient, timeout)
+}
+
+// SetClock подменяет часы, которыми размечается запись запуска
+func (c *VibeCodingLLMClient) SetClock(now func() time.Time) {
+	c.now = now
+}
+
 // SetMCPClient устанавливает MCP клиент для прямого доступа к файлам
 func (c *VibeCodingLLMClient) SetMCPClient(mcpClient *VibeCodingMCPClient) {
 	c.mcpClient = mcpClient
@@ -60,28 +84,30 @@ func (c *VibeCodingLLMClient) SetMCPClient(mcpClient *VibeCodingMCPClient) {
 
 // ProcessRequest обрабатывает запрос через JSON протокол
 func (c *VibeCodingLLMClient) ProcessRequest(ctx context.Context, request VibeCodingRequest) (*VibeCodingResponse, error) {
-	log.Printf("🧠 Processing VibeCoding request: action=%s, query_length=%d", request.Action, len(request.Query))
+	return c.ProcessRequestWithRetry(ctx, request, c.maxRetries)
+}
 
-	var systemPrompt string
-	var userPrompt string
+// ProcessRequestWithRetry обрабатывает запрос, повторяя его до maxAttempts раз, если ответ не
+// разбирается как JSON или нарушает схему. Неудачный ответ и причина отказа остаются в диалоге,
+// чтобы модель видела свою ошибку и исправляла именно её
+func (c *VibeCodingLLMClient) ProcessRequestWithRetry(ctx context.Context, request VibeCodingRequest, maxAttempts int) (*VibeCodingResponse, error) {
+	log.Printf("🧠 Processing VibeCoding request: action=%s, query_length=%d", request.Action, len(request.Query))
 
-	switch request.Action {
-	case "answer_question":
-		systemPrompt, userPrompt = c.buildQuestionPrompts(request)
-	case "generate_code":
-		systemPrompt, userPrompt = c.buildCodeGenerationPrompts(request)
-	case "analyze":
-		systemPrompt, userPrompt = c.buildAnalysisPrompts(request)
-	case "autonomous_work":
+	if request.Action == "autonomous_work" {
 		return c.processAutonomousWork(ctx, request)
-	default:
-		return nil, fmt.Errorf("unsupported action: %s", request.Action)
+	}
+	messages, err := c.requestMessages(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxAttempts < 1 {
+		maxAttempts = 1
 	}
 
-	// Отправляем запрос с retry логикой
 	var lastError error
-	for attempt := 1; attempt <= c.maxRetries; attempt++ {
-		response, err := c.sendRequestWithRetry(ctx, systemPrompt, userPrompt, attempt)
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		response, raw, err := c.sendConversation(ctx, messages, attempt)
 		if err == nil {
 			return response, nil
 		}
@@ -89,57 +115,71 @@ func (c *VibeCodingLLMClient) ProcessRequest(ctx context.Context, request VibeCo
 		lastError = err
 		log.Printf("⚠️ Attempt %d failed: %v", attempt, err)
 
-		// Если это последняя попытка или ошибка не связана с JSON парсингом, выходим
-		if attempt == c.maxRetries || !isJSONParsingError(err) {
+		// Повторяем только ошибки формата ответа: некорректный JSON или нарушение схемы
+		if attempt == maxAttempts || !(isJSONParsingError(err) || errors.Is(err, ErrInvalidVibeCodingResponse)) {
 			break
 		}
+		messages = append(messages,
+			llm.Message{Role: "assistant", Content: raw},
+			llm.Message{Role: "user", Content: retryInstruction(err)},
+		)
 	}
 
-	return nil, fmt.Errorf("failed after %d attempts: %w", c.maxRetries, lastError)
+	return nil, fmt.Errorf("failed after %d attempts: %w", maxAttempts, lastError)
 }
 
-// sendRequestWithRetry отправляет запрос к LLM с обработкой JSON ответа
-func (c *VibeCodingLLMClient) sendRequestWithRetry(ctx context.Context, systemPrompt, userPrompt string, attempt int) (*VibeCodingResponse, error) {
-	messages := []llm.Message{
+// requestMessages системный и пользовательский промпт одношагового действия
+func (c *VibeCodingLLMClient) requestMessages(request VibeCodingRequest) ([]llm.Message, error) {
+	var systemPrompt, userPrompt string
+	switch request.Action {
+	case "answer_question":
+		systemPrompt, userPrompt = c.buildQuestionPrompts(request)
+	case "generate_code":
+		systemPrompt, userPrompt = c.buildCodeGenerationPrompts(request)
+	case "analyze":
+		systemPrompt, userPrompt = c.buildAnalysisPrompts(request)
+	default:
+		return nil, fmt.Errorf("unsupported action: %s", request.Action)
+	}
+	return []llm.Message{
 		{Role: "system", Content: systemPrompt},
 		{Role: "user", Content: userPrompt},
-	}
+	}, nil
+}
 
-	if attempt > 1 {
-		// Добавляем инструкцию для повторной попытки
-		retryInstruction := "IMPORTANT: The previous response was not valid JSON. Please ensure your response is a properly formatted JSON object according to the schema."
-		messages = append(messages, llm.Message{Role: "user", Content: retryInstruction})
+// retryInstruction просьба исправить отклонённый ответ с причиной отказа
+func retryInstruction(err error) string {
+	if errors.Is(err, ErrInvalidVibeCodingResponse) {
+		return fmt.Sprintf("IMPORTANT: Your previous response was rejected: %v. Output valid JSON only, matching the schema, with the problem corrected.", err)
 	}
+	return fmt.Sprintf("IMPORTANT: Your previous response could not be parsed: %v. Output valid JSON only matching the schema: a single JSON object, no markdown fences, no comments and no text around it.", err)
+}
 
+// sendConversation отправляет диалог LLM и разбирает ответ; raw — исходный текст ответа,
+// он возвращается и при ошибке разбора, чтобы добавить его в диалог повторной попытки
+func (c *VibeCodingLLMClient) sendConversation(ctx context.Context, messages []llm.Message, attempt int) (*VibeCodingResponse, string, error) {
 	log.Printf("🔄 Sending request to LLM (attempt %d)", attempt)
 
-	llmResponse, err := c.llmClient.Generate(ctx, messages)
+	llmResponse, err := generateStreamed(ctx, c.llmClient, messages, llm.OptionsForTask(llm.TaskGeneration))
 	if err != nil {
-		return nil, fmt.Errorf("LLM request failed: %w", err)
+		return nil, "", fmt.Errorf("LLM request failed: %w", err)
 	}
 
 	log.Printf("📝 Received LLM response length: %d characters", len(llmResponse.Content))
 
-	// Парсим JSON ответ
 	response, err := c.parseJSONResponse(llmResponse.Content)
 	if err != nil {
 		log.Printf("❌ JSON parsing failed: %v", err)
 		log.Printf("Raw response: %s", llmResponse.Content)
-
-		// Пытаемся исправить JSON если это возможно
-		if fixedResponse, fixErr := c.tryFixJSON(ctx, llmResponse.Content, attempt); fixErr == nil {
-			return fixedResponse, nil
-		}
-
-		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
+		return nil, llmResponse.Content, err
 	}
 
-	// Валидируем ответ
-	if err := c.validateResponse(response); err != nil {
-		return nil, fmt.Errorf("invalid response structure: %w", err)
+	if err := validateVibeCodingResponse(response); err != nil {
+		log.Printf("❌ LLM response rejected: %v", err)
+		return nil, llmResponse.Content, err
 	}
 
-	return response, nil
+	return response, llmResponse.Content, nil
 }
 
 // buildQuestionPrompts строит промпты для ответов на вопросы
@@ -178,7 +218,7 @@ Generated files: %d
 
 AVAILABLE FILES:
 %s
-
+%s
 USER QUESTION:
 %s
 
@@ -189,11 +229,19 @@ Please provide a helpful response about this code project.`,
 		len(request.Context.Files),
 		len(request.Context.GeneratedFiles),
 		c.formatFileList(request.Context.Files),
+		formatProjectOverview(request.Context.ProjectContext),
 		request.Query)
 
 	return systemPrompt, userPrompt
 }
 
+func formatProjectOverview(projectContext string) string {
+	if projectContext == "" {
+		return ""
+	}
+	return "\nPROJECT OVERVIEW:\n" + projectContext + "\n"
+}
+
 // buildCodeGenerationPrompts строит промпты для генерации кода
 func (c *VibeCodingLLMClient) buildCodeGenerationPrompts(request VibeCodingRequest) (string, string) {
 	systemPrompt := `You are an expert code generator in VibeCoding mode. Generate high-quality, working code based on user requests.
@@ -283,90 +331,14 @@ FILES TO ANALYZE:
 
 // parseJSONResponse парсит JSON ответ от LLM
 func (c *VibeCodingLLMClient) parseJSONResponse(content string) (*VibeCodingResponse, error) {
-	// Очищаем контент от лишних символов
-	content = strings.TrimSpace(content)
-
-	// Ищем JSON блок если есть markdown форматирование
-	if strings.Contains(content, "```json") {
-		start := strings.Index(content, "```json") + 7
-		end := strings.Index(content[start:], "```")
-		if end > 0 {
-			content = strings.TrimSpace(content[start : start+end])
-		}
-	} else if strings.Contains(content, "```") {
-		// Пытаемся извлечь JSON из обычных блоков кода
-		start := strings.Index(content, "```") + 3
-		end := strings.Index(content[start:], "```")
-		if end > 0 {
-			candidate := strings.TrimSpace(content[start : start+end])
-			if strings.HasPrefix(candidate, "{") {
-				content = candidate
-			}
-		}
-	}
-
 	var response VibeCodingResponse
-	if err := json.Unmarshal([]byte(content), &response); err != nil {
+	if err := jsonextract.Decode(content, &response, jsonextract.Options{Repair: true}); err != nil {
 		return nil, err
 	}
 
 	return &response, nil
 }
 
-// tryFixJSON пытается исправить некорректный JSON
-func (c *VibeCodingLLMClient) tryFixJSON(ctx context.Context, rawContent string, attempt int) (*VibeCodingResponse, error) {
-	log.Printf("🔧 Attempting to fix JSON response (attempt %d)", attempt)
-
-	fixPrompt := `The following response should be valid JSON but has formatting issues. Please fix it and return only the corrected JSON:
-
-BROKEN JSON:
-` + rawContent + `
-
-Return only the corrected JSON object, no other text.`
-
-	messages := []llm.Message{
-		{Role: "system", Content: "You are a JSON formatter. Fix the provided JSON and return only valid JSON."},
-		{Role: "user", Content: fixPrompt},
-	}
-
-	fixResponse, err := c.llmClient.Generate(ctx, messages)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fix JSON: %w", err)
-	}
-
-	// Пытаемся парсить исправленный JSON
-	return c.parseJSONResponse(fixResponse.Content)
-}
-
-// validateResponse проверяет корректность структуры ответа
-func (c *VibeCodingLLMClient) validateResponse(response *VibeCodingResponse) error {
-	if response.Status == "" {
-		return fmt.Errorf("status field is required")
-	}
-
-	validStatuses := []string{"success", "error", "partial"}
-	isValidStatus := false
-	for _, status := range validStatuses {
-		if response.Status == status {
-			isValidStatus = true
-			break
-		}
-	}
-	if !isValidStatus {
-		return fmt.Errorf("invalid status: %s, must be one of %v", response.Status, validStatuses)
-	}
-
-	if response.Status == "error" && response.Error == "" {
-		return fmt.Errorf("error field is required when status is error")
-	}
-
-	if response.Status == "success" && response.Response == "" {
-		return fmt.Errorf("response field is required when status is success")
-	}
-
-	return nil
-}
-
 // formatFileList форматирует список файлов для контекста
 func (c *VibeCodingLLMClient) formatFileList(files map[string]string) string {
 	var result strings.Builder
@@ -413,7 +385,8 @@ func (c *VibeCodingLLMClient) formatCodeContext(files map[string]string) string
 	return result.String()
 }
 
-// processAutonomousWork обрабатывает запрос на автономную работу с MCP инструментами
+// processAutonomousWork обрабатывает запрос на автономную работу: vibe_* инструменты вызываются
+// через function calling, результаты возвращаются модели tool сообщениями
 func (c *VibeCodingLLMClient) processAutonomousWork(ctx context.Context, request VibeCodingRequest) (*VibeCodingResponse, error) {
 	if c.mcpClient == nil {
 		return &VibeCodingResponse{
@@ -438,60 +411,83 @@ func (c *VibeCodingLLMClient) processAutonomousWork(ctx context.Context, request
 		}
 	}
 
-	// Создаем системный промпт для автономной работы с MCP инструментами
-	systemPrompt := c.buildMCPSystemPrompt()
-	userPrompt := c.buildMCPUserPrompt(request, userID)
+	var rec *RunRecording
+	if c.recordDir != "" {
+		rec = newRunRecording(request, userID, c.now())
+	}
+	response := c.runAutonomousWork(ctx, request, userID, c.llmClient, c.mcpClient, rec)
+	if rec != nil {
+		rec.Response = response
+		if path, err := rec.SaveTo(c.recordDir); err != nil {
+			log.Printf("⚠️ Failed to save autonomous run recording: %v", err)
+		} else {
+			log.Printf("📼 Autonomous run recorded to %s", path)
+		}
+	}
+	return response, nil
+}
+
+// runAutonomousWork цикл автономной работы: запросы к LLM с vibe_* инструментами и выполнение
+// вызовов через tools. rec (если не nil) получает запросы, ответы, вызовы инструментов и решения
+func (c *VibeCodingLLMClient) runAutonomousWork(ctx context.Context, request VibeCodingRequest, userID int64, client llm.Client, tools VibeToolBackend, rec *RunRecording) *VibeCodingResponse {
+	messages := []llm.Message{
+		{Role: "system", Content: c.buildMCPSystemPrompt()},
+		{Role: "user", Content: c.buildMCPUserPrompt(request, userID)},
+	}
+	toolDefs := vibeCodingTools()
+	ctx = llm.WithOptions(ctx, llm.OptionsForTask(llm.TaskGeneration))
 
 	maxSteps := 10 // Максимальное количество шагов автономной работы
+	progress := request.Progress
+	if progress == nil {
+		progress = func(int, int, string, error) {}
+	}
 	var executionLog []string
 	var allGeneratedCode = make(map[string]string)
+	summary := ""
 
 	for step := 1; step <= maxSteps; step++ {
 		log.Printf("🔄 Autonomous work step %d/%d", step, maxSteps)
+		progress(step, maxSteps, "запрос к LLM", nil)
 
-		// Отправляем запрос к LLM
-		messages := []llm.Message{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: userPrompt},
-		}
-
-		// Добавляем предыдущие шаги в контекст
-		if len(executionLog) > 0 {
-			historyPrompt := "PREVIOUS EXECUTION STEPS:\n" + strings.Join(executionLog, "\n\n")
-			messages = append(messages, llm.Message{Role: "assistant", Content: historyPrompt})
-		}
-
-		llmResponse, err := c.llmClient.Generate(ctx, messages)
+		llmResponse, err := client.GenerateWithTools(ctx, messages, toolDefs)
+		rec.recordLLMCall(step, c.now(), messages, llmResponse, err)
 		if err != nil {
 			executionLog = append(executionLog, fmt.Sprintf("Step %d ERROR: LLM request failed: %v", step, err))
+			progress(step, maxSteps, "запрос к LLM", err)
+			rec.recordDecision(RunDecision{Step: step, Kind: DecisionError})
 			break
 		}
 
-		// Парсим ответ LLM на предмет MCP команд
-		stepResult, shouldContinue, err := c.processMCPStep(ctx, llmResponse.Content, userID, step)
-		if err != nil {
-			executionLog = append(executionLog, fmt.Sprintf("Step %d ERROR: %v", step, err))
-			break
-		}
-
-		executionLog = append(executionLog, stepResult)
-
-		// Если LLM указал, что работа завершена
-		if !shouldContinue {
+		// Ответ без вызовов инструментов — итог работы
+		if len(llmResponse.ToolCalls) == 0 {
+			summary = strings.TrimSpace(llmResponse.Content)
+			executionLog = append(executionLog, fmt.Sprintf("Step %d: COMPLETED: %s", step, summary))
 			log.Printf("✅ Autonomous work completed at step %d", step)
+			rec.recordDecision(RunDecision{Step: step, Kind: DecisionComplete})
 			break
 		}
 
+		progress(step, maxSteps, "выполнение инструментов", nil)
+		messages = append(messages, llm.Message{Role: "assistant", Content: llmResponse.Content, ToolCalls: llmResponse.ToolCalls})
+		stepLog, results := c.processMCPStep(ctx, tools, llmResponse, userID, step, rec)
+		messages = append(messages, results...)
+		executionLog = append(executionLog, stepLog)
+
 		// Проверяем, не достигли ли максимума шагов
 		if step == maxSteps {
 			executionLog = append(executionLog, "⚠️ Reached maximum number of steps")
 			log.Printf("⚠️ Autonomous work reached maximum steps (%d)", maxSteps)
+			rec.recordDecision(RunDecision{Step: step, Kind: DecisionMaxSteps})
 		}
 	}
 
+	if summary == "" {
+		summary = "Autonomous work completed"
+	}
 	return &VibeCodingResponse{
 		Status:   "success",
-		Response: "Autonomous work completed",
+		Response: summary,
 		Code:     allGeneratedCode,
 		Suggestions: []string{
 			"Review the generated code",
@@ -502,40 +498,15 @@ func (c *VibeCodingLLMClient) processAutonomousWork(ctx context.Context, request
 			"execution_log":  executionLog,
 			"steps_executed": len(executionLog),
 		},
-	}, nil
+	}
 }
 
 // buildMCPSystemPrompt создает системный промпт для работы с MCP инструментами
 func (c *VibeCodingLLMClient) buildMCPSystemPrompt() string {
-	return `You are an autonomous coding assistant with access to MCP tools for direct file manipulation. 
-You can read, write, delete files, execute commands, run tests, and validate code without user interaction.
-
-AVAILABLE MCP TOOLS:
-- vibe_list_files(user_id): List all files in session
-- vibe_read_file(user_id, filename): Read file content
-- vibe_write_file(user_id, filename, content, generated=true): Write/update file
-- vibe_delete_file(user_id, filename): Delete file
-- vibe_execute_command(user_id, command): Execute shell command
-- vibe_validate_code(user_id, filename=""): Validate code syntax
-- vibe_run_tests(user_id, test_file=""): Run tests
-- vibe_get_session_info(user_id): Get session information
-
-RESPONSE FORMAT:
-Respond with a JSON object containing your action plan:
-
-{
-  "action": "continue|complete",
-  "reasoning": "explain what you're doing and why",
-  "mcp_calls": [
-    {
-      "tool": "tool_name",
-      "params": {"param1": "value1", "param2": "value2"},
-      "purpose": "why you're calling this tool"
-    }
-  ],
-  "next_step": "description of what to do next (if action is continue)",
-  "summary": "summary of work completed (if action is complete)"
-}
+	return `You are an autonomous coding assistant with access to vibe_* tools for direct file manipulation.
+You can read, write and rename files, execute commands, run tests, and validate code without user interaction.
+The tools are provided via function calling; call them directly, several at once if they are independent.
+Generated files never replace original project files unless vibe_write_file is called with overwrite_original=true deliberately.
 
 GUIDELINES:
 - Start by understanding the current project state (list files, read key files)
@@ -543,10 +514,9 @@ GUIDELINES:
 - Test your changes after implementation
 - Fix any errors you encounter
 - Work autonomously without asking for user input
-- Use "action": "complete" when the task is finished
-- Use "action": "continue" if more work is needed
 - Be methodical and careful with file operations
-- Always validate generated code before considering work complete`
+- Always validate generated code before considering work complete
+- When the task is finished, stop calling tools and reply with a short summary of the work done`
 }
 
 // buildMCPUserPrompt создает пользовательский промпт для MCP работы
@@ -623,144 +593,58 @@ Remember to:
 	return prompt
 }
 
-// processMCPStep обрабатывает один шаг автономной работы
-func (c *VibeCodingLLMClient) processMCPStep(ctx context.Context, llmResponse string, userID int64, step int) (string, bool, error) {
-	// Парсим JSON ответ от LLM
-	var stepResponse struct {
-		Action    string `json:"action"` // "continue" или "complete"
-		Reasoning string `json:"reasoning"`
-		MCPCalls  []struct {
-			Tool    string                 `json:"tool"`
-			Params  map[string]interface{} `json:"params"`
-			Purpose string                 `json:"purpose"`
-		} `json:"mcp_calls"`
-		NextStep string `json:"next_step"`
-		Summary  string `json:"summary"`
-	}
-
-	if err := json.Unmarshal([]byte(llmResponse), &stepResponse); err != nil {
-		// Пытаемся извлечь JSON из markdown блока
-		if strings.Contains(llmResponse, "```json") {
-			start := strings.Index(llmResponse, "```json") + 7
-			end := strings.Index(llmResponse[start:], "```")
-			if end > 0 {
-				jsonContent := strings.TrimSpace(llmResponse[start : start+end])
-				if err := json.Unmarshal([]byte(jsonContent), &stepResponse); err != nil {
-					return fmt.Sprintf("Step %d: Failed to parse LLM response as JSON: %v", step, err), false, err
-				}
-			} else {
-				return fmt.Sprintf("Step %d: Invalid JSON in markdown block", step), false, err
-			}
-		} else {
-			return fmt.Sprintf("Step %d: Failed to parse LLM response: %v", step, err), false, err
-		}
+// processMCPStep выполняет вызовы инструментов одного шага автономной работы: возвращает запись
+// журнала шага и tool сообщения с результатами для следующего запроса к LLM
+func (c *VibeCodingLLMClient) processMCPStep(ctx context.Context, tools VibeToolBackend, llmResponse llm.Response, userID int64, step int, rec *RunRecording) (string, []llm.Message) {
+	reasoning := strings.TrimSpace(llmResponse.Content)
+	if reasoning != "" {
+		log.Printf("🎯 Step %d reasoning: %s", step, reasoning)
 	}
 
-	log.Printf("🎯 Step %d reasoning: %s", step, stepResponse.Reasoning)
-
 	var stepLog strings.Builder
-	stepLog.WriteString(fmt.Sprintf("Step %d: %s\n", step, stepResponse.Reasoning))
+	stepLog.WriteString(fmt.Sprintf("Step %d: %s\n", step, reasoning))
 
-	// Выполняем MCP вызовы
-	for i, mcpCall := range stepResponse.MCPCalls {
-		log.Printf("🔧 Executing MCP call %d/%d: %s", i+1, len(stepResponse.MCPCalls), mcpCall.Tool)
-		stepLog.WriteString(fmt.Sprintf("  MCP Call %d: %s - %s\n", i+1, mcpCall.Tool, mcpCall.Purpose))
-
-		// Добавляем user_id если его нет в параметрах
-		if mcpCall.Params["user_id"] == nil {
-			mcpCall.Params["user_id"] = float64(userID) // JSON unmarshaling создает float64
-		}
-
-		// Выполняем MCP инструмент через клиент
-		var result VibeCodingMCPResult
-		var err error
-
-		switch mcpCall.Tool {
-		case "vibe_list_files":
-			result = c.mcpClient.ListFiles(ctx, userID)
-		case "vibe_read_file":
-			filename := ""
-			if f, ok := mcpCall.Params["filename"].(string); ok {
-				filename = f
-			}
-			result = c.mcpClient.ReadFile(ctx, userID, filename)
-		case "vibe_write_file":
-			filename := ""
-			content := ""
-			generated := true
-			if f, ok := mcpCall.Params["filename"].(string); ok {
-				filename = f
-			}
-			if c, ok := mcpCall.Params["content"].(string); ok {
-				content = c
-			}
-			if g, ok := mcpCall.Params["generated"].(bool); ok {
-				generated = g
-			}
-			result = c.mcpClient.WriteFile(ctx, userID, filename, content, generated)
-		case "vibe_execute_command":
-			command := ""
-			if cmd, ok := mcpCall.Params["command"].(string); ok {
-				command = cmd
-			}
-			result = c.mcpClient.ExecuteCommand(ctx, userID, command)
-		case "vibe_validate_code":
-			filename := ""
-			if f, ok := mcpCall.Params["filename"].(string); ok {
-				filename = f
-			}
-			result = c.mcpClient.ValidateCode(ctx, userID, filename)
-		case "vibe_run_tests":
-			testFile := ""
-			if f, ok := mcpCall.Params["test_file"].(string); ok {
-				testFile = f
-			}
-			result = c.mcpClient.RunTests(ctx, userID, testFile)
-		case "vibe_get_session_info":
-			result = c.mcpClient.GetSessionInfo(ctx, userID)
-		default:
-			err = fmt.Errorf("unknown MCP tool: %s", mcpCall.Tool)
-		}
+	results := make([]llm.Message, 0, len(llmResponse.ToolCalls))
+	for i, tc := range llmResponse.ToolCalls {
+		log.Printf("🔧 Executing MCP call %d/%d: %s", i+1, len(llmResponse.ToolCalls), tc.Function.Name)
+		stepLog.WriteString(fmt.Sprintf("  MCP Call %d: %s\n", i+1, tc.Function.Name))
 
+		rec.recordDecision(RunDecision{Step: step, Kind: DecisionToolCall, Tool: tc.Function.Name, Arguments: tc.Function.Arguments})
+		result, err := executeVibeTool(ctx, tools, userID, tc.Function)
+		rec.recordToolCall(step, c.now(), tc.Function, result, err)
 		if err != nil {
-			errorMsg := fmt.Sprintf("    ERROR: %v", err)
-			stepLog.WriteString(errorMsg + "\n")
+			stepLog.WriteString(fmt.Sprintf("    ERROR: %v\n", err))
 			log.Printf("❌ MCP call failed: %v", err)
 		} else if !result.Success {
-			errorMsg := fmt.Sprintf("    FAILED: %s", result.Message)
-			stepLog.WriteString(errorMsg + "\n")
+			stepLog.WriteString(fmt.Sprintf("    FAILED: %s\n", result.Message))
 			log.Printf("❌ MCP call failed: %s", result.Message)
 		} else {
 			stepLog.WriteString("    SUCCESS\n")
 			log.Printf("✅ MCP call successful")
 
 			// Логируем результат для важных операций
-			if mcpCall.Tool == "vibe_read_file" {
+			if tc.Function.Name == "vibe_read_file" {
 				if len(result.Message) > 200 {
 					stepLog.WriteString(fmt.Sprintf("      Content: %s... (%d chars)\n", result.Message[:200], len(result.Message)))
 				} else {
 					stepLog.WriteString(fmt.Sprintf("      Content: %s\n", result.Message))
 				}
-			} else if mcpCall.Tool == "vibe_list_files" {
+			} else if tc.Function.Name == "vibe_list_files" {
 				stepLog.WriteString(fmt.Sprintf("      Total files: %d\n", result.TotalFiles))
 			}
 		}
+		results = append(results, llm.Message{Role: "tool", ToolCallID: tc.ID, Content: vibeToolResultContent(result, err)})
 	}
 
-	// Определяем нужно ли продолжать
-	shouldContinue := stepResponse.Action == "continue"
-
-	if stepResponse.Action == "complete" {
-		stepLog.WriteString(fmt.Sprintf("COMPLETED: %s\n", stepResponse.Summary))
-	} else if stepResponse.NextStep != "" {
-		stepLog.WriteString(fmt.Sprintf("Next: %s\n", stepResponse.NextStep))
-	}
-
-	return stepLog.String(), shouldContinue, nil
+	return strings.TrimRight(stepLog.String(), "\n"), results
 }
 
 // isJSONParsingError проверяет, является ли ошибка ошибкой парсинга JSON
 func isJSONParsingError(err error) bool {
+	var extractErr *jsonextract.Error
+	if errors.As(err, &extractErr) {
+		return true
+	}
 	return strings.Contains(err.Error(), "failed to parse JSON") ||
 		strings.Contains(err.Error(), "invalid character") ||
 		strings.Contains(err.Error(), "unexpected end of JSON")
@@ -7,8 +7,11 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"ai-chatter/internal/codevalidation"
 )
 
 // VibeCodingMCPClient клиент для работы с VibeCoding MCP сервером
@@ -40,7 +43,7 @@ func (m *VibeCodingMCPClient) Connect(ctx context.Context, sessionManager *Sessi
 	}
 
 	cmd := exec.CommandContext(ctx, serverPath)
-	cmd.Env = append(os.Environ())
+	cmd.Env = os.Environ()
 
 	transport := mcp.NewCommandTransport(cmd)
 
@@ -126,6 +129,29 @@ func (m *VibeCodingMCPClient) Close() error {
 	return err
 }
 
+// callTool вызывает MCP инструмент и фиксирует метрики вызова
+func (m *VibeCodingMCPClient) callTool(ctx context.Context, params *mcp.CallToolParams) (*mcp.CallToolResult, error) {
+	start := time.Now()
+	result, err := m.session.CallTool(ctx, params)
+	var errMsg string
+	if err != nil {
+		errMsg = fmt.Sprintf("MCP error: %v", err)
+	} else if result.IsError {
+		errMsg = resultText(result.Content)
+		if errMsg == "" {
+			errMsg = "tool returned error"
+		}
+	}
+	DefaultToolMetrics.Record(params.Name, time.Since(start), errMsg)
+	// Алерт stdio MCP сервера: его обработчик задан только в процессе бота
+	if result != nil {
+		if alert, ok := toolFailureAlertFromMeta(result.Meta); ok {
+			DefaultToolMetrics.ReportAlert(alert)
+		}
+	}
+	return result, err
+}
+
 // ListFiles получает список файлов в VibeCoding сессии через MCP
 func (m *VibeCodingMCPClient) ListFiles(ctx context.Context, userID int64) VibeCodingMCPResult {
 	if m.session == nil {
@@ -134,7 +160,7 @@ func (m *VibeCodingMCPClient) ListFiles(ctx context.Context, userID int64) VibeC
 
 	log.Printf("📁 Listing files via MCP for user: %d", userID)
 
-	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
+	result, err := m.callTool(ctx, &mcp.CallToolParams{
 		Name: "vibe_list_files",
 		Arguments: map[string]any{
 			"user_id": userID,
@@ -182,7 +208,7 @@ func (m *VibeCodingMCPClient) ReadFile(ctx context.Context, userID int64, filena
 
 	log.Printf("📄 Reading file via MCP: %s for user %d", filename, userID)
 
-	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
+	result, err := m.callTool(ctx, &mcp.CallToolParams{
 		Name: "vibe_read_file",
 		Arguments: map[string]any{
 			"user_id":  userID,
@@ -214,21 +240,23 @@ func (m *VibeCodingMCPClient) ReadFile(ctx context.Context, userID int64, filena
 	}
 }
 
-// WriteFile записывает файл в VibeCoding сессии через MCP
-func (m *VibeCodingMCPClient) WriteFile(ctx context.Context, userID int64, filename, content string, generated bool) VibeCodingMCPResult {
+// WriteFile записывает файл в VibeCoding сессии через MCP. overwriteOriginal разрешает
+// сгенерированному файлу заменить исходный файл пользователя
+func (m *VibeCodingMCPClient) WriteFile(ctx context.Context, userID int64, filename, content string, generated, overwriteOriginal bool) VibeCodingMCPResult {
 	if m.session == nil {
 		return VibeCodingMCPResult{Success: false, Message: "VibeCoding MCP session not connected"}
 	}
 
 	log.Printf("✏️ Writing file via MCP: %s for user %d", filename, userID)
 
-	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
+	result, err := m.callTool(ctx, &mcp.CallToolParams{
 		Name: "vibe_write_file",
 		Arguments: map[string]any{
-			"user_id":   userID,
-			"filename":  filename,
-			"content":   content,
-			"generated": generated,
+			"user_id":            userID,
+			"filename":           filename,
+			"content":            content,
+			"generated":          generated,
+			"overwrite_original": overwriteOriginal,
 		},
 	})
 
@@ -238,7 +266,12 @@ func (m *VibeCodingMCPClient) WriteFile(ctx context.Context, userID int64, filen
 	}
 
 	if result.IsError {
-		return VibeCodingMCPResult{Success: false, Message: "Write file tool returned error"}
+		// Сообщение инструмента объясняет причину (например, защиту исходного файла)
+		message := resultText(result.Content)
+		if message == "" {
+			message = "Write file tool returned error"
+		}
+		return VibeCodingMCPResult{Success: false, Message: message}
 	}
 
 	// Извлекаем текст из результата
@@ -256,29 +289,118 @@ func (m *VibeCodingMCPClient) WriteFile(ctx context.Context, userID int64, filen
 	}
 }
 
-// ExecuteCommand выполняет команду в VibeCoding сессии через MCP
-func (m *VibeCodingMCPClient) ExecuteCommand(ctx context.Context, userID int64, command string) VibeCodingMCPResult {
+// RenameFile переименовывает или перемещает файл VibeCoding сессии через MCP
+func (m *VibeCodingMCPClient) RenameFile(ctx context.Context, userID int64, oldPath, newPath string) VibeCodingMCPResult {
 	if m.session == nil {
 		return VibeCodingMCPResult{Success: false, Message: "VibeCoding MCP session not connected"}
 	}
 
-	log.Printf("⚡ Executing command via MCP: %s for user %d", command, userID)
+	log.Printf("🔀 Renaming file via MCP: %s -> %s for user %d", oldPath, newPath, userID)
 
-	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
-		Name: "vibe_execute_command",
+	result, err := m.callTool(ctx, &mcp.CallToolParams{
+		Name: "vibe_rename_file",
+		Arguments: map[string]any{
+			"user_id":  userID,
+			"old_path": oldPath,
+			"new_path": newPath,
+		},
+	})
+
+	if err != nil {
+		log.Printf("❌ VibeCoding MCP rename file error: %v", err)
+		return VibeCodingMCPResult{Success: false, Message: fmt.Sprintf("MCP error: %v", err)}
+	}
+
+	if result.IsError {
+		message := resultText(result.Content)
+		if message == "" {
+			message = "Rename file tool returned error"
+		}
+		return VibeCodingMCPResult{Success: false, Message: message}
+	}
+
+	return VibeCodingMCPResult{
+		Success: true,
+		Message: resultText(result.Content),
+		Data:    formatResultMeta(result.Meta),
+	}
+}
+
+// GitCommit коммитит рабочий каталог VibeCoding сессии через MCP, хэш коммита — в Data
+func (m *VibeCodingMCPClient) GitCommit(ctx context.Context, userID int64, message string) VibeCodingMCPResult {
+	if m.session == nil {
+		return VibeCodingMCPResult{Success: false, Message: "VibeCoding MCP session not connected"}
+	}
+
+	log.Printf("📝 Committing workspace via MCP for user %d", userID)
+
+	result, err := m.callTool(ctx, &mcp.CallToolParams{
+		Name: "vibe_git_commit",
 		Arguments: map[string]any{
 			"user_id": userID,
-			"command": command,
+			"message": message,
 		},
 	})
 
+	if err != nil {
+		log.Printf("❌ VibeCoding MCP git commit error: %v", err)
+		return VibeCodingMCPResult{Success: false, Message: fmt.Sprintf("MCP error: %v", err)}
+	}
+
+	if result.IsError {
+		message := resultText(result.Content)
+		if message == "" {
+			message = "Git commit tool returned error"
+		}
+		return VibeCodingMCPResult{Success: false, Message: message}
+	}
+
+	return VibeCodingMCPResult{
+		Success: true,
+		Message: resultText(result.Content),
+		Data:    formatResultMeta(result.Meta),
+	}
+}
+
+// ExecuteCommand выполняет команду в VibeCoding сессии через MCP
+func (m *VibeCodingMCPClient) ExecuteCommand(ctx context.Context, userID int64, command string) VibeCodingMCPResult {
+	return m.ExecuteCommandWithOptions(ctx, userID, command, codevalidation.ExecOptions{})
+}
+
+// ExecuteCommandWithOptions выполняет команду в каталоге opts.WorkDir с переменными opts.Env через MCP
+func (m *VibeCodingMCPClient) ExecuteCommandWithOptions(ctx context.Context, userID int64, command string, opts codevalidation.ExecOptions) VibeCodingMCPResult {
+	if m.session == nil {
+		return VibeCodingMCPResult{Success: false, Message: "VibeCoding MCP session not connected"}
+	}
+
+	log.Printf("⚡ Executing command via MCP: %s for user %d", command, userID)
+
+	arguments := map[string]any{
+		"user_id": userID,
+		"command": command,
+	}
+	if opts.WorkDir != "" {
+		arguments["workdir"] = opts.WorkDir
+	}
+	if len(opts.Env) > 0 {
+		arguments["env"] = opts.Env
+	}
+	result, err := m.callTool(ctx, &mcp.CallToolParams{
+		Name:      "vibe_execute_command",
+		Arguments: arguments,
+	})
+
 	if err != nil {
 		log.Printf("❌ VibeCoding MCP execute command error: %v", err)
 		return VibeCodingMCPResult{Success: false, Message: fmt.Sprintf("MCP error: %v", err)}
 	}
 
 	if result.IsError {
-		return VibeCodingMCPResult{Success: false, Message: "Execute command tool returned error"}
+		message := resultText(result.Content)
+		if message == "" {
+			message = "Execute command tool returned error"
+		}
+		return VibeCodingMCPResult{Success: false, Message: message}
 	}
 
 	// Извлекаем текст из результата
@@ -309,7 +431,7 @@ func (m *VibeCodingMCPClient) RunTestsWithValidation(ctx context.Context, userID
 
 	log.Printf("🧪 Running tests via MCP for user %d (validate_and_fix: %t)", userID, validateAndFix)
 
-	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
+	result, err := m.callTool(ctx, &mcp.CallToolParams{
 		Name: "vibe_run_tests",
 		Arguments: map[string]any{
 			"user_id":          userID,
@@ -350,7 +472,7 @@ func (m *VibeCodingMCPClient) ValidateCode(ctx context.Context, userID int64, fi
 
 	log.Printf("🔍 Validating code via MCP for user %d", userID)
 
-	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
+	result, err := m.callTool(ctx, &mcp.CallToolParams{
 		Name: "vibe_validate_code",
 		Arguments: map[string]any{
 			"user_id":  userID,
@@ -390,7 +512,7 @@ func (m *VibeCodingMCPClient) GetSessionInfo(ctx context.Context, userID int64)
 
 	log.Printf("ℹ️ Getting session info via MCP for user %d", userID)
 
-	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
+	result, err := m.callTool(ctx, &mcp.CallToolParams{
 		Name: "vibe_get_session_info",
 		Arguments: map[string]any{
 			"user_id": userID,
@@ -421,6 +543,30 @@ func (m *VibeCodingMCPClient) GetSessionInfo(ctx context.Context, userID int64)
 	}
 }
 
+// GetMetrics получает метрики инструментов MCP сервера
+func (m *VibeCodingMCPClient) GetMetrics(ctx context.Context) VibeCodingMCPResult {
+	if m.session == nil {
+		return VibeCodingMCPResult{Success: false, Message: "VibeCoding MCP session not connected"}
+	}
+
+	result, err := m.session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "vibe_get_metrics",
+		Arguments: map[string]any{},
+	})
+	if err != nil {
+		return VibeCodingMCPResult{Success: false, Message: fmt.Sprintf("MCP error: %v", err)}
+	}
+	if result.IsError {
+		return VibeCodingMCPResult{Success: false, Message: "Get metrics tool returned error"}
+	}
+
+	return VibeCodingMCPResult{
+		Success: true,
+		Message: resultText(result.Content),
+		Data:    formatResultMeta(result.Meta),
+	}
+}
+
 // GetAvailableTools получает список доступных MCP тулов
 func (m *VibeCodingMCPClient) GetAvailableTools(ctx context.Context) ([]string, error) {
 	if m.session == nil {
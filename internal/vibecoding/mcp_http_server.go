@@ -37,39 +37,59 @@ func (s *VibeCodingMCPHTTPServer) Start(ctx context.Context) error {
 	mcp.AddTool(mcpServer, &mcp.Tool{
 		Name:        "vibe_list_files",
 		Description: "Lists all files in the VibeCoding workspace for the specified user",
-	}, vibeCodingServer.ListFiles)
+	}, InstrumentTool(DefaultToolMetrics, "vibe_list_files", vibeCodingServer.ListFiles))
 
 	mcp.AddTool(mcpServer, &mcp.Tool{
 		Name:        "vibe_read_file",
 		Description: "Reads the content of a specific file in the VibeCoding workspace",
-	}, vibeCodingServer.ReadFile)
+	}, InstrumentTool(DefaultToolMetrics, "vibe_read_file", vibeCodingServer.ReadFile))
 
 	mcp.AddTool(mcpServer, &mcp.Tool{
 		Name:        "vibe_write_file",
 		Description: "Writes content to a file in the VibeCoding workspace",
-	}, vibeCodingServer.WriteFile)
+	}, InstrumentTool(DefaultToolMetrics, "vibe_write_file", vibeCodingServer.WriteFile))
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "vibe_rename_file",
+		Description: "Renames or moves a file in the VibeCoding workspace, keeping its original/generated status",
+	}, InstrumentTool(DefaultToolMetrics, "vibe_rename_file", RenameFileToolHandler(s.sessionManager)))
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "vibe_git_commit",
+		Description: "Commits the VibeCoding workspace with git and returns the commit hash",
+	}, InstrumentTool(DefaultToolMetrics, "vibe_git_commit", GitCommitToolHandler(s.sessionManager)))
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "vibe_push_github",
+		Description: "Pushes new and modified session files to GitHub as a branch (based on the latest head of base) and opens a pull request with an LLM-written summary. repo is owner/repo (default: the cloned repository), base is the target branch",
+	}, InstrumentTool(DefaultToolMetrics, "vibe_push_github", GitHubPushToolHandler(s.sessionManager)))
 
 	mcp.AddTool(mcpServer, &mcp.Tool{
 		Name:        "vibe_execute_command",
 		Description: "Executes a command in the VibeCoding container environment",
-	}, vibeCodingServer.ExecuteCommand)
+	}, InstrumentTool(DefaultToolMetrics, "vibe_execute_command", vibeCodingServer.ExecuteCommand))
 
 	mcp.AddTool(mcpServer, &mcp.Tool{
 		Name:        "vibe_validate_code",
 		Description: "Validates code syntax and compilation in the VibeCoding environment",
-	}, vibeCodingServer.ValidateCode)
+	}, InstrumentTool(DefaultToolMetrics, "vibe_validate_code", vibeCodingServer.ValidateCode))
 
 	mcp.AddTool(mcpServer, &mcp.Tool{
 		Name:        "vibe_run_tests",
 		Description: "Runs tests in the VibeCoding environment",
-	}, vibeCodingServer.RunTests)
+	}, InstrumentTool(DefaultToolMetrics, "vibe_run_tests", vibeCodingServer.RunTests))
 
 	mcp.AddTool(mcpServer, &mcp.Tool{
 		Name:        "vibe_get_session_info",
 		Description: "Gets information about the current VibeCoding session",
-	}, vibeCodingServer.GetSessionInfo)
+	}, InstrumentTool(DefaultToolMetrics, "vibe_get_session_info", vibeCodingServer.GetSessionInfo))
+
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name:        "vibe_get_metrics",
+		Description: "Returns per-tool call counts, error counts, latency percentiles and last error",
+	}, MetricsToolHandler(DefaultToolMetrics))
 
-	log.Printf("🔗 VibeCoding MCP HTTP server registered %d tools", 7)
+	log.Printf("🔗 VibeCoding MCP HTTP server registered %d tools", 10)
 
 	// TODO: HTTP transport not yet available in MCP SDK
 	// For now, we'll use stdio transport through subprocess
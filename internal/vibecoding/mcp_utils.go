@@ -29,6 +29,13 @@ func ParseUserID(userIDArg interface{}) (int64, error) {
 	return userID, nil
 }
 
+// WriteFileOptionsFromArgs читает флаги generated и overwrite_original инструмента vibe_write_file
+func WriteFileOptionsFromArgs(args map[string]interface{}) WriteFileOptions {
+	generated, _ := args["generated"].(bool)
+	overwrite, _ := args["overwrite_original"].(bool)
+	return WriteFileOptions{Generated: generated, OverwriteOriginal: overwrite}
+}
+
 // FormatFileList форматирует список файлов для вывода
 func FormatFileList(userID int64, fileList []string) string {
 	var fileListText strings.Builder
@@ -47,6 +54,7 @@ func FormatFileList(userID int64, fileList []string) string {
 
 // FormatSessionInfo форматирует информацию о сессии
 func FormatSessionInfo(userID int64, session *VibeCodingSession) string {
+	promptTokens, completionTokens, cost := session.TokenUsage()
 	return fmt.Sprintf(`📊 VibeCoding Session Info for User %d
 
 **Project:** %s
@@ -54,12 +62,27 @@ func FormatSessionInfo(userID int64, session *VibeCodingSession) string {
 **Test Command:** %s
 **Start Time:** %s
 **Files:** %d
-**Generated Files:** %d`,
+**Generated Files:** %d
+**LLM Tokens:** %d prompt + %d completion (≈ $%.4f)`,
 		userID,
 		session.ProjectName,
 		session.ContainerID,
 		session.TestCommand,
 		session.StartTime.Format(time.RFC3339),
 		len(session.Files),
-		len(session.GeneratedFiles))
+		len(session.GeneratedFiles),
+		promptTokens, completionTokens, cost) + formatActiveModelLine(session)
+}
+
+// formatActiveModelLine строка с моделью последнего ответа для vibe_get_session_info
+func formatActiveModelLine(session *VibeCodingSession) string {
+	model, fallbackFrom := session.ActiveModel()
+	if model == "" {
+		return ""
+	}
+	line := "\n**LLM Model:** " + model
+	if fallbackFrom != "" {
+		line += fmt.Sprintf(" (fallback, primary %s failed)", fallbackFrom)
+	}
+	return line
 }
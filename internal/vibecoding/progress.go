@@ -0,0 +1,179 @@
+package vibecoding
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// progressMinEditInterval Telegram ограничивает частоту правок одного сообщения
+	progressMinEditInterval = 1500 * time.Millisecond
+	progressBarMaxWidth     = 10
+	progressErrorRunes      = 160
+)
+
+// ProgressFunc сообщает о ходе многошаговой операции: попытка attempt из total, текущий шаг
+// и ошибка (nil — шаг без ошибки, последняя ошибка остаётся на экране)
+type ProgressFunc func(attempt, total int, step string, err error)
+
+// progressReporter показывает прогресс операции в одном сообщении Telegram:
+// полосу попыток, текущий шаг, прошедшее время и последнюю ошибку одной строкой
+type progressReporter struct {
+	update   func(text string)
+	title    string
+	started  time.Time
+	now      func() time.Time
+	interval time.Duration
+	mu       sync.Mutex
+	attempt  int
+	total    int
+	step     string
+	lastErr  string
+	lastEdit time.Time
+	lastText string
+	flush    *time.Timer
+	finished bool
+}
+
+// newProgressReporter создаёт индикатор прогресса для сообщения messageID
+func (h *VibeCodingHandler) newProgressReporter(chatID int64, messageID int, title string, total int) *progressReporter {
+	return newProgressReporter(func(text string) {
+		h.updateMessage(chatID, messageID, text)
+	}, title, total)
+}
+
+func newProgressReporter(update func(text string), title string, total int) *progressReporter {
+	return &progressReporter{
+		update:   update,
+		title:    title,
+		total:    total,
+		started:  time.Now(),
+		now:      time.Now,
+		interval: progressMinEditInterval,
+	}
+}
+
+// Report обновляет состояние; совместим с ProgressFunc
+func (r *progressReporter) Report(attempt, total int, step string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.finished {
+		return
+	}
+	r.attempt = attempt
+	if total > 0 {
+		r.total = total
+	}
+	r.step = step
+	if err != nil {
+		r.lastErr = collapseError(err.Error())
+	}
+	r.editLocked()
+}
+
+// Step переходит к следующему шагу текущей попытки
+func (r *progressReporter) Step(attempt int, step string) {
+	r.Report(attempt, 0, step, nil)
+}
+
+// Fail запоминает ошибку попытки
+func (r *progressReporter) Fail(attempt int, err error) {
+	r.mu.Lock()
+	step := r.step
+	r.mu.Unlock()
+	r.Report(attempt, 0, step, err)
+}
+
+// Finish показывает итоговое сообщение без полосы прогресса. Правка выполняется всегда,
+// отложенные промежуточные обновления отменяются
+func (r *progressReporter) Finish(text string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.flush != nil {
+		r.flush.Stop()
+		r.flush = nil
+	}
+	r.finished = true
+	r.sendLocked(text)
+}
+
+// editLocked правит сообщение не чаще interval; пропущенное состояние показывается по таймеру
+func (r *progressReporter) editLocked() {
+	wait := r.interval - r.now().Sub(r.lastEdit)
+	if r.lastEdit.IsZero() || wait <= 0 {
+		r.sendLocked(r.renderLocked())
+		return
+	}
+	if r.flush == nil {
+		r.flush = time.AfterFunc(wait, r.flushPending)
+	}
+}
+
+func (r *progressReporter) flushPending() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flush = nil
+	if r.finished {
+		return
+	}
+	r.sendLocked(r.renderLocked())
+}
+
+func (r *progressReporter) sendLocked(text string) {
+	if text == r.lastText {
+		return
+	}
+	r.update(text)
+	r.lastText = text
+	r.lastEdit = r.now()
+}
+
+func (r *progressReporter) renderLocked() string {
+	var b strings.Builder
+	b.WriteString("[vibecoding] ")
+	b.WriteString(r.title)
+	b.WriteString("\n")
+	b.WriteString(progressBar(r.attempt, r.total))
+	if r.step != "" {
+		b.WriteString(" · ")
+		b.WriteString(r.step)
+	}
+	b.WriteString(" · ⏱ ")
+	b.WriteString(r.now().Sub(r.started).Round(time.Second).String())
+	if r.lastErr != "" {
+		b.WriteString("\n⚠️ ")
+		b.WriteString(r.lastErr)
+	}
+	return b.String()
+}
+
+// progressBar рисует полосу вида ▓▓▓░░ 3/5; при большом total полоса масштабируется
+func progressBar(current, total int) string {
+	if total <= 0 {
+		total = 1
+	}
+	if current < 0 {
+		current = 0
+	}
+	if current > total {
+		current = total
+	}
+	width := total
+	if width > progressBarMaxWidth {
+		width = progressBarMaxWidth
+	}
+	filled := current * width / total
+	return fmt.Sprintf("%s%s %d/%d", strings.Repeat("▓", filled), strings.Repeat("░", width-filled), current, total)
+}
+
+// collapseError сворачивает многострочную ошибку в одну короткую строку
+func collapseError(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) > progressErrorRunes {
+		text = string([]rune(text)[:progressErrorRunes]) + "…"
+	}
+	return text
+}
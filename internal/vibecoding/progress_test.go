@@ -0,0 +1,113 @@
+package vibecoding
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// progressRecorder запоминает тексты правок сообщения
+type progressRecorder struct {
+	mu    sync.Mutex
+	edits []string
+}
+
+func (p *progressRecorder) update(text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.edits = append(p.edits, text)
+}
+
+func (p *progressRecorder) snapshot() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.edits...)
+}
+
+func TestProgressBar(t *testing.T) {
+	cases := map[[2]int]string{
+		{3, 5}:   "▓▓▓░░ 3/5",
+		{0, 3}:   "░░░ 0/3",
+		{7, 5}:   "▓▓▓▓▓ 5/5",
+		{5, 20}:  "▓▓░░░░░░░░ 5/20",
+		{1, 0}:   "▓ 1/1",
+		{10, 10}: "▓▓▓▓▓▓▓▓▓▓ 10/10",
+	}
+	for in, want := range cases {
+		if got := progressBar(in[0], in[1]); got != want {
+			t.Errorf("progressBar(%d, %d) = %q, want %q", in[0], in[1], got, want)
+		}
+	}
+}
+
+func TestProgressReporter_RendersStepElapsedAndCollapsedError(t *testing.T) {
+	rec := &progressRecorder{}
+	r := newProgressReporter(rec.update, "🧠 Генерация тестов", 5)
+	started := r.started
+	r.now = func() time.Time { return started.Add(12 * time.Second) }
+
+	r.Report(3, 0, "валидация", errors.New("line one\n\tline two\n"+strings.Repeat("x", 300)))
+
+	edits := rec.snapshot()
+	if len(edits) != 1 {
+		t.Fatalf("expected one edit, got %v", edits)
+	}
+	lines := strings.Split(edits[0], "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected title, bar and error lines, got %q", edits[0])
+	}
+	if lines[0] != "[vibecoding] 🧠 Генерация тестов" || lines[1] != "▓▓▓░░ 3/5 · валидация · ⏱ 12s" {
+		t.Errorf("unexpected header: %q", edits[0])
+	}
+	if !strings.HasPrefix(lines[2], "⚠️ line one line two xxx") || !strings.HasSuffix(lines[2], "…") {
+		t.Errorf("error must be collapsed into one truncated line: %q", lines[2])
+	}
+
+	// Ошибка остаётся видна на следующих шагах
+	r.lastEdit = time.Time{}
+	r.Step(4, "генерация")
+	if last := rec.snapshot()[1]; !strings.Contains(last, "4/5 · генерация") || !strings.Contains(last, "⚠️ line one") {
+		t.Errorf("last error must stay visible: %q", last)
+	}
+}
+
+func TestProgressReporter_RateLimitsAndFlushesLatestState(t *testing.T) {
+	rec := &progressRecorder{}
+	r := newProgressReporter(rec.update, "🔧 Настройка", 3)
+	r.interval = 50 * time.Millisecond
+
+	r.Step(1, "анализ")
+	r.Step(1, "контейнер")
+	r.Step(1, "зависимости")
+	if edits := rec.snapshot(); len(edits) != 1 || !strings.Contains(edits[0], "анализ") {
+		t.Fatalf("edits within the interval must be deferred, got %v", edits)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(rec.snapshot()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	edits := rec.snapshot()
+	if len(edits) != 2 || !strings.Contains(edits[1], "зависимости") {
+		t.Fatalf("deferred edit must show the latest state, got %v", edits)
+	}
+}
+
+func TestProgressReporter_FinishAlwaysEditsAndCancelsPending(t *testing.T) {
+	rec := &progressRecorder{}
+	r := newProgressReporter(rec.update, "🤖 Автономная работа", 10)
+	r.interval = 30 * time.Millisecond
+
+	r.Step(1, "запрос к LLM")
+	r.Step(2, "запрос к LLM")
+	r.Finish("[vibecoding] ✅ Готово")
+	r.Step(3, "поздний шаг")
+	time.Sleep(80 * time.Millisecond)
+
+	edits := rec.snapshot()
+	if len(edits) != 2 || edits[1] != "[vibecoding] ✅ Готово" {
+		t.Fatalf("final state must be the last edit, got %v", edits)
+	}
+}
@@ -0,0 +1,247 @@
+package vibecoding
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Имена метрик /metrics. Это внешний контракт для дашбордов и алертов: имена и метки не
+// переименовываются, новые метрики только добавляются
+const (
+	// metricActiveSessions gauge: открытые сессии
+	metricActiveSessions = "vibecoding_active_sessions"
+	// metricContainersRunning gauge: сессии с запущенным Docker контейнером
+	metricContainersRunning = "vibecoding_containers_running"
+	// metricSessionsStarted counter: созданные сессии (восстановленные после перезапуска не считаются)
+	metricSessionsStarted = "vibecoding_sessions_started_total"
+	// metricSessionsEnded counter: завершённые сессии, включая закрытые по бездействию
+	metricSessionsEnded = "vibecoding_sessions_ended_total"
+	// metricSetupFailures counter: настройки окружения, не удавшиеся после всех попыток
+	metricSetupFailures = "vibecoding_setup_failures_total"
+	// metricTestRuns counter с меткой outcome: запуски команды тестов сессии
+	metricTestRuns = "vibecoding_test_runs_total"
+	// metricToolCalls counter с меткой tool: вызовы vibe_* MCP инструментов
+	metricToolCalls = "vibecoding_mcp_tool_calls_total"
+	// metricToolErrors counter с меткой tool: ошибки vibe_* MCP инструментов
+	metricToolErrors = "vibecoding_mcp_tool_errors_total"
+	// metricLLMTokens counter с меткой type (prompt, completion): токены LLM запросов сессий
+	metricLLMTokens = "vibecoding_llm_tokens_total"
+	// metricLLMCost counter: оценка стоимости LLM запросов сессий в USD (0, если цены не заданы)
+	metricLLMCost = "vibecoding_llm_cost_usd_total"
+)
+
+// Исходы запуска тестов для метки outcome метрики vibecoding_test_runs_total
+const (
+	TestOutcomePassed  = "passed"
+	TestOutcomeFailed  = "failed"
+	TestOutcomeError   = "error"
+	TestOutcomeTimeout = "timeout"
+)
+
+var testOutcomes = [...]string{TestOutcomePassed, TestOutcomeFailed, TestOutcomeError, TestOutcomeTimeout}
+
+// DefaultSessionMetrics счётчики сессий VibeCoding текущего процесса
+var DefaultSessionMetrics = NewSessionMetrics()
+
+// SessionMetrics счётчики жизненного цикла сессий, тестов и LLM (сбрасываются при перезапуске процесса)
+type SessionMetrics struct {
+	sessionsStarted  atomic.Int64
+	sessionsEnded    atomic.Int64
+	setupFailures    atomic.Int64
+	testRuns         [len(testOutcomes)]atomic.Int64
+	promptTokens     atomic.Int64
+	completionTokens atomic.Int64
+
+	costMu  sync.Mutex
+	costUSD float64
+}
+
+// NewSessionMetrics создаёт обнулённые счётчики
+func NewSessionMetrics() *SessionMetrics {
+	return &SessionMetrics{}
+}
+
+// SessionStarted учитывает новую сессию
+func (m *SessionMetrics) SessionStarted() { m.sessionsStarted.Add(1) }
+
+// SessionEnded учитывает завершённую сессию
+func (m *SessionMetrics) SessionEnded() { m.sessionsEnded.Add(1) }
+
+// SetupFailed учитывает неудавшуюся настройку окружения
+func (m *SessionMetrics) SetupFailed() { m.setupFailures.Add(1) }
+
+// TestRun учитывает запуск тестов; неизвестный исход считается ошибкой
+func (m *SessionMetrics) TestRun(outcome string) {
+	for i, o := range testOutcomes {
+		if o == outcome {
+			m.testRuns[i].Add(1)
+			return
+		}
+	}
+	if outcome != TestOutcomeError {
+		m.TestRun(TestOutcomeError)
+	}
+}
+
+// LLMUsage учитывает токены и стоимость LLM запроса
+func (m *SessionMetrics) LLMUsage(promptTokens, completionTokens int64, costUSD float64) {
+	m.promptTokens.Add(promptTokens)
+	m.completionTokens.Add(completionTokens)
+	if costUSD > 0 {
+		m.costMu.Lock()
+		m.costUSD += costUSD
+		m.costMu.Unlock()
+	}
+}
+
+func (m *SessionMetrics) cost() float64 {
+	m.costMu.Lock()
+	defer m.costMu.Unlock()
+	return m.costUSD
+}
+
+// testOutcome исход запуска тестов по результату ExecuteCommand
+func testOutcome(success bool, err error) string {
+	switch {
+	case errors.Is(err, ErrCommandTimeout):
+		return TestOutcomeTimeout
+	case err != nil:
+		return TestOutcomeError
+	case success:
+		return TestOutcomePassed
+	default:
+		return TestOutcomeFailed
+	}
+}
+
+// promWriter пишет метрики в текстовом формате Prometheus (text/plain; version=0.0.4)
+type promWriter struct {
+	w io.Writer
+}
+
+func (p promWriter) header(name, kind, help string) {
+	fmt.Fprintf(p.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (p promWriter) value(name string, v float64, labels ...string) {
+	var lb strings.Builder
+	for i := 0; i+1 < len(labels); i += 2 {
+		if lb.Len() > 0 {
+			lb.WriteByte(',')
+		}
+		fmt.Fprintf(&lb, "%s=%q", labels[i], promLabelValue(labels[i+1]))
+	}
+	if lb.Len() > 0 {
+		fmt.Fprintf(p.w, "%s{%s} %v\n", name, lb.String(), v)
+		return
+	}
+	fmt.Fprintf(p.w, "%s %v\n", name, v)
+}
+
+// promLabelValue убирает из значения метки символы, которые %q экранирует не по правилам Prometheus
+func promLabelValue(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// WritePrometheus пишет метрики менеджера сессий, m и инструментов tools в формате Prometheus.
+// Все метрики выводятся и при нуле сессий, чтобы ряды не пропадали из Prometheus
+func (sm *SessionManager) WritePrometheus(w io.Writer, m *SessionMetrics, tools *ToolMetrics) {
+	active, containers := 0, 0
+	if sm != nil {
+		for _, session := range sm.GetAllSessions() {
+			active++
+			session.mutex.RLock()
+			if session.ContainerID != "" {
+				containers++
+			}
+			session.mutex.RUnlock()
+		}
+	}
+
+	p := promWriter{w: w}
+	p.header(metricActiveSessions, "gauge", "Open VibeCoding sessions.")
+	p.value(metricActiveSessions, float64(active))
+	p.header(metricContainersRunning, "gauge", "VibeCoding sessions with a running Docker container.")
+	p.value(metricContainersRunning, float64(containers))
+
+	p.header(metricSessionsStarted, "counter", "VibeCoding sessions started.")
+	p.value(metricSessionsStarted, float64(m.sessionsStarted.Load()))
+	p.header(metricSessionsEnded, "counter", "VibeCoding sessions ended, including idle timeouts.")
+	p.value(metricSessionsEnded, float64(m.sessionsEnded.Load()))
+	p.header(metricSetupFailures, "counter", "VibeCoding environment setups that failed after all attempts.")
+	p.value(metricSetupFailures, float64(m.setupFailures.Load()))
+
+	p.header(metricTestRuns, "counter", "VibeCoding test command runs by outcome.")
+	for i, outcome := range testOutcomes {
+		p.value(metricTestRuns, float64(m.testRuns[i].Load()), "outcome", outcome)
+	}
+
+	snaps := tools.Snapshot()
+	p.header(metricToolCalls, "counter", "VibeCoding MCP tool calls.")
+	for _, s := range snaps {
+		p.value(metricToolCalls, float64(s.Calls), "tool", s.Tool)
+	}
+	p.header(metricToolErrors, "counter", "VibeCoding MCP tool calls that returned an error.")
+	for _, s := range snaps {
+		p.value(metricToolErrors, float64(s.Errors), "tool", s.Tool)
+	}
+
+	p.header(metricLLMTokens, "counter", "LLM tokens used by VibeCoding sessions.")
+	p.value(metricLLMTokens, float64(m.promptTokens.Load()), "type", "prompt")
+	p.value(metricLLMTokens, float64(m.completionTokens.Load()), "type", "completion")
+	p.header(metricLLMCost, "counter", "Estimated LLM cost of VibeCoding sessions in USD.")
+	p.value(metricLLMCost, m.cost())
+}
+
+// handlePrometheus отдаёт метрики процесса для Prometheus
+func (sm *SessionManager) handlePrometheus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	sm.WritePrometheus(w, DefaultSessionMetrics, DefaultToolMetrics)
+}
+
+// StartMetricsServer запускает отдельный сервер только с /metrics (VIBECODING_METRICS_PORT);
+// port <= 0 — метрики доступны лишь на порту веб-интерфейса
+func (sm *SessionManager) StartMetricsServer(port int) error {
+	if port <= 0 {
+		return nil
+	}
+	if sm.metricsServer != nil {
+		return fmt.Errorf("vibecoding metrics server is already running")
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("vibecoding metrics server: port %d is unavailable (set VIBECODING_METRICS_PORT to a free port or 0 to disable): %w", port, err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", sm.handlePrometheus)
+	sm.metricsServer = &http.Server{
+		Handler:      mux,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+	}
+	log.Printf("📈 VibeCoding metrics available on http://localhost:%d/metrics", listener.Addr().(*net.TCPAddr).Port)
+	server := sm.metricsServer
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ VibeCoding metrics server stopped: %v", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,82 @@
+package vibecoding
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetrics_ZeroSessions(t *testing.T) {
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+
+	rr := httptest.NewRecorder()
+	sm.handlePrometheus(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected response: %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	body := rr.Body.String()
+	for _, line := range []string{
+		"# TYPE vibecoding_active_sessions gauge",
+		"vibecoding_active_sessions 0",
+		"vibecoding_containers_running 0",
+		"# TYPE vibecoding_sessions_started_total counter",
+		"# TYPE vibecoding_test_runs_total counter",
+		"# TYPE vibecoding_mcp_tool_calls_total counter",
+		"# TYPE vibecoding_llm_cost_usd_total counter",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+}
+
+func TestPrometheusMetrics_Values(t *testing.T) {
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+	sm.sessions[1] = &VibeCodingSession{UserID: 1, ContainerID: "abc"}
+	sm.sessions[2] = &VibeCodingSession{UserID: 2}
+
+	m := NewSessionMetrics()
+	m.SessionStarted()
+	m.SessionStarted()
+	m.SessionEnded()
+	m.SetupFailed()
+	m.TestRun(testOutcome(true, nil))
+	m.TestRun(testOutcome(false, nil))
+	m.TestRun(testOutcome(false, fmt.Errorf("%w after 1s: go test", ErrCommandTimeout)))
+	m.TestRun("bogus")
+	m.LLMUsage(1000, 200, 0.5)
+	m.LLMUsage(10, 0, 0)
+
+	tools := NewToolMetrics()
+	tools.Record("vibe_run_tests", time.Millisecond, "")
+	tools.Record("vibe_run_tests", time.Millisecond, "container not found")
+
+	var b strings.Builder
+	sm.WritePrometheus(&b, m, tools)
+	body := b.String()
+	for _, line := range []string{
+		"vibecoding_active_sessions 2",
+		"vibecoding_containers_running 1",
+		"vibecoding_sessions_started_total 2",
+		"vibecoding_sessions_ended_total 1",
+		"vibecoding_setup_failures_total 1",
+		`vibecoding_test_runs_total{outcome="passed"} 1`,
+		`vibecoding_test_runs_total{outcome="failed"} 1`,
+		`vibecoding_test_runs_total{outcome="error"} 1`,
+		`vibecoding_test_runs_total{outcome="timeout"} 1`,
+		`vibecoding_mcp_tool_calls_total{tool="vibe_run_tests"} 2`,
+		`vibecoding_mcp_tool_errors_total{tool="vibe_run_tests"} 1`,
+		`vibecoding_llm_tokens_total{type="prompt"} 1010`,
+		`vibecoding_llm_tokens_total{type="completion"} 200`,
+		"vibecoding_llm_cost_usd_total 0.5",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+}
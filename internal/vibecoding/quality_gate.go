@@ -0,0 +1,393 @@
+package vibecoding
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-chatter/internal/llm"
+)
+
+// GateStatus итог проверки качества
+type GateStatus string
+
+const (
+	GatePass GateStatus = "pass"
+	GateWarn GateStatus = "warn"
+	GateFail GateStatus = "fail"
+)
+
+const (
+	// qualityGateTimeout ограничение на все проверки при завершении сессии
+	qualityGateTimeout = 5 * time.Minute
+	// lowCoverageThreshold покрытие ниже порога даёт предупреждение
+	lowCoverageThreshold = 50.0
+	// maxNextSteps сколько рекомендаций показывать пользователю
+	maxNextSteps = 5
+	// maxCaptionLength подпись к документу в Telegram ограничена 1024 символами, оставляем запас на экранирование
+	maxCaptionLength = 900
+)
+
+var (
+	// coverageRe покрытие из вывода тестов: go test -cover, jest, "Coverage: 81%"
+	coverageRe = regexp.MustCompile(`(?i)coverage:?\s+(\d+(?:\.\d+)?)%`)
+	// pytestTotalRe итоговая строка pytest-cov: "TOTAL   120   12   90%"
+	pytestTotalRe = regexp.MustCompile(`(?m)^TOTAL\s.*?(\d+(?:\.\d+)?)%\s*$`)
+	todoRe        = regexp.MustCompile(`\b(TODO|FIXME)\b`)
+)
+
+// QualityGateItem результат одной проверки
+type QualityGateItem struct {
+	Name    string     `json:"name"`
+	Status  GateStatus `json:"status"`
+	Details string     `json:"details"`
+}
+
+// QualityGateReport сводка по состоянию проекта на момент завершения сессии
+type QualityGateReport struct {
+	Items     []QualityGateItem `json:"items"`
+	Overall   GateStatus        `json:"overall"`
+	NextSteps []string          `json:"next_steps,omitempty"`
+	Skipped   bool              `json:"skipped"` // /vibecoding_end --fast
+}
+
+// AutoWorkItem задача автономной работы и её итог
+type AutoWorkItem struct {
+	Task     string `json:"task"`
+	Finished bool   `json:"finished"`
+	Note     string `json:"note,omitempty"`
+}
+
+// RecordAutoWork запоминает итог задачи автономной работы для отчёта при завершении сессии
+func (s *VibeCodingSession) RecordAutoWork(task string, finished bool, note string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.autoWork = append(s.autoWork, AutoWorkItem{Task: task, Finished: finished, Note: note})
+}
+
+// UnfinishedAutoWork задачи автономной работы, которые не были доведены до конца
+func (s *VibeCodingSession) UnfinishedAutoWork() []AutoWorkItem {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var result []AutoWorkItem
+	for _, item := range s.autoWork {
+		if !item.Finished {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func (s *VibeCodingSession) generatedFilesSnapshot() map[string]string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	result := make(map[string]string, len(s.GeneratedFiles))
+	for name, content := range s.GeneratedFiles {
+		result[name] = content
+	}
+	return result
+}
+
+// autoWorkFinished определяет, доведена ли автономная задача до конца: ошибка шага
+// или исчерпанный лимит шагов означают, что работа осталась незавершённой
+func autoWorkFinished(response *VibeCodingResponse) (bool, string) {
+	if response.Status != "success" {
+		return false, response.Error
+	}
+	if executionLog, ok := response.Metadata["execution_log"].([]string); ok {
+		for _, entry := range executionLog {
+			if strings.Contains(entry, "Reached maximum number of steps") {
+				return false, "достигнут лимит шагов"
+			}
+			if strings.Contains(entry, " ERROR: ") {
+				return false, truncateText(entry, 200)
+			}
+		}
+	}
+	return true, ""
+}
+
+// severity порядок статусов для выбора худшего
+func (g GateStatus) severity() int {
+	switch g {
+	case GateFail:
+		return 2
+	case GateWarn:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Icon значок статуса для сообщений
+func (g GateStatus) Icon() string {
+	switch g {
+	case GateFail:
+		return "❌"
+	case GateWarn:
+		return "⚠️"
+	default:
+		return "✅"
+	}
+}
+
+func (r *QualityGateReport) add(item QualityGateItem) {
+	r.Items = append(r.Items, item)
+	if item.Status.severity() > r.Overall.severity() {
+		r.Overall = item.Status
+	}
+}
+
+// RunQualityGate выполняет быстрые проверки проекта: тесты, дополнительные проверки из
+// .vibecoding.yml (линтеры и т.п.), покрытие, если тесты его выводят, и TODO/FIXME в сгенерированных файлах
+func RunQualityGate(ctx context.Context, session *VibeCodingSession) *QualityGateReport {
+	ctx, cancel := context.WithTimeout(ctx, qualityGateTimeout)
+	defer cancel()
+
+	report := &QualityGateReport{Overall: GatePass}
+	log.Printf("🚦 Running quality gate for user %d", session.UserID)
+
+	var testOutput string
+	switch {
+	case session.TestCommand == "":
+		report.add(QualityGateItem{Name: "tests", Status: GateWarn, Details: "команда тестов не определена"})
+	case session.ContainerID == "":
+		report.add(QualityGateItem{Name: "tests", Status: GateWarn, Details: "окружение не настроено, тесты не запускались"})
+	default:
+		result, err := session.ExecuteCommand(ctx, session.TestCommand)
+		switch {
+		case err != nil:
+			report.add(QualityGateItem{Name: "tests", Status: GateFail, Details: "не удалось запустить: " + err.Error()})
+		case !result.Success:
+			testOutput = result.Output
+			details := fmt.Sprintf("код выхода %d: %s", result.ExitCode, truncateText(result.Output, 200))
+			if _, ok := ParseTestCounts(result.Output); ok {
+				details = FormatTestSummary(result.Output, result.ExitCode, false)
+			}
+			report.add(QualityGateItem{Name: "tests", Status: GateFail, Details: details})
+		default:
+			testOutput = result.Output
+			report.add(QualityGateItem{Name: "tests", Status: GatePass, Details: FormatTestSummary(result.Output, result.ExitCode, true)})
+		}
+	}
+
+	if session.ContainerID != "" {
+		for _, res := range session.RunValidationChecks(ctx, false) {
+			item := QualityGateItem{Name: res.Name, Status: GatePass, Details: "без ошибок"}
+			switch {
+			case res.Error != "":
+				item.Status, item.Details = GateFail, "не удалось запустить: "+res.Error
+			case !res.Success:
+				item.Status, item.Details = GateFail, fmt.Sprintf("код выхода %d: %s", res.ExitCode, truncateText(res.Output, 200))
+			}
+			report.add(item)
+		}
+	}
+
+	if coverage, ok := parseCoverage(testOutput); ok {
+		item := QualityGateItem{Name: "coverage", Status: GatePass, Details: fmt.Sprintf("%.1f%%", coverage)}
+		if coverage < lowCoverageThreshold {
+			item.Status = GateWarn
+			item.Details += fmt.Sprintf(" (ниже %.0f%%)", lowCoverageThreshold)
+		}
+		report.add(item)
+	}
+
+	if count, files := countTodoMarkers(session.generatedFilesSnapshot()); count > 0 {
+		report.add(QualityGateItem{Name: "TODO/FIXME", Status: GateWarn, Details: fmt.Sprintf("%d в сгенерированных файлах: %s", count, strings.Join(files, ", "))})
+	} else {
+		report.add(QualityGateItem{Name: "TODO/FIXME", Status: GatePass, Details: "нет в сгенерированных файлах"})
+	}
+
+	if run, ok := session.LastMatrixRun(); ok {
+		report.add(matrixGateItem(run))
+	}
+
+	if unfinished := session.UnfinishedAutoWork(); len(unfinished) > 0 {
+		report.add(QualityGateItem{Name: "autonomous work", Status: GateWarn, Details: fmt.Sprintf("незавершённых задач: %d", len(unfinished))})
+	}
+
+	log.Printf("🚦 Quality gate for user %d: %s (%d checks)", session.UserID, report.Overall, len(report.Items))
+	return report
+}
+
+// parseCoverage ищет процент покрытия в выводе тестов; для go test берётся минимальный по пакетам
+func parseCoverage(output string) (float64, bool) {
+	if m := pytestTotalRe.FindStringSubmatch(output); m != nil {
+		if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+			return v, true
+		}
+	}
+	found := false
+	lowest := 0.0
+	for _, m := range coverageRe.FindAllStringSubmatch(output, -1) {
+		v, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			continue
+		}
+		if !found || v < lowest {
+			lowest = v
+		}
+		found = true
+	}
+	return lowest, found
+}
+
+// countTodoMarkers считает TODO/FIXME и возвращает отсортированный список файлов, где они есть
+func countTodoMarkers(files map[string]string) (int, []string) {
+	total := 0
+	var names []string
+	for name, content := range files {
+		if n := len(todoRe.FindAllStringIndex(content, -1)); n > 0 {
+			total += n
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return total, names
+}
+
+// generateNextSteps просит LLM составить короткий приоритизированный список дальнейших шагов.
+// Если LLM недоступна, шаги строятся из непройденных проверок
+func (h *VibeCodingHandler) generateNextSteps(ctx context.Context, session *VibeCodingSession, report *QualityGateReport) []string {
+	unfinished := session.UnfinishedAutoWork()
+	fallback := fallbackNextSteps(report, unfinished)
+	if h.llmClient == nil {
+		return fallback
+	}
+
+	language := "unknown"
+	if session.Analysis != nil {
+		language = session.Analysis.Language
+	}
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("Project: %s (%s)\n\nQuality gate results:\n", session.ProjectName, language))
+	for _, item := range report.Items {
+		prompt.WriteString(fmt.Sprintf("- [%s] %s: %s\n", item.Status, item.Name, item.Details))
+	}
+	if len(unfinished) > 0 {
+		prompt.WriteString("\nUnfinished autonomous work tasks:\n")
+		for _, item := range unfinished {
+			prompt.WriteString(fmt.Sprintf("- %s (%s)\n", item.Task, item.Note))
+		}
+	}
+	prompt.WriteString(fmt.Sprintf("\nWrite at most %d next steps for the developer, most important first. "+
+		"One short actionable step per line, no numbering, no extra text. Answer in Russian.", maxNextSteps))
+
+	messages := []llm.Message{
+		{Role: "system", Content: "You are a senior engineer reviewing the state of a project at the end of a coding session."},
+		{Role: "user", Content: prompt.String()},
+	}
+	resp, err := llm.GenerateWithOptions(ctx, h.llmClient, messages, llm.OptionsForTask(llm.TaskGeneration))
+	if err != nil {
+		log.Printf("⚠️ Failed to generate next steps: %v", err)
+		return fallback
+	}
+	if steps := parseNextSteps(resp.Content); len(steps) > 0 {
+		return steps
+	}
+	return fallback
+}
+
+var stepPrefixRe = regexp.MustCompile(`^(\d+[.)]|[-*•])\s*`)
+
+// parseNextSteps разбирает ответ LLM: по шагу на строку, нумерация и маркеры убираются
+func parseNextSteps(content string) []string {
+	var steps []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(stepPrefixRe.ReplaceAllString(strings.TrimSpace(line), ""))
+		if line == "" {
+			continue
+		}
+		steps = append(steps, line)
+		if len(steps) == maxNextSteps {
+			break
+		}
+	}
+	return steps
+}
+
+// fallbackNextSteps шаги без LLM: сначала проваленные проверки, затем предупреждения и незавершённые задачи
+func fallbackNextSteps(report *QualityGateReport, unfinished []AutoWorkItem) []string {
+	var steps []string
+	for _, status := range []GateStatus{GateFail, GateWarn} {
+		for _, item := range report.Items {
+			if item.Status == status && item.Name != "autonomous work" {
+				steps = append(steps, fmt.Sprintf("Разобраться с проверкой %s: %s", item.Name, truncateText(item.Details, 100)))
+			}
+		}
+	}
+	for _, item := range unfinished {
+		steps = append(steps, "Завершить задачу: "+truncateText(item.Task, 100))
+	}
+	if len(steps) > maxNextSteps {
+		steps = steps[:maxNextSteps]
+	}
+	return steps
+}
+
+// FormatQualityGateSummary краткая сводка для подписи к итоговому архиву
+func FormatQualityGateSummary(report *QualityGateReport) string {
+	if report == nil || report.Skipped {
+		return "🚦 Проверка качества пропущена (--fast)"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🚦 Проверка качества: %s %s\n", report.Overall.Icon(), strings.ToUpper(string(report.Overall))))
+	for _, item := range report.Items {
+		line := item.Status.Icon() + " " + item.Name
+		if item.Status != GatePass {
+			line += ": " + truncateText(item.Details, 80)
+		}
+		sb.WriteString(line + "\n")
+	}
+	if len(report.NextSteps) > 0 {
+		sb.WriteString("\n📋 Следующие шаги:\n")
+		for i, step := range report.NextSteps {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, truncateText(step, 120)))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// FormatSessionReport содержимое SESSION_REPORT.md в итоговом архиве
+func FormatSessionReport(session *VibeCodingSession, report *QualityGateReport) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Session Report: %s\n\n", session.ProjectName))
+	if report == nil || report.Skipped {
+		sb.WriteString("Quality gate was skipped (`/vibecoding_end --fast`).\n")
+		writeMatrixReport(&sb, session)
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("## Quality gate: %s\n\n", strings.ToUpper(string(report.Overall))))
+	sb.WriteString("| Check | Status | Details |\n|---|---|---|\n")
+	for _, item := range report.Items {
+		details := strings.ReplaceAll(strings.ReplaceAll(item.Details, "\n", " "), "|", "\\|")
+		sb.WriteString(fmt.Sprintf("| %s | %s %s | %s |\n", item.Name, item.Status.Icon(), item.Status, details))
+	}
+	writeMatrixReport(&sb, session)
+
+	if unfinished := session.UnfinishedAutoWork(); len(unfinished) > 0 {
+		sb.WriteString("\n## Unfinished autonomous work\n\n")
+		for _, item := range unfinished {
+			sb.WriteString(fmt.Sprintf("- %s", item.Task))
+			if item.Note != "" {
+				sb.WriteString(fmt.Sprintf(" — %s", item.Note))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(report.NextSteps) > 0 {
+		sb.WriteString("\n## Next steps\n\n")
+		for i, step := range report.NextSteps {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, step))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,112 @@
+package vibecoding
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseCoverage(t *testing.T) {
+	cases := []struct {
+		output string
+		want   float64
+		ok     bool
+	}{
+		{"ok  \tpkg/a\t0.1s\tcoverage: 81.5% of statements\nok  \tpkg/b\t0.1s\tcoverage: 42.0% of statements", 42, true},
+		{"Name    Stmts   Miss  Cover\nmain.py    10      1    90%\nTOTAL      10      1    90%\n", 90, true},
+		{"All tests passed", 0, false},
+	}
+	for _, c := range cases {
+		got, ok := parseCoverage(c.output)
+		if ok != c.ok || got != c.want {
+			t.Errorf("parseCoverage(%q) = %v, %v; want %v, %v", c.output, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestRunQualityGate_WithoutEnvironment(t *testing.T) {
+	session := &VibeCodingSession{
+		ProjectName:    "demo",
+		Files:          map[string]string{"main.py": "# TODO: original todo is not counted"},
+		GeneratedFiles: map[string]string{"util.py": "# TODO: handle errors\n# FIXME: typo", "ok.py": "x = 1"},
+	}
+	session.RecordAutoWork("add CLI", false, "достигнут лимит шагов")
+	session.RecordAutoWork("fix typo", true, "")
+
+	report := RunQualityGate(context.Background(), session)
+	if report.Overall != GateWarn {
+		t.Fatalf("expected overall warn, got %s: %+v", report.Overall, report.Items)
+	}
+	items := make(map[string]QualityGateItem)
+	for _, item := range report.Items {
+		items[item.Name] = item
+	}
+	if items["tests"].Status != GateWarn {
+		t.Errorf("missing test command must be a warning: %+v", items["tests"])
+	}
+	if todo := items["TODO/FIXME"]; todo.Status != GateWarn || !strings.HasPrefix(todo.Details, "2 ") || !strings.Contains(todo.Details, "util.py") {
+		t.Errorf("unexpected TODO item: %+v", todo)
+	}
+	if items["autonomous work"].Status != GateWarn {
+		t.Errorf("unfinished autonomous task must be reported: %+v", report.Items)
+	}
+
+	// Без LLM шаги строятся из предупреждений и незавершённых задач
+	h := &VibeCodingHandler{}
+	steps := h.generateNextSteps(context.Background(), session, report)
+	if len(steps) == 0 || !strings.Contains(steps[len(steps)-1], "add CLI") {
+		t.Errorf("unexpected fallback next steps: %v", steps)
+	}
+}
+
+func TestGenerateNextSteps_ParsesLLMList(t *testing.T) {
+	llmClient := NewMockLLMClient()
+	llmClient.SetShouldError(true)
+	h := &VibeCodingHandler{llmClient: llmClient}
+	report := &QualityGateReport{Overall: GateFail, Items: []QualityGateItem{{Name: "tests", Status: GateFail, Details: "код выхода 1"}}}
+	steps := h.generateNextSteps(context.Background(), &VibeCodingSession{ProjectName: "demo"}, report)
+	if len(steps) != 1 || !strings.Contains(steps[0], "tests") {
+		t.Errorf("LLM error must fall back to gate-based steps, got %v", steps)
+	}
+
+	got := parseNextSteps("1. Починить тесты\n2) Убрать TODO\n- Добавить README\n\n* a\n* b\n* c")
+	want := []string{"Починить тесты", "Убрать TODO", "Добавить README", "a", "b"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("parseNextSteps = %v, want %v", got, want)
+	}
+}
+
+func TestAutoWorkFinished(t *testing.T) {
+	finished, _ := autoWorkFinished(&VibeCodingResponse{Status: "success", Metadata: map[string]interface{}{"execution_log": []string{"Step 1: ok"}}})
+	if !finished {
+		t.Error("successful run must be finished")
+	}
+	finished, note := autoWorkFinished(&VibeCodingResponse{Status: "success", Metadata: map[string]interface{}{"execution_log": []string{"Step 1: ok", "⚠️ Reached maximum number of steps"}}})
+	if finished || note == "" {
+		t.Error("run that hit the step limit must be unfinished")
+	}
+	if finished, _ := autoWorkFinished(&VibeCodingResponse{Status: "error", Error: "boom"}); finished {
+		t.Error("failed run must be unfinished")
+	}
+}
+
+func TestFormatQualityGateSummary(t *testing.T) {
+	if !strings.Contains(FormatQualityGateSummary(&QualityGateReport{Skipped: true}), "--fast") {
+		t.Error("skipped gate must mention --fast")
+	}
+	report := &QualityGateReport{
+		Overall:   GateFail,
+		Items:     []QualityGateItem{{Name: "tests", Status: GatePass}, {Name: "lint", Status: GateFail, Details: "код выхода 1"}},
+		NextSteps: []string{"Исправить линтер"},
+	}
+	summary := FormatQualityGateSummary(report)
+	for _, want := range []string{"FAIL", "✅ tests", "❌ lint: код выхода 1", "1. Исправить линтер"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary %q must contain %q", summary, want)
+		}
+	}
+	sessionReport := FormatSessionReport(&VibeCodingSession{ProjectName: "demo"}, report)
+	if !strings.Contains(sessionReport, "## Quality gate: FAIL") || !strings.Contains(sessionReport, "## Next steps") {
+		t.Errorf("unexpected session report:\n%s", sessionReport)
+	}
+}
@@ -0,0 +1,69 @@
+package vibecoding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// RenameFileToolHandler реализация vibe_rename_file, общая для stdio и HTTP MCP серверов
+func RenameFileToolHandler(sm *SessionManager) mcp.ToolHandlerFor[map[string]interface{}, any] {
+	return func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]interface{}]) (*mcp.CallToolResultFor[any], error) {
+		userIDArg, ok := params.Arguments["user_id"]
+		if !ok {
+			return toolError("❌ user_id parameter is required"), nil
+		}
+		userID, err := ParseUserID(userIDArg)
+		if err != nil {
+			return toolError("❌ Invalid user_id format"), nil
+		}
+		oldPath, ok := params.Arguments["old_path"].(string)
+		if !ok || oldPath == "" {
+			return toolError("❌ old_path parameter is required and must be a string"), nil
+		}
+		newPath, ok := params.Arguments["new_path"].(string)
+		if !ok || newPath == "" {
+			return toolError("❌ new_path parameter is required and must be a string"), nil
+		}
+
+		log.Printf("🔀 MCP Server: Renaming file %s -> %s for user %d", oldPath, newPath, userID)
+
+		vibeCodingSession := sm.GetSession(userID)
+		if vibeCodingSession == nil {
+			return toolError("❌ No VibeCoding session found for user"), nil
+		}
+
+		generated, err := vibeCodingSession.RenameFile(ctx, oldPath, newPath)
+		if err != nil {
+			if errors.Is(err, ErrFileExists) {
+				return toolError(fmt.Sprintf("❌ Cannot rename %s: %s already exists", oldPath, newPath)), nil
+			}
+			return toolError(fmt.Sprintf("❌ Failed to rename file: %v", err)), nil
+		}
+
+		return &mcp.CallToolResultFor[any]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("✅ Renamed %s -> %s", oldPath, newPath)},
+			},
+			Meta: map[string]interface{}{
+				"user_id":   userID,
+				"old_path":  oldPath,
+				"new_path":  newPath,
+				"generated": generated,
+				"success":   true,
+			},
+		}, nil
+	}
+}
+
+func toolError(text string) *mcp.CallToolResultFor[any] {
+	return &mcp.CallToolResultFor[any]{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: text},
+		},
+	}
+}
@@ -0,0 +1,106 @@
+package vibecoding
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"ai-chatter/internal/codevalidation"
+)
+
+// commandRecordingDocker запоминает команды, выполненные в контейнере
+type commandRecordingDocker struct {
+	codevalidation.DockerManager
+	commands []string
+}
+
+func (d *commandRecordingDocker) ExecuteValidation(ctx context.Context, containerID string, analysis *codevalidation.CodeAnalysisResult) (*codevalidation.ValidationResult, error) {
+	d.commands = append(d.commands, analysis.Commands...)
+	return d.DockerManager.ExecuteValidation(ctx, containerID, analysis)
+}
+
+func TestRenameFile_PreservesKindAndMovesInContainer(t *testing.T) {
+	docker := &commandRecordingDocker{DockerManager: codevalidation.NewMockDockerClient()}
+	session := protectedSession()
+	session.Docker = NewDockerAdapter(docker)
+	session.ContainerID = "container-1"
+	session.GeneratedFiles["helper.py"] = "def helper(): pass"
+	session.Context = &ProjectContextLLM{Files: map[string]LLMFileContext{"helper.py": {Path: "helper.py", Summary: "helper"}}}
+
+	generated, err := session.RenameFile(context.Background(), "helper.py", "utils/helper's.py")
+	if err != nil {
+		t.Fatalf("RenameFile: %v", err)
+	}
+	if !generated || session.GeneratedFiles["utils/helper's.py"] != "def helper(): pass" {
+		t.Fatalf("generated file must stay generated: files=%v generated=%v", session.Files, session.GeneratedFiles)
+	}
+	if _, exists := session.GeneratedFiles["helper.py"]; exists {
+		t.Error("old path must be removed")
+	}
+	if len(docker.commands) != 1 || !strings.Contains(docker.commands[0], `mv '/workspace/helper.py' '/workspace/utils/helper'\''s.py'`) {
+		t.Errorf("unexpected container command: %v", docker.commands)
+	}
+	if ctxFile, ok := session.Context.Files["utils/helper's.py"]; !ok || ctxFile.Path != "utils/helper's.py" || ctxFile.Summary != "helper" {
+		t.Errorf("project context must follow the rename: %+v", session.Context.Files)
+	}
+
+	if generated, err := session.RenameFile(context.Background(), "main.py", "app.py"); err != nil || generated {
+		t.Fatalf("original file must stay original: generated=%v err=%v", generated, err)
+	}
+	if session.Files["app.py"] == "" {
+		t.Error("original file must be renamed in Files")
+	}
+}
+
+func TestRenameFile_Errors(t *testing.T) {
+	session := protectedSession()
+	if _, err := session.RenameFile(context.Background(), "main.py", "tests/test_main.py"); !errors.Is(err, ErrFileExists) {
+		t.Errorf("existing new_path must fail with ErrFileExists, got %v", err)
+	}
+	if _, err := session.RenameFile(context.Background(), "missing.py", "other.py"); err == nil {
+		t.Error("missing old_path must fail")
+	}
+	if _, err := session.RenameFile(context.Background(), "main.py", "../outside.py"); err == nil {
+		t.Error("new_path outside the project must fail")
+	}
+	if session.Files["main.py"] == "" {
+		t.Error("failed rename must keep the file")
+	}
+}
+
+func TestMCPRenameFile(t *testing.T) {
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+	session, err := sm.CreateSession(1, 1, "calc", map[string]string{"main.py": "print(1)", "util.py": "x = 1"}, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	session.Docker = NewDockerAdapter(codevalidation.NewMockDockerClient())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := mcp.NewServer(&mcp.Implementation{Name: "vibe-test", Version: "v0.0.1"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "vibe_rename_file"}, RenameFileToolHandler(sm))
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	go func() { _ = server.Run(ctx, serverTransport) }()
+
+	clientSession, err := mcp.NewClient(&mcp.Implementation{Name: "client", Version: "v0.0.1"}, nil).Connect(ctx, clientTransport)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer clientSession.Close()
+	client := &VibeCodingMCPClient{session: clientSession}
+
+	if result := client.RenameFile(ctx, 1, "main.py", "util.py"); result.Success || !strings.Contains(result.Message, "already exists") {
+		t.Fatalf("rename onto an existing file must fail, got %+v", result)
+	}
+	if result := client.RenameFile(ctx, 1, "main.py", "src/main.py"); !result.Success {
+		t.Fatalf("rename failed: %+v", result)
+	}
+	if _, err := session.ReadFile(ctx, "src/main.py"); err != nil {
+		t.Errorf("renamed file must be readable: %v", err)
+	}
+}
@@ -0,0 +1,353 @@
+package vibecoding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-chatter/internal/codevalidation"
+	"ai-chatter/internal/llm"
+)
+
+// runRecordingVersion версия формата файла записи автономного запуска
+const runRecordingVersion = 1
+
+// Виды решений оркестратора в автономной работе
+const (
+	DecisionToolCall = "tool_call" // вызов инструмента
+	DecisionComplete = "complete"  // модель ответила без вызовов — работа завершена
+	DecisionError    = "error"     // запрос к LLM завершился ошибкой
+	DecisionMaxSteps = "max_steps" // исчерпан лимит шагов
+)
+
+// RunRecording запись автономного запуска: запросы и ответы LLM, вызовы инструментов и решения
+// оркестратора. Воспроизводится командой cmd/vibecoding-replay без новых запросов к LLM
+type RunRecording struct {
+	Version    int                 `json:"version"`
+	RecordedAt time.Time           `json:"recorded_at"`
+	Request    RecordedRequest     `json:"request"`
+	LLMCalls   []RecordedLLMCall   `json:"llm_calls"`
+	ToolCalls  []RecordedToolCall  `json:"tool_calls"`
+	Decisions  []RunDecision       `json:"decisions"`
+	Response   *VibeCodingResponse `json:"response,omitempty"`
+
+	mu sync.Mutex
+}
+
+// RecordedRequest сериализуемая часть VibeCodingRequest: опции с контекстом проекта
+// хранятся типизированными полями, Progress не записывается
+type RecordedRequest struct {
+	Action         string            `json:"action"`
+	Query          string            `json:"query"`
+	Context        VibeCodingContext `json:"context"`
+	UserID         int64             `json:"user_id"`
+	ProjectContext *ProjectContext   `json:"project_context,omitempty"`
+}
+
+// RecordedLLMCall один запрос к LLM и полученный ответ (или ошибка)
+type RecordedLLMCall struct {
+	Step     int           `json:"step"`
+	At       time.Time     `json:"at"`
+	Messages []llm.Message `json:"messages"`
+	Response llm.Response  `json:"response"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// RecordedToolCall вызов инструмента и его результат
+type RecordedToolCall struct {
+	Step      int                    `json:"step"`
+	At        time.Time              `json:"at"`
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Result    VibeCodingMCPResult    `json:"result"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// RunDecision решение оркестратора на шаге: какой инструмент вызвать или чем закончить работу
+type RunDecision struct {
+	Step      int                    `json:"step"`
+	Kind      string                 `json:"kind"`
+	Tool      string                 `json:"tool,omitempty"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+}
+
+func (d RunDecision) String() string {
+	if d.Kind != DecisionToolCall {
+		return fmt.Sprintf("step %d: %s", d.Step, d.Kind)
+	}
+	args, _ := json.Marshal(d.Arguments)
+	return fmt.Sprintf("step %d: %s %s", d.Step, d.Tool, args)
+}
+
+func newRunRecording(request VibeCodingRequest, userID int64, now time.Time) *RunRecording {
+	rec := &RunRecording{
+		Version:    runRecordingVersion,
+		RecordedAt: now.UTC(),
+		Request: RecordedRequest{
+			Action:  request.Action,
+			Query:   request.Query,
+			Context: request.Context,
+			UserID:  userID,
+		},
+	}
+	if pc, ok := request.Options["project_context"].(*ProjectContext); ok {
+		rec.Request.ProjectContext = pc
+	}
+	return rec
+}
+
+// VibeCodingRequest восстанавливает запрос автономной работы из записи
+func (r RecordedRequest) VibeCodingRequest() VibeCodingRequest {
+	options := map[string]interface{}{"user_id": r.UserID}
+	if r.ProjectContext != nil {
+		options["project_context"] = r.ProjectContext
+	}
+	return VibeCodingRequest{Action: r.Action, Query: r.Query, Context: r.Context, Options: options}
+}
+
+// Методы record* допускают nil-получателя: без записи цикл работает как обычно
+
+func (r *RunRecording) recordLLMCall(step int, at time.Time, messages []llm.Message, resp llm.Response, err error) {
+	if r == nil {
+		return
+	}
+	call := RecordedLLMCall{Step: step, At: at.UTC(), Messages: append([]llm.Message(nil), messages...), Response: resp}
+	if err != nil {
+		call.Error = err.Error()
+	}
+	r.mu.Lock()
+	r.LLMCalls = append(r.LLMCalls, call)
+	r.mu.Unlock()
+}
+
+func (r *RunRecording) recordToolCall(step int, at time.Time, call llm.FunctionCall, result VibeCodingMCPResult, err error) {
+	if r == nil {
+		return
+	}
+	tc := RecordedToolCall{Step: step, At: at.UTC(), Tool: call.Name, Arguments: call.Arguments, Result: result}
+	if err != nil {
+		tc.Error = err.Error()
+	}
+	r.mu.Lock()
+	r.ToolCalls = append(r.ToolCalls, tc)
+	r.mu.Unlock()
+}
+
+func (r *RunRecording) recordDecision(d RunDecision) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.Decisions = append(r.Decisions, d)
+	r.mu.Unlock()
+}
+
+// Marshal сериализует запись: ключи map сортируются encoding/json, поэтому одинаковые запуски
+// дают одинаковые байты
+func (r *RunRecording) Marshal() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// SaveTo сохраняет запись в каталог dir и возвращает путь к файлу
+func (r *RunRecording) SaveTo(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	data, err := r.Marshal()
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("autowork-%d-%s.json", r.Request.UserID, r.RecordedAt.Format("20060102-150405.000"))
+	path := filepath.Join(dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return "", err
+	}
+	return path, os.Rename(tmp, path)
+}
+
+// LoadRunRecording читает запись автономного запуска из файла
+func LoadRunRecording(path string) (*RunRecording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rec RunRecording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("invalid recording %s: %w", path, err)
+	}
+	if rec.Version != runRecordingVersion {
+		return nil, fmt.Errorf("unsupported recording version %d (expected %d)", rec.Version, runRecordingVersion)
+	}
+	return &rec, nil
+}
+
+// ReplayReport результат воспроизведения: новая запись и первое расхождение решений
+type ReplayReport struct {
+	Recording *RunRecording
+	Matched   bool
+	// Divergence описание первого расхождения решений; пусто, если последовательности совпали
+	Divergence string
+	// PromptChanges шаги, на которых сообщения к LLM отличаются от записанных
+	PromptChanges []int
+}
+
+// ReplayAutonomousRun заново выполняет оркестрацию записанного запуска: LLM отвечает записанными
+// ответами, инструменты выполняются через tools (nil — записанные результаты). Часы фиксированы
+// на времени записи, поэтому повторные воспроизведения дают одинаковый результат
+func ReplayAutonomousRun(ctx context.Context, recorded *RunRecording, tools VibeToolBackend) *ReplayReport {
+	if tools == nil {
+		tools = newRecordedToolBackend(recorded.ToolCalls)
+	}
+	client := NewVibeCodingLLMClient(&replayLLMClient{calls: recorded.LLMCalls})
+	client.SetClock(func() time.Time { return recorded.RecordedAt })
+
+	request := recorded.Request.VibeCodingRequest()
+	rec := newRunRecording(request, recorded.Request.UserID, recorded.RecordedAt)
+	rec.Response = client.runAutonomousWork(ctx, request, recorded.Request.UserID, client.llmClient, tools, rec)
+
+	report := &ReplayReport{Recording: rec}
+	report.Divergence = compareDecisions(recorded.Decisions, rec.Decisions)
+	report.Matched = report.Divergence == ""
+	for i, call := range rec.LLMCalls {
+		if i < len(recorded.LLMCalls) && !sameMessages(call.Messages, recorded.LLMCalls[i].Messages) {
+			report.PromptChanges = append(report.PromptChanges, call.Step)
+		}
+	}
+	return report
+}
+
+// compareDecisions описание первого расхождения двух последовательностей решений
+func compareDecisions(want, got []RunDecision) string {
+	for i := 0; i < len(want) || i < len(got); i++ {
+		switch {
+		case i >= len(got):
+			return fmt.Sprintf("decision %d missing: expected %s", i+1, want[i])
+		case i >= len(want):
+			return fmt.Sprintf("unexpected decision %d: %s", i+1, got[i])
+		case !sameDecision(want[i], got[i]):
+			return fmt.Sprintf("decision %d differs: expected %s, got %s", i+1, want[i], got[i])
+		}
+	}
+	return ""
+}
+
+// sameDecision сравнивает решения через JSON, чтобы числа из записи (float64) совпадали с живыми
+func sameDecision(a, b RunDecision) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+func sameMessages(a, b []llm.Message) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// replayLLMClient отдаёт записанные ответы LLM по порядку
+type replayLLMClient struct {
+	llm.NoStreaming
+	llm.LocalTokenCounter
+	calls []RecordedLLMCall
+	next  int
+}
+
+func (c *replayLLMClient) Generate(ctx context.Context, messages []llm.Message) (llm.Response, error) {
+	return c.GenerateWithTools(ctx, messages, nil)
+}
+
+func (c *replayLLMClient) GenerateWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (llm.Response, error) {
+	if c.next >= len(c.calls) {
+		return llm.Response{}, fmt.Errorf("replay: no recorded LLM response for call %d", c.next+1)
+	}
+	call := c.calls[c.next]
+	c.next++
+	if call.Error != "" {
+		return llm.Response{}, fmt.Errorf("%s", call.Error)
+	}
+	return call.Response, nil
+}
+
+// recordedToolBackend подменяет инструменты записанными результатами: вызов получает результат
+// очередной неиспользованной записи того же инструмента
+type recordedToolBackend struct {
+	calls []RecordedToolCall
+	used  []bool
+}
+
+func newRecordedToolBackend(calls []RecordedToolCall) *recordedToolBackend {
+	return &recordedToolBackend{calls: calls, used: make([]bool, len(calls))}
+}
+
+func (b *recordedToolBackend) result(tool string) VibeCodingMCPResult {
+	for i, call := range b.calls {
+		if b.used[i] || call.Tool != tool {
+			continue
+		}
+		b.used[i] = true
+		if call.Error != "" {
+			return VibeCodingMCPResult{Success: false, Message: call.Error}
+		}
+		return call.Result
+	}
+	return VibeCodingMCPResult{Success: false, Message: fmt.Sprintf("replay: no recorded result for %s", tool)}
+}
+
+func (b *recordedToolBackend) ListFiles(ctx context.Context, userID int64) VibeCodingMCPResult {
+	return b.result("vibe_list_files")
+}
+
+func (b *recordedToolBackend) ReadFile(ctx context.Context, userID int64, filename string) VibeCodingMCPResult {
+	return b.result("vibe_read_file")
+}
+
+func (b *recordedToolBackend) WriteFile(ctx context.Context, userID int64, filename, content string, generated, overwriteOriginal bool) VibeCodingMCPResult {
+	return b.result("vibe_write_file")
+}
+
+func (b *recordedToolBackend) RenameFile(ctx context.Context, userID int64, oldPath, newPath string) VibeCodingMCPResult {
+	return b.result("vibe_rename_file")
+}
+
+func (b *recordedToolBackend) ExecuteCommandWithOptions(ctx context.Context, userID int64, command string, opts codevalidation.ExecOptions) VibeCodingMCPResult {
+	return b.result("vibe_execute_command")
+}
+
+func (b *recordedToolBackend) ValidateCode(ctx context.Context, userID int64, filename string) VibeCodingMCPResult {
+	return b.result("vibe_validate_code")
+}
+
+func (b *recordedToolBackend) RunTests(ctx context.Context, userID int64, testFile string) VibeCodingMCPResult {
+	return b.result("vibe_run_tests")
+}
+
+func (b *recordedToolBackend) GetSessionInfo(ctx context.Context, userID int64) VibeCodingMCPResult {
+	return b.result("vibe_get_session_info")
+}
+
+// FormatReplayReport текстовый отчёт о воспроизведении для консоли
+func FormatReplayReport(recorded *RunRecording, report *ReplayReport) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Recorded: %s, user %d, task: %s\n", recorded.RecordedAt.Format(time.RFC3339), recorded.Request.UserID, recorded.Request.Query)
+	fmt.Fprintf(&sb, "Decisions: recorded %d, replayed %d\n", len(recorded.Decisions), len(report.Recording.Decisions))
+	for _, d := range report.Recording.Decisions {
+		fmt.Fprintf(&sb, "  %s\n", d)
+	}
+	if len(report.PromptChanges) > 0 {
+		fmt.Fprintf(&sb, "Prompt changed at steps: %v\n", report.PromptChanges)
+	}
+	if report.Matched {
+		sb.WriteString("OK: decision sequence matches the recording")
+	} else {
+		sb.WriteString("MISMATCH: " + report.Divergence)
+	}
+	return sb.String()
+}
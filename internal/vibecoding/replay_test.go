@@ -0,0 +1,119 @@
+package vibecoding
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-chatter/internal/llm"
+)
+
+func recordTestRun(t *testing.T) (*RunRecording, string) {
+	t.Helper()
+	fake := &scriptedToolLLM{replies: []llm.Response{
+		{Content: "Reading the entry point", ToolCalls: []llm.ToolCall{
+			{ID: "call_1", Type: "function", Function: llm.FunctionCall{Name: "vibe_read_file", Arguments: map[string]interface{}{"filename": "main.go"}}},
+		}},
+		{ToolCalls: []llm.ToolCall{
+			{ID: "call_2", Type: "function", Function: llm.FunctionCall{Name: "vibe_execute_command", Arguments: map[string]interface{}{"command": "go test ./...", "timeout": float64(30)}}},
+		}},
+		{Content: "Done"},
+	}}
+	dir := t.TempDir()
+	client := NewVibeCodingLLMClient(fake)
+	client.SetMCPClient(NewVibeCodingMCPClient())
+	client.SetRecordDir(dir)
+	client.SetClock(func() time.Time { return time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC) })
+
+	if _, err := client.ProcessRequest(context.Background(), VibeCodingRequest{
+		Action:  "autonomous_work",
+		Query:   "run the tests",
+		Context: VibeCodingContext{ProjectName: "demo", Language: "Go"},
+		Options: map[string]interface{}{"user_id": int64(42), "project_context": &ProjectContext{ProjectName: "demo", Language: "Go", TotalFiles: 1}},
+	}); err != nil {
+		t.Fatalf("ProcessRequest: %v", err)
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(dir, "autowork-42-*.json"))
+	if len(paths) != 1 {
+		t.Fatalf("expected one recording, got %v", paths)
+	}
+	rec, err := LoadRunRecording(paths[0])
+	if err != nil {
+		t.Fatalf("LoadRunRecording: %v", err)
+	}
+	return rec, paths[0]
+}
+
+func TestRunRecording_RecordsRun(t *testing.T) {
+	rec, _ := recordTestRun(t)
+	if len(rec.LLMCalls) != 3 || len(rec.ToolCalls) != 2 {
+		t.Fatalf("llm calls = %d, tool calls = %d", len(rec.LLMCalls), len(rec.ToolCalls))
+	}
+	kinds := make([]string, 0, len(rec.Decisions))
+	for _, d := range rec.Decisions {
+		kinds = append(kinds, d.Kind+":"+d.Tool)
+	}
+	if got := strings.Join(kinds, ","); got != "tool_call:vibe_read_file,tool_call:vibe_execute_command,complete:" {
+		t.Errorf("decisions = %s", got)
+	}
+	if rec.Request.UserID != 42 || rec.Request.ProjectContext == nil || rec.Request.ProjectContext.TotalFiles != 1 {
+		t.Errorf("request = %+v", rec.Request)
+	}
+	if rec.Response == nil || rec.Response.Response != "Done" {
+		t.Errorf("response = %+v", rec.Response)
+	}
+	if second := rec.LLMCalls[1].Messages; len(second) != 4 || second[3].Role != "tool" {
+		t.Errorf("second request must include the tool result: %+v", second)
+	}
+}
+
+func TestReplayAutonomousRun_MatchesAndIsStable(t *testing.T) {
+	recorded, path := recordTestRun(t)
+
+	first := ReplayAutonomousRun(context.Background(), recorded, nil)
+	if !first.Matched || len(first.PromptChanges) != 0 {
+		t.Fatalf("replay diverged: %s, prompt changes %v", first.Divergence, first.PromptChanges)
+	}
+	second := ReplayAutonomousRun(context.Background(), recorded, nil)
+	a, _ := first.Recording.Marshal()
+	b, _ := second.Recording.Marshal()
+	if string(a) != string(b) {
+		t.Error("replays of the same recording must serialize identically")
+	}
+
+	// Запись → файл → запись даёт те же байты
+	reloaded, err := LoadRunRecording(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig, _ := os.ReadFile(path)
+	again, _ := reloaded.Marshal()
+	if string(orig) != string(again) {
+		t.Error("recording must survive a load/marshal round trip unchanged")
+	}
+}
+
+func TestReplayAutonomousRun_DetectsDivergence(t *testing.T) {
+	recorded, _ := recordTestRun(t)
+	recorded.Decisions[1].Tool = "vibe_run_tests"
+
+	report := ReplayAutonomousRun(context.Background(), recorded, nil)
+	if report.Matched || !strings.Contains(report.Divergence, "decision 2 differs") {
+		t.Errorf("expected divergence at decision 2, got %q", report.Divergence)
+	}
+	if out := FormatReplayReport(recorded, report); !strings.Contains(out, "MISMATCH") {
+		t.Errorf("report = %s", out)
+	}
+
+	// Ответов LLM меньше, чем решений: оркестратор упирается в ошибку
+	recorded, _ = recordTestRun(t)
+	recorded.LLMCalls = recorded.LLMCalls[:2]
+	report = ReplayAutonomousRun(context.Background(), recorded, nil)
+	if report.Matched || !strings.Contains(report.Divergence, "error") {
+		t.Errorf("expected error decision mismatch, got %q", report.Divergence)
+	}
+}
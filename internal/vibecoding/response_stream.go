@@ -0,0 +1,121 @@
+package vibecoding
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+
+	"ai-chatter/internal/llm"
+	"ai-chatter/internal/telegram/streamedit"
+)
+
+// responseStreamInterval как часто превью ответа обновляется в Telegram: не чаще лимита на редактирования
+const responseStreamInterval = streamedit.MinEditInterval
+
+// responseStream накапливает потоковый ответ LLM для превью; каждая попытка протокола
+// начинает текст заново
+type responseStream struct {
+	mu      sync.Mutex
+	buf     strings.Builder
+	once    sync.Once
+	started chan struct{}
+}
+
+func newResponseStream() *responseStream {
+	return &responseStream{started: make(chan struct{})}
+}
+
+func (s *responseStream) write(delta string) {
+	s.mu.Lock()
+	s.buf.WriteString(delta)
+	s.mu.Unlock()
+	s.once.Do(func() { close(s.started) })
+}
+
+func (s *responseStream) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.Reset()
+}
+
+// preview уже полученная часть ответа: у JSON протокола — поле response, у текстового ответа — весь текст
+func (s *responseStream) preview() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return streamedit.PreviewText(s.buf.String(), "response")
+}
+
+type responseStreamKey struct{}
+
+// withResponseStream включает потоковую генерацию для LLM вызовов ответа в ctx
+func withResponseStream(ctx context.Context, s *responseStream) context.Context {
+	return context.WithValue(ctx, responseStreamKey{}, s)
+}
+
+func responseStreamFrom(ctx context.Context) *responseStream {
+	s, _ := ctx.Value(responseStreamKey{}).(*responseStream)
+	return s
+}
+
+// generateStreamed генерирует ответ потоково, если в ctx есть превью (withResponseStream);
+// без превью или у провайдера без стриминга — обычный Generate
+func generateStreamed(ctx context.Context, client llm.Client, messages []llm.Message, opts llm.GenerateOptions) (llm.Response, error) {
+	stream := responseStreamFrom(ctx)
+	if stream == nil {
+		return llm.GenerateWithOptions(ctx, client, messages, opts)
+	}
+	stream.reset()
+	resp, err := client.GenerateStream(llm.WithOptions(ctx, opts), messages, stream.write)
+	if errors.Is(err, llm.ErrStreamingNotSupported) {
+		return llm.GenerateWithOptions(ctx, client, messages, opts)
+	}
+	return resp, err
+}
+
+// streamAnswer выполняет generate, показывая поступающий ответ в сообщении чата, которое
+// редактируется раз в responseStreamInterval. previewID — сообщение превью (0, если ответ
+// пришёл без стриминга и превью не создавалось)
+func (h *VibeCodingHandler) streamAnswer(ctx context.Context, chatID int64, generate func(context.Context) (string, error)) (answer string, previewID int, err error) {
+	stream := newResponseStream()
+	done := make(chan struct{})
+	result := make(chan int, 1)
+	go func() {
+		result <- h.runResponsePreview(chatID, stream, done)
+	}()
+	answer, err = generate(withResponseStream(ctx, stream))
+	close(done)
+	return answer, <-result, err
+}
+
+// runResponsePreview ведёт превью ответа (streamedit) до закрытия done
+func (h *VibeCodingHandler) runResponsePreview(chatID int64, stream *responseStream, done <-chan struct{}) int {
+	editor := streamedit.Editor{
+		ChatID:   chatID,
+		Prefix:   "[vibecoding] ",
+		Interval: responseStreamInterval,
+		Started:  stream.started,
+		Done:     done,
+		Snapshot: stream.preview,
+	}
+	id, _ := editor.Run(h.sender)
+	return id
+}
+
+// sendStreamedAnswer заменяет превью итоговым ответом; длинный ответ отправляется частями,
+// а превью помечается ссылкой на них
+func (h *VibeCodingHandler) sendStreamedAnswer(chatID int64, previewID int, text string) error {
+	if previewID == 0 {
+		return h.sendLongMessage(chatID, text)
+	}
+	if len(text) <= 4000 {
+		if err := h.updateMessage(chatID, previewID, text); err == nil {
+			return nil
+		}
+		log.Printf("⚠️ Failed to replace VibeCoding response preview, sending a new message")
+	} else {
+		_ = h.updateMessage(chatID, previewID, "[vibecoding] ⬇️ Ответ ниже")
+	}
+	return h.sendLongMessage(chatID, text)
+}
@@ -0,0 +1,106 @@
+package vibecoding
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ai-chatter/internal/codevalidation"
+	"ai-chatter/internal/llm"
+)
+
+// streamingLLM отдаёт ответ двумя фрагментами с паузой, чтобы превью успело обновиться
+type streamingLLM struct {
+	llm.LocalTokenCounter
+	chunks []string
+	pause  time.Duration
+}
+
+func (s *streamingLLM) Generate(ctx context.Context, messages []llm.Message) (llm.Response, error) {
+	return llm.Response{Content: strings.Join(s.chunks, "")}, nil
+}
+
+func (s *streamingLLM) GenerateWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (llm.Response, error) {
+	return s.Generate(ctx, messages)
+}
+
+func (s *streamingLLM) GenerateStream(ctx context.Context, messages []llm.Message, onDelta llm.StreamFunc) (llm.Response, error) {
+	for _, chunk := range s.chunks {
+		onDelta(chunk)
+		time.Sleep(s.pause)
+	}
+	return s.Generate(ctx, messages)
+}
+
+// editRecordingSender запоминает новые сообщения и редактирования
+type editRecordingSender struct {
+	mu    sync.Mutex
+	texts []string
+	edits []string
+}
+
+func (r *editRecordingSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch m := c.(type) {
+	case tgbotapi.MessageConfig:
+		r.texts = append(r.texts, m.Text)
+	case tgbotapi.EditMessageTextConfig:
+		r.edits = append(r.edits, m.Text)
+	}
+	return tgbotapi.Message{MessageID: 7}, nil
+}
+
+func (r *editRecordingSender) GetFile(config tgbotapi.FileConfig) (tgbotapi.File, error) {
+	return tgbotapi.File{}, nil
+}
+
+func TestHandleVibeCodingMessage_StreamsAnswerIntoPreview(t *testing.T) {
+	client := &streamingLLM{
+		chunks: []string{`{"status":"success","response":"Функция main `, `запускает сервер"}`},
+		pause:  3 * responseStreamInterval / 2,
+	}
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+	sender := &editRecordingSender{}
+	h := &VibeCodingHandler{sessionManager: sm, sender: sender, formatter: &MockMessageFormatter{},
+		llmClient: client, protocolClient: NewVibeCodingLLMClient(client), awaitingAutoTask: map[int64]bool{}}
+	session, err := sm.CreateSession(1, 100, "demo", map[string]string{"main.go": "package main"}, client)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	session.Analysis = &codevalidation.CodeAnalysisResult{Language: "Go"}
+
+	if err := h.HandleVibeCodingMessage(context.Background(), 1, 100, "что делает main?"); err != nil {
+		t.Fatalf("HandleVibeCodingMessage: %v", err)
+	}
+
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	if len(sender.texts) != 1 || !strings.HasPrefix(sender.texts[0], "[vibecoding] ") || strings.Contains(sender.texts[0], `"status"`) {
+		t.Fatalf("expected a single preview message with the partial answer, got %q", sender.texts)
+	}
+	if len(sender.edits) < 2 {
+		t.Fatalf("preview must be updated while streaming and replaced with the answer, got %q", sender.edits)
+	}
+	if final := sender.edits[len(sender.edits)-1]; final != "[vibecoding] Функция main запускает сервер" {
+		t.Errorf("unexpected final answer: %q", final)
+	}
+}
+
+func TestGenerateStreamed_FallsBackWithoutStreaming(t *testing.T) {
+	stream := newResponseStream()
+	resp, err := generateStreamed(withResponseStream(context.Background(), stream), NewMockLLMClient(), []llm.Message{{Role: "user", Content: "hi"}}, llm.GenerateOptions{})
+	if err != nil || resp.Content == "" {
+		t.Fatalf("providers without streaming must use Generate: %+v, %v", resp, err)
+	}
+	select {
+	case <-stream.started:
+		t.Fatal("no preview must be started without streaming")
+	default:
+	}
+}
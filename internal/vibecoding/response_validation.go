@@ -0,0 +1,83 @@
+package vibecoding
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidVibeCodingResponse ответ LLM разобран, но не соответствует схеме протокола;
+// ProcessRequest повторяет запрос, передавая модели текст ошибки
+var ErrInvalidVibeCodingResponse = errors.New("invalid VibeCoding response")
+
+// maxCodeFilenameLength ограничение длины пути файла в поле code
+const maxCodeFilenameLength = 255
+
+// validVibeCodingStatuses допустимые значения VibeCodingResponse.Status
+var validVibeCodingStatuses = []string{"success", "error", "partial"}
+
+// validateVibeCodingResponse проверяет обязательные поля и значения ответа: status из
+// validVibeCodingStatuses, непустой response при success, error при status=error и
+// относительные пути файлов внутри проекта в ключах code
+func validateVibeCodingResponse(r *VibeCodingResponse) error {
+	if r == nil {
+		return fmt.Errorf("%w: empty response", ErrInvalidVibeCodingResponse)
+	}
+	if r.Status == "" {
+		return fmt.Errorf("%w: status field is required", ErrInvalidVibeCodingResponse)
+	}
+	valid := false
+	for _, status := range validVibeCodingStatuses {
+		if r.Status == status {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("%w: invalid status %q, must be one of %s", ErrInvalidVibeCodingResponse, r.Status, strings.Join(validVibeCodingStatuses, ", "))
+	}
+	if r.Status == "error" && strings.TrimSpace(r.Error) == "" {
+		return fmt.Errorf("%w: error field is required when status is error", ErrInvalidVibeCodingResponse)
+	}
+	if r.Status == "success" && strings.TrimSpace(r.Response) == "" {
+		return fmt.Errorf("%w: response field is required when status is success", ErrInvalidVibeCodingResponse)
+	}
+
+	var problems []string
+	for name := range r.Code {
+		if err := validateCodeFilename(name); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%w: invalid file names in code: %s", ErrInvalidVibeCodingResponse, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validateCodeFilename путь файла из поля code: относительный, внутри проекта, без управляющих
+// символов и обратных слэшей, не каталог
+func validateCodeFilename(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return fmt.Errorf("empty file name")
+	case name != strings.TrimSpace(name):
+		return fmt.Errorf("%q has leading or trailing spaces", name)
+	case len(name) > maxCodeFilenameLength:
+		return fmt.Errorf("%q is longer than %d characters", name[:32]+"...", maxCodeFilenameLength)
+	case strings.ContainsRune(name, '\\'):
+		return fmt.Errorf("%q must use forward slashes", name)
+	case strings.HasSuffix(name, "/"):
+		return fmt.Errorf("%q is a directory, not a file", name)
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return fmt.Errorf("%q contains control characters", name)
+	}
+	if cleaned := path.Clean(name); path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") || cleaned == "." {
+		return fmt.Errorf("%q must be a relative path inside the project", name)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package vibecoding
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"ai-chatter/internal/llm"
+)
+
+func TestValidateVibeCodingResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    VibeCodingResponse
+		wantErr string
+	}{
+		{name: "success", resp: VibeCodingResponse{Status: "success", Response: "ok", Code: map[string]string{"pkg/main.go": "package main"}}},
+		{name: "partial without response", resp: VibeCodingResponse{Status: "partial"}},
+		{name: "error with message", resp: VibeCodingResponse{Status: "error", Error: "boom"}},
+		{name: "missing status", resp: VibeCodingResponse{Response: "ok"}, wantErr: "status field is required"},
+		{name: "unknown status", resp: VibeCodingResponse{Status: "done", Response: "ok"}, wantErr: `invalid status "done"`},
+		{name: "empty success", resp: VibeCodingResponse{Status: "success", Response: "  "}, wantErr: "response field is required"},
+		{name: "error without message", resp: VibeCodingResponse{Status: "error"}, wantErr: "error field is required"},
+		{name: "absolute path", resp: VibeCodingResponse{Status: "partial", Code: map[string]string{"/etc/passwd": ""}}, wantErr: "relative path inside the project"},
+		{name: "escaping path", resp: VibeCodingResponse{Status: "partial", Code: map[string]string{"src/../../x.go": ""}}, wantErr: "relative path inside the project"},
+		{name: "directory", resp: VibeCodingResponse{Status: "partial", Code: map[string]string{"src/": ""}}, wantErr: "is a directory"},
+		{name: "backslash", resp: VibeCodingResponse{Status: "partial", Code: map[string]string{`src\main.go`: ""}}, wantErr: "forward slashes"},
+		{name: "control characters", resp: VibeCodingResponse{Status: "partial", Code: map[string]string{"main\n.go": ""}}, wantErr: "control characters"},
+		{name: "empty name", resp: VibeCodingResponse{Status: "partial", Code: map[string]string{"": ""}}, wantErr: "empty file name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateVibeCodingResponse(&tt.resp)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !errors.Is(err, ErrInvalidVibeCodingResponse) {
+				t.Fatalf("error = %v, want %q wrapping ErrInvalidVibeCodingResponse", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProcessRequest_RetriesInvalidResponseWithReason(t *testing.T) {
+	fake := &scriptedToolLLM{replies: []llm.Response{
+		{Content: `{"status": "done", "response": "ok"}`},
+		{Content: `{"status": "success", "response": "fixed", "code": {"main.go": "package main"}}`},
+	}}
+	client := NewVibeCodingLLMClient(fake)
+
+	resp, err := client.ProcessRequest(context.Background(), VibeCodingRequest{Action: "answer_question", Query: "why?"})
+	if err != nil {
+		t.Fatalf("ProcessRequest: %v", err)
+	}
+	if resp.Response != "fixed" {
+		t.Errorf("response = %+v", resp)
+	}
+	if len(fake.requests) != 2 {
+		t.Fatalf("expected a retry, got %d requests", len(fake.requests))
+	}
+	retry := fake.requests[1]
+	if last := retry[len(retry)-1].Content; !strings.Contains(last, `invalid status "done"`) {
+		t.Errorf("retry must explain why the response was rejected, got %q", last)
+	}
+}
+
+func TestProcessRequestWithRetry_KeepsBrokenResponseInConversation(t *testing.T) {
+	broken := "```json\n{\"status\": \"success\", \"response\": \"half"
+	fake := &scriptedToolLLM{replies: []llm.Response{
+		{Content: broken},
+		{Content: "not json at all"},
+		{Content: `{"status": "success", "response": "fixed"}`},
+	}}
+	client := NewVibeCodingLLMClient(fake)
+
+	resp, err := client.ProcessRequestWithRetry(context.Background(), VibeCodingRequest{Action: "answer_question", Query: "why?"}, 3)
+	if err != nil {
+		t.Fatalf("ProcessRequestWithRetry: %v", err)
+	}
+	if resp.Response != "fixed" || len(fake.requests) != 3 {
+		t.Fatalf("response = %+v after %d requests", resp, len(fake.requests))
+	}
+	last := fake.requests[2]
+	if len(last) != 6 {
+		t.Fatalf("conversation must keep every failed attempt, got %d messages", len(last))
+	}
+	if last[2].Role != "assistant" || last[2].Content != broken || last[4].Content != "not json at all" {
+		t.Errorf("failed responses must be replayed as assistant messages, got %+v", last[2:])
+	}
+	if !strings.Contains(last[3].Content, "unterminated JSON object") || !strings.Contains(last[3].Content, "valid JSON only") {
+		t.Errorf("retry must include the parse error, got %q", last[3].Content)
+	}
+}
+
+func TestProcessRequestWithRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	fake := &scriptedToolLLM{replies: []llm.Response{{Content: "{broken"}, {Content: "{broken"}}}
+	client := NewVibeCodingLLMClient(fake)
+
+	_, err := client.ProcessRequestWithRetry(context.Background(), VibeCodingRequest{Action: "analyze"}, 2)
+	if err == nil || !strings.Contains(err.Error(), "failed after 2 attempts") || len(fake.requests) != 2 {
+		t.Fatalf("expected failure after 2 attempts, got %v (%d requests)", err, len(fake.requests))
+	}
+}
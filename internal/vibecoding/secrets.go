@@ -0,0 +1,315 @@
+package vibecoding
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SecretFinding секрет, найденный в загруженном файле. Хранится только ссылка на файл и строку
+// и тип секрета — само значение никуда не сохраняется
+type SecretFinding struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Kind string `json:"kind"`
+}
+
+// secretPattern эвристика поиска секрета в строке файла
+type secretPattern struct {
+	kind  string
+	re    *regexp.Regexp
+	group int                 // подгруппа со значением секрета, 0 — всё совпадение
+	check func(v string) bool // дополнительная проверка значения, nil — не нужна
+}
+
+const (
+	// secretMinAssignmentLength и secretMinEntropy отсекают плейсхолдеры вроде API_KEY=changeme
+	secretMinAssignmentLength = 16
+	secretMinEntropy          = 3.5
+)
+
+var secretPatterns = []secretPattern{
+	{kind: "aws_access_key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: "github_token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{kind: "openai_key", re: regexp.MustCompile(`\bsk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}`)},
+	{kind: "stripe_key", re: regexp.MustCompile(`\b(?:sk|rk)_live_[0-9A-Za-z]{20,}\b`)},
+	{kind: "slack_token", re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{kind: "google_api_key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{kind: "telegram_bot_token", re: regexp.MustCompile(`\b\d{8,10}:AA[A-Za-z0-9_-]{33}\b`)},
+	// Присваивания переменным *_KEY / *_TOKEN / *_SECRET (и apiKey): .env, yaml, конфиги в коде
+	{
+		kind:  "assignment",
+		re:    regexp.MustCompile(`(?i)\b[A-Z0-9_]*(?:KEY|TOKEN|SECRET)\b["']?\s*[:=]\s*["']?([A-Za-z0-9+/=_\-]{16,})(?:["'\s;,]|$)`),
+		group: 1,
+		check: func(v string) bool {
+			return len(v) >= secretMinAssignmentLength && shannonEntropy(v) >= secretMinEntropy
+		},
+	},
+}
+
+var (
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)
+)
+
+// ScanSecrets ищет секреты в файлах проекта: известные форматы ключей, PEM заголовки приватных
+// ключей и присваивания *_KEY/*_TOKEN со значением высокой энтропии. Результат отсортирован по файлу и строке
+func ScanSecrets(files map[string]string) []SecretFinding {
+	var findings []SecretFinding
+	for filename, content := range files {
+		if strings.ContainsRune(content, 0) {
+			continue // бинарный файл
+		}
+		scanSecretLines(content, func(line int, kind string, _ [][2]int) {
+			findings = append(findings, SecretFinding{File: filename, Line: line, Kind: kind})
+		})
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+// RedactSecrets заменяет найденные значения секретов плейсхолдерами [REDACTED:<kind>] перед
+// отправкой содержимого в LLM; блок приватного ключа заменяется одной строкой
+func RedactSecrets(content string) string {
+	if strings.ContainsRune(content, 0) {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	redacted := false
+	drop := make(map[int]bool)
+	type kindSpan struct {
+		kind       string
+		start, end int
+	}
+	spansByLine := make(map[int][]kindSpan)
+	scanSecretLines(content, func(line int, kind string, spans [][2]int) {
+		redacted = true
+		idx := line - 1
+		if kind == "private_key" {
+			single := privateKeyEnd.MatchString(lines[idx])
+			lines[idx] = secretPlaceholder(kind)
+			for i := idx + 1; i < len(lines) && !single; i++ {
+				drop[i] = true
+				if privateKeyEnd.MatchString(lines[i]) {
+					break
+				}
+			}
+			return
+		}
+		for _, sp := range spans {
+			spansByLine[idx] = append(spansByLine[idx], kindSpan{kind: kind, start: sp[0], end: sp[1]})
+		}
+	})
+	// Спаны не пересекаются: заменяем с конца строки, чтобы индексы остальных не сдвигались
+	for idx, spans := range spansByLine {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].start > spans[j].start })
+		for _, sp := range spans {
+			lines[idx] = lines[idx][:sp.start] + secretPlaceholder(sp.kind) + lines[idx][sp.end:]
+		}
+	}
+	if !redacted {
+		return content
+	}
+	result := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if !drop[i] {
+			result = append(result, line)
+		}
+	}
+	return strings.Join(result, "\n")
+}
+
+// scanSecretLines вызывает found для каждого типа секрета в строке (строки нумеруются с 1)
+// с позициями значений. Внутри блока приватного ключа строки не проверяются
+func scanSecretLines(content string, found func(line int, kind string, spans [][2]int)) {
+	inKey := false
+	for i, line := range strings.Split(content, "\n") {
+		if inKey {
+			inKey = !privateKeyEnd.MatchString(line)
+			continue
+		}
+		if privateKeyBegin.MatchString(line) {
+			found(i+1, "private_key", nil)
+			inKey = !privateKeyEnd.MatchString(line)
+			continue
+		}
+		// Пересекающиеся совпадения (известный ключ внутри присваивания) относятся к первому шаблону
+		var taken [][2]int
+		for _, p := range secretPatterns {
+			var spans [][2]int
+			for _, m := range p.re.FindAllStringSubmatchIndex(line, -1) {
+				start, end := m[2*p.group], m[2*p.group+1]
+				if start < 0 || overlapsSpan(taken, start, end) {
+					continue
+				}
+				if p.check != nil && !p.check(line[start:end]) {
+					continue
+				}
+				spans = append(spans, [2]int{start, end})
+			}
+			if len(spans) > 0 {
+				taken = append(taken, spans...)
+				found(i+1, p.kind, spans)
+			}
+		}
+	}
+}
+
+func overlapsSpan(spans [][2]int, start, end int) bool {
+	for _, s := range spans {
+		if start < s[1] && s[0] < end {
+			return true
+		}
+	}
+	return false
+}
+
+func secretPlaceholder(kind string) string {
+	return "[REDACTED:" + kind + "]"
+}
+
+// shannonEntropy энтропия строки в битах на символ
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// FormatSecretFindings предупреждение пользователю о найденных секретах: файл, строка и тип
+func FormatSecretFindings(findings []SecretFinding) string {
+	if len(findings) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("🔐 В архиве найдены похожие на секреты значения:\n")
+	files := 0
+	prev := ""
+	for _, f := range findings {
+		if f.File != prev {
+			files++
+			prev = f.File
+		}
+		sb.WriteString(fmt.Sprintf("• %s:%d — %s\n", f.File, f.Line, f.Kind))
+	}
+	sb.WriteString(fmt.Sprintf("\nФайлы (%d) скрыты в веб-интерфейсе и не попадут в архив результатов, "+
+		"а значения заменяются плейсхолдерами в запросах к LLM. Если это не секрет, разрешите файл: /vibecoding_allow_secret <файл>", files))
+	return sb.String()
+}
+
+// secretArchivePathLocked путь файла сессии в исходном архиве: для подпроекта добавляется его каталог
+func (s *VibeCodingSession) secretArchivePathLocked(filename string) string {
+	if s.Subproject == "" || s.Subproject == "." {
+		return filename
+	}
+	return s.Subproject + "/" + filename
+}
+
+// secretHiddenLocked файл с найденными секретами, для которого пользователь не разрешил показ
+func (s *VibeCodingSession) secretHiddenLocked(archivePath string) bool {
+	if s.secretOverrides[archivePath] {
+		return false
+	}
+	for _, f := range s.secretFindings {
+		if f.File == archivePath {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretFindings найденные при загрузке секреты (файл и строка, без значений)
+func (s *VibeCodingSession) SecretFindings() []SecretFinding {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return append([]SecretFinding(nil), s.secretFindings...)
+}
+
+// IsSecretFileHidden скрыт ли файл сессии (имя как в s.Files) из веб-интерфейса и архива результатов
+func (s *VibeCodingSession) IsSecretFileHidden(filename string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.secretHiddenLocked(s.secretArchivePathLocked(filename))
+}
+
+// AllowSecretFile разрешает показывать файл с найденными секретами в веб-интерфейсе и архиве.
+// Имя принимается как в предупреждении (путь в архиве) или как в текущем подпроекте
+func (s *VibeCodingSession) AllowSecretFile(filename string) (string, error) {
+	defer s.persist()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	filename = strings.TrimSpace(filename)
+	for _, candidate := range []string{filename, s.secretArchivePathLocked(filename)} {
+		for _, f := range s.secretFindings {
+			if f.File != candidate {
+				continue
+			}
+			if s.secretOverrides == nil {
+				s.secretOverrides = make(map[string]bool)
+			}
+			s.secretOverrides[candidate] = true
+			log.Printf("🔓 User %d allowed file with secrets: %s", s.UserID, candidate)
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("в файле %s секреты не найдены", filename)
+}
+
+// PromptFiles исходные файлы для промптов LLM: значения секретов заменены плейсхолдерами
+func (s *VibeCodingSession) PromptFiles() map[string]string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return redactFiles(s.Files)
+}
+
+// redactFiles копия файлов с замаскированными секретами
+func redactFiles(files map[string]string) map[string]string {
+	result := make(map[string]string, len(files))
+	for filename, content := range files {
+		result[filename] = RedactSecrets(content)
+	}
+	return result
+}
+
+// withoutHiddenSecretsLocked убирает из файлов архива результатов (пути исходного архива) скрытые файлы с секретами
+func (s *VibeCodingSession) withoutHiddenSecretsLocked(files map[string]string) map[string]string {
+	for filename := range files {
+		if s.secretHiddenLocked(filename) {
+			delete(files, filename)
+		}
+	}
+	return files
+}
+
+// hiddenSecretFindings находки в файлах, которые исключены из архива результатов
+func (s *VibeCodingSession) hiddenSecretFindings() []SecretFinding {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var hidden []SecretFinding
+	for _, f := range s.secretFindings {
+		if s.secretHiddenLocked(f.File) {
+			hidden = append(hidden, f)
+		}
+	}
+	return hidden
+}
@@ -3,10 +3,12 @@ package vibecoding
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -43,50 +45,111 @@ func getGlobalMCPClient() *VibeCodingMCPClient {
 
 // VibeCodingSession представляет активную сессию вайбкодинга для пользователя
 type VibeCodingSession struct {
-	UserID         int64                              // ID пользователя Telegram
-	ChatID         int64                              // ID чата
-	ProjectName    string                             // Название проекта
-	StartTime      time.Time                          // Время начала сессии
-	Files          map[string]string                  // Файлы проекта: имя -> содержимое
-	GeneratedFiles map[string]string                  // Сгенерированные файлы
-	ContainerID    string                             // ID Docker контейнера
-	Analysis       *codevalidation.CodeAnalysisResult // Анализ проекта (unified from validator)
-	TestCommand    string                             // Команда для запуска тестов
-	Docker         *DockerAdapter                     // Docker адаптер
-	LLMClient      llm.Client                         // LLM клиент для анализа ошибок
-	Context        *ProjectContextLLM                 // Сжатый контекст проекта для LLM (LLM-generated)
-	mutex          sync.RWMutex                       // Мьютекс для безопасности потоков
+	UserID                int64                              // ID пользователя Telegram
+	ChatID                int64                              // ID чата
+	ProjectName           string                             // Название проекта
+	StartTime             time.Time                          // Время начала сессии
+	Files                 map[string]string                  // Файлы проекта: имя -> содержимое
+	GeneratedFiles        map[string]string                  // Сгенерированные файлы
+	FileHistory           map[string][]string                // Предыдущие версии сгенерированных файлов для /vibecoding_rollback
+	ContainerID           string                             // ID Docker контейнера
+	Analysis              *codevalidation.CodeAnalysisResult // Анализ проекта (unified from validator)
+	TestCommand           string                             // Команда для запуска тестов
+	Docker                *DockerAdapter                     // Docker адаптер
+	LLMClient             llm.Client                         // LLM клиент для анализа ошибок
+	Context               *ProjectContextLLM                 // Сжатый контекст проекта для LLM (LLM-generated)
+	Config                VibeCodingConfig                   // Настройки попыток и таймаутов
+	ValidationChecks      []ValidationCheck                  // Дополнительные проверки из .vibecoding.yml
+	Subprojects           []Subproject                       // Подпроекты монорепозитория (по манифестам)
+	Subproject            string                             // Выбранный подпроект, пусто — весь архив
+	Origin                *SessionOrigin                     // Репозиторий и коммит для /vibecoding_clone, nil — проект из архива
+	TotalPromptTokens     int64                              // Prompt токены всех LLM запросов сессии, см. AddTokenUsage
+	TotalCompletionTokens int64                              // Completion токены всех LLM запросов сессии
+	EstimatedCostUSD      float64                            // Оценка стоимости по ценам из Config
+	activeModel           string                             // Модель, ответившая на последний LLM запрос сессии
+	fallbackFrom          string                             // Основная модель, если последний ответ дала резервная
+	archiveFiles          map[string]string                  // Полное дерево монорепозитория после выбора подпроекта
+	preventedConflicts    []string                           // Исходные файлы, которые не дали перезаписать сгенерированным кодом
+	uploadedOriginals     map[string]string                  // Загруженные версии исходных файлов, перезаписанных с overwrite_original
+	secretFindings        []SecretFinding                    // Секреты в загруженных файлах (пути исходного архива, без значений)
+	secretOverrides       map[string]bool                    // Файлы с секретами, которые пользователь разрешил показывать
+	autoWork              []AutoWorkItem                     // Задачи автономной работы и их итоги
+	matrixRuns            []MatrixRun                        // Последние прогоны /vibecoding_matrix для отчёта сессии
+	matrixContainers      int                                // Контейнеры, потраченные на матрицу, см. MatrixContainerBudget
+	store                 SessionStore                       // Хранилище снимков сессии, nil — без сохранения
+	lastActivity          atomic.Int64                       // Время последней активности (UnixNano), см. touch
+	mutex                 sync.RWMutex                       // Мьютекс для безопасности потоков
 }
 
 // SessionManager управляет активными сессиями вайбкодинга
 type SessionManager struct {
-	sessions  map[int64]*VibeCodingSession // Активные сессии по UserID
-	mutex     sync.RWMutex                 // Мьютекс для безопасности потоков
-	webServer *WebServer                   // Веб-сервер для отображения сессий
+	sessions      map[int64]*VibeCodingSession // Активные сессии по UserID
+	mutex         sync.RWMutex                 // Мьютекс для безопасности потоков
+	webServer     *WebServer                   // Веб-сервер для отображения сессий
+	config        VibeCodingConfig             // Настройки для новых сессий
+	reaper        *idleReaper                  // Закрытие сессий по бездействию
+	onIdleClose   IdleCloseFunc                // Уведомление о закрытой по бездействию сессии
+	idlePaused    bool                         // Закрытие по бездействию приостановлено, см. PauseIdleClose
+	store         SessionStore                 // Хранилище сессий для восстановления после перезапуска
+	metricsServer *http.Server                 // Отдельный сервер /metrics, см. StartMetricsServer
+	gc            *containerGC                 // Сборка осиротевших контейнеров, см. StartContainerGC
+	onContainerGC ContainerGCFunc              // Уведомление об удалённых осиротевших контейнерах
 }
 
-// NewSessionManager создает новый менеджер сессий
-func NewSessionManager() *SessionManager {
+// NewSessionManager создает менеджер сессий с веб-сервером на порту port (0 — без веб-сервера).
+// Занятый порт возвращается ошибкой
+func NewSessionManager(port int) (*SessionManager, error) {
+	sm := NewSessionManagerWithoutWebServer()
+	if err := sm.StartWebServer(port); err != nil {
+		sm.Close()
+		return nil, err
+	}
+	return sm, nil
+}
+
+// NewSessionManagerWithoutWebServer создает менеджер сессий без веб-сервера
+func NewSessionManagerWithoutWebServer() *SessionManager {
 	sm := &SessionManager{
 		sessions: make(map[int64]*VibeCodingSession),
 	}
+	sm.startIdleReaper(idleReaperInterval)
+	return sm
+}
 
-	// Запускаем веб-сервер на порту 8080
-	sm.webServer = NewWebServer(sm, 8080)
+// StartWebServer занимает порт и запускает веб-интерфейс сессий; port <= 0 — веб-сервер не нужен
+func (sm *SessionManager) StartWebServer(port int) error {
+	if port <= 0 {
+		return nil
+	}
+	if sm.webServer != nil {
+		return fmt.Errorf("vibecoding web server is already running on port %d", sm.webServer.Port())
+	}
+	ws := NewWebServer(sm, port)
+	if err := ws.Listen(); err != nil {
+		return err
+	}
+	sm.webServer = ws
 	go func() {
-		if err := sm.webServer.Start(); err != nil && err != http.ErrServerClosed {
-			log.Printf("❌ Failed to start VibeCoding web server: %v", err)
+		if err := ws.Serve(); err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ VibeCoding web server stopped: %v", err)
 		}
 	}()
-
-	return sm
+	return nil
 }
 
-// NewSessionManagerWithoutWebServer создает менеджер сессий без веб-сервера
-func NewSessionManagerWithoutWebServer() *SessionManager {
-	return &SessionManager{
-		sessions: make(map[int64]*VibeCodingSession),
+// WebPort порт веб-интерфейса; 0, если веб-сервер не запущен
+func (sm *SessionManager) WebPort() int {
+	if sm.webServer == nil {
+		return 0
 	}
+	return sm.webServer.Port()
+}
+
+// SetConfig задаёт настройки попыток и таймаутов для новых сессий
+func (sm *SessionManager) SetConfig(cfg VibeCodingConfig) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	sm.config = cfg
 }
 
 // CreateSession создает новую сессию вайбкодинга
@@ -99,18 +162,6 @@ func (sm *SessionManager) CreateSession(userID, chatID int64, projectName string
 		return nil, fmt.Errorf("у пользователя %d уже есть активная сессия: %s", userID, existingSession.ProjectName)
 	}
 
-	// Создаем Docker клиент и адаптер
-	var dockerManager codevalidation.DockerManager
-	realDockerClient, err := codevalidation.NewDockerClient()
-	if err != nil {
-		log.Printf("⚠️ Docker not available, using mock client for vibecoding session")
-		dockerManager = codevalidation.NewMockDockerClient()
-	} else {
-		dockerManager = realDockerClient
-	}
-
-	dockerAdapter := NewDockerAdapter(dockerManager)
-
 	session := &VibeCodingSession{
 		UserID:         userID,
 		ChatID:         chatID,
@@ -118,21 +169,45 @@ func (sm *SessionManager) CreateSession(userID, chatID int64, projectName string
 		StartTime:      time.Now(),
 		Files:          make(map[string]string),
 		GeneratedFiles: make(map[string]string),
-		Docker:         dockerAdapter,
-		LLMClient:      llmClient,
+		Docker:         newSessionDockerAdapter(),
+		LLMClient:      withLLMTimeout(llmClient, sm.config.LLMTimeout),
+		Config:         sm.config,
+		store:          sm.store,
 	}
 
 	// Копируем файлы
 	for filename, content := range files {
 		session.Files[filename] = content
 	}
+	session.ValidationChecks = loadValidationChecks(files)
+	session.Subprojects = DetectSubprojects(files)
+	session.secretFindings = ScanSecrets(files)
+	if len(session.secretFindings) > 0 {
+		log.Printf("🔐 Found %d possible secrets in %s", len(session.secretFindings), projectName)
+	}
+	if len(session.Subprojects) > 1 {
+		log.Printf("📂 Detected monorepo with %d subprojects in %s", len(session.Subprojects), projectName)
+	}
 
+	session.touch()
 	sm.sessions[userID] = session
+	session.persist()
+	DefaultSessionMetrics.SessionStarted()
 	log.Printf("🔥 Created vibecoding session for user %d: %s", userID, projectName)
 
 	return session, nil
 }
 
+// newSessionDockerAdapter создаёт Docker адаптер сессии; без Docker используется mock клиент
+func newSessionDockerAdapter() *DockerAdapter {
+	realDockerClient, err := codevalidation.NewDockerClient()
+	if err != nil {
+		log.Printf("⚠️ Docker not available, using mock client for vibecoding session")
+		return NewDockerAdapter(codevalidation.NewMockDockerClient())
+	}
+	return NewDockerAdapter(realDockerClient)
+}
+
 // CreatedAt возвращает время создания сессии для совместимости с MCP
 func (s *VibeCodingSession) CreatedAt() time.Time {
 	return s.StartTime
@@ -179,6 +254,12 @@ func (sm *SessionManager) EndSession(userID int64) error {
 	}
 
 	delete(sm.sessions, userID)
+	if sm.store != nil {
+		if err := sm.store.Delete(userID); err != nil {
+			log.Printf("⚠️ Failed to delete stored session for user %d: %v", userID, err)
+		}
+	}
+	DefaultSessionMetrics.SessionEnded()
 	log.Printf("🔥 Ended vibecoding session for user %d: %s", userID, session.ProjectName)
 
 	return nil
@@ -203,6 +284,15 @@ func (sm *SessionManager) GetActiveSessions() int {
 
 // SetupEnvironment настраивает окружение для проекта с единым LLM запросом для анализа и контекста
 func (s *VibeCodingSession) SetupEnvironment(ctx context.Context) error {
+	return s.SetupEnvironmentWithProgress(ctx, nil)
+}
+
+// SetupEnvironmentWithProgress как SetupEnvironment, но сообщает о каждом шаге и ошибке попытки в progress
+func (s *VibeCodingSession) SetupEnvironmentWithProgress(ctx context.Context, progress ProgressFunc) error {
+	if progress == nil {
+		progress = func(int, int, string, error) {}
+	}
+	defer s.persist()
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -217,25 +307,31 @@ func (s *VibeCodingSession) SetupEnvironment(ctx context.Context) error {
 		log.Printf("🔥 Environment setup attempt %d/%d", attempt, maxAttempts)
 
 		// 1. Выполняем единый анализ проекта и генерацию контекста
+		progress(attempt, maxAttempts, "анализ проекта", nil)
 		if err := s.analyzeProjectAndGenerateContext(ctx); err != nil {
 			lastError = fmt.Errorf("project analysis and context generation failed: %w", err)
 			log.Printf("❌ Attempt %d failed: %v", attempt, lastError)
+			progress(attempt, maxAttempts, "анализ проекта", lastError)
 			continue
 		}
 
 		// 2. Создаем контейнер
-		containerID, err := s.Docker.CreateContainer(ctx, s.Analysis)
+		progress(attempt, maxAttempts, "создание контейнера", nil)
+		containerID, err := s.Docker.CreateContainer(ctx, s.Analysis, s.containerOwner())
 		if err != nil {
 			lastError = fmt.Errorf("container creation failed: %w", err)
 			log.Printf("❌ Attempt %d failed: %v", attempt, lastError)
+			progress(attempt, maxAttempts, "создание контейнера", lastError)
 			continue
 		}
 		s.ContainerID = containerID
 
 		// 3. Копируем файлы
+		progress(attempt, maxAttempts, "копирование файлов", nil)
 		if err := s.Docker.CopyFilesToContainer(ctx, s.ContainerID, s.Files); err != nil {
 			lastError = fmt.Errorf("file copying failed: %w", err)
 			log.Printf("❌ Attempt %d failed: %v", attempt, lastError)
+			progress(attempt, maxAttempts, "копирование файлов", lastError)
 			// Очищаем контейнер при ошибке
 			s.Docker.RemoveContainer(ctx, s.ContainerID)
 			s.ContainerID = ""
@@ -243,13 +339,16 @@ func (s *VibeCodingSession) SetupEnvironment(ctx context.Context) error {
 		}
 
 		// 4. Устанавливаем зависимости
+		progress(attempt, maxAttempts, "установка зависимостей", nil)
 		if err := s.Docker.InstallDependencies(ctx, s.ContainerID, s.Analysis); err != nil {
 			lastError = fmt.Errorf("dependency installation failed: %w", err)
 			log.Printf("❌ Attempt %d failed: %v", attempt, lastError)
+			progress(attempt, maxAttempts, "установка зависимостей", lastError)
 
 			// Анализируем ошибку и пытаемся исправить конфигурацию
 			if attempt < maxAttempts {
 				log.Printf("🔧 Analyzing error and trying to fix configuration...")
+				progress(attempt, maxAttempts, "исправление конфигурации", nil)
 				if fixedAnalysis, fixErr := s.analyzeAndFixError(ctx, err, s.Analysis, attempt); fixErr == nil {
 					s.Analysis = fixedAnalysis
 					log.Printf("✅ Configuration updated, retrying with new settings")
@@ -282,6 +381,7 @@ func (s *VibeCodingSession) SetupEnvironment(ctx context.Context) error {
 		return nil
 	}
 
+	DefaultSessionMetrics.SetupFailed()
 	return fmt.Errorf("environment setup failed after %d attempts: %w", maxAttempts, lastError)
 }
 
@@ -350,7 +450,8 @@ IMPORTANT:
 	fileList := make([]string, 0, len(s.Files))
 	fileContents := make(map[string]string)
 
-	for filename, content := range s.Files {
+	// SetupEnvironment вызывает анализ под s.mutex: PromptFiles повторно взял бы RLock и заблокировал настройку
+	for filename, content := range redactFiles(s.Files) {
 		fileList = append(fileList, filename)
 		// Ограничиваем размер содержимого для включения в промпт
 		if len(content) > 1000 {
@@ -387,7 +488,7 @@ Focus on:
 	}
 
 	log.Printf("🧠 Requesting combined analysis and context generation from LLM...")
-	response, err := s.LLMClient.Generate(ctx, messages)
+	response, err := s.LLMClient.Generate(withSessionUsage(ctx, s), messages)
 	if err != nil {
 		return fmt.Errorf("failed to get combined analysis: %w", err)
 	}
@@ -512,6 +613,7 @@ Focus on:
 		s.Context.Files[filePath] = fileContext
 	}
 	s.Context.TokensUsed = totalTokens
+	s.Context.DependencyGraph = BuildDependencyGraph(s.Files)
 
 	log.Printf("🔥 Combined analysis complete: %s (%s)", s.Analysis.Language, s.Analysis.DockerImage)
 	log.Printf("📦 Install commands: %v", s.Analysis.InstallCommands)
@@ -522,26 +624,6 @@ Focus on:
 	return nil
 }
 
-// analyzeProject анализирует проект используя LLM (unified approach from validator.go) - DEPRECATED
-func (s *VibeCodingSession) analyzeProject(ctx context.Context) error {
-	log.Printf("📊 Analyzing VibeCoding project with %d files using LLM", len(s.Files))
-
-	// Используем унифицированный подход из CodeValidationWorkflow
-	workflow := codevalidation.NewCodeValidationWorkflow(s.LLMClient, nil)
-
-	analysis, err := workflow.AnalyzeProjectForVibeCoding(ctx, s.Files)
-	if err != nil {
-		return fmt.Errorf("failed to analyze project: %w", err)
-	}
-
-	s.Analysis = analysis
-	log.Printf("🔥 VibeCoding project analysis complete: %s (%s)", s.Analysis.Language, s.Analysis.DockerImage)
-	log.Printf("📦 Install commands: %v", s.Analysis.InstallCommands)
-	log.Printf("⚡ Validation commands: %v", s.Analysis.Commands)
-
-	return nil
-}
-
 // formatFileContentsForPrompt форматирует содержимое файлов для включения в промпт
 func (s *VibeCodingSession) formatFileContentsForPrompt(fileContents map[string]string) string {
 	var result strings.Builder
@@ -592,13 +674,18 @@ func (s *VibeCodingSession) generateTestCommand() string {
 	return "echo 'No test command available from LLM analysis'"
 }
 
-// AddGeneratedFile добавляет сгенерированный файл в сессию
-func (s *VibeCodingSession) AddGeneratedFile(filename, content string) {
+// AddGeneratedFile добавляет сгенерированный файл в сессию и возвращает имя, под которым он сохранён:
+// если имя совпадает с исходным файлом, сгенерированная версия сохраняется как name.generated.ext
+func (s *VibeCodingSession) AddGeneratedFile(filename, content string) string {
+	defer s.persist()
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	s.GeneratedFiles[filename] = content
-	log.Printf("🔥 Added generated file to session: %s (%d bytes)", filename, len(content))
+	target := s.generatedTargetLocked(filename)
+	s.recordFileHistoryLocked(target)
+	s.GeneratedFiles[target] = content
+	log.Printf("🔥 Added generated file to session: %s (%d bytes)", target, len(content))
+	return target
 }
 
 // GetAllFiles возвращает все файлы (исходные + сгенерированные)
@@ -638,14 +725,31 @@ func (s *VibeCodingSession) Cleanup() error {
 	return nil
 }
 
-// ExecuteCommand выполняет команду в контейнере сессии
+// ExecuteCommand выполняет команду в контейнере сессии и возвращает общий вывод.
+// Обёртка над ExecuteCommandWithOptions для вызывающих, которым не нужны раздельные потоки
 func (s *VibeCodingSession) ExecuteCommand(ctx context.Context, command string) (*codevalidation.ValidationResult, error) {
+	result, err := s.ExecuteCommandWithOptions(ctx, command, codevalidation.ExecOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return result.ValidationResult(), nil
+}
+
+// ExecuteCommandWithOptions выполняет команду в каталоге opts.WorkDir (относительно корня проекта)
+// с переменными opts.Env; stdout и stderr возвращаются раздельно. opts.Timeout заменяет CommandTimeout
+func (s *VibeCodingSession) ExecuteCommandWithOptions(ctx context.Context, command string, opts codevalidation.ExecOptions) (*codevalidation.ExecResult, error) {
+	// Долгая команда не должна считаться бездействием: отмечаем и начало, и конец
+	s.touch()
+	defer s.touch()
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
 	if s.ContainerID == "" {
 		return nil, fmt.Errorf("session environment not set up")
 	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
 
 	// Создаем временный анализ для выполнения команды
 	tempAnalysis := &codevalidation.CodeAnalysisResult{
@@ -655,7 +759,63 @@ func (s *VibeCodingSession) ExecuteCommand(ctx context.Context, command string)
 		WorkingDir:  s.Analysis.WorkingDir,
 	}
 
-	return s.Docker.ExecuteValidation(ctx, s.ContainerID, tempAnalysis)
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = s.Config.CommandTimeout
+	}
+	containerID := s.ContainerID
+	result, err := runWithTimeout(ctx, timeout, command, func(ctx context.Context) (*codevalidation.ExecResult, error) {
+		return s.Docker.ExecuteCommand(ctx, containerID, tempAnalysis, command, opts)
+	})
+	if command == s.TestCommand {
+		DefaultSessionMetrics.TestRun(testOutcome(result != nil && result.Success, err))
+	}
+	return result, err
+}
+
+// executeWithTimeout выполняет команды анализа в контейнере с учётом CommandTimeout.
+// Вызывается под s.mutex
+func (s *VibeCodingSession) executeWithTimeout(ctx context.Context, analysis *codevalidation.CodeAnalysisResult, command string) (*codevalidation.ValidationResult, error) {
+	containerID := s.ContainerID
+	return runWithTimeout(ctx, s.Config.CommandTimeout, command, func(ctx context.Context) (*codevalidation.ValidationResult, error) {
+		return s.Docker.ExecuteValidation(ctx, containerID, analysis)
+	})
+}
+
+// runWithTimeout выполняет run в горутине, чтобы зависшая команда не блокировала обработчик;
+// по истечении timeout возвращает ErrCommandTimeout. timeout <= 0 — без ограничения
+func runWithTimeout[T any](ctx context.Context, timeout time.Duration, command string, run func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	if timeout <= 0 {
+		return run(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type execResult struct {
+		result T
+		err    error
+	}
+	done := make(chan execResult, 1)
+	go func() {
+		result, err := run(ctx)
+		done <- execResult{result: result, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return zero, fmt.Errorf("%w after %s: %s", ErrCommandTimeout, timeout, command)
+		}
+		return res.result, res.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			log.Printf("⏱️ Command timed out after %s: %s", timeout, command)
+			return zero, fmt.Errorf("%w after %s: %s", ErrCommandTimeout, timeout, command)
+		}
+		return zero, ctx.Err()
+	}
 }
 
 // ListFiles возвращает список всех файлов в сессии
@@ -674,32 +834,50 @@ func (s *VibeCodingSession) ListFiles(ctx context.Context) ([]string, error) {
 	return files, nil
 }
 
-// ReadFile читает содержимое файла
+// ReadFile читает содержимое файла для MCP инструментов LLM: значения секретов заменены плейсхолдерами
 func (s *VibeCodingSession) ReadFile(ctx context.Context, filename string) (string, error) {
+	s.touch()
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
 	// Сначала ищем в обычных файлах
 	if content, exists := s.Files[filename]; exists {
-		return content, nil
+		return RedactSecrets(content), nil
 	}
 
 	// Потом в сгенерированных файлах
 	if content, exists := s.GeneratedFiles[filename]; exists {
-		return content, nil
+		return RedactSecrets(content), nil
 	}
 
 	return "", fmt.Errorf("file not found: %s", filename)
 }
 
-// WriteFile записывает файл в сессию
+// WriteFile записывает файл в сессию. Сгенерированный файл не может заменить исходный (см. WriteFileWithOptions)
 func (s *VibeCodingSession) WriteFile(ctx context.Context, filename, content string, generated bool) error {
+	return s.WriteFileWithOptions(ctx, filename, content, WriteFileOptions{Generated: generated})
+}
+
+// WriteFileWithOptions записывает файл в сессию. Запись сгенерированного файла поверх исходного
+// возвращает ErrOriginalFileProtected, если не передан OverwriteOriginal
+func (s *VibeCodingSession) WriteFileWithOptions(ctx context.Context, filename, content string, opts WriteFileOptions) error {
+	s.touch()
+	defer s.persist()
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	if generated {
+	_, isOriginal := s.Files[filename]
+	switch {
+	case opts.Generated && isOriginal && !opts.OverwriteOriginal:
+		s.recordConflictLocked(filename)
+		return protectedWriteError(filename)
+	case opts.Generated && !isOriginal:
 		s.GeneratedFiles[filename] = content
-	} else {
+	default:
+		if opts.Generated {
+			log.Printf("⚠️ Overwriting original file %s with generated content (user %d)", filename, s.UserID)
+			s.recordUploadedOriginalLocked(filename)
+		}
 		s.Files[filename] = content
 	}
 
@@ -712,6 +890,10 @@ func (s *VibeCodingSession) WriteFile(ctx context.Context, filename, content str
 		}
 	}
 
+	if filename != "PROJECT_CONTEXT.md" && s.Context != nil {
+		s.refreshDependencyGraph()
+	}
+
 	// Обновляем контекст проекта инкриментально (для LLM контекста)
 	if filename != "PROJECT_CONTEXT.md" && s.Context != nil && s.LLMClient != nil {
 		go func() {
@@ -738,6 +920,7 @@ func (s *VibeCodingSession) WriteFile(ctx context.Context, filename, content str
 
 // RemoveFile удаляет файл из сессии и обновляет контекст
 func (s *VibeCodingSession) RemoveFile(ctx context.Context, filename string) error {
+	defer s.persist()
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -756,6 +939,10 @@ func (s *VibeCodingSession) RemoveFile(ctx context.Context, filename string) err
 		return fmt.Errorf("file not found: %s", filename)
 	}
 
+	if filename != "PROJECT_CONTEXT.md" && s.Context != nil {
+		s.refreshDependencyGraph()
+	}
+
 	// Обновляем контекст (удаляем из LLM контекста)
 	if filename != "PROJECT_CONTEXT.md" && s.Context != nil && s.LLMClient != nil {
 		go func() {
@@ -773,6 +960,94 @@ func (s *VibeCodingSession) RemoveFile(ctx context.Context, filename string) err
 	return nil
 }
 
+// ErrFileExists целевой путь переименования уже занят
+var ErrFileExists = errors.New("file already exists")
+
+// RenameFile переименовывает (перемещает) файл сессии. Файл остаётся исходным или сгенерированным,
+// как и был (generated сообщает, каким именно); в контейнере выполняется mv, контекст проекта
+// переносится на новый путь
+func (s *VibeCodingSession) RenameFile(ctx context.Context, oldPath, newPath string) (generated bool, err error) {
+	s.touch()
+	defer s.persist()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if oldPath == "" || newPath == "" {
+		return false, fmt.Errorf("old_path and new_path are required")
+	}
+	if oldPath == newPath {
+		return false, fmt.Errorf("old_path and new_path are the same: %s", oldPath)
+	}
+	if cleaned := path.Clean(newPath); path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return false, fmt.Errorf("new_path must stay inside the project: %s", newPath)
+	}
+	if _, exists := s.Files[newPath]; exists {
+		return false, fmt.Errorf("%w: %s", ErrFileExists, newPath)
+	}
+	if _, exists := s.GeneratedFiles[newPath]; exists {
+		return false, fmt.Errorf("%w: %s", ErrFileExists, newPath)
+	}
+
+	files := s.Files
+	content, exists := files[oldPath]
+	if !exists {
+		files, generated = s.GeneratedFiles, true
+		if content, exists = files[oldPath]; !exists {
+			return false, fmt.Errorf("file not found: %s", oldPath)
+		}
+	}
+
+	if s.ContainerID != "" {
+		command := fmt.Sprintf("mkdir -p %s && mv %s %s",
+			shellQuote(path.Dir(path.Join("/workspace", newPath))),
+			shellQuote(path.Join("/workspace", oldPath)),
+			shellQuote(path.Join("/workspace", newPath)))
+		analysis := &codevalidation.CodeAnalysisResult{Commands: []string{command}}
+		if s.Analysis != nil {
+			analysis.Language = s.Analysis.Language
+			analysis.DockerImage = s.Analysis.DockerImage
+		}
+		result, err := s.executeWithTimeout(ctx, analysis, command)
+		if err != nil {
+			return false, fmt.Errorf("failed to move file in container: %w", err)
+		}
+		if result != nil && !result.Success {
+			return false, fmt.Errorf("failed to move file in container: %s", strings.TrimSpace(result.Output))
+		}
+	}
+
+	delete(files, oldPath)
+	files[newPath] = content
+
+	if s.Context != nil {
+		NewLLMContextGenerator(s.LLMClient, 5000).RenameFileContext(s.Context, oldPath, newPath)
+		s.refreshDependencyGraph()
+		if _, exists := s.GeneratedFiles["PROJECT_CONTEXT.md"]; exists {
+			s.GeneratedFiles["PROJECT_CONTEXT.md"] = s.generateContextMarkdown()
+		}
+	}
+
+	log.Printf("🔥 Renamed file in session: %s -> %s", oldPath, newPath)
+	return generated, nil
+}
+
+// shellQuote экранирует аргумент для sh в одинарных кавычках
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// refreshDependencyGraph пересчитывает граф зависимостей по текущим файлам (вызывается под s.mutex)
+func (s *VibeCodingSession) refreshDependencyGraph() {
+	allFiles := make(map[string]string, len(s.Files)+len(s.GeneratedFiles))
+	for filename, content := range s.Files {
+		allFiles[filename] = content
+	}
+	for filename, content := range s.GeneratedFiles {
+		allFiles[filename] = content
+	}
+	s.Context.DependencyGraph = BuildDependencyGraph(allFiles)
+}
+
 // ValidateCode валидирует код файла
 func (s *VibeCodingSession) ValidateCode(ctx context.Context, code, filename string) (*codevalidation.ValidationResult, error) {
 	s.mutex.RLock()
@@ -799,7 +1074,7 @@ func (s *VibeCodingSession) ValidateCode(ctx context.Context, code, filename str
 		WorkingDir:  s.Analysis.WorkingDir,
 	}
 
-	return s.Docker.ExecuteValidation(ctx, s.ContainerID, tempAnalysis)
+	return s.executeWithTimeout(ctx, tempAnalysis, strings.Join(s.Analysis.Commands, " && "))
 }
 
 // GetSessionInfo возвращает информацию о сессии
@@ -807,15 +1082,30 @@ func (s *VibeCodingSession) GetSessionInfo() map[string]interface{} {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
+	language := ""
+	if s.Analysis != nil {
+		language = s.Analysis.Language
+	}
+
 	info := map[string]interface{}{
 		"project_name":    s.ProjectName,
-		"language":        s.Analysis.Language,
+		"language":        language,
 		"start_time":      s.StartTime,
 		"files_count":     len(s.Files),
 		"generated_count": len(s.GeneratedFiles),
 		"test_command":    s.TestCommand,
 		"container_id":    s.ContainerID,
 	}
+	if s.Subproject != "" {
+		info["subproject"] = s.Subproject
+	}
+	if s.Origin != nil {
+		info["origin_url"] = s.Origin.URL
+		info["origin_commit"] = s.Origin.Commit
+		if s.Origin.Branch != "" {
+			info["origin_branch"] = s.Origin.Branch
+		}
+	}
 
 	// Добавляем информацию о контексте если доступен
 	if s.Context != nil {
@@ -825,9 +1115,21 @@ func (s *VibeCodingSession) GetSessionInfo() map[string]interface{} {
 		info["context_tokens_used"] = s.Context.TokensUsed
 		info["context_tokens_limit"] = s.Context.TokensLimit
 		info["context_files_count"] = len(s.Context.Files)
+		if central := s.Context.DependencyGraph.TopFiles(3); len(central) > 0 {
+			info["central_files"] = central
+		}
 	} else {
 		info["context_available"] = false
 	}
+	info["prompt_tokens"] = s.TotalPromptTokens
+	info["completion_tokens"] = s.TotalCompletionTokens
+	info["estimated_cost_usd"] = s.EstimatedCostUSD
+	if s.activeModel != "" {
+		info["active_model"] = s.activeModel
+	}
+	if s.fallbackFrom != "" {
+		info["fallback_from"] = s.fallbackFrom
+	}
 
 	return info
 }
@@ -910,7 +1212,7 @@ Be very specific about Docker image versions and install commands.`,
 
 	log.Printf("🧠 Requesting error analysis from LLM")
 
-	response, err := s.LLMClient.Generate(ctx, messages)
+	response, err := llm.GenerateWithOptions(withSessionUsage(ctx, s), s.LLMClient, messages, llm.OptionsForTask(llm.TaskGeneration))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get error analysis: %w", err)
 	}
@@ -1137,8 +1439,8 @@ func (s *VibeCodingSession) generateProjectContext() error {
 	// Используем LLM-генератор контекста с лимитом токенов (5000 по умолчанию)
 	generator := NewLLMContextGenerator(s.LLMClient, 5000)
 
-	// Получаем все файлы (исходные + сгенерированные)
-	allFiles := s.GetAllFiles()
+	// Получаем все файлы (исходные + сгенерированные), секреты заменены плейсхолдерами
+	allFiles := redactFiles(s.GetAllFiles())
 
 	ctx := context.Background()
 	context, err := generator.GenerateContext(ctx, s.ProjectName, allFiles)
@@ -1203,6 +1505,9 @@ func (s *VibeCodingSession) generateContextMarkdown() string {
 		md.WriteString("\n")
 	}
 
+	// Dependency graph
+	md.WriteString(s.Context.DependencyGraph.FormatMarkdown())
+
 	// LLM-generated file descriptions
 	md.WriteString("## File Descriptions (LLM-Generated)\n\n")
 	for filePath, fileContext := range s.Context.Files {
@@ -1304,6 +1609,9 @@ func (s *VibeCodingSession) generateContextJSON(projectContext *ProjectContextLL
 		"files":              make(map[string]interface{}),
 		"usage_instructions": s.generateUsageInstructions(),
 	}
+	if projectContext.DependencyGraph != nil {
+		universalContext["dependency_graph"] = projectContext.DependencyGraph
+	}
 
 	// Добавляем информацию о файлах
 	filesData := make(map[string]interface{})
@@ -1395,7 +1703,7 @@ func (s *VibeCodingSession) countTotalFiles() int {
 
 // ValidateAndFixTests проверяет сгенерированные тесты и запрашивает исправления если они не проходят
 func (s *VibeCodingSession) ValidateAndFixTests(ctx context.Context, testFiles []string) error {
-	const maxAttempts = 3
+	maxAttempts := s.Config.testFixAttempts()
 
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -1648,7 +1956,7 @@ func (s *VibeCodingSession) requestTestFix(ctx context.Context, testFile string,
 		{Role: "user", Content: prompt},
 	}
 
-	response, err := s.LLMClient.Generate(ctx, messages)
+	response, err := llm.GenerateWithOptions(withSessionUsage(ctx, s), s.LLMClient, messages, llm.OptionsForTask(llm.TaskGeneration))
 	if err != nil {
 		return fmt.Errorf("failed to get LLM response for test fix: %w", err)
 	}
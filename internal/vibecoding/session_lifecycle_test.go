@@ -0,0 +1,109 @@
+package vibecoding
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"ai-chatter/internal/codevalidation"
+	"ai-chatter/internal/llm"
+)
+
+// setupAnalysisLLM отвечает на объединённый запрос анализа проекта и контекста из SetupEnvironment
+type setupAnalysisLLM struct {
+	llm.NoStreaming
+	llm.LocalTokenCounter
+	workingDir string
+}
+
+func (c *setupAnalysisLLM) Generate(ctx context.Context, messages []llm.Message) (llm.Response, error) {
+	return llm.Response{Content: fmt.Sprintf(`{
+		"analysis": {
+			"language": "Python",
+			"docker_image": "python:3.11-slim",
+			"install_commands": ["pip install -r requirements.txt"],
+			"validation_commands": ["python -m py_compile main.py"],
+			"test_commands": ["pytest"],
+			"working_dir": %q
+		},
+		"context": {
+			"description": "Calculator",
+			"language": "Python",
+			"files": {"main.py": {"summary": "entry point", "purpose": "adds numbers", "type": "source"}}
+		}
+	}`, c.workingDir)}, nil
+}
+
+func (c *setupAnalysisLLM) GenerateWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (llm.Response, error) {
+	return c.Generate(ctx, messages)
+}
+
+func TestSessionLifecycle_MockDocker(t *testing.T) {
+	ctx := context.Background()
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+
+	files := map[string]string{
+		"main.py":          "def add(a, b):\n    return a + b\n",
+		"requirements.txt": "pytest\n",
+	}
+	// Контекст проекта сохраняется в working_dir на диске, поэтому он указывает во временный каталог
+	session, err := sm.CreateSession(1, 10, "calc", files, &setupAnalysisLLM{workingDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if sm.GetSession(1) != session || !sm.HasActiveSession(1) {
+		t.Fatal("created session is not registered in the manager")
+	}
+	// Тот же mock клиент, на который CreateSession переходит без Docker, но независимо от окружения теста
+	session.Docker = NewDockerAdapter(codevalidation.NewMockDockerClient())
+
+	if err := session.SetupEnvironment(ctx); err != nil {
+		t.Fatalf("SetupEnvironment: %v", err)
+	}
+	if session.ContainerID == "" || session.Analysis == nil || session.Analysis.DockerImage != "python:3.11-slim" {
+		t.Fatalf("environment is not set up: container=%q analysis=%+v", session.ContainerID, session.Analysis)
+	}
+	if session.TestCommand == "" || session.GetProjectContext() == nil {
+		t.Errorf("test command and project context must be set: %q, %v", session.TestCommand, session.GetProjectContext())
+	}
+
+	if err := session.WriteFile(ctx, "test_main.py", "from main import add\n\ndef test_add():\n    assert add(1, 2) == 3\n", true); err != nil {
+		t.Fatalf("WriteFile generated: %v", err)
+	}
+	if err := session.WriteFile(ctx, "main.py", "def add(a, b):\n    return b + a\n", false); err != nil {
+		t.Fatalf("WriteFile original: %v", err)
+	}
+	if _, ok := session.GeneratedFiles["test_main.py"]; !ok {
+		t.Errorf("generated file is not tracked: %v", session.GeneratedFiles)
+	}
+	if _, ok := session.Files["test_main.py"]; ok {
+		t.Error("generated file must not be added to the original files")
+	}
+
+	all := session.GetAllFiles()
+	if len(all) != len(session.Files)+len(session.GeneratedFiles) {
+		t.Errorf("GetAllFiles = %d files, want %d original + %d generated", len(all), len(session.Files), len(session.GeneratedFiles))
+	}
+	if all["main.py"] != "def add(a, b):\n    return b + a\n" || all["test_main.py"] == "" || all["requirements.txt"] == "" {
+		t.Errorf("GetAllFiles does not merge original and generated files: %v", all)
+	}
+
+	result, err := session.ExecuteCommand(ctx, session.TestCommand)
+	if err != nil || !result.Success {
+		t.Fatalf("ExecuteCommand = %+v, %v", result, err)
+	}
+
+	if err := sm.EndSession(1); err != nil {
+		t.Fatalf("EndSession: %v", err)
+	}
+	if sm.GetSession(1) != nil || sm.HasActiveSession(1) || sm.GetActiveSessions() != 0 {
+		t.Error("ended session must be removed from the manager")
+	}
+	if session.ContainerID != "" {
+		t.Errorf("container must be removed on EndSession, got %q", session.ContainerID)
+	}
+	if err := sm.EndSession(1); err == nil {
+		t.Error("second EndSession must fail: the session is already gone")
+	}
+}
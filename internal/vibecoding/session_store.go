@@ -0,0 +1,325 @@
+package vibecoding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-chatter/internal/codevalidation"
+	"ai-chatter/internal/llm"
+)
+
+// ErrSessionNotStored сессия пользователя не найдена в хранилище
+var ErrSessionNotStored = errors.New("vibecoding session is not stored")
+
+// ErrNoSessionStore хранилище сессий не подключено (VIBECODING_SESSIONS_PATH пуст)
+var ErrNoSessionStore = errors.New("vibecoding session store is not configured")
+
+// SessionStore сохраняет состояние сессий, чтобы они переживали перезапуск бота
+type SessionStore interface {
+	Save(session *VibeCodingSession) error
+	Load(userID int64) (*VibeCodingSession, error)
+	LoadAll() ([]*VibeCodingSession, error)
+	Delete(userID int64) error
+}
+
+// sessionSnapshot сериализуемая часть сессии. Контейнер, Docker и LLM клиенты не сохраняются:
+// после перезапуска контейнер создаётся заново
+type sessionSnapshot struct {
+	UserID             int64                              `json:"user_id"`
+	ChatID             int64                              `json:"chat_id"`
+	ProjectName        string                             `json:"project_name"`
+	StartTime          time.Time                          `json:"start_time"`
+	Files              map[string]string                  `json:"files"`
+	GeneratedFiles     map[string]string                  `json:"generated_files"`
+	FileHistory        map[string][]string                `json:"file_history,omitempty"`
+	Analysis           *codevalidation.CodeAnalysisResult `json:"analysis,omitempty"`
+	TestCommand        string                             `json:"test_command,omitempty"`
+	Context            *ProjectContextLLM                 `json:"context,omitempty"`
+	ValidationChecks   []ValidationCheck                  `json:"validation_checks,omitempty"`
+	Subprojects        []Subproject                       `json:"subprojects,omitempty"`
+	Subproject         string                             `json:"subproject,omitempty"`
+	Origin             *SessionOrigin                     `json:"origin,omitempty"`
+	ArchiveFiles       map[string]string                  `json:"archive_files,omitempty"`
+	PreventedConflicts []string                           `json:"prevented_conflicts,omitempty"`
+	UploadedOriginals  map[string]string                  `json:"uploaded_originals,omitempty"`
+	SecretFindings     []SecretFinding                    `json:"secret_findings,omitempty"`
+	SecretOverrides    map[string]bool                    `json:"secret_overrides,omitempty"`
+	AutoWork           []AutoWorkItem                     `json:"auto_work,omitempty"`
+	MatrixRuns         []MatrixRun                        `json:"matrix_runs,omitempty"`
+	MatrixContainers   int                                `json:"matrix_containers,omitempty"`
+	PromptTokens       int64                              `json:"prompt_tokens,omitempty"`
+	CompletionTokens   int64                              `json:"completion_tokens,omitempty"`
+	EstimatedCostUSD   float64                            `json:"estimated_cost_usd,omitempty"`
+	SavedAt            time.Time                          `json:"saved_at"`
+}
+
+// snapshot копирует состояние сессии под блокировкой чтения. Карты и срезы не копируются:
+// для сериализации работающей сессии используйте marshalSnapshot
+func (s *VibeCodingSession) snapshot() sessionSnapshot {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.snapshotLocked()
+}
+
+// marshalSnapshot сериализует снимок, не отпуская блокировку чтения: карты файлов общие с сессией,
+// и без блокировки json.Marshal обходил бы их одновременно с WriteFile
+func (s *VibeCodingSession) marshalSnapshot() ([]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return json.Marshal(s.snapshotLocked())
+}
+
+func (s *VibeCodingSession) snapshotLocked() sessionSnapshot {
+	return sessionSnapshot{
+		UserID:             s.UserID,
+		ChatID:             s.ChatID,
+		ProjectName:        s.ProjectName,
+		StartTime:          s.StartTime,
+		Files:              s.Files,
+		GeneratedFiles:     s.GeneratedFiles,
+		FileHistory:        s.FileHistory,
+		Analysis:           s.Analysis,
+		TestCommand:        s.TestCommand,
+		Context:            s.Context,
+		ValidationChecks:   s.ValidationChecks,
+		Subprojects:        s.Subprojects,
+		Subproject:         s.Subproject,
+		Origin:             s.Origin,
+		ArchiveFiles:       s.archiveFiles,
+		PreventedConflicts: s.preventedConflicts,
+		UploadedOriginals:  s.uploadedOriginals,
+		SecretFindings:     s.secretFindings,
+		SecretOverrides:    s.secretOverrides,
+		AutoWork:           s.autoWork,
+		MatrixRuns:         s.matrixRuns,
+		MatrixContainers:   s.matrixContainers,
+		PromptTokens:       s.TotalPromptTokens,
+		CompletionTokens:   s.TotalCompletionTokens,
+		EstimatedCostUSD:   s.EstimatedCostUSD,
+		SavedAt:            time.Now(),
+	}
+}
+
+func (snap sessionSnapshot) session() *VibeCodingSession {
+	session := &VibeCodingSession{
+		UserID:                snap.UserID,
+		ChatID:                snap.ChatID,
+		ProjectName:           snap.ProjectName,
+		StartTime:             snap.StartTime,
+		Files:                 snap.Files,
+		GeneratedFiles:        snap.GeneratedFiles,
+		FileHistory:           snap.FileHistory,
+		Analysis:              snap.Analysis,
+		TestCommand:           snap.TestCommand,
+		Context:               snap.Context,
+		ValidationChecks:      snap.ValidationChecks,
+		Subprojects:           snap.Subprojects,
+		Subproject:            snap.Subproject,
+		Origin:                snap.Origin,
+		archiveFiles:          snap.ArchiveFiles,
+		preventedConflicts:    snap.PreventedConflicts,
+		uploadedOriginals:     snap.UploadedOriginals,
+		secretFindings:        snap.SecretFindings,
+		secretOverrides:       snap.SecretOverrides,
+		autoWork:              snap.AutoWork,
+		matrixRuns:            snap.MatrixRuns,
+		matrixContainers:      snap.MatrixContainers,
+		TotalPromptTokens:     snap.PromptTokens,
+		TotalCompletionTokens: snap.CompletionTokens,
+		EstimatedCostUSD:      snap.EstimatedCostUSD,
+	}
+	if session.Files == nil {
+		session.Files = make(map[string]string)
+	}
+	if session.GeneratedFiles == nil {
+		session.GeneratedFiles = make(map[string]string)
+	}
+	return session
+}
+
+// FileSessionStore хранит каждую сессию отдельным JSON файлом session_<userID>.json в каталоге
+type FileSessionStore struct {
+	dir string
+}
+
+// NewFileSessionStore создаёт каталог хранилища при необходимости
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("ensure vibecoding sessions dir: %w", err)
+	}
+	return &FileSessionStore{dir: dir}, nil
+}
+
+func (fs *FileSessionStore) path(userID int64) string {
+	return filepath.Join(fs.dir, fmt.Sprintf("session_%d.json", userID))
+}
+
+// Save пишет снимок через временный файл, чтобы сбой посреди записи не портил предыдущий снимок
+func (fs *FileSessionStore) Save(session *VibeCodingSession) error {
+	data, err := session.marshalSnapshot()
+	if err != nil {
+		return fmt.Errorf("encode vibecoding session: %w", err)
+	}
+	path := fs.path(session.UserID)
+	tmp, err := os.CreateTemp(fs.dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write vibecoding session: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write vibecoding session: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write vibecoding session: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (fs *FileSessionStore) Load(userID int64) (*VibeCodingSession, error) {
+	data, err := os.ReadFile(fs.path(userID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrSessionNotStored
+		}
+		return nil, fmt.Errorf("read vibecoding session: %w", err)
+	}
+	var snap sessionSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("parse vibecoding session %d: %w", userID, err)
+	}
+	return snap.session(), nil
+}
+
+// LoadAll загружает все сохранённые сессии; повреждённые файлы пропускаются с предупреждением
+func (fs *FileSessionStore) LoadAll() ([]*VibeCodingSession, error) {
+	entries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return nil, fmt.Errorf("list vibecoding sessions: %w", err)
+	}
+	var sessions []*VibeCodingSession
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "session_") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		userID, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(name, "session_"), ".json"), 10, 64)
+		if err != nil {
+			continue
+		}
+		session, err := fs.Load(userID)
+		if err != nil {
+			log.Printf("⚠️ Skipping stored vibecoding session %s: %v", name, err)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (fs *FileSessionStore) Delete(userID int64) error {
+	if err := os.Remove(fs.path(userID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("delete vibecoding session: %w", err)
+	}
+	return nil
+}
+
+// persist сохраняет сессию в хранилище, если оно подключено. Вызывается без s.mutex
+func (s *VibeCodingSession) persist() {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.Save(s); err != nil {
+		log.Printf("⚠️ Failed to persist vibecoding session for user %d: %v", s.UserID, err)
+	}
+}
+
+// CheckpointSession сохраняет снимок сессии вне обычных изменений (например, перед обслуживанием бота);
+// без хранилища возвращает ErrNoSessionStore
+func (sm *SessionManager) CheckpointSession(session *VibeCodingSession) error {
+	sm.mutex.RLock()
+	store := sm.store
+	sm.mutex.RUnlock()
+	if store == nil {
+		return ErrNoSessionStore
+	}
+	return store.Save(session)
+}
+
+// RestoreSessions подключает хранилище к менеджеру и возвращает сохранённые до перезапуска сессии.
+// Сессии регистрируются без контейнера, его пересоздаёт RecreateContainer
+func (sm *SessionManager) RestoreSessions(store SessionStore, llmClient llm.Client) ([]*VibeCodingSession, error) {
+	sm.mutex.Lock()
+	sm.store = store
+	cfg := sm.config
+	sm.mutex.Unlock()
+	if store == nil {
+		return nil, nil
+	}
+
+	stored, err := store.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var restored []*VibeCodingSession
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	for _, session := range stored {
+		if _, exists := sm.sessions[session.UserID]; exists {
+			continue
+		}
+		session.Docker = newSessionDockerAdapter()
+		session.LLMClient = withLLMTimeout(llmClient, cfg.LLMTimeout)
+		session.Config = cfg
+		session.store = store
+		// Время простоя бота не считается бездействием пользователя
+		session.touch()
+		sm.sessions[session.UserID] = session
+		restored = append(restored, session)
+		log.Printf("♻️ Restored vibecoding session for user %d: %s", session.UserID, session.ProjectName)
+	}
+	return restored, nil
+}
+
+// RecreateContainer заново создаёт контейнер восстановленной сессии по сохранённому анализу:
+// контейнеры не переживают перезапуск
+func (s *VibeCodingSession) RecreateContainer(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.Analysis == nil {
+		return fmt.Errorf("session has no project analysis, upload the archive again")
+	}
+	containerID, err := s.Docker.CreateContainer(ctx, s.Analysis, s.containerOwner())
+	if err != nil {
+		return fmt.Errorf("container creation failed: %w", err)
+	}
+	files := make(map[string]string, len(s.Files)+len(s.GeneratedFiles))
+	for name, content := range s.Files {
+		files[name] = content
+	}
+	for name, content := range s.GeneratedFiles {
+		files[name] = content
+	}
+	if err := s.Docker.CopyFilesToContainer(ctx, containerID, files); err != nil {
+		s.Docker.RemoveContainer(ctx, containerID)
+		return fmt.Errorf("file copying failed: %w", err)
+	}
+	if err := s.Docker.InstallDependencies(ctx, containerID, s.Analysis); err != nil {
+		s.Docker.RemoveContainer(ctx, containerID)
+		return fmt.Errorf("dependency installation failed: %w", err)
+	}
+	s.ContainerID = containerID
+	log.Printf("✅ Recreated container %s for restored session of user %d", containerID, s.UserID)
+	return nil
+}
@@ -0,0 +1,152 @@
+package vibecoding
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-chatter/internal/codevalidation"
+)
+
+func TestFileSessionStore_PersistsFileChanges(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileSessionStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileSessionStore: %v", err)
+	}
+
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+	if _, err := sm.RestoreSessions(store, nil); err != nil {
+		t.Fatalf("RestoreSessions: %v", err)
+	}
+	session, err := sm.CreateSession(7, 70, "demo", map[string]string{"main.py": "print(1)"}, nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	session.Docker = NewDockerAdapter(codevalidation.NewMockDockerClient())
+	session.Analysis = &codevalidation.CodeAnalysisResult{Language: "Python", DockerImage: "python:3.11"}
+	session.TestCommand = "pytest"
+	session.AddGeneratedFile("test_main.py", "def test_ok(): pass")
+	if err := session.WriteFile(context.Background(), "util.py", "x = 1", false); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	loaded, err := store.Load(7)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.ChatID != 70 || loaded.ProjectName != "demo" || loaded.TestCommand != "pytest" {
+		t.Errorf("unexpected loaded session: %+v", loaded)
+	}
+	if loaded.Files["util.py"] != "x = 1" || loaded.GeneratedFiles["test_main.py"] == "" {
+		t.Errorf("file changes were not persisted: files=%v generated=%v", loaded.Files, loaded.GeneratedFiles)
+	}
+	if loaded.Analysis == nil || loaded.Analysis.DockerImage != "python:3.11" {
+		t.Errorf("analysis was not persisted: %+v", loaded.Analysis)
+	}
+
+	if err := sm.EndSession(7); err != nil {
+		t.Fatalf("EndSession: %v", err)
+	}
+	if _, err := store.Load(7); !errors.Is(err, ErrSessionNotStored) {
+		t.Errorf("ended session must be removed from the store, got %v", err)
+	}
+}
+
+func TestSessionManager_RestoreSessionsRecreatesContainer(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileSessionStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileSessionStore: %v", err)
+	}
+	saved := &VibeCodingSession{
+		UserID:         3,
+		ChatID:         30,
+		ProjectName:    "restored",
+		Files:          map[string]string{"main.go": "package main"},
+		GeneratedFiles: map[string]string{"main_test.go": "package main"},
+		Analysis:       &codevalidation.CodeAnalysisResult{Language: "Go", DockerImage: "golang:1.23"},
+		ContainerID:    "stale-container",
+	}
+	if err := store.Save(saved); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	// Повреждённый снимок не мешает восстановлению остальных
+	if err := os.WriteFile(filepath.Join(dir, "session_4.json"), []byte("{broken"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sm := NewSessionManagerWithoutWebServer()
+	defer sm.Close()
+	restored, err := sm.RestoreSessions(store, nil)
+	if err != nil {
+		t.Fatalf("RestoreSessions: %v", err)
+	}
+	if len(restored) != 1 || !sm.HasActiveSession(3) {
+		t.Fatalf("expected one restored session, got %d", len(restored))
+	}
+	session := sm.GetSession(3)
+	if session.ContainerID != "" {
+		t.Errorf("container ID must not survive restart, got %q", session.ContainerID)
+	}
+	if idle := time.Since(session.LastActivity()); idle > time.Minute {
+		t.Errorf("restored session must be marked active, idle %s", idle)
+	}
+
+	session.Docker = NewDockerAdapter(codevalidation.NewMockDockerClient())
+	if err := session.RecreateContainer(context.Background()); err != nil {
+		t.Fatalf("RecreateContainer: %v", err)
+	}
+	if session.ContainerID == "" {
+		t.Error("container must be recreated")
+	}
+}
+
+// Сохранение снимка одновременно с записью файлов не должно гоняться за картами сессии (go test -race)
+func TestFileSessionStore_SaveConcurrentWithWriteFile(t *testing.T) {
+	store, err := NewFileSessionStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileSessionStore: %v", err)
+	}
+	session := &VibeCodingSession{
+		UserID:         3,
+		Files:          map[string]string{"main.py": "print(1)"},
+		GeneratedFiles: make(map[string]string),
+		Docker:         NewDockerAdapter(codevalidation.NewMockDockerClient()),
+		Analysis:       &codevalidation.CodeAnalysisResult{DockerImage: "python:3.11"},
+	}
+	for i := 0; i < 500; i++ {
+		session.Files[strconv.Itoa(i)+".py"] = strings.Repeat("x", 100)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := store.Save(session); err != nil {
+				t.Errorf("Save: %v", err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		name := filepath.Join("gen", strconv.Itoa(i)+".py")
+		if err := session.WriteFile(context.Background(), name, "x = 1", false); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	close(stop)
+	<-done
+}
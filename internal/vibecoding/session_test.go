@@ -1,6 +1,9 @@
 package vibecoding
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -8,7 +11,10 @@ import (
 )
 
 func TestNewSessionManager(t *testing.T) {
-	sm := NewSessionManager()
+	sm, err := NewSessionManager(0)
+	if err != nil {
+		t.Fatalf("NewSessionManager: %v", err)
+	}
 	if sm == nil {
 		t.Error("Expected session manager to be created")
 	}
@@ -19,7 +25,7 @@ func TestNewSessionManager(t *testing.T) {
 }
 
 func TestSessionManager_CreateSession(t *testing.T) {
-	sm := NewSessionManager()
+	sm := NewSessionManagerWithoutWebServer()
 
 	files := map[string]string{
 		"main.py": "print('hello world')",
@@ -53,7 +59,7 @@ func TestSessionManager_CreateSession(t *testing.T) {
 }
 
 func TestSessionManager_DuplicateSession(t *testing.T) {
-	sm := NewSessionManager()
+	sm := NewSessionManagerWithoutWebServer()
 
 	files := map[string]string{
 		"main.py": "print('hello world')",
@@ -77,7 +83,7 @@ func TestSessionManager_DuplicateSession(t *testing.T) {
 }
 
 func TestSessionManager_GetSession(t *testing.T) {
-	sm := NewSessionManager()
+	sm := NewSessionManagerWithoutWebServer()
 
 	files := map[string]string{
 		"main.py": "print('hello world')",
@@ -107,7 +113,7 @@ func TestSessionManager_GetSession(t *testing.T) {
 }
 
 func TestSessionManager_EndSession(t *testing.T) {
-	sm := NewSessionManager()
+	sm := NewSessionManagerWithoutWebServer()
 
 	files := map[string]string{
 		"main.py": "print('hello world')",
@@ -141,7 +147,7 @@ func TestSessionManager_EndSession(t *testing.T) {
 }
 
 func TestSessionManager_HasActiveSession(t *testing.T) {
-	sm := NewSessionManager()
+	sm := NewSessionManagerWithoutWebServer()
 
 	if sm.HasActiveSession(123) {
 		t.Error("Should not have active session initially")
@@ -277,3 +283,69 @@ func TestVibeCodingSession_GetSessionInfo(t *testing.T) {
 		t.Error("Container ID doesn't match")
 	}
 }
+
+// sleepyDockerManager имитирует зависшую команду в контейнере
+type sleepyDockerManager struct {
+	codevalidation.DockerManager
+	delay time.Duration
+}
+
+func (m *sleepyDockerManager) ExecuteValidation(ctx context.Context, containerID string, analysis *codevalidation.CodeAnalysisResult) (*codevalidation.ValidationResult, error) {
+	select {
+	case <-time.After(m.delay):
+		return &codevalidation.ValidationResult{Success: true, Output: "done"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func newTimeoutTestSession(delay, timeout time.Duration) *VibeCodingSession {
+	return &VibeCodingSession{
+		ContainerID: "container",
+		Analysis:    &codevalidation.CodeAnalysisResult{Language: "Python"},
+		Docker:      NewDockerAdapter(&sleepyDockerManager{delay: delay}),
+		Config:      VibeCodingConfig{CommandTimeout: timeout},
+	}
+}
+
+func TestExecuteCommand_TimesOut(t *testing.T) {
+	session := newTimeoutTestSession(time.Second, 50*time.Millisecond)
+
+	start := time.Now()
+	_, err := session.ExecuteCommand(context.Background(), "pip install -r requirements.txt")
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("expected ErrCommandTimeout, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Fatalf("unexpected error message: %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("timeout was not enforced")
+	}
+}
+
+func TestExecuteCommand_NoTimeoutByDefault(t *testing.T) {
+	session := newTimeoutTestSession(20*time.Millisecond, 0)
+
+	result, err := session.ExecuteCommand(context.Background(), "pytest")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("expected success")
+	}
+}
+
+func TestVibeCodingConfig_Defaults(t *testing.T) {
+	var cfg VibeCodingConfig
+	if cfg.testFixAttempts() != 3 || cfg.testGenAttempts() != 5 || cfg.testValidationAttempts() != 3 {
+		t.Fatalf("zero config must preserve default attempts")
+	}
+	cfg = VibeCodingConfig{MaxTestFixAttempts: 1, MaxTestGenAttempts: 2, MaxTestValidationAttempts: 4}
+	if cfg.testFixAttempts() != 1 || cfg.testGenAttempts() != 2 || cfg.testValidationAttempts() != 4 {
+		t.Fatalf("configured attempts must be used")
+	}
+}
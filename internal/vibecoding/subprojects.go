@@ -0,0 +1,224 @@
+package vibecoding
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+)
+
+// subprojectManifests файлы, по которым каталог считается отдельным подпроектом
+var subprojectManifests = map[string]bool{
+	"go.mod":           true,
+	"package.json":     true,
+	"requirements.txt": true,
+	"pyproject.toml":   true,
+	"setup.py":         true,
+	"Pipfile":          true,
+	"Cargo.toml":       true,
+	"pom.xml":          true,
+	"build.gradle":     true,
+	"build.gradle.kts": true,
+	"Gemfile":          true,
+	"composer.json":    true,
+	"mix.exs":          true,
+}
+
+// subprojectIgnoredDirs каталоги с чужими манифестами (зависимости, сборка)
+var subprojectIgnoredDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	".venv":        true,
+	"venv":         true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+}
+
+// Subproject каталог монорепозитория со своим манифестом
+type Subproject struct {
+	Path      string   `json:"path"`      // Путь относительно корня архива, "." для корня
+	Manifests []string `json:"manifests"` // Найденные манифесты
+	Files     int      `json:"files"`     // Количество файлов в каталоге
+}
+
+// DetectSubprojects находит каталоги с манифестами. Монорепозиторий — если подпроектов больше одного
+func DetectSubprojects(files map[string]string) []Subproject {
+	byDir := make(map[string]*Subproject)
+	for filename := range files {
+		dir, name := path.Split(filename)
+		if !subprojectManifests[name] || hasIgnoredDir(dir) {
+			continue
+		}
+		dir = strings.TrimSuffix(dir, "/")
+		if dir == "" {
+			dir = "."
+		}
+		sp, ok := byDir[dir]
+		if !ok {
+			sp = &Subproject{Path: dir}
+			byDir[dir] = sp
+		}
+		sp.Manifests = append(sp.Manifests, name)
+	}
+
+	result := make([]Subproject, 0, len(byDir))
+	for _, sp := range byDir {
+		sort.Strings(sp.Manifests)
+		sp.Files = len(scopeFilesToSubproject(files, sp.Path))
+		result = append(result, *sp)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result
+}
+
+func hasIgnoredDir(dir string) bool {
+	for _, part := range strings.Split(dir, "/") {
+		if subprojectIgnoredDirs[part] {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeFilesToSubproject возвращает файлы каталога с путями относительно него
+func scopeFilesToSubproject(files map[string]string, dir string) map[string]string {
+	if dir == "." || dir == "" {
+		return copyFiles(files)
+	}
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	scoped := make(map[string]string)
+	for filename, content := range files {
+		if rel, ok := strings.CutPrefix(filename, prefix); ok && rel != "" {
+			scoped[rel] = content
+		}
+	}
+	return scoped
+}
+
+func copyFiles(files map[string]string) map[string]string {
+	result := make(map[string]string, len(files))
+	for filename, content := range files {
+		result[filename] = content
+	}
+	return result
+}
+
+// IsMonorepo true, если в сессии найдено несколько подпроектов
+func (s *VibeCodingSession) IsMonorepo() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.Subprojects) > 1
+}
+
+// NeedsSubprojectSelection true, пока в монорепозитории не выбран подпроект и окружение не настроено
+func (s *VibeCodingSession) NeedsSubprojectSelection() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.Subprojects) > 1 && s.Analysis == nil
+}
+
+// SelectSubproject ограничивает сессию каталогом подпроекта: файлы, анализ, команды тестов и контекст
+// строятся заново для этого каталога. Контейнер предыдущего подпроекта удаляется
+func (s *VibeCodingSession) SelectSubproject(ctx context.Context, dir string) error {
+	dir = strings.Trim(path.Clean(strings.TrimSpace(dir)), "/")
+	if dir == "" {
+		dir = "."
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.archiveFiles == nil {
+		s.archiveFiles = copyFiles(s.Files)
+	}
+	if dir != "." && !s.hasSubproject(dir) {
+		return fmt.Errorf("подпроект %s не найден", dir)
+	}
+
+	if s.ContainerID != "" {
+		if err := s.Docker.RemoveContainer(ctx, s.ContainerID); err != nil {
+			log.Printf("⚠️ Failed to remove container of previous subproject: %v", err)
+		}
+		s.ContainerID = ""
+	}
+
+	// Изменения предыдущего подпроекта сохраняются в полном дереве архива
+	s.mergeScopedFilesLocked()
+
+	s.Subproject = dir
+	s.Files = scopeFilesToSubproject(s.archiveFiles, dir)
+	s.GeneratedFiles = make(map[string]string)
+	s.Analysis = nil
+	s.Context = nil
+	s.TestCommand = ""
+	s.ValidationChecks = loadValidationChecks(s.Files)
+
+	log.Printf("📂 Selected subproject '%s' for user %d: %d files", dir, s.UserID, len(s.Files))
+	return nil
+}
+
+func (s *VibeCodingSession) hasSubproject(dir string) bool {
+	for _, sp := range s.Subprojects {
+		if sp.Path == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeScopedFilesLocked переносит файлы текущего подпроекта в полное дерево архива
+func (s *VibeCodingSession) mergeScopedFilesLocked() {
+	if s.archiveFiles == nil || s.Analysis == nil {
+		return
+	}
+	prefix := ""
+	if s.Subproject != "" && s.Subproject != "." {
+		prefix = s.Subproject + "/"
+	}
+	for filename := range s.archiveFiles {
+		if strings.HasPrefix(filename, prefix) {
+			if _, ok := s.Files[strings.TrimPrefix(filename, prefix)]; !ok {
+				delete(s.archiveFiles, filename)
+			}
+		}
+	}
+	for filename, content := range s.Files {
+		s.archiveFiles[prefix+filename] = content
+	}
+	for filename, content := range s.GeneratedFiles {
+		s.archiveFiles[prefix+s.generatedTargetLocked(filename)] = content
+	}
+}
+
+// ResultFiles возвращает файлы для итогового архива: для монорепозитория — всё дерево,
+// файлы подпроекта на своих местах. Сгенерированные файлы не затирают исходные (name.generated.ext),
+// файлы с найденными секретами без разрешения пользователя не попадают в архив
+func (s *VibeCodingSession) ResultFiles() map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.archiveFiles == nil {
+		result := copyFiles(s.Files)
+		for filename, content := range s.GeneratedFiles {
+			result[s.generatedTargetLocked(filename)] = content
+		}
+		return s.withoutHiddenSecretsLocked(result)
+	}
+	s.mergeScopedFilesLocked()
+	return s.withoutHiddenSecretsLocked(copyFiles(s.archiveFiles))
+}
+
+// FormatSubprojects описывает найденные подпроекты для выбора пользователем
+func FormatSubprojects(subprojects []Subproject, selected string) string {
+	var sb strings.Builder
+	for _, sp := range subprojects {
+		marker := "•"
+		if sp.Path == selected {
+			marker = "✅"
+		}
+		sb.WriteString(fmt.Sprintf("%s %s — %s, файлов: %d\n", marker, sp.Path, strings.Join(sp.Manifests, ", "), sp.Files))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
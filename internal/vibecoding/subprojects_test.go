@@ -0,0 +1,103 @@
+package vibecoding
+
+import (
+	"context"
+	"testing"
+
+	"ai-chatter/internal/codevalidation"
+)
+
+func monorepoFiles() map[string]string {
+	return map[string]string{
+		"README.md":                          "# monorepo",
+		"services/api/go.mod":                "module example.com/api",
+		"services/api/main.go":               "package main",
+		"web/package.json":                   `{"name": "web"}`,
+		"web/src/index.js":                   "console.log('hi')",
+		"web/node_modules/left/package.json": `{"name": "left"}`,
+		"ml/requirements.txt":                "numpy",
+		"ml/pyproject.toml":                  "[project]",
+		"ml/train.py":                        "import numpy",
+	}
+}
+
+func TestDetectSubprojects(t *testing.T) {
+	subprojects := DetectSubprojects(monorepoFiles())
+
+	if len(subprojects) != 3 {
+		t.Fatalf("expected 3 subprojects, got %d: %+v", len(subprojects), subprojects)
+	}
+	want := []struct {
+		path      string
+		manifests int
+		files     int
+	}{
+		{"ml", 2, 3},
+		{"services/api", 1, 2},
+		{"web", 1, 3},
+	}
+	for i, w := range want {
+		sp := subprojects[i]
+		if sp.Path != w.path || len(sp.Manifests) != w.manifests || sp.Files != w.files {
+			t.Errorf("subproject %d = %+v, want path=%s manifests=%d files=%d", i, sp, w.path, w.manifests, w.files)
+		}
+	}
+
+	single := DetectSubprojects(map[string]string{"go.mod": "module x", "main.go": "package main", "internal/a.go": "package internal"})
+	if len(single) != 1 || single[0].Path != "." {
+		t.Errorf("expected root subproject only, got %+v", single)
+	}
+}
+
+func TestVibeCodingSession_SelectSubproject(t *testing.T) {
+	sm := NewSessionManagerWithoutWebServer()
+	session, err := sm.CreateSession(1, 1, "mono", monorepoFiles(), nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	session.Docker = NewDockerAdapter(codevalidation.NewMockDockerClient())
+
+	if !session.NeedsSubprojectSelection() {
+		t.Fatal("expected monorepo to require subproject selection")
+	}
+	if err := session.SelectSubproject(context.Background(), "unknown"); err == nil {
+		t.Error("expected error for unknown subproject")
+	}
+
+	if err := session.SelectSubproject(context.Background(), "services/api/"); err != nil {
+		t.Fatalf("SelectSubproject: %v", err)
+	}
+	if session.Subproject != "services/api" {
+		t.Errorf("Subproject = %q", session.Subproject)
+	}
+	if len(session.Files) != 2 || session.Files["main.go"] != "package main" {
+		t.Errorf("expected files relative to subproject, got %v", session.Files)
+	}
+
+	// Имитируем настроенную сессию с изменениями в подпроекте
+	session.Analysis = &codevalidation.CodeAnalysisResult{Language: "Go"}
+	session.Files["main.go"] = "package main // changed"
+	session.AddGeneratedFile("main_test.go", "package main")
+
+	result := session.ResultFiles()
+	if result["services/api/main.go"] != "package main // changed" {
+		t.Errorf("changed file not merged back: %q", result["services/api/main.go"])
+	}
+	if _, ok := result["services/api/main_test.go"]; !ok {
+		t.Error("generated file missing from result archive")
+	}
+	if result["web/src/index.js"] == "" || result["README.md"] == "" {
+		t.Error("files outside the subproject must stay in the result archive")
+	}
+
+	// Смена подпроекта сохраняет изменения предыдущего
+	if err := session.SelectSubproject(context.Background(), "."); err != nil {
+		t.Fatalf("SelectSubproject(.): %v", err)
+	}
+	if session.Files["services/api/main_test.go"] != "package main" {
+		t.Error("whole-tree selection should include files generated in the previous subproject")
+	}
+	if session.Analysis != nil || len(session.GeneratedFiles) != 0 {
+		t.Error("selection must reset analysis and generated files")
+	}
+}
@@ -0,0 +1,309 @@
+package vibecoding
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"ai-chatter/internal/llm"
+	"ai-chatter/internal/llm/jsonextract"
+)
+
+// TestFailureKind тип падения тестов
+type TestFailureKind string
+
+const (
+	FailureSyntaxError       TestFailureKind = "syntax_error"
+	FailureMissingDependency TestFailureKind = "missing_dependency"
+	FailureAssertion         TestFailureKind = "assertion_failure"
+	FailureInvalidReference  TestFailureKind = "invalid_reference"
+	FailureEnvironment       TestFailureKind = "environment_error"
+)
+
+// Источник классификации
+const (
+	classifiedByRunner  = "runner"
+	classifiedByLLM     = "llm"
+	classifiedByDefault = "default"
+)
+
+// NeedsEnvironmentFix true, если чинить нужно окружение (зависимости, запуск), а не код тестов
+func (k TestFailureKind) NeedsEnvironmentFix() bool {
+	return k == FailureMissingDependency || k == FailureEnvironment
+}
+
+func parseTestFailureKind(s string) (TestFailureKind, bool) {
+	switch k := TestFailureKind(strings.ToLower(strings.TrimSpace(s))); k {
+	case FailureSyntaxError, FailureMissingDependency, FailureAssertion, FailureInvalidReference, FailureEnvironment:
+		return k, true
+	}
+	return "", false
+}
+
+// TestFailureClassification результат классификации падения тестов
+type TestFailureClassification struct {
+	Kind      TestFailureKind `json:"kind"`
+	Framework string          `json:"framework,omitempty"` // pytest, go, jest или пусто
+	Source    string          `json:"source"`              // runner, llm или default
+	Reason    string          `json:"reason,omitempty"`
+}
+
+// TestFailureClassifier определяет тип падения тестов: сначала по форматам отчётов pytest/go test/jest
+// и кодам выхода, затем одним запросом к LLM
+type TestFailureClassifier struct {
+	llmClient llm.Client
+}
+
+// NewTestFailureClassifier создает классификатор; без LLM клиента неопознанные падения считаются assertion_failure
+func NewTestFailureClassifier(llmClient llm.Client) *TestFailureClassifier {
+	return &TestFailureClassifier{llmClient: llmClient}
+}
+
+// Classify классифицирует неуспешный запуск тестов
+func (c *TestFailureClassifier) Classify(ctx context.Context, command string, exitCode int, output string) TestFailureClassification {
+	if result, ok := classifyByRunner(command, exitCode, output); ok {
+		log.Printf("🧭 Test failure classified by %s output: %s (%s)", frameworkOrShell(result.Framework), result.Kind, result.Reason)
+		return result
+	}
+	if c != nil && c.llmClient != nil {
+		result, err := c.classifyWithLLM(ctx, command, exitCode, output)
+		if err == nil {
+			log.Printf("🧭 Test failure classified by LLM: %s (%s)", result.Kind, result.Reason)
+			return result
+		}
+		log.Printf("⚠️ LLM test failure classification failed: %v", err)
+	}
+	return TestFailureClassification{
+		Kind:   FailureAssertion,
+		Source: classifiedByDefault,
+		Reason: fmt.Sprintf("unrecognized failure with exit code %d", exitCode),
+	}
+}
+
+func frameworkOrShell(framework string) string {
+	if framework == "" {
+		return "shell"
+	}
+	return framework
+}
+
+// classifyByRunner распознаёт падение по соглашениям конкретного раннера
+func classifyByRunner(command string, exitCode int, output string) (TestFailureClassification, bool) {
+	// Коды оболочки: 127 — команда не найдена, 126 — не исполняемая
+	if exitCode == 126 || exitCode == 127 {
+		return TestFailureClassification{Kind: FailureEnvironment, Source: classifiedByRunner,
+			Reason: fmt.Sprintf("shell exit code %d: test command is not runnable", exitCode)}, true
+	}
+
+	framework := detectTestFramework(command, output)
+	var (
+		kind   TestFailureKind
+		reason string
+		ok     bool
+	)
+	switch framework {
+	case "pytest":
+		kind, reason, ok = classifyPytest(exitCode, output)
+	case "go":
+		kind, reason, ok = classifyGoTest(output)
+	case "jest":
+		kind, reason, ok = classifyJest(output)
+	}
+	if !ok {
+		return TestFailureClassification{}, false
+	}
+	return TestFailureClassification{Kind: kind, Framework: framework, Source: classifiedByRunner, Reason: reason}, true
+}
+
+func detectTestFramework(command, output string) string {
+	cmd := strings.ToLower(command)
+	switch {
+	case strings.Contains(cmd, "pytest"),
+		strings.Contains(output, "test session starts"),
+		strings.Contains(output, "short test summary info"):
+		return "pytest"
+	case strings.Contains(cmd, "go test"),
+		strings.Contains(output, "--- FAIL:"),
+		goPackageResultRe.MatchString(output):
+		return "go"
+	case strings.Contains(cmd, "jest"),
+		strings.Contains(cmd, "npm test"), strings.Contains(cmd, "npm run test"),
+		strings.Contains(cmd, "yarn test"), strings.Contains(cmd, "pnpm test"),
+		strings.Contains(output, "Test Suites:"):
+		return "jest"
+	}
+	return ""
+}
+
+var (
+	// pytestErrorLineRe строка исключения в отчёте pytest: "E   ModuleNotFoundError: No module named 'x'"
+	pytestErrorLineRe = regexp.MustCompile(`(?m)^E\s+(\w+(?:Error|Exception))\b:?(.*)$`)
+	// pythonSummaryRe итоговая строка short test summary: "FAILED test_x.py::test_y - NameError: ..."
+	pythonSummaryRe = regexp.MustCompile(`(?m)^(?:FAILED|ERROR) \S+(?: - (\w+Error)\b)?`)
+
+	goPackageResultRe = regexp.MustCompile(`(?m)^(?:FAIL|ok)\s+\S+\s+(?:[0-9.]+s|\[build failed\]|\[setup failed\]|\(cached\))`)
+	goCompileErrorRe  = regexp.MustCompile(`(?m)^\S+\.go:\d+(?::\d+)?: (.+)$`)
+)
+
+// classifyPytest использует коды выхода pytest (1 — упали тесты, 2 — прервано/ошибки сбора,
+// 3 — внутренняя ошибка, 4 — ошибка запуска) и имена исключений из отчёта
+func classifyPytest(exitCode int, output string) (TestFailureKind, string, bool) {
+	switch exitCode {
+	case 3:
+		return FailureEnvironment, "pytest internal error (exit code 3)", true
+	case 4:
+		return FailureEnvironment, "pytest usage error (exit code 4)", true
+	}
+
+	var exceptions []string
+	for _, m := range pytestErrorLineRe.FindAllStringSubmatch(output, -1) {
+		exceptions = append(exceptions, m[1]+":"+strings.TrimSpace(m[2]))
+	}
+	for _, m := range pythonSummaryRe.FindAllStringSubmatch(output, -1) {
+		if m[1] != "" {
+			exceptions = append(exceptions, m[1]+":")
+		}
+	}
+	// Ошибки сбора важнее провалов отдельных тестов: из-за них тесты не запускались вовсе
+	for _, priority := range []TestFailureKind{FailureSyntaxError, FailureMissingDependency, FailureInvalidReference, FailureAssertion} {
+		for _, e := range exceptions {
+			name, message, _ := strings.Cut(e, ":")
+			if pythonExceptionKind(name, message) == priority {
+				return priority, "pytest reported " + name, true
+			}
+		}
+	}
+
+	if exitCode == 1 && strings.Contains(output, "FAILED") {
+		return FailureAssertion, "pytest reported failed tests", true
+	}
+	return "", "", false
+}
+
+func pythonExceptionKind(name, message string) TestFailureKind {
+	switch name {
+	case "SyntaxError", "IndentationError", "TabError":
+		return FailureSyntaxError
+	case "ModuleNotFoundError":
+		return FailureMissingDependency
+	case "ImportError":
+		// "cannot import name" — модуль есть, но в нём нет такого имени
+		if strings.Contains(message, "cannot import name") {
+			return FailureInvalidReference
+		}
+		return FailureMissingDependency
+	case "NameError", "AttributeError", "UnboundLocalError":
+		return FailureInvalidReference
+	case "AssertionError":
+		return FailureAssertion
+	}
+	return ""
+}
+
+// classifyGoTest разбирает ошибки компиляции пакета ([build failed]/[setup failed]) и "--- FAIL"
+func classifyGoTest(output string) (TestFailureKind, string, bool) {
+	for _, m := range goCompileErrorRe.FindAllStringSubmatch(output, -1) {
+		msg := m[1]
+		switch {
+		case strings.HasPrefix(msg, "syntax error"):
+			return FailureSyntaxError, "go compiler: " + msg, true
+		case strings.HasPrefix(msg, "undefined:"),
+			strings.Contains(msg, "has no field or method"),
+			strings.Contains(msg, "not declared by package"),
+			strings.Contains(msg, "undefined (type"):
+			return FailureInvalidReference, "go compiler: " + msg, true
+		case strings.Contains(msg, "no required module provides package"),
+			strings.Contains(msg, "missing go.sum entry"),
+			strings.Contains(msg, "cannot find package"):
+			return FailureMissingDependency, "go: " + msg, true
+		}
+	}
+	switch {
+	case strings.Contains(output, "no required module provides package"),
+		strings.Contains(output, "missing go.sum entry"),
+		strings.Contains(output, "cannot find module providing package"):
+		return FailureMissingDependency, "go reported a missing module", true
+	case strings.Contains(output, "[setup failed]"):
+		return FailureEnvironment, "go test setup failed", true
+	case strings.Contains(output, "--- FAIL:"), strings.Contains(output, "panic: "):
+		return FailureAssertion, "go test reported failed tests", true
+	}
+	return "", "", false
+}
+
+var (
+	jestMissingModuleRe = regexp.MustCompile(`Cannot find module '([^']+)'`)
+	jestReferenceRe     = regexp.MustCompile(`(?m)(ReferenceError: .+ is not defined|TypeError: .+ is not a (?:function|constructor))`)
+)
+
+// classifyJest разбирает "Test suite failed to run", ошибки npm и провалы expect
+func classifyJest(output string) (TestFailureKind, string, bool) {
+	switch {
+	case strings.Contains(output, "Missing script:"), strings.Contains(output, "missing script:"):
+		return FailureEnvironment, "npm: test script is missing", true
+	case strings.Contains(output, "jest: not found"), strings.Contains(output, "'jest' is not recognized"):
+		return FailureMissingDependency, "jest is not installed", true
+	}
+	if m := jestMissingModuleRe.FindStringSubmatch(output); m != nil {
+		// Относительный путь — ошибка ссылки в тесте, имя пакета — неустановленная зависимость
+		if strings.HasPrefix(m[1], ".") || strings.HasPrefix(m[1], "/") {
+			return FailureInvalidReference, "jest: cannot find module " + m[1], true
+		}
+		return FailureMissingDependency, "jest: cannot find module " + m[1], true
+	}
+	if strings.Contains(output, "Test suite failed to run") && strings.Contains(output, "SyntaxError") {
+		return FailureSyntaxError, "jest: test suite failed to run (SyntaxError)", true
+	}
+	if m := jestReferenceRe.FindStringSubmatch(output); m != nil {
+		return FailureInvalidReference, "jest: " + m[1], true
+	}
+	if strings.Contains(output, "expect(") || (strings.Contains(output, "Expected:") && strings.Contains(output, "Received:")) {
+		return FailureAssertion, "jest reported failed expectations", true
+	}
+	return "", "", false
+}
+
+// maxClassifierOutput сколько символов вывода отправлять в LLM (хвост содержит итоги раннера)
+const maxClassifierOutput = 6000
+
+func (c *TestFailureClassifier) classifyWithLLM(ctx context.Context, command string, exitCode int, output string) (TestFailureClassification, error) {
+	systemPrompt := `You classify failed test runs. Respond with a JSON object matching this exact schema:
+{
+  "type": "syntax_error|missing_dependency|assertion_failure|invalid_reference|environment_error",
+  "reason": "brief explanation"
+}
+
+- syntax_error: code or tests do not parse/compile
+- missing_dependency: a package or module is not installed
+- assertion_failure: tests ran and some expectations failed
+- invalid_reference: tests reference names, functions, fields or files that do not exist
+- environment_error: the test runner or environment is misconfigured`
+
+	if len(output) > maxClassifierOutput {
+		output = "...\n" + output[len(output)-maxClassifierOutput:]
+	}
+	userPrompt := fmt.Sprintf("COMMAND: %s\nEXIT CODE: %d\nOUTPUT:\n%s", command, exitCode, output)
+
+	resp, err := llm.GenerateWithOptions(ctx, c.llmClient, []llm.Message{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: userPrompt},
+	}, llm.OptionsForTask(llm.TaskClassification))
+	if err != nil {
+		return TestFailureClassification{}, err
+	}
+
+	var parsed struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	}
+	if err := jsonextract.Decode(resp.Content, &parsed, jsonextract.Options{Repair: true}); err != nil {
+		return TestFailureClassification{}, fmt.Errorf("failed to parse classification: %w", err)
+	}
+	kind, ok := parseTestFailureKind(parsed.Type)
+	if !ok {
+		return TestFailureClassification{}, fmt.Errorf("unknown failure type %q", parsed.Type)
+	}
+	return TestFailureClassification{Kind: kind, Source: classifiedByLLM, Reason: parsed.Reason}, nil
+}
@@ -0,0 +1,307 @@
+package vibecoding
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ai-chatter/internal/llm"
+)
+
+const pytestCollectionModuleError = `============================= test session starts ==============================
+platform linux -- Python 3.11.6, pytest-7.4.3, pluggy-1.3.0
+rootdir: /app
+collected 0 items / 1 error
+
+==================================== ERRORS ====================================
+_______________________ ERROR collecting test_client.py ________________________
+ImportError while importing test module '/app/test_client.py'.
+Hint: make sure your test modules/packages have valid Python names.
+Traceback:
+/usr/local/lib/python3.11/importlib/__init__.py:126: in import_module
+    return _bootstrap._gcd_import(name[level:], package, level)
+test_client.py:1: in <module>
+    import requests
+E   ModuleNotFoundError: No module named 'requests'
+=========================== short test summary info ============================
+ERROR test_client.py
+!!!!!!!!!!!!!!!!!!!! Interrupted: 1 error during collection !!!!!!!!!!!!!!!!!!!!
+=============================== 1 error in 0.12s ===============================`
+
+const pytestSyntaxError = `============================= test session starts ==============================
+collected 0 items / 1 error
+
+==================================== ERRORS ====================================
+_________________________ ERROR collecting test_calc.py _________________________
+/usr/local/lib/python3.11/site-packages/_pytest/python.py:617: in _importtestmodule
+    mod = import_path(self.path, mode=importmode, root=self.config.rootpath)
+E     File "/app/test_calc.py", line 3
+E       def test_add(
+E                   ^
+E   SyntaxError: '(' was never closed
+=========================== short test summary info ============================
+ERROR test_calc.py
+!!!!!!!!!!!!!!!!!!!! Interrupted: 1 error during collection !!!!!!!!!!!!!!!!!!!!
+=============================== 1 error in 0.05s ===============================`
+
+const pytestCannotImportName = `============================= test session starts ==============================
+collected 0 items / 1 error
+
+==================================== ERRORS ====================================
+_________________________ ERROR collecting test_calc.py _________________________
+test_calc.py:1: in <module>
+    from calc import add, multiply
+E   ImportError: cannot import name 'multiply' from 'calc' (/app/calc.py)
+=========================== short test summary info ============================
+ERROR test_calc.py
+!!!!!!!!!!!!!!!!!!!! Interrupted: 1 error during collection !!!!!!!!!!!!!!!!!!!!
+=============================== 1 error in 0.04s ===============================`
+
+const pytestAssertionFailure = `============================= test session starts ==============================
+collected 2 items
+
+test_calc.py .F                                                          [100%]
+
+=================================== FAILURES ===================================
+___________________________________ test_sub ___________________________________
+
+    def test_sub():
+>       assert sub(5, 3) == 3
+E       assert 2 == 3
+E        +  where 2 = sub(5, 3)
+
+test_calc.py:8: AssertionError
+=========================== short test summary info ============================
+FAILED test_calc.py::test_sub - assert 2 == 3
+========================= 1 failed, 1 passed in 0.03s ==========================`
+
+const pytestAttributeError = `============================= test session starts ==============================
+collected 1 item
+
+test_calc.py F                                                           [100%]
+
+=================================== FAILURES ===================================
+__________________________________ test_calc ___________________________________
+
+    def test_calc():
+>       assert calc.power(2, 3) == 8
+E       AttributeError: module 'calc' has no attribute 'power'
+
+test_calc.py:4: AttributeError
+=========================== short test summary info ============================
+FAILED test_calc.py::test_calc - AttributeError: module 'calc' has no attribute 'power'
+============================== 1 failed in 0.02s ===============================`
+
+const goTestUndefined = `# example.com/calc [example.com/calc.test]
+./calc_test.go:9:7: undefined: Multiply
+FAIL	example.com/calc [build failed]
+FAIL`
+
+const goTestSyntaxError = `# example.com/calc [example.com/calc.test]
+./calc_test.go:12:1: syntax error: unexpected }, expected expression
+FAIL	example.com/calc [build failed]
+FAIL`
+
+const goTestMissingModule = `calc_test.go:6:2: no required module provides package github.com/stretchr/testify/assert; to add it:
+	go get github.com/stretchr/testify/assert
+FAIL	example.com/calc [setup failed]
+FAIL`
+
+const goTestAssertion = `--- FAIL: TestAdd (0.00s)
+    calc_test.go:10: Add(2, 2) = 5, want 4
+FAIL
+exit status 1
+FAIL	example.com/calc	0.002s
+FAIL`
+
+const jestAssertion = `> app@1.0.0 test
+> jest
+
+ FAIL  ./sum.test.js
+  ● adds 1 + 2 to equal 3
+
+    expect(received).toBe(expected) // Object.is equality
+
+    Expected: 3
+    Received: 4
+
+      2 |
+      3 | test('adds 1 + 2 to equal 3', () => {
+    > 4 |   expect(sum(1, 2)).toBe(3);
+        |                     ^
+
+Test Suites: 1 failed, 1 total
+Tests:       1 failed, 1 total`
+
+const jestMissingPackage = `> app@1.0.0 test
+> jest
+
+ FAIL  ./api.test.js
+  ● Test suite failed to run
+
+    Cannot find module 'supertest' from 'api.test.js'
+
+    > 1 | const request = require('supertest');
+        |                 ^
+
+Test Suites: 1 failed, 1 total
+Tests:       0 total`
+
+const jestMissingLocalModule = ` FAIL  ./sum.test.js
+  ● Test suite failed to run
+
+    Cannot find module './summ' from 'sum.test.js'
+
+Test Suites: 1 failed, 1 total`
+
+const jestSyntaxError = ` FAIL  ./sum.test.js
+  ● Test suite failed to run
+
+    Jest encountered an unexpected token
+
+    SyntaxError: /app/sum.test.js: Unexpected token (4:2)
+
+Test Suites: 1 failed, 1 total`
+
+const jestReferenceError = ` FAIL  ./sum.test.js
+  ● sums numbers
+
+    ReferenceError: summ is not defined
+
+      3 | test('sums numbers', () => {
+    > 4 |   expect(summ(1, 2)).toBe(3);
+
+Test Suites: 1 failed, 1 total`
+
+const npmMissingScript = `npm ERR! Missing script: "test"
+npm ERR!
+npm ERR! To see a list of scripts, run:
+npm ERR!   npm run`
+
+const jestNotInstalled = `> app@1.0.0 test
+> jest
+
+sh: 1: jest: not found`
+
+func TestTestFailureClassifier_Runner(t *testing.T) {
+	tests := []struct {
+		name      string
+		command   string
+		exitCode  int
+		output    string
+		kind      TestFailureKind
+		framework string
+	}{
+		{"pytest missing module", "python -m pytest -v", 2, pytestCollectionModuleError, FailureMissingDependency, "pytest"},
+		{"pytest syntax error", "pytest", 2, pytestSyntaxError, FailureSyntaxError, "pytest"},
+		{"pytest cannot import name", "pytest test_calc.py", 2, pytestCannotImportName, FailureInvalidReference, "pytest"},
+		{"pytest assertion", "python -m pytest", 1, pytestAssertionFailure, FailureAssertion, "pytest"},
+		{"pytest attribute error", "python -m pytest", 1, pytestAttributeError, FailureInvalidReference, "pytest"},
+		{"pytest usage error", "pytest --bogus", 4, "ERROR: usage: pytest [options]", FailureEnvironment, "pytest"},
+		{"go undefined", "go test ./...", 1, goTestUndefined, FailureInvalidReference, "go"},
+		{"go syntax error", "go test ./...", 1, goTestSyntaxError, FailureSyntaxError, "go"},
+		{"go missing module", "go test ./...", 1, goTestMissingModule, FailureMissingDependency, "go"},
+		{"go assertion", "go test -v ./...", 1, goTestAssertion, FailureAssertion, "go"},
+		{"jest assertion", "npm test", 1, jestAssertion, FailureAssertion, "jest"},
+		{"jest missing package", "npm test", 1, jestMissingPackage, FailureMissingDependency, "jest"},
+		{"jest missing local module", "npx jest", 1, jestMissingLocalModule, FailureInvalidReference, "jest"},
+		{"jest syntax error", "npx jest", 1, jestSyntaxError, FailureSyntaxError, "jest"},
+		{"jest reference error", "npx jest", 1, jestReferenceError, FailureInvalidReference, "jest"},
+		{"npm missing script", "npm test", 1, npmMissingScript, FailureEnvironment, "jest"},
+		{"jest not installed", "npm test", 1, jestNotInstalled, FailureMissingDependency, "jest"},
+		{"command not found", "cargo test", 127, "sh: 1: cargo: not found", FailureEnvironment, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llmClient := &classifierLLM{err: errors.New("must not be called")}
+			got := NewTestFailureClassifier(llmClient).Classify(context.Background(), tt.command, tt.exitCode, tt.output)
+			if got.Kind != tt.kind {
+				t.Errorf("kind = %s, want %s (reason: %s)", got.Kind, tt.kind, got.Reason)
+			}
+			if got.Framework != tt.framework {
+				t.Errorf("framework = %q, want %q", got.Framework, tt.framework)
+			}
+			if got.Source != classifiedByRunner {
+				t.Errorf("source = %s, want %s", got.Source, classifiedByRunner)
+			}
+			if llmClient.calls != 0 {
+				t.Errorf("LLM called %d times for a structured output", llmClient.calls)
+			}
+		})
+	}
+}
+
+const cargoTestFailure = `   Compiling calc v0.1.0 (/app)
+error[E0425]: cannot find function ` + "`multiply`" + ` in this scope
+ --> src/lib.rs:12:9
+error: could not compile ` + "`calc`" + ` (lib test) due to previous error`
+
+func TestTestFailureClassifier_LLMFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		err      error
+		kind     TestFailureKind
+		source   string
+	}{
+		{"typed answer", `{"type": "invalid_reference", "reason": "multiply is not defined"}`, nil, FailureInvalidReference, classifiedByLLM},
+		{"fenced answer", "```json\n{\"type\": \"missing_dependency\", \"reason\": \"crate missing\"}\n```", nil, FailureMissingDependency, classifiedByLLM},
+		{"unknown type", `{"type": "flaky", "reason": "?"}`, nil, FailureAssertion, classifiedByDefault},
+		{"invalid json", "not json", nil, FailureAssertion, classifiedByDefault},
+		{"llm error", "", errors.New("timeout"), FailureAssertion, classifiedByDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llmClient := &classifierLLM{content: tt.response, err: tt.err}
+			got := NewTestFailureClassifier(llmClient).Classify(context.Background(), "cargo test", 101, cargoTestFailure)
+			if got.Kind != tt.kind || got.Source != tt.source {
+				t.Errorf("got %s from %s, want %s from %s", got.Kind, got.Source, tt.kind, tt.source)
+			}
+			if llmClient.calls != 1 {
+				t.Errorf("expected exactly one LLM call, got %d", llmClient.calls)
+			}
+			if llmClient.opts.Temperature == nil || *llmClient.opts.Temperature != 0 {
+				t.Errorf("classification must run with temperature 0, got %+v", llmClient.opts)
+			}
+		})
+	}
+}
+
+func TestTestFailureKind_NeedsEnvironmentFix(t *testing.T) {
+	for kind, want := range map[TestFailureKind]bool{
+		FailureSyntaxError:       false,
+		FailureMissingDependency: true,
+		FailureAssertion:         false,
+		FailureInvalidReference:  false,
+		FailureEnvironment:       true,
+	} {
+		if got := kind.NeedsEnvironmentFix(); got != want {
+			t.Errorf("%s.NeedsEnvironmentFix() = %v, want %v", kind, got, want)
+		}
+	}
+}
+
+// classifierLLM отвечает фиксированным текстом и считает вызовы
+type classifierLLM struct {
+	llm.NoStreaming
+	llm.LocalTokenCounter
+	content string
+	err     error
+	calls   int
+	opts    llm.GenerateOptions
+}
+
+func (c *classifierLLM) Generate(ctx context.Context, messages []llm.Message) (llm.Response, error) {
+	c.calls++
+	c.opts = llm.OptionsFromContext(ctx)
+	if c.err != nil {
+		return llm.Response{}, c.err
+	}
+	return llm.Response{Content: c.content}, nil
+}
+
+func (c *classifierLLM) GenerateWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (llm.Response, error) {
+	return c.Generate(ctx, messages)
+}
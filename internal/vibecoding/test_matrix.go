@@ -0,0 +1,340 @@
+package vibecoding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-chatter/internal/codevalidation"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	defaultMatrixParallelism     = 2
+	defaultMatrixContainerBudget = 10
+	// maxMatrixRuns сколько последних прогонов матрицы хранится для отчёта сессии
+	maxMatrixRuns = 5
+)
+
+// ErrMatrixBudgetExceeded прогон матрицы превысил бюджет дополнительных контейнеров сессии
+var ErrMatrixBudgetExceeded = errors.New("matrix container budget exceeded")
+
+// defaultMatrixImages матрица по умолчанию для языков, у которых часто расходятся версии
+var defaultMatrixImages = map[string][]string{
+	"python":     {"python:3.9-slim", "python:3.11-slim", "python:3.12-slim"},
+	"go":         {"golang:1.21", "golang:1.22"},
+	"javascript": {"node:18", "node:20", "node:22"},
+	"typescript": {"node:18", "node:20", "node:22"},
+}
+
+// matrixImageTemplates образы для коротких версий ("3.9", "1.22") в /vibecoding_matrix и .vibecoding.yml
+var matrixImageTemplates = map[string]string{
+	"python":     "python:%s-slim",
+	"go":         "golang:%s",
+	"javascript": "node:%s",
+	"typescript": "node:%s",
+}
+
+// MatrixCell результат тестов в одной версии среды
+type MatrixCell struct {
+	Image    string        `json:"image"`
+	Status   GateStatus    `json:"status"`             // pass — тесты прошли, fail — упали, warn — окружение не поднялось
+	Summary  string        `json:"summary"`            // краткий итог тестов или ошибка окружения
+	Output   string        `json:"output,omitempty"`   // начало вывода первой ошибки
+	Duration time.Duration `json:"duration,omitempty"` // время прогона ячейки с созданием контейнера
+}
+
+// MatrixRun прогон тестов по матрице версий
+type MatrixRun struct {
+	TestCommand string       `json:"test_command"`
+	StartedAt   time.Time    `json:"started_at"`
+	Cells       []MatrixCell `json:"cells"`
+}
+
+func (c VibeCodingConfig) matrixParallelism() int {
+	if c.MatrixParallelism > 0 {
+		return c.MatrixParallelism
+	}
+	return defaultMatrixParallelism
+}
+
+func (c VibeCodingConfig) matrixContainerBudget() int {
+	if c.MatrixContainerBudget > 0 {
+		return c.MatrixContainerBudget
+	}
+	return defaultMatrixContainerBudget
+}
+
+// ParseMatrixConfig разбирает секцию matrix из .vibecoding.yml — список образов или версий:
+//
+//	matrix:
+//	  - python:3.9-slim
+//	  - "3.12"
+func ParseMatrixConfig(content string) []string {
+	var entries []string
+	inSection := false
+	for _, rawLine := range strings.Split(content, "\n") {
+		line := strings.TrimRight(rawLine, " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, "-") {
+			inSection = strings.HasPrefix(trimmed, "matrix:")
+			continue
+		}
+		if inSection && strings.HasPrefix(trimmed, "-") {
+			if entry := unquoteYAML(strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))); entry != "" {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+// resolveMatrixImages образы для прогона: аргументы команды, затем .vibecoding.yml, затем матрица
+// по умолчанию для языка проекта. Короткие версии превращаются в образ языка
+func resolveMatrixImages(language string, args []string, files map[string]string) ([]string, error) {
+	entries := args
+	if len(entries) == 0 {
+		for _, name := range validationConfigFiles {
+			if content, ok := files[name]; ok {
+				entries = ParseMatrixConfig(content)
+				break
+			}
+		}
+	}
+	lang := strings.ToLower(language)
+	if len(entries) == 0 {
+		entries = defaultMatrixImages[lang]
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("для языка %s нет матрицы по умолчанию: укажите образы в команде или в секции matrix .vibecoding.yml", language)
+	}
+
+	images := make([]string, 0, len(entries))
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		image := entry
+		if !strings.ContainsAny(entry, ":/") {
+			template, ok := matrixImageTemplates[lang]
+			if !ok {
+				return nil, fmt.Errorf("версию %q нельзя сопоставить с образом для языка %s, укажите образ целиком", entry, language)
+			}
+			image = fmt.Sprintf(template, entry)
+		}
+		if !seen[image] {
+			seen[image] = true
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// reserveMatrixContainers списывает n дополнительных контейнеров из бюджета сессии
+func (s *VibeCodingSession) reserveMatrixContainers(n int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	budget := s.Config.matrixContainerBudget()
+	if s.matrixContainers+n > budget {
+		return fmt.Errorf("%w: нужно %d, осталось %d из %d", ErrMatrixBudgetExceeded, n, budget-s.matrixContainers, budget)
+	}
+	s.matrixContainers += n
+	return nil
+}
+
+// MatrixContainersUsed сколько дополнительных контейнеров сессия уже потратила на матрицу
+func (s *VibeCodingSession) MatrixContainersUsed() (used, budget int) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.matrixContainers, s.Config.matrixContainerBudget()
+}
+
+func (s *VibeCodingSession) recordMatrixRun(run MatrixRun) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.matrixRuns = append(s.matrixRuns, run)
+	if len(s.matrixRuns) > maxMatrixRuns {
+		s.matrixRuns = s.matrixRuns[len(s.matrixRuns)-maxMatrixRuns:]
+	}
+}
+
+// LastMatrixRun последний прогон матрицы для отчёта сессии
+func (s *VibeCodingSession) LastMatrixRun() (MatrixRun, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if len(s.matrixRuns) == 0 {
+		return MatrixRun{}, false
+	}
+	return s.matrixRuns[len(s.matrixRuns)-1], true
+}
+
+// RunTestMatrix запускает тесты сессии в отдельных короткоживущих контейнерах — по одному на образ —
+// с теми же файлами проекта. Одновременно работает не больше MatrixParallelism контейнеров,
+// каждый списывается из бюджета MatrixContainerBudget сессии
+func (s *VibeCodingSession) RunTestMatrix(ctx context.Context, images []string) (MatrixRun, error) {
+	s.mutex.RLock()
+	analysis, testCommand, docker := s.Analysis, s.TestCommand, s.Docker
+	parallelism, timeout := s.Config.matrixParallelism(), s.Config.CommandTimeout
+	s.mutex.RUnlock()
+	if analysis == nil || testCommand == "" || docker == nil {
+		return MatrixRun{}, fmt.Errorf("session environment not set up")
+	}
+	if err := s.reserveMatrixContainers(len(images)); err != nil {
+		return MatrixRun{}, err
+	}
+	s.touch()
+	defer s.touch()
+
+	files := s.GetAllFiles()
+	run := MatrixRun{TestCommand: testCommand, StartedAt: time.Now(), Cells: make([]MatrixCell, len(images))}
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, image := range images {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			cellAnalysis := *analysis
+			cellAnalysis.DockerImage = image
+			cellAnalysis.Commands = []string{testCommand}
+			run.Cells[i] = runMatrixCell(ctx, docker, &cellAnalysis, s.containerOwner(), files, timeout)
+		}()
+	}
+	wg.Wait()
+
+	for _, cell := range run.Cells {
+		DefaultSessionMetrics.TestRun(testOutcome(cell.Status == GatePass, nil))
+	}
+	s.recordMatrixRun(run)
+	return run, nil
+}
+
+// runMatrixCell создаёт контейнер с образом analysis.DockerImage, копирует файлы, ставит зависимости,
+// запускает тесты и удаляет контейнер
+func runMatrixCell(ctx context.Context, docker *DockerAdapter, analysis *codevalidation.CodeAnalysisResult, owner ContainerOwner, files map[string]string, timeout time.Duration) MatrixCell {
+	started := time.Now()
+	cell := MatrixCell{Image: analysis.DockerImage}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	defer func() { cell.Duration = time.Since(started).Round(time.Second) }()
+
+	log.Printf("🧪 Matrix cell %s: %s", analysis.DockerImage, analysis.Commands[0])
+	containerID, err := docker.CreateContainer(ctx, analysis, owner)
+	if err != nil {
+		cell.Status, cell.Summary = GateWarn, "не удалось создать контейнер: "+err.Error()
+		return cell
+	}
+	defer func() {
+		if err := docker.RemoveContainer(context.Background(), containerID); err != nil {
+			log.Printf("⚠️ Failed to remove matrix container %s: %v", containerID, err)
+		}
+	}()
+
+	if err := docker.CopyFilesToContainer(ctx, containerID, files); err != nil {
+		cell.Status, cell.Summary = GateWarn, "не удалось скопировать файлы: "+err.Error()
+		return cell
+	}
+	if err := docker.InstallDependencies(ctx, containerID, analysis); err != nil {
+		cell.Status, cell.Summary = GateWarn, "не удалось установить зависимости: "+err.Error()
+		return cell
+	}
+	result, err := docker.ExecuteValidation(ctx, containerID, analysis)
+	switch {
+	case err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		cell.Status, cell.Summary = GateFail, fmt.Sprintf("тесты не уложились в %s", timeout)
+	case err != nil:
+		cell.Status, cell.Summary = GateWarn, "не удалось запустить тесты: "+err.Error()
+	case !result.Success:
+		cell.Status = GateFail
+		cell.Summary = FormatTestSummary(result.Output, result.ExitCode, false)
+		cell.Output = truncateText(result.Output, 500)
+	default:
+		cell.Status = GatePass
+		cell.Summary = FormatTestSummary(result.Output, result.ExitCode, true)
+	}
+	return cell
+}
+
+// FormatMatrixRun сетка pass/fail по версиям с началом вывода первой ошибки в каждой ячейке
+func FormatMatrixRun(run MatrixRun) string {
+	passed := 0
+	var sb strings.Builder
+	for _, cell := range run.Cells {
+		if cell.Status == GatePass {
+			passed++
+		}
+		sb.WriteString(fmt.Sprintf("%s %s — %s", cell.Status.Icon(), cell.Image, cell.Summary))
+		if cell.Duration > 0 {
+			sb.WriteString(fmt.Sprintf(" (%s)", cell.Duration))
+		}
+		if cell.Output != "" {
+			sb.WriteString("\n   " + strings.ReplaceAll(truncateText(cell.Output, 300), "\n", "\n   "))
+		}
+		sb.WriteString("\n")
+	}
+	return fmt.Sprintf("[vibecoding] 🧪 Матрица тестов (%s): %d/%d версий прошли\n\n%s", run.TestCommand, passed, len(run.Cells), strings.TrimRight(sb.String(), "\n"))
+}
+
+// matrixGateItem итог последнего прогона матрицы для проверки качества: упавшие версии дают предупреждение
+func matrixGateItem(run MatrixRun) QualityGateItem {
+	var failed []string
+	for _, cell := range run.Cells {
+		if cell.Status != GatePass {
+			failed = append(failed, cell.Image)
+		}
+	}
+	if len(failed) == 0 {
+		return QualityGateItem{Name: "test matrix", Status: GatePass, Details: fmt.Sprintf("все %d версий прошли", len(run.Cells))}
+	}
+	return QualityGateItem{Name: "test matrix", Status: GateWarn, Details: "не прошли: " + strings.Join(failed, ", ")}
+}
+
+// writeMatrixReport добавляет в SESSION_REPORT.md сетку последнего прогона матрицы
+func writeMatrixReport(sb *strings.Builder, session *VibeCodingSession) {
+	run, ok := session.LastMatrixRun()
+	if !ok {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n## Test matrix (`%s`)\n\n", run.TestCommand))
+	sb.WriteString("| Runtime | Status | Details |\n|---|---|---|\n")
+	for _, cell := range run.Cells {
+		details := strings.ReplaceAll(strings.ReplaceAll(cell.Summary, "\n", " "), "|", "\\|")
+		sb.WriteString(fmt.Sprintf("| %s | %s %s | %s |\n", cell.Image, cell.Status.Icon(), cell.Status, details))
+	}
+}
+
+// handleMatrixCommand /vibecoding_matrix [образы или версии...] — тесты в нескольких версиях среды
+func (h *VibeCodingHandler) handleMatrixCommand(ctx context.Context, chatID int64, session *VibeCodingSession, args string) error {
+	if session.TestCommand == "" || session.Analysis == nil {
+		return h.sendMessage(chatID, "[vibecoding] ❌ Команда тестов не определена, матрицу запустить нельзя")
+	}
+	images, err := resolveMatrixImages(session.Analysis.Language, strings.Fields(args), session.GetAllFiles())
+	if err != nil {
+		return h.sendMessage(chatID, "[vibecoding] ❌ "+err.Error())
+	}
+
+	text := fmt.Sprintf("[vibecoding] 🧪 Запуск тестов в %d версиях: %s...", len(images), strings.Join(images, ", "))
+	msg := tgbotapi.NewMessage(chatID, h.formatter.EscapeText(text))
+	msg.ParseMode = h.formatter.ParseModeValue()
+	sentMsg, _ := h.sender.Send(msg)
+
+	run, err := session.RunTestMatrix(ctx, images)
+	if err != nil {
+		if errors.Is(err, ErrMatrixBudgetExceeded) {
+			return h.updateMessage(chatID, sentMsg.MessageID, "[vibecoding] ⛔ Бюджет контейнеров сессии исчерпан: "+err.Error())
+		}
+		h.updateMessage(chatID, sentMsg.MessageID, fmt.Sprintf("[vibecoding] ❌ Ошибка запуска матрицы: %v", err))
+		return err
+	}
+	return h.updateMessage(chatID, sentMsg.MessageID, FormatMatrixRun(run))
+}
@@ -0,0 +1,126 @@
+package vibecoding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ai-chatter/internal/codevalidation"
+)
+
+// matrixDocker падает в образе python:3.9-slim и считает одновременно живущие контейнеры
+type matrixDocker struct {
+	codevalidation.DockerManager
+	mu       sync.Mutex
+	live     int
+	maxLive  int
+	removed  int
+	images   map[string]string
+	nextID   int
+	copied   map[string]bool
+	failWith string
+}
+
+func (d *matrixDocker) CreateContainer(ctx context.Context, analysis *codevalidation.CodeAnalysisResult) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nextID++
+	id := fmt.Sprintf("matrix-%d", d.nextID)
+	d.images[id] = analysis.DockerImage
+	d.live++
+	d.maxLive = max(d.maxLive, d.live)
+	return id, nil
+}
+
+func (d *matrixDocker) CopyFilesToContainer(ctx context.Context, containerID string, files map[string]string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, hasGenerated := files["gen_test.py"]
+	d.copied[containerID] = hasGenerated
+	return nil
+}
+
+func (d *matrixDocker) ExecuteValidation(ctx context.Context, containerID string, analysis *codevalidation.CodeAnalysisResult) (*codevalidation.ValidationResult, error) {
+	time.Sleep(20 * time.Millisecond)
+	if analysis.DockerImage == d.failWith {
+		return &codevalidation.ValidationResult{Success: false, ExitCode: 1, Output: "TypeError: unsupported operand type(s) for |"}, nil
+	}
+	return &codevalidation.ValidationResult{Success: true, Output: "3 passed"}, nil
+}
+
+func (d *matrixDocker) RemoveContainer(ctx context.Context, containerID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.live--
+	d.removed++
+	return nil
+}
+
+func TestRunTestMatrix_BoundedGridAndBudget(t *testing.T) {
+	docker := &matrixDocker{DockerManager: codevalidation.NewMockDockerClient(), images: map[string]string{},
+		copied: map[string]bool{}, failWith: "python:3.9-slim"}
+	session := &VibeCodingSession{
+		ProjectName:    "demo",
+		Files:          map[string]string{"app.py": "x = 1"},
+		GeneratedFiles: map[string]string{"gen_test.py": "def test(): pass"},
+		Analysis:       &codevalidation.CodeAnalysisResult{Language: "Python", DockerImage: "python:3.12-slim"},
+		TestCommand:    "pytest",
+		Docker:         NewDockerAdapter(docker),
+		Config:         VibeCodingConfig{MatrixParallelism: 2, MatrixContainerBudget: 4},
+	}
+
+	images, err := resolveMatrixImages("Python", nil, session.GetAllFiles())
+	if err != nil || len(images) != 3 {
+		t.Fatalf("default python matrix expected, got %v, %v", images, err)
+	}
+	run, err := session.RunTestMatrix(context.Background(), images)
+	if err != nil {
+		t.Fatalf("RunTestMatrix: %v", err)
+	}
+	if docker.maxLive > 2 || docker.removed != 3 {
+		t.Errorf("parallelism must be bounded and containers removed: max live %d, removed %d", docker.maxLive, docker.removed)
+	}
+	for id, ok := range docker.copied {
+		if !ok {
+			t.Errorf("container %s (%s) must get the generated files too", id, docker.images[id])
+		}
+	}
+	if run.Cells[0].Status != GateFail || !strings.Contains(run.Cells[0].Output, "TypeError") || run.Cells[2].Status != GatePass {
+		t.Fatalf("unexpected grid: %+v", run.Cells)
+	}
+	text := FormatMatrixRun(run)
+	if !strings.Contains(text, "2/3 версий прошли") || !strings.Contains(text, "❌ python:3.9-slim") {
+		t.Errorf("unexpected matrix message:\n%s", text)
+	}
+
+	report := FormatSessionReport(session, &QualityGateReport{Skipped: true})
+	if !strings.Contains(report, "## Test matrix (`pytest`)") || !strings.Contains(report, "| python:3.9-slim | ❌ fail |") {
+		t.Errorf("matrix results must be kept for the session report:\n%s", report)
+	}
+
+	if _, err := session.RunTestMatrix(context.Background(), images); !errors.Is(err, ErrMatrixBudgetExceeded) {
+		t.Fatalf("second run must exceed the container budget, got %v", err)
+	}
+	if used, budget := session.MatrixContainersUsed(); used != 3 || budget != 4 {
+		t.Errorf("rejected run must not consume budget: %d/%d", used, budget)
+	}
+}
+
+func TestResolveMatrixImages(t *testing.T) {
+	files := map[string]string{".vibecoding.yml": "validations:\n  lint: ruff check .\nmatrix:\n  - \"3.10\"\n  - python:3.13-rc\n"}
+	images, err := resolveMatrixImages("Python", nil, files)
+	if err != nil || strings.Join(images, ",") != "python:3.10-slim,python:3.13-rc" {
+		t.Fatalf(".vibecoding.yml matrix expected, got %v, %v", images, err)
+	}
+	images, err = resolveMatrixImages("Go", []string{"1.21", "1.21", "golang:1.23"}, files)
+	if err != nil || strings.Join(images, ",") != "golang:1.21,golang:1.23" {
+		t.Fatalf("command arguments take precedence, got %v, %v", images, err)
+	}
+	if _, err := resolveMatrixImages("Rust", nil, nil); err == nil {
+		t.Error("languages without a default matrix must ask for images")
+	}
+}
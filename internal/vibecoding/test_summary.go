@@ -0,0 +1,100 @@
+package vibecoding
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TestCounts количество тестов по итогам, разобранное из вывода тест-раннера
+type TestCounts struct {
+	Runner  string // go, pytest, jest
+	Passed  int
+	Failed  int
+	Skipped int
+}
+
+var (
+	// jestSummaryRe "Tests:       2 failed, 1 skipped, 42 passed, 45 total"
+	jestSummaryRe = regexp.MustCompile(`(?m)^Tests:\s+(.+)$`)
+	// pytestSummaryRe "===== 42 passed, 2 failed, 1 skipped in 0.12s ====="
+	pytestSummaryRe = regexp.MustCompile(`(?m)^=+ (.+?) in [\d.]+s(?: \([^)]*\))? =+\s*$`)
+	// goTestResultRe строки результатов go test -v, включая подтесты
+	goTestResultRe = regexp.MustCompile(`(?m)^\s*--- (PASS|FAIL|SKIP): `)
+	testCountRe    = regexp.MustCompile(`(\d+) (passed|failed|skipped|errors?|xfailed|xpassed|todo)\b`)
+)
+
+// ParseTestCounts извлекает число пройденных, упавших и пропущенных тестов из вывода
+// go test -v, pytest или jest. ok=false, если формат не распознан
+func ParseTestCounts(output string) (TestCounts, bool) {
+	if m := jestSummaryRe.FindAllStringSubmatch(output, -1); m != nil {
+		if counts, ok := parseCountList(m[len(m)-1][1]); ok {
+			counts.Runner = "jest"
+			return counts, true
+		}
+	}
+	if m := pytestSummaryRe.FindAllStringSubmatch(output, -1); m != nil {
+		if counts, ok := parseCountList(m[len(m)-1][1]); ok {
+			counts.Runner = "pytest"
+			return counts, true
+		}
+	}
+	if m := goTestResultRe.FindAllStringSubmatch(output, -1); m != nil {
+		counts := TestCounts{Runner: "go"}
+		for _, match := range m {
+			switch match[1] {
+			case "PASS":
+				counts.Passed++
+			case "FAIL":
+				counts.Failed++
+			case "SKIP":
+				counts.Skipped++
+			}
+		}
+		return counts, true
+	}
+	return TestCounts{}, false
+}
+
+// parseCountList разбирает перечисление вида "42 passed, 2 failed, 1 skipped"
+func parseCountList(s string) (TestCounts, bool) {
+	var counts TestCounts
+	found := false
+	for _, m := range testCountRe.FindAllStringSubmatch(s, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		found = true
+		switch m[2] {
+		case "passed", "xfailed":
+			counts.Passed += n
+		case "failed", "error", "errors", "xpassed":
+			counts.Failed += n
+		case "skipped", "todo":
+			counts.Skipped += n
+		}
+	}
+	return counts, found
+}
+
+// FormatTestSummary строка-сводка над выводом тестов: "✅ 42 passed, ❌ 2 failed, 1 skipped".
+// Если формат вывода не распознан, показывается только результат по коду выхода
+func FormatTestSummary(output string, exitCode int, success bool) string {
+	counts, ok := ParseTestCounts(output)
+	if !ok {
+		if success {
+			return fmt.Sprintf("✅ Тесты прошли (код выхода %d)", exitCode)
+		}
+		return fmt.Sprintf("❌ Тесты не прошли (код выхода %d)", exitCode)
+	}
+	parts := []string{fmt.Sprintf("✅ %d passed", counts.Passed)}
+	if counts.Failed > 0 {
+		parts = append(parts, fmt.Sprintf("❌ %d failed", counts.Failed))
+	}
+	if counts.Skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped", counts.Skipped))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,45 @@
+package vibecoding
+
+import "testing"
+
+func TestParseTestCounts(t *testing.T) {
+	cases := []struct {
+		name   string
+		output string
+		want   TestCounts
+	}{
+		{
+			name:   "go test -v",
+			output: "=== RUN   TestA\n--- PASS: TestA (0.00s)\n=== RUN   TestB\n    --- PASS: TestB/sub (0.00s)\n--- FAIL: TestB (0.00s)\n--- SKIP: TestC (0.00s)\nFAIL\n",
+			want:   TestCounts{Runner: "go", Passed: 2, Failed: 1, Skipped: 1},
+		},
+		{
+			name:   "pytest",
+			output: "test_main.py ..F.s\n=========== 42 passed, 1 failed, 1 error, 1 skipped in 0.12s ===========\n",
+			want:   TestCounts{Runner: "pytest", Passed: 42, Failed: 2, Skipped: 1},
+		},
+		{
+			name:   "jest",
+			output: "Test Suites: 1 failed, 3 passed, 4 total\nTests:       2 failed, 1 skipped, 42 passed, 45 total\nTime:        1.2 s\n",
+			want:   TestCounts{Runner: "jest", Passed: 42, Failed: 2, Skipped: 1},
+		},
+	}
+	for _, c := range cases {
+		got, ok := ParseTestCounts(c.output)
+		if !ok || got != c.want {
+			t.Errorf("%s: ParseTestCounts = %+v, %v; want %+v", c.name, got, ok, c.want)
+		}
+	}
+}
+
+func TestFormatTestSummary(t *testing.T) {
+	if got := FormatTestSummary("=== 42 passed, 2 failed, 1 skipped in 1.00s ===", 1, false); got != "✅ 42 passed, ❌ 2 failed, 1 skipped" {
+		t.Errorf("unexpected summary: %q", got)
+	}
+	if got := FormatTestSummary("ok  \tpkg\t0.1s", 0, true); got != "✅ Тесты прошли (код выхода 0)" {
+		t.Errorf("unrecognized output must fall back to exit code: %q", got)
+	}
+	if got := FormatTestSummary("Segmentation fault", 139, false); got != "❌ Тесты не прошли (код выхода 139)" {
+		t.Errorf("unrecognized output must fall back to exit code: %q", got)
+	}
+}
@@ -0,0 +1,130 @@
+package vibecoding
+
+import (
+	"context"
+	"fmt"
+
+	"ai-chatter/internal/llm"
+)
+
+// sessionUsageKey ключ контекста с сессией, на которую записываются токены LLM запросов
+type sessionUsageKey struct{}
+
+// withSessionUsage привязывает LLM запросы в ctx к сессии для учёта токенов
+func withSessionUsage(ctx context.Context, session *VibeCodingSession) context.Context {
+	if session == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionUsageKey{}, session)
+}
+
+func sessionFromUsageContext(ctx context.Context) *VibeCodingSession {
+	session, _ := ctx.Value(sessionUsageKey{}).(*VibeCodingSession)
+	return session
+}
+
+// AddTokenUsage добавляет токены запроса к счётчикам сессии и пересчитывает оценку стоимости
+func (s *VibeCodingSession) AddTokenUsage(promptTokens, completionTokens int) {
+	if promptTokens <= 0 && completionTokens <= 0 {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.TotalPromptTokens += int64(promptTokens)
+	s.TotalCompletionTokens += int64(completionTokens)
+	previousCost := s.EstimatedCostUSD
+	s.EstimatedCostUSD = s.Config.estimateCostUSD(s.TotalPromptTokens, s.TotalCompletionTokens)
+	DefaultSessionMetrics.LLMUsage(int64(promptTokens), int64(completionTokens), s.EstimatedCostUSD-previousCost)
+}
+
+// TokenUsage токены prompt/completion и оценка стоимости за сессию
+func (s *VibeCodingSession) TokenUsage() (promptTokens, completionTokens int64, costUSD float64) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.TotalPromptTokens, s.TotalCompletionTokens, s.EstimatedCostUSD
+}
+
+// recordModel запоминает модель, ответившую на запрос сессии
+func (s *VibeCodingSession) recordModel(model, fallbackFrom string) {
+	if model == "" {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.activeModel = model
+	s.fallbackFrom = fallbackFrom
+}
+
+// ActiveModel модель последнего ответа LLM и основная модель, если ответила резервная (иначе пусто)
+func (s *VibeCodingSession) ActiveModel() (model, fallbackFrom string) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.activeModel, s.fallbackFrom
+}
+
+// formatFallbackNote подпись под ответом резервной модели; пусто, если ответила основная
+func formatFallbackNote(model, fallbackFrom string) string {
+	if fallbackFrom == "" {
+		return ""
+	}
+	return fmt.Sprintf("↪️ Ответила резервная модель %s (%s недоступна)", model, fallbackFrom)
+}
+
+// estimateCostUSD стоимость по ценам за миллион токенов; 0, если цены не заданы
+func (c VibeCodingConfig) estimateCostUSD(promptTokens, completionTokens int64) float64 {
+	return (float64(promptTokens)*c.PromptPricePerMillion + float64(completionTokens)*c.CompletionPricePerMillion) / 1e6
+}
+
+// formatTokenUsage строка для /vibecoding_info
+func formatTokenUsage(promptTokens, completionTokens int64, costUSD float64) string {
+	line := fmt.Sprintf("🧮 Токены LLM: %d prompt + %d completion = %d", promptTokens, completionTokens, promptTokens+completionTokens)
+	if costUSD > 0 {
+		line += fmt.Sprintf(" (≈ $%.4f)", costUSD)
+	}
+	return line
+}
+
+// usageTrackingClient записывает usage и ответившую модель каждого ответа в сессию из контекста запроса (см. withSessionUsage)
+type usageTrackingClient struct {
+	inner llm.Client
+}
+
+// withUsageTracking оборачивает клиент учётом токенов; повторная обёртка не создаётся
+func withUsageTracking(client llm.Client) llm.Client {
+	if client == nil {
+		return nil
+	}
+	if _, ok := client.(*usageTrackingClient); ok {
+		return client
+	}
+	return &usageTrackingClient{inner: client}
+}
+
+func (c *usageTrackingClient) record(ctx context.Context, resp llm.Response, err error) (llm.Response, error) {
+	if err == nil {
+		if session := sessionFromUsageContext(ctx); session != nil {
+			session.AddTokenUsage(resp.PromptTokens, resp.CompletionTokens)
+			session.recordModel(resp.Model, resp.FallbackFrom)
+		}
+	}
+	return resp, err
+}
+
+func (c *usageTrackingClient) Generate(ctx context.Context, messages []llm.Message) (llm.Response, error) {
+	resp, err := c.inner.Generate(ctx, messages)
+	return c.record(ctx, resp, err)
+}
+
+func (c *usageTrackingClient) GenerateWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool) (llm.Response, error) {
+	resp, err := c.inner.GenerateWithTools(ctx, messages, tools)
+	return c.record(ctx, resp, err)
+}
+
+func (c *usageTrackingClient) GenerateStream(ctx context.Context, messages []llm.Message, onDelta llm.StreamFunc) (llm.Response, error) {
+	resp, err := c.inner.GenerateStream(ctx, messages, onDelta)
+	return c.record(ctx, resp, err)
+}
+
+func (c *usageTrackingClient) CountTokens(messages []llm.Message) (int, error) {
+	return c.inner.CountTokens(messages)
+}
@@ -0,0 +1,85 @@
+package vibecoding
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"ai-chatter/internal/llm"
+)
+
+func TestUsageTrackingClient_AccumulatesPerSession(t *testing.T) {
+	inner := &scriptedToolLLM{replies: []llm.Response{
+		{Content: "a", PromptTokens: 1000, CompletionTokens: 200},
+		{Content: "b", PromptTokens: 500, CompletionTokens: 100},
+		{Content: "c", PromptTokens: 7, CompletionTokens: 7},
+	}}
+	client := withUsageTracking(inner)
+	if withUsageTracking(client) != client {
+		t.Fatal("tracking client must not be wrapped twice")
+	}
+
+	session := &VibeCodingSession{Config: VibeCodingConfig{PromptPricePerMillion: 3, CompletionPricePerMillion: 15}}
+	ctx := withSessionUsage(context.Background(), session)
+	if _, err := client.Generate(ctx, nil); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if _, err := client.GenerateWithTools(ctx, nil, vibeCodingTools()); err != nil {
+		t.Fatalf("GenerateWithTools: %v", err)
+	}
+	// Запрос без сессии в контексте никому не засчитывается
+	if _, err := client.Generate(context.Background(), nil); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	prompt, completion, cost := session.TokenUsage()
+	if prompt != 1500 || completion != 300 {
+		t.Errorf("tokens = %d/%d, want 1500/300", prompt, completion)
+	}
+	if want := (1500*3.0 + 300*15.0) / 1e6; math.Abs(cost-want) > 1e-12 {
+		t.Errorf("cost = %v, want %v", cost, want)
+	}
+	if info := session.GetSessionInfo(); info["prompt_tokens"] != int64(1500) || info["completion_tokens"] != int64(300) {
+		t.Errorf("session info must include token totals: %v", info)
+	}
+}
+
+func TestUsageTrackingClient_RecordsAnsweringModel(t *testing.T) {
+	inner := &scriptedToolLLM{replies: []llm.Response{
+		{Content: "a", Model: "claude-3-5-haiku-latest", FallbackFrom: "qwen/qwen3-coder", PromptTokens: 1},
+		{Content: "b", Model: "qwen/qwen3-coder", PromptTokens: 1},
+	}}
+	client := withUsageTracking(inner)
+	session := &VibeCodingSession{}
+	ctx := withSessionUsage(context.Background(), session)
+
+	if _, err := client.Generate(ctx, nil); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	model, from := session.ActiveModel()
+	if note := formatFallbackNote(model, from); note != "↪️ Ответила резервная модель claude-3-5-haiku-latest (qwen/qwen3-coder недоступна)" {
+		t.Errorf("unexpected fallback note %q", note)
+	}
+	if info := session.GetSessionInfo(); info["active_model"] != "claude-3-5-haiku-latest" || info["fallback_from"] != "qwen/qwen3-coder" {
+		t.Errorf("session info must expose the answering model: %v", info)
+	}
+
+	if _, err := client.Generate(ctx, nil); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if model, from := session.ActiveModel(); model != "qwen/qwen3-coder" || formatFallbackNote(model, from) != "" {
+		t.Errorf("primary answer must clear the fallback mark: %q from %q", model, from)
+	}
+}
+
+func TestSessionSnapshot_KeepsTokenUsage(t *testing.T) {
+	session := &VibeCodingSession{Files: map[string]string{}, GeneratedFiles: map[string]string{}}
+	session.AddTokenUsage(42, 8)
+	session.EstimatedCostUSD = 0.5
+
+	restored := session.snapshot().session()
+	prompt, completion, cost := restored.TokenUsage()
+	if prompt != 42 || completion != 8 || cost != 0.5 {
+		t.Errorf("restored usage = %d/%d/%v, want 42/8/0.5", prompt, completion, cost)
+	}
+}
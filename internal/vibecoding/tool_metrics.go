@@ -0,0 +1,405 @@
+package vibecoding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// latencyRingSize размер кольцевого буфера задержек на один инструмент
+	latencyRingSize = 256
+	// defaultAlertThreshold количество ошибок одного инструмента в окне для алерта
+	defaultAlertThreshold = 3
+	// defaultAlertWindow окно подсчёта ошибок для алерта
+	defaultAlertWindow = 10 * time.Minute
+	// toolFailureAlertMetaKey ключ Meta результата инструмента, в котором MCP сервер передаёт сработавший алерт
+	toolFailureAlertMetaKey = "tool_failure_alert"
+)
+
+// Категории ошибок MCP инструментов
+const (
+	ToolErrorSession   = "session"
+	ToolErrorDocker    = "docker"
+	ToolErrorTimeout   = "timeout"
+	ToolErrorArguments = "arguments"
+	ToolErrorTransport = "transport"
+	ToolErrorOther     = "other"
+)
+
+var toolErrorCategories = [...]string{ToolErrorSession, ToolErrorDocker, ToolErrorTimeout, ToolErrorArguments, ToolErrorTransport, ToolErrorOther}
+
+// DefaultToolMetrics метрики vibe_* инструментов текущего процесса
+var DefaultToolMetrics = NewToolMetrics()
+
+// ToolFailureAlert сигнал о повторяющихся ошибках инструмента
+type ToolFailureAlert struct {
+	Tool      string        `json:"tool"`
+	Failures  int           `json:"failures"`
+	Window    time.Duration `json:"window"`
+	LastError string        `json:"last_error"`
+	Category  string        `json:"category"`
+}
+
+// ToolMetricsSnapshot срез метрик одного инструмента
+type ToolMetricsSnapshot struct {
+	Tool             string           `json:"tool"`
+	Calls            int64            `json:"calls"`
+	Errors           int64            `json:"errors"`
+	ErrorsByCategory map[string]int64 `json:"errors_by_category,omitempty"`
+	P50Ms            int64            `json:"p50_ms"`
+	P95Ms            int64            `json:"p95_ms"`
+	LastError        string           `json:"last_error,omitempty"`
+	LastErrorAt      time.Time        `json:"last_error_at,omitempty"`
+}
+
+// toolStats счётчики одного инструмента
+type toolStats struct {
+	calls      atomic.Int64
+	errors     atomic.Int64
+	byCategory [len(toolErrorCategories)]atomic.Int64
+	latencies  [latencyRingSize]atomic.Int64
+	latencyPos atomic.Uint64
+	lastError  atomic.Value // string
+	lastErrAt  atomic.Int64 // unix nano
+
+	alertMu     sync.Mutex
+	recentFails []time.Time
+	lastAlertAt time.Time
+}
+
+// ToolMetrics метрики вызовов MCP инструментов (сбрасываются при перезапуске процесса)
+type ToolMetrics struct {
+	mu    sync.RWMutex
+	tools map[string]*toolStats
+
+	alertMu        sync.RWMutex
+	alertFunc      func(ToolFailureAlert)
+	alertThreshold int
+	alertWindow    time.Duration
+}
+
+// NewToolMetrics создаёт пустой реестр метрик
+func NewToolMetrics() *ToolMetrics {
+	return &ToolMetrics{
+		tools:          make(map[string]*toolStats),
+		alertThreshold: defaultAlertThreshold,
+		alertWindow:    defaultAlertWindow,
+	}
+}
+
+// SetFailureAlert задаёт обработчик алертов о повторяющихся ошибках. Инструменты stdio MCP сервера
+// работают в другом процессе: их алерты приходят в Meta результата и передаются сюда через ReportAlert
+func (m *ToolMetrics) SetFailureAlert(fn func(ToolFailureAlert)) {
+	m.alertMu.Lock()
+	defer m.alertMu.Unlock()
+	m.alertFunc = fn
+}
+
+// SetAlertPolicy задаёт порог и окно для алертов
+func (m *ToolMetrics) SetAlertPolicy(threshold int, window time.Duration) {
+	m.alertMu.Lock()
+	defer m.alertMu.Unlock()
+	if threshold > 0 {
+		m.alertThreshold = threshold
+	}
+	if window > 0 {
+		m.alertWindow = window
+	}
+}
+
+func (m *ToolMetrics) stats(tool string) *toolStats {
+	m.mu.RLock()
+	st := m.tools[tool]
+	m.mu.RUnlock()
+	if st != nil {
+		return st
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if st = m.tools[tool]; st == nil {
+		st = &toolStats{}
+		m.tools[tool] = st
+	}
+	return st
+}
+
+// Record фиксирует вызов инструмента; пустой errMsg означает успех
+func (m *ToolMetrics) Record(tool string, duration time.Duration, errMsg string) {
+	if alert := m.record(tool, duration, errMsg); alert != nil {
+		m.dispatchAlert(*alert)
+	}
+}
+
+// record фиксирует вызов и возвращает алерт, если ошибки инструмента достигли порога
+func (m *ToolMetrics) record(tool string, duration time.Duration, errMsg string) *ToolFailureAlert {
+	st := m.stats(tool)
+	st.calls.Add(1)
+	pos := st.latencyPos.Add(1) - 1
+	st.latencies[pos%latencyRingSize].Store(duration.Milliseconds())
+	if errMsg == "" {
+		return nil
+	}
+
+	category := ClassifyToolError(errMsg)
+	st.errors.Add(1)
+	for i, c := range toolErrorCategories {
+		if c == category {
+			st.byCategory[i].Add(1)
+		}
+	}
+	st.lastError.Store(errMsg)
+	now := time.Now()
+	st.lastErrAt.Store(now.UnixNano())
+
+	return m.checkAlert(tool, st, now, errMsg, category)
+}
+
+func (m *ToolMetrics) checkAlert(tool string, st *toolStats, now time.Time, errMsg, category string) *ToolFailureAlert {
+	m.alertMu.RLock()
+	threshold, window := m.alertThreshold, m.alertWindow
+	m.alertMu.RUnlock()
+
+	st.alertMu.Lock()
+	cutoff := now.Add(-window)
+	kept := st.recentFails[:0]
+	for _, t := range st.recentFails {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	st.recentFails = append(kept, now)
+	failures := len(st.recentFails)
+	// Не чаще одного алерта на окно для каждого инструмента
+	fire := failures >= threshold && now.Sub(st.lastAlertAt) >= window
+	if fire {
+		st.lastAlertAt = now
+	}
+	st.alertMu.Unlock()
+
+	if !fire {
+		return nil
+	}
+	return &ToolFailureAlert{Tool: tool, Failures: failures, Window: window, LastError: errMsg, Category: category}
+}
+
+// ReportAlert передаёт обработчику алерт, полученный от MCP сервера в другом процессе.
+// Алерты одного инструмента по-прежнему не чаще одного на окно
+func (m *ToolMetrics) ReportAlert(alert ToolFailureAlert) {
+	m.alertMu.RLock()
+	window := m.alertWindow
+	m.alertMu.RUnlock()
+
+	st := m.stats(alert.Tool)
+	now := time.Now()
+	st.alertMu.Lock()
+	due := now.Sub(st.lastAlertAt) >= window
+	if due {
+		st.lastAlertAt = now
+	}
+	st.alertMu.Unlock()
+	if due {
+		m.dispatchAlert(alert)
+	}
+}
+
+func (m *ToolMetrics) dispatchAlert(alert ToolFailureAlert) {
+	m.alertMu.RLock()
+	fn := m.alertFunc
+	m.alertMu.RUnlock()
+	if fn != nil {
+		go fn(alert)
+	}
+}
+
+// toolFailureAlertFromMeta достаёт алерт из Meta результата инструмента (после JSON транспорта)
+func toolFailureAlertFromMeta(meta map[string]any) (ToolFailureAlert, bool) {
+	raw, ok := meta[toolFailureAlertMetaKey]
+	if !ok {
+		return ToolFailureAlert{}, false
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return ToolFailureAlert{}, false
+	}
+	var alert ToolFailureAlert
+	if err := json.Unmarshal(data, &alert); err != nil || alert.Tool == "" {
+		return ToolFailureAlert{}, false
+	}
+	return alert, true
+}
+
+// Snapshot возвращает метрики всех инструментов, отсортированные по имени
+func (m *ToolMetrics) Snapshot() []ToolMetricsSnapshot {
+	m.mu.RLock()
+	names := make([]string, 0, len(m.tools))
+	for name := range m.tools {
+		names = append(names, name)
+	}
+	m.mu.RUnlock()
+	sort.Strings(names)
+
+	result := make([]ToolMetricsSnapshot, 0, len(names))
+	for _, name := range names {
+		st := m.stats(name)
+		snap := ToolMetricsSnapshot{
+			Tool:   name,
+			Calls:  st.calls.Load(),
+			Errors: st.errors.Load(),
+		}
+		for i, c := range toolErrorCategories {
+			if n := st.byCategory[i].Load(); n > 0 {
+				if snap.ErrorsByCategory == nil {
+					snap.ErrorsByCategory = make(map[string]int64)
+				}
+				snap.ErrorsByCategory[c] = n
+			}
+		}
+		if v, ok := st.lastError.Load().(string); ok {
+			snap.LastError = v
+		}
+		if ts := st.lastErrAt.Load(); ts > 0 {
+			snap.LastErrorAt = time.Unix(0, ts).UTC()
+		}
+
+		count := st.latencyPos.Load()
+		if count > latencyRingSize {
+			count = latencyRingSize
+		}
+		samples := make([]int64, 0, count)
+		for i := uint64(0); i < count; i++ {
+			samples = append(samples, st.latencies[i].Load())
+		}
+		snap.P50Ms = percentile(samples, 50)
+		snap.P95Ms = percentile(samples, 95)
+		result = append(result, snap)
+	}
+	return result
+}
+
+// Format формирует текстовую сводку метрик (для MCP ответа и ежедневного отчёта)
+func (m *ToolMetrics) Format() string {
+	snaps := m.Snapshot()
+	if len(snaps) == 0 {
+		return "VibeCoding MCP: вызовов инструментов не было"
+	}
+	var sb strings.Builder
+	sb.WriteString("VibeCoding MCP инструменты:\n")
+	for _, s := range snaps {
+		sb.WriteString(fmt.Sprintf("- %s: вызовов %d, ошибок %d, p50 %dms, p95 %dms", s.Tool, s.Calls, s.Errors, s.P50Ms, s.P95Ms))
+		if len(s.ErrorsByCategory) > 0 {
+			cats := make([]string, 0, len(s.ErrorsByCategory))
+			for _, c := range toolErrorCategories {
+				if n, ok := s.ErrorsByCategory[c]; ok {
+					cats = append(cats, fmt.Sprintf("%s=%d", c, n))
+				}
+			}
+			sb.WriteString(" (" + strings.Join(cats, ", ") + ")")
+		}
+		if s.LastError != "" {
+			sb.WriteString(fmt.Sprintf("; последняя ошибка: %s", truncateText(s.LastError, 120)))
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// ClassifyToolError определяет категорию ошибки по тексту
+func ClassifyToolError(errMsg string) string {
+	lower := strings.ToLower(errMsg)
+	switch {
+	case strings.Contains(lower, "timeout") || strings.Contains(lower, "deadline exceeded"):
+		return ToolErrorTimeout
+	case strings.Contains(lower, "no vibecoding session") || strings.Contains(lower, "session not"):
+		return ToolErrorSession
+	case strings.Contains(lower, "docker") || strings.Contains(lower, "container"):
+		return ToolErrorDocker
+	case strings.Contains(lower, "parameter is required") || strings.Contains(lower, "invalid") || strings.Contains(lower, "must be"):
+		return ToolErrorArguments
+	case strings.Contains(lower, "mcp error") || strings.Contains(lower, "connection") || strings.Contains(lower, "broken pipe") || strings.Contains(lower, "eof"):
+		return ToolErrorTransport
+	default:
+		return ToolErrorOther
+	}
+}
+
+// InstrumentTool оборачивает обработчик MCP инструмента сбором метрик. Сработавший алерт также кладётся
+// в Meta результата: обработчик алертов задан в процессе бота, а не в stdio MCP сервере
+func InstrumentTool[In any](m *ToolMetrics, name string, h mcp.ToolHandlerFor[In, any]) mcp.ToolHandlerFor[In, any] {
+	return func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[In]) (*mcp.CallToolResultFor[any], error) {
+		start := time.Now()
+		result, err := h(ctx, session, params)
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		} else if result != nil && result.IsError {
+			errMsg = resultText(result.Content)
+			if errMsg == "" {
+				errMsg = "tool returned error"
+			}
+		}
+		if alert := m.record(name, time.Since(start), errMsg); alert != nil {
+			m.dispatchAlert(*alert)
+			if result != nil {
+				if result.Meta == nil {
+					result.Meta = make(mcp.Meta)
+				}
+				result.Meta[toolFailureAlertMetaKey] = alert
+			}
+		}
+		return result, err
+	}
+}
+
+// MetricsToolHandler обработчик инструмента vibe_get_metrics
+func MetricsToolHandler(m *ToolMetrics) mcp.ToolHandlerFor[map[string]interface{}, any] {
+	return func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]interface{}]) (*mcp.CallToolResultFor[any], error) {
+		return &mcp.CallToolResultFor[any]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "📈 " + m.Format()},
+			},
+			Meta: map[string]interface{}{
+				"tools":   m.Snapshot(),
+				"success": true,
+			},
+		}, nil
+	}
+}
+
+func resultText(contents []mcp.Content) string {
+	var text string
+	for _, content := range contents {
+		if textContent, ok := content.(*mcp.TextContent); ok {
+			text += textContent.Text
+		}
+	}
+	return text
+}
+
+func percentile(samples []int64, p int) int64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]int64(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := (len(sorted)*p + 99) / 100
+	if idx > 0 {
+		idx--
+	}
+	return sorted[idx]
+}
+
+func truncateText(s string, limit int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len([]rune(s)) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit]) + "..."
+}
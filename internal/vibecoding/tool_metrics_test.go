@@ -0,0 +1,127 @@
+package vibecoding
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestToolMetrics_RecordAndSnapshot(t *testing.T) {
+	m := NewToolMetrics()
+	for i := 1; i <= 100; i++ {
+		m.Record("vibe_list_files", time.Duration(i)*time.Millisecond, "")
+	}
+	m.Record("vibe_read_file", 5*time.Millisecond, "❌ No VibeCoding session found for user")
+	m.Record("vibe_read_file", 5*time.Millisecond, "docker exec failed")
+
+	snaps := m.Snapshot()
+	if len(snaps) != 2 {
+		t.Fatalf("expected 2 tools, got %d", len(snaps))
+	}
+	list := snaps[0]
+	if list.Tool != "vibe_list_files" || list.Calls != 100 || list.Errors != 0 {
+		t.Fatalf("unexpected list stats: %+v", list)
+	}
+	if list.P50Ms != 50 || list.P95Ms != 95 {
+		t.Fatalf("unexpected percentiles: p50=%d p95=%d", list.P50Ms, list.P95Ms)
+	}
+	read := snaps[1]
+	if read.Errors != 2 || read.ErrorsByCategory[ToolErrorSession] != 1 || read.ErrorsByCategory[ToolErrorDocker] != 1 {
+		t.Fatalf("unexpected error stats: %+v", read)
+	}
+	if read.LastError != "docker exec failed" {
+		t.Fatalf("unexpected last error: %q", read.LastError)
+	}
+	if !strings.Contains(m.Format(), "vibe_read_file") {
+		t.Fatalf("format must include tool name")
+	}
+}
+
+func TestToolMetrics_FailureAlert(t *testing.T) {
+	m := NewToolMetrics()
+	m.SetAlertPolicy(2, time.Minute)
+	alerts := make(chan ToolFailureAlert, 4)
+	m.SetFailureAlert(func(a ToolFailureAlert) { alerts <- a })
+
+	m.Record("vibe_run_tests", time.Millisecond, "timeout")
+	m.Record("vibe_run_tests", time.Millisecond, "timeout")
+	m.Record("vibe_run_tests", time.Millisecond, "timeout")
+
+	select {
+	case a := <-alerts:
+		if a.Tool != "vibe_run_tests" || a.Failures != 2 || a.Category != ToolErrorTimeout {
+			t.Fatalf("unexpected alert: %+v", a)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("alert was not fired")
+	}
+	select {
+	case a := <-alerts:
+		t.Fatalf("only one alert per window expected, got %+v", a)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestInstrumentTool_CountsErrorResults(t *testing.T) {
+	m := NewToolMetrics()
+	h := InstrumentTool(m, "vibe_write_file", func(ctx context.Context, s *mcp.ServerSession, p *mcp.CallToolParamsFor[map[string]interface{}]) (*mcp.CallToolResultFor[any], error) {
+		return &mcp.CallToolResultFor[any]{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "❌ filename parameter is required"}}}, nil
+	})
+	if _, err := h(context.Background(), nil, &mcp.CallToolParamsFor[map[string]interface{}]{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	snaps := m.Snapshot()
+	if len(snaps) != 1 || snaps[0].Errors != 1 || snaps[0].ErrorsByCategory[ToolErrorArguments] != 1 {
+		t.Fatalf("unexpected snapshot: %+v", snaps)
+	}
+}
+
+func TestInstrumentTool_ForwardsAlertThroughMeta(t *testing.T) {
+	// MCP сервер в отдельном процессе: обработчика алертов нет
+	server := NewToolMetrics()
+	server.SetAlertPolicy(2, time.Minute)
+	h := InstrumentTool(server, "vibe_run_tests", func(ctx context.Context, s *mcp.ServerSession, p *mcp.CallToolParamsFor[map[string]interface{}]) (*mcp.CallToolResultFor[any], error) {
+		return &mcp.CallToolResultFor[any]{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "docker exec failed"}}}, nil
+	})
+	var result *mcp.CallToolResultFor[any]
+	for i := 0; i < 2; i++ {
+		result, _ = h(context.Background(), nil, &mcp.CallToolParamsFor[map[string]interface{}]{})
+	}
+
+	// Meta проходит через JSON транспорт до клиента в процессе бота
+	data, err := json.Marshal(result.Meta)
+	if err != nil {
+		t.Fatalf("marshal meta: %v", err)
+	}
+	var meta map[string]any
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("unmarshal meta: %v", err)
+	}
+	alert, ok := toolFailureAlertFromMeta(meta)
+	if !ok || alert.Tool != "vibe_run_tests" || alert.Failures != 2 || alert.Category != ToolErrorDocker || alert.Window != time.Minute {
+		t.Fatalf("alert must be passed in result meta, got %+v, %v", alert, ok)
+	}
+
+	bot := NewToolMetrics()
+	alerts := make(chan ToolFailureAlert, 4)
+	bot.SetFailureAlert(func(a ToolFailureAlert) { alerts <- a })
+	bot.ReportAlert(alert)
+	bot.ReportAlert(alert)
+	select {
+	case a := <-alerts:
+		if a.Tool != "vibe_run_tests" {
+			t.Fatalf("unexpected alert: %+v", a)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("forwarded alert was not fired")
+	}
+	select {
+	case a := <-alerts:
+		t.Fatalf("only one alert per window expected, got %+v", a)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
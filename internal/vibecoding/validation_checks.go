@@ -0,0 +1,216 @@
+package vibecoding
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// validationConfigFiles файлы конфигурации дополнительных проверок проекта
+var validationConfigFiles = []string{".vibecoding.yml", ".vibecoding.yaml"}
+
+// ValidationCheck дополнительная проверка проекта (type-checking, линтер, скрипт и т.п.)
+type ValidationCheck struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+}
+
+// ValidationCheckResult результат одной проверки
+type ValidationCheckResult struct {
+	Name     string `json:"name"`
+	Command  string `json:"command"`
+	Success  bool   `json:"success"`
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output"`
+	Error    string `json:"error,omitempty"`
+}
+
+// loadValidationChecks ищет .vibecoding.yml среди файлов проекта и читает из него проверки
+func loadValidationChecks(files map[string]string) []ValidationCheck {
+	for _, name := range validationConfigFiles {
+		if content, ok := files[name]; ok {
+			checks := ParseValidationConfig(content)
+			log.Printf("🔧 Loaded %d custom validation checks from %s", len(checks), name)
+			return checks
+		}
+	}
+	return nil
+}
+
+// ParseValidationConfig разбирает секцию validations из .vibecoding.yml.
+// Поддерживаются список элементов с полями name/command и словарь name: command.
+// Прочие поля элемента списка (timeout и т.п.) игнорируются:
+//
+//	validations:
+//	  - name: typecheck
+//	    command: mypy .
+//	  lint: ruff check .
+func ParseValidationConfig(content string) []ValidationCheck {
+	var checks []ValidationCheck
+	inSection := false
+	var current *ValidationCheck
+	// itemIndent отступ "-" текущего элемента списка: более глубокие строки — его поля
+	itemIndent := 0
+
+	flush := func() {
+		if current != nil && strings.TrimSpace(current.Command) != "" {
+			if current.Name == "" {
+				current.Name = current.Command
+			}
+			checks = append(checks, *current)
+		}
+		current = nil
+	}
+
+	for _, rawLine := range strings.Split(content, "\n") {
+		line := strings.TrimRight(rawLine, " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// Секции верхнего уровня
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			flush()
+			inSection = strings.HasPrefix(trimmed, "validations:")
+			continue
+		}
+		if !inSection {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			flush()
+			current = &ValidationCheck{}
+			itemIndent = indent
+			trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+			if trimmed == "" {
+				continue
+			}
+			if key, value, ok := splitYAMLPair(trimmed); ok {
+				setValidationField(current, key, value)
+			} else {
+				// "- mypy ." — короткая форма: только команда
+				current.Command = unquoteYAML(trimmed)
+			}
+			continue
+		}
+
+		key, value, ok := splitYAMLPair(trimmed)
+		if !ok {
+			continue
+		}
+		if current != nil && indent > itemIndent {
+			setValidationField(current, key, value)
+			continue
+		}
+		// Форма словаря name: command
+		flush()
+		if value != "" {
+			checks = append(checks, ValidationCheck{Name: key, Command: value})
+		}
+	}
+	flush()
+	return checks
+}
+
+func splitYAMLPair(s string) (string, string, bool) {
+	idx := strings.Index(s, ":")
+	if idx <= 0 {
+		return "", "", false
+	}
+	key := strings.TrimSpace(s[:idx])
+	if strings.ContainsAny(key, " \t") {
+		return "", "", false
+	}
+	return key, unquoteYAML(strings.TrimSpace(s[idx+1:])), true
+}
+
+func unquoteYAML(s string) string {
+	if len(s) >= 2 && ((s[0] == '"' && s[len(s)-1] == '"') || (s[0] == '\'' && s[len(s)-1] == '\'')) {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func setValidationField(check *ValidationCheck, key, value string) {
+	switch key {
+	case "name":
+		check.Name = value
+	case "command":
+		check.Command = value
+	}
+}
+
+// GetValidationChecks возвращает копию настроенных проверок
+func (s *VibeCodingSession) GetValidationChecks() []ValidationCheck {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return append([]ValidationCheck(nil), s.ValidationChecks...)
+}
+
+// AddValidationCheck добавляет или заменяет проверку с тем же именем
+func (s *VibeCodingSession) AddValidationCheck(check ValidationCheck) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, existing := range s.ValidationChecks {
+		if existing.Name == check.Name {
+			s.ValidationChecks[i] = check
+			return
+		}
+	}
+	s.ValidationChecks = append(s.ValidationChecks, check)
+}
+
+// RunValidationChecks выполняет дополнительные проверки по очереди, при includeTests первой идёт команда тестов
+func (s *VibeCodingSession) RunValidationChecks(ctx context.Context, includeTests bool) []ValidationCheckResult {
+	checks := s.GetValidationChecks()
+	if includeTests && s.TestCommand != "" {
+		checks = append([]ValidationCheck{{Name: "tests", Command: s.TestCommand}}, checks...)
+	}
+
+	results := make([]ValidationCheckResult, 0, len(checks))
+	for _, check := range checks {
+		log.Printf("🔍 Running validation check '%s': %s", check.Name, check.Command)
+		res := ValidationCheckResult{Name: check.Name, Command: check.Command}
+		out, err := s.ExecuteCommand(ctx, check.Command)
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Success = out.Success
+			res.ExitCode = out.ExitCode
+			res.Output = out.Output
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// FormatValidationCheckResults формирует сводку по проверкам для Telegram
+func FormatValidationCheckResults(results []ValidationCheckResult) string {
+	if len(results) == 0 {
+		return "[vibecoding] ℹ️ Дополнительные проверки не настроены. Добавьте секцию validations в .vibecoding.yml или используйте /vibecoding_validate_add <name>: <command>"
+	}
+
+	passed := 0
+	var sb strings.Builder
+	for _, r := range results {
+		status := "❌"
+		if r.Success {
+			status = "✅"
+			passed++
+		}
+		sb.WriteString(fmt.Sprintf("%s %s (`%s`)", status, r.Name, r.Command))
+		switch {
+		case r.Error != "":
+			sb.WriteString(fmt.Sprintf("\n   Ошибка: %s", r.Error))
+		case !r.Success:
+			sb.WriteString(fmt.Sprintf("\n   Код выхода: %d\n   %s", r.ExitCode, truncateText(r.Output, 300)))
+		}
+		sb.WriteString("\n")
+	}
+
+	return fmt.Sprintf("[vibecoding] 🔍 Проверки: %d/%d пройдено\n\n%s", passed, len(results), strings.TrimRight(sb.String(), "\n"))
+}
@@ -0,0 +1,89 @@
+package vibecoding
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseValidationConfig(t *testing.T) {
+	content := `# project checks
+language: python
+validations:
+  - name: typecheck
+    command: mypy .
+  - command: "python scripts/check_schema.py"
+  lint: ruff check .
+other:
+  key: value
+`
+	checks := ParseValidationConfig(content)
+	if len(checks) != 3 {
+		t.Fatalf("expected 3 checks, got %d: %+v", len(checks), checks)
+	}
+	if checks[0].Name != "typecheck" || checks[0].Command != "mypy ." {
+		t.Errorf("unexpected first check: %+v", checks[0])
+	}
+	if checks[1].Name != "python scripts/check_schema.py" || checks[1].Command != "python scripts/check_schema.py" {
+		t.Errorf("unexpected second check: %+v", checks[1])
+	}
+	if checks[2].Name != "lint" || checks[2].Command != "ruff check ." {
+		t.Errorf("unexpected third check: %+v", checks[2])
+	}
+}
+
+func TestParseValidationConfig_IgnoresUnknownItemKeys(t *testing.T) {
+	content := `validations:
+  - name: typecheck
+    timeout: 30s
+    command: mypy .
+  - timeout: 10s
+    command: pytest
+  lint: ruff check .
+`
+	checks := ParseValidationConfig(content)
+	if len(checks) != 3 {
+		t.Fatalf("expected 3 checks, got %d: %+v", len(checks), checks)
+	}
+	if checks[0].Name != "typecheck" || checks[0].Command != "mypy ." {
+		t.Errorf("unknown key must not split the item: %+v", checks[0])
+	}
+	if checks[1].Name != "pytest" || checks[1].Command != "pytest" {
+		t.Errorf("unknown first key must not become a command: %+v", checks[1])
+	}
+	if checks[2].Name != "lint" || checks[2].Command != "ruff check ." {
+		t.Errorf("unexpected third check: %+v", checks[2])
+	}
+}
+
+func TestLoadValidationChecks_FromProjectFiles(t *testing.T) {
+	sm := NewSessionManagerWithoutWebServer()
+	files := map[string]string{
+		"main.py":         "print('hi')",
+		".vibecoding.yml": "validations:\n  typecheck: mypy .\n",
+	}
+	session, err := sm.CreateSession(1, 1, "p", files, nil)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	checks := session.GetValidationChecks()
+	if len(checks) != 1 || checks[0].Name != "typecheck" {
+		t.Fatalf("unexpected checks: %+v", checks)
+	}
+
+	session.AddValidationCheck(ValidationCheck{Name: "typecheck", Command: "pyright"})
+	session.AddValidationCheck(ValidationCheck{Name: "schema", Command: "python check.py"})
+	checks = session.GetValidationChecks()
+	if len(checks) != 2 || checks[0].Command != "pyright" {
+		t.Fatalf("add must replace by name and append new: %+v", checks)
+	}
+}
+
+func TestFormatValidationCheckResults(t *testing.T) {
+	out := FormatValidationCheckResults([]ValidationCheckResult{
+		{Name: "tests", Command: "pytest", Success: true},
+		{Name: "typecheck", Command: "mypy .", Success: false, ExitCode: 1, Output: "error: bad type"},
+	})
+	if !strings.Contains(out, "1/2") || !strings.Contains(out, "✅ tests") || !strings.Contains(out, "❌ typecheck") {
+		t.Fatalf("unexpected report: %s", out)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"html/template"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -20,6 +21,7 @@ import (
 type WebServer struct {
 	sessionManager *SessionManager
 	server         *http.Server
+	listener       net.Listener
 	port           int
 	startTime      time.Time
 }
@@ -58,30 +60,59 @@ func NewWebServer(sessionManager *SessionManager, port int) *WebServer {
 	}
 }
 
-// Start запускает веб-сервер
+// Start занимает порт и обслуживает запросы до остановки сервера
 func (ws *WebServer) Start() error {
+	if err := ws.Listen(); err != nil {
+		return err
+	}
+	return ws.Serve()
+}
+
+// Listen занимает порт веб-сервера и готовит обработчики; занятый порт возвращается
+// понятной ошибкой сразу, а не в фоне
+func (ws *WebServer) Listen() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", ws.port))
+	if err != nil {
+		return fmt.Errorf("vibecoding web server: port %d is unavailable (set VIBECODING_WEB_PORT to a free port or 0 to disable): %w", ws.port, err)
+	}
+	ws.listener = listener
+	ws.port = listener.Addr().(*net.TCPAddr).Port
+
 	mux := http.NewServeMux()
 
 	// Регистрируем обработчики
-	mux.HandleFunc("/static/", ws.handleStatic)        // Статические файлы
-	mux.HandleFunc("/api/status", ws.handleStatus)     // Health check endpoint
-	mux.HandleFunc("/api/sessions", ws.handleSessions) // Список всех сессий (админ)
-	mux.HandleFunc("/api/context/", ws.handleContext)  // API для получения контекста сессии
-	mux.HandleFunc("/api/save/", ws.handleSaveFile)    // API для сохранения файлов
-	mux.HandleFunc("/vibe_", ws.handleVibeSession)     // HTML страницы vibe сессий
-	mux.HandleFunc("/admin", ws.handleAdmin)           // Админская страница
-	mux.HandleFunc("/", ws.handleRoot)                 // Корневой обработчик (должен быть последним)
+	mux.HandleFunc("/static/", ws.handleStatic)                    // Статические файлы
+	mux.HandleFunc("/api/status", ws.handleStatus)                 // Health check endpoint
+	mux.HandleFunc("/api/sessions", ws.handleSessions)             // Список всех сессий (админ)
+	mux.HandleFunc("/api/metrics", ws.handleMetrics)               // Метрики MCP инструментов (админ)
+	mux.HandleFunc("/metrics", ws.sessionManager.handlePrometheus) // Метрики для Prometheus
+	mux.HandleFunc("/api/context/", ws.handleContext)              // API для получения контекста сессии
+	mux.HandleFunc("/api/save/", ws.handleSaveFile)                // API для сохранения файлов
+	mux.HandleFunc("/vibe_", ws.handleVibeSession)                 // HTML страницы vibe сессий
+	mux.HandleFunc("/admin", ws.handleAdmin)                       // Админская страница
+	mux.HandleFunc("/", ws.handleRoot)                             // Корневой обработчик (должен быть последним)
 
 	ws.server = &http.Server{
-		Addr:         fmt.Sprintf(":%d", ws.port),
 		Handler:      mux,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	return nil
+}
+
+// Port порт веб-сервера (после Listen — фактически занятый)
+func (ws *WebServer) Port() int {
+	return ws.port
+}
 
+// Serve обслуживает запросы на порту, занятом Listen
+func (ws *WebServer) Serve() error {
+	if ws.listener == nil {
+		return fmt.Errorf("vibecoding web server: Listen must be called before Serve")
+	}
 	log.Printf("🌐 Starting VibeCoding web server on http://localhost:%d (accessible locally)", ws.port)
-	return ws.server.ListenAndServe()
+	return ws.server.Serve(ws.listener)
 }
 
 // Stop останавливает веб-сервер
@@ -93,7 +124,12 @@ func (ws *WebServer) Stop() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	return ws.server.Shutdown(ctx)
+	err := ws.server.Shutdown(ctx)
+	// Если Serve ещё не успел стартовать, Shutdown не знает о слушателе
+	if ws.listener != nil {
+		ws.listener.Close()
+	}
+	return err
 }
 
 // handleContext обрабатывает API запросы на получение контекста сессии
@@ -313,6 +349,12 @@ func (ws *WebServer) handleFileContent(w http.ResponseWriter, r *http.Request, s
 		decodedPath = filePath // Fallback to original if decoding fails
 	}
 
+	// Файлы с найденными секретами не показываем, пока пользователь их не разрешил
+	if _, original := session.Files[decodedPath]; original && session.secretHiddenLocked(session.secretArchivePathLocked(decodedPath)) {
+		http.Error(w, "File hidden: possible secrets detected, allow it with /vibecoding_allow_secret", http.StatusForbidden)
+		return
+	}
+
 	// Ищем файл в основных файлах
 	if content, exists := session.Files[decodedPath]; exists {
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
@@ -341,6 +383,10 @@ func (ws *WebServer) handleFileContent(w http.ResponseWriter, r *http.Request, s
 	// Это для случаев, когда путь уже содержит [generated] префикс
 	for path, content := range session.Files {
 		if path == decodedPath || "[generated] "+path == decodedPath {
+			if session.secretHiddenLocked(session.secretArchivePathLocked(path)) {
+				http.Error(w, "File hidden: possible secrets detected, allow it with /vibecoding_allow_secret", http.StatusForbidden)
+				return
+			}
 			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 			w.Write([]byte(content))
 			return
@@ -392,8 +438,14 @@ func (ws *WebServer) prepareSessionData(session *VibeCodingSession) *SessionData
 		data.Language = session.Analysis.Language
 	}
 
-	// Строим дерево файлов
-	data.FilesTree = ws.buildFileTree(session.Files, session.GeneratedFiles)
+	// Строим дерево файлов без скрытых файлов с секретами
+	visible := make(map[string]string, len(session.Files))
+	for filename, content := range session.Files {
+		if !session.secretHiddenLocked(session.secretArchivePathLocked(filename)) {
+			visible[filename] = content
+		}
+	}
+	data.FilesTree = ws.buildFileTree(visible, session.GeneratedFiles)
 
 	// Подсчитываем статистику
 	data.Stats.TotalFiles = len(session.Files)
@@ -1067,6 +1119,25 @@ func (ws *WebServer) handleSessions(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleMetrics возвращает метрики вызовов vibe_* инструментов
+func (ws *WebServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{
+		"success":   true,
+		"tools":     DefaultToolMetrics.Snapshot(),
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
 // handleAdmin обрабатывает админскую страницу для просмотра всех сессий
 func (ws *WebServer) handleAdmin(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -1113,6 +1184,11 @@ func (ws *WebServer) handleAdmin(w http.ResponseWriter, r *http.Request) {
         <div id="sessions-container">
             <p>Loading sessions...</p>
         </div>
+
+        <div class="stats">
+            <h3>📈 MCP Tool Metrics</h3>
+            <div id="metrics-container">Loading metrics...</div>
+        </div>
     </div>
 
     <script>
@@ -1205,11 +1281,36 @@ func (ws *WebServer) handleAdmin(w http.ResponseWriter, r *http.Request) {
             }
         }
 
+        async function loadMetrics() {
+            try {
+                const response = await fetch('/api/metrics');
+                const data = await response.json();
+                const container = document.getElementById('metrics-container');
+
+                if (!data.tools || data.tools.length === 0) {
+                    container.innerHTML = '<div class="no-sessions">No MCP tool calls yet</div>';
+                    return;
+                }
+
+                let html = '<table style="width:100%; border-collapse: collapse;"><tr><th align="left">Tool</th><th>Calls</th><th>Errors</th><th>p50 ms</th><th>p95 ms</th><th align="left">Last error</th></tr>';
+                data.tools.forEach(t => {
+                    html += ` + "`<tr><td>${t.tool}</td><td align=\"center\">${t.calls}</td><td align=\"center\">${t.errors}</td><td align=\"center\">${t.p50_ms}</td><td align=\"center\">${t.p95_ms}</td><td>${t.last_error || ''}</td></tr>`" + `;
+                });
+                html += '</table>';
+                container.innerHTML = html;
+            } catch (error) {
+                document.getElementById('metrics-container').innerHTML =
+                    ` + "`<div style='color: red;'>Error loading metrics: ${error.message}</div>`" + `;
+            }
+        }
+
         // Load sessions on page load
         loadSessions();
+        loadMetrics();
         
         // Auto-refresh every 30 seconds
         setInterval(loadSessions, 30000);
+        setInterval(loadMetrics, 30000);
     </script>
 </body>
 </html>`
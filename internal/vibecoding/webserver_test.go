@@ -2,8 +2,11 @@ package vibecoding
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -147,3 +150,51 @@ func TestHandleSessionsWithValidAnalysis(t *testing.T) {
 		t.Errorf("Expected language to be 'Python', got '%s'", language)
 	}
 }
+
+func TestNewSessionManager_PortInUse(t *testing.T) {
+	busy, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	port := busy.Addr().(*net.TCPAddr).Port
+
+	if _, err := NewSessionManager(port); err == nil || !strings.Contains(err.Error(), fmt.Sprintf("port %d", port)) {
+		t.Fatalf("expected port-in-use error naming port %d, got %v", port, err)
+	}
+
+	sm, err := NewSessionManager(0)
+	if err != nil {
+		t.Fatalf("port 0 must disable the web server: %v", err)
+	}
+	defer sm.Close()
+	if sm.WebPort() != 0 {
+		t.Fatalf("web server must not run, got port %d", sm.WebPort())
+	}
+}
+
+func TestHandler_WebInterfaceLineUsesActualPort(t *testing.T) {
+	free, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := free.Addr().(*net.TCPAddr).Port
+	free.Close()
+
+	h := &VibeCodingHandler{sessionManager: NewSessionManagerWithoutWebServer()}
+	defer h.Close()
+	if line := h.webInterfaceLine(1); line != "" {
+		t.Fatalf("without web server no link expected, got %q", line)
+	}
+	if err := h.StartWebServer(port); err != nil {
+		t.Fatalf("StartWebServer: %v", err)
+	}
+	if line := h.webInterfaceLine(42); !strings.Contains(line, fmt.Sprintf("http://localhost:%d/vibe_42", port)) {
+		t.Fatalf("link must use the actual port: %q", line)
+	}
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/api/status", port))
+	if err != nil {
+		t.Fatalf("web server is not serving: %v", err)
+	}
+	resp.Body.Close()
+}